@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiClient calls the API of a running instance and prints its responses
+type apiClient struct {
+	baseURL string
+	apiKey  string
+	timeout time.Duration
+	http    *http.Client
+
+	out    io.Writer // Receives the data of responses
+	errOut io.Writer // Receives the messages of responses
+}
+
+// envelope is the response format of the API
+type envelope struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Meta    json.RawMessage `json:"meta"`
+}
+
+// call sends a request with an optional JSON body and prints the data of the
+// response, along with its pagination metadata when there is any. Error
+// responses are returned as errors carrying their message.
+func (c *apiClient) call(ctx context.Context, method, path string, query url.Values, body interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	target := strings.TrimRight(c.baseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	httpClient := c.http
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var result envelope
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("unexpected response to %s %s (HTTP %d): %w", method, path, resp.StatusCode, err)
+	}
+	if resp.StatusCode >= 400 || result.Status == "error" {
+		if len(result.Data) > 0 && string(result.Data) != "null" {
+			c.print(result.Data, nil)
+		}
+		return fmt.Errorf("%s (HTTP %d)", result.Message, resp.StatusCode)
+	}
+
+	if result.Message != "" {
+		fmt.Fprintln(c.errOut, result.Message)
+	}
+	return c.print(result.Data, result.Meta)
+}
+
+// print writes data indented, wrapped with its metadata when given
+func (c *apiClient) print(data, meta json.RawMessage) error {
+	value := data
+	if len(meta) > 0 {
+		wrapped, err := json.Marshal(map[string]json.RawMessage{"data": data, "meta": meta})
+		if err != nil {
+			return err
+		}
+		value = wrapped
+	}
+	if len(value) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, value, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := c.out.Write(buf.Bytes())
+	return err
+}
+
+// repositoryPath returns the API path of a repository given as owner/name
+func repositoryPath(fullName string, suffix string) (string, error) {
+	owner, name, err := splitRepository(fullName)
+	if err != nil {
+		return "", err
+	}
+	return "/api/v1/repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(name) + suffix, nil
+}
+
+// pageQuery adds the page and per_page parameters when they are set
+func pageQuery(query url.Values, page, perPage int) url.Values {
+	if page > 0 {
+		query.Set("page", fmt.Sprint(page))
+	}
+	if perPage > 0 {
+		query.Set("per_page", fmt.Sprint(perPage))
+	}
+	return query
+}
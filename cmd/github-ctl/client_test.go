@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientCall(t *testing.T) {
+	var gotKey, gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotPath = r.Header.Get("X-API-Key"), r.URL.RequestURI()
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		gotBody = body.String()
+
+		if strings.Contains(r.URL.Path, "missing") {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "message": "Repository golang/missing not found"})
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success","message":"Resync scheduled","data":{"job_id":"abc"}}`))
+	}))
+	defer server.Close()
+
+	var out, errOut bytes.Buffer
+	client := &apiClient{baseURL: server.URL + "/", apiKey: "secret", timeout: time.Second, out: &out, errOut: &errOut}
+
+	path, _ := repositoryPath("golang/go", "/sync")
+	if err := client.call(context.Background(), http.MethodPost, path, nil, map[string]interface{}{"full": true}); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if gotKey != "secret" || gotPath != "/api/v1/repositories/golang/go/sync" || gotBody != `{"full":true}` {
+		t.Errorf("request = key %q, path %q, body %q", gotKey, gotPath, gotBody)
+	}
+	if out.String() != "{\n  \"job_id\": \"abc\"\n}\n" {
+		t.Errorf("output = %q, want the indented data", out.String())
+	}
+	if errOut.String() != "Resync scheduled\n" {
+		t.Errorf("message = %q", errOut.String())
+	}
+
+	path, _ = repositoryPath("golang/missing", "")
+	err := client.call(context.Background(), http.MethodGet, path, nil, nil)
+	if err == nil || err.Error() != "Repository golang/missing not found (HTTP 404)" {
+		t.Errorf("err = %v, want the response message", err)
+	}
+}
+
+func TestSplitRepository(t *testing.T) {
+	if owner, name, err := splitRepository("golang/go"); err != nil || owner != "golang" || name != "go" {
+		t.Errorf("splitRepository = %q, %q, %v", owner, name, err)
+	}
+	for _, invalid := range []string{"golang", "/go", "golang/", "a/b/c"} {
+		if _, _, err := splitRepository(invalid); err == nil {
+			t.Errorf("splitRepository(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+// newJobsCommand builds the commands inspecting and enqueueing jobs
+func newJobsCommand(client *apiClient) *cobra.Command {
+	jobs := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect and enqueue jobs",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List jobs, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/jobs", nil, nil)
+		},
+	}
+
+	get := &cobra.Command{
+		Use:   "get JOB_ID",
+		Short: "Show the status of a job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/jobs/"+url.PathEscape(args[0]), nil, nil)
+		},
+	}
+
+	var payload, runAt string
+	var maxRetries int
+	enqueue := &cobra.Command{
+		Use:   "enqueue TYPE",
+		Short: "Enqueue a job, such as resync, report, cleanup or maintenance",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body := map[string]interface{}{"type": args[0]}
+			if payload != "" {
+				if !json.Valid([]byte(payload)) {
+					return fmt.Errorf("payload must be a JSON object")
+				}
+				body["payload"] = json.RawMessage(payload)
+			}
+			if runAt != "" {
+				body["run_at"] = runAt
+			}
+			if cmd.Flags().Changed("max-retries") {
+				body["max_retries"] = maxRetries
+			}
+			return client.call(cmd.Context(), http.MethodPost, "/api/v1/jobs", nil, body)
+		},
+	}
+	enqueue.Flags().StringVar(&payload, "payload", "", `payload of the job as JSON, e.g. '{"owner":"golang","name":"go"}'`)
+	enqueue.Flags().StringVar(&runAt, "run-at", "", "time the job runs at (RFC3339 or YYYY-MM-DD); at once when unset")
+	enqueue.Flags().IntVar(&maxRetries, "max-retries", 0, "retries after a failure; the queue default when unset")
+
+	var since, until string
+	latency := &cobra.Command{
+		Use:   "latency",
+		Short: "Show how long jobs waited in the queue and ran, per type",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if since != "" {
+				query.Set("since", since)
+			}
+			if until != "" {
+				query.Set("until", until)
+			}
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/admin/jobs/latency", query, nil)
+		},
+	}
+	latency.Flags().StringVar(&since, "since", "", "start of the window (RFC3339 or YYYY-MM-DD); 24 hours ago when unset")
+	latency.Flags().StringVar(&until, "until", "", "end of the window (RFC3339 or YYYY-MM-DD); now when unset")
+
+	release := &cobra.Command{
+		Use:   "release JOB_ID",
+		Short: "Release a quarantined job so it runs again",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.call(cmd.Context(), http.MethodPost, "/api/v1/admin/jobs/"+url.PathEscape(args[0])+"/release", nil, nil)
+		},
+	}
+
+	jobs.AddCommand(list, get, enqueue, latency, release)
+	return jobs
+}
@@ -0,0 +1,68 @@
+// Command github-ctl administers a github-service deployment: it manages the
+// monitored repositories, resyncs and jobs and dumps stats through the API of
+// a running instance, and migrates the database directly.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand builds the command tree
+func newRootCommand() *cobra.Command {
+	client := &apiClient{}
+
+	root := &cobra.Command{
+		Use:   "github-ctl",
+		Short: "Administer a github-service deployment",
+		Long: `Administer a github-service deployment.
+
+Commands other than migrate talk to the API of a running instance at --url,
+authenticating with --api-key. Their output is the data of the response as
+JSON, so it can be piped to jq; the response message is written to stderr.`,
+		SilenceUsage: true,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&client.baseURL, "url", envOr("GITHUB_CTL_URL", "http://localhost:8080"), "base URL of the service API (env GITHUB_CTL_URL)")
+	flags.StringVar(&client.apiKey, "api-key", os.Getenv("GITHUB_CTL_API_KEY"), "API key sent as X-API-Key (env GITHUB_CTL_API_KEY)")
+	flags.DurationVar(&client.timeout, "timeout", 30*time.Second, "timeout of each API request")
+
+	client.out = root.OutOrStdout()
+	client.errOut = root.ErrOrStderr()
+
+	root.AddCommand(
+		newReposCommand(client),
+		newJobsCommand(client),
+		newStatsCommand(client),
+		newMigrateCommand(),
+	)
+	return root
+}
+
+// envOr returns the value of an environment variable, or fallback when unset
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// splitRepository splits owner/name into its parts
+func splitRepository(fullName string) (owner, name string, err error) {
+	owner, name, ok := strings.Cut(fullName, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("repository %q must be given as owner/name", fullName)
+	}
+	return owner, name, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+
+	"github-service/internal/config"
+	"github-service/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+// newMigrateCommand builds the commands migrating the database directly,
+// without a running instance
+func newMigrateCommand() *cobra.Command {
+	var configPath string
+
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database",
+		Long: `Migrate the database named by the service configuration at --config,
+connecting to it directly.`,
+	}
+	migrate.PersistentFlags().StringVar(&configPath, "config", "configs/config.yaml", "path to the service config file")
+
+	schema := &cobra.Command{
+		Use:   "schema",
+		Short: "Apply the schema the service applies when it starts",
+		Long: `Apply the schema the service applies when it starts, creating missing
+tables and indexes and converting older tables. Running it before rolling
+out a new version keeps the first start of the instances short.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			db, err := database.New(cfg.GetDSN())
+			if err != nil {
+				return err
+			}
+			return db.Close()
+		},
+	}
+
+	var migrationsPath string
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending SQL migrations from --path",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+			conn, err := sql.Open("postgres", cfg.GetDSN())
+			if err != nil {
+				return fmt.Errorf("error opening database: %w", err)
+			}
+			defer conn.Close()
+			return database.NewFromDB(conn).MigrateDB(migrationsPath)
+		},
+	}
+	up.Flags().StringVar(&migrationsPath, "path", "internal/database/migrations", "directory of the numbered migrations")
+
+	migrate.AddCommand(schema, up)
+	return migrate
+}
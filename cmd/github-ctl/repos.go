@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+// newReposCommand builds the commands managing monitored repositories
+func newReposCommand(client *apiClient) *cobra.Command {
+	repos := &cobra.Command{
+		Use:     "repos",
+		Aliases: []string{"repositories"},
+		Short:   "Manage monitored repositories",
+	}
+
+	var page, perPage int
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List monitored repositories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/repositories", pageQuery(url.Values{}, page, perPage), nil)
+		},
+	}
+	list.Flags().IntVar(&page, "page", 0, "page number (1-based)")
+	list.Flags().IntVar(&perPage, "per-page", 0, "repositories per page")
+
+	get := &cobra.Command{
+		Use:   "get OWNER/NAME",
+		Short: "Show a monitored repository and its sync status",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := repositoryPath(args[0], "")
+			if err != nil {
+				return err
+			}
+			return client.call(cmd.Context(), http.MethodGet, path, nil, nil)
+		},
+	}
+
+	var addSince, provider string
+	add := &cobra.Command{
+		Use:   "add OWNER/NAME",
+		Short: "Start monitoring a repository and schedule its initial sync",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := repositoryPath(args[0], "")
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			if addSince != "" {
+				query.Set("since", addSince)
+			}
+			if provider != "" {
+				query.Set("provider", provider)
+			}
+			return client.call(cmd.Context(), http.MethodPut, path, query, nil)
+		},
+	}
+	add.Flags().StringVar(&addSince, "since", "", "sync commits made since this time (RFC3339 or YYYY-MM-DD), or full for the full history")
+	add.Flags().StringVar(&provider, "provider", "", "where the repository is hosted: github or gitlab")
+
+	remove := &cobra.Command{
+		Use:     "remove OWNER/NAME",
+		Aliases: []string{"rm"},
+		Short:   "Stop monitoring a repository",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := repositoryPath(args[0], "")
+			if err != nil {
+				return err
+			}
+			return client.call(cmd.Context(), http.MethodDelete, path, nil, nil)
+		},
+	}
+
+	var resyncSince string
+	var resyncFull bool
+	resync := &cobra.Command{
+		Use:   "resync OWNER/NAME",
+		Short: "Schedule a resync of a repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := repositoryPath(args[0], "/sync")
+			if err != nil {
+				return err
+			}
+			body := map[string]interface{}{}
+			if resyncSince != "" {
+				body["since"] = resyncSince
+			}
+			if resyncFull {
+				body["full"] = true
+			}
+			return client.call(cmd.Context(), http.MethodPost, path, nil, body)
+		},
+	}
+	resync.Flags().StringVar(&resyncSince, "since", "", "resync commits made since this time (RFC3339 or YYYY-MM-DD)")
+	resync.Flags().BoolVar(&resyncFull, "full", false, "resync the full history")
+	resync.MarkFlagsMutuallyExclusive("since", "full")
+
+	var allSince, batchInterval string
+	var allFull, dryRun bool
+	var batchSize int
+	resyncAll := &cobra.Command{
+		Use:   "resync-all",
+		Short: "Schedule a resync of every monitored repository, in batches",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body := map[string]interface{}{
+				"since":          allSince,
+				"full":           allFull,
+				"dry_run":        dryRun,
+				"batch_size":     batchSize,
+				"batch_interval": batchInterval,
+			}
+			return client.call(cmd.Context(), http.MethodPost, "/api/v1/admin/resync-all", nil, body)
+		},
+	}
+	resyncAll.Flags().StringVar(&allSince, "since", "", "resync commits made since this time (RFC3339 or YYYY-MM-DD)")
+	resyncAll.Flags().BoolVar(&allFull, "full", false, "resync the full history")
+	resyncAll.Flags().BoolVar(&dryRun, "dry-run", false, "report the resyncs without scheduling them")
+	resyncAll.Flags().IntVar(&batchSize, "batch-size", 0, "resyncs starting at once; the service default when 0")
+	resyncAll.Flags().StringVar(&batchInterval, "batch-interval", "", "duration between the starts of consecutive batches, e.g. 5m")
+	resyncAll.MarkFlagsMutuallyExclusive("since", "full")
+
+	repos.AddCommand(list, get, add, remove, resync, resyncAll)
+	return repos
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+// newStatsCommand builds the commands dumping stats
+func newStatsCommand(client *apiClient) *cobra.Command {
+	stats := &cobra.Command{
+		Use:   "stats",
+		Short: "Dump commit stats and service metrics",
+	}
+
+	var repository, language, since, until string
+	var page, perPage int
+	topAuthors := &cobra.Command{
+		Use:   "top-authors",
+		Short: "Rank commit authors by commit count, overall or for a repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := windowQuery(since, until)
+			if repository != "" {
+				query.Set("repository", repository)
+			}
+			if language != "" {
+				query.Set("language", language)
+			}
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/stats/top-authors", pageQuery(query, page, perPage), nil)
+		},
+	}
+	topAuthors.Flags().StringVar(&repository, "repository", "", "only authors of this repository (owner/name)")
+	topAuthors.Flags().StringVar(&language, "language", "", "only repositories with this primary language")
+	topAuthors.Flags().IntVar(&page, "page", 0, "page number (1-based)")
+	topAuthors.Flags().IntVar(&perPage, "per-page", 0, "authors per page")
+	addWindowFlags(topAuthors, &since, &until)
+
+	var metric, window string
+	var limit int
+	topRepositories := &cobra.Command{
+		Use:   "top-repositories",
+		Short: "Rank monitored repositories by recent activity",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if metric != "" {
+				query.Set("metric", metric)
+			}
+			if window != "" {
+				query.Set("window", window)
+			}
+			if limit > 0 {
+				query.Set("limit", fmt.Sprint(limit))
+			}
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/stats/top-repositories", query, nil)
+		},
+	}
+	topRepositories.Flags().StringVar(&metric, "metric", "", "ranking metric: commits or authors")
+	topRepositories.Flags().StringVar(&window, "window", "", "only count commits made within this duration, e.g. 7d")
+	topRepositories.Flags().IntVar(&limit, "limit", 0, "number of repositories")
+
+	stats.AddCommand(
+		topAuthors,
+		topRepositories,
+		repositoryStatsCommand(client, "file-extensions", "Show a repository's changes by file extension"),
+		repositoryStatsCommand(client, "commit-types", "Count a repository's commits by Conventional Commits type"),
+		repositoryStatsCommand(client, "contribution-distribution", "Show how concentrated a repository's commits are among its authors"),
+		&cobra.Command{
+			Use:   "metrics",
+			Short: "Show the runtime metrics of the instance",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return client.call(cmd.Context(), http.MethodGet, "/metrics", nil, nil)
+			},
+		},
+	)
+	return stats
+}
+
+// repositoryStatsCommand builds a command dumping a stats endpoint taking a
+// repository and a time window
+func repositoryStatsCommand(client *apiClient, name, short string) *cobra.Command {
+	var since, until string
+	cmd := &cobra.Command{
+		Use:   name + " OWNER/NAME",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, _, err := splitRepository(args[0]); err != nil {
+				return err
+			}
+			query := windowQuery(since, until)
+			query.Set("repository", args[0])
+			return client.call(cmd.Context(), http.MethodGet, "/api/v1/stats/"+name, query, nil)
+		},
+	}
+	addWindowFlags(cmd, &since, &until)
+	return cmd
+}
+
+func addWindowFlags(cmd *cobra.Command, since, until *string) {
+	cmd.Flags().StringVar(since, "since", "", "only commits on or after this time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(until, "until", "", "only commits on or before this time (RFC3339 or YYYY-MM-DD)")
+}
+
+func windowQuery(since, until string) url.Values {
+	query := url.Values{}
+	if since != "" {
+		query.Set("since", since)
+	}
+	if until != "" {
+		query.Set("until", until)
+	}
+	return query
+}
@@ -2,40 +2,96 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
 
 	"github-service/internal/app"
+	"github-service/internal/backup"
+	"github-service/internal/cache"
 	"github-service/internal/config"
 	"github-service/internal/database"
+	"github-service/internal/events"
 	"github-service/internal/github"
+	"github-service/internal/gitlab"
+	"github-service/internal/httpclient"
+	"github-service/internal/jira"
+	"github-service/internal/logbuffer"
+	"github-service/internal/models"
+	"github-service/internal/notify"
 	"github-service/internal/queue"
 	"github-service/internal/service"
+	"github-service/internal/tracing"
+	"github-service/internal/webhook"
 	"github-service/internal/worker"
 
 	"github.com/rs/zerolog"
 )
 
+// jobDrainTimeout bounds how long shutdown waits for a running job before requeueing it
+const jobDrainTimeout = 30 * time.Second
+
+// tracingFlushTimeout bounds how long shutdown waits to export the remaining spans
+const tracingFlushTimeout = 5 * time.Second
+
+// deletedCleanupInterval is how often removed repositories past their retention are purged
+const deletedCleanupInterval = time.Hour
+
 func main() {
 	// Parse command line flags
 	configPath := flag.String("config", "configs/config.yaml", "path to config file")
+	importRepo := flag.String("import-commits", "", "import commits from a dump into the stored repository owner/name, then exit")
+	importFile := flag.String("import-file", "-", "commit dump to import, - for standard input")
+	importFormat := flag.String("import-format", service.ImportFormatCSV, "format of the commit dump, csv or ndjson")
+	backupFile := flag.String("backup", "", "write an encrypted backup to this file, - for standard output, then exit")
+	restoreFile := flag.String("restore", "", "restore an encrypted backup from this file into an empty database, - for standard input, then exit")
 	flag.Parse()
 
-	// Create logger
-	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
-
 	// Load configuration
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Create logger, keeping recent entries in memory for the admin log stream
+	var logs *logbuffer.Buffer
+	var logOutput io.Writer = os.Stdout
+	if cfg.Log.BufferSize > 0 {
+		logs = logbuffer.New(cfg.Log.BufferSize)
+		logOutput = zerolog.MultiLevelWriter(os.Stdout, logs)
+	}
+	logger := zerolog.New(logOutput).With().Timestamp().Logger()
+
+	// Export traces of requests, jobs and the calls they make
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			ServiceName: cfg.Tracing.ServiceName,
+		})
+		if err != nil {
+			log.Fatalf("Error setting up tracing: %v", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error().Err(err).Msg("Failed to flush traces")
+			}
+		}()
+	}
+
 	// Initialize database connection
 	db, err := database.New(cfg.GetDSN())
 	if err != nil {
@@ -43,28 +99,195 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize GitHub client
+	// Back up or restore the database instead of serving when asked to
+	if *backupFile != "" || *restoreFile != "" {
+		if err := runBackup(db, cfg.Backup, *backupFile, *restoreFile); err != nil {
+			log.Fatalf("Error running backup: %v", err)
+		}
+		return
+	}
+
+	// Retry transient database errors such as serialization failures and dropped connections
+	dbLogger := logger.With().Str("component", "database").Logger()
+	retryDB := database.NewRetryDB(db, map[database.OperationClass]database.RetryPolicy{
+		database.OperationRead:  retryPolicy(cfg.Database.Retry.Read),
+		database.OperationWrite: retryPolicy(cfg.Database.Retry.Write),
+	}, dbLogger)
+
+	// Outbound requests share a transport honouring the proxy and CA settings
+	transport, err := httpclient.NewTransport(cfg.HTTP.Options())
+	if err != nil {
+		log.Fatalf("Error configuring outbound HTTP: %v", err)
+	}
+	githubHTTP := &http.Client{Timeout: cfg.GitHub.RequestTimeout, Transport: transport}
+	apiHTTP := &http.Client{Timeout: 30 * time.Second, Transport: transport}
+
+	// Initialize GitHub client, authenticating as a GitHub App when one is configured
+	// and rotating between tokens when several are
+	github.SetBaseURL(cfg.GitHub.BaseURL)
 	githubClient := github.NewClient(cfg.GitHub.Token)
+	if tokens := cfg.GitHub.AllTokens(); len(tokens) > 1 {
+		githubClient = github.NewMultiTokenClient(tokens)
+	}
+	if cfg.GitHub.App.Enabled() {
+		privateKey, err := cfg.GitHub.App.PrivateKeyPEM()
+		if err != nil {
+			log.Fatalf("Error reading GitHub app private key: %v", err)
+		}
+		tokens, err := github.NewAppTokenSource(cfg.GitHub.App.ID, cfg.GitHub.App.InstallationID, privateKey)
+		if err != nil {
+			log.Fatalf("Error configuring GitHub app authentication: %v", err)
+		}
+		tokens.SetHTTPClient(githubHTTP)
+		githubClient = github.NewAppClient(tokens)
+	}
+	githubClient.SetHTTPClient(githubHTTP)
+
+	// Job and sync events are fanned out to clients of the event stream
+	eventBus := events.NewBus()
+
+	// Notify the configured channels of repositories that keep failing to sync
+	// and of jobs that failed their last retry
+	notifications := notify.New(logger.With().Str("component", "notify").Logger())
+	if cfg.Notify.Slack.Enabled {
+		notifications.Add("slack", notify.NewSlack(cfg.Notify.Slack.WebhookURL, apiHTTP), cfg.Notify.Slack.Events)
+	}
+	if email := cfg.Notify.Email; email.Enabled {
+		notifications.Add("email", notify.NewEmail(email.Host, email.Port, email.Username, email.Password, email.From, email.To), email.Events)
+	}
 
 	// Create service layer
 	svcLogger := logger.With().Str("component", "service").Logger()
-	svc := service.New(githubClient, db, &svcLogger)
+	svcOptions := []service.Option{
+		service.WithCommitFiles(cfg.GitHub.FetchCommitFiles),
+		service.WithCommitStats(cfg.GitHub.CommitStatsBatch),
+		service.WithIssues(cfg.GitHub.SyncIssues),
+		service.WithReleases(cfg.GitHub.SyncReleases),
+		service.WithContributorStats(cfg.GitHub.SyncContributors),
+		service.WithDependencies(cfg.GitHub.SyncDependencies),
+		service.WithMaxCommitPages(cfg.GitHub.MaxCommitPages),
+		service.WithTokenExpiryWarning(cfg.GitHub.TokenExpiryWarn),
+		service.WithDefaultHistory(cfg.Monitor.DefaultHistory),
+		service.WithMinResyncInterval(cfg.Monitor.MinResyncInterval),
+		service.WithDeletedRetention(cfg.Monitor.DeletedRetention),
+		service.WithMaxConcurrentSyncs(cfg.Worker.MaxConcurrentSyncs),
+		service.WithWebhookSender(webhook.NewSender(10 * time.Second)),
+		service.WithWebhookAttempts(cfg.Webhooks.MaxAttempts),
+		service.WithEventPublisher(eventBus),
+	}
+	if notifications.Enabled() {
+		svcOptions = append(svcOptions, service.WithSyncFailureNotifier(notifications, cfg.Notify.SyncFailureThreshold))
+		svcOptions = append(svcOptions, service.WithAlertNotifier(notifications))
+		if cfg.Reports.NotifyDigests {
+			svcOptions = append(svcOptions, service.WithDigestNotifier(notifications))
+		}
+	}
+	if cfg.Cache.Enabled {
+		// Redis shares the cache between instances, so their invalidations
+		// reach each other
+		var store cache.Store = cache.NewMemory(cfg.Cache.MaxEntries)
+		if cfg.Cache.Redis.Address != "" {
+			redis := cache.NewRedis(cfg.Cache.Redis.Address, cfg.Cache.Redis.Password, cfg.Cache.Redis.DB, "github-service:")
+			defer redis.Close()
+			store = redis
+		}
+		statsCache := cache.New(store, cfg.Cache.TTL, logger.With().Str("component", "cache").Logger())
+		svcOptions = append(svcOptions, service.WithCache(statsCache))
+	}
+	if cfg.GitLab.Enabled {
+		gitlabClient := gitlab.NewClient(cfg.GitLab.BaseURL, cfg.GitLab.Token)
+		gitlabClient.SetHTTPClient(apiHTTP)
+		svcOptions = append(svcOptions, service.WithProvider(models.ProviderGitLab, gitlabClient))
+	}
+	if cfg.Jira.Enabled {
+		tracker := jira.NewClient(cfg.Jira.BaseURL, cfg.Jira.Email, cfg.Jira.Token)
+		tracker.SetHTTPClient(apiHTTP)
+		svcOptions = append(svcOptions, service.WithTicketTracker(tracker, cfg.Jira.Projects, cfg.Jira.RefreshInterval))
+	}
+	var oauthClient *github.OAuthClient
+	if cfg.Auth.OAuth.Enabled() {
+		oauth := cfg.Auth.OAuth
+		oauthClient = github.NewOAuthClient(oauth.BaseURL, oauth.ClientID, oauth.ClientSecret, oauth.RedirectURL)
+		oauthClient.SetHTTPClient(apiHTTP)
+		svcOptions = append(svcOptions, service.WithOAuth(oauthClient, oauth.DefaultRole, oauth.SessionTTL))
+	}
+	svc := service.New(githubClient, retryDB, &svcLogger, svcOptions...)
+
+	// Import a commit dump instead of serving when asked to
+	if *importRepo != "" {
+		if err := importCommits(svc, *importRepo, *importFile, *importFormat); err != nil {
+			log.Fatalf("Error importing commits: %v", err)
+		}
+		return
+	}
 
 	// Create job queue
-	jobQueue, err := queue.NewPostgresQueue(db.DB())
+	pgQueue, err := queue.NewPostgresQueue(db.DB())
 	if err != nil {
 		log.Fatalf("Error creating job queue: %v", err)
 	}
 
+	// Recover jobs interrupted by a previous crash or forced shutdown; backfills
+	// resume from the commit page they reached. Jobs other instances are still
+	// running keep sending heartbeats, so they aren't taken from them; without
+	// heartbeats every running job is requeued.
+	requeued, resumed, err := pgQueue.RequeueRunningJobs(context.Background(), cfg.Queue.HeartbeatTimeout())
+	if err != nil {
+		log.Fatalf("Error recovering interrupted jobs: %v", err)
+	}
+	if requeued > 0 {
+		logger.Warn().Int64("count", requeued).Int64("resuming_from_checkpoint", resumed).Msg("Requeued jobs interrupted by a previous run")
+	}
+
+	// Keep bulk imports from syncing too many histories at once; per-type limits
+	// from the queue config take precedence
+	pgQueue.SetConcurrencyLimit(queue.JobTypeSync, cfg.Monitor.MaxBackfills)
+	for jobType, limit := range cfg.Queue.Concurrency {
+		pgQueue.SetConcurrencyLimit(queue.JobType(jobType), limit)
+	}
+	pgQueue.SetLeaseDuration(cfg.Queue.LeaseDuration)
+	pgQueue.SetMaxPanics(cfg.Queue.MaxJobPanics)
+
+	// Publish job transitions on the event bus
+	jobQueue := queue.NewEventQueue(pgQueue, eventBus)
+
 	// Create sync worker for repository monitoring
-	syncWorker := worker.NewSyncWorker(svc, cfg.GitHub.Interval, 7*24*time.Hour)
+	syncWorker := worker.NewSyncWorker(svc, cfg.GitHub.Interval)
+	syncWorker.SetConcurrency(cfg.Monitor.SyncConcurrency)
 
-	// Create job worker
+	// Create the job worker pool
 	workerLogger := logger.With().Str("component", "worker").Logger()
-	jobWorker := worker.NewJobWorker(jobQueue, svc, workerLogger)
+	pool := worker.NewPool(jobQueue, svc, workerLogger, cfg.Worker.Count)
+	pool.SetPollInterval(cfg.Worker.PollInterval)
+	pool.SetSyncInterval(cfg.GitHub.Interval)
+	pool.SetHeartbeatInterval(cfg.Queue.HeartbeatInterval())
+	if notifications.Enabled() {
+		pool.SetFailureNotifier(notifications)
+	}
+
+	// Wake the workers as soon as jobs are enqueued; without notifications they
+	// only poll
+	notifier, err := queue.NewNotifier(cfg.GetDSN())
+	if err != nil {
+		logger.Warn().Err(err).Msg("Job notifications unavailable, falling back to polling")
+	} else {
+		defer notifier.Close()
+		pool.SetNotifier(notifier)
+	}
 
 	// Initialize and start the application
-	app, err := app.New(cfg, logger, svc, jobQueue, syncWorker)
+	appOpts := []app.Option{app.WithEvents(eventBus)}
+	if logs != nil {
+		appOpts = append(appOpts, app.WithLogBuffer(logs))
+	}
+	if cfg.Backup.Key != "" {
+		key, _ := backup.ParseKey(cfg.Backup.Key) // checked by config validation
+		appOpts = append(appOpts, app.WithBackup(db.DB(), key))
+	}
+	if oauthClient != nil {
+		appOpts = append(appOpts, app.WithOAuth(oauthClient))
+	}
+	app, err := app.New(cfg, logger, svc, jobQueue, syncWorker, appOpts...)
 	if err != nil {
 		log.Fatalf("Error creating application: %v", err)
 	}
@@ -73,16 +296,162 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Start job worker in a goroutine
-	go func() {
-		if err := jobWorker.Start(ctx); err != nil {
-			logger.Error().Err(err).Msg("Job worker error")
+	// Start the job workers
+	if notifier != nil {
+		go notifier.Run(ctx)
+	}
+	pool.Start(ctx)
+
+	// Return jobs whose worker crashed mid-run to the queue
+	if cfg.Queue.StuckTimeout > 0 {
+		reaperLogger := logger.With().Str("component", "reaper").Logger()
+		go worker.NewReaper(jobQueue, cfg.Queue.StuckTimeout, cfg.Queue.ReapInterval, reaperLogger).Start(ctx)
+	}
+
+	// Send the webhook deliveries queued by syncs, retrying failed ones
+	webhookLogger := logger.With().Str("component", "webhooks").Logger()
+	go worker.NewWebhookDispatcher(svc, cfg.Webhooks.DeliveryInterval, webhookLogger).Start(ctx)
+
+	// Schedule database maintenance jobs, cleanup jobs purging removed
+	// repositories once their retention has passed, ticket status refreshes,
+	// discovery of the repositories of watched users and digests
+	if cfg.Maintenance.Enabled || cfg.Monitor.DeletedRetention > 0 || cfg.Jira.Enabled || cfg.Monitor.DiscoveryInterval > 0 || len(cfg.Reports.Digests) > 0 {
+		var analyzeInterval, reindexInterval, cleanupInterval time.Duration
+		if cfg.Maintenance.Enabled {
+			analyzeInterval, reindexInterval = cfg.Maintenance.AnalyzeInterval, cfg.Maintenance.ReindexInterval
+		}
+		if cfg.Monitor.DeletedRetention > 0 {
+			cleanupInterval = deletedCleanupInterval
 		}
-	}()
+		maintenanceLogger := logger.With().Str("component", "maintenance").Logger()
+		scheduler := worker.NewMaintenanceScheduler(jobQueue, analyzeInterval, reindexInterval, cleanupInterval, maintenanceLogger)
+		if cfg.Jira.Enabled {
+			scheduler.SetTicketRefreshInterval(cfg.Jira.RefreshInterval)
+		}
+		scheduler.SetDiscoveryInterval(cfg.Monitor.DiscoveryInterval)
+		scheduler.SetDigestPeriods(cfg.Reports.Digests)
+		go scheduler.Start(ctx)
+	}
+
+	// Sync monitored repositories in the background
+	if cfg.Monitor.Enabled {
+		if err := syncWorker.Start(ctx); err != nil {
+			log.Fatalf("Error starting sync worker: %v", err)
+		}
+	}
 
 	// Start the application
-	if err := app.Run(ctx); err != nil {
-		logger.Error().Err(err).Msg("Application error")
+	runErr := app.Run(ctx)
+	if runErr != nil {
+		logger.Error().Err(runErr).Msg("Application error")
+	}
+	syncWorker.Stop()
+
+	// Let the running jobs finish; requeue them if they don't in time
+	drainCtx, cancel := context.WithTimeout(context.Background(), jobDrainTimeout)
+	defer cancel()
+	if err := pool.Shutdown(drainCtx); err != nil {
+		logger.Error().Err(err).Msg("Failed to drain job workers")
+	}
+
+	if runErr != nil {
 		os.Exit(1)
 	}
 }
+
+// importCommits imports a commit dump into a stored repository and prints the result
+func importCommits(svc *service.Service, fullName, path, format string) error {
+	var dump io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dump = f
+	}
+
+	result, err := svc.ImportCommits(context.Background(), fullName, format, dump)
+	if result != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	}
+	return err
+}
+
+// runBackup writes an encrypted backup to backupPath or restores one from
+// restorePath, and prints a summary of the rows
+func runBackup(db *database.DB, cfg config.BackupConfig, backupPath, restorePath string) error {
+	if backupPath != "" && restorePath != "" {
+		return fmt.Errorf("-backup and -restore cannot be combined")
+	}
+	if cfg.Key == "" {
+		return fmt.Errorf("backup.key (or BACKUP_KEY) is required")
+	}
+	key, err := backup.ParseKey(cfg.Key)
+	if err != nil {
+		return err
+	}
+
+	var summary *backup.Summary
+	if backupPath != "" {
+		summary, err = writeBackup(db, backupPath, key)
+	} else {
+		summary, err = restoreBackup(db, restorePath, key)
+	}
+	if summary != nil {
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(summary)
+	}
+	return err
+}
+
+func writeBackup(db *database.DB, path string, key []byte) (*backup.Summary, error) {
+	if path == "-" {
+		return backup.Export(context.Background(), db.DB(), os.Stdout, key)
+	}
+
+	// Write next to the target and rename, so a failed backup never replaces a good one
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	summary, err := backup.Export(context.Background(), db.DB(), tmp, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	return summary, os.Rename(tmp.Name(), path)
+}
+
+func restoreBackup(db *database.DB, path string, key []byte) (*backup.Summary, error) {
+	var dump io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		dump = f
+	}
+	return backup.Restore(context.Background(), db.DB(), dump, key)
+}
+
+// retryPolicy converts a configured retry policy to its database representation
+func retryPolicy(cfg config.RetryPolicyConfig) database.RetryPolicy {
+	return database.RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}
+}
@@ -0,0 +1,332 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/errors"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// oauthStateCookie holds the state of a login between the redirect to GitHub
+// and the callback
+const oauthStateCookie = "github_oauth_state"
+
+// accountScope returns the account whose repositories the request is limited
+// to, or 0 when it sees every repository
+func accountScope(ctx context.Context) int64 {
+	if key := apiKeyFromContext(ctx); key != nil && key.Scoped() {
+		return *key.AccountID
+	}
+	return 0
+}
+
+// routeScope is how a route limits user sessions to their account's repositories
+type routeScope int
+
+const (
+	scopeAccount            routeScope = iota + 1 // Allowed; the handler filters by account where it lists repositories
+	scopeRepositoryParam                          // A repository query parameter must be the account's; without one the handler filters by account
+	scopeRepositoryRequired                       // A repository query parameter naming one of the account's repositories is required
+)
+
+// repositoryRoutes prefixes the routes of a repository, which user sessions
+// may only use for their account's repositories
+const repositoryRoutes = "/api/v1/repositories/{owner}/{repo}"
+
+// scopedRoutes are the other routes user sessions may use. The rest span every
+// repository, such as groups and jobs, or administer the service.
+var scopedRoutes = map[string]routeScope{
+	"/api/v1/repositories":                        scopeAccount,
+	"/api/v1/stats/top-authors":                   scopeRepositoryParam,
+	"/api/v1/stats/top-repositories":              scopeAccount,
+	"/api/v1/stats/file-extensions":               scopeRepositoryParam,
+	"/api/v1/stats/commit-types":                  scopeRepositoryParam,
+	"/api/v1/stats/contribution-distribution":     scopeRepositoryParam,
+	"/api/v1/stats/release-cadence":               scopeRepositoryParam,
+	"/api/v1/authors/{email}/commits":             scopeRepositoryRequired,
+	"/api/v1/dependencies/{ecosystem}/dependents": scopeAccount,
+	"/api/v1/account":                             scopeAccount,
+}
+
+// scopeMiddleware limits user sessions to the repositories their account
+// added. Repositories of other accounts are reported as not found, so their
+// names don't leak.
+func (a *App) scopeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account := accountScope(r.Context())
+		if account == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var template string
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+
+		var repository string
+		switch scope := scopedRoutes[template]; {
+		case strings.HasPrefix(template, repositoryRoutes+"/") || template == repositoryRoutes:
+			// Adding a repository associates it with the account
+			if template == repositoryRoutes && r.Method == http.MethodPut {
+				break
+			}
+			vars := mux.Vars(r)
+			repository = vars["owner"] + "/" + vars["repo"]
+		case scope == scopeRepositoryParam || scope == scopeRepositoryRequired:
+			repository = r.URL.Query().Get("repository")
+			if repository == "" && scope == scopeRepositoryRequired {
+				response.JSON(w, http.StatusBadRequest, response.ErrorCode(response.CodeInvalidInput, "Parameter repository is required for user sessions"))
+				return
+			}
+		case scope == scopeAccount:
+		default:
+			response.JSON(w, http.StatusForbidden, response.ErrorCode(response.CodeForbidden, "User sessions cannot use this endpoint"))
+			return
+		}
+
+		if repository != "" {
+			ok, err := a.service.HasAccountRepository(r.Context(), account, repository)
+			if err != nil {
+				a.writeError(w, r, err, "check repository access")
+				return
+			}
+			if !ok {
+				a.writeError(w, r, errors.NewNotFoundError("repository", repository), "check repository access")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loginWithGitHub handles starting a login by redirecting to GitHub
+//
+// @Summary     Log in with GitHub
+// @Description Redirect to GitHub to authorize the service's OAuth app, which redirects back to /auth/github/callback. Only available when auth.oauth is configured.
+// @Tags        auth
+// @Success     302
+// @Router      /auth/github/login [get]
+func (a *App) loginWithGitHub(w http.ResponseWriter, r *http.Request) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		a.writeError(w, r, err, "start login")
+		return
+	}
+	state := hex.EncodeToString(nonce)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/auth/github",
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(a.cfg.Auth.OAuth.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, a.oauth.AuthorizeURL(state), http.StatusFound)
+}
+
+// githubCallback handles GitHub redirecting back after a login
+//
+// @Summary     GitHub login callback
+// @Description Complete a login started at /auth/github/login, creating the account on its first login. Responds with an API key for the session, valid for auth.oauth.session_ttl, which is only shown once. Requests made with it only see the repositories the account added.
+// @Tags        auth
+// @Produce     json
+// @Param       code  query string true "Code GitHub redirected back with"
+// @Param       state query string true "State GitHub redirected back with"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     401 {object} response.Response
+// @Failure     502 {object} response.Response
+// @Router      /auth/github/callback [get]
+func (a *App) githubCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if reason := query.Get("error"); reason != "" {
+		response.JSON(w, http.StatusUnauthorized, response.ErrorCode(response.CodeUnauthorized, "GitHub login was not authorized: "+reason))
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookie)
+	state := query.Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		response.JSON(w, http.StatusBadRequest, response.ErrorCode(response.CodeInvalidInput, "Login state does not match; start again at /auth/github/login"))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthStateCookie, Path: "/auth/github", MaxAge: -1})
+
+	account, key, plaintext, err := a.service.LoginWithGitHub(r.Context(), query.Get("code"))
+	if err != nil {
+		a.writeError(w, r, err, "log in with GitHub")
+		return
+	}
+
+	a.log.Info().
+		Int64("account_id", account.ID).
+		Str("login", account.Login).
+		Msg("Account logged in")
+
+	response.JSON(w, http.StatusOK, response.Success("Logged in; store the key now, it will not be shown again", map[string]interface{}{
+		"account":    account,
+		"api_key":    key,
+		"key":        plaintext,
+		"expires_at": key.ExpiresAt,
+	}))
+}
+
+// logout handles ending the session of the request's API key
+//
+// @Summary     Log out
+// @Description Revoke the API key a login issued. Any role may log out.
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     401 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /auth/logout [post]
+func (a *App) logout(w http.ResponseWriter, r *http.Request) {
+	key := apiKeyFromContext(r.Context())
+	if key == nil || key.AccountID == nil {
+		response.JSON(w, http.StatusBadRequest, response.ErrorCode(response.CodeInvalidInput, "Only keys issued by a login can log out"))
+		return
+	}
+
+	if err := a.service.RevokeAPIKey(r.Context(), key.ID); err != nil {
+		a.writeError(w, r, err, "log out")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Logged out successfully", map[string]interface{}{
+		"api_key_id": key.ID,
+	}))
+}
+
+// getAccount handles retrieving the account of the request's session
+//
+// @Summary     Get account
+// @Description The account that logged in to issue the request's API key, with the number of repositories it added
+// @Tags        auth
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/account [get]
+func (a *App) getAccount(w http.ResponseWriter, r *http.Request) {
+	key := apiKeyFromContext(r.Context())
+	if key == nil || key.AccountID == nil {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeNotFound, "The request was not authenticated by a login"))
+		return
+	}
+
+	account, err := a.service.GetAccount(r.Context(), *key.AccountID)
+	if err != nil {
+		a.writeError(w, r, err, "get account")
+		return
+	}
+	_, repositories, err := a.service.GetAccountRepositoryListings(r.Context(), account.ID, 1, 1)
+	if err != nil {
+		a.writeError(w, r, err, "get account")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Account retrieved successfully", map[string]interface{}{
+		"account":      account,
+		"repositories": repositories,
+		"expires_at":   key.ExpiresAt,
+	}))
+}
+
+// listAccounts handles listing the accounts that logged in with GitHub
+//
+// @Summary     List accounts
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/accounts [get]
+func (a *App) listAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := a.service.ListAccounts(r.Context())
+	if err != nil {
+		a.writeError(w, r, err, "list accounts")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Accounts retrieved successfully", map[string]interface{}{
+		"accounts": accounts,
+		"count":    len(accounts),
+	}))
+}
+
+// updateAccountRole handles changing the role of an account
+//
+// @Summary     Update account role
+// @Description Change the role of an account along with that of its live sessions. Sessions of admin accounts see every repository.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       id      path int                     true "Account ID"
+// @Param       request body updateAPIKeyRoleRequest true "New role"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/accounts/{id} [patch]
+func (a *App) updateAccountRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid account id"))
+		return
+	}
+
+	var req updateAPIKeyRoleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := a.service.UpdateAccountRole(r.Context(), id, req.Role); err != nil {
+		a.writeError(w, r, err, "update account role")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Account role updated successfully", map[string]interface{}{
+		"id":   id,
+		"role": req.Role,
+	}))
+}
+
+// deleteAccount handles deleting an account
+//
+// @Summary     Delete account
+// @Description Delete an account, revoking its sessions. The repositories it added stay monitored; it is created again on its next login.
+// @Tags        admin
+// @Produce     json
+// @Param       id path int true "Account ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/accounts/{id} [delete]
+func (a *App) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid account id"))
+		return
+	}
+
+	if err := a.service.DeleteAccount(r.Context(), id); err != nil {
+		a.writeError(w, r, err, "delete account")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Account deleted successfully", map[string]interface{}{
+		"id": id,
+	}))
+}
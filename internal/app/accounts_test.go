@@ -0,0 +1,67 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestScopeMiddleware(t *testing.T) {
+	a := newAuthApp()
+
+	// The API middleware in front of routes that only report being reached
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	a.useAPIMiddleware(api)
+	var served string
+	reached := func(w http.ResponseWriter, r *http.Request) {
+		served = r.URL.Query().Get("repository")
+		w.WriteHeader(http.StatusOK)
+	}
+	api.HandleFunc("/repositories/{owner}/{repo}", reached).Methods(http.MethodGet, http.MethodPut)
+	api.HandleFunc("/repositories/{owner}/{repo}/commits", reached).Methods(http.MethodGet)
+	api.HandleFunc("/stats/file-extensions", reached).Methods(http.MethodGet)
+	api.HandleFunc("/authors/{email}/commits", reached).Methods(http.MethodGet)
+	api.HandleFunc("/jobs", reached).Methods(http.MethodGet)
+
+	for _, tt := range []struct {
+		name       string
+		method     string
+		target     string
+		key        string
+		want       int
+		repository string // The repository parameter the handler reads
+	}{
+		{"own repository", http.MethodGet, "/api/v1/repositories/octo/api", "session-key", http.StatusOK, ""},
+		{"own repository's commits", http.MethodGet, "/api/v1/repositories/octo/api/commits", "session-key", http.StatusOK, ""},
+		{"another account's repository", http.MethodGet, "/api/v1/repositories/octo/web", "session-key", http.StatusNotFound, ""},
+		{"another account's repository's commits", http.MethodGet, "/api/v1/repositories/octo/web/commits", "session-key", http.StatusNotFound, ""},
+		{"adding a repository", http.MethodPut, "/api/v1/repositories/octo/web", "session-key", http.StatusOK, ""},
+		{"stats of own repository", http.MethodGet, "/api/v1/stats/file-extensions?repository=octo/api", "session-key", http.StatusOK, "octo/api"},
+		{"stats of own repository with spaces", http.MethodGet, "/api/v1/stats/file-extensions?repository=%20octo/api%20", "session-key", http.StatusOK, "octo/api"},
+		{"stats of another account's repository", http.MethodGet, "/api/v1/stats/file-extensions?repository=octo/web", "session-key", http.StatusNotFound, ""},
+		{"stats of another account's repository with spaces", http.MethodGet, "/api/v1/stats/file-extensions?repository=%20octo/web", "session-key", http.StatusNotFound, ""},
+		{"stats of the account's repositories", http.MethodGet, "/api/v1/stats/file-extensions", "session-key", http.StatusOK, ""},
+		{"author commits without repository", http.MethodGet, "/api/v1/authors/rsc@golang.org/commits", "session-key", http.StatusBadRequest, ""},
+		{"author commits of own repository", http.MethodGet, "/api/v1/authors/rsc@golang.org/commits?repository=octo/api", "session-key", http.StatusOK, "octo/api"},
+		{"unlisted route", http.MethodGet, "/api/v1/jobs", "session-key", http.StatusForbidden, ""},
+		{"unscoped key", http.MethodGet, "/api/v1/repositories/octo/web", "writer-key", http.StatusOK, ""},
+		{"unscoped key on unlisted route", http.MethodGet, "/api/v1/jobs", "writer-key", http.StatusOK, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			served = ""
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if served != tt.repository {
+				t.Errorf("handler read repository %q, want %q", served, tt.repository)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github-service/internal/logbuffer"
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+// logKeepAliveInterval is how often an idle log stream sends a keep-alive comment
+const logKeepAliveInterval = 15 * time.Second
+
+// initializeAdminRouter configures the router for the dedicated admin listener
+func (a *App) initializeAdminRouter(router *mux.Router) {
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.JSON(w, http.StatusNotFound, response.Error("Route not found"))
+	})
+	router.MethodNotAllowedHandler = methodNotAllowedHandler(router)
+
+	router.Use(tracingMiddleware)
+	router.Use(a.loggingMiddleware)
+	router.Use(a.usageMiddleware)
+	router.Use(a.recoveryMiddleware)
+	router.Use(a.timestampsMiddleware)
+
+	router.HandleFunc("/health", a.healthCheck).Methods(http.MethodGet)
+
+	// Profiling endpoints are only exposed on the dedicated admin listener
+	debug := router.PathPrefix("/debug/pprof").Subrouter()
+	debug.Use(a.authMiddleware)
+	debug.Use(a.requireRole(models.RoleAdmin))
+	debug.HandleFunc("/cmdline", pprof.Cmdline)
+	debug.HandleFunc("/profile", pprof.Profile)
+	debug.HandleFunc("/symbol", pprof.Symbol)
+	debug.HandleFunc("/trace", pprof.Trace)
+	debug.PathPrefix("/").HandlerFunc(pprof.Index)
+
+	a.initAdminRoutes(router)
+}
+
+// initAdminRoutes configures the administrative routes on the given router
+func (a *App) initAdminRoutes(router *mux.Router) {
+	metrics := router.PathPrefix("/metrics").Subrouter()
+	metrics.Use(a.authMiddleware)
+	metrics.Use(a.requireRole(models.RoleAdmin))
+	metrics.HandleFunc("", a.getMetrics).Methods(http.MethodGet)
+
+	admin := router.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(a.authMiddleware)
+	admin.Use(a.requireRole(models.RoleAdmin))
+	admin.HandleFunc("/api-keys", a.listAPIKeys).Methods(http.MethodGet)
+	admin.HandleFunc("/api-keys", a.createAPIKey).Methods(http.MethodPost)
+	admin.HandleFunc("/api-keys/{id}", a.updateAPIKeyRole).Methods(http.MethodPatch)
+	admin.HandleFunc("/api-keys/{id}", a.revokeAPIKey).Methods(http.MethodDelete)
+	admin.HandleFunc("/accounts", a.listAccounts).Methods(http.MethodGet)
+	admin.HandleFunc("/accounts/{id}", a.updateAccountRole).Methods(http.MethodPatch)
+	admin.HandleFunc("/accounts/{id}", a.deleteAccount).Methods(http.MethodDelete)
+	admin.HandleFunc("/maintenance/history", a.getMaintenanceHistory).Methods(http.MethodGet)
+	admin.HandleFunc("/logs/stream", a.streamLogs).Methods(http.MethodGet)
+	admin.HandleFunc("/api-usage", a.getAPIUsage).Methods(http.MethodGet)
+	admin.HandleFunc("/scheduler", a.getScheduler).Methods(http.MethodGet)
+	admin.HandleFunc("/scheduler/pause", a.pauseScheduler).Methods(http.MethodPost)
+	admin.HandleFunc("/scheduler/resume", a.resumeScheduler).Methods(http.MethodPost)
+	admin.HandleFunc("/repositories/{owner}/{repo}/import", a.importCommits).Methods(http.MethodPost)
+	admin.HandleFunc("/backup", a.downloadBackup).Methods(http.MethodGet)
+	admin.HandleFunc("/jobs/latency", a.getJobLatency).Methods(http.MethodGet)
+	admin.HandleFunc("/resync-all", a.resyncAll).Methods(http.MethodPost)
+	admin.HandleFunc("/jobs/{job_id}/release", a.releaseJob).Methods(http.MethodPost)
+}
+
+// getMetrics handles retrieving runtime metrics of the service
+//
+// @Summary     Runtime metrics
+// @Description Uptime, goroutine and memory statistics, how many GitHub repository lookups were deduplicated, the hits and misses of the stats cache (null when it is disabled), and the depth of the job queue with how long its oldest due job has waited. Served on the admin listener when one is configured.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /metrics [get]
+func (a *App) getMetrics(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	depth, err := a.queue.GetQueueDepth(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get queue depth")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get queue depth"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Metrics retrieved successfully", map[string]interface{}{
+		"uptime_seconds": int64(time.Since(a.startedAt).Seconds()),
+		"goroutines":     runtime.NumGoroutine(),
+		"memory": map[string]interface{}{
+			"alloc_bytes":       mem.Alloc,
+			"total_alloc_bytes": mem.TotalAlloc,
+			"sys_bytes":         mem.Sys,
+			"num_gc":            mem.NumGC,
+		},
+		"github": map[string]interface{}{
+			"repository_requests": a.service.GetRepositoryDedupStats(),
+		},
+		"cache": a.service.CacheStats(),
+		"queue": depth,
+	}))
+}
+
+// getMaintenanceHistory handles listing the most recent database maintenance runs
+//
+// @Summary     Maintenance history
+// @Description Most recent ANALYZE and REINDEX runs, newest first, one entry per table or index.
+// @Tags        admin
+// @Produce     json
+// @Param       limit query int false "Maximum number of runs to return" default(10)
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/maintenance/history [get]
+func (a *App) getMaintenanceHistory(w http.ResponseWriter, r *http.Request) {
+	runs, err := a.service.GetMaintenanceHistory(r.Context(), a.parseLimit(r))
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get maintenance history")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get maintenance history"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Maintenance history retrieved successfully", map[string]interface{}{
+		"runs":  runs,
+		"count": len(runs),
+	}))
+}
+
+// streamLogs handles tailing recent log entries as server-sent events
+//
+// @Summary     Stream logs
+// @Description Server-sent events with one "log" event per structured log entry. The most recent matching entries are sent first, then new entries as they are logged.
+// @Tags        admin
+// @Produce     text/event-stream
+// @Param       level     query string false "Minimum level (trace, debug, info, warn, error)"
+// @Param       component query string false "Only entries logged by this component, e.g. worker or database"
+// @Param       tail      query int    false "Number of recent entries to send first (default 100)"
+// @Success     200 {string} string "event stream"
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Failure     503 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/logs/stream [get]
+func (a *App) streamLogs(w http.ResponseWriter, r *http.Request) {
+	if a.logs == nil {
+		response.JSON(w, http.StatusServiceUnavailable, response.Error("Log streaming is not enabled"))
+		return
+	}
+
+	minLevel := zerolog.TraceLevel
+	if level := r.URL.Query().Get("level"); level != "" {
+		parsed, err := zerolog.ParseLevel(level)
+		if err != nil || parsed == zerolog.NoLevel {
+			response.JSON(w, http.StatusBadRequest, response.Error("Invalid level: must be trace, debug, info, warn or error"))
+			return
+		}
+		minLevel = parsed
+	}
+	component := r.URL.Query().Get("component")
+
+	tail := 100
+	if t := r.URL.Query().Get("tail"); t != "" {
+		parsed, err := strconv.Atoi(t)
+		if err != nil || parsed < 0 {
+			response.JSON(w, http.StatusBadRequest, response.Error("Invalid tail: must be a non-negative integer"))
+			return
+		}
+		tail = parsed
+	}
+
+	// Subscribe before reading the backlog so no entry falls between the two
+	entries, unsubscribe := a.logs.Subscribe()
+	defer unsubscribe()
+
+	stream, err := response.NewEventStream(w)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to start log stream")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to start log stream"))
+		return
+	}
+
+	var backlog []logbuffer.Entry
+	for _, entry := range a.logs.Recent() {
+		if entry.Matches(minLevel, component) {
+			backlog = append(backlog, entry)
+		}
+	}
+	if len(backlog) > tail {
+		backlog = backlog[len(backlog)-tail:]
+	}
+	for _, entry := range backlog {
+		if err := stream.Send("log", entry.Raw); err != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(logKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := stream.KeepAlive(); err != nil {
+				return
+			}
+		case entry := <-entries:
+			if !entry.Matches(minLevel, component) {
+				continue
+			}
+			if err := stream.Send("log", entry.Raw); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -2,10 +2,15 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github-service/internal/config"
+	"github-service/internal/events"
+	"github-service/internal/github"
+	"github-service/internal/logbuffer"
 	"github-service/internal/queue"
 	"github-service/internal/service"
+	"github-service/internal/usage"
 	"github-service/internal/worker"
 	"net/http"
 	"strings"
@@ -19,25 +24,74 @@ import (
 // @version 1.0
 // @description A service that monitors GitHub repositories, fetches commit information, and provides statistics.
 // @host localhost:8080
-// @BasePath /api/v1
+// @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued via /api/v1/admin/api-keys; "Authorization: Bearer <key>" is also accepted
 
 type App struct {
-	cfg     *config.Config
-	log     zerolog.Logger
-	service *service.Service
-	server  *http.Server
-	monitor *time.Ticker
-	queue   queue.Queue
-	worker  *worker.SyncWorker
+	cfg         *config.Config
+	log         zerolog.Logger
+	service     *service.Service
+	server      *http.Server
+	adminServer *http.Server
+	monitor     *time.Ticker
+	queue       queue.Queue
+	worker      *worker.SyncWorker
+	logs        *logbuffer.Buffer
+	events      *events.Bus
+	backupDB    *sql.DB
+	backupKey   []byte
+	usage       *usage.Recorder
+	oauth       *github.OAuthClient
+	startedAt   time.Time
 }
 
-func New(cfg *config.Config, log zerolog.Logger, svc *service.Service, queue queue.Queue, worker *worker.SyncWorker) (*App, error) {
+// Option configures optional App behaviour
+type Option func(*App)
+
+// WithLogBuffer enables tailing recent log entries through the admin API
+func WithLogBuffer(logs *logbuffer.Buffer) Option {
+	return func(a *App) {
+		a.logs = logs
+	}
+}
+
+// WithBackup enables downloading encrypted backups of db through the admin API
+func WithBackup(db *sql.DB, key []byte) Option {
+	return func(a *App) {
+		a.backupDB = db
+		a.backupKey = key
+	}
+}
+
+// WithEvents enables streaming job and sync events through the API
+func WithEvents(bus *events.Bus) Option {
+	return func(a *App) {
+		a.events = bus
+	}
+}
+
+// WithOAuth enables logging in with GitHub accounts through an OAuth app
+func WithOAuth(client *github.OAuthClient) Option {
+	return func(a *App) {
+		a.oauth = client
+	}
+}
+
+func New(cfg *config.Config, log zerolog.Logger, svc *service.Service, queue queue.Queue, worker *worker.SyncWorker, opts ...Option) (*App, error) {
 	app := &App{
-		cfg:     cfg,
-		log:     log,
-		service: svc,
-		queue:   queue,
-		worker:  worker,
+		cfg:       cfg,
+		log:       log,
+		service:   svc,
+		queue:     queue,
+		worker:    worker,
+		usage:     usage.NewRecorder(),
+		startedAt: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(app)
 	}
 
 	router := mux.NewRouter()
@@ -50,6 +104,22 @@ func New(cfg *config.Config, log zerolog.Logger, svc *service.Service, queue que
 		WriteTimeout: 30 * time.Second,
 	}
 
+	// Admin endpoints get their own listener when an admin port is configured,
+	// otherwise they are served alongside the public API
+	if cfg.Server.AdminPort != 0 {
+		adminRouter := mux.NewRouter()
+		app.initializeAdminRouter(adminRouter)
+
+		app.adminServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", cfg.Server.AdminPort),
+			Handler:      adminRouter,
+			ReadTimeout:  30 * time.Second,
+			WriteTimeout: 30 * time.Second,
+		}
+	} else {
+		app.initAdminRoutes(router)
+	}
+
 	return app, nil
 }
 
@@ -58,6 +128,7 @@ func (a *App) Run(ctx context.Context) error {
 		a.monitor = time.NewTicker(a.cfg.GitHub.Interval)
 		go a.runMonitor(ctx)
 	}
+	go a.runUsageFlusher(ctx)
 
 	go func() {
 		<-ctx.Done()
@@ -66,14 +137,41 @@ func (a *App) Run(ctx context.Context) error {
 		}
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		if err := a.server.Shutdown(shutdownCtx); err != nil {
+		if err := a.Shutdown(shutdownCtx); err != nil {
 			a.log.Error().Err(err).Msg("Failed to shutdown server gracefully")
 		}
+		// Keep usage recorded by the requests that were still in flight
+		a.flushUsage(shutdownCtx)
+	}()
+
+	errCh := make(chan error, 2)
+
+	if a.adminServer != nil {
+		go func() {
+			a.log.Info().Msgf("Starting admin server on port %d", a.cfg.Server.AdminPort)
+			if err := a.adminServer.ListenAndServe(); err != http.ErrServerClosed {
+				errCh <- fmt.Errorf("admin server error: %w", err)
+				return
+			}
+			errCh <- nil
+		}()
+	}
+
+	go func() {
+		a.log.Info().Msgf("Starting server on port %d", a.cfg.Server.Port)
+		if err := a.server.ListenAndServe(); err != http.ErrServerClosed {
+			errCh <- fmt.Errorf("server error: %w", err)
+			return
+		}
+		errCh <- nil
 	}()
 
-	a.log.Info().Msgf("Starting server on port %d", a.cfg.Server.Port)
-	if err := a.server.ListenAndServe(); err != http.ErrServerClosed {
-		return fmt.Errorf("server error: %w", err)
+	// Stop everything as soon as either listener fails
+	if err := <-errCh; err != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		a.Shutdown(shutdownCtx)
+		return err
 	}
 	return nil
 }
@@ -86,13 +184,13 @@ func (a *App) runMonitor(ctx context.Context) {
 		case <-a.monitor.C:
 			since := a.cfg.GitHub.Since
 			if since.IsZero() {
-				since = time.Now().AddDate(0, 0, -7)
+				since = a.service.DefaultSince()
 			}
 
 			if a.cfg.GitHub.Repo != "" {
 				parts := strings.Split(a.cfg.GitHub.Repo, "/")
 				if len(parts) == 2 {
-					err := a.service.SyncRepository(ctx, parts[0], parts[1], since)
+					err := a.service.SyncRepositoryIncremental(ctx, parts[0], parts[1], since)
 					if err != nil {
 						a.log.Error().
 							Err(err).
@@ -110,7 +208,18 @@ func (a *App) runMonitor(ctx context.Context) {
 	}
 }
 
+// Handler returns the handler serving the public API, along with the admin
+// routes when no admin port is configured
+func (a *App) Handler() http.Handler {
+	return a.server.Handler
+}
+
 func (a *App) Shutdown(ctx context.Context) error {
+	if a.adminServer != nil {
+		if err := a.adminServer.Shutdown(ctx); err != nil {
+			a.log.Error().Err(err).Msg("Failed to shutdown admin server gracefully")
+		}
+	}
 	return a.server.Shutdown(ctx)
 }
 
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+type contextKey string
+
+const apiKeyContextKey contextKey = "api_key"
+
+// apiKeyFromContext returns the API key that authenticated the request, if any
+func apiKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey).(*models.APIKey)
+	return key
+}
+
+// extractAPIKey reads the API key from the X-API-Key or Authorization: Bearer headers
+func extractAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
+
+// authMiddleware authenticates requests by API key when authentication is enabled
+func (a *App) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.cfg.Auth.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		plaintext := extractAPIKey(r)
+		if plaintext == "" {
+			response.JSON(w, http.StatusUnauthorized, response.Error("API key required"))
+			return
+		}
+
+		var key *models.APIKey
+		if a.cfg.Auth.AdminKey != "" && subtle.ConstantTimeCompare([]byte(plaintext), []byte(a.cfg.Auth.AdminKey)) == 1 {
+			key = &models.APIKey{Name: "bootstrap", Role: models.RoleAdmin}
+		} else {
+			var err error
+			key, err = a.service.AuthenticateAPIKey(r.Context(), plaintext)
+			if err != nil {
+				if errors.Is(err, errors.ErrUnauthorized) {
+					response.JSON(w, http.StatusUnauthorized, response.Error("Invalid API key"))
+					return
+				}
+				a.log.Error().Err(err).Msg("Failed to authenticate API key")
+				response.JSON(w, http.StatusInternalServerError, response.Error("Failed to authenticate API key"))
+				return
+			}
+		}
+
+		setUsageAPIKey(r.Context(), key.ID)
+		ctx := context.WithValue(r.Context(), apiKeyContextKey, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireRole returns a middleware that rejects requests whose API key lacks the given role
+func (a *App) requireRole(role models.Role) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.authorize(w, r, role) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// methodRoleMiddleware requires the reader role for safe methods and the writer role otherwise
+func (a *App) methodRoleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := models.RoleWriter
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			role = models.RoleReader
+		}
+
+		if a.authorize(w, r, role) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// authorize checks the request's API key against the required role and writes
+// an error response when access is denied
+func (a *App) authorize(w http.ResponseWriter, r *http.Request, role models.Role) bool {
+	if !a.cfg.Auth.Enabled {
+		return true
+	}
+
+	key := apiKeyFromContext(r.Context())
+	if key == nil {
+		response.JSON(w, http.StatusUnauthorized, response.Error("API key required"))
+		return false
+	}
+	if !key.Role.Allows(role) {
+		a.log.Warn().
+			Str("api_key", key.Prefix).
+			Str("role", string(key.Role)).
+			Str("required_role", string(role)).
+			Str("path", r.URL.Path).
+			Msg("API key lacks required role")
+		response.JSON(w, http.StatusForbidden, response.Error(fmt.Sprintf("API key role %s cannot perform this action", key.Role)))
+		return false
+	}
+	return true
+}
+
+// createAPIKeyRequest is the body of a request to issue an API key
+type createAPIKeyRequest struct {
+	Name string      `json:"name" example:"ci-pipeline"`
+	Role models.Role `json:"role" enums:"reader,writer,admin" example:"reader"`
+}
+
+// updateAPIKeyRoleRequest is the body of a request to change an API key's role
+type updateAPIKeyRoleRequest struct {
+	Role models.Role `json:"role" enums:"reader,writer,admin" example:"writer"`
+}
+
+// createAPIKey handles issuing a new API key
+//
+// @Summary     Create API key
+// @Description Issue a new API key. The plaintext key is only returned once.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       request body createAPIKeyRequest true "Key name and role"
+// @Success     201 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/api-keys [post]
+func (a *App) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req createAPIKeyRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleReader
+	}
+
+	key, plaintext, err := a.service.CreateAPIKey(r.Context(), req.Name, req.Role)
+	if err != nil {
+		a.writeError(w, r, err, "create API key")
+		return
+	}
+
+	a.log.Info().
+		Int64("api_key_id", key.ID).
+		Str("role", string(key.Role)).
+		Msg("API key created")
+
+	response.JSON(w, http.StatusCreated, response.Success("API key created; store it now, it will not be shown again", map[string]interface{}{
+		"api_key": key,
+		"key":     plaintext,
+	}))
+}
+
+// listAPIKeys handles listing all API keys
+//
+// @Summary     List API keys
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/api-keys [get]
+func (a *App) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := a.service.ListAPIKeys(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to list API keys")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to list API keys"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("API keys retrieved successfully", map[string]interface{}{
+		"api_keys": keys,
+		"count":    len(keys),
+	}))
+}
+
+// updateAPIKeyRole handles changing the role of an API key
+//
+// @Summary     Update API key role
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       id      path int                     true "API key ID"
+// @Param       request body updateAPIKeyRoleRequest true "New role"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/api-keys/{id} [patch]
+func (a *App) updateAPIKeyRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid API key id"))
+		return
+	}
+
+	var req updateAPIKeyRoleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if err := a.service.UpdateAPIKeyRole(r.Context(), id, req.Role); err != nil {
+		a.writeError(w, r, err, "update API key role")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("API key role updated successfully", map[string]interface{}{
+		"id":   id,
+		"role": req.Role,
+	}))
+}
+
+// revokeAPIKey handles revoking an API key
+//
+// @Summary     Revoke API key
+// @Tags        admin
+// @Produce     json
+// @Param       id path int true "API key ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/api-keys/{id} [delete]
+func (a *App) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid API key id"))
+		return
+	}
+
+	if err := a.service.RevokeAPIKey(r.Context(), id); err != nil {
+		a.writeError(w, r, err, "revoke API key")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("API key revoked successfully", map[string]interface{}{
+		"id": id,
+	}))
+}
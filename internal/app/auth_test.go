@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github-service/internal/config"
+	"github-service/internal/models"
+	"github-service/internal/service"
+	"github-service/internal/usage"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+// apiKeyStore is a database holding API keys by their plaintext and the
+// repositories each account added
+type apiKeyStore struct {
+	service.Database
+	keys         map[string]*models.APIKey
+	repositories map[int64][]string
+}
+
+func (s *apiKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for plaintext, key := range s.keys {
+		if service.HashAPIKey(plaintext) == keyHash {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *apiKeyStore) TouchAPIKey(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (s *apiKeyStore) HasAccountRepository(ctx context.Context, accountID int64, fullName string) (bool, error) {
+	for _, repository := range s.repositories[accountID] {
+		if repository == fullName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newAuthApp returns an app requiring API keys that knows a key of each role
+// and the session key of an account that added octo/api
+func newAuthApp() *App {
+	logger := zerolog.Nop()
+	account := int64(7)
+	store := &apiKeyStore{
+		keys: map[string]*models.APIKey{
+			"reader-key":  {ID: 1, Name: "reader", Role: models.RoleReader},
+			"writer-key":  {ID: 2, Name: "writer", Role: models.RoleWriter},
+			"admin-key":   {ID: 3, Name: "admin", Role: models.RoleAdmin},
+			"session-key": {ID: 4, Name: "session", Role: models.RoleWriter, AccountID: &account},
+		},
+		repositories: map[int64][]string{account: {"octo/api"}},
+	}
+	return &App{
+		cfg:     &config.Config{Auth: config.AuthConfig{Enabled: true, AdminKey: "bootstrap-key"}},
+		log:     logger,
+		service: service.New(nil, store, &logger),
+		usage:   usage.NewRecorder(),
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	a := newAuthApp()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// The middleware of the API and admin routers, in the order they apply it
+	api := a.authMiddleware(a.methodRoleMiddleware(ok))
+	admin := a.authMiddleware(a.requireRole(models.RoleAdmin)(ok))
+
+	for _, tt := range []struct {
+		name    string
+		handler http.Handler
+		method  string
+		header  string
+		key     string
+		want    int
+	}{
+		{"missing key", api, http.MethodGet, "", "", http.StatusUnauthorized},
+		{"invalid key", api, http.MethodGet, "X-API-Key", "unknown-key", http.StatusUnauthorized},
+		{"invalid bearer token", api, http.MethodGet, "Authorization", "Bearer unknown-key", http.StatusUnauthorized},
+		{"missing key on admin route", admin, http.MethodGet, "", "", http.StatusUnauthorized},
+		{"reader reads", api, http.MethodGet, "X-API-Key", "reader-key", http.StatusOK},
+		{"reader reads with bearer token", api, http.MethodGet, "Authorization", "Bearer reader-key", http.StatusOK},
+		{"reader posts", api, http.MethodPost, "X-API-Key", "reader-key", http.StatusForbidden},
+		{"reader puts", api, http.MethodPut, "X-API-Key", "reader-key", http.StatusForbidden},
+		{"reader deletes", api, http.MethodDelete, "X-API-Key", "reader-key", http.StatusForbidden},
+		{"writer posts", api, http.MethodPost, "X-API-Key", "writer-key", http.StatusOK},
+		{"writer deletes", api, http.MethodDelete, "X-API-Key", "writer-key", http.StatusOK},
+		{"reader on admin route", admin, http.MethodGet, "X-API-Key", "reader-key", http.StatusForbidden},
+		{"writer on admin route", admin, http.MethodGet, "X-API-Key", "writer-key", http.StatusForbidden},
+		{"admin on admin route", admin, http.MethodPost, "X-API-Key", "admin-key", http.StatusOK},
+		{"bootstrap key on admin route", admin, http.MethodPost, "X-API-Key", "bootstrap-key", http.StatusOK},
+		{"admin writes", api, http.MethodDelete, "X-API-Key", "admin-key", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/repositories", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAuthorizationDisabled(t *testing.T) {
+	a := newAuthApp()
+	a.cfg.Auth.Enabled = false
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	a.authMiddleware(a.requireRole(models.RoleAdmin)(ok)).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d without authentication", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHealthWithoutAPIKey(t *testing.T) {
+	router := mux.NewRouter()
+	newAuthApp().initializeRouter(router)
+
+	for path, want := range map[string]int{
+		"/health":              http.StatusOK,
+		"/api/v1/health":       http.StatusOK,
+		"/api/v1/repositories": http.StatusUnauthorized,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"net/http"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// mergeAuthorsRequest is the body of a request to merge author identities
+type mergeAuthorsRequest struct {
+	// Email the merged commits are attributed to
+	Email string `json:"email" example:"jane@example.com"`
+	// Display name of the merged identity; defaults to the name the email most often commits under
+	Name string `json:"name" example:"Jane Doe"`
+	// Other emails of the same person
+	Aliases []string `json:"aliases" example:"jane.doe@old-company.com,jdoe@users.noreply.github.com"`
+}
+
+// mergeAuthors handles merging several author emails into one identity
+//
+// @Summary     Merge author identities
+// @Description Attribute the commits of every alias to the identity of email in top-author statistics. Identities previously merged into an alias move along with it.
+// @Tags        stats
+// @Accept      json
+// @Produce     json
+// @Param       request body mergeAuthorsRequest true "Identity to merge into and its aliases"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/authors/merge [post]
+func (a *App) mergeAuthors(w http.ResponseWriter, r *http.Request) {
+	var req mergeAuthorsRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	identities, err := a.service.MergeAuthorIdentities(r.Context(), req.Name, req.Email, req.Aliases)
+	if err != nil {
+		a.writeError(w, r, err, "merge author identities")
+		return
+	}
+
+	a.log.Info().
+		Str("email", identities[0].CanonicalEmail).
+		Int("emails", len(identities)).
+		Msg("Merged author identities")
+
+	response.JSON(w, http.StatusOK, response.Success("Author identities merged successfully", map[string]interface{}{
+		"name":   identities[0].CanonicalName,
+		"email":  identities[0].CanonicalEmail,
+		"emails": identities,
+	}))
+}
+
+// listAuthorIdentities handles listing merged author emails
+//
+// @Summary     List author identities
+// @Description Every merged author email with the identity its commits are attributed to
+// @Tags        stats
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/authors/identities [get]
+func (a *App) listAuthorIdentities(w http.ResponseWriter, r *http.Request) {
+	identities, err := a.service.ListAuthorIdentities(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to list author identities")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to list author identities"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Author identities retrieved successfully", map[string]interface{}{
+		"identities": identities,
+		"count":      len(identities),
+	}))
+}
+
+// unmergeAuthorIdentity handles detaching an email from its merged identity
+//
+// @Summary     Unmerge author identity
+// @Description Count the email's commits under its own name and email again
+// @Tags        stats
+// @Produce     json
+// @Param       email path string true "Merged author email"
+// @Success     200 {object} response.Response
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/authors/identities/{email} [delete]
+func (a *App) unmergeAuthorIdentity(w http.ResponseWriter, r *http.Request) {
+	email := mux.Vars(r)["email"]
+
+	if err := a.service.UnmergeAuthorIdentity(r.Context(), email); err != nil {
+		a.writeError(w, r, err, "unmerge author identity")
+		return
+	}
+
+	a.log.Info().Str("email", email).Msg("Unmerged author identity")
+	response.JSON(w, http.StatusOK, response.Success("Author identity unmerged successfully", map[string]string{
+		"email": email,
+	}))
+}
+
+// getAuthorCommits handles listing the commits of an author across repositories
+//
+// @Summary     Get author commits
+// @Description Get a page of the commits of an author across the monitored repositories, newest first, including the commits of the emails merged into the same identity. The email is matched ignoring case.
+// @Tags        commits
+// @Produce     json
+// @Param       email      path  string true  "Author email"
+// @Param       repository query string false "Only commits to this repository (owner/repo)"
+// @Param       page       query int    false "Page number (1-based)" default(1)
+// @Param       per_page   query int    false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=[]models.AuthorCommit}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/authors/{email}/commits [get]
+func (a *App) getAuthorCommits(w http.ResponseWriter, r *http.Request) {
+	email := mux.Vars(r)["email"]
+	repository := r.URL.Query().Get("repository")
+	page, perPage := a.parsePagination(r)
+
+	commits, totalItems, err := a.service.GetAuthorCommits(r.Context(), email, repository, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "get author commits")
+		return
+	}
+	if commits == nil {
+		commits = []*models.AuthorCommit{}
+	}
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Author commits retrieved successfully", commits, page, perPage, totalItems))
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github-service/internal/backup"
+	"github-service/internal/response"
+)
+
+// downloadBackup handles streaming an encrypted backup of the database
+//
+// @Summary     Download a backup
+// @Description Encrypted, gzip compressed dump of the repositories, their commits and the monitoring configuration (monitored repositories, threshold rules and commit hooks), sealed with the configured backup key. Restore it with the -restore flag of the service binary.
+// @Tags        admin
+// @Produce     application/octet-stream
+// @Success     200 {file}   file "encrypted backup"
+// @Failure     403 {object} response.Response
+// @Failure     503 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/backup [get]
+func (a *App) downloadBackup(w http.ResponseWriter, r *http.Request) {
+	if a.backupDB == nil {
+		response.JSON(w, http.StatusServiceUnavailable, response.Error("Backups are not enabled"))
+		return
+	}
+
+	// Large databases take longer to dump than the server write timeout allows
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		a.log.Warn().Err(err).Msg("Failed to lift write deadline for backup")
+	}
+
+	filename := fmt.Sprintf("github-service-%s.backup", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// The status is sent with the first chunk, so a failure after that can only
+	// cut the download short, which restore rejects as truncated
+	summary, err := backup.Export(r.Context(), a.backupDB, w, a.backupKey)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to export backup")
+		return
+	}
+	a.log.Info().Interface("rows", summary.Rows).Msg("Backup exported")
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// getRepositoryDependencies handles listing the dependencies of a repository
+//
+// @Summary     Get repository dependencies
+// @Description Packages declared in the go.mod, package.json and requirements.txt at the root of a repository, ordered by manifest and name. Versions are as declared, so npm ranges such as ^18.2.0 are kept; exact pip pins are stored as the bare version. Dependencies are only synced when github.sync_dependencies is enabled.
+// @Tags        dependencies
+// @Produce     json
+// @Param       owner     path  string true  "GitHub repository owner"
+// @Param       repo      path  string true  "GitHub repository name"
+// @Param       ecosystem query string false "Only dependencies of this ecosystem" Enums(go, npm, pypi)
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/dependencies [get]
+func (a *App) getRepositoryDependencies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	deps, err := a.service.GetRepositoryDependencies(r.Context(), fullName, r.URL.Query().Get("ecosystem"))
+	if err != nil {
+		a.writeError(w, r, err, "get repository dependencies")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Dependencies retrieved successfully", map[string]interface{}{
+		"repository":   fullName,
+		"dependencies": deps,
+		"n":            len(deps),
+	}))
+}
+
+// getPackageDependents handles listing the monitored repositories depending on a package
+//
+// @Summary     Get package dependents
+// @Description Monitored repositories declaring a package in one of their manifests, with the version each declares and the number of repositories per version. The package is a query parameter since Go module paths and scoped npm packages contain slashes. PyPI names are matched case-insensitively, treating -, _ and . alike.
+// @Tags        dependencies
+// @Produce     json
+// @Param       ecosystem path  string true  "Package ecosystem" Enums(go, npm, pypi)
+// @Param       package   query string true  "Package name, e.g. github.com/gorilla/mux, @types/node or requests"
+// @Param       version   query string false "Only repositories declaring exactly this version or constraint"
+// @Success     200 {object} response.Response{data=models.PackageDependents}
+// @Failure     400 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/dependencies/{ecosystem}/dependents [get]
+func (a *App) getPackageDependents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	dependents, err := a.service.GetPackageDependents(r.Context(), mux.Vars(r)["ecosystem"], query.Get("package"), query.Get("version"), accountScope(r.Context()))
+	if err != nil {
+		a.writeError(w, r, err, "get package dependents")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Dependents retrieved successfully", dependents))
+}
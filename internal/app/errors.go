@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/errors"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// errorStatus returns the HTTP status and error code an error from the
+// service layer is reported with, from the sentinel errors and error types it
+// wraps
+func errorStatus(err error) (int, string) {
+	var githubErr *errors.GitHubError
+	var apiErr *errors.APIError
+	switch {
+	case errors.Is(err, errors.ErrInvalidInput):
+		return http.StatusBadRequest, response.CodeInvalidInput
+	case errors.Is(err, errors.ErrUnauthorized):
+		return http.StatusUnauthorized, response.CodeUnauthorized
+	case errors.Is(err, errors.ErrRepositoryNotFound):
+		return http.StatusNotFound, response.CodeRepoNotFound
+	case errors.Is(err, errors.ErrNotFound):
+		return http.StatusNotFound, response.CodeNotFound
+	case errors.Is(err, errors.ErrDuplicate), errors.Is(err, errors.ErrConflict),
+		errors.Is(err, errors.ErrAmbiguous), errors.Is(err, errors.ErrSyncInProgress):
+		return http.StatusConflict, response.CodeConflict
+	case errors.Is(err, errors.ErrRateLimit):
+		return http.StatusTooManyRequests, response.CodeRateLimited
+	case errors.As(err, &githubErr), errors.As(err, &apiErr), errors.Is(err, errors.ErrGitHubAPI):
+		return http.StatusBadGateway, response.CodeUpstream
+	}
+	return http.StatusInternalServerError, response.CodeInternal
+}
+
+// writeError writes the response for an operation that failed with err, with
+// the status and code errorStatus derives from it. Client errors carry the
+// error's message; server errors are logged with the request's path variables
+// and reported as failing to do action, e.g. "get commits".
+func (a *App) writeError(w http.ResponseWriter, r *http.Request, err error, action string) {
+	status, code := errorStatus(err)
+	var apiErr *errors.APIError
+	if status == http.StatusTooManyRequests && errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(apiErr.RetryAfter.Seconds())))
+	}
+	if status < http.StatusInternalServerError {
+		response.JSON(w, status, response.ErrorCode(code, errorMessage(err)))
+		return
+	}
+
+	event := a.log.Error().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Str("query", r.URL.RawQuery)
+	for name, value := range mux.Vars(r) {
+		event = event.Str(name, value)
+	}
+	event.Msg("Failed to " + action)
+	response.JSON(w, status, response.ErrorCode(code, fmt.Sprintf("Failed to %s: %v", action, err)))
+}
+
+// errorMessage returns the message a client error is reported with. Missing
+// resources are named with their key, e.g. "Repository octo/hello not found".
+func errorMessage(err error) string {
+	var notFound *errors.NotFoundError
+	if errors.As(err, &notFound) {
+		resource := strings.ToUpper(notFound.Resource[:1]) + notFound.Resource[1:]
+		if notFound.Key == "" {
+			return resource + " not found"
+		}
+		return fmt.Sprintf("%s %s not found", resource, notFound.Key)
+	}
+	return err.Error()
+}
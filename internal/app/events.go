@@ -0,0 +1,68 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github-service/internal/response"
+)
+
+// eventKeepAliveInterval is how often an idle event stream sends a keep-alive comment
+const eventKeepAliveInterval = 15 * time.Second
+
+// streamEvents handles streaming job and sync events as server-sent events
+//
+// @Summary     Stream job and sync events
+// @Description Server-sent events for job progress (job.enqueued, job.started, job.completed, job.failed) and repository syncs (repository.synced, commits.ingested). Each event's data is a JSON object with type, time and data. Only events published while connected are sent.
+// @Tags        jobs
+// @Produce     text/event-stream
+// @Param       types query string false "Comma-separated event types or categories to receive, e.g. job,repository.synced"
+// @Success     200 {string} string "event stream"
+// @Failure     503 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/events [get]
+func (a *App) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if a.events == nil {
+		response.JSON(w, http.StatusServiceUnavailable, response.Error("Event streaming is not enabled"))
+		return
+	}
+
+	var types []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	events, unsubscribe := a.events.Subscribe()
+	defer unsubscribe()
+
+	stream, err := response.NewEventStream(w)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to start event stream")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to start event stream"))
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if err := stream.KeepAlive(); err != nil {
+				return
+			}
+		case event := <-events:
+			if !event.Matches(types) {
+				continue
+			}
+			if err := stream.Send(event.Type, event); err != nil {
+				return
+			}
+		}
+	}
+}
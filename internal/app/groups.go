@@ -0,0 +1,430 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// repositoryGroupRequest is the body of a request to create or replace a repository group
+type repositoryGroupRequest struct {
+	// Lowercase letters, digits, - and _; only read when creating a group
+	Name        string `json:"name,omitempty" example:"platform"`
+	Description string `json:"description" example:"Platform team services"`
+	// Full names of monitored repositories
+	Repositories []string `json:"repositories" example:"octo/api,octo/web"`
+}
+
+// group converts the request to a repository group
+func (req repositoryGroupRequest) group() *models.RepositoryGroup {
+	return &models.RepositoryGroup{
+		Name:         req.Name,
+		Description:  req.Description,
+		Repositories: req.Repositories,
+	}
+}
+
+// groupMemberResult is the outcome of an action on a member of a group
+type groupMemberResult struct {
+	Repository string `json:"repository"`
+	Status     string `json:"status"` // paused, resumed or not_monitored
+}
+
+// listRepositoryGroups handles listing the repository groups
+//
+// @Summary     List repository groups
+// @Description Groups of monitored repositories, e.g. per team or product, with their members
+// @Tags        groups
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups [get]
+func (a *App) listRepositoryGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := a.service.ListRepositoryGroups(r.Context())
+	if err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Repository groups retrieved successfully", map[string]interface{}{
+		"groups": groups,
+		"n":      len(groups),
+	}))
+}
+
+// createRepositoryGroup handles creating a repository group
+//
+// @Summary     Create repository group
+// @Description Group monitored repositories under a name that stats, resyncs and pauses can target. Names are lowercased; every repository must be monitored.
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Param       request body repositoryGroupRequest true "Group definition"
+// @Success     201 {object} response.Response{data=models.RepositoryGroup}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups [post]
+func (a *App) createRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	var req repositoryGroupRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	group := req.group()
+	if err := a.service.CreateRepositoryGroup(r.Context(), group); err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	a.log.Info().
+		Str("group", group.Name).
+		Int("repositories", len(group.Repositories)).
+		Msg("Created repository group")
+
+	response.JSON(w, http.StatusCreated, response.Success("Repository group created successfully", group))
+}
+
+// getRepositoryGroup handles retrieving a repository group
+//
+// @Summary     Get repository group
+// @Tags        groups
+// @Produce     json
+// @Param       name path string true "Group name"
+// @Success     200 {object} response.Response{data=models.RepositoryGroup}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name} [get]
+func (a *App) getRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := a.service.GetRepositoryGroup(r.Context(), groupName(r))
+	if err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Repository group retrieved successfully", group))
+}
+
+// updateRepositoryGroup handles replacing the description and members of a repository group
+//
+// @Summary     Update repository group
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Param       name    path string                 true "Group name"
+// @Param       request body repositoryGroupRequest true "Group definition; name is ignored"
+// @Success     200 {object} response.Response{data=models.RepositoryGroup}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name} [put]
+func (a *App) updateRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	var req repositoryGroupRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	group := req.group()
+	group.Name = groupName(r)
+	if err := a.service.UpdateRepositoryGroup(r.Context(), group); err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Repository group updated successfully", group))
+}
+
+// deleteRepositoryGroup handles removing a repository group
+//
+// @Summary     Delete repository group
+// @Description Remove a group. Its repositories stay monitored.
+// @Tags        groups
+// @Produce     json
+// @Param       name path string true "Group name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name} [delete]
+func (a *App) deleteRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	name := groupName(r)
+	if err := a.service.DeleteRepositoryGroup(r.Context(), name); err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	a.log.Info().Str("group", name).Msg("Deleted repository group")
+	response.JSON(w, http.StatusOK, response.Success("Repository group deleted successfully", map[string]interface{}{
+		"name": name,
+	}))
+}
+
+// addRepositoryGroupMember handles adding a repository to a group
+//
+// @Summary     Add repository to group
+// @Description Add a monitored repository to a group. Adding a member again is a no-op.
+// @Tags        groups
+// @Produce     json
+// @Param       name  path string true "Group name"
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/repositories/{owner}/{repo} [put]
+func (a *App) addRepositoryGroupMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := groupName(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	if err := a.service.AddRepositoryGroupMember(r.Context(), name, fullName); err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success(fmt.Sprintf("Added %s to group %s", fullName, name), map[string]interface{}{
+		"group":      name,
+		"repository": fullName,
+	}))
+}
+
+// removeRepositoryGroupMember handles removing a repository from a group
+//
+// @Summary     Remove repository from group
+// @Description Remove a repository from a group. It stays monitored.
+// @Tags        groups
+// @Produce     json
+// @Param       name  path string true "Group name"
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/repositories/{owner}/{repo} [delete]
+func (a *App) removeRepositoryGroupMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := groupName(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	if err := a.service.RemoveRepositoryGroupMember(r.Context(), name, fullName); err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success(fmt.Sprintf("Removed %s from group %s", fullName, name), map[string]interface{}{
+		"group":      name,
+		"repository": fullName,
+	}))
+}
+
+// getGroupSummary handles summarizing the recent activity of a repository group
+//
+// @Summary     Get group summary
+// @Description Commits, active repositories, authors, top authors and the change in stars of a group's repositories within a recent window. Star changes come from the daily stats snapshots, so repositories without a snapshot from before the window don't count towards star_delta.
+// @Tags        groups
+// @Produce     json
+// @Param       name   path  string true  "Group name"
+// @Param       window query string false "Summarize this duration, e.g. 168h or 30d" default(7d)
+// @Success     200 {object} response.Response{data=models.GroupSummary}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/summary [get]
+func (a *App) getGroupSummary(w http.ResponseWriter, r *http.Request) {
+	window := 7 * 24 * time.Hour
+	if value := r.URL.Query().Get("window"); value != "" {
+		parsed, err := parseWindow("window", value)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+			return
+		}
+		window = parsed
+	}
+
+	summary, err := a.service.GetGroupSummary(r.Context(), groupName(r), time.Now().Add(-window))
+	if err != nil {
+		a.writeError(w, r, err, "summarize repository group")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Repository group summary retrieved successfully", summary))
+}
+
+// resyncRepositoryGroup handles resyncing every repository in a group
+//
+// @Summary     Resync repository group
+// @Description Schedule a resync of every active repository in a group. Commits made since the given time (RFC3339 or YYYY-MM-DD) are fetched, or the full history when full is set; without a body the default history window is used. Like global resyncs, the resyncs run at low priority and ignore monitor.min_resync_interval, and a repository whose sync is already queued keeps that job.
+// @Tags        groups
+// @Accept      json
+// @Produce     json
+// @Param       name    path string                  true  "Group name"
+// @Param       request body resyncRepositoryRequest false "History to resync"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/sync [post]
+func (a *App) resyncRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	since, err := a.resyncSince(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	group, monitored, ok := a.groupMembers(w, r)
+	if !ok {
+		return
+	}
+
+	resyncs := []scheduledResync{}
+	skipped := []string{}
+	for _, fullName := range group.Repositories {
+		if repo, ok := monitored[fullName]; !ok || !repo.IsActive {
+			skipped = append(skipped, fullName)
+			continue
+		}
+
+		job, err := backgroundResyncJob(fullName, since)
+		if err != nil {
+			a.log.Error().Err(err).Msg("Failed to marshal resync payload")
+			response.JSON(w, http.StatusInternalServerError, response.Error("Internal server error"))
+			return
+		}
+		if err := a.enqueue(r.Context(), job); err != nil {
+			a.log.Error().Err(err).Str("repository", fullName).Int("scheduled", len(resyncs)).Msg("Failed to enqueue resync job")
+			response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to schedule resync of %s after scheduling %d: %v", fullName, len(resyncs), err)))
+			return
+		}
+		resyncs = append(resyncs, scheduledResync{
+			Repository: fullName,
+			JobID:      job.ID,
+			Status:     scheduleStatus(job),
+			RunAt:      job.NextRunAt,
+		})
+	}
+
+	a.log.Info().
+		Str("group", group.Name).
+		Int("repositories", len(resyncs)).
+		Msg("Scheduled resync of repository group")
+	response.JSON(w, http.StatusAccepted, response.Success(
+		fmt.Sprintf("Scheduled %d repositories of group %s for resynchronization", len(resyncs), group.Name),
+		map[string]interface{}{
+			"group":   group.Name,
+			"since":   since,
+			"resyncs": resyncs,
+			"skipped": skipped,
+		},
+	))
+}
+
+// pauseRepositoryGroup handles pausing the monitoring of every repository in a group
+//
+// @Summary     Pause repository group
+// @Description Stop syncing every monitored repository in a group while keeping their stored data
+// @Tags        groups
+// @Produce     json
+// @Param       name path string true "Group name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/pause [post]
+func (a *App) pauseRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	a.setGroupMonitoring(w, r, false)
+}
+
+// resumeRepositoryGroup handles resuming the monitoring of every repository in a group
+//
+// @Summary     Resume repository group
+// @Description Resume syncing every paused repository in a group. The next scheduled syncs fetch the commits made since their last sync.
+// @Tags        groups
+// @Produce     json
+// @Param       name path string true "Group name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/groups/{name}/resume [post]
+func (a *App) resumeRepositoryGroup(w http.ResponseWriter, r *http.Request) {
+	a.setGroupMonitoring(w, r, true)
+}
+
+// setGroupMonitoring pauses or resumes the monitoring of the repositories in the requested group
+func (a *App) setGroupMonitoring(w http.ResponseWriter, r *http.Request, active bool) {
+	group, monitored, ok := a.groupMembers(w, r)
+	if !ok {
+		return
+	}
+
+	state := "paused"
+	if active {
+		state = "resumed"
+	}
+
+	results := make([]groupMemberResult, 0, len(group.Repositories))
+	updated := 0
+	for _, fullName := range group.Repositories {
+		result := groupMemberResult{Repository: fullName, Status: state}
+		if _, ok := monitored[fullName]; !ok {
+			result.Status = "not_monitored"
+			results = append(results, result)
+			continue
+		}
+
+		owner, repo, _ := strings.Cut(fullName, "/")
+		var err error
+		if active {
+			err = a.worker.ResumeRepository(r.Context(), owner, repo)
+		} else {
+			err = a.worker.PauseRepository(r.Context(), owner, repo)
+		}
+		if err != nil {
+			a.writeError(w, r, fmt.Errorf("%s after updating %d repositories: %w", fullName, updated, err), "update repository monitoring")
+			return
+		}
+		updated++
+		results = append(results, result)
+	}
+
+	a.log.Info().Str("group", group.Name).Int("repositories", updated).Msg("Repository group monitoring " + state)
+	response.JSON(w, http.StatusOK, response.Success(
+		fmt.Sprintf("Monitoring of %d repositories of group %s %s", updated, group.Name, state),
+		map[string]interface{}{
+			"group":        group.Name,
+			"active":       active,
+			"repositories": results,
+		},
+	))
+}
+
+// groupMembers returns the requested group along with the monitored
+// repositories by full name. It writes the error response and returns false
+// when either can't be read.
+func (a *App) groupMembers(w http.ResponseWriter, r *http.Request) (*models.RepositoryGroup, map[string]models.MonitoredRepository, bool) {
+	group, err := a.service.GetRepositoryGroup(r.Context(), groupName(r))
+	if err != nil {
+		a.writeError(w, r, err, "access repository groups")
+		return nil, nil, false
+	}
+
+	repos, err := a.service.Monitor().GetMonitoredRepositories(r.Context())
+	if err != nil {
+		a.writeError(w, r, err, "get monitored repositories")
+		return nil, nil, false
+	}
+	monitored := make(map[string]models.MonitoredRepository, len(repos))
+	for _, repo := range repos {
+		monitored[repo.FullName] = repo
+	}
+	return group, monitored, true
+}
+
+// groupName returns the name of the requested group, which is stored lowercase
+func groupName(r *http.Request) string {
+	return strings.ToLower(mux.Vars(r)["name"])
+}
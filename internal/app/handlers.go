@@ -3,221 +3,1242 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"github-service/internal/errors"
+	"github-service/internal/github"
+	"github-service/internal/gitlab"
 	"github-service/internal/models"
 	"github-service/internal/response"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github-service/internal/queue"
+	"github-service/internal/service"
 
 	"github.com/gorilla/mux"
 )
 
 // healthCheck handles the health check endpoint
+//
+// @Summary     Health check
+// @Description Report that the service is up
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} response.Response{data=map[string]string}
+// @Router      / [get]
+// @Router      /health [get]
+// @Router      /api/v1/health [get]
 func (a *App) healthCheck(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, response.Success("Service is healthy", map[string]string{"status": "ok"}))
 }
 
 // getCommits handles retrieving commits for a repository
+//
+// @Summary     Get repository commits
+// @Description Get a page of a repository's commits, newest first, optionally filtered by author, date range and SHA prefix. The author must match a name or email exactly, ignoring case; use the search endpoint for partial matches. Commits are streamed as they are read. Pages are selected by number, or by the cursor of meta.next_cursor, which stays fast however deep the page is; pages fetched by cursor are not counted, so their meta only has per_page and next_cursor, empty after the last page. An empty cursor starts at the newest commit.
+// @Tags        commits
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       author query string false "Author name or email, matched exactly ignoring case"
+// @Param       since  query string false "Only commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until  query string false "Only commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Param       sha    query string false "Commit SHA prefix"
+// @Param       page     query int false "Page number (1-based)" default(1)
+// @Param       cursor   query string false "Cursor of the page, from meta.next_cursor; not combined with page"
+// @Param       per_page query int false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=[]models.Commit}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     500 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits [get]
 func (a *App) getCommits(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	owner, repo := vars["owner"], vars["repo"]
 	fullName := fmt.Sprintf("%s/%s", owner, repo)
 
+	query := r.URL.Query()
+
+	filter := models.CommitFilter{
+		Author:    strings.TrimSpace(query.Get("author")),
+		SHAPrefix: strings.TrimSpace(query.Get("sha")),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(r, "since"); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if filter.Until, err = parseTimeParam(r, "until"); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if filter.Since != nil && filter.Until != nil && filter.Since.After(*filter.Until) {
+		response.JSON(w, http.StatusBadRequest, response.Error("since must not be after until"))
+		return
+	}
+	if filter.SHAPrefix != "" && !isHexString(filter.SHAPrefix) {
+		response.JSON(w, http.StatusBadRequest, response.Error("Parameter sha must be a hexadecimal SHA prefix"))
+		return
+	}
+
 	a.log.Debug().
 		Str("owner", owner).
 		Str("repo", repo).
+		Str("author", filter.Author).
+		Str("sha", filter.SHAPrefix).
 		Msg("Getting commits for repository")
 
-	// Parse pagination parameters
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil || page < 1 {
-		page = 1
-	}
+	page, perPage := a.parsePagination(r)
 
-	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
-	if err != nil || perPage < 1 {
-		perPage = 10 // Default page size
+	useCursor := query.Has("cursor")
+	var cursor *models.CommitCursor
+	if useCursor {
+		if query.Has("page") {
+			response.JSON(w, http.StatusBadRequest, response.Error("Parameters page and cursor cannot be combined"))
+			return
+		}
+		if value := query.Get("cursor"); value != "" {
+			if cursor, err = service.ParseCommitCursor(value); err != nil {
+				response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+				return
+			}
+		}
 	}
 
-	commits, totalItems, err := a.service.GetCommitsByRepository(r.Context(), fullName, page, perPage)
+	// Commits are streamed to the client as they are read from the database
+	stream := response.NewStream(w, http.StatusOK, "Commits retrieved successfully", "")
+	var last *models.Commit
+	write := func(commit *models.Commit) error {
+		last = commit
+		return stream.Write(commit)
+	}
+	var totalItems int
+	if useCursor {
+		err = a.service.StreamCommitsAfter(r.Context(), fullName, filter, cursor, perPage, write)
+	} else {
+		totalItems, err = a.service.StreamCommitsByRepository(r.Context(), fullName, filter, page, perPage, write)
+	}
 	if err != nil {
-		a.log.Error().
-			Err(err).
-			Str("repository", fullName).
-			Int("page", page).
-			Int("per_page", perPage).
-			Msg("Failed to get commits")
-		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to get commits: %v", err)))
+		if stream.Started() {
+			a.log.Error().
+				Err(err).
+				Str("repository", fullName).
+				Int("page", page).
+				Int("per_page", perPage).
+				Msg("Failed to stream commits")
+			stream.Abort(fmt.Errorf("failed to get commits"))
+			return
+		}
+		a.writeError(w, r, err, "get commits")
 		return
 	}
 
 	a.log.Info().
 		Str("repository", fullName).
-		Int("commit_count", len(commits)).
+		Int("commit_count", stream.Count()).
 		Int("page", page).
 		Int("per_page", perPage).
 		Int("total_items", totalItems).
 		Msg("Successfully retrieved commits")
 
+	nextCursor := ""
+	if stream.Count() == perPage {
+		nextCursor = service.EncodeCommitCursor(last)
+	}
+	if useCursor {
+		stream.Close(map[string]interface{}{
+			"meta": response.CursorPagination{PerPage: perPage, NextCursor: nextCursor},
+		})
+		return
+	}
+	meta := response.NewPagination(page, perPage, totalItems)
+	if page < meta.TotalPages {
+		meta.NextCursor = nextCursor
+	}
+	stream.Close(map[string]interface{}{"meta": meta})
+}
+
+// searchCommits handles full-text search over a repository's commits
+//
+// @Summary     Search repository commits
+// @Description Full-text search over commit messages with optional author, date range and SHA prefix filters. At least one filter is required.
+// @Tags        commits
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       q      query string false "Full-text query matched against commit messages"
+// @Param       author query string false "Part of an author name or email, matched ignoring case"
+// @Param       since  query string false "Only commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until  query string false "Only commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Param       sha    query string false "Commit SHA prefix"
+// @Param       page     query int false "Page number (1-based)" default(1)
+// @Param       per_page query int false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=[]models.Commit}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits/search [get]
+func (a *App) searchCommits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+	query := r.URL.Query()
+
+	opts := models.CommitSearchOptions{
+		Query:     strings.TrimSpace(query.Get("q")),
+		Author:    strings.TrimSpace(query.Get("author")),
+		SHAPrefix: strings.TrimSpace(query.Get("sha")),
+	}
+
+	var err error
+	if opts.Since, err = parseTimeParam(r, "since"); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if opts.Until, err = parseTimeParam(r, "until"); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if opts.Query == "" && opts.Author == "" && opts.SHAPrefix == "" && opts.Since == nil && opts.Until == nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("At least one of q, author, sha, since or until is required"))
+		return
+	}
+	if opts.SHAPrefix != "" && !isHexString(opts.SHAPrefix) {
+		response.JSON(w, http.StatusBadRequest, response.Error("Parameter sha must be a hexadecimal SHA prefix"))
+		return
+	}
+
+	page, perPage := a.parsePagination(r)
+
+	a.log.Debug().
+		Str("repository", fullName).
+		Str("q", opts.Query).
+		Str("author", opts.Author).
+		Str("sha", opts.SHAPrefix).
+		Msg("Searching commits")
+
+	commits, totalItems, err := a.service.SearchCommits(r.Context(), fullName, opts, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "search commits")
+		return
+	}
+
+	a.log.Info().
+		Str("repository", fullName).
+		Int("commit_count", len(commits)).
+		Int("total_items", totalItems).
+		Msg("Successfully searched commits")
+
 	response.JSON(w, http.StatusOK, response.SuccessPaginated("Commits retrieved successfully", commits, page, perPage, totalItems))
 }
 
-// getTopAuthors handles retrieving top commit authors
+// getCommit handles looking up a single commit by its full or abbreviated SHA
+//
+// @Summary     Get repository commit
+// @Description Look up a stored commit by full or abbreviated SHA (at least 4 characters), returning it with its changed files and the commits made directly before and after it. Ambiguous prefixes are rejected with the matching SHAs.
+// @Tags        commits
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       sha   path string true "Full or abbreviated commit SHA"
+// @Success     200 {object} response.Response{data=models.CommitLookup}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits/{sha} [get]
+func (a *App) getCommit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo, sha := vars["owner"], vars["repo"], vars["sha"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	if !isHexString(sha) {
+		response.JSON(w, http.StatusBadRequest, response.Error("Commit SHA must be hexadecimal"))
+		return
+	}
+
+	lookup, err := a.service.LookupCommit(r.Context(), fullName, sha)
+	if err != nil {
+		a.writeError(w, r, err, "get commit")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit retrieved successfully", lookup))
+}
+
+// getNewCommits handles fetching the commits ingested after a sync run
+//
+// @Summary     Get new commits
+// @Description Get the commits ingested by the finished sync runs after since_run, in ingestion order. Whole runs are returned, as many as fit within limit commits but at least one. Pass the returned cursor as the next since_run to process every commit exactly once; has_more reports whether further runs are ready.
+// @Tags        commits
+// @Produce     json
+// @Param       owner     path  string true  "GitHub repository owner"
+// @Param       repo      path  string true  "GitHub repository name"
+// @Param       since_run query int    false "ID of the last sync run already processed" default(0)
+// @Param       limit     query int    false "Commit budget of the response" default(10)
+// @Success     200 {object} response.Response{data=models.CommitIncrement}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits/new [get]
+func (a *App) getNewCommits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	var sinceRun int64
+	if v := r.URL.Query().Get("since_run"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			response.JSON(w, http.StatusBadRequest, response.Error("since_run must be a non-negative sync run ID"))
+			return
+		}
+		sinceRun = id
+	}
+
+	increment, err := a.service.GetCommitIncrement(r.Context(), fullName, sinceRun, a.parseLimit(r))
+	if err != nil {
+		a.writeError(w, r, err, "get new commits")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("New commits retrieved successfully", increment))
+}
+
+// getLatestCommits handles retrieving the newest commits of a repository for pollers
+//
+// @Summary     Get latest repository commits
+// @Description Get the newest commits of a repository with a strong ETag. Send the ETag back in If-None-Match to get a 304 without a body while nothing changed; the check only reads an index.
+// @Tags        commits
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       count query int false "Number of commits" default(10) maximum(100)
+// @Param       If-None-Match header string false "ETag of a previous response"
+// @Success     200 {object} response.Response{data=[]models.Commit}
+// @Success     304 "Commits unchanged since the ETag"
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits/latest [get]
+func (a *App) getLatestCommits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	count := 10
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			response.JSON(w, http.StatusBadRequest, response.Error("count must be between 1 and 100"))
+			return
+		}
+		count = n
+	}
+
+	// The representation depends on the timestamp format, so the ETag does too
+	format := r.URL.Query().Get("timestamps")
+	if format == "" {
+		format = response.TimestampsRFC3339
+	}
+	etag := func(version string) string {
+		return fmt.Sprintf(`"%s-%s"`, version, format)
+	}
+
+	handleErr := func(err error) {
+		a.writeError(w, r, err, "get latest commits")
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		version, err := a.service.GetLatestCommitsVersion(r.Context(), fullName, count)
+		if err != nil {
+			handleErr(err)
+			return
+		}
+		if response.ETagMatches(ifNoneMatch, etag(version)) {
+			w.Header().Set("ETag", etag(version))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	commits, version, err := a.service.GetLatestCommits(r.Context(), fullName, count)
+	if err != nil {
+		handleErr(err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	response.JSON(w, http.StatusOK, response.Success("Latest commits retrieved successfully", commits))
+}
+
+// getIssues handles retrieving a repository's issues with pagination and a state filter
+//
+// @Summary     Get repository issues
+// @Description Get a page of a repository's synced issues, most recently updated first
+// @Tags        issues
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       state query string false "Issue state" Enums(open, closed, all) default(open)
+// @Param       page     query int false "Page number (1-based)" default(1)
+// @Param       per_page query int false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=[]models.Issue}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/issues [get]
+func (a *App) getIssues(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	state := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("state")))
+	if state == "" {
+		state = models.IssueStateOpen
+	}
+	if state != models.IssueStateOpen && state != models.IssueStateClosed && state != models.IssueStateAll {
+		response.JSON(w, http.StatusBadRequest, response.Error("Parameter state must be one of open, closed or all"))
+		return
+	}
+
+	page, perPage := a.parsePagination(r)
+
+	a.log.Debug().
+		Str("repository", fullName).
+		Str("state", state).
+		Msg("Getting issues for repository")
+
+	issues, totalItems, err := a.service.GetIssuesByRepository(r.Context(), fullName, state, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "get issues")
+		return
+	}
+
+	a.log.Info().
+		Str("repository", fullName).
+		Int("issue_count", len(issues)).
+		Int("total_items", totalItems).
+		Msg("Successfully retrieved issues")
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Issues retrieved successfully", issues, page, perPage, totalItems))
+}
+
+// getReleases handles retrieving a repository's releases with pagination
+//
+// @Summary     Get repository releases
+// @Description Get a page of a repository's synced releases, most recently published first with drafts last. Releases are only synced when github.sync_releases is enabled.
+// @Tags        releases
+// @Produce     json
+// @Param       owner    path  string true  "GitHub repository owner"
+// @Param       repo     path  string true  "GitHub repository name"
+// @Param       page     query int    false "Page number (1-based)" default(1)
+// @Param       per_page query int    false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=[]models.Release}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/releases [get]
+func (a *App) getReleases(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+	page, perPage := a.parsePagination(r)
+
+	releases, totalItems, err := a.service.GetReleasesByRepository(r.Context(), fullName, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "get releases")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Releases retrieved successfully", releases, page, perPage, totalItems))
+}
+
+// getRepositoryStatsHistory handles retrieving a repository's daily stats snapshots
+//
+// @Summary     Get repository stats history
+// @Description Daily snapshots of stars, forks, watchers and open issues, oldest first. A snapshot is recorded whenever the repository is synced; the last sync of a day wins.
+// @Tags        stats
+// @Produce     json
+// @Param       owner path  string true  "GitHub repository owner"
+// @Param       repo  path  string true  "GitHub repository name"
+// @Param       since query string false "First day to include (RFC3339 or YYYY-MM-DD)"
+// @Param       until query string false "Last day to include (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/stats/history [get]
+func (a *App) getRepositoryStatsHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	history, err := a.service.GetRepositoryStatsHistory(r.Context(), fullName, since, until)
+	if err != nil {
+		a.writeError(w, r, err, "get repository stats history")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Repository stats history retrieved successfully", map[string]interface{}{
+		"repository": fullName,
+		"history":    history,
+		"n":          len(history),
+	}))
+}
+
+// getContributorStats handles retrieving the weekly activity of a repository's contributors
+//
+// @Summary     Get repository contributor stats
+// @Description Weekly commits, additions and deletions of a repository's top 100 contributors as computed by GitHub, with their totals over the listed weeks. Contributors are ordered by commits, most first; weeks without activity are left out. Stats are only synced when github.sync_contributor_stats is enabled.
+// @Tags        stats
+// @Produce     json
+// @Param       owner path  string true  "GitHub repository owner"
+// @Param       repo  path  string true  "GitHub repository name"
+// @Param       since query string false "Only weeks starting on or after this day (RFC3339 or YYYY-MM-DD)"
+// @Param       until query string false "Only weeks starting on or before this day (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/contributors [get]
+func (a *App) getContributorStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	contributors, err := a.service.GetContributorStats(r.Context(), fullName, since, until)
+	if err != nil {
+		a.writeError(w, r, err, "get contributor stats")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Contributor stats retrieved successfully", map[string]interface{}{
+		"repository":   fullName,
+		"contributors": contributors,
+		"n":            len(contributors),
+	}))
+}
+
+// getTopAuthors handles retrieving top commit authors with pagination
+//
+// @Summary     Get top commit authors
+// @Description Get a page of the most active commit authors globally or for a specific repository. Across repositories, language limits the ranking to repositories with that primary language and group to the repositories in that group.
+// @Tags        stats
+// @Produce     json
+// @Param       repository query string false "Full repository name (owner/repo)"
+// @Param       language   query string false "Primary repository language, matched case-insensitively (e.g. Go); not combinable with repository"
+// @Param       group      query string false "Repository group name; not combinable with repository"
+// @Param       page     query int false "Page number (1-based)" default(1)
+// @Param       per_page query int false "Number of items per page" default(10)
+// @Param       limit    query int false "Deprecated alias for per_page"
+// @Param       since    query string false "Only count commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until    query string false "Only count commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.PaginatedResponse{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/top-authors [get]
 func (a *App) getTopAuthors(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit <= 0 {
-		limit = 10
+	page, perPage := a.parsePagination(r)
+	// limit is accepted as an alias for per_page for older clients
+	if r.URL.Query().Get("per_page") == "" {
+		if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+			perPage = a.capPerPage(limit)
+		}
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if since != nil && until != nil && until.Before(*since) {
+		response.JSON(w, http.StatusBadRequest, response.Error("until must not be before since"))
+		return
 	}
 
 	// Check if repository is specified
 	repoFullName := r.URL.Query().Get("repository")
+	language := strings.TrimSpace(r.URL.Query().Get("language"))
+	group := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("group")))
+	if repoFullName != "" && language != "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("language cannot be combined with repository"))
+		return
+	}
+	if repoFullName != "" && group != "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("group cannot be combined with repository"))
+		return
+	}
 	var (
-		authors []*models.CommitStats
-		err     error
+		authors    []*models.CommitStats
+		totalItems int
 	)
 
 	a.log.Debug().
-		Int("limit", limit).
+		Int("page", page).
+		Int("per_page", perPage).
 		Str("repository", repoFullName).
+		Str("language", language).
+		Str("group", group).
 		Msg("Getting top authors")
 
 	if repoFullName != "" {
 		// First check if the repository is being monitored
 		if !a.worker.IsRepositoryMonitored(r.Context(), repoFullName) {
-			response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("Repository %s is not being monitored", repoFullName)))
+			response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", repoFullName)))
 			return
 		}
 
 		// Get repository-specific authors
-		authors, err = a.service.GetTopCommitAuthorsByRepository(r.Context(), repoFullName, limit)
+		authors, totalItems, err = a.service.GetTopCommitAuthorsByRepository(r.Context(), repoFullName, since, until, page, perPage)
 		if err != nil {
-			a.log.Error().
-				Err(err).
-				Int("limit", limit).
-				Str("repository", repoFullName).
-				Msg("Failed to get top authors")
-
-			// Handle specific error cases
-			if strings.Contains(err.Error(), "no commits found") {
+			if errors.Is(err, errors.ErrNotFound) && !errors.Is(err, errors.ErrRepositoryNotFound) {
 				response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("No commits found for repository %s", repoFullName)))
 				return
 			}
-
-			response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to get top authors: %v", err)))
+			a.writeError(w, r, err, "get top authors")
 			return
 		}
 	} else {
 		// Get global top authors
-		authors, err = a.service.GetTopCommitAuthors(r.Context(), limit)
+		authors, totalItems, err = a.service.GetTopCommitAuthors(r.Context(), since, until, language, group, accountScope(r.Context()), page, perPage)
 		if err != nil {
-			a.log.Error().
-				Err(err).
-				Int("limit", limit).
-				Msg("Failed to get top authors")
-			response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to get top authors: %v", err)))
+			a.writeError(w, r, err, "get top authors")
 			return
 		}
 	}
 
 	a.log.Info().
 		Int("author_count", len(authors)).
+		Int("total_items", totalItems).
 		Str("repository", repoFullName).
 		Msg("Successfully retrieved top authors")
 
-	response.JSON(w, http.StatusOK, response.Success("Top authors retrieved successfully", map[string]interface{}{
+	data := map[string]interface{}{
 		"authors":    authors,
 		"n":          len(authors),
 		"repository": repoFullName,
+	}
+	if language != "" {
+		data["language"] = language
+	}
+	if group != "" {
+		data["group"] = group
+	}
+	if since != nil {
+		data["since"] = since
+	}
+	if until != nil {
+		data["until"] = until
+	}
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Top authors retrieved successfully", data, page, perPage, totalItems))
+}
+
+// getFileExtensionStats handles aggregating commit file changes by file extension
+//
+// @Summary     Get changes by file extension
+// @Description Aggregate the files changed by a repository's commits by file extension
+// @Tags        stats
+// @Produce     json
+// @Param       repository query string true  "Full repository name (owner/repo)"
+// @Param       since      query string false "Only commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until      query string false "Only commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/file-extensions [get]
+func (a *App) getFileExtensionStats(w http.ResponseWriter, r *http.Request) {
+	repoFullName := r.URL.Query().Get("repository")
+	if repoFullName == "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("repository query parameter is required"))
+		return
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	a.log.Debug().
+		Str("repository", repoFullName).
+		Msg("Getting file extension stats")
+
+	if !a.worker.IsRepositoryMonitored(r.Context(), repoFullName) {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", repoFullName)))
+		return
+	}
+
+	stats, err := a.service.GetFileExtensionStats(r.Context(), repoFullName, since, until)
+	if err != nil {
+		a.writeError(w, r, err, "get file extension stats")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("File extension stats retrieved successfully", map[string]interface{}{
+		"extensions": stats,
+		"count":      len(stats),
+		"repository": repoFullName,
+		"since":      since,
+		"until":      until,
 	}))
 }
 
-// listRepositories handles listing all monitored repositories
-func (a *App) listRepositories(w http.ResponseWriter, r *http.Request) {
-	a.log.Debug().Msg("Listing repositories")
+// getCommitTypeStats handles counting commits by Conventional Commits type
+//
+// @Summary     Get commits by type
+// @Description Count a repository's commits by Conventional Commits type (feat, fix, docs, ...); messages without a type count as other
+// @Tags        stats
+// @Produce     json
+// @Param       repository query string true  "Full repository name (owner/repo)"
+// @Param       since      query string false "Only commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until      query string false "Only commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/commit-types [get]
+func (a *App) getCommitTypeStats(w http.ResponseWriter, r *http.Request) {
+	repoFullName := r.URL.Query().Get("repository")
+	if repoFullName == "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("repository query parameter is required"))
+		return
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	a.log.Debug().
+		Str("repository", repoFullName).
+		Msg("Getting commit type stats")
+
+	if !a.worker.IsRepositoryMonitored(r.Context(), repoFullName) {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", repoFullName)))
+		return
+	}
+
+	stats, err := a.service.GetCommitTypeStats(r.Context(), repoFullName, since, until)
+	if err != nil {
+		a.writeError(w, r, err, "get commit type stats")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit type stats retrieved successfully", map[string]interface{}{
+		"types":      stats,
+		"n":          len(stats),
+		"repository": repoFullName,
+		"since":      since,
+		"until":      until,
+	}))
+}
+
+// getContributionDistribution handles measuring how concentrated a repository's commits are among its authors
+//
+// @Summary     Get contribution distribution
+// @Description Gini coefficient and Lorenz curve of a repository's commits across its authors. A Gini coefficient of 0 means every author made as many commits; it approaches 1 as a single author makes them all. The Lorenz curve gives the share of the commits made by each tenth of the authors with the fewest commits. Commits of merged author identities count toward their canonical identity.
+// @Tags        stats
+// @Produce     json
+// @Param       repository query string true  "Full repository name (owner/repo)"
+// @Param       since      query string false "Only commits on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until      query string false "Only commits on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/contribution-distribution [get]
+func (a *App) getContributionDistribution(w http.ResponseWriter, r *http.Request) {
+	repoFullName := r.URL.Query().Get("repository")
+	if repoFullName == "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("repository query parameter is required"))
+		return
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if !a.worker.IsRepositoryMonitored(r.Context(), repoFullName) {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", repoFullName)))
+		return
+	}
+
+	dist, err := a.service.GetContributionDistribution(r.Context(), repoFullName, since, until)
+	if err != nil {
+		a.writeError(w, r, err, "get contribution distribution")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Contribution distribution retrieved successfully", map[string]interface{}{
+		"distribution": dist,
+		"repository":   repoFullName,
+		"since":        since,
+		"until":        until,
+	}))
+}
+
+// getReleaseCadence handles summarizing how often a repository publishes releases
+//
+// @Summary     Get release cadence
+// @Description Days between a repository's releases, commits per release and the average days from commit to release, over the releases published in the time window. A release ships the commits made after the commit tagged by the previous release, up to the commit its own tag points at. Requires github.sync_releases.
+// @Tags        stats
+// @Produce     json
+// @Param       repository  query string true  "Full repository name (owner/repo)"
+// @Param       since       query string false "Only releases published on or after this time (RFC3339 or YYYY-MM-DD)"
+// @Param       until       query string false "Only releases published on or before this time (RFC3339 or YYYY-MM-DD)"
+// @Param       prereleases query bool   false "Include prereleases" default(false)
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/release-cadence [get]
+func (a *App) getReleaseCadence(w http.ResponseWriter, r *http.Request) {
+	repoFullName := r.URL.Query().Get("repository")
+	if repoFullName == "" {
+		response.JSON(w, http.StatusBadRequest, response.Error("repository query parameter is required"))
+		return
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if since != nil && until != nil && until.Before(*since) {
+		response.JSON(w, http.StatusBadRequest, response.Error("until must not be before since"))
+		return
+	}
+	prereleases := r.URL.Query().Get("prereleases") == "true"
+
+	cadence, err := a.service.GetReleaseCadence(r.Context(), repoFullName, since, until, prereleases)
+	if err != nil {
+		a.writeError(w, r, err, "get release cadence")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Release cadence retrieved successfully", map[string]interface{}{
+		"repository":  repoFullName,
+		"since":       since,
+		"until":       until,
+		"prereleases": prereleases,
+		"cadence":     cadence,
+	}))
+}
+
+// Limits of the top repositories ranking
+const (
+	defaultTopRepositories = 10
+	maxTopRepositories     = 100
+)
+
+// getTopRepositories handles ranking monitored repositories by recent activity
+//
+// @Summary     Get top repositories
+// @Description Get the monitored repositories with the most commits, or the most distinct commit authors, within a recent window, optionally only among the repositories in a group. Merged author identities count as one author.
+// @Tags        stats
+// @Produce     json
+// @Param       metric query string false "Ranking metric" Enums(commits, authors) default(commits)
+// @Param       window query string false "Only count commits made within this duration, e.g. 168h or 7d" default(7d)
+// @Param       limit  query int    false "Number of repositories to return, at most 100" default(10)
+// @Param       group  query string false "Only rank the repositories in this group"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/stats/top-repositories [get]
+func (a *App) getTopRepositories(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	metric := query.Get("metric")
+	switch metric {
+	case "":
+		metric = models.ActivityMetricCommits
+	case models.ActivityMetricCommits, models.ActivityMetricAuthors:
+	default:
+		response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Invalid metric %q: expected commits or authors", metric)))
+		return
+	}
+
+	window := 7 * 24 * time.Hour
+	if value := query.Get("window"); value != "" {
+		parsed, err := parseWindow("window", value)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+			return
+		}
+		window = parsed
+	}
+
+	limit := defaultTopRepositories
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxTopRepositories {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Parameter limit must be between 1 and %d", maxTopRepositories)))
+			return
+		}
+		limit = parsed
+	}
+
+	group := strings.ToLower(strings.TrimSpace(query.Get("group")))
+	since := time.Now().Add(-window)
+	repositories, err := a.service.GetTopRepositories(r.Context(), metric, group, accountScope(r.Context()), since, limit)
+	if err != nil {
+		a.writeError(w, r, err, "get top repositories")
+		return
+	}
+	if repositories == nil {
+		repositories = []*models.RepositoryActivity{}
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Top repositories retrieved successfully", map[string]interface{}{
+		"metric":       metric,
+		"group":        group,
+		"window":       window.String(),
+		"since":        since,
+		"repositories": repositories,
+	}))
+}
+
+// listRepositories handles listing monitored repositories with pagination
+//
+// @Summary     List repositories
+// @Description Get a page of monitored repositories with their details, ordered by name. Repositories whose initial sync hasn't completed are listed with status pending and without details.
+// @Tags        repositories
+// @Produce     json
+// @Param       page     query int false "Page number (1-based)" default(1)
+// @Param       per_page query int false "Number of items per page" default(10)
+// @Success     200 {object} response.PaginatedResponse{data=object}
+// @Failure     500 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories [get]
+func (a *App) listRepositories(w http.ResponseWriter, r *http.Request) {
+	page, perPage := a.parsePagination(r)
+
+	a.log.Debug().
+		Int("page", page).
+		Int("per_page", perPage).
+		Msg("Listing repositories")
+
+	// User sessions list the repositories their account added
+	if account := accountScope(r.Context()); account != 0 {
+		repositories, totalItems, err := a.service.GetAccountRepositoryListings(r.Context(), account, page, perPage)
+		if err != nil {
+			a.writeError(w, r, err, "list repositories")
+			return
+		}
+		response.JSON(w, http.StatusOK, response.SuccessPaginated("Repositories retrieved successfully", map[string]interface{}{
+			"count":        len(repositories),
+			"repositories": repositories,
+		}, page, perPage, totalItems))
+		return
+	}
+
+	totalItems, err := a.service.Monitor().CountMonitoredRepositories(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to count repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to list repositories"))
+		return
+	}
+
+	// Get the requested page of monitored repositories merged with their details
+	repositories, err := a.service.Monitor().GetRepositoryListings(r.Context(), page, perPage)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to list repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to list repositories"))
+		return
+	}
+
+	a.log.Info().
+		Int("repository_count", len(repositories)).
+		Int("total_items", totalItems).
+		Msg("Successfully listed repositories")
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Repositories retrieved successfully", map[string]interface{}{
+		"count":        len(repositories),
+		"repositories": repositories,
+	}, page, perPage, totalItems))
+}
+
+// getRepository handles retrieving a monitored repository
+//
+// @Summary     Get repository
+// @Description Get a monitored repository with its sync state: pending until its initial sync completes, then synced with its details and last sync time
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=models.RepositoryListing}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo} [get]
+func (a *App) getRepository(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
 
-	// Get monitored repositories
-	monitoredRepos, err := a.service.DB().GetMonitoredRepositories(r.Context())
+	listing, err := a.service.Monitor().GetRepositoryListing(r.Context(), fullName)
 	if err != nil {
-		a.log.Error().Err(err).Msg("Failed to list repositories")
-		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to list repositories"))
+		a.log.Error().Err(err).Str("repository", fullName).Msg("Failed to get repository")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get repository"))
+		return
+	}
+	if listing == nil {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", fullName)))
 		return
 	}
 
-	// Get full repository details for each monitored repository
-	var repositories []*models.Repository
-	for _, monitoredRepo := range monitoredRepos {
-		repo, err := a.service.GetRepositoryByName(r.Context(), monitoredRepo.FullName)
+	response.JSON(w, http.StatusOK, response.Success("Repository retrieved successfully", listing))
+}
+
+// repositoryPath is the API path of a monitored repository
+func repositoryPath(fullName string) string {
+	return "/api/v1/repositories/" + fullName
+}
+
+// alreadyMonitored answers a request to add a repository that is already
+// monitored without syncing it again. PUT is idempotent: it answers 200 with the
+// repository's current status, unless a sync of it is still pending or running,
+// which conflicts and is answered with 409 and that job. Adding by URL with POST
+// always conflicts, pointing the client at the existing repository.
+func (a *App) alreadyMonitored(w http.ResponseWriter, r *http.Request, existing *models.RepositoryListing) {
+	path := repositoryPath(existing.FullName)
+	message := fmt.Sprintf("Repository %s is already being monitored", existing.FullName)
+	data := map[string]interface{}{
+		"already_monitored": true,
+		"repository":        existing,
+		"url":               path,
+	}
+
+	if r.Method == http.MethodPut {
+		owner, repo, _ := strings.Cut(existing.FullName, "/")
+		job, err := a.queue.GetActiveJob(r.Context(), queue.SyncDedupeKey(owner, repo))
 		if err != nil {
-			a.log.Error().
-				Err(err).
-				Str("repository", monitoredRepo.FullName).
-				Msg("Failed to get repository details")
-			continue
+			a.log.Error().Err(err).Str("repository", existing.FullName).Msg("Failed to look up sync job")
+			response.JSON(w, http.StatusInternalServerError, response.Error("Failed to add repository"))
+			return
 		}
-		if repo != nil {
-			repositories = append(repositories, repo)
+		if job == nil {
+			response.JSON(w, http.StatusOK, response.Success(message, data))
+			return
 		}
+		message = fmt.Sprintf("Repository %s is already being monitored and synced", existing.FullName)
+		data["job"] = job
 	}
 
-	a.log.Info().
-		Int("repository_count", len(repositories)).
-		Msg("Successfully listed repositories")
+	w.Header().Set("Location", path)
+	response.JSON(w, http.StatusConflict, response.ErrorWithData(message, data))
+}
 
-	response.JSON(w, http.StatusOK, response.Success("Repositories retrieved successfully", map[string]interface{}{
-		"count":        len(repositories),
-		"repositories": repositories,
-	}))
+// providerNames are the display names of the code hosting providers
+var providerNames = map[string]string{
+	models.ProviderGitHub: "GitHub",
+	models.ProviderGitLab: "GitLab",
 }
 
 // addRepository handles adding a new repository to monitor
+//
+// @Summary     Add repository
+// @Description Start monitoring a repository and schedule the sync of its commit history. Only commits within monitor.default_history are synced unless since is given. Adding a repository that is already monitored is idempotent: it is not synced again and answered with 200 and its current status, or with 409, a Location header and the pending or running sync job while its sync has not finished.
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path  string true  "GitHub repository owner"
+// @Param       repo  path  string true  "GitHub repository name"
+// @Param       since query string false "Sync commits made since this time (RFC3339 or YYYY-MM-DD), or full for the full history"
+// @Param       provider query string false "Where the repository is hosted" Enums(github, gitlab) default(github)
+// @Success     200 {object} response.Response{data=object}
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Failure     500 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo} [put]
 func (a *App) addRepository(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	owner, repo := vars["owner"], vars["repo"]
+	a.monitorRepository(w, r, r.URL.Query().Get("provider"), vars["owner"], vars["repo"])
+}
+
+// addRepositoryRequest is the body of a request to monitor a repository by URL
+type addRepositoryRequest struct {
+	URL      string `json:"url" example:"https://github.com/golang/go"`
+	Provider string `json:"provider,omitempty" enums:"github,gitlab" example:"github"` // Defaults to github
+}
+
+// Validate checks that the request names a repository on a known provider
+func (req *addRepositoryRequest) Validate() error {
+	if strings.TrimSpace(req.URL) == "" {
+		return fmt.Errorf("url is required")
+	}
+	if req.Provider != "" && !models.ValidProvider(req.Provider) {
+		return fmt.Errorf("invalid provider %q, expected github or gitlab", req.Provider)
+	}
+	return nil
+}
+
+// addRepositoryFromURL handles adding a new repository to monitor from a GitHub or GitLab URL
+//
+// @Summary     Add repository from URL
+// @Description Start monitoring a repository given a GitHub web or clone URL, or a GitLab one with provider set to gitlab. A repository that is already monitored is left as is and answered with 409, the existing repository and a Location header.
+// @Tags        repositories
+// @Accept      json
+// @Produce     json
+// @Param       request body  addRepositoryRequest true  "Repository URL"
+// @Param       since   query string               false "Sync commits made since this time (RFC3339 or YYYY-MM-DD), or full for the full history"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Failure     429 {object} response.Response
+// @Failure     502 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories [post]
+func (a *App) addRepositoryFromURL(w http.ResponseWriter, r *http.Request) {
+	var req addRepositoryRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	parse := github.ParseRepositoryURL
+	if req.Provider == models.ProviderGitLab {
+		parse = gitlab.ParseProjectURL
+	}
+	owner, repo, err := parse(req.URL)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ErrorCode(response.CodeInvalidRepository, fmt.Sprintf("Invalid repository URL: %v", err)))
+		return
+	}
+	if err := validateRepositoryName(owner, repo); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ErrorCode(err.code, err.message))
+		return
+	}
+
+	a.monitorRepository(w, r, req.Provider, owner, repo)
+}
+
+// monitorRepository validates a repository on its provider, syncs it and
+// schedules the sync of its history. An empty provider is GitHub.
+func (a *App) monitorRepository(w http.ResponseWriter, r *http.Request, provider, owner, repo string) {
+	since, err := a.historySince(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	if provider == "" {
+		provider = models.ProviderGitHub
+	}
+	if !models.ValidProvider(provider) {
+		response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Invalid provider %q, expected github or gitlab", provider)))
+		return
+	}
+	if !a.service.ProviderEnabled(provider) {
+		response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Provider %s is not configured", provider)))
+		return
+	}
 
 	a.log.Debug().
 		Str("owner", owner).
 		Str("repo", repo).
+		Time("since", since).
 		Msg("Adding repository")
 
-	// First check if repository exists in GitHub without syncing commits
-	exists, err := a.service.RepositoryExists(r.Context(), owner, repo)
+	// Adding a monitored repository again would only repeat its initial sync
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+	existing, err := a.service.Monitor().GetRepositoryListing(r.Context(), fullName)
 	if err != nil {
-		a.log.Error().
-			Err(err).
-			Str("owner", owner).
-			Str("repo", repo).
-			Msg("Failed to validate repository")
-
-		if strings.Contains(strings.ToLower(err.Error()), "rate limit") {
-			response.JSON(w, http.StatusTooManyRequests, response.Error("GitHub rate limit exceeded, please try again later"))
-			return
+		a.log.Error().Err(err).Str("repository", fullName).Msg("Failed to look up monitored repository")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to add repository"))
+		return
+	}
+	account := accountScope(r.Context())
+	if existing != nil {
+		// A user session adding a repository another account added shares it
+		if account != 0 {
+			if err := a.service.AddAccountRepository(r.Context(), account, fullName); err != nil {
+				a.writeError(w, r, err, "add repository")
+				return
+			}
 		}
+		a.alreadyMonitored(w, r, existing)
+		return
+	}
 
-		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to validate repository: %v", err)))
+	// First check if repository exists on its provider without syncing commits
+	exists, err := a.service.RepositoryExists(r.Context(), provider, owner, repo)
+	if err != nil {
+		a.writeError(w, r, err, "validate repository")
 		return
 	}
 
 	if !exists {
-		response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("Repository %s/%s not found on GitHub", owner, repo)))
+		response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("Repository %s/%s not found on %s", owner, repo, providerNames[provider])))
 		return
 	}
 
-	// Get repository information from GitHub and sync it to our database
-	if err := a.service.SyncRepository(r.Context(), owner, repo, time.Now().AddDate(0, 0, -7)); err != nil {
-		a.log.Error().
-			Err(err).
-			Str("owner", owner).
-			Str("repo", repo).
-			Msg("Failed to sync repository")
-		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to sync repository: %v", err)))
+	// Get repository information from the provider and sync it to our database
+	if err := a.service.SyncRepositoryFrom(r.Context(), provider, owner, repo, since); err != nil {
+		a.writeError(w, r, err, "sync repository")
 		return
 	}
 
 	// Add to monitoring list
-	if err := a.worker.AddRepository(r.Context(), owner, repo); err != nil {
+	if err := a.worker.AddRepository(r.Context(), provider, owner, repo, since); err != nil {
 		a.log.Error().
 			Err(err).
 			Str("owner", owner).
@@ -226,12 +1247,21 @@ func (a *App) addRepository(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to add repository to monitoring: %v", err)))
 		return
 	}
+	if account != 0 {
+		if err := a.service.AddAccountRepository(r.Context(), account, fullName); err != nil {
+			a.writeError(w, r, err, "add repository")
+			return
+		}
+	}
 
-	// Create a sync job for full history
+	// Create a sync job for the requested history
 	payload := queue.SyncPayload{
 		Owner: owner,
 		Repo:  repo,
 	}
+	if !since.IsZero() {
+		payload.Since = &since
+	}
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -243,11 +1273,12 @@ func (a *App) addRepository(w http.ResponseWriter, r *http.Request) {
 	}
 
 	job := &queue.Job{
-		Type:    queue.JobTypeSync,
-		Payload: payloadBytes,
+		Type:      queue.JobTypeSync,
+		Payload:   payloadBytes,
+		DedupeKey: queue.SyncDedupeKey(owner, repo),
 	}
 
-	if err := a.queue.Enqueue(job); err != nil {
+	if err := a.enqueue(r.Context(), job); err != nil {
 		a.log.Error().
 			Err(err).
 			Str("owner", owner).
@@ -257,18 +1288,50 @@ func (a *App) addRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	data := map[string]interface{}{
+		"job_id":   job.ID,
+		"status":   scheduleStatus(job),
+		"owner":    owner,
+		"repo":     repo,
+		"provider": provider,
+		"since":    payload.Since,
+	}
+
+	// Issues are synced by a separate job so a failure doesn't hold up commits
+	if a.service.IssuesEnabled() && provider == models.ProviderGitHub {
+		issuesJob := &queue.Job{
+			Type:    queue.JobTypeIssues,
+			Payload: payloadBytes,
+		}
+		if err := a.enqueue(r.Context(), issuesJob); err != nil {
+			a.log.Warn().
+				Err(err).
+				Str("owner", owner).
+				Str("repo", repo).
+				Msg("Failed to enqueue issues sync job")
+		} else {
+			data["issues_job_id"] = issuesJob.ID
+		}
+	}
+
 	response.JSON(w, http.StatusAccepted, response.Success(
 		fmt.Sprintf("Repository %s/%s scheduled for synchronization", owner, repo),
-		map[string]interface{}{
-			"job_id": job.ID,
-			"status": "scheduled",
-			"owner":  owner,
-			"repo":   repo,
-		},
+		data,
 	))
 }
 
 // removeRepository handles removing a repository from monitoring
+//
+// @Summary     Remove repository
+// @Description Stop monitoring a repository and delete its stored data. With monitor.deleted_retention set, the data is kept for that long and the repository can be restored until then. For a user session, the repository is removed from the account's repositories and stays monitored while other accounts have it.
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=map[string]string}
+// @Failure     500 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo} [delete]
 func (a *App) removeRepository(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	owner, repo := vars["owner"], vars["repo"]
@@ -279,10 +1342,29 @@ func (a *App) removeRepository(w http.ResponseWriter, r *http.Request) {
 		Str("repo", repo).
 		Msg("Removing repository")
 
+	if account := accountScope(r.Context()); account != 0 {
+		remaining, err := a.service.RemoveAccountRepository(r.Context(), account, fullName)
+		if err != nil {
+			a.writeError(w, r, err, "remove repository")
+			return
+		}
+		if remaining > 0 {
+			response.JSON(w, http.StatusOK, response.Success(
+				fmt.Sprintf("Repository %s/%s removed from your repositories; it stays monitored for other accounts", owner, repo),
+				map[string]string{"owner": owner, "repo": repo},
+			))
+			return
+		}
+	}
+
 	// First remove from worker's monitoring list
 	a.worker.RemoveRepository(r.Context(), owner, repo)
 
-	// Then remove from database
+	// Then remove from database, or mark it removed while its data is retained
+	data := map[string]string{
+		"owner": owner,
+		"repo":  repo,
+	}
 	dbRepo, err := a.service.GetRepositoryByName(r.Context(), fullName)
 	if err != nil {
 		a.log.Error().
@@ -299,6 +1381,9 @@ func (a *App) removeRepository(w http.ResponseWriter, r *http.Request) {
 			response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to delete repository %s: %v", fullName, err)))
 			return
 		}
+		if retention := a.service.DeletedRetention(); retention > 0 {
+			data["retained_until"] = time.Now().Add(retention).UTC().Format(time.RFC3339)
+		}
 	}
 
 	a.log.Info().
@@ -308,27 +1393,166 @@ func (a *App) removeRepository(w http.ResponseWriter, r *http.Request) {
 
 	response.JSON(w, http.StatusOK, response.Success(
 		fmt.Sprintf("Repository %s/%s removed successfully", owner, repo),
-		map[string]string{
-			"owner": owner,
-			"repo":  repo,
+		data,
+	))
+}
+
+// restoreRepository handles restoring a removed repository whose data is retained
+//
+// @Summary     Restore repository
+// @Description Undo the removal of a repository within the monitor.deleted_retention window, restoring its stored data and resuming its monitoring. The next scheduled sync fetches the commits made since its last sync.
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=models.Repository}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/restore [post]
+func (a *App) restoreRepository(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	restored, err := a.service.RestoreRepository(r.Context(), fullName)
+	if err != nil {
+		a.writeError(w, r, err, "restore repository")
+		return
+	}
+
+	if err := a.worker.EnrollRepository(r.Context(), restored.Provider, owner, repo); err != nil {
+		a.log.Error().Err(err).Str("repository", fullName).Msg("Failed to resume monitoring of restored repository")
+		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Restored %s but failed to resume monitoring: %v", fullName, err)))
+		return
+	}
+
+	a.log.Info().Str("repository", fullName).Msg("Repository restored")
+	response.JSON(w, http.StatusOK, response.Success(fmt.Sprintf("Repository %s restored successfully", fullName), restored))
+}
+
+// pauseRepository handles pausing the monitoring of a repository
+//
+// @Summary     Pause repository
+// @Description Stop syncing a monitored repository while keeping its stored data, unlike removing it. Paused repositories are left out of the repository listing until resumed.
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/pause [post]
+func (a *App) pauseRepository(w http.ResponseWriter, r *http.Request) {
+	a.setRepositoryMonitoring(w, r, false)
+}
+
+// resumeRepository handles resuming the monitoring of a paused repository
+//
+// @Summary     Resume repository
+// @Description Resume syncing a paused repository. The next scheduled sync fetches the commits made since its last sync.
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/resume [post]
+func (a *App) resumeRepository(w http.ResponseWriter, r *http.Request) {
+	a.setRepositoryMonitoring(w, r, true)
+}
+
+// setRepositoryMonitoring pauses or resumes the monitoring of the requested repository
+func (a *App) setRepositoryMonitoring(w http.ResponseWriter, r *http.Request, active bool) {
+	vars := mux.Vars(r)
+	owner, repo := vars["owner"], vars["repo"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	var err error
+	if active {
+		err = a.worker.ResumeRepository(r.Context(), owner, repo)
+	} else {
+		err = a.worker.PauseRepository(r.Context(), owner, repo)
+	}
+	if err != nil {
+		a.writeError(w, r, err, "update repository monitoring")
+		return
+	}
+
+	state := "paused"
+	if active {
+		state = "resumed"
+	}
+	a.log.Info().Str("repository", fullName).Msg("Repository monitoring " + state)
+
+	response.JSON(w, http.StatusOK, response.Success(
+		fmt.Sprintf("Monitoring of %s %s", fullName, state),
+		map[string]interface{}{
+			"owner":  owner,
+			"repo":   repo,
+			"active": active,
 		},
 	))
 }
 
+// resyncRepositoryRequest is the optional body of a resync request
+type resyncRepositoryRequest struct {
+	Since string `json:"since,omitempty" example:"2024-01-01T00:00:00Z"`
+	Full  bool   `json:"full,omitempty" example:"false"`
+}
+
 // resyncRepository handles repository resynchronization with a specific time
+//
+// @Summary     Resync repository
+// @Description Schedule a resynchronization of a monitored repository, at most once per monitor.min_resync_interval. Commits made since the given time (RFC3339 or YYYY-MM-DD) are fetched, or the full history when full is set; without a body the default history window is used.
+// @Tags        repositories
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string                  true  "GitHub repository owner"
+// @Param       repo    path string                  true  "GitHub repository name"
+// @Param       request body resyncRepositoryRequest false "History to resync"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     429 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/sync [post]
 func (a *App) resyncRepository(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	owner, repo := vars["owner"], vars["repo"]
 	fullName := fmt.Sprintf("%s/%s", owner, repo)
 
+	since, err := a.resyncSince(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
 	a.log.Debug().
 		Str("owner", owner).
 		Str("repo", repo).
+		Time("since", since).
 		Msg("Resyncing repository")
 
 	// Check if repository is being monitored
 	if !a.worker.IsRepositoryMonitored(r.Context(), fullName) {
-		response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("Repository %s is not being monitored", fullName)))
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s is not being monitored", fullName)))
+		return
+	}
+
+	wait, err := a.service.ClaimResync(r.Context(), fullName)
+	if err != nil {
+		a.log.Error().
+			Err(err).
+			Str("repository", fullName).
+			Msg("Failed to claim resync")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Internal server error"))
+		return
+	}
+	if wait > 0 {
+		wait = wait.Truncate(time.Second) + time.Second // Round up to whole seconds
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+		response.JSON(w, http.StatusTooManyRequests, response.Error(fmt.Sprintf("Repository %s was resynced recently, try again in %s", fullName, wait)))
 		return
 	}
 
@@ -336,6 +1560,7 @@ func (a *App) resyncRepository(w http.ResponseWriter, r *http.Request) {
 	payload := queue.SyncPayload{
 		Owner: owner,
 		Repo:  repo,
+		Since: &since,
 	}
 
 	payloadBytes, err := json.Marshal(payload)
@@ -347,12 +1572,15 @@ func (a *App) resyncRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A client waits on a manual resync, so it goes ahead of background syncs
 	job := &queue.Job{
-		Type:    queue.JobTypeResync,
-		Payload: payloadBytes,
+		Type:      queue.JobTypeResync,
+		Payload:   payloadBytes,
+		DedupeKey: queue.SyncDedupeKey(owner, repo),
+		Priority:  queue.PriorityHigh,
 	}
 
-	if err := a.queue.Enqueue(job); err != nil {
+	if err := a.enqueue(r.Context(), job); err != nil {
 		a.log.Error().
 			Err(err).
 			Str("owner", owner).
@@ -366,13 +1594,180 @@ func (a *App) resyncRepository(w http.ResponseWriter, r *http.Request) {
 		fmt.Sprintf("Repository %s/%s scheduled for resynchronization", owner, repo),
 		map[string]interface{}{
 			"job_id": job.ID,
-			"status": "scheduled",
+			"status": scheduleStatus(job),
 			"owner":  owner,
 			"repo":   repo,
+			"since":  since,
 		},
 	))
 }
 
+// commitsSinceRequest is the body of a request to set a repository's commits_since override
+type commitsSinceRequest struct {
+	// RFC3339 timestamp or YYYY-MM-DD date; null clears the override
+	CommitsSince *string `json:"commits_since" example:"2024-01-01T00:00:00Z"`
+}
+
+// getCommitsSince handles retrieving a repository's commits_since override
+//
+// @Summary     Get commits_since override
+// @Description Get the lower bound for commit syncs of a repository; null when syncs use the default history window
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits-since [get]
+func (a *App) getCommitsSince(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	since, err := a.service.GetCommitsSince(r.Context(), fullName)
+	if err != nil {
+		a.writeError(w, r, err, "access commits since override")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commits since retrieved successfully", map[string]interface{}{
+		"repository":    fullName,
+		"commits_since": since,
+	}))
+}
+
+// setCommitsSince handles setting or clearing a repository's commits_since override
+//
+// @Summary     Set commits_since override
+// @Description Set the lower bound for commit syncs of a repository. Commits older than it are never fetched. Setting null clears the override.
+// @Tags        repositories
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string              true "GitHub repository owner"
+// @Param       repo    path string              true "GitHub repository name"
+// @Param       request body commitsSinceRequest true "New override"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits-since [put]
+func (a *App) setCommitsSince(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	var req commitsSinceRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	var since *time.Time
+	if req.CommitsSince != nil && *req.CommitsSince != "" {
+		t, err := parseTimeValue(*req.CommitsSince)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Invalid commits_since %q: %v", *req.CommitsSince, err)))
+			return
+		}
+		if t.After(time.Now()) {
+			response.JSON(w, http.StatusBadRequest, response.Error("commits_since cannot be in the future"))
+			return
+		}
+		since = t
+	}
+
+	a.updateCommitsSince(w, r, fullName, since)
+}
+
+// clearCommitsSince handles removing a repository's commits_since override
+//
+// @Summary     Clear commits_since override
+// @Description Remove the lower bound for commit syncs so they fall back to the default history window
+// @Tags        repositories
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/commits-since [delete]
+func (a *App) clearCommitsSince(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	a.updateCommitsSince(w, r, fmt.Sprintf("%s/%s", vars["owner"], vars["repo"]), nil)
+}
+
+// updateCommitsSince stores a repository's commits_since override and writes the response
+func (a *App) updateCommitsSince(w http.ResponseWriter, r *http.Request, fullName string, since *time.Time) {
+	if err := a.service.SetCommitsSince(r.Context(), fullName, since); err != nil {
+		a.writeError(w, r, err, "access commits since override")
+		return
+	}
+
+	a.log.Info().
+		Str("repository", fullName).
+		Interface("commits_since", since).
+		Msg("Updated commits since override")
+
+	response.JSON(w, http.StatusOK, response.Success("Commits since updated successfully", map[string]interface{}{
+		"repository":    fullName,
+		"commits_since": since,
+	}))
+}
+
+// getRateLimit handles retrieving the GitHub API rate limit status
+//
+// @Summary     GitHub rate limit status
+// @Description Current GitHub API rate limit of the service's client. When several tokens are configured, it is that of the token used next, and tokens lists each of them.
+// @Tags        github
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/github/rate-limit [get]
+func (a *App) getRateLimit(w http.ResponseWriter, r *http.Request) {
+	info := a.service.GetRateLimitInfo()
+	now := time.Now()
+
+	resetIn := time.Duration(0)
+	if info.Reset.After(now) {
+		resetIn = info.Reset.Sub(now).Round(time.Second)
+	}
+
+	data := map[string]interface{}{
+		"remaining": info.Remaining,
+		"limit":     info.Limit,
+		"reset":     info.Reset.UTC().Format(time.RFC3339),
+		"reset_in":  resetIn.String(),
+		"throttled": info.Throttled(now),
+	}
+	if tokens := a.service.GetTokenRateLimits(); tokens != nil {
+		data["tokens"] = tokens
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Rate limit retrieved successfully", data))
+}
+
+// getTokenStatus handles retrieving the expiry of the GitHub token
+//
+// @Summary     GitHub token status
+// @Description Expiry of the service's GitHub token as reported by GitHub; expires_at is null for tokens that don't expire
+// @Tags        github
+// @Produce     json
+// @Success     200 {object} response.Response{data=models.TokenStatus}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/github/token [get]
+func (a *App) getTokenStatus(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.Success("Token status retrieved successfully", a.service.GetTokenStatus()))
+}
+
+// getJobStatus handles retrieving the status of a job
+//
+// @Summary     Get job status
+// @Description Returns the job's status and, once a sync or resync job has run, its result
+// @Tags        jobs
+// @Produce     json
+// @Param       job_id path string true "Job ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/jobs/{job_id} [get]
 func (a *App) getJobStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID := vars["job_id"]
@@ -381,52 +1776,183 @@ func (a *App) getJobStatus(w http.ResponseWriter, r *http.Request) {
 		Str("job_id", jobID).
 		Msg("Getting job status")
 
-	status, err := a.queue.GetStatus(jobID)
+	job, err := a.queue.GetJob(r.Context(), jobID)
 	if err != nil {
-		a.log.Error().
-			Err(err).
-			Str("job_id", jobID).
-			Msg("Failed to get job status")
-
-		if strings.Contains(err.Error(), "job not found") {
-			response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("Job %s not found", jobID)))
-			return
-		}
-
-		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to get job status: %v", err)))
+		a.writeError(w, r, err, "get job status")
 		return
 	}
 
 	a.log.Info().
 		Str("job_id", jobID).
-		Str("status", string(status)).
+		Str("status", string(job.Status)).
 		Msg("Successfully retrieved job status")
 
-	response.JSON(w, http.StatusOK, response.Success("Job status retrieved successfully", map[string]interface{}{
+	data := map[string]interface{}{
 		"job_id": jobID,
-		"status": status,
-	}))
+		"status": job.Status,
+	}
+	if len(job.Result) > 0 {
+		data["result"] = job.Result
+	}
+	response.JSON(w, http.StatusOK, response.Success("Job status retrieved successfully", data))
 }
 
 // listJobs handles retrieving all jobs
+//
+// @Summary     List jobs
+// @Description List all jobs, newest first. Jobs are streamed as they are read.
+// @Tags        jobs
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/jobs [get]
 func (a *App) listJobs(w http.ResponseWriter, r *http.Request) {
 	a.log.Debug().Msg("Listing all jobs")
 
-	jobs, err := a.queue.GetJobs()
+	stream := response.NewStream(w, http.StatusOK, "Jobs retrieved successfully", "jobs")
+	err := a.queue.StreamJobs(r.Context(), func(job *queue.Job) error {
+		return stream.Write(job)
+	})
 	if err != nil {
 		a.log.Error().
 			Err(err).
 			Msg("Failed to get jobs")
+		if stream.Started() {
+			stream.Abort(fmt.Errorf("failed to get jobs"))
+			return
+		}
 		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to get jobs: %v", err)))
 		return
 	}
 
 	a.log.Info().
-		Int("job_count", len(jobs)).
+		Int("job_count", stream.Count()).
 		Msg("Successfully retrieved jobs")
 
-	response.JSON(w, http.StatusOK, response.Success("Jobs retrieved successfully", map[string]interface{}{
-		"jobs":  jobs,
-		"count": len(jobs),
-	}))
+	stream.Close(map[string]interface{}{
+		"count": stream.Count(),
+	})
+}
+
+// parsePagination reads the page and per_page query parameters, falling back to
+// the first page and the configured page size. List endpoints share it, so
+// per_page is capped at server.max_per_page everywhere.
+func (a *App) parsePagination(r *http.Request) (page, perPage int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err = strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = a.cfg.Server.DefaultPerPage
+	}
+
+	return page, a.capPerPage(perPage)
+}
+
+// capPerPage reduces a page size to server.max_per_page
+func (a *App) capPerPage(perPage int) int {
+	return min(perPage, a.cfg.Server.MaxPerPage)
+}
+
+// parseLimit reads the limit query parameter of endpoints returning a single
+// batch, sized like a page of the list endpoints
+func (a *App) parseLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = a.cfg.Server.DefaultPerPage
+	}
+	return a.capPerPage(limit)
+}
+
+// parseTimeParam parses an optional RFC3339 (or YYYY-MM-DD) timestamp query parameter
+func parseTimeParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := parseTimeValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s parameter %q: %w", name, value, err)
+	}
+	return t, nil
+}
+
+// historySince reads the since query parameter of a request to add a repository.
+// It defaults to the configured history depth; "full" requests the full history,
+// returned as the zero time.
+func (a *App) historySince(r *http.Request) (time.Time, error) {
+	value := strings.TrimSpace(r.URL.Query().Get("since"))
+	switch strings.ToLower(value) {
+	case "":
+		return a.service.DefaultSince(), nil
+	case "full":
+		return time.Time{}, nil
+	}
+
+	since, err := parseTimeValue(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since parameter %q: expected RFC3339 timestamp, YYYY-MM-DD date or \"full\"", value)
+	}
+	return *since, nil
+}
+
+// scheduleStatus describes the outcome of enqueueing a job that may have been
+// deduplicated against an active job, whose ID the job then carries, or
+// quarantined because its payload keeps crashing the workers
+func scheduleStatus(job *queue.Job) string {
+	if job.Existing {
+		return "already_scheduled"
+	}
+	if job.Status == queue.JobStatusQuarantined {
+		return string(queue.JobStatusQuarantined)
+	}
+	return "scheduled"
+}
+
+// resyncSince resolves the history to resync from the optional request body:
+// the default window without a body, the zero time for the full history
+func (a *App) resyncSince(r *http.Request) (time.Time, error) {
+	var req resyncRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return time.Time{}, fmt.Errorf("invalid request body")
+	}
+
+	value := strings.TrimSpace(req.Since)
+	switch {
+	case req.Full && value != "":
+		return time.Time{}, fmt.Errorf("since and full are mutually exclusive")
+	case req.Full:
+		return time.Time{}, nil
+	case value == "":
+		return a.service.DefaultSince(), nil
+	}
+
+	since, err := parseTimeValue(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q: expected RFC3339 timestamp or YYYY-MM-DD date", value)
+	}
+	return *since, nil
+}
+
+// parseTimeValue parses an RFC3339 timestamp or a YYYY-MM-DD date
+func parseTimeValue(value string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("expected RFC3339 timestamp or YYYY-MM-DD date")
+}
+
+// isHexString reports whether s only contains hexadecimal characters
+func isHexString(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
 }
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// commitHookRequest is the body of a request to create or replace a commit hook
+type commitHookRequest struct {
+	WebhookURL string `json:"webhook_url" example:"https://example.com/hooks/deploy"`
+	// Author names or emails; commits by anyone else are not delivered
+	Authors []string `json:"authors" example:"octocat@github.com"`
+	// Glob patterns or directories; only commits changing a matching file are delivered
+	Paths []string `json:"paths" example:"deploy/,*.tf"`
+}
+
+// hook converts the request to a commit hook
+func (req commitHookRequest) hook() *models.CommitHook {
+	return &models.CommitHook{
+		WebhookURL: strings.TrimSpace(req.WebhookURL),
+		Authors:    req.Authors,
+		Paths:      req.Paths,
+	}
+}
+
+// listCommitHooks handles listing a repository's commit hooks
+//
+// @Summary     List commit hooks
+// @Description Hooks that receive the commits ingested by each sync of a repository, in batches, optionally filtered by author or changed path.
+// @Tags        hooks
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/hooks [get]
+func (a *App) listCommitHooks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	hooks, err := a.service.ListCommitHooks(r.Context(), fullName)
+	if err != nil {
+		a.writeError(w, r, err, "access commit hooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit hooks retrieved successfully", map[string]interface{}{
+		"repository": fullName,
+		"hooks":      hooks,
+		"n":          len(hooks),
+	}))
+}
+
+// createCommitHook handles adding a commit hook to a repository
+//
+// @Summary     Create commit hook
+// @Description Later syncs post the new commits matching the hook's filters to its webhook, at most 100 per request. Path filters need github.fetch_commit_files; without it no commit matches them.
+// @Tags        hooks
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string            true "GitHub repository owner"
+// @Param       repo    path string            true "GitHub repository name"
+// @Param       request body commitHookRequest true "Hook definition"
+// @Success     201 {object} response.Response{data=models.CommitHook}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/hooks [post]
+func (a *App) createCommitHook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	var req commitHookRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	hook := req.hook()
+	if err := a.service.CreateCommitHook(r.Context(), fullName, hook); err != nil {
+		a.writeError(w, r, err, "access commit hooks")
+		return
+	}
+
+	a.log.Info().
+		Str("repository", fullName).
+		Int64("hook_id", hook.ID).
+		Msg("Created commit hook")
+
+	response.JSON(w, http.StatusCreated, response.Success("Commit hook created successfully", hook))
+}
+
+// getCommitHook handles retrieving a commit hook
+//
+// @Summary     Get commit hook
+// @Tags        hooks
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       id    path int    true "Hook ID"
+// @Success     200 {object} response.Response{data=models.CommitHook}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/hooks/{id} [get]
+func (a *App) getCommitHook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid hook id"))
+		return
+	}
+
+	hook, err := a.service.GetCommitHook(r.Context(), fullName, id)
+	if err != nil {
+		a.writeError(w, r, err, "access commit hooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit hook retrieved successfully", hook))
+}
+
+// updateCommitHook handles replacing the definition of a commit hook
+//
+// @Summary     Update commit hook
+// @Tags        hooks
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string            true "GitHub repository owner"
+// @Param       repo    path string            true "GitHub repository name"
+// @Param       id      path int               true "Hook ID"
+// @Param       request body commitHookRequest true "Hook definition"
+// @Success     200 {object} response.Response{data=models.CommitHook}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/hooks/{id} [put]
+func (a *App) updateCommitHook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid hook id"))
+		return
+	}
+
+	var req commitHookRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	hook := req.hook()
+	hook.ID = id
+	if err := a.service.UpdateCommitHook(r.Context(), fullName, hook); err != nil {
+		a.writeError(w, r, err, "access commit hooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit hook updated successfully", hook))
+}
+
+// deleteCommitHook handles removing a commit hook
+//
+// @Summary     Delete commit hook
+// @Tags        hooks
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       id    path int    true "Hook ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/hooks/{id} [delete]
+func (a *App) deleteCommitHook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid hook id"))
+		return
+	}
+
+	if err := a.service.DeleteCommitHook(r.Context(), fullName, id); err != nil {
+		a.writeError(w, r, err, "access commit hooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Commit hook deleted successfully", map[string]interface{}{
+		"id": id,
+	}))
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github-service/internal/response"
+	"github-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImportSize bounds the size of an uploaded commit dump
+const maxImportSize = 256 << 20
+
+// importCommits handles importing commits from a dump into a stored repository
+//
+// @Summary     Import commits
+// @Description Store the commits of a CSV or NDJSON dump, e.g. produced by git log in an air-gapped environment, in a repository the token cannot access. The repository must already be stored. CSV dumps need a header row; both formats use the fields sha, author_name, author_email, author_date, committer_name, committer_email, commit_date, message and url, of which sha and author_date are required. Commits are deduplicated by SHA and invalid records are reported without failing the import.
+// @Tags        admin
+// @Accept      text/csv
+// @Accept      application/x-ndjson
+// @Produce     json
+// @Param       owner  path  string true  "Repository owner"
+// @Param       repo   path  string true  "Repository name"
+// @Param       format query string false "Dump format, csv or ndjson (default from Content-Type)"
+// @Success     200 {object} response.Response{data=models.CommitImportResult}
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/repositories/{owner}/{repo}/import [post]
+func (a *App) importCommits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormat(r.Header.Get("Content-Type"))
+	}
+
+	result, err := a.service.ImportCommits(r.Context(), fullName, format, http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		a.writeError(w, r, err, "import commits")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success(
+		fmt.Sprintf("Imported %d commits into %s", result.Imported, fullName),
+		result,
+	))
+}
+
+// importFormat derives the format of a commit dump from its content type
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return service.ImportFormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/jsonlines":
+		return service.ImportFormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,455 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+	"github-service/internal/queue"
+	"github-service/internal/response"
+	"github-service/internal/service"
+	"github-service/internal/tracing"
+
+	"github.com/gorilla/mux"
+)
+
+// enqueueJobRequest is the body of a request to enqueue a job
+type enqueueJobRequest struct {
+	Type    queue.JobType   `json:"type" enums:"resync,sync_issues,report,cleanup,maintenance" example:"resync"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object"`
+	// RFC3339 timestamp or YYYY-MM-DD date the job runs at; at once when unset
+	RunAt string `json:"run_at,omitempty" example:"2024-01-01T02:00:00Z"`
+	// Retries after a failure, at least 1; the queue default when unset
+	MaxRetries *int `json:"max_retries,omitempty" example:"3"`
+}
+
+// maxJobRetries bounds the retries clients may ask for
+const maxJobRetries = 10
+
+// Validate checks the fields that don't depend on the job type
+func (req *enqueueJobRequest) Validate() error {
+	if req.Type == "" {
+		return fmt.Errorf("type is required, expected one of %s", strings.Join(enqueueableJobTypes(), ", "))
+	}
+	if req.MaxRetries != nil && (*req.MaxRetries < 1 || *req.MaxRetries > maxJobRetries) {
+		return fmt.Errorf("max_retries must be between 1 and %d", maxJobRetries)
+	}
+	return nil
+}
+
+// jobSpec describes a job type clients may enqueue
+type jobSpec struct {
+	role  models.Role // Role required to enqueue the job
+	build func(a *App, ctx context.Context, payload json.RawMessage) (*queue.Job, error)
+}
+
+// enqueueableJobs are the job types accepted by POST /api/v1/jobs. Each builder
+// validates the payload and returns the job with its dedupe key, so a job
+// enqueued here is deduplicated against the same job enqueued by its own endpoint.
+var enqueueableJobs = map[queue.JobType]jobSpec{
+	queue.JobTypeResync:      {role: models.RoleWriter, build: (*App).buildResyncJob},
+	queue.JobTypeIssues:      {role: models.RoleWriter, build: (*App).buildIssuesJob},
+	queue.JobTypeReport:      {role: models.RoleWriter, build: (*App).buildReportJob},
+	queue.JobTypeDigest:      {role: models.RoleWriter, build: (*App).buildDigestJob},
+	queue.JobTypeCleanup:     {role: models.RoleAdmin, build: (*App).buildCleanupJob},
+	queue.JobTypeMaintenance: {role: models.RoleAdmin, build: (*App).buildMaintenanceJob},
+}
+
+// resyncTooSoonError is returned when a repository was resynced within the minimum resync interval
+type resyncTooSoonError struct {
+	fullName string
+	wait     time.Duration
+}
+
+func (e *resyncTooSoonError) Error() string {
+	return fmt.Sprintf("Repository %s was resynced recently, try again in %s", e.fullName, e.wait)
+}
+
+// enqueueJob handles enqueuing a job of any supported type
+//
+// @Summary     Enqueue job
+// @Description Enqueue a job with a validated payload, optionally at a later time. resync, sync_issues, report and digest take {"owner", "repo"} (plus "since" for resync, "month" for report and "period" and optionally "start" for digest) and require the writer role; cleanup takes {} and maintenance {"tasks": ["analyze", "reindex"]}, both requiring the admin role. A job matching a pending or running one is not enqueued twice; its ID is returned with status already_scheduled.
+// @Tags        jobs
+// @Accept      json
+// @Produce     json
+// @Param       job body     enqueueJobRequest true "Job to enqueue"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     429 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/jobs [post]
+func (a *App) enqueueJob(w http.ResponseWriter, r *http.Request) {
+	var req enqueueJobRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	spec, ok := enqueueableJobs[req.Type]
+	if !ok {
+		response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Unsupported job type %q, expected one of %s", req.Type, strings.Join(enqueueableJobTypes(), ", "))))
+		return
+	}
+	if !a.authorize(w, r, spec.role) {
+		return
+	}
+
+	var runAt *time.Time
+	if req.RunAt != "" {
+		t, err := parseTimeValue(req.RunAt)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("Invalid run_at %q: %v", req.RunAt, err)))
+			return
+		}
+		runAt = t
+	}
+	job, err := spec.build(a, r.Context(), req.Payload)
+	if err != nil {
+		var tooSoon *resyncTooSoonError
+		if errors.As(err, &tooSoon) {
+			wait := tooSoon.wait.Truncate(time.Second) + time.Second // Round up to whole seconds
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+			response.JSON(w, http.StatusTooManyRequests, response.Error(err.Error()))
+			return
+		}
+		a.writeError(w, r, err, "build job")
+		return
+	}
+
+	if runAt != nil {
+		job.NextRunAt = *runAt
+	}
+	if req.MaxRetries != nil {
+		job.MaxRetries = *req.MaxRetries
+	}
+
+	if err := a.enqueue(r.Context(), job); err != nil {
+		a.log.Error().
+			Err(err).
+			Str("type", string(req.Type)).
+			Msg("Failed to enqueue job")
+		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to enqueue job: %v", err)))
+		return
+	}
+
+	data := map[string]interface{}{
+		"job_id": job.ID,
+		"type":   job.Type,
+		"status": scheduleStatus(job),
+	}
+	if runAt != nil && !job.Existing {
+		data["run_at"] = runAt.UTC()
+	}
+	response.JSON(w, http.StatusAccepted, response.Success(fmt.Sprintf("Job %s scheduled", job.Type), data))
+}
+
+// enqueueableJobTypes returns the job types accepted by enqueueJob in a stable order
+func enqueueableJobTypes() []string {
+	return []string{
+		string(queue.JobTypeResync),
+		string(queue.JobTypeIssues),
+		string(queue.JobTypeReport),
+		string(queue.JobTypeDigest),
+		string(queue.JobTypeCleanup),
+		string(queue.JobTypeMaintenance),
+	}
+}
+
+// decodeJobPayload decodes a job payload strictly, rejecting unknown fields. A
+// missing payload decodes as an empty object.
+func decodeJobPayload(payload json.RawMessage, v interface{}) error {
+	if len(payload) == 0 || string(payload) == "null" {
+		payload = json.RawMessage(`{}`)
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid payload: %v", errors.ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// monitoredRepository validates the owner and repo of a payload and checks that
+// the repository is monitored
+func (a *App) monitoredRepository(ctx context.Context, owner, repo string) (string, error) {
+	if owner == "" || repo == "" {
+		return "", fmt.Errorf("%w: payload requires owner and repo", errors.ErrInvalidInput)
+	}
+	fullName := owner + "/" + repo
+	if !a.worker.IsRepositoryMonitored(ctx, fullName) {
+		return "", errors.NewNotFoundError("repository", fullName)
+	}
+	return fullName, nil
+}
+
+// buildResyncJob builds a resync of a monitored repository. Like POST
+// .../{owner}/{repo}/sync, it is subject to monitor.min_resync_interval.
+func (a *App) buildResyncJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload struct {
+		Owner string `json:"owner"`
+		Repo  string `json:"repo"`
+		Since string `json:"since"` // RFC3339 timestamp, YYYY-MM-DD date or "full"; the default history when unset
+	}
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+
+	since := a.service.DefaultSince()
+	switch payload.Since {
+	case "":
+	case "full":
+		since = time.Time{}
+	default:
+		t, err := parseTimeValue(payload.Since)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid since %q: %v", errors.ErrInvalidInput, payload.Since, err)
+		}
+		since = *t
+	}
+
+	fullName, err := a.monitoredRepository(ctx, payload.Owner, payload.Repo)
+	if err != nil {
+		return nil, err
+	}
+	wait, err := a.service.ClaimResync(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, &resyncTooSoonError{fullName: fullName, wait: wait}
+	}
+
+	job, err := newJob(queue.JobTypeResync, queue.SyncPayload{Owner: payload.Owner, Repo: payload.Repo, Since: &since}, queue.SyncDedupeKey(payload.Owner, payload.Repo))
+	if err != nil {
+		return nil, err
+	}
+	job.Priority = queue.PriorityHigh
+	return job, nil
+}
+
+// buildIssuesJob builds an issue sync of a monitored repository
+func (a *App) buildIssuesJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload struct {
+		Owner string `json:"owner"`
+		Repo  string `json:"repo"`
+	}
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+	if _, err := a.monitoredRepository(ctx, payload.Owner, payload.Repo); err != nil {
+		return nil, err
+	}
+
+	return newJob(queue.JobTypeIssues, queue.SyncPayload{Owner: payload.Owner, Repo: payload.Repo}, "")
+}
+
+// buildReportJob builds the generation of a repository's monthly report
+func (a *App) buildReportJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload queue.ReportPayload
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Owner == "" || payload.Repo == "" {
+		return nil, fmt.Errorf("%w: payload requires owner and repo", errors.ErrInvalidInput)
+	}
+	if _, err := service.ParseReportMonth(payload.Month); err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidInput, err)
+	}
+
+	fullName := payload.Owner + "/" + payload.Repo
+	stored, err := a.service.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	return newJob(queue.JobTypeReport, payload, queue.ReportDedupeKey(payload.Owner, payload.Repo, payload.Month))
+}
+
+// buildDigestJob builds the generation of a repository's weekly or monthly digest
+func (a *App) buildDigestJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload queue.DigestPayload
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Owner == "" || payload.Repo == "" {
+		return nil, fmt.Errorf("%w: payload requires owner and repo", errors.ErrInvalidInput)
+	}
+	start, err := service.ParseDigestPeriod(payload.Period, payload.Start)
+	if err != nil {
+		return nil, err
+	}
+	// Pin the period so the job digests the one that was last when enqueued
+	payload.Start = start.Format("2006-01-02")
+
+	fullName := payload.Owner + "/" + payload.Repo
+	stored, err := a.service.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	return newJob(queue.JobTypeDigest, payload, queue.DigestDedupeKey(payload.Owner, payload.Repo, payload.Period, payload.Start))
+}
+
+// buildCleanupJob builds a purge of removed repositories past their retention
+func (a *App) buildCleanupJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload struct{}
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+
+	job, err := newJob(queue.JobTypeCleanup, payload, string(queue.JobTypeCleanup))
+	if err != nil {
+		return nil, err
+	}
+	job.MaxRetries = 1
+	return job, nil
+}
+
+// buildMaintenanceJob builds a run of database maintenance tasks
+func (a *App) buildMaintenanceJob(ctx context.Context, raw json.RawMessage) (*queue.Job, error) {
+	var payload queue.MaintenancePayload
+	if err := decodeJobPayload(raw, &payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Tasks) == 0 {
+		return nil, fmt.Errorf("%w: payload requires at least one task", errors.ErrInvalidInput)
+	}
+	for _, task := range payload.Tasks {
+		if task != models.MaintenanceAnalyze && task != models.MaintenanceReindex {
+			return nil, fmt.Errorf("%w: unknown maintenance task %q", errors.ErrInvalidInput, task)
+		}
+	}
+
+	job, err := newJob(queue.JobTypeMaintenance, payload, "")
+	if err != nil {
+		return nil, err
+	}
+	job.MaxRetries = 1
+	return job, nil
+}
+
+// newJob returns a job of the given type with the payload encoded
+func newJob(jobType queue.JobType, payload interface{}, dedupeKey string) (*queue.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", jobType, err)
+	}
+	return &queue.Job{
+		Type:      jobType,
+		Payload:   encoded,
+		DedupeKey: dedupeKey,
+	}, nil
+}
+
+// enqueue adds a job to the queue with the trace context of the request, so
+// processing the job continues the request's trace
+func (a *App) enqueue(ctx context.Context, job *queue.Job) error {
+	job.TraceContext = tracing.Inject(ctx)
+	return a.queue.Enqueue(ctx, job)
+}
+
+// releaseJob handles returning a quarantined job to the queue
+//
+// @Summary     Release quarantined job
+// @Description Return a job quarantined because jobs with its payload kept panicking to pending, and forget the panics counted against the payload so its later jobs run again.
+// @Tags        admin
+// @Produce     json
+// @Param       job_id path string true "Job ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/jobs/{job_id}/release [post]
+func (a *App) releaseJob(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job_id"]
+
+	job, err := a.queue.Release(r.Context(), jobID)
+	if err != nil {
+		a.writeError(w, r, err, "release job")
+		return
+	}
+
+	a.log.Info().
+		Str("job_id", jobID).
+		Str("type", string(job.Type)).
+		Msg("Released quarantined job")
+	response.JSON(w, http.StatusOK, response.Success("Job released successfully", job))
+}
+
+// getJobLatency handles reporting how long jobs wait in the queue and run
+//
+// @Summary     Job latency
+// @Description Per job type histograms and percentiles of the wait from being due to starting and of the run from starting to finishing, for jobs started within the window, and the percentage of jobs started within the queue's start SLO. sync_started_within_slo highlights repository syncs.
+// @Tags        admin
+// @Produce     json
+// @Param       since query string false "Start of the window (RFC3339 or YYYY-MM-DD, default 24 hours ago)"
+// @Param       until query string false "End of the window (RFC3339 or YYYY-MM-DD, default now)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/jobs/latency [get]
+func (a *App) getJobLatency(w http.ResponseWriter, r *http.Request) {
+	until := time.Now().UTC()
+	since := until.Add(-defaultUsageWindow)
+
+	sinceParam, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	untilParam, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if sinceParam != nil {
+		since = *sinceParam
+	}
+	if untilParam != nil {
+		until = *untilParam
+	}
+	if since.After(until) {
+		response.JSON(w, http.StatusBadRequest, response.Error("since must not be after until"))
+		return
+	}
+
+	slo := a.cfg.Queue.StartSLO
+	latencies, err := a.queue.GetLatencyStats(r.Context(), since, until, slo)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get job latency")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get job latency"))
+		return
+	}
+
+	// Without sync jobs in the window there is nothing to hold the SLO against
+	var syncWithinSLO *float64
+	for _, latency := range latencies {
+		if latency.Type == queue.JobTypeSync {
+			syncWithinSLO = &latency.StartedWithinSLO
+		}
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Job latency retrieved successfully", map[string]interface{}{
+		"since":                   since,
+		"until":                   until,
+		"start_slo_seconds":       slo.Seconds(),
+		"sync_started_within_slo": syncWithinSLO,
+		"job_types":               latencies,
+	}))
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"github-service/internal/response"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// routeMethods are the methods probed when listing what a path accepts
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// methodNotAllowedHandler handles requests whose path matches a route of the
+// router but whose method does not. HEAD is answered by the GET route without a
+// body, OPTIONS lists the allowed methods, anything else gets a 405 with an
+// Allow header
+func methodNotAllowedHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+
+		switch {
+		case r.Method == http.MethodHead && containsMethod(allowed, http.MethodGet):
+			serveHead(router, w, r)
+		case r.Method == http.MethodOptions && len(allowed) > 0:
+			w.Header().Set("Allow", allowHeader(allowed))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			if len(allowed) > 0 {
+				w.Header().Set("Allow", allowHeader(allowed))
+			}
+			response.JSON(w, http.StatusMethodNotAllowed, response.Error("Method not allowed"))
+		}
+	})
+}
+
+// allowedMethods returns the methods the router has a route for at the request path
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range routeMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		// Match also succeeds on a method mismatch, handing over to this handler
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// allowHeader renders the Allow header, adding HEAD next to GET and OPTIONS,
+// which every route answers
+func allowHeader(allowed []string) string {
+	methods := make([]string, 0, len(allowed)+2)
+	for _, method := range allowed {
+		methods = append(methods, method)
+		if method == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	methods = append(methods, http.MethodOptions)
+	return strings.Join(methods, ", ")
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// serveHead dispatches a HEAD request as a GET through the router, so it goes
+// through the same middleware and handler, and discards the body. The request
+// context is cancelled once the headers are out, which ends streaming handlers
+// such as the event stream instead of holding the connection open
+func serveHead(router *mux.Router, w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	get := r.Clone(ctx)
+	get.Method = http.MethodGet
+	router.ServeHTTP(&headWriter{ResponseWriter: w, cancel: cancel}, get)
+}
+
+// headWriter forwards headers and the status code but drops the response body
+type headWriter struct {
+	http.ResponseWriter
+	cancel      context.CancelFunc
+	wroteHeader bool
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+	w.cancel()
+}
+
+func (w *headWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return len(b), nil
+}
+
+// Flush sends the headers, which is all a HEAD response carries
+func (w *headWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
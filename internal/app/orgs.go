@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/queue"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// importedRepository describes the initial sync scheduled for a repository of an imported organization
+type importedRepository struct {
+	Repository string    `json:"repository"`
+	JobID      string    `json:"job_id"`
+	RunAt      time.Time `json:"run_at"`
+}
+
+// importOrganization handles monitoring every repository of a GitHub organization
+//
+// @Summary     Import organization
+// @Description Start monitoring every active repository of a GitHub organization. Their initial syncs are queued as jobs spread over monitor.import_window, and at most monitor.max_concurrent_backfills of them run at once. Repositories that are already monitored are skipped.
+// @Tags        repositories
+// @Produce     json
+// @Param       org   path  string true  "GitHub organization"
+// @Param       since query string false "Sync commits made since this time (RFC3339 or YYYY-MM-DD), or full for the full history"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/orgs/{org}/import [post]
+func (a *App) importOrganization(w http.ResponseWriter, r *http.Request) {
+	org := mux.Vars(r)["org"]
+
+	since, err := a.historySince(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	names, err := a.service.ListOrganizationRepositories(r.Context(), org)
+	if err != nil {
+		a.writeError(w, r, err, "list organization repositories")
+		return
+	}
+
+	monitored, err := a.service.Monitor().GetMonitoredRepositories(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get monitored repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get monitored repositories"))
+		return
+	}
+	known := make(map[string]bool, len(monitored))
+	for _, repo := range monitored {
+		known[repo.FullName] = true
+	}
+
+	var pending []string
+	skipped := []string{}
+	for _, name := range names {
+		if known[name] {
+			skipped = append(skipped, name)
+			continue
+		}
+		pending = append(pending, name)
+	}
+
+	scheduled, err := a.scheduleInitialSyncs(r.Context(), pending, since)
+	if err != nil {
+		a.log.Error().Err(err).Str("org", org).Msg("Failed to schedule organization repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to import organization: %v", err)))
+		return
+	}
+
+	a.log.Info().
+		Str("org", org).
+		Int("scheduled", len(scheduled)).
+		Int("skipped", len(skipped)).
+		Msg("Organization import scheduled")
+
+	response.JSON(w, http.StatusAccepted, response.Success(
+		fmt.Sprintf("Scheduled %d repositories of %s for synchronization", len(scheduled), org),
+		map[string]interface{}{
+			"org":       org,
+			"since":     since,
+			"window":    a.cfg.Monitor.ImportWindow.String(),
+			"scheduled": scheduled,
+			"skipped":   skipped,
+		},
+	))
+}
+
+// scheduleInitialSyncs adds GitHub repositories to monitoring and queues their
+// initial syncs of the commits made since the given time, the full history
+// when zero. The syncs are spread evenly over the import window so a large
+// import doesn't use up the API quota at once.
+func (a *App) scheduleInitialSyncs(ctx context.Context, names []string, since time.Time) ([]importedRepository, error) {
+	var step time.Duration
+	if len(names) > 1 {
+		step = a.cfg.Monitor.ImportWindow / time.Duration(len(names))
+	}
+	start := time.Now()
+
+	scheduled := []importedRepository{}
+	for i, name := range names {
+		owner, repo, _ := strings.Cut(name, "/")
+		if err := a.worker.EnrollRepository(ctx, models.ProviderGitHub, owner, repo); err != nil {
+			return scheduled, fmt.Errorf("adding %s to monitoring: %w", name, err)
+		}
+
+		payload := queue.SyncPayload{Owner: owner, Repo: repo}
+		if !since.IsZero() {
+			payload.Since = &since
+		}
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return scheduled, fmt.Errorf("marshaling sync payload: %w", err)
+		}
+
+		job := &queue.Job{
+			Type:      queue.JobTypeSync,
+			Payload:   payloadBytes,
+			NextRunAt: start.Add(time.Duration(i) * step),
+			DedupeKey: queue.SyncDedupeKey(owner, repo),
+			Priority:  queue.PriorityLow,
+		}
+		if err := a.enqueue(ctx, job); err != nil {
+			return scheduled, fmt.Errorf("scheduling sync of %s: %w", name, err)
+		}
+
+		scheduled = append(scheduled, importedRepository{Repository: name, JobID: job.ID, RunAt: job.NextRunAt})
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github-service/internal/models"
+	"github-service/internal/queue"
+	"github-service/internal/response"
+	"github-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// generateMonthlyReport handles scheduling the generation of a repository's monthly report
+//
+// @Summary     Generate monthly report
+// @Description Queues a job computing the repository's report for a calendar month (UTC) from its synced commits: new contributors, top authors, commit volume against the previous month and the busiest days. A report generated earlier for the month is replaced.
+// @Tags        reports
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       month path string true "Month (YYYY-MM)"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/reports/{month} [post]
+func (a *App) generateMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	owner, repo, month := vars["owner"], vars["repo"], vars["month"]
+	fullName := fmt.Sprintf("%s/%s", owner, repo)
+
+	if _, err := service.ParseReportMonth(month); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	stored, err := a.service.GetRepositoryByName(r.Context(), fullName)
+	if err != nil {
+		a.log.Error().
+			Err(err).
+			Str("repository", fullName).
+			Msg("Failed to look up repository")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Internal server error"))
+		return
+	}
+	if stored == nil {
+		response.JSON(w, http.StatusNotFound, response.ErrorCode(response.CodeRepoNotFound, fmt.Sprintf("Repository %s not found", fullName)))
+		return
+	}
+
+	payload, err := json.Marshal(queue.ReportPayload{Owner: owner, Repo: repo, Month: month})
+	if err != nil {
+		a.log.Error().
+			Err(err).
+			Msg("Failed to marshal report payload")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Internal server error"))
+		return
+	}
+
+	job := &queue.Job{
+		Type:      queue.JobTypeReport,
+		Payload:   payload,
+		DedupeKey: queue.ReportDedupeKey(owner, repo, month),
+	}
+	if err := a.enqueue(r.Context(), job); err != nil {
+		a.log.Error().
+			Err(err).
+			Str("repository", fullName).
+			Str("month", month).
+			Msg("Failed to enqueue report job")
+		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to schedule report generation: %v", err)))
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, response.Success(
+		fmt.Sprintf("Report of %s for %s scheduled for generation", fullName, month),
+		map[string]interface{}{
+			"job_id":     job.ID,
+			"status":     scheduleStatus(job),
+			"repository": fullName,
+			"month":      month,
+		},
+	))
+}
+
+// getMonthlyReport handles retrieving a repository's generated monthly report
+//
+// @Summary     Get monthly report
+// @Description Returns the report generated for the month as JSON, or as Markdown with format=markdown or an Accept header of text/markdown.
+// @Tags        reports
+// @Produce     json,text/markdown
+// @Param       owner  path  string true  "GitHub repository owner"
+// @Param       repo   path  string true  "GitHub repository name"
+// @Param       month  path  string true  "Month (YYYY-MM)"
+// @Param       format query string false "Response format" Enums(json, markdown)
+// @Success     200 {object} response.Response{data=models.MonthlyReport}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/reports/{month} [get]
+func (a *App) getMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+	month := vars["month"]
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/markdown") {
+		format = "markdown"
+	}
+	if format != "" && format != "json" && format != "markdown" {
+		response.JSON(w, http.StatusBadRequest, response.Error("format must be json or markdown"))
+		return
+	}
+
+	report, err := a.service.GetMonthlyReport(r.Context(), fullName, month)
+	if err != nil {
+		a.writeError(w, r, err, "get monthly report")
+		return
+	}
+
+	if format == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(report.Markdown))
+		return
+	}
+	response.JSON(w, http.StatusOK, response.Success("Monthly report retrieved successfully", report))
+}
+
+// getDigests handles listing a repository's generated digests
+//
+// @Summary     List digests
+// @Description Returns the repository's weekly (Monday to Sunday, UTC) or monthly digests, latest first: commits, contributors, top authors, the change in stars over the period from the daily stats snapshots and the busiest days. Digests of the last complete period are generated for every monitored repository when reports.digests schedules the period, and on demand by enqueuing a digest job.
+// @Tags        reports
+// @Produce     json
+// @Param       owner    path  string true  "GitHub repository owner"
+// @Param       repo     path  string true  "GitHub repository name"
+// @Param       period   query string false "Digest period" Enums(weekly, monthly) default(weekly)
+// @Param       page     query int    false "Page number"
+// @Param       per_page query int    false "Items per page"
+// @Success     200 {object} response.PaginatedResponse{data=[]models.Digest}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/reports [get]
+func (a *App) getDigests(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.DigestWeekly
+	}
+	page, perPage := a.parsePagination(r)
+
+	digests, total, err := a.service.GetDigests(r.Context(), fullName, period, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "get digests")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Digests retrieved successfully", digests, page, perPage, total))
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github-service/internal/queue"
+	"github-service/internal/response"
+)
+
+// Batching of global resyncs unless the request sets it
+const (
+	defaultResyncBatchSize     = 50
+	maxResyncBatchSize         = 1000
+	defaultResyncBatchInterval = 5 * time.Minute
+)
+
+// resyncAllRequest is the optional body of a request to resync every repository
+type resyncAllRequest struct {
+	// RFC3339 timestamp or YYYY-MM-DD date; the default history window when unset
+	Since string `json:"since,omitempty" example:"2024-01-01T00:00:00Z"`
+	Full  bool   `json:"full,omitempty" example:"false"`
+	// Report the resyncs that would be scheduled without scheduling them
+	DryRun bool `json:"dry_run,omitempty" example:"true"`
+	// Resyncs starting at once, at most 1000
+	BatchSize int `json:"batch_size,omitempty" example:"50"`
+	// Go duration between the starts of consecutive batches
+	BatchInterval string `json:"batch_interval,omitempty" example:"5m"`
+}
+
+// scheduledResync describes the resync scheduled for a repository by a global resync
+type scheduledResync struct {
+	Repository string    `json:"repository"`
+	JobID      string    `json:"job_id,omitempty"` // Empty in a dry run
+	Status     string    `json:"status"`           // scheduled, already_scheduled or quarantined; planned in a dry run
+	RunAt      time.Time `json:"run_at"`
+}
+
+// resyncAll handles resyncing every active monitored repository, e.g. to
+// recover after an outage
+//
+// @Summary     Resync all repositories
+// @Description Schedule a resync of every active monitored repository, in batches of batch_size (default 50) starting batch_interval (default 5m) apart so the API quota isn't used up at once. Commits made since the given time are fetched, or the full history when full is set; without either the default history window is used. Resyncs run at low priority, after manual resyncs and other jobs, and ignore monitor.min_resync_interval. A repository whose sync is already queued keeps that job. With dry_run set, the schedule is returned without enqueuing anything.
+// @Tags        admin
+// @Accept      json
+// @Produce     json
+// @Param       request body resyncAllRequest false "History to resync and batching"
+// @Success     202 {object} response.Response{data=object}
+// @Success     200 {object} response.Response{data=object} "Dry run"
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/resync-all [post]
+func (a *App) resyncAll(w http.ResponseWriter, r *http.Request) {
+	var req resyncAllRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid request body"))
+		return
+	}
+
+	var since *time.Time
+	value := strings.TrimSpace(req.Since)
+	switch {
+	case req.Full && value != "":
+		response.JSON(w, http.StatusBadRequest, response.Error("since and full are mutually exclusive"))
+		return
+	case req.Full:
+		since = &time.Time{}
+	case value != "":
+		parsed, err := parseTimeValue(value)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("invalid since %q: expected RFC3339 timestamp or YYYY-MM-DD date", value)))
+			return
+		}
+		since = parsed
+	default:
+		defaultSince := a.service.DefaultSince()
+		since = &defaultSince
+	}
+
+	batchSize := defaultResyncBatchSize
+	if req.BatchSize != 0 {
+		if req.BatchSize < 1 || req.BatchSize > maxResyncBatchSize {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("batch_size must be between 1 and %d", maxResyncBatchSize)))
+			return
+		}
+		batchSize = req.BatchSize
+	}
+	batchInterval := defaultResyncBatchInterval
+	if req.BatchInterval != "" {
+		interval, err := time.ParseDuration(req.BatchInterval)
+		if err != nil || interval < 0 {
+			response.JSON(w, http.StatusBadRequest, response.Error(fmt.Sprintf("invalid batch_interval %q: expected a non-negative duration such as 5m", req.BatchInterval)))
+			return
+		}
+		batchInterval = interval
+	}
+
+	monitored, err := a.service.Monitor().GetMonitoredRepositories(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get monitored repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get monitored repositories"))
+		return
+	}
+
+	start := time.Now()
+	resyncs := []scheduledResync{}
+	paused := 0
+	for _, repo := range monitored {
+		if !repo.IsActive {
+			paused++
+			continue
+		}
+		resync := scheduledResync{
+			Repository: repo.FullName,
+			Status:     "planned",
+			RunAt:      start.Add(time.Duration(len(resyncs)/batchSize) * batchInterval),
+		}
+		if !req.DryRun {
+			job, err := backgroundResyncJob(repo.FullName, *since)
+			if err != nil {
+				a.log.Error().Err(err).Msg("Failed to marshal resync payload")
+				response.JSON(w, http.StatusInternalServerError, response.Error("Internal server error"))
+				return
+			}
+			job.NextRunAt = resync.RunAt
+			if err := a.enqueue(r.Context(), job); err != nil {
+				a.log.Error().Err(err).Str("repository", repo.FullName).Int("scheduled", len(resyncs)).Msg("Failed to enqueue resync job")
+				response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to schedule resync of %s after scheduling %d: %v", repo.FullName, len(resyncs), err)))
+				return
+			}
+			resync.JobID = job.ID
+			resync.Status = scheduleStatus(job)
+		}
+		resyncs = append(resyncs, resync)
+	}
+
+	data := map[string]interface{}{
+		"dry_run":        req.DryRun,
+		"since":          since,
+		"batch_size":     batchSize,
+		"batch_interval": batchInterval.String(),
+		"batches":        (len(resyncs) + batchSize - 1) / batchSize,
+		"skipped_paused": paused,
+		"resyncs":        resyncs,
+	}
+	if req.DryRun {
+		response.JSON(w, http.StatusOK, response.Success(fmt.Sprintf("Would schedule %d repositories for resynchronization", len(resyncs)), data))
+		return
+	}
+
+	a.log.Warn().
+		Int("repositories", len(resyncs)).
+		Int("batch_size", batchSize).
+		Dur("batch_interval", batchInterval).
+		Msg("Scheduled resync of all repositories")
+	response.JSON(w, http.StatusAccepted, response.Success(fmt.Sprintf("Scheduled %d repositories for resynchronization", len(resyncs)), data))
+}
+
+// backgroundResyncJob returns the low priority resync job of a repository that
+// global and group resyncs schedule. Its payload always has a since, the zero
+// time for the full history, as resync jobs without one sync the default window.
+func backgroundResyncJob(fullName string, since time.Time) (*queue.Job, error) {
+	owner, name, _ := strings.Cut(fullName, "/")
+	job, err := newJob(queue.JobTypeResync, queue.SyncPayload{Owner: owner, Repo: name, Since: &since}, queue.SyncDedupeKey(owner, name))
+	if err != nil {
+		return nil, err
+	}
+	job.Priority = queue.PriorityLow
+	return job, nil
+}
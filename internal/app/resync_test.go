@@ -0,0 +1,51 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github-service/internal/queue"
+)
+
+func TestBackgroundResyncJob(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name      string
+		since     time.Time
+		wantSince string
+	}{
+		// A nil since would make the resync job fall back to the default window
+		{"full history", time.Time{}, `"0001-01-01T00:00:00Z"`},
+		{"since", since, `"2024-01-01T00:00:00Z"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := backgroundResyncJob("octo/api", tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Type != queue.JobTypeResync || job.Priority != queue.PriorityLow || job.DedupeKey != queue.SyncDedupeKey("octo", "api") {
+				t.Errorf("job = %s at priority %d with dedupe key %q, want a low priority resync of octo/api", job.Type, job.Priority, job.DedupeKey)
+			}
+
+			var payload map[string]json.RawMessage
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				t.Fatal(err)
+			}
+			if string(payload["owner"]) != `"octo"` || string(payload["repo"]) != `"api"` {
+				t.Errorf("payload = %s, want octo/api", job.Payload)
+			}
+			if got := string(payload["since"]); got != tt.wantSince {
+				t.Errorf("payload since = %s, want %s", got, tt.wantSince)
+			}
+
+			var decoded queue.SyncPayload
+			if err := json.Unmarshal(job.Payload, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Since == nil || !decoded.Since.Equal(tt.since) {
+				t.Errorf("decoded since = %v, want %v", decoded.Since, tt.since)
+			}
+		})
+	}
+}
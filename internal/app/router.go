@@ -3,76 +3,277 @@ package app
 import (
 	"github-service/internal/response"
 	"net/http"
+	"time"
 
+	_ "github-service/docs/swagger" // Generated OpenAPI spec, see `make swagger`
+
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // initializeRouter configures all routes for the application
 func (a *App) initializeRouter(router *mux.Router) {
-	// Set custom error handlers for 404 and 405 responses
+	// Set custom error handlers for 404 and 405 responses, the latter also
+	// answering HEAD and OPTIONS for every route
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response.JSON(w, http.StatusNotFound, response.Error("Route not found"))
 	})
-	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response.JSON(w, http.StatusMethodNotAllowed, response.Error("Method not allowed"))
-	})
+	router.MethodNotAllowedHandler = methodNotAllowedHandler(router)
 
 	// Apply common middleware
+	router.Use(tracingMiddleware)
 	router.Use(a.loggingMiddleware)
+	router.Use(a.usageMiddleware)
 	router.Use(a.recoveryMiddleware)
+	router.Use(a.timestampsMiddleware)
 
-	// Health check endpoints
+	// Health check endpoints, registered ahead of the API subrouter so load
+	// balancers can probe them without an API key
 	router.HandleFunc("/", a.healthCheck).Methods(http.MethodGet)
 	router.HandleFunc("/health", a.healthCheck).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/health", a.healthCheck).Methods(http.MethodGet)
+
+	// Interactive API docs, served without authentication
+	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(httpSwagger.URL("/swagger/doc.json"))).Methods(http.MethodGet)
 
 	// API v1 routes
 	api := router.PathPrefix("/api/v1").Subrouter()
-	api.HandleFunc("/health", a.healthCheck).Methods(http.MethodGet)
+	a.useAPIMiddleware(api)
 
 	// Repository endpoints with their own subrouter
 	initRepositoryRoutes(api.PathPrefix("/repositories").Subrouter(), a)
 
+	// Repository groups with their own subrouter
+	initGroupRoutes(api.PathPrefix("/groups").Subrouter(), a)
+
+	// Organization imports
+	api.HandleFunc("/orgs/{org}/import", a.importOrganization).Methods(http.MethodPost)
+
+	// Watched users whose repositories are discovered
+	api.HandleFunc("/users", a.listWatchedUsers).Methods(http.MethodGet)
+	api.HandleFunc("/users/{username}", a.watchUser).Methods(http.MethodPut)
+	api.HandleFunc("/users/{username}", a.unwatchUser).Methods(http.MethodDelete)
+
+	// Webhooks receiving the commits ingested for their repositories
+	api.HandleFunc("/webhooks", a.listWebhooks).Methods(http.MethodGet)
+	api.HandleFunc("/webhooks", a.createWebhook).Methods(http.MethodPost)
+	api.HandleFunc("/webhooks/{id}", a.getWebhook).Methods(http.MethodGet)
+	api.HandleFunc("/webhooks/{id}", a.deleteWebhook).Methods(http.MethodDelete)
+	api.HandleFunc("/webhooks/{id}/deliveries", a.listWebhookDeliveries).Methods(http.MethodGet)
+
 	// Statistics endpoints with their own subrouter
 	initStatsRoutes(api.PathPrefix("/stats").Subrouter(), a)
 
+	// Repositories depending on a package
+	api.HandleFunc("/dependencies/{ecosystem}/dependents", a.getPackageDependents).Methods(http.MethodGet)
+
+	// Author identity endpoints
+	api.HandleFunc("/authors/merge", a.mergeAuthors).Methods(http.MethodPost)
+	api.HandleFunc("/authors/identities", a.listAuthorIdentities).Methods(http.MethodGet)
+	api.HandleFunc("/authors/identities/{email}", a.unmergeAuthorIdentity).Methods(http.MethodDelete)
+	api.HandleFunc("/authors/{email}/commits", a.getAuthorCommits).Methods(http.MethodGet)
+
+	// GitHub API status endpoints
+	api.HandleFunc("/github/rate-limit", a.getRateLimit).Methods(http.MethodGet)
+	api.HandleFunc("/github/token", a.getTokenStatus).Methods(http.MethodGet)
+
 	// Jobs endpoints
 	api.HandleFunc("/jobs", a.listJobs).Methods(http.MethodGet)
+	api.HandleFunc("/jobs", a.enqueueJob).Methods(http.MethodPost)
 	api.HandleFunc("/jobs/{job_id}", a.getJobStatus).Methods(http.MethodGet)
+
+	// Live job and sync events
+	api.HandleFunc("/events", a.streamEvents).Methods(http.MethodGet)
+
+	// Logging in with GitHub
+	api.HandleFunc("/account", a.getAccount).Methods(http.MethodGet)
+	if a.oauth != nil {
+		auth := router.PathPrefix("/auth").Subrouter()
+		auth.HandleFunc("/github/login", a.loginWithGitHub).Methods(http.MethodGet)
+		auth.HandleFunc("/github/callback", a.githubCallback).Methods(http.MethodGet)
+		logout := auth.PathPrefix("/logout").Subrouter()
+		logout.Use(a.authMiddleware)
+		logout.HandleFunc("", a.logout).Methods(http.MethodPost)
+	}
+}
+
+// useAPIMiddleware applies the middleware of the /api/v1 routes: requests are
+// authenticated and authorized, their parameters validated, then user sessions
+// limited to their account's repositories
+func (a *App) useAPIMiddleware(api *mux.Router) {
+	api.Use(a.authMiddleware)
+	api.Use(a.methodRoleMiddleware)
+	api.Use(validateRequest)
+	api.Use(a.scopeMiddleware)
 }
 
 // initRepositoryRoutes configures all repository-related routes
 func initRepositoryRoutes(router *mux.Router, a *App) {
 	router.HandleFunc("", a.listRepositories).Methods(http.MethodGet)
+	router.HandleFunc("", a.addRepositoryFromURL).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}", a.getRepository).Methods(http.MethodGet)
 	router.HandleFunc("/{owner}/{repo}", a.addRepository).Methods(http.MethodPut)
 	router.HandleFunc("/{owner}/{repo}", a.removeRepository).Methods(http.MethodDelete)
 	router.HandleFunc("/{owner}/{repo}/commits", a.getCommits).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits/search", a.searchCommits).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits/new", a.getNewCommits).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits/latest", a.getLatestCommits).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits/{sha}", a.getCommit).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/issues", a.getIssues).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/releases", a.getReleases).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/stats/history", a.getRepositoryStatsHistory).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/contributors", a.getContributorStats).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/dependencies", a.getRepositoryDependencies).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits-since", a.getCommitsSince).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/commits-since", a.setCommitsSince).Methods(http.MethodPut)
+	router.HandleFunc("/{owner}/{repo}/commits-since", a.clearCommitsSince).Methods(http.MethodDelete)
 	router.HandleFunc("/{owner}/{repo}/sync", a.resyncRepository).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/pause", a.pauseRepository).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/resume", a.resumeRepository).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/restore", a.restoreRepository).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/rules", a.listThresholdRules).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/rules", a.createThresholdRule).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/rules/{id}", a.getThresholdRule).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/rules/{id}", a.updateThresholdRule).Methods(http.MethodPut)
+	router.HandleFunc("/{owner}/{repo}/rules/{id}", a.deleteThresholdRule).Methods(http.MethodDelete)
+	router.HandleFunc("/{owner}/{repo}/alerts", a.listThresholdAlerts).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/hooks", a.listCommitHooks).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/hooks", a.createCommitHook).Methods(http.MethodPost)
+	router.HandleFunc("/{owner}/{repo}/hooks/{id}", a.getCommitHook).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/hooks/{id}", a.updateCommitHook).Methods(http.MethodPut)
+	router.HandleFunc("/{owner}/{repo}/hooks/{id}", a.deleteCommitHook).Methods(http.MethodDelete)
+	router.HandleFunc("/{owner}/{repo}/reports", a.getDigests).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/reports/{month}", a.getMonthlyReport).Methods(http.MethodGet)
+	router.HandleFunc("/{owner}/{repo}/reports/{month}", a.generateMonthlyReport).Methods(http.MethodPost)
+}
+
+// initGroupRoutes configures all repository group routes
+func initGroupRoutes(router *mux.Router, a *App) {
+	router.HandleFunc("", a.listRepositoryGroups).Methods(http.MethodGet)
+	router.HandleFunc("", a.createRepositoryGroup).Methods(http.MethodPost)
+	router.HandleFunc("/{name}", a.getRepositoryGroup).Methods(http.MethodGet)
+	router.HandleFunc("/{name}", a.updateRepositoryGroup).Methods(http.MethodPut)
+	router.HandleFunc("/{name}", a.deleteRepositoryGroup).Methods(http.MethodDelete)
+	router.HandleFunc("/{name}/repositories/{owner}/{repo}", a.addRepositoryGroupMember).Methods(http.MethodPut)
+	router.HandleFunc("/{name}/repositories/{owner}/{repo}", a.removeRepositoryGroupMember).Methods(http.MethodDelete)
+	router.HandleFunc("/{name}/summary", a.getGroupSummary).Methods(http.MethodGet)
+	router.HandleFunc("/{name}/sync", a.resyncRepositoryGroup).Methods(http.MethodPost)
+	router.HandleFunc("/{name}/pause", a.pauseRepositoryGroup).Methods(http.MethodPost)
+	router.HandleFunc("/{name}/resume", a.resumeRepositoryGroup).Methods(http.MethodPost)
 }
 
 // initStatsRoutes configures all statistics-related routes
 func initStatsRoutes(router *mux.Router, a *App) {
 	router.HandleFunc("/top-authors", a.getTopAuthors).Methods(http.MethodGet)
+	router.HandleFunc("/file-extensions", a.getFileExtensionStats).Methods(http.MethodGet)
+	router.HandleFunc("/commit-types", a.getCommitTypeStats).Methods(http.MethodGet)
+	router.HandleFunc("/contribution-distribution", a.getContributionDistribution).Methods(http.MethodGet)
+	router.HandleFunc("/release-cadence", a.getReleaseCadence).Methods(http.MethodGet)
+	router.HandleFunc("/top-repositories", a.getTopRepositories).Methods(http.MethodGet)
+}
+
+// tracingMiddleware starts a server span for each request, continuing the
+// caller's trace, named after the matched route so requests for different
+// repositories are grouped
+func tracingMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				return r.Method + " " + template
+			}
+		}
+		return r.Method
+	}))
 }
 
 // loggingMiddleware logs information about each request
 func (a *App) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		a.log.Info().
+		requestID := r.Header.Get(response.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(response.RequestIDHeader, requestID)
+
+		// Handlers can log with the request ID through zerolog.Ctx
+		logCtx := a.log.With().Str("request_id", requestID)
+		if span := trace.SpanContextFromContext(r.Context()); span.IsValid() {
+			logCtx = logCtx.Str("trace_id", span.TraceID().String())
+		}
+		log := logCtx.Logger()
+		ctx := log.WithContext(r.Context())
+
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		event := log.Info()
+		if status >= http.StatusInternalServerError {
+			event = log.Error()
+		}
+		event.
 			Str("method", r.Method).
 			Str("path", r.URL.Path).
 			Str("remote_addr", r.RemoteAddr).
-			Msg("Incoming request")
-
-		next.ServeHTTP(w, r)
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Msg("Request handled")
 	})
 }
 
+// validRequestID reports whether a client-supplied request ID can be propagated
+// as is: short and made of visible ASCII characters only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. for event streams
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // recoveryMiddleware recovers from panics and returns a 500 error
 func (a *App) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				a.log.Error().
+				zerolog.Ctx(r.Context()).Error().
 					Interface("error", err).
 					Str("path", r.URL.Path).
 					Msg("Panic recovered in request handler")
@@ -84,3 +285,20 @@ func (a *App) recoveryMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// timestampsMiddleware renders response timestamps in the format requested by
+// the timestamps query parameter, defaulting to RFC 3339 with epoch siblings
+func (a *App) timestampsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("timestamps")
+		if format == "" {
+			format = response.TimestampsRFC3339
+		}
+		if !response.ValidTimestampFormat(format) {
+			response.JSON(w, http.StatusBadRequest, response.Error("Invalid timestamps format, expected unix or rfc3339"))
+			return
+		}
+
+		next.ServeHTTP(response.WithTimestampFormat(w, format), r)
+	})
+}
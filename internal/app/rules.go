@@ -0,0 +1,251 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// thresholdRuleRequest is the body of a request to create or replace a threshold rule
+type thresholdRuleRequest struct {
+	// One of stars, forks, watchers, open_issues, weekly_commits or the changes
+	// stars_delta, forks_delta, watchers_delta and open_issues_delta
+	Metric string `json:"metric" example:"stars"`
+	// One of gt, gte, lt or lte
+	Operator  string `json:"operator" example:"gte"`
+	Threshold int64  `json:"threshold" example:"1000"`
+	// Days a delta metric is measured over, 1 to 90; 1 when unset
+	WindowDays int `json:"window_days,omitempty" example:"1"`
+	// Optional: also sent each alert
+	WebhookURL string `json:"webhook_url,omitempty" example:"https://example.com/hooks/stars"`
+}
+
+// rule converts the request to a threshold rule
+func (req thresholdRuleRequest) rule() *models.ThresholdRule {
+	return &models.ThresholdRule{
+		Metric:     strings.ToLower(strings.TrimSpace(req.Metric)),
+		Operator:   strings.ToLower(strings.TrimSpace(req.Operator)),
+		Threshold:  req.Threshold,
+		WindowDays: req.WindowDays,
+		WebhookURL: strings.TrimSpace(req.WebhookURL),
+	}
+}
+
+// listThresholdRules handles listing a repository's threshold rules
+//
+// @Summary     List threshold rules
+// @Description Rules that alert when a repository metric, or its change over a number of days, starts or stops meeting a threshold. Rules are evaluated after every sync.
+// @Tags        rules
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/rules [get]
+func (a *App) listThresholdRules(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	rules, err := a.service.ListThresholdRules(r.Context(), fullName)
+	if err != nil {
+		a.writeError(w, r, err, "access threshold rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Threshold rules retrieved successfully", map[string]interface{}{
+		"repository": fullName,
+		"rules":      rules,
+		"n":          len(rules),
+	}))
+}
+
+// createThresholdRule handles adding a threshold rule to a repository
+//
+// @Summary     Create threshold rule
+// @Description The rule's state is established by the next sync without alerting; later syncs alert whenever the rule starts or stops holding. Alerts are recorded in the alert history, sent to the notification channels subscribed to rule_triggered and rule_resolved, and posted to the rule's webhook if it has one. Delta metrics compare the synced counters with the stats snapshot from window_days ago, so they aren't evaluated until the repository has one.
+// @Tags        rules
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string               true "GitHub repository owner"
+// @Param       repo    path string               true "GitHub repository name"
+// @Param       request body thresholdRuleRequest true "Rule definition"
+// @Success     201 {object} response.Response{data=models.ThresholdRule}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/rules [post]
+func (a *App) createThresholdRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	var req thresholdRuleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	rule := req.rule()
+	if err := a.service.CreateThresholdRule(r.Context(), fullName, rule); err != nil {
+		a.writeError(w, r, err, "access threshold rules")
+		return
+	}
+
+	a.log.Info().
+		Str("repository", fullName).
+		Int64("rule_id", rule.ID).
+		Msg("Created threshold rule")
+
+	response.JSON(w, http.StatusCreated, response.Success("Threshold rule created successfully", rule))
+}
+
+// getThresholdRule handles retrieving a threshold rule with its current state
+//
+// @Summary     Get threshold rule
+// @Tags        rules
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       id    path int    true "Rule ID"
+// @Success     200 {object} response.Response{data=models.ThresholdRule}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/rules/{id} [get]
+func (a *App) getThresholdRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid rule id"))
+		return
+	}
+
+	rule, err := a.service.GetThresholdRule(r.Context(), fullName, id)
+	if err != nil {
+		a.writeError(w, r, err, "access threshold rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Threshold rule retrieved successfully", rule))
+}
+
+// updateThresholdRule handles replacing the definition of a threshold rule
+//
+// @Summary     Update threshold rule
+// @Description Replaces the rule definition and resets its state, which the next sync establishes again without alerting.
+// @Tags        rules
+// @Accept      json
+// @Produce     json
+// @Param       owner   path string               true "GitHub repository owner"
+// @Param       repo    path string               true "GitHub repository name"
+// @Param       id      path int                  true "Rule ID"
+// @Param       request body thresholdRuleRequest true "Rule definition"
+// @Success     200 {object} response.Response{data=models.ThresholdRule}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/rules/{id} [put]
+func (a *App) updateThresholdRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid rule id"))
+		return
+	}
+
+	var req thresholdRuleRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	rule := req.rule()
+	rule.ID = id
+	if err := a.service.UpdateThresholdRule(r.Context(), fullName, rule); err != nil {
+		a.writeError(w, r, err, "access threshold rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Threshold rule updated successfully", rule))
+}
+
+// deleteThresholdRule handles removing a threshold rule
+//
+// @Summary     Delete threshold rule
+// @Tags        rules
+// @Produce     json
+// @Param       owner path string true "GitHub repository owner"
+// @Param       repo  path string true "GitHub repository name"
+// @Param       id    path int    true "Rule ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/rules/{id} [delete]
+func (a *App) deleteThresholdRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid rule id"))
+		return
+	}
+
+	if err := a.service.DeleteThresholdRule(r.Context(), fullName, id); err != nil {
+		a.writeError(w, r, err, "access threshold rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Threshold rule deleted successfully", map[string]interface{}{
+		"id": id,
+	}))
+}
+
+// listThresholdAlerts handles listing the alert history of a repository
+//
+// @Summary     List threshold alerts
+// @Description The times the repository's threshold rules started (triggered) or stopped (resolved) holding, newest first, with the rule's definition at the time. Alerts outlive their rules.
+// @Tags        rules
+// @Produce     json
+// @Param       owner    path  string true  "GitHub repository owner"
+// @Param       repo     path  string true  "GitHub repository name"
+// @Param       rule_id  query int    false "Only the alerts of this rule"
+// @Param       page     query int    false "Page number"
+// @Param       per_page query int    false "Items per page"
+// @Success     200 {object} response.PaginatedResponse{data=[]models.ThresholdAlert}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/repositories/{owner}/{repo}/alerts [get]
+func (a *App) listThresholdAlerts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	fullName := fmt.Sprintf("%s/%s", vars["owner"], vars["repo"])
+
+	var ruleID *int64
+	if value := r.URL.Query().Get("rule_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error("Invalid rule_id"))
+			return
+		}
+		ruleID = &id
+	}
+	page, perPage := a.parsePagination(r)
+
+	alerts, total, err := a.service.ListThresholdAlerts(r.Context(), fullName, ruleID, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "access threshold alerts")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Threshold alerts retrieved successfully", alerts, page, perPage, total))
+}
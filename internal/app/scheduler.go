@@ -0,0 +1,69 @@
+package app
+
+import (
+	"net/http"
+
+	"github-service/internal/response"
+)
+
+// getScheduler handles reporting the state of the background sync scheduler
+//
+// @Summary     Scheduler state
+// @Description State of the background scheduler that syncs monitored repositories: running, paused or stopped.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/scheduler [get]
+func (a *App) getScheduler(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, response.Success("Scheduler state retrieved successfully", map[string]interface{}{
+		"state": a.worker.State(),
+	}))
+}
+
+// pauseScheduler handles pausing the background sync scheduler
+//
+// @Summary     Pause scheduler
+// @Description Stops scheduling syncs of monitored repositories until resumed, cancelling the syncs in progress. Queued jobs and manual syncs are unaffected.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/scheduler/pause [post]
+func (a *App) pauseScheduler(w http.ResponseWriter, r *http.Request) {
+	if err := a.worker.Pause(); err != nil {
+		response.JSON(w, http.StatusConflict, response.Error("Cannot pause scheduler: "+err.Error()))
+		return
+	}
+	a.log.Info().Msg("Sync scheduler paused")
+
+	response.JSON(w, http.StatusOK, response.Success("Scheduler paused successfully", map[string]interface{}{
+		"state": a.worker.State(),
+	}))
+}
+
+// resumeScheduler handles resuming a paused background sync scheduler
+//
+// @Summary     Resume scheduler
+// @Description Resumes a paused scheduler, starting with a sync of every monitored repository.
+// @Tags        admin
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Failure     403 {object} response.Response
+// @Failure     409 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/scheduler/resume [post]
+func (a *App) resumeScheduler(w http.ResponseWriter, r *http.Request) {
+	if err := a.worker.Resume(); err != nil {
+		response.JSON(w, http.StatusConflict, response.Error("Cannot resume scheduler: "+err.Error()))
+		return
+	}
+	a.log.Info().Msg("Sync scheduler resumed")
+
+	response.JSON(w, http.StatusOK, response.Success("Scheduler resumed successfully", map[string]interface{}{
+		"state": a.worker.State(),
+	}))
+}
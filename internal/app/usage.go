@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// usageFlushInterval is how often aggregated API usage is written to the database
+const usageFlushInterval = time.Minute
+
+// defaultUsageWindow is the period reported by the usage endpoint when since is not given
+const defaultUsageWindow = 24 * time.Hour
+
+const requestUsageContextKey contextKey = "request_usage"
+
+// requestUsage collects what the usage middleware records about a request
+// that is only known to inner handlers
+type requestUsage struct {
+	apiKeyID int64
+}
+
+// setUsageAPIKey attributes the request's usage to an API key
+func setUsageAPIKey(ctx context.Context, apiKeyID int64) {
+	if u, ok := ctx.Value(requestUsageContextKey).(*requestUsage); ok {
+		u.apiKeyID = apiKeyID
+	}
+}
+
+// usageMiddleware records each request's endpoint, API key, status and latency
+func (a *App) usageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		u := &requestUsage{}
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestUsageContextKey, u)))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		a.usage.Record(time.Now(), r.Method, route, u.apiKeyID, status, time.Since(start))
+	})
+}
+
+// runUsageFlusher periodically writes aggregated API usage to the database until
+// the context is cancelled; the remainder is flushed on shutdown
+func (a *App) runUsageFlusher(ctx context.Context) {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.flushUsage(ctx)
+		}
+	}
+}
+
+// flushUsage writes aggregated API usage to the database, keeping it for the
+// next flush when that fails
+func (a *App) flushUsage(ctx context.Context) {
+	entries := a.usage.Drain()
+	if len(entries) == 0 {
+		return
+	}
+	if err := a.service.RecordAPIUsage(ctx, entries); err != nil {
+		a.log.Warn().Err(err).Int("aggregates", len(entries)).Msg("Failed to store API usage")
+		a.usage.Restore(entries)
+	}
+}
+
+// getAPIUsage handles reporting which endpoints and API keys drive load
+//
+// @Summary     API usage
+// @Description Request counts, 5xx errors and latencies of the service's own API per endpoint and per API key, aggregated by hour. Usage is written to the database every minute. API key 0 covers unauthenticated requests and the bootstrap admin key.
+// @Tags        admin
+// @Produce     json
+// @Param       since query string false "Start of the window (RFC3339 or YYYY-MM-DD, default 24 hours ago)"
+// @Param       until query string false "End of the window (RFC3339 or YYYY-MM-DD, default now)"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     403 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/admin/api-usage [get]
+func (a *App) getAPIUsage(w http.ResponseWriter, r *http.Request) {
+	until := time.Now().UTC()
+	since := until.Add(-defaultUsageWindow)
+
+	sinceParam, err := parseTimeParam(r, "since")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	untilParam, err := parseTimeParam(r, "until")
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+	if sinceParam != nil {
+		since = *sinceParam
+	}
+	if untilParam != nil {
+		until = *untilParam
+	}
+	if since.After(until) {
+		response.JSON(w, http.StatusBadRequest, response.Error("since must not be after until"))
+		return
+	}
+
+	// Include the hour bucket the window starts in
+	endpoints, keys, err := a.service.GetAPIUsage(r.Context(), since.Truncate(time.Hour), until)
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get API usage")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get API usage"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("API usage retrieved successfully", map[string]interface{}{
+		"since":     since,
+		"until":     until,
+		"endpoints": endpoints,
+		"api_keys":  keys,
+	}))
+}
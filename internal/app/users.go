@@ -0,0 +1,219 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// watchedUser is a watched GitHub user as returned by the API
+type watchedUser struct {
+	Username      string     `json:"username"`
+	MinStars      int        `json:"min_stars"`
+	Language      string     `json:"language"`
+	UpdatedWithin string     `json:"updated_within"` // Empty when repositories are not filtered by their last push
+	CreatedAt     time.Time  `json:"created_at"`
+	RefreshedAt   *time.Time `json:"refreshed_at"`
+}
+
+func newWatchedUser(user *models.WatchedUser) watchedUser {
+	watched := watchedUser{
+		Username:    user.Username,
+		MinStars:    user.MinStars,
+		Language:    user.Language,
+		CreatedAt:   user.CreatedAt,
+		RefreshedAt: user.RefreshedAt,
+	}
+	if user.UpdatedWithin > 0 {
+		watched.UpdatedWithin = user.UpdatedWithin.String()
+	}
+	return watched
+}
+
+// watchUser handles monitoring the repositories of a GitHub user
+//
+// @Summary     Watch user
+// @Description Start monitoring the public repositories a GitHub user owns that match the filters, skipping forks and archived repositories. Their initial syncs are queued as jobs spread over monitor.import_window. Repositories the user creates later are added every monitor.discovery_interval with the default history window. Repositories that are already monitored are skipped, and a discovered repository that is removed is not added again. Watching a user again replaces its filters.
+// @Tags        repositories
+// @Produce     json
+// @Param       username       path  string true  "GitHub username"
+// @Param       min_stars      query int    false "Only repositories with at least this many stars"
+// @Param       language       query string false "Only repositories with this primary language, ignoring case"
+// @Param       updated_within query string false "Only repositories pushed to within this duration, e.g. 720h or 90d"
+// @Param       since          query string false "Sync commits made since this time (RFC3339 or YYYY-MM-DD), or full for the full history"
+// @Success     202 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/users/{username} [put]
+func (a *App) watchUser(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	user := &models.WatchedUser{
+		Username: strings.ToLower(mux.Vars(r)["username"]),
+		Language: strings.TrimSpace(query.Get("language")),
+	}
+
+	if value := query.Get("min_stars"); value != "" {
+		minStars, err := strconv.Atoi(value)
+		if err != nil || minStars < 0 {
+			response.JSON(w, http.StatusBadRequest, response.Error("Parameter min_stars must be a non-negative integer"))
+			return
+		}
+		user.MinStars = minStars
+	}
+	if value := query.Get("updated_within"); value != "" {
+		updatedWithin, err := parseWindow("updated_within", value)
+		if err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+			return
+		}
+		user.UpdatedWithin = updatedWithin
+	}
+
+	since, err := a.historySince(r)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+		return
+	}
+
+	matching, undiscovered, err := a.service.DiscoverUserRepositories(r.Context(), user)
+	if err != nil {
+		a.writeError(w, r, err, "list user repositories")
+		return
+	}
+
+	if err := a.service.Monitor().SaveWatchedUser(r.Context(), user); err != nil {
+		a.log.Error().Err(err).Str("username", user.Username).Msg("Failed to save watched user")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to save watched user"))
+		return
+	}
+
+	scheduled, scheduleErr := a.scheduleInitialSyncs(r.Context(), undiscovered, since)
+	names := make([]string, len(scheduled))
+	for i, repo := range scheduled {
+		names[i] = repo.Repository
+	}
+	// Record what was scheduled even when scheduling stopped part way, so the
+	// next discovery doesn't schedule it twice
+	if err := a.service.RecordDiscoveredRepositories(r.Context(), user.Username, names); err != nil && scheduleErr == nil {
+		scheduleErr = err
+	}
+	if scheduleErr != nil {
+		a.log.Error().Err(scheduleErr).Str("username", user.Username).Msg("Failed to schedule user repositories")
+		response.JSON(w, http.StatusInternalServerError, response.Error(fmt.Sprintf("Failed to schedule user repositories: %v", scheduleErr)))
+		return
+	}
+
+	added := make(map[string]bool, len(undiscovered))
+	for _, name := range undiscovered {
+		added[name] = true
+	}
+	skipped := []string{}
+	for _, name := range matching {
+		if !added[name] {
+			skipped = append(skipped, name)
+		}
+	}
+
+	a.log.Info().
+		Str("username", user.Username).
+		Int("scheduled", len(scheduled)).
+		Int("skipped", len(skipped)).
+		Msg("User repositories scheduled")
+
+	response.JSON(w, http.StatusAccepted, response.Success(
+		fmt.Sprintf("Scheduled %d repositories of %s for synchronization", len(scheduled), user.Username),
+		map[string]interface{}{
+			"user":      newWatchedUser(user),
+			"since":     since,
+			"window":    a.cfg.Monitor.ImportWindow.String(),
+			"scheduled": scheduled,
+			"skipped":   skipped,
+		},
+	))
+}
+
+// listWatchedUsers handles listing the GitHub users whose repositories are discovered
+//
+// @Summary     List watched users
+// @Description List the GitHub users whose matching repositories are added to monitoring, with their filters and when their repositories were last discovered
+// @Tags        repositories
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/users [get]
+func (a *App) listWatchedUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := a.service.Monitor().GetWatchedUsers(r.Context())
+	if err != nil {
+		a.log.Error().Err(err).Msg("Failed to get watched users")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to get watched users"))
+		return
+	}
+
+	watched := make([]watchedUser, len(users))
+	for i, user := range users {
+		watched[i] = newWatchedUser(user)
+	}
+	response.JSON(w, http.StatusOK, response.Success("Watched users retrieved successfully", watched))
+}
+
+// unwatchUser handles no longer discovering the repositories of a GitHub user
+//
+// @Summary     Unwatch user
+// @Description Stop adding the new repositories of a GitHub user to monitoring. Repositories already added stay monitored.
+// @Tags        repositories
+// @Produce     json
+// @Param       username path string true "GitHub username"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/users/{username} [delete]
+func (a *App) unwatchUser(w http.ResponseWriter, r *http.Request) {
+	username := strings.ToLower(mux.Vars(r)["username"])
+
+	deleted, err := a.service.Monitor().DeleteWatchedUser(r.Context(), username)
+	if err != nil {
+		a.log.Error().Err(err).Str("username", username).Msg("Failed to delete watched user")
+		response.JSON(w, http.StatusInternalServerError, response.Error("Failed to delete watched user"))
+		return
+	}
+	if !deleted {
+		response.JSON(w, http.StatusNotFound, response.Error(fmt.Sprintf("User %s is not watched", username)))
+		return
+	}
+
+	a.log.Info().Str("username", username).Msg("Stopped watching user")
+	response.JSON(w, http.StatusOK, response.Success(fmt.Sprintf("Stopped watching %s", username), map[string]interface{}{
+		"username": username,
+	}))
+}
+
+// parseWindow parses a positive duration, also accepting a number of days
+// such as 90d
+func parseWindow(name, value string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s parameter %q: expected a duration such as 720h or a number of days such as 30d", name, value)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s parameter %q: expected a duration such as 720h or a number of days such as 30d", name, value)
+		}
+		window = d
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("parameter %s must be positive", name)
+	}
+	return window, nil
+}
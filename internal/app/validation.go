@@ -0,0 +1,130 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// namePattern matches the names of repositories and their owners that can
+// exist on GitHub or GitLab
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+// nameParams are the path variables holding an owner or repository name
+var nameParams = []string{"owner", "repo", "org", "username"}
+
+// countParams are the query parameters that must be positive integers
+var countParams = []string{"page", "per_page", "limit"}
+
+// validationError is a malformed request parameter
+type validationError struct {
+	code    string
+	message string
+}
+
+func (e *validationError) Error() string {
+	return e.message
+}
+
+// validateRequest rejects requests whose common path and query parameters are
+// malformed before a handler sees them: owner and repository names that can't
+// exist on GitHub or GitLab, and page, per_page and limit values that aren't
+// positive integers. The repository query parameter is passed on trimmed.
+func validateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if err := validateParams(mux.Vars(r), query); err != nil {
+			response.JSON(w, http.StatusBadRequest, response.ErrorCode(err.code, err.message))
+			return
+		}
+
+		// The account scope check and the handlers read the repository as validated
+		if value := query.Get("repository"); value != strings.TrimSpace(value) {
+			query.Set("repository", strings.TrimSpace(value))
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateParams checks the path variables and query parameters of a request
+func validateParams(vars map[string]string, query url.Values) *validationError {
+	for _, param := range nameParams {
+		if value, ok := vars[param]; ok {
+			if err := validateName(param, value); err != nil {
+				return err
+			}
+		}
+	}
+	if value := query.Get("repository"); value != "" {
+		owner, repo, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			return &validationError{response.CodeInvalidRepository, fmt.Sprintf("Parameter repository %q must be in the form owner/repo", value)}
+		}
+		if err := validateRepositoryName(owner, repo); err != nil {
+			return err
+		}
+	}
+
+	for _, param := range countParams {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err != nil || n < 1 {
+			code := response.CodeInvalidPagination
+			if param == "limit" {
+				code = response.CodeInvalidInput
+			}
+			return &validationError{code, fmt.Sprintf("Parameter %s must be a positive integer", param)}
+		}
+	}
+	return nil
+}
+
+// validateRepositoryName checks that an owner and repository name can exist
+func validateRepositoryName(owner, repo string) *validationError {
+	if err := validateName("owner", owner); err != nil {
+		return err
+	}
+	return validateName("repo", repo)
+}
+
+// validateName checks an owner or repository name
+func validateName(param, value string) *validationError {
+	if !namePattern.MatchString(value) || value == "." || value == ".." {
+		return &validationError{response.CodeInvalidRepository, fmt.Sprintf("Invalid %s %q: expected up to 100 letters, digits, '-', '_' or '.'", param, value)}
+	}
+	return nil
+}
+
+// validator is implemented by request bodies that check their own fields
+type validator interface {
+	Validate() error
+}
+
+// decodeBody decodes a JSON request body into v, validating it when v is a
+// validator. It writes the error response and returns false when the body is
+// malformed or invalid.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		response.JSON(w, http.StatusBadRequest, response.ErrorCode(response.CodeInvalidBody, "Invalid request body"))
+		return false
+	}
+	if body, ok := v.(validator); ok {
+		if err := body.Validate(); err != nil {
+			response.JSON(w, http.StatusBadRequest, response.Error(err.Error()))
+			return false
+		}
+	}
+	return true
+}
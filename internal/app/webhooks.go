@@ -0,0 +1,183 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github-service/internal/models"
+	"github-service/internal/response"
+
+	"github.com/gorilla/mux"
+)
+
+// webhookRequest is the body of a request to register a webhook
+type webhookRequest struct {
+	URL string `json:"url" example:"https://example.com/hooks/commits"`
+	// Full names of the repositories whose new commits are delivered
+	Repositories []string `json:"repositories" example:"octocat/hello-world"`
+}
+
+// createdWebhook is a newly registered webhook along with its secret
+type createdWebhook struct {
+	models.Webhook
+	// Key of the HMAC-SHA256 signature of each delivery; only returned once
+	Secret string `json:"secret"`
+}
+
+// listWebhooks handles listing the registered webhooks
+//
+// @Summary     List webhooks
+// @Description Webhooks receiving the commits ingested for the repositories they subscribe to
+// @Tags        hooks
+// @Produce     json
+// @Success     200 {object} response.Response{data=object}
+// @Security    ApiKeyAuth
+// @Router      /api/v1/webhooks [get]
+func (a *App) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := a.service.ListWebhooks(r.Context())
+	if err != nil {
+		a.writeError(w, r, err, "access webhooks")
+		return
+	}
+	if hooks == nil {
+		hooks = []*models.Webhook{}
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Webhooks retrieved successfully", map[string]interface{}{
+		"webhooks": hooks,
+		"n":        len(hooks),
+	}))
+}
+
+// createWebhook handles registering a webhook
+//
+// @Summary     Create webhook
+// @Description Later syncs of the subscribed repositories post their new commits to the URL, at most 100 per request. Each request carries an X-Webhook-Signature-256 header holding sha256= and the hex HMAC-SHA256 of the body keyed with the returned secret, which is not shown again. Failed deliveries are retried with exponential backoff up to webhooks.max_attempts times.
+// @Tags        hooks
+// @Accept      json
+// @Produce     json
+// @Param       request body webhookRequest true "Webhook definition"
+// @Success     201 {object} response.Response{data=createdWebhook}
+// @Failure     400 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/webhooks [post]
+func (a *App) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	hook := &models.Webhook{URL: strings.TrimSpace(req.URL), Repositories: req.Repositories}
+	secret, err := a.service.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		a.writeError(w, r, err, "access webhooks")
+		return
+	}
+
+	a.log.Info().
+		Int64("webhook_id", hook.ID).
+		Strs("repositories", hook.Repositories).
+		Msg("Created webhook")
+
+	response.JSON(w, http.StatusCreated, response.Success("Webhook created successfully", createdWebhook{
+		Webhook: *hook,
+		Secret:  secret,
+	}))
+}
+
+// getWebhook handles retrieving a webhook
+//
+// @Summary     Get webhook
+// @Tags        hooks
+// @Produce     json
+// @Param       id path int true "Webhook ID"
+// @Success     200 {object} response.Response{data=models.Webhook}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/webhooks/{id} [get]
+func (a *App) getWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	hook, err := a.service.GetWebhook(r.Context(), id)
+	if err != nil {
+		a.writeError(w, r, err, "access webhooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.Success("Webhook retrieved successfully", hook))
+}
+
+// deleteWebhook handles removing a webhook
+//
+// @Summary     Delete webhook
+// @Description Removes the webhook along with its delivery log; pending deliveries are not sent
+// @Tags        hooks
+// @Produce     json
+// @Param       id path int true "Webhook ID"
+// @Success     200 {object} response.Response{data=object}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/webhooks/{id} [delete]
+func (a *App) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := a.service.DeleteWebhook(r.Context(), id); err != nil {
+		a.writeError(w, r, err, "access webhooks")
+		return
+	}
+
+	a.log.Info().Int64("webhook_id", id).Msg("Deleted webhook")
+	response.JSON(w, http.StatusOK, response.Success("Webhook deleted successfully", map[string]interface{}{
+		"id": id,
+	}))
+}
+
+// listWebhookDeliveries handles listing the deliveries made to a webhook
+//
+// @Summary     List webhook deliveries
+// @Description Deliveries to a webhook, newest first, with their status (pending, delivered or failed), attempts and the response of the last attempt
+// @Tags        hooks
+// @Produce     json
+// @Param       id       path  int true  "Webhook ID"
+// @Param       page     query int false "Page number"
+// @Param       per_page query int false "Items per page"
+// @Success     200 {object} response.PaginatedResponse{data=[]models.WebhookDelivery}
+// @Failure     400 {object} response.Response
+// @Failure     404 {object} response.Response
+// @Security    ApiKeyAuth
+// @Router      /api/v1/webhooks/{id}/deliveries [get]
+func (a *App) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	page, perPage := a.parsePagination(r)
+
+	deliveries, total, err := a.service.ListWebhookDeliveries(r.Context(), id, page, perPage)
+	if err != nil {
+		a.writeError(w, r, err, "access webhooks")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, response.SuccessPaginated("Webhook deliveries retrieved successfully", deliveries, page, perPage, total))
+}
+
+// parseWebhookID parses the webhook ID path parameter, writing a 400 response
+// when it's invalid
+func parseWebhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusBadRequest, response.Error("Invalid webhook id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,220 @@
+// Package backup exports the repositories, their commits and the monitoring
+// configuration to an encrypted, compressed file and restores them from one.
+//
+// A backup is a stream of JSON lines, gzip compressed and sealed in AES-256-GCM
+// chunks. The first line describes the backup, every following line holds one
+// table row as produced by row_to_json, so rows restore with all their columns
+// through json_populate_record.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// formatVersion is the version of the backup contents
+const formatVersion = 1
+
+// tables are the backed up tables, parents before the tables referencing them
+var tables = []string{
+	"repositories",
+	"commits",
+	"commit_files",
+	"monitored_repositories",
+	"threshold_rules",
+	"commit_hooks",
+}
+
+// stagedTables are restored into a temporary copy, moved into place once every
+// row is loaded, after running the statement that fills in the columns older
+// backups lack
+var stagedTables = map[string]string{
+	// Commit files reference commits by repository since commits are partitioned
+	"commit_files": "UPDATE restore_commit_files f SET repository_id = c.repository_id FROM commits c WHERE f.repository_id IS NULL AND c.id = f.commit_id",
+}
+
+// ErrNotEmpty is returned when restoring into a database that already holds data
+var ErrNotEmpty = errors.New("database is not empty")
+
+// Summary describes a backup
+type Summary struct {
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Rows      map[string]int `json:"rows,omitempty"`
+}
+
+// record is a single table row of a backup
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Export writes an encrypted backup of the database to w. The rows are read in
+// a single repeatable read transaction, so the backup is consistent while the
+// service keeps syncing
+func Export(ctx context.Context, db *sql.DB, w io.Writer, key []byte) (*Summary, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("error starting backup transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	encrypted, err := NewEncryptWriter(w, key)
+	if err != nil {
+		return nil, err
+	}
+	compressed := gzip.NewWriter(encrypted)
+	encoder := json.NewEncoder(compressed)
+
+	summary := &Summary{Version: formatVersion, CreatedAt: time.Now().UTC(), Rows: make(map[string]int)}
+	if err := encoder.Encode(Summary{Version: summary.Version, CreatedAt: summary.CreatedAt}); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		count, err := exportTable(ctx, tx, table, encoder)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %w", table, err)
+		}
+		summary.Rows[table] = count
+	}
+
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	if err := encrypted.Close(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func exportTable(ctx context.Context, tx *sql.Tx, table string, encoder *json.Encoder) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT row_to_json(t) FROM %s t ORDER BY id", table))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var row json.RawMessage
+		if err := rows.Scan(&row); err != nil {
+			return count, err
+		}
+		if err := encoder.Encode(record{Table: table, Row: row}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// Restore loads an encrypted backup into the database in a single transaction.
+// Rows keep their IDs, so the target tables must be empty: restore into a
+// freshly created database
+func Restore(ctx context.Context, db *sql.DB, r io.Reader, key []byte) (*Summary, error) {
+	decrypted, err := NewDecryptReader(r, key)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := gzip.NewReader(decrypted)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing backup: %w", err)
+	}
+	defer decompressed.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(decompressed))
+	var summary Summary
+	if err := decoder.Decode(&summary); err != nil {
+		return nil, fmt.Errorf("error reading backup header: %w", err)
+	}
+	if summary.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d", summary.Version)
+	}
+	summary.Rows = make(map[string]int)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting restore transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserts := make(map[string]*sql.Stmt, len(tables))
+	for _, table := range tables {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", table)).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("%w: %s already has rows", ErrNotEmpty, table)
+		}
+
+		target := table
+		if _, ok := stagedTables[table]; ok {
+			target = "restore_" + table
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+				"CREATE TEMPORARY TABLE %s ON COMMIT DROP AS SELECT * FROM %s WITH NO DATA", target, table)); err != nil {
+				return nil, err
+			}
+		}
+
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(
+			"INSERT INTO %[1]s SELECT * FROM json_populate_record(NULL::%[1]s, $1::json)", target))
+		if err != nil {
+			return nil, err
+		}
+		defer stmt.Close()
+		inserts[table] = stmt
+	}
+
+	for {
+		var rec record
+		if err := decoder.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading backup: %w", err)
+		}
+
+		stmt, ok := inserts[rec.Table]
+		if !ok {
+			return nil, fmt.Errorf("backup contains unknown table %q", rec.Table)
+		}
+		if _, err := stmt.ExecContext(ctx, string(rec.Row)); err != nil {
+			return nil, fmt.Errorf("error restoring %s row: %w", rec.Table, err)
+		}
+		summary.Rows[rec.Table]++
+	}
+
+	for _, table := range tables {
+		fill, ok := stagedTables[table]
+		if !ok {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, fill); err != nil {
+			return nil, fmt.Errorf("error completing %s rows: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %[1]s SELECT * FROM restore_%[1]s", table)); err != nil {
+			return nil, fmt.Errorf("error restoring %s rows: %w", table, err)
+		}
+	}
+
+	// Continue the ID sequences after the restored rows
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+			"SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM %[1]s", table)); err != nil {
+			return nil, fmt.Errorf("error resetting %s id sequence: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
@@ -0,0 +1,235 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KeySize is the length in bytes of a backup encryption key (AES-256)
+const KeySize = 32
+
+// chunkSize is the most plaintext sealed in a single chunk
+const chunkSize = 64 * 1024
+
+// magic identifies an encrypted backup and its format version
+var magic = []byte("GSBK\x01")
+
+// noncePrefixSize is the random part of every chunk nonce; the rest is the
+// chunk counter and the final-chunk flag
+const noncePrefixSize = 7
+
+var (
+	// ErrInvalidBackup is returned for input that is not an encrypted backup
+	ErrInvalidBackup = errors.New("not an encrypted backup")
+	// ErrTruncated is returned when a backup ends before its final chunk
+	ErrTruncated = errors.New("backup is truncated")
+	// ErrDecrypt is returned when a chunk fails authentication, because the key
+	// is wrong or the backup was modified
+	ErrDecrypt = errors.New("backup cannot be decrypted: wrong key or corrupted data")
+)
+
+// ParseKey decodes a base64 encoded backup key
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("backup key is not valid base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("backup key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce derives the nonce of a chunk from the stream prefix, its position
+// and whether it is the last one, so chunks cannot be reordered, dropped or
+// cut off without failing authentication
+func chunkNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// encryptWriter seals everything written to it in fixed size AES-GCM chunks.
+// Each chunk is written as a flag byte, the ciphertext length and the ciphertext
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+}
+
+// NewEncryptWriter returns a writer encrypting to w with the given key. Close
+// must be called to write the final chunk; a backup without it is rejected as
+// truncated on restore
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(magic)+noncePrefixSize)
+	copy(header, magic)
+	if _, err := rand.Read(header[len(magic):]); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write to closed backup writer")
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full buffer is only sealed once more data arrives, so the last chunk
+		// is always sealed by Close with the final flag set
+		if len(e.buf) == chunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the remaining data as the final chunk. It does not close the
+// underlying writer
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(final bool) error {
+	nonce := chunkNonce(e.header[len(magic):], e.counter, final)
+	sealed := e.aead.Seal(nil, nonce, e.buf, e.header)
+	e.counter++
+	e.buf = e.buf[:0]
+
+	frame := make([]byte, 5, 5+len(sealed))
+	if final {
+		frame[0] = 1
+	}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(sealed)))
+	_, err := e.w.Write(append(frame, sealed...))
+	return err
+}
+
+// decryptReader opens the chunks written by encryptWriter
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	counter uint32
+	done    bool
+}
+
+// NewDecryptReader returns a reader decrypting an encrypted backup from r
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, len(magic)+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrInvalidBackup
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, ErrInvalidBackup
+	}
+
+	return &decryptReader{r: r, aead: aead, header: header}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var frame [5]byte
+	if _, err := io.ReadFull(d.r, frame[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return err
+	}
+	final := frame[0] == 1
+	size := binary.BigEndian.Uint32(frame[1:])
+	if frame[0] > 1 || size > chunkSize+uint32(d.aead.Overhead()) {
+		return ErrDecrypt
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return err
+	}
+
+	plain, err := d.aead.Open(sealed[:0], chunkNonce(d.header[len(magic):], d.counter, final), sealed, d.header)
+	if err != nil {
+		return ErrDecrypt
+	}
+	d.counter++
+	d.buf = plain
+
+	if final {
+		d.done = true
+		// Anything after the final chunk was not written by the backup
+		var extra [1]byte
+		if n, _ := d.r.Read(extra[:]); n > 0 {
+			return ErrDecrypt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func encrypt(t *testing.T, key, plain []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := NewEncryptWriter(&out, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decrypt(key, sealed []byte) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(sealed), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := testKey(t)
+	for _, size := range []int{0, 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		got, err := decrypt(key, encrypt(t, key, plain))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: decrypted data differs", size)
+		}
+	}
+}
+
+func TestDecryptRejectsModifiedBackups(t *testing.T) {
+	key := testKey(t)
+	plain := make([]byte, 2*chunkSize+100)
+	rand.Read(plain)
+	sealed := encrypt(t, key, plain)
+
+	if _, err := decrypt(testKey(t), sealed); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong key: got %v, want ErrDecrypt", err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)/2] ^= 1
+	if _, err := decrypt(key, tampered); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered: got %v, want ErrDecrypt", err)
+	}
+
+	// Cutting the backup at a chunk boundary leaves only complete chunks
+	firstChunk := len(magic) + noncePrefixSize + 5 + chunkSize + 16
+	if _, err := decrypt(key, sealed[:firstChunk]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated at chunk boundary: got %v, want ErrTruncated", err)
+	}
+	if _, err := decrypt(key, sealed[:len(sealed)-1]); !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated: got %v, want ErrTruncated", err)
+	}
+
+	if _, err := decrypt(key, append(bytes.Clone(sealed), 0)); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("trailing data: got %v, want ErrDecrypt", err)
+	}
+
+	if _, err := decrypt(key, []byte("not a backup at all")); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("plain input: got %v, want ErrInvalidBackup", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := testKey(t)
+	parsed, err := ParseKey(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed, key) {
+		t.Error("parsed key differs")
+	}
+
+	if _, err := ParseKey(base64.StdEncoding.EncodeToString(key[:16])); err == nil {
+		t.Error("expected error for a 16 byte key")
+	}
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+}
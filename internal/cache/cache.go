@@ -0,0 +1,134 @@
+// Package cache keeps the results of expensive aggregate queries for a short
+// while, in memory or in Redis, so dashboards polling the stats endpoints
+// don't recompute them on every request.
+//
+// Cached values belong to groups, such as the commits of one repository.
+// Invalidating a group bumps its generation, which is part of the keys of its
+// values, so stale values are never read again and simply expire.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// GroupAll holds the values computed over every repository, invalidated by a
+// change to any of them
+const GroupAll = "all"
+
+// RepositoryGroup returns the group of the values computed over a repository
+func RepositoryGroup(fullName string) string {
+	return "repo:" + strings.ToLower(fullName)
+}
+
+// Store holds cached values
+type Store interface {
+	// Get returns a value, or false when it is missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores a value that expires after ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr increments a counter that never expires, starting from 0
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Stats counts cache lookups
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"` // Store failures, answered from the database
+}
+
+// Cache caches the results of loaders for a TTL
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	log   zerolog.Logger
+
+	hits, misses, errors atomic.Int64
+}
+
+// New creates a cache keeping values in store for ttl
+func New(store Store, ttl time.Duration, log zerolog.Logger) *Cache {
+	return &Cache{store: store, ttl: ttl, log: log}
+}
+
+// Stats returns the lookups counted since the cache was created
+func (c *Cache) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Errors: c.errors.Load()}
+}
+
+// Invalidate makes the values of the groups stale
+func (c *Cache) Invalidate(ctx context.Context, groups ...string) {
+	if c == nil {
+		return
+	}
+	for _, group := range groups {
+		if _, err := c.store.Incr(ctx, generationKey(group)); err != nil {
+			c.errors.Add(1)
+			c.log.Warn().Err(err).Str("group", group).Msg("Failed to invalidate cache group")
+		}
+	}
+}
+
+// Get returns the cached value of key in group, or calls load and caches its
+// result. A nil cache always loads. Store failures are logged and answered by
+// load, so the cache never fails a read.
+func Get[T any](ctx context.Context, c *Cache, group, key string, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+
+	generation, err := c.generation(ctx, group)
+	if err != nil {
+		c.errors.Add(1)
+		c.log.Warn().Err(err).Str("group", group).Msg("Failed to read cache generation")
+		return load()
+	}
+	fullKey := fmt.Sprintf("%s:%d:%s", group, generation, key)
+
+	data, ok, err := c.store.Get(ctx, fullKey)
+	if err != nil {
+		c.errors.Add(1)
+		c.log.Warn().Err(err).Str("key", fullKey).Msg("Failed to read cached value")
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			c.hits.Add(1)
+			return value, nil
+		}
+	}
+
+	c.misses.Add(1)
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if data, err := json.Marshal(value); err == nil {
+		if err := c.store.Set(ctx, fullKey, data, c.ttl); err != nil {
+			c.errors.Add(1)
+			c.log.Warn().Err(err).Str("key", fullKey).Msg("Failed to cache value")
+		}
+	}
+	return value, nil
+}
+
+// generation returns the current generation of a group
+func (c *Cache) generation(ctx context.Context, group string) (int64, error) {
+	data, ok, err := c.store.Get(ctx, generationKey(group))
+	if err != nil || !ok {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+func generationKey(group string) string {
+	return "generation:" + group
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory(10)
+	m.now = func() time.Time { return now }
+
+	m.Set(ctx, "a", []byte("1"), time.Minute)
+	if data, ok, _ := m.Get(ctx, "a"); !ok || string(data) != "1" {
+		t.Fatalf("Get = %q, %v, want 1", data, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("value still present after its TTL")
+	}
+}
+
+func TestMemoryEviction(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(20)
+	for i := 0; i < 50; i++ {
+		m.Set(ctx, strconv.Itoa(i), []byte("x"), time.Hour)
+	}
+	if len(m.values) > 20 {
+		t.Errorf("store holds %d values, want at most 20", len(m.values))
+	}
+	if _, ok, _ := m.Get(ctx, "49"); !ok {
+		t.Error("latest value was evicted")
+	}
+}
+
+func TestGetAndInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(100), time.Minute, zerolog.Nop())
+
+	loads := 0
+	load := func() ([]int, error) {
+		loads++
+		return []int{loads}, nil
+	}
+
+	first, _ := Get(ctx, c, RepositoryGroup("golang/go"), "authors", load)
+	second, _ := Get(ctx, c, RepositoryGroup("Golang/Go"), "authors", load)
+	if loads != 1 || second[0] != first[0] {
+		t.Fatalf("loads = %d, second = %v, want one load", loads, second)
+	}
+
+	// Invalidating another group keeps the value
+	c.Invalidate(ctx, RepositoryGroup("rust-lang/rust"))
+	Get(ctx, c, RepositoryGroup("golang/go"), "authors", load)
+	if loads != 1 {
+		t.Errorf("loads = %d after invalidating another group, want 1", loads)
+	}
+
+	c.Invalidate(ctx, RepositoryGroup("golang/go"))
+	third, _ := Get(ctx, c, RepositoryGroup("golang/go"), "authors", load)
+	if loads != 2 || third[0] != 2 {
+		t.Errorf("loads = %d, third = %v, want a reload after invalidation", loads, third)
+	}
+
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("stats = %+v, want 2 hits and 2 misses", stats)
+	}
+}
+
+func TestGetLoadError(t *testing.T) {
+	ctx := context.Background()
+	c := New(NewMemory(100), time.Minute, zerolog.Nop())
+
+	failure := errors.New("database unavailable")
+	if _, err := Get(ctx, c, GroupAll, "authors", func() (int, error) { return 0, failure }); err != failure {
+		t.Fatalf("err = %v, want the load error", err)
+	}
+	// Failures aren't cached
+	if value, err := Get(ctx, c, GroupAll, "authors", func() (int, error) { return 7, nil }); err != nil || value != 7 {
+		t.Errorf("Get = %d, %v, want 7", value, err)
+	}
+}
+
+func TestNilCacheLoads(t *testing.T) {
+	var c *Cache
+	c.Invalidate(context.Background(), GroupAll)
+	value, err := Get(context.Background(), c, GroupAll, "count", func() (int, error) { return 3, nil })
+	if err != nil || value != 3 {
+		t.Errorf("Get = %d, %v, want 3", value, err)
+	}
+}
+
+// fakeRedis serves GET, SET, INCR and AUTH from a map over one connection
+func fakeRedis(t *testing.T, password string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		values := make(map[string]string)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			for {
+				args, err := readCommand(reader)
+				if err != nil {
+					conn.Close()
+					break
+				}
+				switch args[0] {
+				case "AUTH":
+					if args[1] != password {
+						fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+					} else {
+						fmt.Fprint(conn, "+OK\r\n")
+					}
+				case "GET":
+					if value, ok := values[args[1]]; ok {
+						fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+					} else {
+						fmt.Fprint(conn, "$-1\r\n")
+					}
+				case "SET":
+					values[args[1]] = args[2]
+					fmt.Fprint(conn, "+OK\r\n")
+				case "INCR":
+					n, _ := strconv.Atoi(values[args[1]])
+					values[args[1]] = strconv.Itoa(n + 1)
+					fmt.Fprintf(conn, ":%d\r\n", n+1)
+				default:
+					fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+				}
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(reader, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	r := NewRedis(fakeRedis(t, "secret"), "secret", 0, "test:")
+	defer r.Close()
+
+	if _, ok, err := r.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get missing = %v, %v, want not found", ok, err)
+	}
+	if err := r.Set(ctx, "key", []byte("line one\r\nline two"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if data, ok, err := r.Get(ctx, "key"); err != nil || !ok || string(data) != "line one\r\nline two" {
+		t.Errorf("Get = %q, %v, %v", data, ok, err)
+	}
+	if n, err := r.Incr(ctx, "generation"); err != nil || n != 1 {
+		t.Errorf("Incr = %d, %v, want 1", n, err)
+	}
+	if data, _, _ := r.Get(ctx, "generation"); string(data) != "1" {
+		t.Errorf("counter = %q, want 1", data)
+	}
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	r := NewRedis(fakeRedis(t, "secret"), "wrong", 0, "test:")
+	defer r.Close()
+
+	_, _, err := r.Get(context.Background(), "key")
+	var replyErr redisError
+	if !errors.As(err, &replyErr) {
+		t.Errorf("err = %v, want the server's error reply", err)
+	}
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is a Store keeping values in process memory, holding at most a fixed
+// number of them
+type Memory struct {
+	mu         sync.Mutex
+	values     map[string]memoryValue
+	counters   map[string]int64
+	maxEntries int
+	now        func() time.Time
+}
+
+type memoryValue struct {
+	data    []byte
+	expires time.Time
+}
+
+// NewMemory creates an in-memory store holding up to maxEntries values
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		values:     make(map[string]memoryValue),
+		counters:   make(map[string]int64),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get returns an unexpired value
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if count, ok := m.counters[key]; ok {
+		return []byte(strconv.FormatInt(count, 10)), true, nil
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(value.expires) {
+		delete(m.values, key)
+		return nil, false, nil
+	}
+	return value.data, true, nil
+}
+
+// Set stores a value. When the store is full, expired values are dropped
+// first, then arbitrary ones.
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.values[key]; !ok && len(m.values) >= m.maxEntries {
+		m.evict()
+	}
+	m.values[key] = memoryValue{data: value, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Incr increments a counter
+func (m *Memory) Incr(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.counters[key]++
+	return m.counters[key], nil
+}
+
+// evict makes room for a value, dropping the expired values or, when none
+// expired, about a tenth of the values
+func (m *Memory) evict() {
+	now := m.now()
+	for key, value := range m.values {
+		if !now.Before(value.expires) {
+			delete(m.values, key)
+		}
+	}
+
+	drop := len(m.values) - m.maxEntries + 1
+	if drop > 0 {
+		drop = max(drop, m.maxEntries/10)
+	}
+	for key := range m.values {
+		if drop <= 0 {
+			break
+		}
+		delete(m.values, key)
+		drop--
+	}
+}
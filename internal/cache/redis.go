@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisTimeout bounds each Redis command whose context has no earlier deadline
+const redisTimeout = 2 * time.Second
+
+// Redis is a Store keeping values in Redis, so the instances of the service
+// share them. It speaks the few RESP commands it needs over one connection,
+// which is reopened after any failure.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedis creates a store using the Redis server at addr, with keys prefixed
+// by prefix. The connection is opened by the first command.
+func NewRedis(addr, password string, db int, prefix string) *Redis {
+	return &Redis{addr: addr, password: password, db: db, prefix: prefix}
+}
+
+// Get returns a value, or false when Redis doesn't hold it
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", r.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected redis reply to GET: %v", reply)
+	}
+	return data, true, nil
+}
+
+// Set stores a value with a TTL
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", r.prefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// Incr increments a counter
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	reply, err := r.do(ctx, "INCR", r.prefix+key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected redis reply to INCR: %v", reply)
+	}
+	return n, nil
+}
+
+// Close closes the connection
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.disconnect()
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do sends a command and returns its reply: nil, a string, []byte or int64.
+// The connection is dropped after any failure other than an error reply.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := r.command(ctx, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		r.disconnect()
+	}
+	return reply, err
+}
+
+// connect opens the connection, authenticating and selecting the database
+func (r *Redis) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: redisTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return fmt.Errorf("error connecting to redis: %w", err)
+	}
+	r.conn, r.reader = conn, bufio.NewReader(conn)
+
+	if r.password != "" {
+		if _, err := r.command(ctx, "AUTH", r.password); err != nil {
+			r.disconnect()
+			return fmt.Errorf("error authenticating to redis: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := r.command(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			r.disconnect()
+			return fmt.Errorf("error selecting redis database: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *Redis) disconnect() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn, r.reader = nil, nil
+	return err
+}
+
+// command writes a command as an array of bulk strings and reads its reply
+func (r *Redis) command(ctx context.Context, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(redisTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := r.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := r.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(r.reader)
+}
+
+// readReply reads a simple string, error, integer or bulk string reply
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply type %q", kind)
+	}
+}
@@ -2,19 +2,38 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
+	"github-service/internal/backup"
+	"github-service/internal/httpclient"
+	"github-service/internal/models"
+	"github-service/internal/queue"
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	GitHub   GitHubConfig
-	Server   ServerConfig
-	Monitor  MonitorConfig
-	Log      LogConfig
+	Database    DatabaseConfig
+	GitHub      GitHubConfig
+	GitLab      GitLabConfig
+	Jira        JiraConfig
+	HTTP        HTTPConfig
+	Server      ServerConfig
+	Monitor     MonitorConfig
+	Maintenance MaintenanceConfig
+	Worker      WorkerConfig
+	Queue       QueueConfig
+	Log         LogConfig
+	Auth        AuthConfig
+	Backup      BackupConfig
+	Tracing     TracingConfig
+	Notify      NotifyConfig
+	Webhooks    WebhooksConfig
+	Cache       CacheConfig
+	Reports     ReportsConfig
 }
 
 type DatabaseConfig struct {
@@ -24,33 +43,276 @@ type DatabaseConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+	Retry    DatabaseRetryConfig
+}
+
+// DatabaseRetryConfig configures retries of transient database errors per operation class
+type DatabaseRetryConfig struct {
+	Read  RetryPolicyConfig
+	Write RetryPolicyConfig
+}
+
+// RetryPolicyConfig configures the attempts and backoff of a retried operation
+type RetryPolicyConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
 }
 
 type GitHubConfig struct {
-	Token          string
-	RateLimit      time.Duration
-	RequestTimeout time.Duration
-	MaxRetries     int
-	RetryBackoff   time.Duration
-	Repo           string        // Optional: specific repository to monitor
-	Since          time.Time     // Optional: sync commits since this time
-	Interval       time.Duration // Optional: sync interval
+	BaseURL          string `mapstructure:"base_url"` // API root, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server
+	Token            string
+	Tokens           []string // Optional: more tokens to rotate between, using the one with the most rate limit left
+	RateLimit        time.Duration
+	RequestTimeout   time.Duration
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	Repo             string          // Optional: specific repository to monitor
+	Since            time.Time       // Optional: sync commits since this time
+	Interval         time.Duration   // Optional: sync interval
+	FetchCommitFiles bool            `mapstructure:"fetch_commit_files"`     // Optional: store files changed by each new commit (one extra request per commit)
+	CommitStatsBatch int             `mapstructure:"commit_stats_batch"`     // Optional: commits per sync enriched with additions/deletions (one request each); 0 disables
+	SyncIssues       bool            `mapstructure:"sync_issues"`            // Optional: also sync issues of monitored repositories
+	SyncReleases     bool            `mapstructure:"sync_releases"`          // Optional: also sync tags and releases of monitored repositories
+	SyncContributors bool            `mapstructure:"sync_contributor_stats"` // Optional: also sync GitHub's weekly contributor stats of monitored repositories
+	SyncDependencies bool            `mapstructure:"sync_dependencies"`      // Optional: also sync the dependencies declared in manifests of monitored repositories
+	MaxCommitPages   int             `mapstructure:"max_commit_pages"`       // Most pages of 100 commits fetched per sync
+	TokenExpiryWarn  time.Duration   `mapstructure:"token_expiry_warning"`   // Warn this long before the token expires; 0 disables
+	App              GitHubAppConfig // Optional: authenticate as a GitHub App installation instead of with the token
+}
+
+// AllTokens returns the configured personal access tokens without duplicates,
+// token first
+func (c GitHubConfig) AllTokens() []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for _, token := range append([]string{c.Token}, c.Tokens...) {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// GitHubAppConfig holds the credentials of a GitHub App installation
+type GitHubAppConfig struct {
+	ID             int64
+	InstallationID int64  `mapstructure:"installation_id"`
+	PrivateKey     string `mapstructure:"private_key"`      // PEM encoded private key
+	PrivateKeyPath string `mapstructure:"private_key_path"` // Path to the PEM file, used when private_key is empty
+}
+
+// Enabled reports whether GitHub App authentication is configured
+func (c GitHubAppConfig) Enabled() bool {
+	return c.ID != 0
+}
+
+// PrivateKeyPEM returns the app's private key, reading it from disk when configured by path
+func (c GitHubAppConfig) PrivateKeyPEM() ([]byte, error) {
+	if c.PrivateKey != "" {
+		return []byte(c.PrivateKey), nil
+	}
+	return os.ReadFile(c.PrivateKeyPath)
+}
+
+// GitLabConfig configures GitLab as a second repository provider
+type GitLabConfig struct {
+	Enabled bool
+	BaseURL string `mapstructure:"base_url"` // API root, e.g. https://gitlab.example.com/api/v4 for a self-managed instance
+	Token   string // Optional: personal access token, needed for private projects and higher rate limits
+}
+
+// JiraConfig configures looking up the Jira tickets referenced by commit messages
+type JiraConfig struct {
+	Enabled         bool
+	BaseURL         string `mapstructure:"base_url"` // Instance root, e.g. https://example.atlassian.net
+	Email           string // Jira Cloud account the token belongs to; empty sends the token as a personal access token
+	Token           string
+	Projects        []string      // Project keys whose tickets are recorded; all keys when empty
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // How often ticket statuses are refreshed
 }
 
 type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	AdminPort    int `mapstructure:"admin_port"` // Optional: separate listener for admin, debug and metrics endpoints
+
+	DefaultPerPage int `mapstructure:"default_per_page"` // Page size of list endpoints when per_page isn't given
+	MaxPerPage     int `mapstructure:"max_per_page"`     // Largest per_page served; larger ones are reduced to it
 }
 
 type MonitorConfig struct {
-	Interval time.Duration
+	Interval          time.Duration
+	Enabled           bool
+	DefaultHistory    time.Duration `mapstructure:"default_history"`          // How far back commits of a newly added repository are synced; 0 syncs its full history
+	MinResyncInterval time.Duration `mapstructure:"min_resync_interval"`      // Shortest time between manual resyncs of a repository; 0 disables the limit
+	ImportWindow      time.Duration `mapstructure:"import_window"`            // Window over which the initial syncs of an organization import are spread
+	MaxBackfills      int           `mapstructure:"max_concurrent_backfills"` // Most initial syncs running at once; 0 is unlimited
+	DeletedRetention  time.Duration `mapstructure:"deleted_retention"`        // How long the data of removed repositories is kept and restorable; 0 deletes it at once
+	DiscoveryInterval time.Duration `mapstructure:"discovery_interval"`       // How often new repositories of watched users are discovered; 0 disables
+	SyncConcurrency   int           `mapstructure:"sync_concurrency"`         // Repositories each round of scheduled syncs syncs at once
+}
+
+// MaintenanceConfig schedules database maintenance. A zero interval disables the task.
+type MaintenanceConfig struct {
+	Enabled         bool
+	AnalyzeInterval time.Duration `mapstructure:"analyze_interval"` // ANALYZE of the commit tables
+	ReindexInterval time.Duration `mapstructure:"reindex_interval"` // REINDEX CONCURRENTLY of the hot commit indexes
+}
+
+// WorkerConfig configures the job workers
+type WorkerConfig struct {
+	Count              int           // Job workers run by this instance
+	PollInterval       time.Duration `mapstructure:"poll_interval"`        // Wait between dequeues of each idle worker when no job notification arrives
+	MaxConcurrentSyncs int           `mapstructure:"max_concurrent_syncs"` // Most repository syncs this instance runs at once; 0 is unlimited
+}
+
+// QueueConfig configures the job queue shared by all workers
+type QueueConfig struct {
+	LeaseDuration time.Duration  `mapstructure:"lease_duration"` // Running jobs not updated for this long are taken over by another worker; 0 disables
+	Concurrency   map[string]int // Most jobs of a type running at once across all workers, by job type; 0 is unlimited
+	MaxJobPanics  int            `mapstructure:"max_job_panics"` // Panics of jobs with the same payload before its jobs are quarantined; 0 never quarantines
+	StartSLO      time.Duration  `mapstructure:"start_slo"`      // Jobs should start within this long of being due; reported by the job latency endpoint
+	StuckTimeout  time.Duration  `mapstructure:"stuck_timeout"`  // Running jobs without a heartbeat for this long are returned to pending; 0 disables
+	ReapInterval  time.Duration  `mapstructure:"reap_interval"`  // How often stuck jobs are looked for
+}
+
+// HeartbeatInterval returns how often workers record that their jobs are still
+// running, often enough that live jobs are neither reaped nor taken over, or 0
+// when neither is enabled
+func (c QueueConfig) HeartbeatInterval() time.Duration {
+	return c.HeartbeatTimeout() / 3
+}
+
+// HeartbeatTimeout returns how long a running job may go without a heartbeat
+// before it is considered interrupted: the shorter of the stuck timeout and
+// the lease duration, or 0 when neither is enabled
+func (c QueueConfig) HeartbeatTimeout() time.Duration {
+	timeout := c.StuckTimeout
+	if c.LeaseDuration > 0 && (timeout == 0 || c.LeaseDuration < timeout) {
+		timeout = c.LeaseDuration
+	}
+	return timeout
+}
+
+// NotifyConfig configures notifications about failing syncs and jobs
+type NotifyConfig struct {
+	SyncFailureThreshold int `mapstructure:"sync_failure_threshold"` // Consecutive failed syncs of a repository before notifying; 0 disables
+	Slack                SlackConfig
+	Email                EmailConfig
+}
+
+// WebhooksConfig configures the delivery of commits to registered webhooks
+type WebhooksConfig struct {
+	DeliveryInterval time.Duration `mapstructure:"delivery_interval"` // How often due deliveries are sent
+	MaxAttempts      int           `mapstructure:"max_attempts"`      // Attempts at a delivery before it is marked failed
+}
+
+// CacheConfig configures the cache of aggregate stats reads
+type CacheConfig struct {
+	Enabled    bool
+	TTL        time.Duration // How long a cached value is served
+	MaxEntries int           `mapstructure:"max_entries"` // Values held by the in-memory cache
+	Redis      RedisConfig
+}
+
+// RedisConfig configures a Redis server shared by every instance as the cache.
+// Without an address, each instance caches in its own memory.
+type RedisConfig struct {
+	Address  string // host:port
+	Password string
+	DB       int
+}
+
+// ReportsConfig schedules the digests of the monitored repositories
+type ReportsConfig struct {
+	Digests       []string // Periods digested once they end, out of weekly and monthly; none when empty
+	NotifyDigests bool     `mapstructure:"notify_digests"` // Send scheduled digests as digest notifications
+}
+
+// SlackConfig configures notifications posted to a Slack incoming webhook
+type SlackConfig struct {
+	Enabled    bool
+	WebhookURL string   `mapstructure:"webhook_url"`
+	Events     []string // Events notified on the channel; all when empty
+}
+
+// EmailConfig configures notifications sent by SMTP
+type EmailConfig struct {
 	Enabled  bool
+	Host     string
+	Port     int
+	Username string // Optional: authenticates with PLAIN auth when set
+	Password string
+	From     string
+	To       []string
+	Events   []string // Events notified by email; all when empty
+}
+
+type AuthConfig struct {
+	Enabled  bool
+	AdminKey string      `mapstructure:"admin_key"` // Optional: static key with the admin role, used to bootstrap API keys
+	OAuth    OAuthConfig // Optional: let people log in with their GitHub account
+}
+
+// OAuthConfig configures logging in with a GitHub OAuth app
+type OAuthConfig struct {
+	BaseURL      string        `mapstructure:"base_url"`      // GitHub web root, e.g. https://github.example.com for GitHub Enterprise Server
+	ClientID     string        `mapstructure:"client_id"`     // OAuth app client ID; login is disabled without one
+	ClientSecret string        `mapstructure:"client_secret"` // OAuth app client secret
+	RedirectURL  string        `mapstructure:"redirect_url"`  // Callback URL registered with the app, ending in /auth/github/callback
+	DefaultRole  models.Role   `mapstructure:"default_role"`  // Role of accounts on their first login, reader or writer
+	SessionTTL   time.Duration `mapstructure:"session_ttl"`   // How long the API key issued on login stays valid
+}
+
+// Enabled reports whether GitHub login is configured
+func (c OAuthConfig) Enabled() bool {
+	return c.ClientID != ""
+}
+
+// BackupConfig configures encrypted backups
+type BackupConfig struct {
+	Key string // Base64 encoded 32 byte key encrypting backups; backups are disabled without one
+}
+
+// HTTPConfig configures the outbound connections to GitHub, GitLab and Jira
+type HTTPConfig struct {
+	ProxyURL              string        `mapstructure:"proxy_url"`               // Optional: proxy for all outbound requests; empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	CAFile                string        `mapstructure:"ca_file"`                 // Optional: PEM bundle of certificate authorities trusted next to the system ones
+	InsecureSkipVerify    bool          `mapstructure:"insecure_skip_verify"`    // Skip verifying server certificates; never use in production
+	DialTimeout           time.Duration `mapstructure:"dial_timeout"`            // Timeout of establishing a connection
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tls_handshake_timeout"`   // Timeout of the TLS handshake
+	ResponseHeaderTimeout time.Duration `mapstructure:"response_header_timeout"` // Timeout waiting for response headers; 0 only applies the request timeout
+}
+
+// Options returns the transport options of the configuration
+func (c HTTPConfig) Options() httpclient.Options {
+	return httpclient.Options{
+		ProxyURL:              c.ProxyURL,
+		CAFile:                c.CAFile,
+		InsecureSkipVerify:    c.InsecureSkipVerify,
+		DialTimeout:           c.DialTimeout,
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+	}
+}
+
+// TracingConfig configures exporting OpenTelemetry traces
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string  // OTLP/HTTP endpoint URL, e.g. http://collector:4318; empty uses the OTEL_EXPORTER_OTLP_* environment variables
+	Insecure    bool    // Export over plain HTTP
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Share of traces started by the service that are recorded
+	ServiceName string  `mapstructure:"service_name"`
 }
 
 type LogConfig struct {
-	Level  string
-	Format string
+	Level      string
+	Format     string
+	BufferSize int `mapstructure:"buffer_size"` // Recent entries kept in memory for the admin log stream; 0 disables it
 }
 
 // Load reads configuration from file and environment variables
@@ -78,16 +340,27 @@ func Load(configPath string) (*Config, error) {
 
 	// Override with environment variables
 	envVars := map[string]string{
-		"database.host":     "DB_HOST",
-		"database.port":     "DB_PORT",
-		"database.user":     "DB_USER",
-		"database.password": "DB_PASSWORD",
-		"database.name":     "DB_NAME",
-		"database.sslmode":  "DB_SSLMODE",
-		"github.token":      "GITHUB_TOKEN",
-		"monitor.interval":  "MONITOR_INTERVAL",
-		"log.level":         "LOG_LEVEL",
-		"log.format":        "LOG_FORMAT",
+		"database.host":            "DB_HOST",
+		"database.port":            "DB_PORT",
+		"database.user":            "DB_USER",
+		"database.password":        "DB_PASSWORD",
+		"database.name":            "DB_NAME",
+		"database.sslmode":         "DB_SSLMODE",
+		"github.token":             "GITHUB_TOKEN",
+		"github.tokens":            "GITHUB_TOKENS",
+		"github.app.private_key":   "GITHUB_APP_PRIVATE_KEY",
+		"gitlab.token":             "GITLAB_TOKEN",
+		"jira.token":               "JIRA_TOKEN",
+		"monitor.interval":         "MONITOR_INTERVAL",
+		"log.level":                "LOG_LEVEL",
+		"log.format":               "LOG_FORMAT",
+		"auth.admin_key":           "ADMIN_API_KEY",
+		"auth.oauth.client_secret": "GITHUB_OAUTH_CLIENT_SECRET",
+		"backup.key":               "BACKUP_KEY",
+		"notify.slack.webhook_url": "SLACK_WEBHOOK_URL",
+		"notify.email.password":    "SMTP_PASSWORD",
+		"cache.redis.address":      "REDIS_ADDRESS",
+		"cache.redis.password":     "REDIS_PASSWORD",
 	}
 
 	for configKey, envVar := range envVars {
@@ -114,27 +387,115 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("server.read_timeout", "30s")
 	v.SetDefault("server.write_timeout", "30s")
+	v.SetDefault("server.admin_port", 0) // 0 serves admin endpoints on the main port
+	v.SetDefault("server.default_per_page", 10)
+	v.SetDefault("server.max_per_page", 100)
 
 	// Database defaults
 	v.SetDefault("database.host", "localhost")
 	v.SetDefault("database.port", 5432)
 	v.SetDefault("database.name", "github_service")
 	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.retry.read.max_attempts", 3)
+	v.SetDefault("database.retry.read.initial_backoff", "50ms")
+	v.SetDefault("database.retry.read.max_backoff", "1s")
+	v.SetDefault("database.retry.write.max_attempts", 3)
+	v.SetDefault("database.retry.write.initial_backoff", "100ms")
+	v.SetDefault("database.retry.write.max_backoff", "2s")
 
 	// GitHub defaults
+	v.SetDefault("github.base_url", "https://api.github.com")
 	v.SetDefault("github.rate_limit", "1s")
 	v.SetDefault("github.request_timeout", "30s")
 	v.SetDefault("github.max_retries", 3)
 	v.SetDefault("github.retry_backoff", "2s")
 	v.SetDefault("github.interval", "1h") // Set default sync interval
+	v.SetDefault("github.fetch_commit_files", false)
+	v.SetDefault("github.commit_stats_batch", 0)
+	v.SetDefault("github.sync_issues", false)
+	v.SetDefault("github.sync_releases", false)
+	v.SetDefault("github.sync_contributor_stats", false)
+	v.SetDefault("github.sync_dependencies", false)
+	v.SetDefault("github.max_commit_pages", 10)
+	v.SetDefault("github.token_expiry_warning", "168h")
+
+	// GitLab defaults
+	v.SetDefault("gitlab.enabled", false)
+	v.SetDefault("gitlab.base_url", "https://gitlab.com/api/v4")
+
+	// Jira defaults
+	v.SetDefault("jira.enabled", false)
+	v.SetDefault("jira.refresh_interval", "6h")
 
 	// Monitor defaults
 	v.SetDefault("monitor.interval", "1h")
 	v.SetDefault("monitor.enabled", true)
+	v.SetDefault("monitor.default_history", "168h")
+	v.SetDefault("monitor.min_resync_interval", "5m")
+	v.SetDefault("monitor.import_window", "1h")
+	v.SetDefault("monitor.max_concurrent_backfills", 2)
+	v.SetDefault("monitor.deleted_retention", "0s")
+	v.SetDefault("monitor.discovery_interval", "24h")
+	v.SetDefault("monitor.sync_concurrency", 4)
+
+	// Maintenance defaults
+	v.SetDefault("maintenance.enabled", false)
+	v.SetDefault("maintenance.analyze_interval", "24h")
+	v.SetDefault("maintenance.reindex_interval", "168h")
+
+	// Worker defaults
+	v.SetDefault("worker.count", 1)
+	v.SetDefault("worker.poll_interval", "5s")
+	v.SetDefault("worker.max_concurrent_syncs", 0)
+
+	// Queue defaults
+	v.SetDefault("queue.lease_duration", "0s")
+	v.SetDefault("queue.max_job_panics", queue.DefaultMaxPanics)
+	v.SetDefault("queue.start_slo", "1m")
+	v.SetDefault("queue.stuck_timeout", "15m")
+	v.SetDefault("queue.reap_interval", "1m")
+
+	// Notification defaults
+	v.SetDefault("notify.sync_failure_threshold", 3)
+	v.SetDefault("notify.slack.enabled", false)
+	v.SetDefault("notify.email.enabled", false)
+	v.SetDefault("notify.email.port", 587)
+
+	// Webhook defaults
+	v.SetDefault("webhooks.delivery_interval", "10s")
+	v.SetDefault("webhooks.max_attempts", 6)
+
+	// Cache defaults
+	v.SetDefault("cache.enabled", true)
+	v.SetDefault("cache.ttl", "1m")
+	v.SetDefault("cache.max_entries", 10000)
+	v.SetDefault("cache.redis.db", 0)
+
+	// Report defaults
+	v.SetDefault("reports.digests", []string{})
+	v.SetDefault("reports.notify_digests", false)
+
+	// Auth defaults
+	v.SetDefault("auth.enabled", false)
+	v.SetDefault("auth.oauth.base_url", "https://github.com")
+	v.SetDefault("auth.oauth.default_role", string(models.RoleWriter))
+	v.SetDefault("auth.oauth.session_ttl", "720h")
 
 	// Log defaults
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "json")
+	v.SetDefault("log.buffer_size", 1000)
+
+	// Outbound HTTP defaults
+	v.SetDefault("http.insecure_skip_verify", false)
+	v.SetDefault("http.dial_timeout", "30s")
+	v.SetDefault("http.tls_handshake_timeout", "10s")
+	v.SetDefault("http.response_header_timeout", "0s")
+
+	// Tracing defaults
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("tracing.service_name", "github-service")
 }
 
 func (c *Config) Validate() error {
@@ -142,6 +503,19 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
 
+	if c.Server.AdminPort < 0 || c.Server.AdminPort > 65535 {
+		return fmt.Errorf("invalid admin port: %d", c.Server.AdminPort)
+	}
+	if c.Server.AdminPort != 0 && c.Server.AdminPort == c.Server.Port {
+		return fmt.Errorf("admin port must differ from server port: %d", c.Server.AdminPort)
+	}
+	if c.Server.DefaultPerPage < 1 {
+		return fmt.Errorf("server default_per_page must be at least 1")
+	}
+	if c.Server.MaxPerPage < c.Server.DefaultPerPage {
+		return fmt.Errorf("server max_per_page must be at least default_per_page (%d)", c.Server.DefaultPerPage)
+	}
+
 	if c.Database.Host == "" {
 		return fmt.Errorf("database host is required")
 	}
@@ -161,13 +535,183 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database sslmode is required")
 	}
 
-	if c.GitHub.Token == "" {
-		return fmt.Errorf("GitHub token is required")
+	if c.Database.Retry.Read.MaxAttempts < 1 || c.Database.Retry.Write.MaxAttempts < 1 {
+		return fmt.Errorf("database retry max_attempts must be at least 1")
+	}
+
+	if c.GitHub.App.Enabled() {
+		if c.GitHub.App.InstallationID == 0 {
+			return fmt.Errorf("GitHub app installation_id is required")
+		}
+		if c.GitHub.App.PrivateKey == "" && c.GitHub.App.PrivateKeyPath == "" {
+			return fmt.Errorf("GitHub app private_key or private_key_path is required")
+		}
+	} else if len(c.GitHub.AllTokens()) == 0 {
+		return fmt.Errorf("GitHub token or app credentials are required")
+	}
+
+	if c.GitLab.Enabled && c.GitLab.BaseURL == "" {
+		return fmt.Errorf("GitLab base_url is required")
+	}
+
+	if c.Jira.Enabled {
+		if c.Jira.BaseURL == "" {
+			return fmt.Errorf("Jira base_url is required")
+		}
+		if c.Jira.RefreshInterval <= 0 {
+			return fmt.Errorf("Jira refresh_interval must be positive")
+		}
+	}
+
+	if c.GitHub.BaseURL == "" {
+		return fmt.Errorf("GitHub base_url is required")
+	}
+	if c.GitHub.RequestTimeout <= 0 {
+		return fmt.Errorf("GitHub request_timeout must be positive")
+	}
+
+	if c.HTTP.DialTimeout < 0 || c.HTTP.TLSHandshakeTimeout < 0 || c.HTTP.ResponseHeaderTimeout < 0 {
+		return fmt.Errorf("http timeouts must not be negative")
 	}
 
 	if c.GitHub.Interval <= 0 {
 		return fmt.Errorf("GitHub sync interval must be positive")
 	}
+	if c.GitHub.MaxCommitPages < 1 {
+		return fmt.Errorf("GitHub max_commit_pages must be at least 1")
+	}
+	if c.GitHub.CommitStatsBatch < 0 {
+		return fmt.Errorf("GitHub commit_stats_batch must not be negative")
+	}
+	if c.GitHub.TokenExpiryWarn < 0 {
+		return fmt.Errorf("GitHub token_expiry_warning must not be negative")
+	}
+
+	if c.Monitor.DefaultHistory < 0 {
+		return fmt.Errorf("monitor default_history must not be negative")
+	}
+
+	if c.Monitor.MinResyncInterval < 0 {
+		return fmt.Errorf("monitor min_resync_interval must not be negative")
+	}
+
+	if c.Monitor.DeletedRetention < 0 {
+		return fmt.Errorf("monitor deleted_retention must not be negative")
+	}
+	if c.Monitor.DiscoveryInterval < 0 {
+		return fmt.Errorf("monitor discovery_interval must not be negative")
+	}
+
+	if c.Monitor.ImportWindow < 0 {
+		return fmt.Errorf("monitor import_window must not be negative")
+	}
+
+	if c.Monitor.MaxBackfills < 0 {
+		return fmt.Errorf("monitor max_concurrent_backfills must not be negative")
+	}
+
+	if c.Monitor.SyncConcurrency < 1 {
+		return fmt.Errorf("monitor sync_concurrency must be at least 1")
+	}
+
+	if c.Worker.Count < 1 {
+		return fmt.Errorf("worker count must be at least 1")
+	}
+	if c.Worker.PollInterval <= 0 {
+		return fmt.Errorf("worker poll_interval must be positive")
+	}
+	if c.Worker.MaxConcurrentSyncs < 0 {
+		return fmt.Errorf("worker max_concurrent_syncs must not be negative")
+	}
+
+	if c.Queue.LeaseDuration < 0 {
+		return fmt.Errorf("queue lease_duration must not be negative")
+	}
+	if c.Queue.MaxJobPanics < 0 {
+		return fmt.Errorf("queue max_job_panics must not be negative")
+	}
+	if c.Queue.StartSLO <= 0 {
+		return fmt.Errorf("queue start_slo must be positive")
+	}
+	if err := c.Notify.validate(); err != nil {
+		return err
+	}
+
+	if c.Webhooks.DeliveryInterval <= 0 {
+		return fmt.Errorf("webhooks delivery_interval must be positive")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		return fmt.Errorf("webhooks max_attempts must be at least 1")
+	}
+
+	if c.Cache.Enabled {
+		if c.Cache.TTL <= 0 {
+			return fmt.Errorf("cache ttl must be positive")
+		}
+		if c.Cache.Redis.Address == "" && c.Cache.MaxEntries < 1 {
+			return fmt.Errorf("cache max_entries must be at least 1")
+		}
+		if c.Cache.Redis.DB < 0 {
+			return fmt.Errorf("cache redis db must not be negative")
+		}
+	}
+
+	for _, period := range c.Reports.Digests {
+		if !models.ValidDigestPeriod(period) {
+			return fmt.Errorf("reports digests: unknown period %q, expected weekly or monthly", period)
+		}
+	}
+
+	if c.Queue.StuckTimeout < 0 {
+		return fmt.Errorf("queue stuck_timeout must not be negative")
+	}
+	if c.Queue.StuckTimeout > 0 && c.Queue.ReapInterval <= 0 {
+		return fmt.Errorf("queue reap_interval must be positive when stuck_timeout is set")
+	}
+	for jobType, limit := range c.Queue.Concurrency {
+		if !queue.JobType(jobType).Valid() {
+			return fmt.Errorf("queue concurrency: unknown job type %q", jobType)
+		}
+		if limit < 0 {
+			return fmt.Errorf("queue concurrency of %s must not be negative", jobType)
+		}
+	}
+
+	if c.Log.BufferSize < 0 {
+		return fmt.Errorf("log buffer_size must not be negative")
+	}
+
+	if c.Maintenance.AnalyzeInterval < 0 || c.Maintenance.ReindexInterval < 0 {
+		return fmt.Errorf("maintenance intervals must not be negative")
+	}
+
+	if c.Backup.Key != "" {
+		if _, err := backup.ParseKey(c.Backup.Key); err != nil {
+			return err
+		}
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample_ratio must be between 0 and 1")
+	}
+
+	if c.Auth.OAuth.Enabled() {
+		if !c.Auth.Enabled {
+			return fmt.Errorf("auth oauth requires auth enabled")
+		}
+		if c.Auth.OAuth.ClientSecret == "" {
+			return fmt.Errorf("auth oauth client_secret is required")
+		}
+		if c.Auth.OAuth.RedirectURL == "" {
+			return fmt.Errorf("auth oauth redirect_url is required")
+		}
+		if role := c.Auth.OAuth.DefaultRole; role != models.RoleReader && role != models.RoleWriter {
+			return fmt.Errorf("auth oauth default_role must be reader or writer, got %q", role)
+		}
+		if c.Auth.OAuth.SessionTTL <= 0 {
+			return fmt.Errorf("auth oauth session_ttl must be positive")
+		}
+	}
 
 	return nil
 }
@@ -182,3 +726,40 @@ func (c *Config) GetDSN() string {
 		c.Database.SSLMode,
 	)
 }
+
+// validate checks the enabled notification channels
+func (c NotifyConfig) validate() error {
+	if c.SyncFailureThreshold < 0 {
+		return fmt.Errorf("notify sync_failure_threshold must not be negative")
+	}
+	if c.Slack.Enabled {
+		if u, err := url.Parse(c.Slack.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("notify slack webhook_url must be an absolute http(s) URL")
+		}
+		if err := validateNotificationEvents("slack", c.Slack.Events); err != nil {
+			return err
+		}
+	}
+	if c.Email.Enabled {
+		if c.Email.Host == "" || c.Email.Port <= 0 {
+			return fmt.Errorf("notify email host and port are required")
+		}
+		if c.Email.From == "" || len(c.Email.To) == 0 {
+			return fmt.Errorf("notify email from and to are required")
+		}
+		if err := validateNotificationEvents("email", c.Email.Events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNotificationEvents checks that a channel only subscribes to known events
+func validateNotificationEvents(channel string, events []string) error {
+	for _, event := range events {
+		if !models.ValidNotificationEvent(event) {
+			return fmt.Errorf("notify %s events: unknown event %q", channel, event)
+		}
+	}
+	return nil
+}
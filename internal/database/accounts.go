@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+const accountColumns = `id, github_id, login, role, created_at, last_login_at`
+
+// scanAccount scans a row selected with accountColumns into an account
+func scanAccount(row rowScanner) (*models.Account, error) {
+	account := &models.Account{}
+	err := row.Scan(&account.ID, &account.GitHubID, &account.Login, &account.Role, &account.CreatedAt, &account.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// UpsertAccount records a login of a GitHub user, creating their account with
+// the given role on their first login. The login name is updated as GitHub
+// users can rename themselves; the role of an existing account is kept.
+func (d *DB) UpsertAccount(ctx context.Context, user *models.GitHubUser, role models.Role) (*models.Account, error) {
+	query := `
+		INSERT INTO accounts (github_id, login, role, last_login_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (github_id) DO UPDATE SET
+			login = EXCLUDED.login,
+			last_login_at = EXCLUDED.last_login_at
+		RETURNING ` + accountColumns
+
+	return scanAccount(d.db.QueryRowContext(ctx, query, user.ID, user.Login, role))
+}
+
+// GetAccount returns an account by ID, or nil when it doesn't exist
+func (d *DB) GetAccount(ctx context.Context, id int64) (*models.Account, error) {
+	account, err := scanAccount(d.db.QueryRowContext(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return account, err
+}
+
+// ListAccounts returns all accounts ordered by login
+func (d *DB) ListAccounts(ctx context.Context) ([]*models.Account, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+accountColumns+` FROM accounts ORDER BY login`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*models.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// UpdateAccountRole changes the role of an account along with that of its
+// live sessions, so a change takes effect without logging in again
+func (d *DB) UpdateAccountRole(ctx context.Context, id int64, role models.Role) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE accounts SET role = $1 WHERE id = $2`, role, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("account", id)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE api_keys SET role = $1 WHERE account_id = $2 AND revoked_at IS NULL`,
+		role, id,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAccount removes an account with its sessions and repository
+// associations. The repositories stay monitored.
+func (d *DB) DeleteAccount(ctx context.Context, id int64) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("account", id)
+	}
+	return nil
+}
+
+// AddAccountRepository associates a monitored repository with an account;
+// associating it again is a no-op
+func (d *DB) AddAccountRepository(ctx context.Context, accountID int64, fullName string) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO account_repositories (account_id, repository)
+		VALUES ($1, $2)
+		ON CONFLICT (account_id, repository) DO NOTHING`,
+		accountID, fullName,
+	)
+	return err
+}
+
+// RemoveAccountRepository dissociates a repository from an account, returning
+// how many accounts remain associated with it
+func (d *DB) RemoveAccountRepository(ctx context.Context, accountID int64, fullName string) (int, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM account_repositories WHERE account_id = $1 AND repository = $2`,
+		accountID, fullName,
+	)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, errors.NewNotFoundError("repository", fullName)
+	}
+
+	var remaining int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM account_repositories WHERE repository = $1`, fullName,
+	).Scan(&remaining); err != nil {
+		return 0, err
+	}
+	return remaining, tx.Commit()
+}
+
+// HasAccountRepository reports whether a repository is associated with an account
+func (d *DB) HasAccountRepository(ctx context.Context, accountID int64, fullName string) (bool, error) {
+	var exists bool
+	err := d.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM account_repositories WHERE account_id = $1 AND repository = $2)`,
+		accountID, fullName,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetAccountRepositoryListings returns a page of the actively monitored
+// repositories associated with an account, merged with their synced details
+func (d *DB) GetAccountRepositoryListings(ctx context.Context, accountID int64, page, perPage int) ([]*models.RepositoryListing, error) {
+	offset := (page - 1) * perPage
+	query := `
+		SELECT ` + repositoryListingColumns + `
+		FROM monitored_repositories m
+		JOIN account_repositories a ON a.repository = m.full_name AND a.account_id = $1
+		LEFT JOIN repositories r ON r.full_name = m.full_name
+		WHERE m.is_active = true
+		ORDER BY m.full_name
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := d.db.QueryContext(ctx, query, accountID, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []*models.RepositoryListing
+	for rows.Next() {
+		listing, err := scanRepositoryListing(rows)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, listing)
+	}
+	return listings, rows.Err()
+}
+
+// CountAccountRepositories returns the number of actively monitored
+// repositories associated with an account
+func (d *DB) CountAccountRepositories(ctx context.Context, accountID int64) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM monitored_repositories m
+		JOIN account_repositories a ON a.repository = m.full_name AND a.account_id = $1
+		WHERE m.is_active = true`, accountID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+const apiKeyColumns = `id, name, key_prefix, role, created_at, last_used_at, revoked_at, account_id, expires_at`
+
+// scanAPIKey scans a row selected with apiKeyColumns into an API key
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Role, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt, &key.AccountID, &key.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CreateAPIKey stores a new API key. Only the hash of the key is persisted.
+func (d *DB) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, role, account_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	return d.db.QueryRowContext(ctx, query, key.Name, key.Prefix, keyHash, key.Role, key.AccountID, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+}
+
+// GetAPIKeyByHash retrieves a non-revoked, unexpired API key by its hash
+func (d *DB) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+
+	key, err := scanAPIKey(d.db.QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// ListAPIKeys returns all API keys, including revoked ones
+func (d *DB) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// UpdateAPIKeyRole changes the role of an API key
+func (d *DB) UpdateAPIKeyRole(ctx context.Context, id int64, role models.Role) error {
+	query := `UPDATE api_keys SET role = $1 WHERE id = $2 AND revoked_at IS NULL`
+	result, err := d.db.ExecContext(ctx, query, role, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("api key", id)
+	}
+	return nil
+}
+
+// RevokeAPIKey marks an API key as revoked
+func (d *DB) RevokeAPIKey(ctx context.Context, id int64) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+	result, err := d.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("api key", id)
+	}
+	return nil
+}
+
+// TouchAPIKey records the last time an API key was used
+func (d *DB) TouchAPIKey(ctx context.Context, id int64) error {
+	_, err := d.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// AddAPIUsage adds request aggregates to the stored usage of their hour, endpoint and API key
+func (d *DB) AddAPIUsage(ctx context.Context, usage []*models.APIUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO api_usage (bucket, method, route, api_key_id, request_count, error_count, total_duration_us, max_duration_us)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (bucket, method, route, api_key_id) DO UPDATE
+		SET request_count = api_usage.request_count + EXCLUDED.request_count,
+			error_count = api_usage.error_count + EXCLUDED.error_count,
+			total_duration_us = api_usage.total_duration_us + EXCLUDED.total_duration_us,
+			max_duration_us = GREATEST(api_usage.max_duration_us, EXCLUDED.max_duration_us)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range usage {
+		_, err := stmt.ExecContext(ctx, u.Bucket, u.Method, u.Route, u.APIKeyID, u.Requests, u.Errors,
+			u.TotalDuration.Microseconds(), u.MaxDuration.Microseconds())
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetEndpointUsage summarizes API usage per endpoint for the hours starting within
+// [since, until], busiest first
+func (d *DB) GetEndpointUsage(ctx context.Context, since, until time.Time) ([]*models.EndpointUsage, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT method, route, SUM(request_count), SUM(error_count),
+			SUM(total_duration_us)::float8 / GREATEST(SUM(request_count), 1) / 1000,
+			MAX(max_duration_us)::float8 / 1000
+		FROM api_usage
+		WHERE bucket >= $1 AND bucket <= $2
+		GROUP BY method, route
+		ORDER BY SUM(request_count) DESC, route, method`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []*models.EndpointUsage
+	for rows.Next() {
+		u := &models.EndpointUsage{}
+		if err := rows.Scan(&u.Method, &u.Route, &u.Requests, &u.Errors, &u.AvgLatencyMs, &u.MaxLatencyMs); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// GetAPIKeyUsage summarizes API usage per API key for the hours starting within
+// [since, until], busiest first
+func (d *DB) GetAPIKeyUsage(ctx context.Context, since, until time.Time) ([]*models.APIKeyUsage, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT u.api_key_id, COALESCE(k.name, ''), COALESCE(k.key_prefix, ''),
+			SUM(u.request_count), SUM(u.error_count),
+			SUM(u.total_duration_us)::float8 / GREATEST(SUM(u.request_count), 1) / 1000
+		FROM api_usage u
+		LEFT JOIN api_keys k ON k.id = u.api_key_id
+		WHERE u.bucket >= $1 AND u.bucket <= $2
+		GROUP BY u.api_key_id, k.name, k.key_prefix
+		ORDER BY SUM(u.request_count) DESC, u.api_key_id`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []*models.APIKeyUsage
+	for rows.Next() {
+		u := &models.APIKeyUsage{}
+		if err := rows.Scan(&u.APIKeyID, &u.Name, &u.Prefix, &u.Requests, &u.Errors, &u.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+
+	"github-service/internal/models"
+)
+
+// authorEmailsCTE selects, as author_emails, every email of the identity the
+// lower-case email $1 belongs to: its canonical email and each email merged
+// into it
+const authorEmailsCTE = `
+	WITH identity AS (
+		SELECT COALESCE((SELECT canonical_email FROM author_identities WHERE email = $1), $1) AS email
+	), author_emails AS (
+		SELECT email FROM identity
+		UNION
+		SELECT ai.email FROM author_identities ai JOIN identity ON ai.canonical_email = identity.email
+	)`
+
+// authorCommitFilter keeps the commits of the author_emails, only those to
+// repository $2 unless it is 0
+const authorCommitFilter = `LOWER(c.author_email) IN (SELECT email FROM author_emails)
+		AND ($2::bigint = 0 OR c.repository_id = $2)`
+
+// GetAuthorCommits returns a page of the commits of an author, after merging
+// identities, newest first, across repositories or to repoID unless it is 0.
+// The email must be lower case.
+func (d *DB) GetAuthorCommits(ctx context.Context, email string, repoID int64, page, perPage int) ([]*models.AuthorCommit, error) {
+	rows, err := d.db.QueryContext(ctx, authorEmailsCTE+`
+		SELECT c.id, c.repository_id, c.sha, c.message, c.author_name, c.author_email,
+			c.author_date, c.committer_name, c.committer_email, c.commit_date, c.url,
+			c.additions, c.deletions, c.files_changed, COALESCE(c.commit_type, ''), c.created_at_local,
+			r.full_name
+		FROM commits c
+		`+retainedRepositoryJoin+`
+		WHERE `+authorCommitFilter+`
+		ORDER BY c.commit_date DESC, c.id DESC
+		LIMIT $3 OFFSET $4`, email, repoID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commits []*models.AuthorCommit
+	for rows.Next() {
+		commit := &models.AuthorCommit{}
+		err := rows.Scan(
+			&commit.ID, &commit.RepositoryID, &commit.SHA, &commit.Message,
+			&commit.AuthorName, &commit.AuthorEmail, &commit.AuthorDate,
+			&commit.CommitterName, &commit.CommitterEmail, &commit.CommitDate,
+			&commit.URL, &commit.Additions, &commit.Deletions, &commit.FilesChanged,
+			&commit.Type, &commit.CreatedAtLocal, &commit.Repository,
+		)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, rows.Err()
+}
+
+// CountAuthorCommits returns the number of commits GetAuthorCommits pages through
+func (d *DB) CountAuthorCommits(ctx context.Context, email string, repoID int64) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, authorEmailsCTE+`
+		SELECT COUNT(*)
+		FROM commits c
+		`+retainedRepositoryJoin+`
+		WHERE `+authorCommitFilter, email, repoID).Scan(&count)
+	return count, err
+}
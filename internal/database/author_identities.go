@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// authorIdentityColumns lists the author identity columns in the order expected by scanAuthorIdentities
+const authorIdentityColumns = `id, email, canonical_name, canonical_email, created_at`
+
+// Commit author expressions resolving a commit's author through author_identities.
+// Queries using them must join the identities as ai.
+const (
+	authorIdentityJoin   = `LEFT JOIN author_identities ai ON ai.email = LOWER(c.author_email)`
+	canonicalAuthorName  = `COALESCE(ai.canonical_name, c.author_name)`
+	canonicalAuthorEmail = `COALESCE(ai.canonical_email, c.author_email)`
+)
+
+// scanAuthorIdentities scans all remaining rows selected with authorIdentityColumns
+func scanAuthorIdentities(rows *sql.Rows) ([]*models.AuthorIdentity, error) {
+	var identities []*models.AuthorIdentity
+	for rows.Next() {
+		identity := &models.AuthorIdentity{}
+		if err := rows.Scan(&identity.ID, &identity.Email, &identity.CanonicalName, &identity.CanonicalEmail, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// MergeAuthorIdentities attributes the commits of every email in emails to the
+// identity of canonicalEmail, including identities previously merged into any of
+// them. Emails must be lower case and include canonicalEmail. When name is empty,
+// the name the canonical email most often commits under is used. It returns all
+// emails of the merged identity.
+func (d *DB) MergeAuthorIdentities(ctx context.Context, name, canonicalEmail string, emails []string) ([]*models.AuthorIdentity, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if name == "" {
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(
+				(SELECT canonical_name FROM author_identities WHERE email = $1),
+				(SELECT author_name FROM commits WHERE LOWER(author_email) = $1
+					GROUP BY author_name ORDER BY COUNT(*) DESC, author_name LIMIT 1),
+				$1)`, canonicalEmail).Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Identities already merged into one of the emails follow them
+	_, err = tx.ExecContext(ctx, `
+		UPDATE author_identities SET canonical_name = $1, canonical_email = $2
+		WHERE canonical_email = ANY($3)`, name, canonicalEmail, pq.Array(emails))
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO author_identities (email, canonical_name, canonical_email)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO UPDATE
+		SET canonical_name = EXCLUDED.canonical_name, canonical_email = EXCLUDED.canonical_email`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, email := range emails {
+		if _, err := stmt.ExecContext(ctx, email, name, canonicalEmail); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+authorIdentityColumns+` FROM author_identities
+		WHERE canonical_email = $1
+		ORDER BY email`, canonicalEmail)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := scanAuthorIdentities(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, tx.Commit()
+}
+
+// ListAuthorIdentities returns all merged author emails grouped by canonical identity
+func (d *DB) ListAuthorIdentities(ctx context.Context) ([]*models.AuthorIdentity, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+authorIdentityColumns+` FROM author_identities
+		ORDER BY canonical_email, email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuthorIdentities(rows)
+}
+
+// DeleteAuthorIdentity removes an email from its merged identity so its commits
+// are attributed to it alone again
+func (d *DB) DeleteAuthorIdentity(ctx context.Context, email string) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM author_identities WHERE email = $1`, email)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("author identity", email)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// GetTopCommitAuthors retrieves the top N commit authors across all repositories,
+// skipping the first offset authors. Only commits made within since and until,
+// when given, are counted, and only those of repositories whose primary language
+// matches language case-insensitively unless it is empty, that are members of
+// group unless it is empty and that account added unless it is 0. Commits of
+// merged author identities count toward their canonical identity.
+func (d *DB) GetTopCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64, limit, offset int) ([]*models.CommitStats, error) {
+	query := `
+		SELECT ` + canonicalAuthorName + ` AS author_name, ` + canonicalAuthorEmail + ` AS author_email,
+			COUNT(*) as commit_count, ` + commitLineTotals + `
+		FROM commits c
+		` + retainedRepositoryJoin + `
+		` + authorIdentityJoin + `
+		WHERE ($1::timestamptz IS NULL OR c.commit_date >= $1)
+			AND ($2::timestamptz IS NULL OR c.commit_date <= $2)
+			AND ` + repositoryLanguageFilter + `
+			AND ` + repositoryGroupFilter("$6") + `
+			AND ` + repositoryAccountFilter("$7") + `
+		GROUP BY 1, 2
+		ORDER BY commit_count DESC, author_name, author_email
+		LIMIT $4 OFFSET $5`
+
+	rows, err := d.db.QueryContext(ctx, query, since, until, language, limit, offset, group, account)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommitStats(rows)
+}
+
+// GetTopCommitAuthorsByRepository retrieves the top N commit authors for a specific repository,
+// skipping the first offset authors. Only commits made within since and until,
+// when given, are counted. Commits of merged author identities count toward
+// their canonical identity.
+func (d *DB) GetTopCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time, limit, offset int) ([]*models.CommitStats, error) {
+	query := `
+		SELECT ` + canonicalAuthorName + ` AS author_name, ` + canonicalAuthorEmail + ` AS author_email,
+			COUNT(*) as commit_count, ` + commitLineTotals + `
+		FROM commits c
+		` + authorIdentityJoin + `
+		WHERE c.repository_id = $1
+			AND ($2::timestamptz IS NULL OR c.commit_date >= $2)
+			AND ($3::timestamptz IS NULL OR c.commit_date <= $3)
+		GROUP BY 1, 2
+		ORDER BY commit_count DESC, author_name, author_email
+		LIMIT $4 OFFSET $5`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommitStats(rows)
+}
+
+// retainedRepositoryJoin joins the repository of each commit, leaving out the
+// commits of removed repositories whose data is only retained, so they stop
+// counting toward statistics across repositories
+const retainedRepositoryJoin = `JOIN repositories r ON r.id = c.repository_id AND r.deleted_at IS NULL`
+
+// repositoryLanguageFilter keeps the commits of repositories whose primary
+// language matches $3, ignoring case; an empty $3 keeps all
+const repositoryLanguageFilter = `($3::text = '' OR LOWER(r.language) = LOWER($3))`
+
+// repositoryGroupFilter keeps the commits of the members of the repository
+// group named by the parameter; an empty name keeps all
+func repositoryGroupFilter(param string) string {
+	return `(` + param + `::text = '' OR r.full_name IN (
+				SELECT gm.repository FROM repository_group_members gm
+				JOIN repository_groups g ON g.id = gm.group_id
+				WHERE g.name = ` + param + `))`
+}
+
+// repositoryAccountFilter keeps the commits of the repositories the account
+// with the ID in the parameter added; 0 keeps all
+func repositoryAccountFilter(param string) string {
+	return `(` + param + `::bigint = 0 OR r.full_name IN (
+				SELECT ar.repository FROM account_repositories ar
+				WHERE ar.account_id = ` + param + `))`
+}
+
+// commitLineTotals selects the lines changed by an author's enriched commits;
+// commits without stats count as unchanged
+const commitLineTotals = `COALESCE(SUM(c.additions), 0) AS additions,
+			COALESCE(SUM(c.deletions), 0) AS deletions,
+			COALESCE(SUM(c.files_changed), 0) AS files_changed,
+			COUNT(c.additions) AS enriched_commits`
+
+// scanCommitStats scans author commit counts selected as author_name, author_email,
+// commit_count followed by commitLineTotals
+func scanCommitStats(rows *sql.Rows) ([]*models.CommitStats, error) {
+	var stats []*models.CommitStats
+	for rows.Next() {
+		stat := &models.CommitStats{}
+		err := rows.Scan(&stat.AuthorName, &stat.AuthorEmail, &stat.Count,
+			&stat.Additions, &stat.Deletions, &stat.FilesChanged, &stat.EnrichedCommits)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// CountCommitAuthors returns the number of distinct authors, after merging
+// identities, of commits made within since and until to repositories whose
+// primary language matches language, that are members of group and that
+// account added, ignoring each when it is empty or 0
+func (d *DB) CountCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT DISTINCT `+canonicalAuthorName+`, `+canonicalAuthorEmail+`
+			FROM commits c
+			`+retainedRepositoryJoin+`
+			`+authorIdentityJoin+`
+			WHERE ($1::timestamptz IS NULL OR c.commit_date >= $1)
+				AND ($2::timestamptz IS NULL OR c.commit_date <= $2)
+				AND `+repositoryLanguageFilter+`
+				AND `+repositoryGroupFilter("$4")+`
+				AND `+repositoryAccountFilter("$5")+`
+		) authors`, since, until, language, group, account).Scan(&count)
+	return count, err
+}
+
+// CountCommitAuthorsByRepository returns the number of distinct authors, after
+// merging identities, of a repository's commits made within since and until
+func (d *DB) CountCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT DISTINCT `+canonicalAuthorName+`, `+canonicalAuthorEmail+`
+			FROM commits c
+			`+authorIdentityJoin+`
+			WHERE c.repository_id = $1
+				AND ($2::timestamptz IS NULL OR c.commit_date >= $2)
+				AND ($3::timestamptz IS NULL OR c.commit_date <= $3)
+		) authors`, repoID, since, until).Scan(&count)
+	return count, err
+}
+
+// GetAuthorCommitCounts returns the number of commits each author, after merging
+// identities, made to a repository within since and until, fewest first
+func (d *DB) GetAuthorCommitCounts(ctx context.Context, repoID int64, since, until *time.Time) ([]int, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT COUNT(*) AS commit_count
+		FROM commits c
+		`+authorIdentityJoin+`
+		WHERE c.repository_id = $1
+			AND ($2::timestamptz IS NULL OR c.commit_date >= $2)
+			AND ($3::timestamptz IS NULL OR c.commit_date <= $3)
+		GROUP BY `+canonicalAuthorName+`, `+canonicalAuthorEmail+`
+		ORDER BY commit_count`, repoID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []int
+	for rows.Next() {
+		var count int
+		if err := rows.Scan(&count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// activityOrder maps the metrics repositories are ranked by to their ORDER BY clauses
+var activityOrder = map[string]string{
+	models.ActivityMetricCommits: "commit_count DESC, author_count DESC",
+	models.ActivityMetricAuthors: "author_count DESC, commit_count DESC",
+}
+
+// GetTopRepositories returns the monitored repositories with the most commits,
+// or authors, made since the given time, only ranking the members of group
+// unless it is empty and the repositories account added unless it is 0.
+// Commits of merged author identities count toward their canonical identity.
+func (d *DB) GetTopRepositories(ctx context.Context, since time.Time, metric, group string, account int64, limit int) ([]*models.RepositoryActivity, error) {
+	order, ok := activityOrder[metric]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown activity metric: %s", errors.ErrInvalidInput, metric)
+	}
+
+	query := `
+		SELECT r.full_name, COALESCE(r.language, ''),
+			COUNT(*) AS commit_count, COUNT(DISTINCT ` + canonicalAuthorEmail + `) AS author_count
+		FROM commits c
+		` + retainedRepositoryJoin + `
+		JOIN monitored_repositories m ON m.full_name = r.full_name
+		` + authorIdentityJoin + `
+		WHERE c.commit_date >= $1
+			AND ` + repositoryGroupFilter("$3") + `
+			AND ` + repositoryAccountFilter("$4") + `
+		GROUP BY r.id, r.full_name, r.language
+		ORDER BY ` + order + `, r.full_name
+		LIMIT $2`
+
+	rows, err := d.db.QueryContext(ctx, query, since, limit, group, account)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repositories []*models.RepositoryActivity
+	for rows.Next() {
+		repo := &models.RepositoryActivity{}
+		if err := rows.Scan(&repo.FullName, &repo.Language, &repo.CommitCount, &repo.AuthorCount); err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, repo)
+	}
+	return repositories, rows.Err()
+}
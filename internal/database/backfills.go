@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// GetBackfillCursor returns how far the commit history backfill of a
+// repository got, or nil when no backfill is unfinished
+func (d *DB) GetBackfillCursor(ctx context.Context, repoID int64) (*models.BackfillCursor, error) {
+	cursor := &models.BackfillCursor{RepositoryID: repoID}
+	var since, oldestDate sql.NullTime
+	err := d.db.QueryRowContext(ctx, `
+		SELECT since, page, oldest_sha, oldest_date, updated_at
+		FROM repository_backfills
+		WHERE repository_id = $1`, repoID).
+		Scan(&since, &cursor.Page, &cursor.OldestSHA, &oldestDate, &cursor.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if since.Valid {
+		cursor.Since = &since.Time
+	}
+	cursor.OldestDate = oldestDate.Time
+	return cursor, nil
+}
+
+// SaveBackfillCursor records the last commit page a backfill stored
+func (d *DB) SaveBackfillCursor(ctx context.Context, cursor *models.BackfillCursor) error {
+	query := `
+		INSERT INTO repository_backfills (repository_id, since, page, oldest_sha, oldest_date, updated_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+		ON CONFLICT (repository_id) DO UPDATE
+		SET since = EXCLUDED.since, page = EXCLUDED.page, oldest_sha = EXCLUDED.oldest_sha,
+			oldest_date = EXCLUDED.oldest_date, updated_at = EXCLUDED.updated_at`
+	_, err := d.db.ExecContext(ctx, query, cursor.RepositoryID, cursor.Since, cursor.Page, cursor.OldestSHA, cursor.OldestDate)
+	return err
+}
+
+// DeleteBackfillCursor forgets the cursor of a backfill that finished. Only
+// the cursor of the backfill with the given since is removed; nil is the full
+// history.
+func (d *DB) DeleteBackfillCursor(ctx context.Context, repoID int64, since *time.Time) error {
+	_, err := d.db.ExecContext(ctx, `
+		DELETE FROM repository_backfills
+		WHERE repository_id = $1 AND since IS NOT DISTINCT FROM $2`, repoID, since)
+	return err
+}
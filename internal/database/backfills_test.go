@@ -0,0 +1,59 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillCursor(t *testing.T) {
+	_, db := setupTestDB(t)
+	ctx := context.Background()
+
+	cursor, err := db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	// A full-history backfill
+	oldest := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, db.SaveBackfillCursor(ctx, &models.BackfillCursor{RepositoryID: 1, Page: 3, OldestSHA: "abc123", OldestDate: oldest}))
+	require.NoError(t, db.SaveBackfillCursor(ctx, &models.BackfillCursor{RepositoryID: 1, Page: 4, OldestSHA: "def456", OldestDate: oldest}))
+	cursor, err = db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	assert.Nil(t, cursor.Since)
+	assert.Equal(t, 4, cursor.Page)
+	assert.Equal(t, "def456", cursor.OldestSHA)
+	assert.True(t, oldest.Equal(cursor.OldestDate))
+
+	// Finishing a backfill with another since leaves the cursor
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, db.DeleteBackfillCursor(ctx, 1, &since))
+	cursor, err = db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+
+	require.NoError(t, db.DeleteBackfillCursor(ctx, 1, nil))
+	cursor, err = db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	// A windowed backfill is only cleared by a backfill with its since
+	require.NoError(t, db.SaveBackfillCursor(ctx, &models.BackfillCursor{RepositoryID: 1, Since: &since, Page: 2, OldestSHA: "abc123", OldestDate: oldest}))
+	require.NoError(t, db.DeleteBackfillCursor(ctx, 1, nil))
+	cursor, err = db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	require.NotNil(t, cursor.Since)
+	assert.True(t, since.Equal(*cursor.Since))
+
+	require.NoError(t, db.DeleteBackfillCursor(ctx, 1, &since))
+	cursor, err = db.GetBackfillCursor(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+}
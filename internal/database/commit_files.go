@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// CreateCommitFiles stores the files changed by a repository's commit, ignoring
+// files already stored
+func (d *DB) CreateCommitFiles(ctx context.Context, repoID, commitID int64, files []models.CommitFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO commit_files (repository_id, commit_id, filename, extension, status, additions, deletions, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (commit_id, filename) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range files {
+		if _, err := stmt.ExecContext(ctx, repoID, commitID, f.Filename, f.Extension, f.Status, f.Additions, f.Deletions, f.Changes); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCommitFiles retrieves the files changed by a commit, ordered by filename
+func (d *DB) GetCommitFiles(ctx context.Context, commitID int64) ([]models.CommitFile, error) {
+	query := `
+		SELECT commit_id, filename, extension, status, additions, deletions, changes
+		FROM commit_files
+		WHERE commit_id = $1
+		ORDER BY filename`
+
+	rows, err := d.db.QueryContext(ctx, query, commitID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []models.CommitFile
+	for rows.Next() {
+		var f models.CommitFile
+		if err := rows.Scan(&f.CommitID, &f.Filename, &f.Extension, &f.Status, &f.Additions, &f.Deletions, &f.Changes); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// GetFileExtensionStats aggregates file changes by extension for a repository
+// over commits in the optional [since, until] range, most changed first
+func (d *DB) GetFileExtensionStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.FileExtensionStats, error) {
+	query := `
+		SELECT f.extension,
+			COUNT(DISTINCT f.commit_id) AS commit_count,
+			COUNT(*) AS files_changed,
+			COALESCE(SUM(f.additions), 0),
+			COALESCE(SUM(f.deletions), 0),
+			COALESCE(SUM(f.changes), 0) AS changes
+		FROM commit_files f
+		JOIN commits c ON c.repository_id = f.repository_id AND c.id = f.commit_id
+		WHERE f.repository_id = $1 AND c.repository_id = $1
+			AND ($2::timestamptz IS NULL OR c.commit_date >= $2)
+			AND ($3::timestamptz IS NULL OR c.commit_date <= $3)
+		GROUP BY f.extension
+		ORDER BY changes DESC, files_changed DESC`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*models.FileExtensionStats
+	for rows.Next() {
+		stat := &models.FileExtensionStats{}
+		err := rows.Scan(&stat.Extension, &stat.CommitCount, &stat.FilesChanged, &stat.Additions, &stat.Deletions, &stat.Changes)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// commitHookColumns lists the commit hook columns in the order expected by scanCommitHook
+const commitHookColumns = `id, repository_id, webhook_url, authors, paths, created_at, updated_at`
+
+// scanCommitHook scans a row selected with commitHookColumns into a commit hook
+func scanCommitHook(row rowScanner) (*models.CommitHook, error) {
+	hook := &models.CommitHook{}
+	err := row.Scan(
+		&hook.ID, &hook.RepositoryID, &hook.WebhookURL, pq.Array(&hook.Authors), pq.Array(&hook.Paths),
+		&hook.CreatedAt, &hook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// CreateCommitHook stores a new commit hook
+func (d *DB) CreateCommitHook(ctx context.Context, hook *models.CommitHook) error {
+	query := `
+		INSERT INTO commit_hooks (repository_id, webhook_url, authors, paths)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+
+	return d.db.QueryRowContext(ctx, query,
+		hook.RepositoryID, hook.WebhookURL, pq.Array(hook.Authors), pq.Array(hook.Paths),
+	).Scan(&hook.ID, &hook.CreatedAt, &hook.UpdatedAt)
+}
+
+// GetCommitHook retrieves a hook of a repository, or nil if it doesn't exist
+func (d *DB) GetCommitHook(ctx context.Context, repoID, id int64) (*models.CommitHook, error) {
+	query := `SELECT ` + commitHookColumns + ` FROM commit_hooks WHERE repository_id = $1 AND id = $2`
+
+	hook, err := scanCommitHook(d.db.QueryRowContext(ctx, query, repoID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return hook, err
+}
+
+// ListCommitHooks returns the hooks of a repository in creation order
+func (d *DB) ListCommitHooks(ctx context.Context, repoID int64) ([]*models.CommitHook, error) {
+	query := `SELECT ` + commitHookColumns + ` FROM commit_hooks WHERE repository_id = $1 ORDER BY id`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*models.CommitHook
+	for rows.Next() {
+		hook, err := scanCommitHook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// UpdateCommitHook changes the definition of a hook
+func (d *DB) UpdateCommitHook(ctx context.Context, hook *models.CommitHook) error {
+	query := `
+		UPDATE commit_hooks
+		SET webhook_url = $1, authors = $2, paths = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE repository_id = $4 AND id = $5
+		RETURNING ` + commitHookColumns
+
+	updated, err := scanCommitHook(d.db.QueryRowContext(ctx, query,
+		hook.WebhookURL, pq.Array(hook.Authors), pq.Array(hook.Paths), hook.RepositoryID, hook.ID,
+	))
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundError("commit hook", hook.ID)
+	}
+	if err != nil {
+		return err
+	}
+	*hook = *updated
+	return nil
+}
+
+// DeleteCommitHook removes a hook of a repository
+func (d *DB) DeleteCommitHook(ctx context.Context, repoID, id int64) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM commit_hooks WHERE repository_id = $1 AND id = $2`, repoID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("commit hook", id)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// UpdateCommitStats stores the diff stats of a repository's commit
+func (d *DB) UpdateCommitStats(ctx context.Context, repoID, commitID int64, additions, deletions, filesChanged int) error {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE commits SET additions = $3, deletions = $4, files_changed = $5
+		WHERE repository_id = $1 AND id = $2`, repoID, commitID, additions, deletions, filesChanged)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("commit", commitID)
+	}
+	return nil
+}
+
+// GetCommitsMissingStats returns up to limit commits of a repository that have
+// not been enriched with diff stats yet, newest first
+func (d *DB) GetCommitsMissingStats(ctx context.Context, repoID int64, limit int) ([]*models.Commit, error) {
+	query := `
+		SELECT ` + commitColumns + ` FROM commits
+		WHERE repository_id = $1 AND additions IS NULL
+		ORDER BY commit_date DESC
+		LIMIT $2`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommits(rows)
+}
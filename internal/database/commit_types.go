@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// commitTypeBackfill classifies commits stored before commit types were recorded,
+// with the rules of the service's commit type parser
+const commitTypeBackfill = `UPDATE commits SET commit_type = CASE
+	WHEN lower(substring(message from '^([A-Za-z]+)(\([^()]*\))?!?: ')) IN
+		('build', 'chore', 'ci', 'docs', 'feat', 'fix', 'perf', 'refactor', 'revert', 'style', 'test')
+		THEN lower(substring(message from '^([A-Za-z]+)(\([^()]*\))?!?: '))
+	WHEN message LIKE 'Revert "%' THEN 'revert'
+	ELSE 'other'
+END
+WHERE commit_type IS NULL;`
+
+// GetCommitTypeStats counts a repository's commits by Conventional Commits type
+// over the optional [since, until] range, most frequent first
+func (d *DB) GetCommitTypeStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.CommitTypeStats, error) {
+	query := `
+		SELECT COALESCE(commit_type, 'other') AS type,
+			COUNT(*) AS commit_count,
+			ROUND(100.0 * COUNT(*) / SUM(COUNT(*)) OVER (), 1)
+		FROM commits
+		WHERE repository_id = $1
+			AND ($2::timestamptz IS NULL OR commit_date >= $2)
+			AND ($3::timestamptz IS NULL OR commit_date <= $3)
+		GROUP BY type
+		ORDER BY commit_count DESC, type`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*models.CommitTypeStats
+	for rows.Next() {
+		stat := &models.CommitTypeStats{}
+		if err := rows.Scan(&stat.Type, &stat.CommitCount, &stat.Percentage); err != nil {
+			return nil, err
+		}
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,417 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github-service/internal/models"
+)
+
+// insertCommitQuery stores a commit unless its SHA is already stored for the
+// repository, returning its ID only when it was stored
+const insertCommitQuery = `
+	INSERT INTO commits (
+		repository_id, sha, message, author_name, author_email,
+		author_date, committer_name, committer_email, commit_date, url, sync_run_id, commit_type
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''))
+	ON CONFLICT (repository_id, sha) DO NOTHING
+	RETURNING id`
+
+// insertCommit stores a commit with the given statement, reporting whether it
+// was new
+func insertCommit(ctx context.Context, stmt *sql.Stmt, commit *models.Commit) (bool, error) {
+	err := stmt.QueryRowContext(ctx,
+		commit.RepositoryID, commit.SHA, commit.Message,
+		commit.AuthorName, commit.AuthorEmail, commit.AuthorDate,
+		commit.CommitterName, commit.CommitterEmail, commit.CommitDate,
+		commit.URL, commit.SyncRunID, commit.Type,
+	).Scan(&commit.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateCommit stores a commit, reporting false without changing the stored
+// commit when its SHA is already stored for the repository
+func (d *DB) CreateCommit(ctx context.Context, commit *models.Commit) (bool, error) {
+	created, _, err := d.CreateCommits(ctx, []*models.Commit{commit})
+	return created == 1, err
+}
+
+// CreateCommits stores a batch of commits in one transaction, skipping those
+// whose SHA is already stored for their repository. Stored commits get their
+// ID; skipped ones are left with ID 0. It returns how many were stored and how
+// many already existed.
+func (d *DB) CreateCommits(ctx context.Context, commits []*models.Commit) (created, existing int, err error) {
+	if len(commits) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, insertCommitQuery)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer stmt.Close()
+
+	for _, commit := range commits {
+		commit.ID = 0
+		isNew, err := insertCommit(ctx, stmt, commit)
+		if err != nil {
+			return 0, 0, err
+		}
+		if isNew {
+			created++
+		} else {
+			existing++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return created, existing, nil
+}
+
+// commitColumns lists the commit columns in the order expected by scanCommit
+const commitColumns = `id, repository_id, sha, message, author_name, author_email,
+	author_date, committer_name, committer_email, commit_date, url,
+	additions, deletions, files_changed, COALESCE(commit_type, ''), created_at_local`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCommit scans a row selected with commitColumns into a commit
+func scanCommit(row rowScanner) (*models.Commit, error) {
+	commit := &models.Commit{}
+	err := row.Scan(
+		&commit.ID, &commit.RepositoryID, &commit.SHA, &commit.Message,
+		&commit.AuthorName, &commit.AuthorEmail, &commit.AuthorDate,
+		&commit.CommitterName, &commit.CommitterEmail, &commit.CommitDate,
+		&commit.URL, &commit.Additions, &commit.Deletions, &commit.FilesChanged,
+		&commit.Type, &commit.CreatedAtLocal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// scanCommits scans all remaining rows into commits
+func scanCommits(rows *sql.Rows) ([]*models.Commit, error) {
+	var commits []*models.Commit
+	for rows.Next() {
+		commit, err := scanCommit(rows)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, rows.Err()
+}
+
+// GetCommitsBySHA retrieves a commit by its SHA
+func (d *DB) GetCommitsBySHA(ctx context.Context, repoID int64, sha string) (*models.Commit, error) {
+	query := `SELECT ` + commitColumns + ` FROM commits WHERE repository_id = $1 AND sha = $2`
+
+	commit, err := scanCommit(d.db.QueryRowContext(ctx, query, repoID, sha))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return commit, err
+}
+
+// FindCommitsBySHAPrefix retrieves up to limit commits whose SHA starts with prefix,
+// ordered by SHA
+func (d *DB) FindCommitsBySHAPrefix(ctx context.Context, repoID int64, prefix string, limit int) ([]*models.Commit, error) {
+	query := `
+		SELECT ` + commitColumns + ` FROM commits
+		WHERE repository_id = $1 AND sha LIKE $2
+		ORDER BY sha
+		LIMIT $3`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, strings.ToLower(prefix)+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommits(rows)
+}
+
+// GetNeighborCommits returns the commits made directly before and after the given commit
+// in the same repository. Either is nil at the ends of the history.
+func (d *DB) GetNeighborCommits(ctx context.Context, commit *models.Commit) (previous, next *models.Commit, err error) {
+	previousQuery := `
+		SELECT ` + commitColumns + ` FROM commits
+		WHERE repository_id = $1 AND (commit_date, id) < ($2, $3)
+		ORDER BY commit_date DESC, id DESC
+		LIMIT 1`
+	previous, err = scanCommit(d.db.QueryRowContext(ctx, previousQuery, commit.RepositoryID, commit.CommitDate, commit.ID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+
+	nextQuery := `
+		SELECT ` + commitColumns + ` FROM commits
+		WHERE repository_id = $1 AND (commit_date, id) > ($2, $3)
+		ORDER BY commit_date, id
+		LIMIT 1`
+	next, err = scanCommit(d.db.QueryRowContext(ctx, nextQuery, commit.RepositoryID, commit.CommitDate, commit.ID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, nil, err
+	}
+
+	return previous, next, nil
+}
+
+// GetCommitsByRepository retrieves commits for a repository with pagination
+func (d *DB) GetCommitsByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Commit, error) {
+	offset := (page - 1) * perPage
+	query := `
+		SELECT ` + commitColumns + ` FROM commits 
+		WHERE repository_id = $1 
+		ORDER BY commit_date DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommits(rows)
+}
+
+// GetLatestCommits returns the newest commits of a repository, ties broken by
+// ID so the order is stable between calls
+func (d *DB) GetLatestCommits(ctx context.Context, repoID int64, count int) ([]*models.Commit, error) {
+	query := `
+		SELECT ` + commitColumns + ` FROM commits
+		WHERE repository_id = $1
+		ORDER BY commit_date DESC, id DESC
+		LIMIT $2`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommits(rows)
+}
+
+// GetLatestCommitKeys returns the newest commits of a repository in the order of
+// GetLatestCommits with only their SHA and diff stats set, the fields that can
+// differ between two reads. It is answered from idx_commits_repository_latest alone.
+func (d *DB) GetLatestCommitKeys(ctx context.Context, repoID int64, count int) ([]*models.Commit, error) {
+	query := `
+		SELECT sha, additions, deletions, files_changed FROM commits
+		WHERE repository_id = $1
+		ORDER BY commit_date DESC, id DESC
+		LIMIT $2`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, count)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var commits []*models.Commit
+	for rows.Next() {
+		commit := &models.Commit{}
+		if err := rows.Scan(&commit.SHA, &commit.Additions, &commit.Deletions, &commit.FilesChanged); err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, rows.Err()
+}
+
+// StreamCommitsByRepository calls fn for each commit of a page matching the
+// filter as rows are scanned, without collecting the page in memory
+func (d *DB) StreamCommitsByRepository(ctx context.Context, repoID int64, filter models.CommitFilter, page, perPage int, fn func(*models.Commit) error) error {
+	where, args := buildCommitFilter(repoID, filter)
+	args = append(args, perPage, (page-1)*perPage)
+	query := fmt.Sprintf(`
+		SELECT %s FROM commits
+		WHERE %s
+		ORDER BY commit_date DESC, id DESC
+		LIMIT $%d OFFSET $%d`, commitColumns, where, len(args)-1, len(args))
+
+	return d.streamCommits(ctx, query, args, fn)
+}
+
+// StreamCommitsAfter calls fn for each of up to limit commits matching the
+// filter that follow the cursor, newest first. Unlike an offset, the cursor
+// seeks idx_commits_repository_latest directly, however deep it is.
+func (d *DB) StreamCommitsAfter(ctx context.Context, repoID int64, filter models.CommitFilter, cursor *models.CommitCursor, limit int, fn func(*models.Commit) error) error {
+	where, args := buildCommitFilter(repoID, filter)
+	if cursor != nil {
+		args = append(args, cursor.CommitDate, cursor.ID)
+		where += fmt.Sprintf(" AND (commit_date, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT %s FROM commits
+		WHERE %s
+		ORDER BY commit_date DESC, id DESC
+		LIMIT $%d`, commitColumns, where, len(args))
+
+	return d.streamCommits(ctx, query, args, fn)
+}
+
+// streamCommits calls fn for each commit a query returns as rows are scanned
+func (d *DB) streamCommits(ctx context.Context, query string, args []interface{}, fn func(*models.Commit) error) error {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		commit, err := scanCommit(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(commit); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// buildCommitFilter builds the WHERE clause and arguments for listing commits.
+// The repository ID is always the first argument.
+func buildCommitFilter(repoID int64, filter models.CommitFilter) (string, []interface{}) {
+	conditions := []string{"repository_id = $1"}
+	args := []interface{}{repoID}
+
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Author != "" {
+		// Matches idx_commits_repository_author_name and _email
+		args = append(args, strings.ToLower(filter.Author))
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("(LOWER(author_name) = $%d OR LOWER(author_email) = $%d)", n, n))
+	}
+	if filter.Since != nil {
+		addCondition("commit_date >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCondition("commit_date <= $%d", *filter.Until)
+	}
+	if filter.SHAPrefix != "" {
+		addCondition("sha LIKE $%d", strings.ToLower(filter.SHAPrefix)+"%")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// likeEscaper escapes the characters LIKE patterns treat specially
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike returns s as a LIKE pattern matching only s itself
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// buildCommitSearchFilter builds the WHERE clause and arguments for a commit search.
+// The repository ID is always the first argument.
+func buildCommitSearchFilter(repoID int64, opts models.CommitSearchOptions) (string, []interface{}) {
+	conditions := []string{"repository_id = $1"}
+	args := []interface{}{repoID}
+
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if opts.Query != "" {
+		addCondition("to_tsvector('english', message) @@ websearch_to_tsquery('english', $%d)", opts.Query)
+	}
+	if opts.Author != "" {
+		// Matches the author anywhere in a name or email, taking the input literally
+		args = append(args, "%"+escapeLike(opts.Author)+"%")
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf(`(author_name ILIKE $%d ESCAPE '\' OR author_email ILIKE $%d ESCAPE '\')`, n, n))
+	}
+	if opts.Since != nil {
+		addCondition("commit_date >= $%d", *opts.Since)
+	}
+	if opts.Until != nil {
+		addCondition("commit_date <= $%d", *opts.Until)
+	}
+	if opts.SHAPrefix != "" {
+		addCondition("sha LIKE $%d", strings.ToLower(opts.SHAPrefix)+"%")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// SearchCommits performs a full-text search over commit messages of a repository with pagination.
+// Results are ordered by relevance when a query is given, then by commit date.
+func (d *DB) SearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions, page, perPage int) ([]*models.Commit, error) {
+	where, args := buildCommitSearchFilter(repoID, opts)
+
+	orderBy := "commit_date DESC"
+	if opts.Query != "" {
+		orderBy = "ts_rank(to_tsvector('english', message), websearch_to_tsquery('english', $2)) DESC, commit_date DESC"
+	}
+
+	args = append(args, perPage, (page-1)*perPage)
+	query := fmt.Sprintf(`
+		SELECT %s FROM commits
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, commitColumns, where, orderBy, len(args)-1, len(args))
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommits(rows)
+}
+
+// CountSearchCommits returns the total number of commits matching a search
+func (d *DB) CountSearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions) (int, error) {
+	where, args := buildCommitSearchFilter(repoID, opts)
+
+	var count int
+	query := `SELECT COUNT(*) FROM commits WHERE ` + where
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// CountCommits returns the number of commits of a repository matching the filter
+func (d *DB) CountCommits(ctx context.Context, repoID int64, filter models.CommitFilter) (int, error) {
+	where, args := buildCommitFilter(repoID, filter)
+
+	var count int
+	query := `SELECT COUNT(*) FROM commits WHERE ` + where
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// GetCommitCountByRepository returns the total number of commits for a repository
+func (d *DB) GetCommitCountByRepository(ctx context.Context, repoID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM commits WHERE repository_id = $1`
+	err := d.db.QueryRowContext(ctx, query, repoID).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,132 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github-service/internal/database"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCommits returns commits of a repository with the given SHAs
+func testCommits(repoID int64, shas ...string) []*models.Commit {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commits := make([]*models.Commit, len(shas))
+	for i, sha := range shas {
+		commits[i] = &models.Commit{
+			RepositoryID: repoID,
+			SHA:          sha,
+			Message:      fmt.Sprintf("Commit %s", sha),
+			AuthorName:   "author",
+			AuthorEmail:  "author@example.com",
+			AuthorDate:   date,
+			CommitDate:   date,
+		}
+	}
+	return commits
+}
+
+func TestCreateCommits(t *testing.T) {
+	pg, db := setupTestDB(t)
+	ctx := context.Background()
+
+	var before int
+	require.NoError(t, pg.DB.QueryRow(`SELECT COUNT(*) FROM commits WHERE repository_id = 1`).Scan(&before))
+
+	first := testCommits(1, "batch01", "batch02", "batch03")
+	created, existing, err := db.CreateCommits(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, 3, created)
+	assert.Equal(t, 0, existing)
+	for _, commit := range first {
+		assert.NotZero(t, commit.ID, commit.SHA)
+	}
+
+	// A batch overlapping the first one and the fixtures, with a SHA repeated
+	// within it
+	second := testCommits(1, "batch02", "batch03", "batch04", "abc123", "batch05", "batch05")
+	created, existing, err = db.CreateCommits(ctx, second)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 4, existing)
+	for i, stored := range []bool{false, false, true, false, true, false} {
+		assert.Equal(t, stored, second[i].ID != 0, "ID of %s at %d", second[i].SHA, i)
+	}
+
+	// The same SHAs are new in another repository
+	created, existing, err = db.CreateCommits(ctx, testCommits(2, "batch01", "batch04"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 0, existing)
+
+	var after, duplicates int
+	require.NoError(t, pg.DB.QueryRow(`SELECT COUNT(*) FROM commits WHERE repository_id = 1`).Scan(&after))
+	assert.Equal(t, before+5, after)
+	require.NoError(t, pg.DB.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT repository_id, sha FROM commits GROUP BY repository_id, sha HAVING COUNT(*) > 1
+		) d`).Scan(&duplicates))
+	assert.Zero(t, duplicates)
+
+	// A skipped commit leaves the stored one unchanged
+	stored, err := db.GetCommitsBySHA(ctx, 1, "batch02")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, first[1].ID, stored.ID)
+}
+
+func TestStreamCommitsAfterEqualDates(t *testing.T) {
+	pg, db := setupTestDB(t)
+	ctx := context.Background()
+
+	// Seven commits on the same date, so pages split them by ID
+	_, _, err := db.CreateCommits(ctx, testCommits(1, "same01", "same02", "same03", "same04", "same05", "same06", "same07"))
+	require.NoError(t, err)
+
+	var want []string
+	rows, err := pg.DB.Query(`SELECT sha FROM commits WHERE repository_id = 1 ORDER BY commit_date DESC, id DESC`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var sha string
+		require.NoError(t, rows.Scan(&sha))
+		want = append(want, sha)
+	}
+	require.NoError(t, rows.Err())
+	rows.Close()
+
+	var got []string
+	var cursor *models.CommitCursor
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, len(want), "paging does not end")
+		var last *models.Commit
+		err := db.StreamCommitsAfter(ctx, 1, models.CommitFilter{}, cursor, 3, func(commit *models.Commit) error {
+			got = append(got, commit.SHA)
+			last = commit
+			return nil
+		})
+		require.NoError(t, err)
+		if last == nil {
+			break
+		}
+		cursor = &models.CommitCursor{CommitDate: last.CommitDate, ID: last.ID}
+	}
+	assert.Equal(t, want, got, "every commit once, in order")
+}
+
+func TestEscapeLike(t *testing.T) {
+	for input, want := range map[string]string{
+		"octocat":     "octocat",
+		"100%":        `100\%`,
+		"first_last":  `first\_last`,
+		`domain\user`: `domain\\user`,
+		`%_\`:         `\%\_\\`,
+		"":            "",
+	} {
+		assert.Equal(t, want, database.EscapeLike(input), input)
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// UpsertContributorWeeks stores the weekly activity of a repository's
+// contributors, replacing the stored figures of weeks that were recomputed
+func (d *DB) UpsertContributorWeeks(ctx context.Context, repoID int64, contributors []models.ContributorStats) error {
+	if len(contributors) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO contributor_weeks (repository_id, login, week, commits, additions, deletions)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (repository_id, login, week) DO UPDATE SET
+			commits = EXCLUDED.commits,
+			additions = EXCLUDED.additions,
+			deletions = EXCLUDED.deletions`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range contributors {
+		for _, w := range c.Weeks {
+			if _, err := stmt.ExecContext(ctx,
+				repoID, c.Login, w.Week.UTC().Format("2006-01-02"), w.Commits, w.Additions, w.Deletions,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetContributorStats returns the contributors of a repository with their weeks
+// of activity, oldest first, optionally bounded by since and until. Contributors
+// are ordered by their commits in those weeks, most first.
+func (d *DB) GetContributorStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.ContributorStats, error) {
+	query := `
+		SELECT login, week, commits, additions, deletions
+		FROM contributor_weeks
+		WHERE repository_id = $1
+			AND ($2::date IS NULL OR week >= $2::date)
+			AND ($3::date IS NULL OR week <= $3::date)
+		ORDER BY SUM(commits) OVER (PARTITION BY login) DESC, login, week`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contributors []*models.ContributorStats
+	var current *models.ContributorStats
+	for rows.Next() {
+		var login string
+		var week models.ContributorWeek
+		if err := rows.Scan(&login, &week.Week, &week.Commits, &week.Additions, &week.Deletions); err != nil {
+			return nil, err
+		}
+		if current == nil || current.Login != login {
+			current = &models.ContributorStats{Login: login}
+			contributors = append(contributors, current)
+		}
+		current.Weeks = append(current.Weeks, week)
+		current.Commits += week.Commits
+		current.Additions += week.Additions
+		current.Deletions += week.Deletions
+	}
+	return contributors, rows.Err()
+}
@@ -1,13 +1,12 @@
 package database
 
 import (
-	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"time"
 
-	"github-service/internal/models"
-
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
@@ -19,7 +18,8 @@ type DB struct {
 const schema = `
 CREATE TABLE IF NOT EXISTS repositories (
 	id SERIAL PRIMARY KEY,
-	github_id BIGINT UNIQUE NOT NULL,
+	github_id BIGINT NOT NULL,
+	provider TEXT NOT NULL DEFAULT 'github',
 	name TEXT NOT NULL,
 	full_name TEXT NOT NULL UNIQUE,
 	description TEXT,
@@ -33,12 +33,17 @@ CREATE TABLE IF NOT EXISTS repositories (
 	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
 	last_commit_check TIMESTAMP WITH TIME ZONE,
 	commits_since TIMESTAMP WITH TIME ZONE,
+	deleted_at TIMESTAMP WITH TIME ZONE,
 	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
 	updated_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE repositories DROP CONSTRAINT IF EXISTS repositories_github_id_key;
+
 CREATE TABLE IF NOT EXISTS commits (
-	id SERIAL PRIMARY KEY,
+	id SERIAL,
 	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
 	sha TEXT NOT NULL,
 	message TEXT NOT NULL,
@@ -49,23 +54,375 @@ CREATE TABLE IF NOT EXISTS commits (
 	committer_email TEXT NOT NULL,
 	commit_date TIMESTAMP WITH TIME ZONE NOT NULL,
 	url TEXT NOT NULL,
+	sync_run_id INTEGER,
+	additions INTEGER,
+	deletions INTEGER,
+	files_changed INTEGER,
 	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (repository_id, id),
 	UNIQUE(repository_id, sha)
-);
+) PARTITION BY HASH (repository_id);
+
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS sync_run_id INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS additions INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS deletions INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS files_changed INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS commit_type TEXT;
+` + commitTypeBackfill + `
+
+` + commitPartitioning + `
 
 CREATE TABLE IF NOT EXISTS monitored_repositories (
 	id SERIAL PRIMARY KEY,
 	full_name TEXT NOT NULL UNIQUE,
+	provider TEXT NOT NULL DEFAULT 'github',
 	last_sync_time TIMESTAMP WITH TIME ZONE,
 	sync_interval TEXT NOT NULL,
 	is_active BOOLEAN DEFAULT true,
+	last_resync_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS last_resync_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS commit_files (
+	id SERIAL PRIMARY KEY,
+	commit_id INTEGER NOT NULL,
+	filename TEXT NOT NULL,
+	extension TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	additions INTEGER NOT NULL DEFAULT 0,
+	deletions INTEGER NOT NULL DEFAULT 0,
+	changes INTEGER NOT NULL DEFAULT 0,
+	repository_id INTEGER NOT NULL,
+	UNIQUE(commit_id, filename),
+	FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS issues (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	github_id BIGINT NOT NULL,
+	number INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	body TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL,
+	author_login TEXT NOT NULL DEFAULT '',
+	comments INTEGER NOT NULL DEFAULT 0,
+	url TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	closed_at TIMESTAMP WITH TIME ZONE,
+	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(repository_id, number)
+);
+
+CREATE TABLE IF NOT EXISTS repository_stats_history (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	snapshot_date DATE NOT NULL,
+	stars_count INTEGER NOT NULL DEFAULT 0,
+	forks_count INTEGER NOT NULL DEFAULT 0,
+	watchers_count INTEGER NOT NULL DEFAULT 0,
+	open_issues_count INTEGER NOT NULL DEFAULT 0,
+	recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(repository_id, snapshot_date)
+);
+
+CREATE TABLE IF NOT EXISTS threshold_rules (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	metric TEXT NOT NULL,
+	operator TEXT NOT NULL,
+	threshold BIGINT NOT NULL,
+	webhook_url TEXT NOT NULL,
+	triggered BOOLEAN NOT NULL DEFAULT false,
+	last_value BIGINT,
+	last_triggered_at TIMESTAMP WITH TIME ZONE,
 	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
 	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
+ALTER TABLE threshold_rules ADD COLUMN IF NOT EXISTS window_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE threshold_rules ALTER COLUMN webhook_url SET DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS threshold_alerts (
+	id BIGSERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	rule_id INTEGER REFERENCES threshold_rules(id) ON DELETE SET NULL,
+	event TEXT NOT NULL,
+	metric TEXT NOT NULL,
+	operator TEXT NOT NULL,
+	threshold BIGINT NOT NULL,
+	window_days INTEGER NOT NULL DEFAULT 0,
+	value BIGINT NOT NULL,
+	previous_value BIGINT NOT NULL,
+	occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS repository_dependencies (
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	manifest TEXT NOT NULL,
+	ecosystem TEXT NOT NULL,
+	name TEXT NOT NULL,
+	version TEXT NOT NULL DEFAULT '',
+	scope TEXT NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (repository_id, manifest, name)
+);
+
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+	id SERIAL PRIMARY KEY,
+	task TEXT NOT NULL,
+	target TEXT NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT,
+	started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+	id SERIAL PRIMARY KEY,
+	github_id BIGINT NOT NULL UNIQUE,
+	login TEXT NOT NULL,
+	role TEXT NOT NULL DEFAULT 'reader',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	last_login_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	key_prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	role TEXT NOT NULL DEFAULT 'reader',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP WITH TIME ZONE,
+	revoked_at TIMESTAMP WITH TIME ZONE
+);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS account_repositories (
+	account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+	repository TEXT NOT NULL,
+	added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (account_id, repository)
+);
+
+CREATE TABLE IF NOT EXISTS repository_backfills (
+	repository_id INTEGER PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+	since TIMESTAMP WITH TIME ZONE,
+	page INTEGER NOT NULL,
+	oldest_sha TEXT NOT NULL,
+	oldest_date TIMESTAMP WITH TIME ZONE,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS author_identities (
+	id SERIAL PRIMARY KEY,
+	email TEXT NOT NULL UNIQUE,
+	canonical_name TEXT NOT NULL,
+	canonical_email TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS api_usage (
+	id SERIAL PRIMARY KEY,
+	bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+	method TEXT NOT NULL,
+	route TEXT NOT NULL,
+	api_key_id BIGINT NOT NULL DEFAULT 0,
+	request_count BIGINT NOT NULL DEFAULT 0,
+	error_count BIGINT NOT NULL DEFAULT 0,
+	total_duration_us BIGINT NOT NULL DEFAULT 0,
+	max_duration_us BIGINT NOT NULL DEFAULT 0,
+	UNIQUE (bucket, method, route, api_key_id)
+);
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	incremental BOOLEAN NOT NULL DEFAULT false,
+	since TIMESTAMP WITH TIME ZONE,
+	status TEXT NOT NULL,
+	new_commits INTEGER NOT NULL DEFAULT 0,
+	error TEXT,
+	started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	commit_sha TEXT NOT NULL,
+	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(repository_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS releases (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	github_id BIGINT NOT NULL,
+	tag_name TEXT NOT NULL,
+	name TEXT NOT NULL DEFAULT '',
+	draft BOOLEAN NOT NULL DEFAULT false,
+	prerelease BOOLEAN NOT NULL DEFAULT false,
+	author_login TEXT NOT NULL DEFAULT '',
+	url TEXT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	published_at TIMESTAMP WITH TIME ZONE,
+	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(repository_id, github_id)
+);
+
+CREATE TABLE IF NOT EXISTS commit_hooks (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	webhook_url TEXT NOT NULL,
+	authors TEXT[] NOT NULL DEFAULT '{}',
+	paths TEXT[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS monthly_reports (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	month TEXT NOT NULL,
+	report JSONB NOT NULL,
+	markdown TEXT NOT NULL,
+	generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	UNIQUE(repository_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS digests (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	period TEXT NOT NULL,
+	period_start DATE NOT NULL,
+	report JSONB NOT NULL,
+	markdown TEXT NOT NULL,
+	generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	UNIQUE(repository_id, period, period_start)
+);
+
+CREATE TABLE IF NOT EXISTS repository_groups (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS repository_group_members (
+	group_id INTEGER NOT NULL REFERENCES repository_groups(id) ON DELETE CASCADE,
+	repository TEXT NOT NULL,
+	PRIMARY KEY (group_id, repository)
+);
+
+CREATE TABLE IF NOT EXISTS tickets (
+	key TEXT PRIMARY KEY,
+	summary TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT '',
+	status_category TEXT NOT NULL DEFAULT '',
+	found BOOLEAN NOT NULL DEFAULT FALSE,
+	refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS commit_tickets (
+	commit_id INTEGER NOT NULL,
+	ticket_key TEXT NOT NULL,
+	repository_id INTEGER NOT NULL,
+	PRIMARY KEY (commit_id, ticket_key),
+	FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS contributor_weeks (
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	login TEXT NOT NULL,
+	week DATE NOT NULL,
+	commits INTEGER NOT NULL DEFAULT 0,
+	additions INTEGER NOT NULL DEFAULT 0,
+	deletions INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (repository_id, login, week)
+);
+
+CREATE TABLE IF NOT EXISTS watched_users (
+	username TEXT PRIMARY KEY,
+	min_stars INTEGER NOT NULL DEFAULT 0,
+	language TEXT NOT NULL DEFAULT '',
+	updated_within_seconds BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS discovered_repositories (
+	username TEXT NOT NULL REFERENCES watched_users(username) ON DELETE CASCADE,
+	full_name TEXT NOT NULL,
+	discovered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (username, full_name)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	repositories TEXT[] NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id BIGSERIAL PRIMARY KEY,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	repository TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	response_status INTEGER,
+	error TEXT NOT NULL DEFAULT '',
+	next_attempt_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	delivered_at TIMESTAMP WITH TIME ZONE
+);
 
 CREATE INDEX IF NOT EXISTS idx_commits_repository_date ON commits(repository_id, commit_date DESC);
 CREATE INDEX IF NOT EXISTS idx_commits_author ON commits(author_name, author_email);
+CREATE INDEX IF NOT EXISTS idx_commits_message_search ON commits USING GIN (to_tsvector('english', message));
+CREATE INDEX IF NOT EXISTS idx_commits_repository_sha ON commits(repository_id, sha text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_commit_files_extension ON commit_files(extension);
+CREATE INDEX IF NOT EXISTS idx_commit_files_repository ON commit_files(repository_id, commit_id);
+CREATE INDEX IF NOT EXISTS idx_issues_repository_state ON issues(repository_id, state, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_threshold_rules_repository ON threshold_rules(repository_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_runs_started ON maintenance_runs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_author_identities_canonical ON author_identities(canonical_email);
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_lower ON commits(LOWER(author_email));
+CREATE INDEX IF NOT EXISTS idx_api_usage_bucket ON api_usage(bucket);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_repository ON sync_runs(repository_id, id);
+CREATE INDEX IF NOT EXISTS idx_commits_sync_run ON commits(repository_id, sync_run_id);
+CREATE INDEX IF NOT EXISTS idx_commits_missing_stats ON commits(repository_id, commit_date DESC) WHERE additions IS NULL;
+CREATE INDEX IF NOT EXISTS idx_releases_repository_published ON releases(repository_id, published_at DESC);
+CREATE INDEX IF NOT EXISTS idx_repositories_deleted ON repositories(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_commit_hooks_repository ON commit_hooks(repository_id);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_latest ON commits(repository_id, commit_date DESC, id DESC) INCLUDE (sha, additions, deletions, files_changed);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repositories_provider_id ON repositories(provider, github_id);
+CREATE INDEX IF NOT EXISTS idx_commit_tickets_key ON commit_tickets(ticket_key);
+CREATE INDEX IF NOT EXISTS idx_tickets_refreshed ON tickets(refreshed_at NULLS FIRST);
 CREATE INDEX IF NOT EXISTS idx_monitored_repositories_active ON monitored_repositories(is_active);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_name ON commits(repository_id, LOWER(author_name), commit_date DESC);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_email ON commits(repository_id, LOWER(author_email), commit_date DESC);
+CREATE INDEX IF NOT EXISTS idx_webhooks_repositories ON webhooks USING GIN (repositories);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_date ON commits(LOWER(author_email), commit_date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_group_members_repository ON repository_group_members(repository);
+CREATE INDEX IF NOT EXISTS idx_threshold_alerts_repository ON threshold_alerts(repository_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_dependencies_package ON repository_dependencies(ecosystem, name);
+CREATE INDEX IF NOT EXISTS idx_account_repositories_repository ON account_repositories(repository);
 `
 
 // New creates a new database connection
@@ -96,274 +453,58 @@ func New(dsn string) (*DB, error) {
 	return &DB{db: db}, nil
 }
 
+// initializeDB applies the schema unless the checksum recorded in schema_version
+// shows it is already in place. Replicas starting together serialize on an
+// advisory lock, so only the first applies the DDL and the others skip it.
 func initializeDB(db *sql.DB) error {
-	_, err := db.Exec(schema)
-	return err
-}
-
-// Close closes the database connection
-func (d *DB) Close() error {
-	return d.db.Close()
-}
-
-// CreateRepository creates a new repository record
-func (d *DB) CreateRepository(ctx context.Context, repo *models.Repository) error {
-	fmt.Printf("Creating repository: %s (GitHub ID: %d)\n", repo.FullName, repo.GitHubID)
-	query := `
-		INSERT INTO repositories (
-			github_id, name, full_name, description, url, language,
-			forks_count, stars_count, open_issues_count, watchers_count,
-			created_at, updated_at, commits_since
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		RETURNING id`
-
-	err := d.db.QueryRowContext(ctx, query,
-		repo.GitHubID, repo.Name, repo.FullName, repo.Description, repo.URL,
-		repo.Language, repo.ForksCount, repo.StarsCount, repo.OpenIssuesCount,
-		repo.WatchersCount, repo.CreatedAt, repo.UpdatedAt, repo.CommitsSince,
-	).Scan(&repo.ID)
-
-	if err != nil {
-		fmt.Printf("Error creating repository %s: %v\n", repo.FullName, err)
-		return err
-	}
-	fmt.Printf("Successfully created repository %s with ID %d\n", repo.FullName, repo.ID)
-
-	return nil
-}
-
-// UpdateRepository updates an existing repository record
-func (d *DB) UpdateRepository(ctx context.Context, repo *models.Repository) error {
-	query := `
-		UPDATE repositories SET
-			name = $1, description = $2, url = $3, language = $4,
-			forks_count = $5, stars_count = $6, open_issues_count = $7,
-			watchers_count = $8, updated_at = $9, updated_at_local = CURRENT_TIMESTAMP
-		WHERE github_id = $10`
-
-	result, err := d.db.ExecContext(ctx, query,
-		repo.Name, repo.Description, repo.URL, repo.Language,
-		repo.ForksCount, repo.StarsCount, repo.OpenIssuesCount,
-		repo.WatchersCount, repo.UpdatedAt, repo.GitHubID,
-	)
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("repository not found: %d", repo.GitHubID)
-	}
-
-	return nil
-}
-
-// GetRepositoryByName retrieves a repository by its full name
-func (d *DB) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
-	query := `SELECT * FROM repositories WHERE full_name = $1`
-
-	repo := &models.Repository{}
-	err := d.db.QueryRowContext(ctx, query, fullName).Scan(
-		&repo.ID, &repo.GitHubID, &repo.Name, &repo.FullName,
-		&repo.Description, &repo.URL, &repo.Language, &repo.ForksCount,
-		&repo.StarsCount, &repo.OpenIssuesCount, &repo.WatchersCount,
-		&repo.CreatedAt, &repo.UpdatedAt, &repo.LastCommitCheck,
-		&repo.CommitsSince, &repo.CreatedAtLocal, &repo.UpdatedAtLocal,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	return repo, err
-}
-
-// UpdateLastCommitCheck updates the last commit check timestamp
-func (d *DB) UpdateLastCommitCheck(ctx context.Context, repoID int64, lastCheck time.Time) error {
-	query := `UPDATE repositories SET last_commit_check = $1, updated_at_local = CURRENT_TIMESTAMP WHERE id = $2`
-	result, err := d.db.ExecContext(ctx, query, &lastCheck, repoID)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("repository not found: %d", repoID)
-	}
-	return nil
-}
-
-// SetCommitsSince sets the commits_since timestamp
-func (d *DB) SetCommitsSince(ctx context.Context, repoID int64, since time.Time) error {
-	query := `UPDATE repositories SET commits_since = $1, updated_at_local = CURRENT_TIMESTAMP WHERE id = $2`
-	result, err := d.db.ExecContext(ctx, query, &since, repoID)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("repository not found: %d", repoID)
-	}
-	return nil
-}
-
-// CreateCommit creates a new commit record
-func (d *DB) CreateCommit(ctx context.Context, commit *models.Commit) error {
-	query := `
-		INSERT INTO commits (
-			repository_id, sha, message, author_name, author_email,
-			author_date, committer_name, committer_email, commit_date, url
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		RETURNING id`
-
-	err := d.db.QueryRowContext(ctx, query,
-		commit.RepositoryID, commit.SHA, commit.Message,
-		commit.AuthorName, commit.AuthorEmail, commit.AuthorDate,
-		commit.CommitterName, commit.CommitterEmail, commit.CommitDate,
-		commit.URL,
-	).Scan(&commit.ID)
-
-	return err
-}
-
-// GetCommitsBySHA retrieves a commit by its SHA
-func (d *DB) GetCommitsBySHA(ctx context.Context, repoID int64, sha string) (*models.Commit, error) {
-	query := `SELECT * FROM commits WHERE repository_id = $1 AND sha = $2`
-
-	commit := &models.Commit{}
-	err := d.db.QueryRowContext(ctx, query, repoID, sha).Scan(
-		&commit.ID, &commit.RepositoryID, &commit.SHA, &commit.Message,
-		&commit.AuthorName, &commit.AuthorEmail, &commit.AuthorDate,
-		&commit.CommitterName, &commit.CommitterEmail, &commit.CommitDate,
-		&commit.URL, &commit.CreatedAtLocal,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	// Transaction level, so the lock is released on commit or rollback
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1, hashtext('schema'))`, schemaLockClass); err != nil {
+		return fmt.Errorf("error acquiring schema lock: %w", err)
 	}
-	return commit, err
-}
 
-// GetCommitsByRepository retrieves commits for a repository with pagination
-func (d *DB) GetCommitsByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Commit, error) {
-	offset := (page - 1) * perPage
-	query := `
-		SELECT * FROM commits 
-		WHERE repository_id = $1 
-		ORDER BY commit_date DESC 
-		LIMIT $2 OFFSET $3`
-
-	rows, err := d.db.QueryContext(ctx, query, repoID, perPage, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var commits []*models.Commit
-	for rows.Next() {
-		commit := &models.Commit{}
-		err := rows.Scan(
-			&commit.ID, &commit.RepositoryID, &commit.SHA, &commit.Message,
-			&commit.AuthorName, &commit.AuthorEmail, &commit.AuthorDate,
-			&commit.CommitterName, &commit.CommitterEmail, &commit.CommitDate,
-			&commit.URL, &commit.CreatedAtLocal,
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
 		)
-		if err != nil {
-			return nil, err
-		}
-		commits = append(commits, commit)
+	`); err != nil {
+		return fmt.Errorf("error creating schema_version table: %w", err)
 	}
-	return commits, rows.Err()
-}
 
-// GetCommitCountByRepository returns the total number of commits for a repository
-func (d *DB) GetCommitCountByRepository(ctx context.Context, repoID int64) (int, error) {
-	var count int
-	query := `SELECT COUNT(*) FROM commits WHERE repository_id = $1`
-	err := d.db.QueryRowContext(ctx, query, repoID).Scan(&count)
-	return count, err
-}
-
-// GetTopCommitAuthors retrieves the top N commit authors by commit count
-func (d *DB) GetTopCommitAuthors(ctx context.Context, limit int) ([]*models.CommitStats, error) {
-	query := `
-		SELECT author_name, author_email, COUNT(*) as commit_count
-		FROM commits
-		GROUP BY author_name, author_email
-		ORDER BY commit_count DESC
-		LIMIT $1`
-
-	rows, err := d.db.QueryContext(ctx, query, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var stats []*models.CommitStats
-	for rows.Next() {
-		stat := &models.CommitStats{}
-		err := rows.Scan(&stat.AuthorName, &stat.AuthorEmail, &stat.Count)
-		if err != nil {
-			return nil, err
-		}
-		stats = append(stats, stat)
-	}
-	return stats, rows.Err()
-}
+	sum := sha256.Sum256([]byte(schema))
+	checksum := hex.EncodeToString(sum[:])
 
-// GetTopCommitAuthorsByRepository retrieves the top N commit authors for a specific repository
-func (d *DB) GetTopCommitAuthorsByRepository(ctx context.Context, repoID int64, limit int) ([]*models.CommitStats, error) {
-	query := `
-		SELECT author_name, author_email, COUNT(*) as commit_count
-		FROM commits
-		WHERE repository_id = $1
-		GROUP BY author_name, author_email
-		ORDER BY commit_count DESC
-		LIMIT $2`
-
-	rows, err := d.db.QueryContext(ctx, query, repoID, limit)
-	if err != nil {
-		return nil, err
+	var applied string
+	err = tx.QueryRow(`SELECT checksum FROM schema_version WHERE id = 1`).Scan(&applied)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error reading schema version: %w", err)
 	}
-	defer rows.Close()
-
-	var stats []*models.CommitStats
-	for rows.Next() {
-		stat := &models.CommitStats{}
-		err := rows.Scan(&stat.AuthorName, &stat.AuthorEmail, &stat.Count)
-		if err != nil {
-			return nil, err
-		}
-		stats = append(stats, stat)
+	if applied == checksum {
+		return tx.Commit()
 	}
-	return stats, rows.Err()
-}
 
-// DeleteRepository deletes a repository and its associated commits from the database
-func (d *DB) DeleteRepository(ctx context.Context, repoID int64) error {
-	// The commits will be automatically deleted due to ON DELETE CASCADE
-	query := `DELETE FROM repositories WHERE id = $1`
-	result, err := d.db.ExecContext(ctx, query, repoID)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
+	if _, err := tx.Exec(schema); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("repository not found: %d", repoID)
+	if _, err := tx.Exec(`
+		INSERT INTO schema_version (id, checksum, applied_at) VALUES (1, $1, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = EXCLUDED.applied_at
+	`, checksum); err != nil {
+		return fmt.Errorf("error recording schema version: %w", err)
 	}
+	return tx.Commit()
+}
 
-	return nil
+// Close closes the database connection
+func (d *DB) Close() error {
+	return d.db.Close()
 }
 
 // NewFromDB creates a new DB instance from an existing *sql.DB
@@ -371,99 +512,6 @@ func NewFromDB(db *sql.DB) *DB {
 	return &DB{db: db}
 }
 
-// MonitoredRepository represents a repository being monitored
-type MonitoredRepository struct {
-	ID           int64
-	FullName     string
-	LastSyncTime time.Time
-	SyncInterval time.Duration
-	IsActive     bool
-}
-
-// AddMonitoredRepository adds a repository to the monitoring list
-func (d *DB) AddMonitoredRepository(ctx context.Context, fullName string, syncInterval time.Duration) error {
-	query := `
-		INSERT INTO monitored_repositories (full_name, last_sync_time, sync_interval, is_active)
-		VALUES ($1, $2, $3, true)
-		ON CONFLICT (full_name) 
-		DO UPDATE SET sync_interval = $3, is_active = true, updated_at = CURRENT_TIMESTAMP
-	`
-	_, err := d.db.ExecContext(ctx, query, fullName, time.Now().UTC(), syncInterval.String())
-	return err
-}
-
-// GetMonitoredRepositories returns all actively monitored repositories
-func (d *DB) GetMonitoredRepositories(ctx context.Context) ([]models.MonitoredRepository, error) {
-	query := `
-		SELECT id, full_name, last_sync_time, sync_interval, is_active
-		FROM monitored_repositories
-		WHERE is_active = true
-	`
-	rows, err := d.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var repos []models.MonitoredRepository
-	for rows.Next() {
-		var repo models.MonitoredRepository
-		var intervalStr string
-		err := rows.Scan(&repo.ID, &repo.FullName, &repo.LastSyncTime, &intervalStr, &repo.IsActive)
-		if err != nil {
-			return nil, err
-		}
-		repo.SyncInterval, err = time.ParseDuration(intervalStr)
-		if err != nil {
-			return nil, fmt.Errorf("invalid sync interval for %s: %w", repo.FullName, err)
-		}
-		repos = append(repos, repo)
-	}
-	return repos, rows.Err()
-}
-
-// UpdateMonitoredRepositorySync updates the last sync time for a monitored repository
-func (d *DB) UpdateMonitoredRepositorySync(ctx context.Context, fullName string, lastSyncTime time.Time) error {
-	query := `
-		UPDATE monitored_repositories
-		SET last_sync_time = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE full_name = $1
-	`
-	result, err := d.db.ExecContext(ctx, query, fullName, lastSyncTime)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("monitored repository not found: %s", fullName)
-	}
-	return nil
-}
-
-// RemoveMonitoredRepository marks a repository as inactive
-func (d *DB) RemoveMonitoredRepository(ctx context.Context, fullName string) error {
-	query := `
-		UPDATE monitored_repositories
-		SET is_active = false, updated_at = CURRENT_TIMESTAMP
-		WHERE full_name = $1
-	`
-	result, err := d.db.ExecContext(ctx, query, fullName)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("monitored repository not found: %s", fullName)
-	}
-	return nil
-}
-
 // DB returns the underlying sql.DB instance
 func (d *DB) DB() *sql.DB {
 	return d.db
@@ -0,0 +1,84 @@
+package database_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github-service/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaChecksum is the checksum initializeDB records for the current schema
+func schemaChecksum() string {
+	sum := sha256.Sum256([]byte(database.Schema))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInitializeDBConcurrently(t *testing.T) {
+	pg, _ := setupTestDB(t)
+	db := pg.DB
+	_, err := db.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public;`)
+	require.NoError(t, err)
+
+	// Replicas starting together against an empty database
+	const replicas = 5
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = database.InitializeDB(db)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		assert.NoError(t, err, "replica %d", i)
+	}
+
+	var checksum string
+	var appliedAt time.Time
+	require.NoError(t, db.QueryRow(`SELECT checksum, applied_at FROM schema_version`).Scan(&checksum, &appliedAt))
+	assert.Equal(t, schemaChecksum(), checksum)
+	assert.Equal(t, 16, countRows(t, db, `SELECT COUNT(*) FROM pg_inherits WHERE inhparent = 'commits'::regclass`))
+
+	// A later start finds the schema in place and leaves it be
+	require.NoError(t, database.InitializeDB(db))
+	var again time.Time
+	require.NoError(t, db.QueryRow(`SELECT applied_at FROM schema_version`).Scan(&again))
+	assert.True(t, appliedAt.Equal(again), "schema applied again at %s", again)
+}
+
+func TestInitializeDBChecksum(t *testing.T) {
+	pg, _ := setupTestDB(t)
+	db := pg.DB
+	require.NoError(t, database.InitializeDB(db))
+	hasBackfills := `SELECT COUNT(*) FROM pg_class WHERE relname = 'repository_backfills'`
+
+	// With a matching checksum the schema is not applied, so a dropped table
+	// stays dropped
+	_, err := db.Exec(`DROP TABLE repository_backfills`)
+	require.NoError(t, err)
+	require.NoError(t, database.InitializeDB(db))
+	assert.Zero(t, countRows(t, db, hasBackfills))
+
+	// A schema that changed since it was applied is applied again
+	_, err = db.Exec(`UPDATE schema_version SET checksum = 'stale', applied_at = applied_at - INTERVAL '1 day'`)
+	require.NoError(t, err)
+	var before time.Time
+	require.NoError(t, db.QueryRow(`SELECT applied_at FROM schema_version`).Scan(&before))
+	require.NoError(t, database.InitializeDB(db))
+	assert.Equal(t, 1, countRows(t, db, hasBackfills))
+
+	var checksum string
+	var after time.Time
+	require.NoError(t, db.QueryRow(`SELECT checksum, applied_at FROM schema_version`).Scan(&checksum, &after))
+	assert.Equal(t, schemaChecksum(), checksum)
+	assert.True(t, after.After(before), "applied at %s, before %s", after, before)
+	assert.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM schema_version`))
+}
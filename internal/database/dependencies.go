@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+
+	"github-service/internal/models"
+)
+
+// ReplaceRepositoryDependencies replaces the dependencies stored for one
+// manifest of a repository. No dependencies remove those of the manifest,
+// such as when it was deleted.
+func (d *DB) ReplaceRepositoryDependencies(ctx context.Context, repoID int64, manifest string, deps []models.Dependency) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM repository_dependencies WHERE repository_id = $1 AND manifest = $2`,
+		repoID, manifest,
+	); err != nil {
+		return err
+	}
+
+	if len(deps) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO repository_dependencies (repository_id, manifest, ecosystem, name, version, scope)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (repository_id, manifest, name) DO NOTHING`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, dep := range deps {
+			if _, err := stmt.ExecContext(ctx, repoID, manifest, dep.Ecosystem, dep.Name, dep.Version, dep.Scope); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRepositoryDependencies returns the dependencies of a repository ordered
+// by manifest and name, optionally only those of one ecosystem
+func (d *DB) GetRepositoryDependencies(ctx context.Context, repoID int64, ecosystem string) ([]*models.Dependency, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT manifest, ecosystem, name, version, scope
+		FROM repository_dependencies
+		WHERE repository_id = $1 AND ($2 = '' OR ecosystem = $2)
+		ORDER BY manifest, name`,
+		repoID, ecosystem,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := []*models.Dependency{}
+	for rows.Next() {
+		dep := &models.Dependency{}
+		if err := rows.Scan(&dep.Manifest, &dep.Ecosystem, &dep.Name, &dep.Version, &dep.Scope); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+	return deps, rows.Err()
+}
+
+// GetPackageDependents returns the monitored repositories depending on a
+// package ordered by name, optionally only those declaring the given version
+// and those account added unless it is 0
+func (d *DB) GetPackageDependents(ctx context.Context, ecosystem, name, version string, account int64) ([]*models.Dependent, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT r.full_name, d.manifest, d.version, d.scope, d.updated_at
+		FROM repository_dependencies d
+		JOIN repositories r ON r.id = d.repository_id AND r.deleted_at IS NULL
+		JOIN monitored_repositories m ON m.full_name = r.full_name
+		WHERE d.ecosystem = $1 AND d.name = $2 AND ($3 = '' OR d.version = $3)
+			AND `+repositoryAccountFilter("$4")+`
+		ORDER BY r.full_name, d.manifest`,
+		ecosystem, name, version, account,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependents := []*models.Dependent{}
+	for rows.Next() {
+		dep := &models.Dependent{}
+		if err := rows.Scan(&dep.Repository, &dep.Manifest, &dep.Version, &dep.Scope, &dep.UpdatedAt); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, dep)
+	}
+	return dependents, rows.Err()
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// SaveDigest stores a repository's digest, replacing an earlier one of the same period
+func (d *DB) SaveDigest(ctx context.Context, repoID int64, digest *models.Digest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO digests (repository_id, period, period_start, report, markdown, generated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (repository_id, period, period_start) DO UPDATE
+		SET report = EXCLUDED.report, markdown = EXCLUDED.markdown, generated_at = EXCLUDED.generated_at`
+
+	_, err = d.db.ExecContext(ctx, query, repoID, digest.Period, digest.Start, data, digest.Markdown, digest.GeneratedAt)
+	return err
+}
+
+// DigestExists reports whether a repository's digest of the period starting on
+// start has been generated
+func (d *DB) DigestExists(ctx context.Context, repoID int64, period string, start time.Time) (bool, error) {
+	var exists bool
+	err := d.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM digests WHERE repository_id = $1 AND period = $2 AND period_start = $3::date)`,
+		repoID, period, start,
+	).Scan(&exists)
+	return exists, err
+}
+
+// GetDigests returns a page of a repository's digests of a period, latest first
+func (d *DB) GetDigests(ctx context.Context, repoID int64, period string, page, perPage int) ([]*models.Digest, int, error) {
+	var total int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM digests WHERE repository_id = $1 AND period = $2`,
+		repoID, period,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT report, markdown
+		FROM digests
+		WHERE repository_id = $1 AND period = $2
+		ORDER BY period_start DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, period, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	digests := []*models.Digest{}
+	for rows.Next() {
+		var data []byte
+		digest := &models.Digest{}
+		if err := rows.Scan(&data, &digest.Markdown); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal(data, digest); err != nil {
+			return nil, 0, err
+		}
+		digests = append(digests, digest)
+	}
+	return digests, total, rows.Err()
+}
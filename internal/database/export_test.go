@@ -0,0 +1,10 @@
+package database
+
+// Unexported schema setup used by the container tests, which live in package
+// database_test as testutil imports this package
+var (
+	Schema             = schema
+	InitializeDB       = initializeDB
+	CommitPartitioning = commitPartitioning
+	EscapeLike         = escapeLike
+)
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// issueColumns lists the issue columns in the order expected by scanIssue
+const issueColumns = `id, repository_id, github_id, number, title, body, state, author_login,
+	comments, url, created_at, updated_at, closed_at, created_at_local`
+
+// scanIssue scans a row selected with issueColumns into an issue
+func scanIssue(row rowScanner) (*models.Issue, error) {
+	issue := &models.Issue{}
+	var closedAt sql.NullTime
+	err := row.Scan(
+		&issue.ID, &issue.RepositoryID, &issue.GitHubID, &issue.Number,
+		&issue.Title, &issue.Body, &issue.State, &issue.AuthorLogin,
+		&issue.Comments, &issue.URL, &issue.CreatedAt, &issue.UpdatedAt,
+		&closedAt, &issue.CreatedAtLocal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		issue.ClosedAt = &closedAt.Time
+	}
+	return issue, nil
+}
+
+// UpsertIssue creates an issue or updates the stored copy when it already exists
+func (d *DB) UpsertIssue(ctx context.Context, issue *models.Issue) error {
+	query := `
+		INSERT INTO issues (
+			repository_id, github_id, number, title, body, state, author_login,
+			comments, url, created_at, updated_at, closed_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (repository_id, number) DO UPDATE SET
+			title = EXCLUDED.title,
+			body = EXCLUDED.body,
+			state = EXCLUDED.state,
+			comments = EXCLUDED.comments,
+			updated_at = EXCLUDED.updated_at,
+			closed_at = EXCLUDED.closed_at
+		RETURNING id`
+
+	return d.db.QueryRowContext(ctx, query,
+		issue.RepositoryID, issue.GitHubID, issue.Number, issue.Title, issue.Body,
+		issue.State, issue.AuthorLogin, issue.Comments, issue.URL,
+		issue.CreatedAt, issue.UpdatedAt, issue.ClosedAt,
+	).Scan(&issue.ID)
+}
+
+// GetIssuesByRepository returns a page of a repository's issues, most recently
+// updated first. An empty state or "all" returns issues in any state.
+func (d *DB) GetIssuesByRepository(ctx context.Context, repoID int64, state string, page, perPage int) ([]*models.Issue, error) {
+	offset := (page - 1) * perPage
+	query := `
+		SELECT ` + issueColumns + ` FROM issues
+		WHERE repository_id = $1 AND ($2::text IN ('', 'all') OR state = $2)
+		ORDER BY updated_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, state, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*models.Issue
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// GetIssueCountByRepository returns the number of a repository's issues in a state
+func (d *DB) GetIssueCountByRepository(ctx context.Context, repoID int64, state string) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM issues
+		WHERE repository_id = $1 AND ($2::text IN ('', 'all') OR state = $2)`,
+		repoID, state,
+	).Scan(&count)
+	return count, err
+}
+
+// GetLatestIssueUpdate returns the most recent updated_at of a repository's
+// stored issues, or nil when none have been synced yet
+func (d *DB) GetLatestIssueUpdate(ctx context.Context, repoID int64) (*time.Time, error) {
+	var latest sql.NullTime
+	err := d.db.QueryRowContext(ctx,
+		`SELECT MAX(updated_at) FROM issues WHERE repository_id = $1`, repoID,
+	).Scan(&latest)
+	if err != nil {
+		return nil, err
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+	return &latest.Time, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// repositorySyncLockClass namespaces the advisory locks guarding repository syncs
+const repositorySyncLockClass = 1
+
+// schemaLockClass namespaces the advisory lock guarding schema initialization
+const schemaLockClass = 2
+
+// TryLockRepositorySync takes the session advisory lock that allows only one sync
+// of a repository at a time across all processes. It reports false without
+// waiting when another sync holds the lock. The returned function releases it.
+func (d *DB) TryLockRepositorySync(ctx context.Context, fullName string) (func(), bool, error) {
+	// Session locks belong to a connection, so hold one until the lock is released
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, repositorySyncLockClass, fullName).Scan(&locked)
+	if err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Release even when the sync's context was cancelled
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, hashtext($2))`, repositorySyncLockClass, fullName); err != nil {
+			// Discard the connection rather than return it to the pool still holding the lock
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// HotCommitIndexes lists the commit indexes used by the most frequent queries
+var HotCommitIndexes = []string{
+	"commits_repository_id_sha_key",
+	"idx_commits_repository_date",
+	"idx_commits_author",
+	"idx_commits_message_search",
+	"idx_commits_repository_sha",
+}
+
+// MaintainedTables lists the tables whose planner statistics are refreshed by maintenance
+var MaintainedTables = []string{"commits", "repositories"}
+
+// ReindexConcurrently rebuilds an index without blocking reads or writes to its table.
+// It cannot run inside a transaction.
+func (d *DB) ReindexConcurrently(ctx context.Context, index string) error {
+	_, err := d.db.ExecContext(ctx, `REINDEX INDEX CONCURRENTLY `+pq.QuoteIdentifier(index))
+	return err
+}
+
+// Analyze refreshes the planner statistics of a table
+func (d *DB) Analyze(ctx context.Context, table string) error {
+	_, err := d.db.ExecContext(ctx, `ANALYZE `+pq.QuoteIdentifier(table))
+	return err
+}
+
+// CreateMaintenanceRun records the outcome of a maintenance task
+func (d *DB) CreateMaintenanceRun(ctx context.Context, run *models.MaintenanceRun) error {
+	query := `
+		INSERT INTO maintenance_runs (task, target, status, error, started_at, finished_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	var errMsg sql.NullString
+	if run.Error != "" {
+		errMsg = sql.NullString{String: run.Error, Valid: true}
+	}
+	return d.db.QueryRowContext(ctx, query,
+		run.Task, run.Target, run.Status, errMsg, run.StartedAt, run.FinishedAt, run.DurationMs,
+	).Scan(&run.ID)
+}
+
+// ListMaintenanceRuns returns the most recent maintenance runs, newest first
+func (d *DB) ListMaintenanceRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	query := `
+		SELECT id, task, target, status, error, started_at, finished_at, duration_ms
+		FROM maintenance_runs
+		ORDER BY started_at DESC, id DESC
+		LIMIT $1`
+
+	rows, err := d.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*models.MaintenanceRun
+	for rows.Next() {
+		run := &models.MaintenanceRun{}
+		var errMsg sql.NullString
+		if err := rows.Scan(&run.ID, &run.Task, &run.Target, &run.Status, &errMsg,
+			&run.StartedAt, &run.FinishedAt, &run.DurationMs); err != nil {
+			return nil, err
+		}
+		run.Error = errMsg.String
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,9 @@
+-- Full-text index on commit messages for commit search
+CREATE INDEX IF NOT EXISTS idx_commits_message_search ON commits USING GIN (to_tsvector('english', message));
+
+-- Index supporting abbreviated SHA lookups within a repository
+CREATE INDEX IF NOT EXISTS idx_commits_repository_sha ON commits(repository_id, sha text_pattern_ops);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_repository_sha;
+-- DROP INDEX IF EXISTS idx_commits_message_search;
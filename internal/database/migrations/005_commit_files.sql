@@ -0,0 +1,18 @@
+-- Create commit files table
+CREATE TABLE IF NOT EXISTS commit_files (
+    id BIGSERIAL PRIMARY KEY,
+    commit_id BIGINT NOT NULL REFERENCES commits(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    extension TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    additions INTEGER NOT NULL DEFAULT 0,
+    deletions INTEGER NOT NULL DEFAULT 0,
+    changes INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(commit_id, filename)
+);
+
+-- Index for aggregating changes by file extension
+CREATE INDEX IF NOT EXISTS idx_commit_files_extension ON commit_files(extension);
+
+-- Down migration
+-- DROP TABLE IF EXISTS commit_files;
@@ -0,0 +1,24 @@
+-- Create issues table
+CREATE TABLE IF NOT EXISTS issues (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    github_id BIGINT NOT NULL,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    state TEXT NOT NULL,
+    author_login TEXT NOT NULL DEFAULT '',
+    comments INTEGER NOT NULL DEFAULT 0,
+    url TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, number)
+);
+
+-- Index for listing a repository's issues by state
+CREATE INDEX IF NOT EXISTS idx_issues_repository_state ON issues(repository_id, state, updated_at DESC);
+
+-- Down migration
+-- DROP TABLE IF EXISTS issues;
@@ -0,0 +1,17 @@
+-- Create maintenance runs table
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+    id BIGSERIAL PRIMARY KEY,
+    task TEXT NOT NULL,
+    target TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+-- Index for listing the most recent runs
+CREATE INDEX IF NOT EXISTS idx_maintenance_runs_started ON maintenance_runs(started_at DESC);
+
+-- Down migration
+-- DROP TABLE IF EXISTS maintenance_runs;
@@ -0,0 +1,15 @@
+-- Create repository stats history table
+CREATE TABLE IF NOT EXISTS repository_stats_history (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    stars_count INTEGER NOT NULL DEFAULT 0,
+    forks_count INTEGER NOT NULL DEFAULT 0,
+    watchers_count INTEGER NOT NULL DEFAULT 0,
+    open_issues_count INTEGER NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, snapshot_date)
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS repository_stats_history;
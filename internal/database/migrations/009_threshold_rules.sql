@@ -0,0 +1,20 @@
+-- Create threshold rules table
+CREATE TABLE IF NOT EXISTS threshold_rules (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold BIGINT NOT NULL,
+    webhook_url TEXT NOT NULL,
+    triggered BOOLEAN NOT NULL DEFAULT false,
+    last_value BIGINT,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for evaluating the rules of a repository after each sync
+CREATE INDEX IF NOT EXISTS idx_threshold_rules_repository ON threshold_rules(repository_id);
+
+-- Down migration
+-- DROP TABLE IF EXISTS threshold_rules;
@@ -0,0 +1,18 @@
+-- Create author identities table mapping commit author emails to a canonical identity
+CREATE TABLE IF NOT EXISTS author_identities (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    canonical_name TEXT NOT NULL,
+    canonical_email TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for re-pointing identities when an identity is merged into another
+CREATE INDEX IF NOT EXISTS idx_author_identities_canonical ON author_identities(canonical_email);
+
+-- Index for joining commits to identities by case-insensitive email
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_lower ON commits(LOWER(author_email));
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_author_email_lower;
+-- DROP TABLE IF EXISTS author_identities;
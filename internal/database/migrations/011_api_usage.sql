@@ -0,0 +1,19 @@
+-- Create API usage table aggregating the service's own API requests per hour, endpoint and API key
+CREATE TABLE IF NOT EXISTS api_usage (
+    id BIGSERIAL PRIMARY KEY,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    error_count BIGINT NOT NULL DEFAULT 0,
+    total_duration_us BIGINT NOT NULL DEFAULT 0,
+    max_duration_us BIGINT NOT NULL DEFAULT 0,
+    UNIQUE (bucket, method, route, api_key_id)
+);
+
+-- Index for reporting usage over a time window
+CREATE INDEX IF NOT EXISTS idx_api_usage_bucket ON api_usage(bucket);
+
+-- Down migration
+-- DROP TABLE IF EXISTS api_usage;
@@ -0,0 +1,5 @@
+-- Track manual resyncs so they can be rate limited per repository
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS last_resync_at TIMESTAMP WITH TIME ZONE;
+
+-- Down migration
+-- ALTER TABLE monitored_repositories DROP COLUMN IF EXISTS last_resync_at;
@@ -0,0 +1,24 @@
+-- Create sync runs table recording each sync of a repository
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    incremental BOOLEAN NOT NULL DEFAULT false,
+    since TIMESTAMP WITH TIME ZONE,
+    status TEXT NOT NULL,
+    new_commits INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_repository ON sync_runs(repository_id, id);
+
+-- Commits remember the run that ingested them so consumers can page through increments
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS sync_run_id BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_commits_sync_run ON commits(repository_id, sync_run_id);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_sync_run;
+-- ALTER TABLE commits DROP COLUMN IF EXISTS sync_run_id;
+-- DROP TABLE IF EXISTS sync_runs;
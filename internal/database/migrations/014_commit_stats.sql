@@ -0,0 +1,12 @@
+-- Store the diff stats of commits enriched from the single-commit API
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS additions INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS deletions INTEGER;
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS files_changed INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_commits_missing_stats ON commits(repository_id, commit_date DESC) WHERE additions IS NULL;
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_missing_stats;
+-- ALTER TABLE commits DROP COLUMN IF EXISTS files_changed;
+-- ALTER TABLE commits DROP COLUMN IF EXISTS deletions;
+-- ALTER TABLE commits DROP COLUMN IF EXISTS additions;
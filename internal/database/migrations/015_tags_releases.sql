@@ -0,0 +1,33 @@
+-- Create tags table
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, name)
+);
+
+-- Create releases table
+CREATE TABLE IF NOT EXISTS releases (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    github_id BIGINT NOT NULL,
+    tag_name TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    draft BOOLEAN NOT NULL DEFAULT false,
+    prerelease BOOLEAN NOT NULL DEFAULT false,
+    author_login TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, github_id)
+);
+
+-- Index for listing a repository's releases, newest first
+CREATE INDEX IF NOT EXISTS idx_releases_repository_published ON releases(repository_id, published_at DESC);
+
+-- Down migration
+-- DROP TABLE IF EXISTS releases;
+-- DROP TABLE IF EXISTS tags;
@@ -0,0 +1,8 @@
+-- Retain the data of removed repositories until the retention window has passed
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_repositories_deleted ON repositories(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_repositories_deleted;
+-- ALTER TABLE repositories DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+-- Create commit hooks table
+CREATE TABLE IF NOT EXISTS commit_hooks (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    webhook_url TEXT NOT NULL,
+    authors TEXT[] NOT NULL DEFAULT '{}',
+    paths TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for delivering the new commits of a repository after each sync
+CREATE INDEX IF NOT EXISTS idx_commit_hooks_repository ON commit_hooks(repository_id);
+
+-- Down migration
+-- DROP TABLE IF EXISTS commit_hooks;
@@ -0,0 +1,13 @@
+-- Create monthly reports table; a report is replaced when regenerated
+CREATE TABLE IF NOT EXISTS monthly_reports (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id BIGINT NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    month TEXT NOT NULL, -- YYYY-MM
+    report JSONB NOT NULL,
+    markdown TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(repository_id, month)
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS monthly_reports;
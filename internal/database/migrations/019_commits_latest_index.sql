@@ -0,0 +1,6 @@
+-- Covering index for polling the newest commits of a repository: the fields that
+-- version the latest commits are read without touching the table
+CREATE INDEX IF NOT EXISTS idx_commits_repository_latest ON commits(repository_id, commit_date DESC, id DESC) INCLUDE (sha, additions, deletions, files_changed);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_repository_latest;
@@ -0,0 +1,12 @@
+-- Repositories can come from GitHub or GitLab. Provider IDs are only unique
+-- within their provider, so the github_id uniqueness moves to (provider, github_id)
+ALTER TABLE repositories ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE repositories DROP CONSTRAINT IF EXISTS repositories_github_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repositories_provider_id ON repositories(provider, github_id);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_repositories_provider_id;
+-- ALTER TABLE repositories ADD CONSTRAINT repositories_github_id_key UNIQUE (github_id);
+-- ALTER TABLE monitored_repositories DROP COLUMN IF EXISTS provider;
+-- ALTER TABLE repositories DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,15 @@
+-- Conventional Commits type of each commit message (feat, fix, docs, ...), 'other'
+-- when the message has none. Commits stored before are classified in place.
+ALTER TABLE commits ADD COLUMN IF NOT EXISTS commit_type TEXT;
+
+UPDATE commits SET commit_type = CASE
+    WHEN lower(substring(message from '^([A-Za-z]+)(\([^()]*\))?!?: ')) IN
+        ('build', 'chore', 'ci', 'docs', 'feat', 'fix', 'perf', 'refactor', 'revert', 'style', 'test')
+        THEN lower(substring(message from '^([A-Za-z]+)(\([^()]*\))?!?: '))
+    WHEN message LIKE 'Revert "%' THEN 'revert'
+    ELSE 'other'
+END
+WHERE commit_type IS NULL;
+
+-- Down migration
+-- ALTER TABLE commits DROP COLUMN IF EXISTS commit_type;
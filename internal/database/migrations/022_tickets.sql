@@ -0,0 +1,23 @@
+-- Tickets referenced by commit messages (e.g. PROJ-123) and their status in the
+-- configured ticket tracker, refreshed periodically
+CREATE TABLE IF NOT EXISTS tickets (
+    key TEXT PRIMARY KEY,
+    summary TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT '',
+    status_category TEXT NOT NULL DEFAULT '',
+    found BOOLEAN NOT NULL DEFAULT FALSE,
+    refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS commit_tickets (
+    commit_id INTEGER NOT NULL REFERENCES commits(id) ON DELETE CASCADE,
+    ticket_key TEXT NOT NULL,
+    PRIMARY KEY (commit_id, ticket_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commit_tickets_key ON commit_tickets(ticket_key);
+CREATE INDEX IF NOT EXISTS idx_tickets_refreshed ON tickets(refreshed_at NULLS FIRST);
+
+-- Down migration
+-- DROP TABLE IF EXISTS commit_tickets;
+-- DROP TABLE IF EXISTS tickets;
@@ -0,0 +1,14 @@
+-- Weekly additions, deletions and commits of each contributor as computed by
+-- GitHub's contributor stats API; weeks without any activity are not stored
+CREATE TABLE IF NOT EXISTS contributor_weeks (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    login TEXT NOT NULL,
+    week DATE NOT NULL,
+    commits INTEGER NOT NULL DEFAULT 0,
+    additions INTEGER NOT NULL DEFAULT 0,
+    deletions INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (repository_id, login, week)
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS contributor_weeks;
@@ -0,0 +1,8 @@
+-- Listing a repository's commits by author matches the lowercased name or
+-- email exactly, newest first
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_name ON commits(repository_id, LOWER(author_name), commit_date DESC);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_email ON commits(repository_id, LOWER(author_email), commit_date DESC);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_repository_author_email;
+-- DROP INDEX IF EXISTS idx_commits_repository_author_name;
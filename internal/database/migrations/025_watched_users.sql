@@ -0,0 +1,23 @@
+-- GitHub users whose public repositories matching the filters are added to
+-- monitoring, and rediscovered periodically
+CREATE TABLE IF NOT EXISTS watched_users (
+    username TEXT PRIMARY KEY,
+    min_stars INTEGER NOT NULL DEFAULT 0,
+    language TEXT NOT NULL DEFAULT '',
+    updated_within_seconds BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Repositories discovery added to monitoring for a watched user; they are not
+-- added again after being removed
+CREATE TABLE IF NOT EXISTS discovered_repositories (
+    username TEXT NOT NULL REFERENCES watched_users(username) ON DELETE CASCADE,
+    full_name TEXT NOT NULL,
+    discovered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (username, full_name)
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS discovered_repositories;
+-- DROP TABLE IF EXISTS watched_users;
@@ -0,0 +1,5 @@
+-- Consecutive failed syncs of each monitored repository, for failure notifications
+ALTER TABLE monitored_repositories ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- Down migration
+-- ALTER TABLE monitored_repositories DROP COLUMN IF EXISTS consecutive_failures;
@@ -0,0 +1,65 @@
+-- Partition commits by a hash of the repository so the queries of one
+-- repository, which all filter on repository_id, only touch its partition and
+-- its indexes, however many commits other repositories have
+ALTER TABLE commits RENAME TO commits_unpartitioned;
+ALTER INDEX commits_pkey RENAME TO commits_unpartitioned_pkey;
+ALTER INDEX commits_repository_id_sha_key RENAME TO commits_unpartitioned_repository_id_sha_key;
+
+CREATE TABLE commits (
+    LIKE commits_unpartitioned INCLUDING DEFAULTS,
+    PRIMARY KEY (repository_id, id),
+    UNIQUE (repository_id, sha),
+    FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+) PARTITION BY HASH (repository_id);
+ALTER SEQUENCE commits_id_seq OWNED BY commits.id;
+
+CREATE TABLE commits_p0 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 0);
+CREATE TABLE commits_p1 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 1);
+CREATE TABLE commits_p2 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 2);
+CREATE TABLE commits_p3 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 3);
+CREATE TABLE commits_p4 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 4);
+CREATE TABLE commits_p5 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 5);
+CREATE TABLE commits_p6 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 6);
+CREATE TABLE commits_p7 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 7);
+CREATE TABLE commits_p8 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 8);
+CREATE TABLE commits_p9 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 9);
+CREATE TABLE commits_p10 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 10);
+CREATE TABLE commits_p11 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 11);
+CREATE TABLE commits_p12 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 12);
+CREATE TABLE commits_p13 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 13);
+CREATE TABLE commits_p14 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 14);
+CREATE TABLE commits_p15 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 15);
+
+INSERT INTO commits SELECT * FROM commits_unpartitioned;
+DROP TABLE commits_unpartitioned CASCADE;
+
+-- Keys of a partitioned table include its partition key, so the tables
+-- referencing commits reference them by repository and ID
+ALTER TABLE commit_files ADD COLUMN repository_id INTEGER;
+UPDATE commit_files f SET repository_id = c.repository_id FROM commits c WHERE c.id = f.commit_id;
+ALTER TABLE commit_files ALTER COLUMN repository_id SET NOT NULL;
+ALTER TABLE commit_files ADD FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE;
+
+ALTER TABLE commit_tickets ADD COLUMN repository_id INTEGER;
+UPDATE commit_tickets t SET repository_id = c.repository_id FROM commits c WHERE c.id = t.commit_id;
+ALTER TABLE commit_tickets ALTER COLUMN repository_id SET NOT NULL;
+ALTER TABLE commit_tickets ADD FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE;
+
+-- The indexes of the old table were dropped with it
+CREATE INDEX idx_commits_repository_date ON commits(repository_id, commit_date DESC);
+CREATE INDEX idx_commits_author ON commits(author_name, author_email);
+CREATE INDEX idx_commits_message_search ON commits USING GIN (to_tsvector('english', message));
+CREATE INDEX idx_commits_repository_sha ON commits(repository_id, sha text_pattern_ops);
+CREATE INDEX idx_commits_author_email_lower ON commits(LOWER(author_email));
+CREATE INDEX idx_commits_sync_run ON commits(repository_id, sync_run_id);
+CREATE INDEX idx_commits_missing_stats ON commits(repository_id, commit_date DESC) WHERE additions IS NULL;
+CREATE INDEX idx_commits_repository_latest ON commits(repository_id, commit_date DESC, id DESC) INCLUDE (sha, additions, deletions, files_changed);
+CREATE INDEX idx_commits_repository_author_name ON commits(repository_id, LOWER(author_name), commit_date DESC);
+CREATE INDEX idx_commits_repository_author_email ON commits(repository_id, LOWER(author_email), commit_date DESC);
+CREATE INDEX idx_commit_files_repository ON commit_files(repository_id, commit_id);
+
+-- Down migration
+-- Copy the rows back into an unpartitioned commits table with
+-- CREATE TABLE ... (LIKE commits INCLUDING DEFAULTS), restore its keys, point
+-- commit_files and commit_tickets at commits(id) and drop their repository_id
+-- columns, then recreate the commit indexes of migrations 001 to 024.
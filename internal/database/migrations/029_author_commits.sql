@@ -0,0 +1,5 @@
+-- Lists an author's commits across repositories, newest first
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_date ON commits(LOWER(author_email), commit_date DESC, id DESC);
+
+-- Down migration
+-- DROP INDEX IF EXISTS idx_commits_author_email_date;
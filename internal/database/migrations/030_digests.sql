@@ -0,0 +1,14 @@
+-- Weekly and monthly digests of a repository's activity
+CREATE TABLE IF NOT EXISTS digests (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    period TEXT NOT NULL,
+    period_start DATE NOT NULL,
+    report JSONB NOT NULL,
+    markdown TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(repository_id, period, period_start)
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS digests;
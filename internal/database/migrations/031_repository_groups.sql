@@ -0,0 +1,20 @@
+-- Named groups of monitored repositories, e.g. those of a team
+CREATE TABLE IF NOT EXISTS repository_groups (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Members are monitored repositories by full name
+CREATE TABLE IF NOT EXISTS repository_group_members (
+    group_id INTEGER NOT NULL REFERENCES repository_groups(id) ON DELETE CASCADE,
+    repository TEXT NOT NULL,
+    PRIMARY KEY (group_id, repository)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_group_members_repository ON repository_group_members(repository);
+
+-- Down migration
+-- DROP TABLE IF EXISTS repository_group_members;
+-- DROP TABLE IF EXISTS repository_groups;
@@ -0,0 +1,27 @@
+-- Rules on the change of a metric compare it with its snapshot window_days ago
+ALTER TABLE threshold_rules ADD COLUMN IF NOT EXISTS window_days INTEGER NOT NULL DEFAULT 0;
+-- Rules may only notify the configured channels, without a webhook
+ALTER TABLE threshold_rules ALTER COLUMN webhook_url SET DEFAULT '';
+
+-- History of the times threshold rules started or stopped holding. The rule's
+-- definition is copied so the history outlives changes to the rule.
+CREATE TABLE IF NOT EXISTS threshold_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    rule_id INTEGER REFERENCES threshold_rules(id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold BIGINT NOT NULL,
+    window_days INTEGER NOT NULL DEFAULT 0,
+    value BIGINT NOT NULL,
+    previous_value BIGINT NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_threshold_alerts_repository ON threshold_alerts(repository_id, id DESC);
+
+-- Down migration
+-- DROP TABLE IF EXISTS threshold_alerts;
+-- ALTER TABLE threshold_rules ALTER COLUMN webhook_url DROP DEFAULT;
+-- ALTER TABLE threshold_rules DROP COLUMN IF EXISTS window_days;
@@ -0,0 +1,17 @@
+-- Dependencies declared in the manifests at the root of repositories, replaced
+-- per manifest on every sync
+CREATE TABLE IF NOT EXISTS repository_dependencies (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    manifest TEXT NOT NULL,
+    ecosystem TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL DEFAULT '',
+    scope TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (repository_id, manifest, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_dependencies_package ON repository_dependencies(ecosystem, name);
+
+-- Down migration
+-- DROP TABLE IF EXISTS repository_dependencies;
@@ -0,0 +1,29 @@
+-- People who logged in with their GitHub account
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    github_id BIGINT NOT NULL UNIQUE,
+    login TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'reader',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP WITH TIME ZONE
+);
+
+-- The API keys issued on login belong to an account and expire
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Monitored repositories each account added, which scope its sessions
+CREATE TABLE IF NOT EXISTS account_repositories (
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    repository TEXT NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, repository)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_repositories_repository ON account_repositories(repository);
+
+-- Down migration
+-- DROP TABLE IF EXISTS account_repositories;
+-- ALTER TABLE api_keys DROP COLUMN IF EXISTS expires_at;
+-- ALTER TABLE api_keys DROP COLUMN IF EXISTS account_id;
+-- DROP TABLE IF EXISTS accounts;
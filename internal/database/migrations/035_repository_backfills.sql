@@ -0,0 +1,13 @@
+-- How far the commit history backfill of each repository got, so an
+-- interrupted backfill resumes there instead of fetching every page again
+CREATE TABLE IF NOT EXISTS repository_backfills (
+    repository_id INTEGER PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+    since TIMESTAMP WITH TIME ZONE, -- Lower bound of the backfill; NULL for the full history
+    page INTEGER NOT NULL,
+    oldest_sha TEXT NOT NULL,
+    oldest_date TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Down migration
+-- DROP TABLE IF EXISTS repository_backfills;
@@ -0,0 +1,332 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// MonitoredRepository represents a repository being monitored
+type MonitoredRepository struct {
+	ID           int64
+	FullName     string
+	LastSyncTime time.Time
+	SyncInterval time.Duration
+	IsActive     bool
+}
+
+// AddMonitoredRepository adds a repository to the monitoring list
+func (d *DB) AddMonitoredRepository(ctx context.Context, fullName, provider string, syncInterval time.Duration) error {
+	query := `
+		INSERT INTO monitored_repositories (full_name, provider, last_sync_time, sync_interval, is_active)
+		VALUES ($1, $2, $3, $4, true)
+		ON CONFLICT (full_name) 
+		DO UPDATE SET provider = $2, sync_interval = $4, is_active = true, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := d.db.ExecContext(ctx, query, fullName, provider, time.Now().UTC(), syncInterval.String())
+	return err
+}
+
+// GetRepositoryProvider returns the provider a repository is monitored on, or
+// was synced from when it is no longer monitored. It returns an empty string
+// for a repository that is neither.
+func (d *DB) GetRepositoryProvider(ctx context.Context, fullName string) (string, error) {
+	query := `
+		SELECT provider FROM (
+			SELECT provider, 1 AS rank FROM monitored_repositories WHERE full_name = $1
+			UNION ALL
+			SELECT provider, 2 AS rank FROM repositories WHERE full_name = $1
+		) providers
+		ORDER BY rank
+		LIMIT 1
+	`
+	var provider string
+	err := d.db.QueryRowContext(ctx, query, fullName).Scan(&provider)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return provider, err
+}
+
+// GetMonitoredRepositories returns all actively monitored repositories
+func (d *DB) GetMonitoredRepositories(ctx context.Context) ([]models.MonitoredRepository, error) {
+	query := `
+		SELECT id, full_name, provider, last_sync_time, sync_interval, is_active
+		FROM monitored_repositories
+		WHERE is_active = true
+	`
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMonitoredRepositories(rows)
+}
+
+// repositoryListingColumns selects a monitored repository joined with its
+// synced details in the order expected by scanRepositoryListing
+const repositoryListingColumns = `
+	m.full_name, m.provider, m.sync_interval, m.last_sync_time, m.created_at,
+	r.id, r.github_id, r.name, r.description, r.url, r.language,
+	r.forks_count, r.stars_count, r.open_issues_count, r.watchers_count,
+	r.created_at, r.updated_at, r.last_commit_check, r.commits_since,
+	r.created_at_local, r.updated_at_local`
+
+// GetRepositoryListings returns a page of actively monitored repositories ordered
+// by name, each merged with its synced details when the initial sync has completed
+func (d *DB) GetRepositoryListings(ctx context.Context, page, perPage int) ([]*models.RepositoryListing, error) {
+	offset := (page - 1) * perPage
+	query := `
+		SELECT ` + repositoryListingColumns + `
+		FROM monitored_repositories m
+		LEFT JOIN repositories r ON r.full_name = m.full_name
+		WHERE m.is_active = true
+		ORDER BY m.full_name
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := d.db.QueryContext(ctx, query, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []*models.RepositoryListing
+	for rows.Next() {
+		listing, err := scanRepositoryListing(rows)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, listing)
+	}
+	return listings, rows.Err()
+}
+
+// GetRepositoryListing returns an actively monitored repository merged with its
+// synced details, or nil when the repository is not monitored
+func (d *DB) GetRepositoryListing(ctx context.Context, fullName string) (*models.RepositoryListing, error) {
+	query := `
+		SELECT ` + repositoryListingColumns + `
+		FROM monitored_repositories m
+		LEFT JOIN repositories r ON r.full_name = m.full_name
+		WHERE m.full_name = $1 AND m.is_active = true
+	`
+	listing, err := scanRepositoryListing(d.db.QueryRowContext(ctx, query, fullName))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return listing, err
+}
+
+// scanRepositoryListing scans a row selected with repositoryListingColumns
+func scanRepositoryListing(row rowScanner) (*models.RepositoryListing, error) {
+	listing := &models.RepositoryListing{}
+	var (
+		lastSync                                         sql.NullTime
+		id, githubID                                     sql.NullInt64
+		name, description, url, language                 sql.NullString
+		forks, stars, openIssues, watchers               sql.NullInt64
+		createdAt, updatedAt, createdLocal, updatedLocal sql.NullTime
+		lastCommitCheck, commitsSince                    sql.NullTime
+	)
+	err := row.Scan(
+		&listing.FullName, &listing.Provider, &listing.SyncInterval, &lastSync, &listing.MonitoredSince,
+		&id, &githubID, &name, &description, &url, &language,
+		&forks, &stars, &openIssues, &watchers,
+		&createdAt, &updatedAt, &lastCommitCheck, &commitsSince,
+		&createdLocal, &updatedLocal,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastSync.Valid {
+		listing.LastSyncTime = &lastSync.Time
+	}
+
+	listing.Status = models.RepositoryStatusPending
+	if id.Valid {
+		listing.Status = models.RepositoryStatusSynced
+		listing.Repository = &models.Repository{
+			ID:              id.Int64,
+			GitHubID:        githubID.Int64,
+			Provider:        listing.Provider,
+			Name:            name.String,
+			FullName:        listing.FullName,
+			Description:     description.String,
+			URL:             url.String,
+			Language:        language.String,
+			ForksCount:      int(forks.Int64),
+			StarsCount:      int(stars.Int64),
+			OpenIssuesCount: int(openIssues.Int64),
+			WatchersCount:   int(watchers.Int64),
+			CreatedAt:       createdAt.Time,
+			UpdatedAt:       updatedAt.Time,
+			CreatedAtLocal:  createdLocal.Time,
+			UpdatedAtLocal:  updatedLocal.Time,
+		}
+		if lastCommitCheck.Valid {
+			listing.Repository.LastCommitCheck = &lastCommitCheck.Time
+		}
+		if commitsSince.Valid {
+			listing.Repository.CommitsSince = &commitsSince.Time
+		}
+	}
+	return listing, nil
+}
+
+// CountMonitoredRepositories returns the number of actively monitored repositories
+func (d *DB) CountMonitoredRepositories(ctx context.Context) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM monitored_repositories WHERE is_active = true`).Scan(&count)
+	return count, err
+}
+
+// scanMonitoredRepositories scans all monitored repository rows
+func scanMonitoredRepositories(rows *sql.Rows) ([]models.MonitoredRepository, error) {
+	var repos []models.MonitoredRepository
+	for rows.Next() {
+		var repo models.MonitoredRepository
+		var intervalStr string
+		err := rows.Scan(&repo.ID, &repo.FullName, &repo.Provider, &repo.LastSyncTime, &intervalStr, &repo.IsActive)
+		if err != nil {
+			return nil, err
+		}
+		repo.SyncInterval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sync interval for %s: %w", repo.FullName, err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+// UpdateMonitoredRepositorySync updates the last sync time for a monitored repository
+func (d *DB) UpdateMonitoredRepositorySync(ctx context.Context, fullName string, lastSyncTime time.Time) error {
+	query := `
+		UPDATE monitored_repositories
+		SET last_sync_time = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE full_name = $1
+	`
+	result, err := d.db.ExecContext(ctx, query, fullName, lastSyncTime)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("monitored repository", fullName)
+	}
+	return nil
+}
+
+// RecordSyncOutcome counts the consecutive failed syncs of a monitored
+// repository, resetting the count when a sync succeeds. It returns the count
+// before this sync, and false for repositories that are not monitored.
+func (d *DB) RecordSyncOutcome(ctx context.Context, fullName string, failed bool) (int, bool, error) {
+	query := `
+		WITH previous AS (
+			SELECT id, consecutive_failures
+			FROM monitored_repositories
+			WHERE full_name = $1
+			FOR UPDATE
+		)
+		UPDATE monitored_repositories m
+		SET consecutive_failures = CASE WHEN $2 THEN p.consecutive_failures + 1 ELSE 0 END
+		FROM previous p
+		WHERE m.id = p.id
+		RETURNING p.consecutive_failures
+	`
+	var previous int
+	err := d.db.QueryRowContext(ctx, query, fullName, failed).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return previous, true, nil
+}
+
+// RemoveMonitoredRepository marks a repository as inactive
+func (d *DB) RemoveMonitoredRepository(ctx context.Context, fullName string) error {
+	query := `
+		UPDATE monitored_repositories
+		SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE full_name = $1
+	`
+	result, err := d.db.ExecContext(ctx, query, fullName)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("monitored repository", fullName)
+	}
+	return nil
+}
+
+// SetMonitoredRepositoryActive pauses or resumes the monitoring of a repository,
+// keeping its stored data
+func (d *DB) SetMonitoredRepositoryActive(ctx context.Context, fullName string, active bool) error {
+	query := `
+		UPDATE monitored_repositories
+		SET is_active = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE full_name = $1
+	`
+	result, err := d.db.ExecContext(ctx, query, fullName, active)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("monitored repository", fullName)
+	}
+	return nil
+}
+
+// ClaimResync records a manual resync of a monitored repository unless the
+// previous one was less than minInterval ago, in which case nothing is recorded
+// and the time left until the next resync is allowed is returned
+func (d *DB) ClaimResync(ctx context.Context, fullName string, minInterval time.Duration) (time.Duration, error) {
+	// The select sees the row as it was before the update, in one snapshot
+	query := `
+		WITH claimed AS (
+			UPDATE monitored_repositories
+			SET last_resync_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE full_name = $1
+				AND (last_resync_at IS NULL OR last_resync_at <= CURRENT_TIMESTAMP - make_interval(secs => $2))
+			RETURNING id
+		)
+		SELECT EXISTS (SELECT 1 FROM claimed),
+			EXTRACT(EPOCH FROM m.last_resync_at + make_interval(secs => $2) - CURRENT_TIMESTAMP)
+		FROM monitored_repositories m
+		WHERE m.full_name = $1
+	`
+	var (
+		claimed   bool
+		remaining sql.NullFloat64
+	)
+	err := d.db.QueryRowContext(ctx, query, fullName, minInterval.Seconds()).Scan(&claimed, &remaining)
+	if err == sql.ErrNoRows {
+		return 0, errors.NewNotFoundError("monitored repository", fullName)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if claimed || !remaining.Valid {
+		return 0, nil
+	}
+	return time.Duration(remaining.Float64 * float64(time.Second)), nil
+}
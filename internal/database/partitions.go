@@ -0,0 +1,53 @@
+package database
+
+// commitPartitioning partitions the commits table by a hash of repository_id
+// into 16 partitions. Every repository's commits live in a single partition, so
+// the queries of one repository, which filter on repository_id, only touch that
+// partition and its indexes.
+//
+// It converts a commits table created before commits were partitioned,
+// copying its rows into a partitioned table, and creates any missing
+// partitions. The tables referencing commits reference them by repository and
+// ID from then on, as the keys of a partitioned table must include its
+// partition key. The commit indexes are dropped with the old table and
+// recreated by the rest of the schema.
+const commitPartitioning = `DO $$
+DECLARE
+	converting BOOLEAN := (SELECT relkind = 'r' FROM pg_class WHERE oid = 'commits'::regclass);
+BEGIN
+	IF converting THEN
+		ALTER TABLE commits RENAME TO commits_unpartitioned;
+		ALTER INDEX IF EXISTS commits_pkey RENAME TO commits_unpartitioned_pkey;
+		ALTER INDEX IF EXISTS commits_repository_id_sha_key RENAME TO commits_unpartitioned_repository_id_sha_key;
+
+		CREATE TABLE commits (
+			LIKE commits_unpartitioned INCLUDING DEFAULTS,
+			PRIMARY KEY (repository_id, id),
+			UNIQUE (repository_id, sha),
+			FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+		) PARTITION BY HASH (repository_id);
+		ALTER SEQUENCE commits_id_seq OWNED BY commits.id;
+	END IF;
+
+	FOR i IN 0..15 LOOP
+		EXECUTE format('CREATE TABLE IF NOT EXISTS commits_p%s PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER %s)', i, i);
+	END LOOP;
+
+	IF converting THEN
+		INSERT INTO commits SELECT * FROM commits_unpartitioned;
+		DROP TABLE commits_unpartitioned CASCADE;
+
+		IF to_regclass('commit_files') IS NOT NULL THEN
+			ALTER TABLE commit_files ADD COLUMN IF NOT EXISTS repository_id INTEGER;
+			UPDATE commit_files f SET repository_id = c.repository_id FROM commits c WHERE c.id = f.commit_id;
+			ALTER TABLE commit_files ALTER COLUMN repository_id SET NOT NULL;
+			ALTER TABLE commit_files ADD FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE;
+		END IF;
+		IF to_regclass('commit_tickets') IS NOT NULL THEN
+			ALTER TABLE commit_tickets ADD COLUMN IF NOT EXISTS repository_id INTEGER;
+			UPDATE commit_tickets t SET repository_id = c.repository_id FROM commits c WHERE c.id = t.commit_id;
+			ALTER TABLE commit_tickets ALTER COLUMN repository_id SET NOT NULL;
+			ALTER TABLE commit_tickets ADD FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE;
+		END IF;
+	END IF;
+END $$;`
@@ -0,0 +1,138 @@
+package database_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github-service/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unpartitionedSchema is the layout of the commits table and the tables
+// referencing it before commits were partitioned
+const unpartitionedSchema = `
+DROP SCHEMA public CASCADE;
+CREATE SCHEMA public;
+
+CREATE TABLE repositories (
+	id SERIAL PRIMARY KEY,
+	full_name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE commits (
+	id SERIAL PRIMARY KEY,
+	repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+	sha TEXT NOT NULL,
+	message TEXT NOT NULL,
+	created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(repository_id, sha)
+);
+
+CREATE TABLE commit_files (
+	id SERIAL PRIMARY KEY,
+	commit_id INTEGER NOT NULL REFERENCES commits(id) ON DELETE CASCADE,
+	filename TEXT NOT NULL,
+	UNIQUE(commit_id, filename)
+);
+
+CREATE TABLE commit_tickets (
+	commit_id INTEGER NOT NULL REFERENCES commits(id) ON DELETE CASCADE,
+	ticket_key TEXT NOT NULL,
+	PRIMARY KEY (commit_id, ticket_key)
+);
+
+INSERT INTO repositories (full_name) VALUES ('octo/api'), ('octo/web'), ('octo/cli');
+INSERT INTO commits (repository_id, sha, message)
+SELECT r, 'sha-' || r || '-' || n, 'Commit ' || n
+FROM generate_series(1, 3) r, generate_series(1, 20) n;
+INSERT INTO commit_files (commit_id, filename) SELECT id, 'main.go' FROM commits;
+INSERT INTO commit_files (commit_id, filename) SELECT id, 'README.md' FROM commits WHERE id % 2 = 0;
+INSERT INTO commit_tickets (commit_id, ticket_key) SELECT id, 'PROJ-' || id FROM commits WHERE id % 3 = 0;
+`
+
+// countRows returns the number of rows a query counts
+func countRows(t *testing.T, db *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.QueryRow(query, args...).Scan(&n))
+	return n
+}
+
+func TestCommitPartitioningConvertsPopulatedTable(t *testing.T) {
+	pg, _ := setupTestDB(t)
+	db := pg.DB
+
+	_, err := db.Exec(unpartitionedSchema)
+	require.NoError(t, err)
+	commits := countRows(t, db, `SELECT COUNT(*) FROM commits`)
+	files := countRows(t, db, `SELECT COUNT(*) FROM commit_files`)
+	tickets := countRows(t, db, `SELECT COUNT(*) FROM commit_tickets`)
+	require.Equal(t, 60, commits)
+
+	// Running the conversion again finds the table partitioned and leaves it be
+	for run := 1; run <= 2; run++ {
+		_, err := db.Exec(database.CommitPartitioning)
+		require.NoError(t, err, "run %d", run)
+
+		assert.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM pg_class WHERE oid = 'commits'::regclass AND relkind = 'p'`), "run %d", run)
+		assert.Equal(t, 16, countRows(t, db, `SELECT COUNT(*) FROM pg_inherits WHERE inhparent = 'commits'::regclass`), "run %d", run)
+		assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM pg_class WHERE relname = 'commits_unpartitioned'`), "run %d", run)
+
+		assert.Equal(t, commits, countRows(t, db, `SELECT COUNT(*) FROM commits`), "run %d", run)
+		assert.Equal(t, files, countRows(t, db, `SELECT COUNT(*) FROM commit_files`), "run %d", run)
+		assert.Equal(t, tickets, countRows(t, db, `SELECT COUNT(*) FROM commit_tickets`), "run %d", run)
+
+		// The referencing rows were given their commit's repository
+		assert.Zero(t, countRows(t, db, `
+			SELECT COUNT(*) FROM commit_files f
+			LEFT JOIN commits c ON c.repository_id = f.repository_id AND c.id = f.commit_id
+			WHERE c.id IS NULL`), "run %d", run)
+		assert.Zero(t, countRows(t, db, `
+			SELECT COUNT(*) FROM commit_tickets t
+			LEFT JOIN commits c ON c.repository_id = t.repository_id AND c.id = t.commit_id
+			WHERE c.id IS NULL`), "run %d", run)
+	}
+
+	// The foreign keys were recreated against the partitioned table
+	_, err = db.Exec(`INSERT INTO commit_files (commit_id, repository_id, filename) VALUES (1, 2, 'orphan.go')`)
+	assert.Error(t, err, "file of a commit in another repository")
+
+	var repoID, commitID int64
+	require.NoError(t, db.QueryRow(`SELECT repository_id, id FROM commits WHERE sha = 'sha-2-6'`).Scan(&repoID, &commitID))
+	_, err = db.Exec(`DELETE FROM commits WHERE repository_id = $1 AND id = $2`, repoID, commitID)
+	require.NoError(t, err)
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM commit_files WHERE commit_id = $1`, commitID))
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM commit_tickets WHERE commit_id = $1`, commitID))
+
+	_, err = db.Exec(`DELETE FROM repositories WHERE full_name = 'octo/cli'`)
+	require.NoError(t, err)
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM commits WHERE repository_id = 3`))
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM commit_files WHERE repository_id = 3`))
+
+	// New commits continue the ID sequence of the old table
+	var maxID, newID int64
+	require.NoError(t, db.QueryRow(`SELECT MAX(id) FROM commits`).Scan(&maxID))
+	require.NoError(t, db.QueryRow(`INSERT INTO commits (repository_id, sha, message) VALUES (1, 'sha-new', 'New') RETURNING id`).Scan(&newID))
+	assert.Greater(t, newID, maxID)
+	_, err = db.Exec(`INSERT INTO commits (repository_id, sha, message) VALUES (1, 'sha-new', 'Again')`)
+	assert.Error(t, err, "duplicate SHA in a repository")
+}
+
+func TestInitializeDBOverPartitionedCommits(t *testing.T) {
+	pg, _ := setupTestDB(t)
+	db := pg.DB
+	commits := countRows(t, db, `SELECT COUNT(*) FROM commits`)
+	require.NotZero(t, commits)
+
+	// The schema is applied over the one testutil created, then again after
+	// its recorded checksum is cleared
+	require.NoError(t, database.InitializeDB(db))
+	_, err := db.Exec(`DELETE FROM schema_version`)
+	require.NoError(t, err)
+	require.NoError(t, database.InitializeDB(db))
+
+	assert.Equal(t, 16, countRows(t, db, `SELECT COUNT(*) FROM pg_inherits WHERE inhparent = 'commits'::regclass`))
+	assert.Equal(t, commits, countRows(t, db, `SELECT COUNT(*) FROM commits`))
+}
@@ -0,0 +1,23 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github-service/internal/database"
+	"github-service/internal/testutil"
+
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB starts a Postgres container with the schema and fixtures loaded
+func setupTestDB(t *testing.T) (*testutil.TestPostgres, *database.DB) {
+	ctx := context.Background()
+	pg, err := testutil.NewTestPostgres(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, pg.Close(ctx))
+	})
+	require.NoError(t, pg.LoadFixtures())
+	return pg, database.NewFromDB(pg.DB)
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// UpsertTags stores the tags of a repository, moving tags that now point at a
+// different commit
+func (d *DB) UpsertTags(ctx context.Context, repoID int64, tags []models.Tag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO tags (repository_id, name, commit_sha)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (repository_id, name) DO UPDATE SET commit_sha = EXCLUDED.commit_sha`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, t := range tags {
+		if _, err := stmt.ExecContext(ctx, repoID, t.Name, t.CommitSHA); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpsertReleases stores the releases of a repository, updating the stored copy
+// of releases that already exist
+func (d *DB) UpsertReleases(ctx context.Context, repoID int64, releases []models.Release) error {
+	if len(releases) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO releases (
+			repository_id, github_id, tag_name, name, draft, prerelease,
+			author_login, url, created_at, published_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (repository_id, github_id) DO UPDATE SET
+			tag_name = EXCLUDED.tag_name,
+			name = EXCLUDED.name,
+			draft = EXCLUDED.draft,
+			prerelease = EXCLUDED.prerelease,
+			published_at = EXCLUDED.published_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range releases {
+		if _, err := stmt.ExecContext(ctx,
+			repoID, r.GitHubID, r.TagName, r.Name, r.Draft, r.Prerelease,
+			r.AuthorLogin, r.URL, r.CreatedAt, r.PublishedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReleasesByRepository returns a page of a repository's releases, most
+// recently published first with drafts last. Each release carries the commit
+// its tag points at when the tag has been synced.
+func (d *DB) GetReleasesByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Release, error) {
+	offset := (page - 1) * perPage
+	query := `
+		SELECT r.id, r.repository_id, r.github_id, r.tag_name, r.name, r.draft, r.prerelease,
+			r.author_login, r.url, r.created_at, r.published_at, t.commit_sha, r.created_at_local
+		FROM releases r
+		LEFT JOIN tags t ON t.repository_id = r.repository_id AND t.name = r.tag_name
+		WHERE r.repository_id = $1
+		ORDER BY r.published_at DESC NULLS LAST, r.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, perPage, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*models.Release
+	for rows.Next() {
+		release := &models.Release{}
+		var publishedAt sql.NullTime
+		var commitSHA sql.NullString
+		if err := rows.Scan(
+			&release.ID, &release.RepositoryID, &release.GitHubID, &release.TagName,
+			&release.Name, &release.Draft, &release.Prerelease, &release.AuthorLogin,
+			&release.URL, &release.CreatedAt, &publishedAt, &commitSHA, &release.CreatedAtLocal,
+		); err != nil {
+			return nil, err
+		}
+		if publishedAt.Valid {
+			release.PublishedAt = &publishedAt.Time
+		}
+		release.CommitSHA = commitSHA.String
+		releases = append(releases, release)
+	}
+	return releases, rows.Err()
+}
+
+// GetReleaseIntervals returns the published releases of a repository within the
+// time window, oldest first, each with the commits it shipped. A release ships
+// the commits made up to the commit its tag points at, or up to its publication
+// when the tag or its commit isn't synced, since the previous release's cutoff.
+func (d *DB) GetReleaseIntervals(ctx context.Context, repoID int64, since, until *time.Time, includePrereleases bool) ([]*models.ReleaseInterval, error) {
+	query := `
+		WITH published AS (
+			SELECT r.tag_name, r.published_at, COALESCE(c.commit_date, r.published_at) AS cutoff
+			FROM releases r
+			LEFT JOIN tags t ON t.repository_id = r.repository_id AND t.name = r.tag_name
+			LEFT JOIN commits c ON c.repository_id = r.repository_id AND c.sha = t.commit_sha
+			WHERE r.repository_id = $1 AND NOT r.draft AND r.published_at IS NOT NULL
+				AND ($4 OR NOT r.prerelease)
+		), bounded AS (
+			SELECT tag_name, published_at, cutoff,
+				LAG(published_at) OVER (ORDER BY published_at) AS previous_published_at,
+				LAG(cutoff) OVER (ORDER BY published_at) AS previous_cutoff
+			FROM published
+		)
+		SELECT b.tag_name, b.published_at, b.previous_published_at, COUNT(c.id),
+			AVG(EXTRACT(EPOCH FROM b.published_at - c.commit_date)) / 86400
+		FROM bounded b
+		LEFT JOIN commits c ON c.repository_id = $1 AND c.commit_date <= b.cutoff
+			AND (b.previous_cutoff IS NULL OR c.commit_date > b.previous_cutoff)
+		WHERE ($2::timestamptz IS NULL OR b.published_at >= $2)
+			AND ($3::timestamptz IS NULL OR b.published_at <= $3)
+		GROUP BY b.tag_name, b.published_at, b.previous_published_at
+		ORDER BY b.published_at`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until, includePrereleases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var intervals []*models.ReleaseInterval
+	for rows.Next() {
+		interval := &models.ReleaseInterval{}
+		var previous sql.NullTime
+		var daysToRelease sql.NullFloat64
+		if err := rows.Scan(&interval.TagName, &interval.PublishedAt, &previous, &interval.Commits, &daysToRelease); err != nil {
+			return nil, err
+		}
+		if previous.Valid {
+			interval.PreviousPublishedAt = &previous.Time
+			days := interval.PublishedAt.Sub(previous.Time).Hours() / 24
+			interval.DaysSincePrevious = &days
+		}
+		if daysToRelease.Valid {
+			interval.AvgDaysToRelease = &daysToRelease.Float64
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals, rows.Err()
+}
+
+// GetReleaseCountByRepository returns the number of a repository's releases
+func (d *DB) GetReleaseCountByRepository(ctx context.Context, repoID int64) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM releases WHERE repository_id = $1`, repoID,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// CountCommitsAndAuthors counts a repository's commits made in [start, end) and
+// their distinct authors, with merged author identities counted once
+func (d *DB) CountCommitsAndAuthors(ctx context.Context, repoID int64, start, end time.Time) (commits, authors int, err error) {
+	query := `
+		SELECT COUNT(*), COUNT(DISTINCT LOWER(` + canonicalAuthorEmail + `))
+		FROM commits c
+		` + authorIdentityJoin + `
+		WHERE c.repository_id = $1 AND c.commit_date >= $2 AND c.commit_date < $3`
+
+	err = d.db.QueryRowContext(ctx, query, repoID, start, end).Scan(&commits, &authors)
+	return commits, authors, err
+}
+
+// GetNewCommitAuthors returns the authors whose first commit to a repository was
+// made in [start, end), most active first
+func (d *DB) GetNewCommitAuthors(ctx context.Context, repoID int64, start, end time.Time) ([]*models.CommitStats, error) {
+	query := `
+		SELECT ` + canonicalAuthorName + ` AS author_name, ` + canonicalAuthorEmail + ` AS author_email,
+			COUNT(*) as commit_count, ` + commitLineTotals + `
+		FROM commits c
+		` + authorIdentityJoin + `
+		WHERE c.repository_id = $1 AND c.commit_date < $3
+		GROUP BY 1, 2
+		HAVING MIN(c.commit_date) >= $2
+		ORDER BY commit_count DESC, author_name, author_email`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCommitStats(rows)
+}
+
+// GetBusiestDays returns the days in [start, end) with the most commits to a
+// repository, busiest first
+func (d *DB) GetBusiestDays(ctx context.Context, repoID int64, start, end time.Time, limit int) ([]*models.DailyCommitCount, error) {
+	query := `
+		SELECT TO_CHAR(c.commit_date AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, COUNT(*) AS commits
+		FROM commits c
+		WHERE c.repository_id = $1 AND c.commit_date >= $2 AND c.commit_date < $3
+		GROUP BY 1
+		ORDER BY commits DESC, day
+		LIMIT $4`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*models.DailyCommitCount
+	for rows.Next() {
+		day := &models.DailyCommitCount{}
+		if err := rows.Scan(&day.Date, &day.Commits); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// SaveMonthlyReport stores a repository's report, replacing an earlier one for the same month
+func (d *DB) SaveMonthlyReport(ctx context.Context, repoID int64, report *models.MonthlyReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO monthly_reports (repository_id, month, report, markdown, generated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (repository_id, month) DO UPDATE
+		SET report = EXCLUDED.report, markdown = EXCLUDED.markdown, generated_at = EXCLUDED.generated_at`
+
+	_, err = d.db.ExecContext(ctx, query, repoID, report.Month, data, report.Markdown, report.GeneratedAt)
+	return err
+}
+
+// GetMonthlyReport retrieves a repository's report for a month (YYYY-MM), or nil
+// if it hasn't been generated
+func (d *DB) GetMonthlyReport(ctx context.Context, repoID int64, month string) (*models.MonthlyReport, error) {
+	var data []byte
+	var markdown string
+	err := d.db.QueryRowContext(ctx,
+		`SELECT report, markdown FROM monthly_reports WHERE repository_id = $1 AND month = $2`,
+		repoID, month,
+	).Scan(&data, &markdown)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.MonthlyReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, err
+	}
+	report.Markdown = markdown
+	return report, nil
+}
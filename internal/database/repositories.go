@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// CreateRepository creates a new repository record
+func (d *DB) CreateRepository(ctx context.Context, repo *models.Repository) error {
+	if repo.Provider == "" {
+		repo.Provider = models.ProviderGitHub
+	}
+	fmt.Printf("Creating repository: %s (%s ID: %d)\n", repo.FullName, repo.Provider, repo.GitHubID)
+	query := `
+		INSERT INTO repositories (
+			github_id, provider, name, full_name, description, url, language,
+			forks_count, stars_count, open_issues_count, watchers_count,
+			created_at, updated_at, commits_since
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id`
+
+	err := d.db.QueryRowContext(ctx, query,
+		repo.GitHubID, repo.Provider, repo.Name, repo.FullName, repo.Description, repo.URL,
+		repo.Language, repo.ForksCount, repo.StarsCount, repo.OpenIssuesCount,
+		repo.WatchersCount, repo.CreatedAt, repo.UpdatedAt, repo.CommitsSince,
+	).Scan(&repo.ID)
+
+	if err != nil {
+		fmt.Printf("Error creating repository %s: %v\n", repo.FullName, err)
+		return err
+	}
+	fmt.Printf("Successfully created repository %s with ID %d\n", repo.FullName, repo.ID)
+
+	return nil
+}
+
+// UpdateRepository updates an existing repository record
+func (d *DB) UpdateRepository(ctx context.Context, repo *models.Repository) error {
+	query := `
+		UPDATE repositories SET
+			name = $1, description = $2, url = $3, language = $4,
+			forks_count = $5, stars_count = $6, open_issues_count = $7,
+			watchers_count = $8, updated_at = $9, updated_at_local = CURRENT_TIMESTAMP
+		WHERE id = $10`
+
+	result, err := d.db.ExecContext(ctx, query,
+		repo.Name, repo.Description, repo.URL, repo.Language,
+		repo.ForksCount, repo.StarsCount, repo.OpenIssuesCount,
+		repo.WatchersCount, repo.UpdatedAt, repo.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository", repo.ID)
+	}
+
+	return nil
+}
+
+// repositoryColumns lists the repository columns in the order scanned by scanRepository
+const repositoryColumns = `id, github_id, provider, name, full_name, description, url, language,
+	forks_count, stars_count, open_issues_count, watchers_count, created_at, updated_at,
+	last_commit_check, commits_since, created_at_local, updated_at_local`
+
+// scanRepository scans a row of repositoryColumns
+func scanRepository(row *sql.Row) (*models.Repository, error) {
+	repo := &models.Repository{}
+	err := row.Scan(
+		&repo.ID, &repo.GitHubID, &repo.Provider, &repo.Name, &repo.FullName,
+		&repo.Description, &repo.URL, &repo.Language, &repo.ForksCount,
+		&repo.StarsCount, &repo.OpenIssuesCount, &repo.WatchersCount,
+		&repo.CreatedAt, &repo.UpdatedAt, &repo.LastCommitCheck,
+		&repo.CommitsSince, &repo.CreatedAtLocal, &repo.UpdatedAtLocal,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return repo, err
+}
+
+// GetRepositoryByName retrieves a repository by its full name. Removed
+// repositories whose data is still retained are not returned.
+func (d *DB) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
+	query := `SELECT ` + repositoryColumns + ` FROM repositories WHERE full_name = $1 AND deleted_at IS NULL`
+	return scanRepository(d.db.QueryRowContext(ctx, query, fullName))
+}
+
+// UpdateLastCommitCheck updates the last commit check timestamp
+func (d *DB) UpdateLastCommitCheck(ctx context.Context, repoID int64, lastCheck time.Time) error {
+	query := `UPDATE repositories SET last_commit_check = $1, updated_at_local = CURRENT_TIMESTAMP WHERE id = $2`
+	result, err := d.db.ExecContext(ctx, query, &lastCheck, repoID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository", repoID)
+	}
+	return nil
+}
+
+// SetCommitsSince sets the commits_since timestamp, or clears it when since is nil
+func (d *DB) SetCommitsSince(ctx context.Context, repoID int64, since *time.Time) error {
+	query := `UPDATE repositories SET commits_since = $1, updated_at_local = CURRENT_TIMESTAMP WHERE id = $2`
+	result, err := d.db.ExecContext(ctx, query, since, repoID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository", repoID)
+	}
+	return nil
+}
+
+// DeleteRepository deletes a repository and its associated commits from the database
+func (d *DB) DeleteRepository(ctx context.Context, repoID int64) error {
+	// The commits will be automatically deleted due to ON DELETE CASCADE
+	query := `DELETE FROM repositories WHERE id = $1`
+	result, err := d.db.ExecContext(ctx, query, repoID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository", repoID)
+	}
+
+	return nil
+}
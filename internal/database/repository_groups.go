@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// repositoryGroupColumns selects a group with its sorted members in the order
+// expected by scanRepositoryGroup
+const repositoryGroupColumns = `g.id, g.name, g.description, g.created_at,
+	COALESCE(ARRAY(SELECT gm.repository FROM repository_group_members gm
+		WHERE gm.group_id = g.id ORDER BY gm.repository), '{}')`
+
+// scanRepositoryGroup scans a row selected with repositoryGroupColumns
+func scanRepositoryGroup(row rowScanner) (*models.RepositoryGroup, error) {
+	group := &models.RepositoryGroup{}
+	err := row.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt, pq.Array(&group.Repositories))
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// CreateRepositoryGroup stores a new group with its members. It fails with
+// ErrDuplicate when a group of the name exists, and with a NotFoundError when
+// a member is not a monitored repository.
+func (d *DB) CreateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO repository_groups (name, description)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING id, created_at`, group.Name, group.Description).Scan(&group.ID, &group.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: repository group %s", errors.ErrDuplicate, group.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := addGroupMembers(ctx, tx, group.ID, group.Repositories); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRepositoryGroup retrieves a group by name, or nil if it doesn't exist
+func (d *DB) GetRepositoryGroup(ctx context.Context, name string) (*models.RepositoryGroup, error) {
+	group, err := scanRepositoryGroup(d.db.QueryRowContext(ctx,
+		`SELECT `+repositoryGroupColumns+` FROM repository_groups g WHERE g.name = $1`, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return group, err
+}
+
+// ListRepositoryGroups returns every group ordered by name
+func (d *DB) ListRepositoryGroups(ctx context.Context) ([]*models.RepositoryGroup, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+repositoryGroupColumns+` FROM repository_groups g ORDER BY g.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*models.RepositoryGroup
+	for rows.Next() {
+		group, err := scanRepositoryGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// UpdateRepositoryGroup replaces the description and members of a group,
+// filling in its ID and creation time. It fails with a NotFoundError when the
+// group or a member doesn't exist.
+func (d *DB) UpdateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE repository_groups SET description = $2
+		WHERE name = $1
+		RETURNING id, created_at`, group.Name, group.Description).Scan(&group.ID, &group.CreatedAt)
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundError("repository group", group.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM repository_group_members WHERE group_id = $1`, group.ID); err != nil {
+		return err
+	}
+	if err := addGroupMembers(ctx, tx, group.ID, group.Repositories); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteRepositoryGroup removes a group; its repositories stay monitored
+func (d *DB) DeleteRepositoryGroup(ctx context.Context, name string) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM repository_groups WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository group", name)
+	}
+	return nil
+}
+
+// AddRepositoryGroupMember adds a monitored repository to a group, doing
+// nothing when it is a member already
+func (d *DB) AddRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var groupID int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM repository_groups WHERE name = $1`, name).Scan(&groupID)
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundError("repository group", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := addGroupMembers(ctx, tx, groupID, []string{fullName}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveRepositoryGroupMember removes a repository from a group. It fails with
+// a NotFoundError when the repository is not a member.
+func (d *DB) RemoveRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	result, err := d.db.ExecContext(ctx, `
+		DELETE FROM repository_group_members gm
+		USING repository_groups g
+		WHERE g.id = gm.group_id AND g.name = $1 AND gm.repository = $2`, name, fullName)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository group member", fullName)
+	}
+	return nil
+}
+
+// addGroupMembers adds monitored repositories to a group within tx. It fails
+// with a NotFoundError naming the first repository that is not monitored.
+func addGroupMembers(ctx context.Context, tx *sql.Tx, groupID int64, repositories []string) error {
+	if len(repositories) == 0 {
+		return nil
+	}
+
+	var missing string
+	err := tx.QueryRowContext(ctx, `
+		SELECT name FROM unnest($1::text[]) WITH ORDINALITY AS names(name, position)
+		WHERE NOT EXISTS (SELECT 1 FROM monitored_repositories m WHERE m.full_name = names.name)
+		ORDER BY position
+		LIMIT 1`, pq.Array(repositories)).Scan(&missing)
+	if err == nil {
+		return errors.NewNotFoundError("monitored repository", missing)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO repository_group_members (group_id, repository)
+		SELECT $1, name FROM unnest($2::text[]) AS name
+		ON CONFLICT DO NOTHING`, groupID, pq.Array(repositories))
+	return err
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// RecordRepositoryStats stores the repository's current counters as its snapshot
+// for the given day, replacing any snapshot already taken that day
+func (d *DB) RecordRepositoryStats(ctx context.Context, repo *models.Repository, day time.Time) error {
+	query := `
+		INSERT INTO repository_stats_history (
+			repository_id, snapshot_date, stars_count, forks_count, watchers_count, open_issues_count
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (repository_id, snapshot_date) DO UPDATE SET
+			stars_count = EXCLUDED.stars_count,
+			forks_count = EXCLUDED.forks_count,
+			watchers_count = EXCLUDED.watchers_count,
+			open_issues_count = EXCLUDED.open_issues_count,
+			recorded_at = CURRENT_TIMESTAMP`
+
+	_, err := d.db.ExecContext(ctx, query,
+		repo.ID, day.UTC().Format("2006-01-02"),
+		repo.StarsCount, repo.ForksCount, repo.WatchersCount, repo.OpenIssuesCount,
+	)
+	return err
+}
+
+// GetStarsCountAsOf returns a repository's stars count from its latest snapshot
+// taken on or before day, or nil without one
+func (d *DB) GetStarsCountAsOf(ctx context.Context, repoID int64, day time.Time) (*int, error) {
+	query := `
+		SELECT stars_count
+		FROM repository_stats_history
+		WHERE repository_id = $1 AND snapshot_date <= $2::date
+		ORDER BY snapshot_date DESC
+		LIMIT 1`
+
+	var stars int
+	err := d.db.QueryRowContext(ctx, query, repoID, day).Scan(&stars)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stars, nil
+}
+
+// GetRepositoryStatsAsOf returns a repository's latest snapshot taken on or
+// before day, or nil without one
+func (d *DB) GetRepositoryStatsAsOf(ctx context.Context, repoID int64, day time.Time) (*models.RepositoryStatsSnapshot, error) {
+	query := `
+		SELECT snapshot_date, stars_count, forks_count, watchers_count, open_issues_count, recorded_at
+		FROM repository_stats_history
+		WHERE repository_id = $1 AND snapshot_date <= $2::date
+		ORDER BY snapshot_date DESC
+		LIMIT 1`
+
+	snapshot := &models.RepositoryStatsSnapshot{}
+	err := d.db.QueryRowContext(ctx, query, repoID, day).Scan(
+		&snapshot.Date, &snapshot.StarsCount, &snapshot.ForksCount,
+		&snapshot.WatchersCount, &snapshot.OpenIssuesCount, &snapshot.RecordedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// GetRepositoryStatsHistory returns a repository's daily snapshots, oldest first,
+// optionally bounded by since and until
+func (d *DB) GetRepositoryStatsHistory(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.RepositoryStatsSnapshot, error) {
+	query := `
+		SELECT snapshot_date, stars_count, forks_count, watchers_count, open_issues_count, recorded_at
+		FROM repository_stats_history
+		WHERE repository_id = $1
+			AND ($2::date IS NULL OR snapshot_date >= $2::date)
+			AND ($3::date IS NULL OR snapshot_date <= $3::date)
+		ORDER BY snapshot_date`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.RepositoryStatsSnapshot
+	for rows.Next() {
+		snapshot := &models.RepositoryStatsSnapshot{}
+		if err := rows.Scan(
+			&snapshot.Date, &snapshot.StarsCount, &snapshot.ForksCount,
+			&snapshot.WatchersCount, &snapshot.OpenIssuesCount, &snapshot.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, snapshot)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// SoftDeleteRepository marks a repository as removed while retaining its data.
+// The repository is hidden from lookups until restored or purged.
+func (d *DB) SoftDeleteRepository(ctx context.Context, repoID int64) error {
+	query := `
+		UPDATE repositories
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at_local = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL`
+	result, err := d.db.ExecContext(ctx, query, repoID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("repository", repoID)
+	}
+	return nil
+}
+
+// RestoreRepository clears the removal of a repository whose data is retained
+// and returns it, or returns nil when no such repository exists
+func (d *DB) RestoreRepository(ctx context.Context, fullName string) (*models.Repository, error) {
+	query := `
+		UPDATE repositories
+		SET deleted_at = NULL, updated_at_local = CURRENT_TIMESTAMP
+		WHERE full_name = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + repositoryColumns
+	return scanRepository(d.db.QueryRowContext(ctx, query, fullName))
+}
+
+// PurgeDeletedRepositories deletes the repositories removed before the given
+// time along with their commits, and returns the names of the purged repositories
+func (d *DB) PurgeDeletedRepositories(ctx context.Context, before time.Time) ([]string, error) {
+	// The commits and other data are deleted by ON DELETE CASCADE
+	rows, err := d.db.QueryContext(ctx,
+		`DELETE FROM repositories WHERE deleted_at < $1 RETURNING full_name`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purged []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		purged = append(purged, name)
+	}
+	return purged, rows.Err()
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// OperationClass groups database operations that share a retry policy
+type OperationClass string
+
+const (
+	// OperationRead covers queries without side effects, which are always safe to repeat
+	OperationRead OperationClass = "read"
+	// OperationWrite covers statements that modify data
+	OperationWrite OperationClass = "write"
+)
+
+// RetryPolicy configures how an operation class is retried on transient errors
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 1 disables retries
+	InitialBackoff time.Duration // Delay before the first retry, doubled for each further retry
+	MaxBackoff     time.Duration // Upper bound for the delay between attempts
+}
+
+// DefaultRetryPolicies returns the retry policies used when none are configured
+func DefaultRetryPolicies() map[OperationClass]RetryPolicy {
+	return map[OperationClass]RetryPolicy{
+		OperationRead:  {MaxAttempts: 3, InitialBackoff: 50 * time.Millisecond, MaxBackoff: time.Second},
+		OperationWrite: {MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second},
+	}
+}
+
+// backoff returns the delay before the given retry (1-based)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff << (retry - 1)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// transientKind describes whether, and how safely, a failed operation can be retried
+type transientKind int
+
+const (
+	notTransient transientKind = iota
+	// transientNoEffect errors guarantee the statement was rolled back or never ran
+	transientNoEffect
+	// transientConnection errors are raised when a connection dies, possibly after
+	// the statement was applied
+	transientConnection
+)
+
+// classifyError reports whether err is a transient Postgres or connection error
+func classifyError(err error) transientKind {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return notTransient
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"55P03", // lock_not_available
+			"53300", // too_many_connections
+			"57P03", // cannot_connect_now
+			"08001", // sqlclient_unable_to_establish_sqlconnection
+			"08004": // sqlserver_rejected_establishment_of_sqlconnection
+			return transientNoEffect
+		case "57P01", // admin_shutdown
+			"57P02": // crash_shutdown
+			return transientConnection
+		}
+		if pqErr.Code.Class() == "08" { // connection_exception
+			return transientConnection
+		}
+		return notTransient
+	}
+
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, syscall.ECONNREFUSED):
+		return transientNoEffect
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return transientConnection
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return transientConnection
+	}
+	return notTransient
+}
+
+// IsTransient reports whether err is a transient error that may succeed if retried
+func IsTransient(err error) bool {
+	return classifyError(err) != notTransient
+}
+
+// retryable reports whether err may be retried for an operation of the given class.
+// Writes are only retried when the failed attempt is known to have had no effect,
+// so a dropped connection never causes a statement to be applied twice.
+func retryable(class OperationClass, err error) bool {
+	switch classifyError(err) {
+	case transientNoEffect:
+		return true
+	case transientConnection:
+		return class == OperationRead
+	default:
+		return false
+	}
+}
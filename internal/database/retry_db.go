@@ -0,0 +1,875 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/tracing"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RetryDB decorates a DB, retrying operations that fail with transient errors
+// according to the policy of their operation class. Operations without an
+// override here, such as streaming queries, run once.
+type RetryDB struct {
+	*DB
+	policies map[OperationClass]RetryPolicy
+	logger   zerolog.Logger
+}
+
+// NewRetryDB wraps db with retries. Classes missing from policies use the defaults.
+func NewRetryDB(db *DB, policies map[OperationClass]RetryPolicy, logger zerolog.Logger) *RetryDB {
+	merged := DefaultRetryPolicies()
+	for class, policy := range policies {
+		merged[class] = policy
+	}
+	return &RetryDB{DB: db, policies: merged, logger: logger}
+}
+
+// do runs fn, retrying it with backoff while it fails with an error that is
+// retryable for the operation class
+func (r *RetryDB) do(ctx context.Context, class OperationClass, op string, fn func() error) error {
+	policy := r.policies[class]
+
+	// One span covers all attempts of the operation
+	_, span := tracing.Tracer().Start(ctx, "db "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", op),
+			attribute.String("db.operation.class", string(class)),
+		))
+	defer span.End()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= policy.MaxAttempts || !retryable(class, err) {
+			span.SetAttributes(attribute.Int("db.attempts", attempt))
+			tracing.RecordError(span, err)
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		r.logger.Warn().
+			Err(err).
+			Str("operation", op).
+			Str("class", string(class)).
+			Int("attempt", attempt).
+			Dur("backoff", delay).
+			Msg("Retrying database operation after transient error")
+
+		select {
+		case <-ctx.Done():
+			span.SetAttributes(attribute.Int("db.attempts", attempt))
+			tracing.RecordError(span, err)
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryValue is do for operations that return a value
+func retryValue[T any](ctx context.Context, r *RetryDB, class OperationClass, op string, fn func() (T, error)) (T, error) {
+	var result T
+	err := r.do(ctx, class, op, func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
+
+func (r *RetryDB) CreateRepository(ctx context.Context, repo *models.Repository) error {
+	return r.do(ctx, OperationWrite, "CreateRepository", func() error { return r.DB.CreateRepository(ctx, repo) })
+}
+
+func (r *RetryDB) UpdateRepository(ctx context.Context, repo *models.Repository) error {
+	return r.do(ctx, OperationWrite, "UpdateRepository", func() error { return r.DB.UpdateRepository(ctx, repo) })
+}
+
+func (r *RetryDB) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryByName", func() (*models.Repository, error) {
+		return r.DB.GetRepositoryByName(ctx, fullName)
+	})
+}
+
+func (r *RetryDB) UpdateLastCommitCheck(ctx context.Context, repoID int64, lastCheck time.Time) error {
+	return r.do(ctx, OperationWrite, "UpdateLastCommitCheck", func() error { return r.DB.UpdateLastCommitCheck(ctx, repoID, lastCheck) })
+}
+
+func (r *RetryDB) SetCommitsSince(ctx context.Context, repoID int64, since *time.Time) error {
+	return r.do(ctx, OperationWrite, "SetCommitsSince", func() error { return r.DB.SetCommitsSince(ctx, repoID, since) })
+}
+
+func (r *RetryDB) CreateCommit(ctx context.Context, commit *models.Commit) (created bool, err error) {
+	err = r.do(ctx, OperationWrite, "CreateCommit", func() error {
+		created, err = r.DB.CreateCommit(ctx, commit)
+		return err
+	})
+	return created, err
+}
+
+func (r *RetryDB) CreateCommits(ctx context.Context, commits []*models.Commit) (created, existing int, err error) {
+	err = r.do(ctx, OperationWrite, "CreateCommits", func() error {
+		created, existing, err = r.DB.CreateCommits(ctx, commits)
+		return err
+	})
+	return created, existing, err
+}
+
+func (r *RetryDB) GetCommitsBySHA(ctx context.Context, repoID int64, sha string) (*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitsBySHA", func() (*models.Commit, error) {
+		return r.DB.GetCommitsBySHA(ctx, repoID, sha)
+	})
+}
+
+func (r *RetryDB) FindCommitsBySHAPrefix(ctx context.Context, repoID int64, prefix string, limit int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "FindCommitsBySHAPrefix", func() ([]*models.Commit, error) {
+		return r.DB.FindCommitsBySHAPrefix(ctx, repoID, prefix, limit)
+	})
+}
+
+func (r *RetryDB) GetNeighborCommits(ctx context.Context, commit *models.Commit) (previous, next *models.Commit, err error) {
+	err = r.do(ctx, OperationRead, "GetNeighborCommits", func() error {
+		var err error
+		previous, next, err = r.DB.GetNeighborCommits(ctx, commit)
+		return err
+	})
+	return previous, next, err
+}
+
+func (r *RetryDB) GetCommitsByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitsByRepository", func() ([]*models.Commit, error) {
+		return r.DB.GetCommitsByRepository(ctx, repoID, page, perPage)
+	})
+}
+
+func (r *RetryDB) GetLatestCommits(ctx context.Context, repoID int64, count int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetLatestCommits", func() ([]*models.Commit, error) {
+		return r.DB.GetLatestCommits(ctx, repoID, count)
+	})
+}
+
+func (r *RetryDB) GetLatestCommitKeys(ctx context.Context, repoID int64, count int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetLatestCommitKeys", func() ([]*models.Commit, error) {
+		return r.DB.GetLatestCommitKeys(ctx, repoID, count)
+	})
+}
+
+func (r *RetryDB) GetCommitCountByRepository(ctx context.Context, repoID int64) (int, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitCountByRepository", func() (int, error) {
+		return r.DB.GetCommitCountByRepository(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) CountCommits(ctx context.Context, repoID int64, filter models.CommitFilter) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountCommits", func() (int, error) {
+		return r.DB.CountCommits(ctx, repoID, filter)
+	})
+}
+
+func (r *RetryDB) SearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions, page, perPage int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "SearchCommits", func() ([]*models.Commit, error) {
+		return r.DB.SearchCommits(ctx, repoID, opts, page, perPage)
+	})
+}
+
+func (r *RetryDB) CountSearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountSearchCommits", func() (int, error) {
+		return r.DB.CountSearchCommits(ctx, repoID, opts)
+	})
+}
+
+func (r *RetryDB) GetTopRepositories(ctx context.Context, since time.Time, metric, group string, account int64, limit int) ([]*models.RepositoryActivity, error) {
+	return retryValue(ctx, r, OperationRead, "GetTopRepositories", func() ([]*models.RepositoryActivity, error) {
+		return r.DB.GetTopRepositories(ctx, since, metric, group, account, limit)
+	})
+}
+
+func (r *RetryDB) GetTopCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64, limit, offset int) ([]*models.CommitStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetTopCommitAuthors", func() ([]*models.CommitStats, error) {
+		return r.DB.GetTopCommitAuthors(ctx, since, until, language, group, account, limit, offset)
+	})
+}
+
+func (r *RetryDB) GetTopCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time, limit, offset int) ([]*models.CommitStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetTopCommitAuthorsByRepository", func() ([]*models.CommitStats, error) {
+		return r.DB.GetTopCommitAuthorsByRepository(ctx, repoID, since, until, limit, offset)
+	})
+}
+
+func (r *RetryDB) CountCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountCommitAuthors", func() (int, error) {
+		return r.DB.CountCommitAuthors(ctx, since, until, language, group, account)
+	})
+}
+
+func (r *RetryDB) CountCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountCommitAuthorsByRepository", func() (int, error) {
+		return r.DB.CountCommitAuthorsByRepository(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) GetAuthorCommitCounts(ctx context.Context, repoID int64, since, until *time.Time) ([]int, error) {
+	return retryValue(ctx, r, OperationRead, "GetAuthorCommitCounts", func() ([]int, error) {
+		return r.DB.GetAuthorCommitCounts(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) DeleteRepository(ctx context.Context, repoID int64) error {
+	return r.do(ctx, OperationWrite, "DeleteRepository", func() error { return r.DB.DeleteRepository(ctx, repoID) })
+}
+
+func (r *RetryDB) SoftDeleteRepository(ctx context.Context, repoID int64) error {
+	return r.do(ctx, OperationWrite, "SoftDeleteRepository", func() error { return r.DB.SoftDeleteRepository(ctx, repoID) })
+}
+
+func (r *RetryDB) RestoreRepository(ctx context.Context, fullName string) (*models.Repository, error) {
+	return retryValue(ctx, r, OperationWrite, "RestoreRepository", func() (*models.Repository, error) {
+		return r.DB.RestoreRepository(ctx, fullName)
+	})
+}
+
+func (r *RetryDB) PurgeDeletedRepositories(ctx context.Context, before time.Time) ([]string, error) {
+	return retryValue(ctx, r, OperationWrite, "PurgeDeletedRepositories", func() ([]string, error) {
+		return r.DB.PurgeDeletedRepositories(ctx, before)
+	})
+}
+
+func (r *RetryDB) MergeAuthorIdentities(ctx context.Context, name, canonicalEmail string, emails []string) ([]*models.AuthorIdentity, error) {
+	return retryValue(ctx, r, OperationWrite, "MergeAuthorIdentities", func() ([]*models.AuthorIdentity, error) {
+		return r.DB.MergeAuthorIdentities(ctx, name, canonicalEmail, emails)
+	})
+}
+
+func (r *RetryDB) ListAuthorIdentities(ctx context.Context) ([]*models.AuthorIdentity, error) {
+	return retryValue(ctx, r, OperationRead, "ListAuthorIdentities", func() ([]*models.AuthorIdentity, error) {
+		return r.DB.ListAuthorIdentities(ctx)
+	})
+}
+
+func (r *RetryDB) DeleteAuthorIdentity(ctx context.Context, email string) error {
+	return r.do(ctx, OperationWrite, "DeleteAuthorIdentity", func() error { return r.DB.DeleteAuthorIdentity(ctx, email) })
+}
+
+func (r *RetryDB) GetAuthorCommits(ctx context.Context, email string, repoID int64, page, perPage int) ([]*models.AuthorCommit, error) {
+	return retryValue(ctx, r, OperationRead, "GetAuthorCommits", func() ([]*models.AuthorCommit, error) {
+		return r.DB.GetAuthorCommits(ctx, email, repoID, page, perPage)
+	})
+}
+
+func (r *RetryDB) CountAuthorCommits(ctx context.Context, email string, repoID int64) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountAuthorCommits", func() (int, error) {
+		return r.DB.CountAuthorCommits(ctx, email, repoID)
+	})
+}
+
+func (r *RetryDB) CreateCommitFiles(ctx context.Context, repoID, commitID int64, files []models.CommitFile) error {
+	return r.do(ctx, OperationWrite, "CreateCommitFiles", func() error { return r.DB.CreateCommitFiles(ctx, repoID, commitID, files) })
+}
+
+func (r *RetryDB) GetCommitFiles(ctx context.Context, commitID int64) ([]models.CommitFile, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitFiles", func() ([]models.CommitFile, error) {
+		return r.DB.GetCommitFiles(ctx, commitID)
+	})
+}
+
+func (r *RetryDB) UpdateCommitStats(ctx context.Context, repoID, commitID int64, additions, deletions, filesChanged int) error {
+	return r.do(ctx, OperationWrite, "UpdateCommitStats", func() error {
+		return r.DB.UpdateCommitStats(ctx, repoID, commitID, additions, deletions, filesChanged)
+	})
+}
+
+func (r *RetryDB) GetCommitsMissingStats(ctx context.Context, repoID int64, limit int) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitsMissingStats", func() ([]*models.Commit, error) {
+		return r.DB.GetCommitsMissingStats(ctx, repoID, limit)
+	})
+}
+
+func (r *RetryDB) GetFileExtensionStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.FileExtensionStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetFileExtensionStats", func() ([]*models.FileExtensionStats, error) {
+		return r.DB.GetFileExtensionStats(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) GetCommitTypeStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.CommitTypeStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitTypeStats", func() ([]*models.CommitTypeStats, error) {
+		return r.DB.GetCommitTypeStats(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) UpsertIssue(ctx context.Context, issue *models.Issue) error {
+	return r.do(ctx, OperationWrite, "UpsertIssue", func() error { return r.DB.UpsertIssue(ctx, issue) })
+}
+
+func (r *RetryDB) GetIssuesByRepository(ctx context.Context, repoID int64, state string, page, perPage int) ([]*models.Issue, error) {
+	return retryValue(ctx, r, OperationRead, "GetIssuesByRepository", func() ([]*models.Issue, error) {
+		return r.DB.GetIssuesByRepository(ctx, repoID, state, page, perPage)
+	})
+}
+
+func (r *RetryDB) GetIssueCountByRepository(ctx context.Context, repoID int64, state string) (int, error) {
+	return retryValue(ctx, r, OperationRead, "GetIssueCountByRepository", func() (int, error) {
+		return r.DB.GetIssueCountByRepository(ctx, repoID, state)
+	})
+}
+
+func (r *RetryDB) GetLatestIssueUpdate(ctx context.Context, repoID int64) (*time.Time, error) {
+	return retryValue(ctx, r, OperationRead, "GetLatestIssueUpdate", func() (*time.Time, error) {
+		return r.DB.GetLatestIssueUpdate(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) UpsertTags(ctx context.Context, repoID int64, tags []models.Tag) error {
+	return r.do(ctx, OperationWrite, "UpsertTags", func() error { return r.DB.UpsertTags(ctx, repoID, tags) })
+}
+
+func (r *RetryDB) UpsertReleases(ctx context.Context, repoID int64, releases []models.Release) error {
+	return r.do(ctx, OperationWrite, "UpsertReleases", func() error { return r.DB.UpsertReleases(ctx, repoID, releases) })
+}
+
+func (r *RetryDB) GetReleasesByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Release, error) {
+	return retryValue(ctx, r, OperationRead, "GetReleasesByRepository", func() ([]*models.Release, error) {
+		return r.DB.GetReleasesByRepository(ctx, repoID, page, perPage)
+	})
+}
+
+func (r *RetryDB) GetReleaseCountByRepository(ctx context.Context, repoID int64) (int, error) {
+	return retryValue(ctx, r, OperationRead, "GetReleaseCountByRepository", func() (int, error) {
+		return r.DB.GetReleaseCountByRepository(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) GetReleaseIntervals(ctx context.Context, repoID int64, since, until *time.Time, includePrereleases bool) ([]*models.ReleaseInterval, error) {
+	return retryValue(ctx, r, OperationRead, "GetReleaseIntervals", func() ([]*models.ReleaseInterval, error) {
+		return r.DB.GetReleaseIntervals(ctx, repoID, since, until, includePrereleases)
+	})
+}
+
+func (r *RetryDB) RecordRepositoryStats(ctx context.Context, repo *models.Repository, day time.Time) error {
+	return r.do(ctx, OperationWrite, "RecordRepositoryStats", func() error { return r.DB.RecordRepositoryStats(ctx, repo, day) })
+}
+
+func (r *RetryDB) GetRepositoryStatsHistory(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.RepositoryStatsSnapshot, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryStatsHistory", func() ([]*models.RepositoryStatsSnapshot, error) {
+		return r.DB.GetRepositoryStatsHistory(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) GetStarsCountAsOf(ctx context.Context, repoID int64, day time.Time) (*int, error) {
+	return retryValue(ctx, r, OperationRead, "GetStarsCountAsOf", func() (*int, error) {
+		return r.DB.GetStarsCountAsOf(ctx, repoID, day)
+	})
+}
+
+func (r *RetryDB) GetRepositoryStatsAsOf(ctx context.Context, repoID int64, day time.Time) (*models.RepositoryStatsSnapshot, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryStatsAsOf", func() (*models.RepositoryStatsSnapshot, error) {
+		return r.DB.GetRepositoryStatsAsOf(ctx, repoID, day)
+	})
+}
+
+func (r *RetryDB) UpsertContributorWeeks(ctx context.Context, repoID int64, contributors []models.ContributorStats) error {
+	return r.do(ctx, OperationWrite, "UpsertContributorWeeks", func() error { return r.DB.UpsertContributorWeeks(ctx, repoID, contributors) })
+}
+
+func (r *RetryDB) GetContributorStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.ContributorStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetContributorStats", func() ([]*models.ContributorStats, error) {
+		return r.DB.GetContributorStats(ctx, repoID, since, until)
+	})
+}
+
+func (r *RetryDB) ReplaceRepositoryDependencies(ctx context.Context, repoID int64, manifest string, deps []models.Dependency) error {
+	return r.do(ctx, OperationWrite, "ReplaceRepositoryDependencies", func() error {
+		return r.DB.ReplaceRepositoryDependencies(ctx, repoID, manifest, deps)
+	})
+}
+
+func (r *RetryDB) GetRepositoryDependencies(ctx context.Context, repoID int64, ecosystem string) ([]*models.Dependency, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryDependencies", func() ([]*models.Dependency, error) {
+		return r.DB.GetRepositoryDependencies(ctx, repoID, ecosystem)
+	})
+}
+
+func (r *RetryDB) GetPackageDependents(ctx context.Context, ecosystem, name, version string, account int64) ([]*models.Dependent, error) {
+	return retryValue(ctx, r, OperationRead, "GetPackageDependents", func() ([]*models.Dependent, error) {
+		return r.DB.GetPackageDependents(ctx, ecosystem, name, version, account)
+	})
+}
+
+func (r *RetryDB) CreateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error {
+	return r.do(ctx, OperationWrite, "CreateThresholdRule", func() error { return r.DB.CreateThresholdRule(ctx, rule) })
+}
+
+func (r *RetryDB) GetThresholdRule(ctx context.Context, repoID, id int64) (*models.ThresholdRule, error) {
+	return retryValue(ctx, r, OperationRead, "GetThresholdRule", func() (*models.ThresholdRule, error) {
+		return r.DB.GetThresholdRule(ctx, repoID, id)
+	})
+}
+
+func (r *RetryDB) ListThresholdRules(ctx context.Context, repoID int64) ([]*models.ThresholdRule, error) {
+	return retryValue(ctx, r, OperationRead, "ListThresholdRules", func() ([]*models.ThresholdRule, error) {
+		return r.DB.ListThresholdRules(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) UpdateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error {
+	return r.do(ctx, OperationWrite, "UpdateThresholdRule", func() error { return r.DB.UpdateThresholdRule(ctx, rule) })
+}
+
+func (r *RetryDB) UpdateThresholdRuleState(ctx context.Context, id int64, triggered bool, value int64, triggeredAt *time.Time) error {
+	return r.do(ctx, OperationWrite, "UpdateThresholdRuleState", func() error {
+		return r.DB.UpdateThresholdRuleState(ctx, id, triggered, value, triggeredAt)
+	})
+}
+
+func (r *RetryDB) DeleteThresholdRule(ctx context.Context, repoID, id int64) error {
+	return r.do(ctx, OperationWrite, "DeleteThresholdRule", func() error { return r.DB.DeleteThresholdRule(ctx, repoID, id) })
+}
+
+func (r *RetryDB) CreateThresholdAlert(ctx context.Context, alert *models.ThresholdAlert) error {
+	return r.do(ctx, OperationWrite, "CreateThresholdAlert", func() error { return r.DB.CreateThresholdAlert(ctx, alert) })
+}
+
+func (r *RetryDB) ListThresholdAlerts(ctx context.Context, repoID int64, ruleID *int64, page, perPage int) (alerts []*models.ThresholdAlert, total int, err error) {
+	err = r.do(ctx, OperationRead, "ListThresholdAlerts", func() error {
+		alerts, total, err = r.DB.ListThresholdAlerts(ctx, repoID, ruleID, page, perPage)
+		return err
+	})
+	return alerts, total, err
+}
+
+func (r *RetryDB) CreateCommitHook(ctx context.Context, hook *models.CommitHook) error {
+	return r.do(ctx, OperationWrite, "CreateCommitHook", func() error { return r.DB.CreateCommitHook(ctx, hook) })
+}
+
+func (r *RetryDB) GetCommitHook(ctx context.Context, repoID, id int64) (*models.CommitHook, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitHook", func() (*models.CommitHook, error) {
+		return r.DB.GetCommitHook(ctx, repoID, id)
+	})
+}
+
+func (r *RetryDB) ListCommitHooks(ctx context.Context, repoID int64) ([]*models.CommitHook, error) {
+	return retryValue(ctx, r, OperationRead, "ListCommitHooks", func() ([]*models.CommitHook, error) {
+		return r.DB.ListCommitHooks(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) UpdateCommitHook(ctx context.Context, hook *models.CommitHook) error {
+	return r.do(ctx, OperationWrite, "UpdateCommitHook", func() error { return r.DB.UpdateCommitHook(ctx, hook) })
+}
+
+func (r *RetryDB) DeleteCommitHook(ctx context.Context, repoID, id int64) error {
+	return r.do(ctx, OperationWrite, "DeleteCommitHook", func() error { return r.DB.DeleteCommitHook(ctx, repoID, id) })
+}
+
+func (r *RetryDB) CreateWebhook(ctx context.Context, hook *models.Webhook, secret string) error {
+	return r.do(ctx, OperationWrite, "CreateWebhook", func() error { return r.DB.CreateWebhook(ctx, hook, secret) })
+}
+
+func (r *RetryDB) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	return retryValue(ctx, r, OperationRead, "GetWebhook", func() (*models.Webhook, error) {
+		return r.DB.GetWebhook(ctx, id)
+	})
+}
+
+func (r *RetryDB) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return retryValue(ctx, r, OperationRead, "ListWebhooks", func() ([]*models.Webhook, error) {
+		return r.DB.ListWebhooks(ctx)
+	})
+}
+
+func (r *RetryDB) GetRepositoryWebhooks(ctx context.Context, fullName string) ([]*models.Webhook, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryWebhooks", func() ([]*models.Webhook, error) {
+		return r.DB.GetRepositoryWebhooks(ctx, fullName)
+	})
+}
+
+func (r *RetryDB) DeleteWebhook(ctx context.Context, id int64) error {
+	return r.do(ctx, OperationWrite, "DeleteWebhook", func() error { return r.DB.DeleteWebhook(ctx, id) })
+}
+
+func (r *RetryDB) CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	return r.do(ctx, OperationWrite, "CreateWebhookDeliveries", func() error { return r.DB.CreateWebhookDeliveries(ctx, deliveries) })
+}
+
+func (r *RetryDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	return retryValue(ctx, r, OperationWrite, "ClaimWebhookDeliveries", func() ([]*models.WebhookDelivery, error) {
+		return r.DB.ClaimWebhookDeliveries(ctx, now, lease, limit)
+	})
+}
+
+func (r *RetryDB) FinishWebhookDeliveryAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.do(ctx, OperationWrite, "FinishWebhookDeliveryAttempt", func() error { return r.DB.FinishWebhookDeliveryAttempt(ctx, delivery) })
+}
+
+func (r *RetryDB) ListWebhookDeliveries(ctx context.Context, webhookID int64, page, perPage int) (deliveries []*models.WebhookDelivery, total int, err error) {
+	err = r.do(ctx, OperationRead, "ListWebhookDeliveries", func() error {
+		deliveries, total, err = r.DB.ListWebhookDeliveries(ctx, webhookID, page, perPage)
+		return err
+	})
+	return deliveries, total, err
+}
+
+func (r *RetryDB) CountCommitsAndAuthors(ctx context.Context, repoID int64, start, end time.Time) (commits, authors int, err error) {
+	err = r.do(ctx, OperationRead, "CountCommitsAndAuthors", func() error {
+		var err error
+		commits, authors, err = r.DB.CountCommitsAndAuthors(ctx, repoID, start, end)
+		return err
+	})
+	return commits, authors, err
+}
+
+func (r *RetryDB) GetNewCommitAuthors(ctx context.Context, repoID int64, start, end time.Time) ([]*models.CommitStats, error) {
+	return retryValue(ctx, r, OperationRead, "GetNewCommitAuthors", func() ([]*models.CommitStats, error) {
+		return r.DB.GetNewCommitAuthors(ctx, repoID, start, end)
+	})
+}
+
+func (r *RetryDB) GetBusiestDays(ctx context.Context, repoID int64, start, end time.Time, limit int) ([]*models.DailyCommitCount, error) {
+	return retryValue(ctx, r, OperationRead, "GetBusiestDays", func() ([]*models.DailyCommitCount, error) {
+		return r.DB.GetBusiestDays(ctx, repoID, start, end, limit)
+	})
+}
+
+func (r *RetryDB) SaveMonthlyReport(ctx context.Context, repoID int64, report *models.MonthlyReport) error {
+	return r.do(ctx, OperationWrite, "SaveMonthlyReport", func() error { return r.DB.SaveMonthlyReport(ctx, repoID, report) })
+}
+
+func (r *RetryDB) SaveDigest(ctx context.Context, repoID int64, digest *models.Digest) error {
+	return r.do(ctx, OperationWrite, "SaveDigest", func() error { return r.DB.SaveDigest(ctx, repoID, digest) })
+}
+
+func (r *RetryDB) DigestExists(ctx context.Context, repoID int64, period string, start time.Time) (bool, error) {
+	return retryValue(ctx, r, OperationRead, "DigestExists", func() (bool, error) {
+		return r.DB.DigestExists(ctx, repoID, period, start)
+	})
+}
+
+func (r *RetryDB) GetDigests(ctx context.Context, repoID int64, period string, page, perPage int) (digests []*models.Digest, total int, err error) {
+	err = r.do(ctx, OperationRead, "GetDigests", func() error {
+		digests, total, err = r.DB.GetDigests(ctx, repoID, period, page, perPage)
+		return err
+	})
+	return digests, total, err
+}
+
+func (r *RetryDB) GetMonthlyReport(ctx context.Context, repoID int64, month string) (*models.MonthlyReport, error) {
+	return retryValue(ctx, r, OperationRead, "GetMonthlyReport", func() (*models.MonthlyReport, error) {
+		return r.DB.GetMonthlyReport(ctx, repoID, month)
+	})
+}
+
+func (r *RetryDB) CreateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	return r.do(ctx, OperationWrite, "CreateRepositoryGroup", func() error { return r.DB.CreateRepositoryGroup(ctx, group) })
+}
+
+func (r *RetryDB) GetRepositoryGroup(ctx context.Context, name string) (*models.RepositoryGroup, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryGroup", func() (*models.RepositoryGroup, error) {
+		return r.DB.GetRepositoryGroup(ctx, name)
+	})
+}
+
+func (r *RetryDB) ListRepositoryGroups(ctx context.Context) ([]*models.RepositoryGroup, error) {
+	return retryValue(ctx, r, OperationRead, "ListRepositoryGroups", func() ([]*models.RepositoryGroup, error) {
+		return r.DB.ListRepositoryGroups(ctx)
+	})
+}
+
+func (r *RetryDB) UpdateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	return r.do(ctx, OperationWrite, "UpdateRepositoryGroup", func() error { return r.DB.UpdateRepositoryGroup(ctx, group) })
+}
+
+func (r *RetryDB) DeleteRepositoryGroup(ctx context.Context, name string) error {
+	return r.do(ctx, OperationWrite, "DeleteRepositoryGroup", func() error { return r.DB.DeleteRepositoryGroup(ctx, name) })
+}
+
+func (r *RetryDB) AddRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	return r.do(ctx, OperationWrite, "AddRepositoryGroupMember", func() error { return r.DB.AddRepositoryGroupMember(ctx, name, fullName) })
+}
+
+func (r *RetryDB) RemoveRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	return r.do(ctx, OperationWrite, "RemoveRepositoryGroupMember", func() error { return r.DB.RemoveRepositoryGroupMember(ctx, name, fullName) })
+}
+
+func (r *RetryDB) AddCommitTickets(ctx context.Context, repoID, commitID int64, keys []string) ([]string, error) {
+	return retryValue(ctx, r, OperationWrite, "AddCommitTickets", func() ([]string, error) {
+		return r.DB.AddCommitTickets(ctx, repoID, commitID, keys)
+	})
+}
+
+func (r *RetryDB) GetTicketsToRefresh(ctx context.Context, refreshedBefore time.Time, limit int) ([]string, error) {
+	return retryValue(ctx, r, OperationRead, "GetTicketsToRefresh", func() ([]string, error) {
+		return r.DB.GetTicketsToRefresh(ctx, refreshedBefore, limit)
+	})
+}
+
+func (r *RetryDB) SaveTicket(ctx context.Context, ticket *models.Ticket) error {
+	return r.do(ctx, OperationWrite, "SaveTicket", func() error { return r.DB.SaveTicket(ctx, ticket) })
+}
+
+func (r *RetryDB) CountCommitsByTicketStatus(ctx context.Context, repoID int64, start, end time.Time) (*models.TicketCommitCounts, error) {
+	return retryValue(ctx, r, OperationRead, "CountCommitsByTicketStatus", func() (*models.TicketCommitCounts, error) {
+		return r.DB.CountCommitsByTicketStatus(ctx, repoID, start, end)
+	})
+}
+
+func (r *RetryDB) CountCommitsSince(ctx context.Context, repoID int64, since time.Time) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountCommitsSince", func() (int, error) {
+		return r.DB.CountCommitsSince(ctx, repoID, since)
+	})
+}
+
+func (r *RetryDB) CreateMaintenanceRun(ctx context.Context, run *models.MaintenanceRun) error {
+	return r.do(ctx, OperationWrite, "CreateMaintenanceRun", func() error { return r.DB.CreateMaintenanceRun(ctx, run) })
+}
+
+func (r *RetryDB) ListMaintenanceRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	return retryValue(ctx, r, OperationRead, "ListMaintenanceRuns", func() ([]*models.MaintenanceRun, error) {
+		return r.DB.ListMaintenanceRuns(ctx, limit)
+	})
+}
+
+func (r *RetryDB) AddMonitoredRepository(ctx context.Context, fullName, provider string, syncInterval time.Duration) error {
+	return r.do(ctx, OperationWrite, "AddMonitoredRepository", func() error {
+		return r.DB.AddMonitoredRepository(ctx, fullName, provider, syncInterval)
+	})
+}
+
+func (r *RetryDB) GetRepositoryProvider(ctx context.Context, fullName string) (string, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryProvider", func() (string, error) {
+		return r.DB.GetRepositoryProvider(ctx, fullName)
+	})
+}
+
+func (r *RetryDB) GetMonitoredRepositories(ctx context.Context) ([]models.MonitoredRepository, error) {
+	return retryValue(ctx, r, OperationRead, "GetMonitoredRepositories", func() ([]models.MonitoredRepository, error) {
+		return r.DB.GetMonitoredRepositories(ctx)
+	})
+}
+
+func (r *RetryDB) GetRepositoryListings(ctx context.Context, page, perPage int) ([]*models.RepositoryListing, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryListings", func() ([]*models.RepositoryListing, error) {
+		return r.DB.GetRepositoryListings(ctx, page, perPage)
+	})
+}
+
+func (r *RetryDB) GetRepositoryListing(ctx context.Context, fullName string) (*models.RepositoryListing, error) {
+	return retryValue(ctx, r, OperationRead, "GetRepositoryListing", func() (*models.RepositoryListing, error) {
+		return r.DB.GetRepositoryListing(ctx, fullName)
+	})
+}
+
+func (r *RetryDB) CountMonitoredRepositories(ctx context.Context) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountMonitoredRepositories", func() (int, error) {
+		return r.DB.CountMonitoredRepositories(ctx)
+	})
+}
+
+func (r *RetryDB) UpdateMonitoredRepositorySync(ctx context.Context, fullName string, lastSyncTime time.Time) error {
+	return r.do(ctx, OperationWrite, "UpdateMonitoredRepositorySync", func() error {
+		return r.DB.UpdateMonitoredRepositorySync(ctx, fullName, lastSyncTime)
+	})
+}
+
+func (r *RetryDB) RecordSyncOutcome(ctx context.Context, fullName string, failed bool) (previous int, monitored bool, err error) {
+	err = r.do(ctx, OperationWrite, "RecordSyncOutcome", func() error {
+		previous, monitored, err = r.DB.RecordSyncOutcome(ctx, fullName, failed)
+		return err
+	})
+	return previous, monitored, err
+}
+
+func (r *RetryDB) RemoveMonitoredRepository(ctx context.Context, fullName string) error {
+	return r.do(ctx, OperationWrite, "RemoveMonitoredRepository", func() error { return r.DB.RemoveMonitoredRepository(ctx, fullName) })
+}
+
+func (r *RetryDB) SetMonitoredRepositoryActive(ctx context.Context, fullName string, active bool) error {
+	return r.do(ctx, OperationWrite, "SetMonitoredRepositoryActive", func() error {
+		return r.DB.SetMonitoredRepositoryActive(ctx, fullName, active)
+	})
+}
+
+func (r *RetryDB) TryLockRepositorySync(ctx context.Context, fullName string) (func(), bool, error) {
+	var unlock func()
+	var locked bool
+	err := r.do(ctx, OperationWrite, "TryLockRepositorySync", func() error {
+		var err error
+		unlock, locked, err = r.DB.TryLockRepositorySync(ctx, fullName)
+		return err
+	})
+	return unlock, locked, err
+}
+
+func (r *RetryDB) ClaimResync(ctx context.Context, fullName string, minInterval time.Duration) (time.Duration, error) {
+	return retryValue(ctx, r, OperationWrite, "ClaimResync", func() (time.Duration, error) {
+		return r.DB.ClaimResync(ctx, fullName, minInterval)
+	})
+}
+
+func (r *RetryDB) SaveWatchedUser(ctx context.Context, user *models.WatchedUser) error {
+	return r.do(ctx, OperationWrite, "SaveWatchedUser", func() error { return r.DB.SaveWatchedUser(ctx, user) })
+}
+
+func (r *RetryDB) GetWatchedUsers(ctx context.Context) ([]*models.WatchedUser, error) {
+	return retryValue(ctx, r, OperationRead, "GetWatchedUsers", func() ([]*models.WatchedUser, error) {
+		return r.DB.GetWatchedUsers(ctx)
+	})
+}
+
+func (r *RetryDB) DeleteWatchedUser(ctx context.Context, username string) (bool, error) {
+	return retryValue(ctx, r, OperationWrite, "DeleteWatchedUser", func() (bool, error) {
+		return r.DB.DeleteWatchedUser(ctx, username)
+	})
+}
+
+func (r *RetryDB) GetUndiscoveredRepositories(ctx context.Context, username string, names []string) ([]string, error) {
+	return retryValue(ctx, r, OperationRead, "GetUndiscoveredRepositories", func() ([]string, error) {
+		return r.DB.GetUndiscoveredRepositories(ctx, username, names)
+	})
+}
+
+func (r *RetryDB) AddDiscoveredRepositories(ctx context.Context, username string, names []string, refreshedAt time.Time) error {
+	return r.do(ctx, OperationWrite, "AddDiscoveredRepositories", func() error {
+		return r.DB.AddDiscoveredRepositories(ctx, username, names, refreshedAt)
+	})
+}
+
+func (r *RetryDB) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	return r.do(ctx, OperationWrite, "CreateAPIKey", func() error { return r.DB.CreateAPIKey(ctx, key, keyHash) })
+}
+
+func (r *RetryDB) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	return retryValue(ctx, r, OperationRead, "GetAPIKeyByHash", func() (*models.APIKey, error) {
+		return r.DB.GetAPIKeyByHash(ctx, keyHash)
+	})
+}
+
+func (r *RetryDB) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return retryValue(ctx, r, OperationRead, "ListAPIKeys", func() ([]*models.APIKey, error) {
+		return r.DB.ListAPIKeys(ctx)
+	})
+}
+
+func (r *RetryDB) UpdateAPIKeyRole(ctx context.Context, id int64, role models.Role) error {
+	return r.do(ctx, OperationWrite, "UpdateAPIKeyRole", func() error { return r.DB.UpdateAPIKeyRole(ctx, id, role) })
+}
+
+func (r *RetryDB) RevokeAPIKey(ctx context.Context, id int64) error {
+	return r.do(ctx, OperationWrite, "RevokeAPIKey", func() error { return r.DB.RevokeAPIKey(ctx, id) })
+}
+
+func (r *RetryDB) TouchAPIKey(ctx context.Context, id int64) error {
+	return r.do(ctx, OperationWrite, "TouchAPIKey", func() error { return r.DB.TouchAPIKey(ctx, id) })
+}
+
+func (r *RetryDB) UpsertAccount(ctx context.Context, user *models.GitHubUser, role models.Role) (*models.Account, error) {
+	return retryValue(ctx, r, OperationWrite, "UpsertAccount", func() (*models.Account, error) {
+		return r.DB.UpsertAccount(ctx, user, role)
+	})
+}
+
+func (r *RetryDB) GetAccount(ctx context.Context, id int64) (*models.Account, error) {
+	return retryValue(ctx, r, OperationRead, "GetAccount", func() (*models.Account, error) {
+		return r.DB.GetAccount(ctx, id)
+	})
+}
+
+func (r *RetryDB) ListAccounts(ctx context.Context) ([]*models.Account, error) {
+	return retryValue(ctx, r, OperationRead, "ListAccounts", func() ([]*models.Account, error) {
+		return r.DB.ListAccounts(ctx)
+	})
+}
+
+func (r *RetryDB) UpdateAccountRole(ctx context.Context, id int64, role models.Role) error {
+	return r.do(ctx, OperationWrite, "UpdateAccountRole", func() error { return r.DB.UpdateAccountRole(ctx, id, role) })
+}
+
+func (r *RetryDB) DeleteAccount(ctx context.Context, id int64) error {
+	return r.do(ctx, OperationWrite, "DeleteAccount", func() error { return r.DB.DeleteAccount(ctx, id) })
+}
+
+func (r *RetryDB) AddAccountRepository(ctx context.Context, accountID int64, fullName string) error {
+	return r.do(ctx, OperationWrite, "AddAccountRepository", func() error {
+		return r.DB.AddAccountRepository(ctx, accountID, fullName)
+	})
+}
+
+func (r *RetryDB) RemoveAccountRepository(ctx context.Context, accountID int64, fullName string) (remaining int, err error) {
+	err = r.do(ctx, OperationWrite, "RemoveAccountRepository", func() error {
+		remaining, err = r.DB.RemoveAccountRepository(ctx, accountID, fullName)
+		return err
+	})
+	return remaining, err
+}
+
+func (r *RetryDB) HasAccountRepository(ctx context.Context, accountID int64, fullName string) (bool, error) {
+	return retryValue(ctx, r, OperationRead, "HasAccountRepository", func() (bool, error) {
+		return r.DB.HasAccountRepository(ctx, accountID, fullName)
+	})
+}
+
+func (r *RetryDB) GetAccountRepositoryListings(ctx context.Context, accountID int64, page, perPage int) ([]*models.RepositoryListing, error) {
+	return retryValue(ctx, r, OperationRead, "GetAccountRepositoryListings", func() ([]*models.RepositoryListing, error) {
+		return r.DB.GetAccountRepositoryListings(ctx, accountID, page, perPage)
+	})
+}
+
+func (r *RetryDB) CountAccountRepositories(ctx context.Context, accountID int64) (int, error) {
+	return retryValue(ctx, r, OperationRead, "CountAccountRepositories", func() (int, error) {
+		return r.DB.CountAccountRepositories(ctx, accountID)
+	})
+}
+
+func (r *RetryDB) AddAPIUsage(ctx context.Context, usage []*models.APIUsage) error {
+	return r.do(ctx, OperationWrite, "AddAPIUsage", func() error { return r.DB.AddAPIUsage(ctx, usage) })
+}
+
+func (r *RetryDB) GetEndpointUsage(ctx context.Context, since, until time.Time) ([]*models.EndpointUsage, error) {
+	return retryValue(ctx, r, OperationRead, "GetEndpointUsage", func() ([]*models.EndpointUsage, error) {
+		return r.DB.GetEndpointUsage(ctx, since, until)
+	})
+}
+
+func (r *RetryDB) GetAPIKeyUsage(ctx context.Context, since, until time.Time) ([]*models.APIKeyUsage, error) {
+	return retryValue(ctx, r, OperationRead, "GetAPIKeyUsage", func() ([]*models.APIKeyUsage, error) {
+		return r.DB.GetAPIKeyUsage(ctx, since, until)
+	})
+}
+
+func (r *RetryDB) CreateSyncRun(ctx context.Context, run *models.SyncRun) error {
+	return r.do(ctx, OperationWrite, "CreateSyncRun", func() error { return r.DB.CreateSyncRun(ctx, run) })
+}
+
+func (r *RetryDB) FinishSyncRun(ctx context.Context, id int64, newCommits int, syncErr string) error {
+	return r.do(ctx, OperationWrite, "FinishSyncRun", func() error { return r.DB.FinishSyncRun(ctx, id, newCommits, syncErr) })
+}
+
+func (r *RetryDB) GetFinishedSyncRuns(ctx context.Context, repoID, afterRun int64, limit int) ([]*models.SyncRun, error) {
+	return retryValue(ctx, r, OperationRead, "GetFinishedSyncRuns", func() ([]*models.SyncRun, error) {
+		return r.DB.GetFinishedSyncRuns(ctx, repoID, afterRun, limit)
+	})
+}
+
+func (r *RetryDB) GetBackfillCursor(ctx context.Context, repoID int64) (*models.BackfillCursor, error) {
+	return retryValue(ctx, r, OperationRead, "GetBackfillCursor", func() (*models.BackfillCursor, error) {
+		return r.DB.GetBackfillCursor(ctx, repoID)
+	})
+}
+
+func (r *RetryDB) SaveBackfillCursor(ctx context.Context, cursor *models.BackfillCursor) error {
+	return r.do(ctx, OperationWrite, "SaveBackfillCursor", func() error { return r.DB.SaveBackfillCursor(ctx, cursor) })
+}
+
+func (r *RetryDB) DeleteBackfillCursor(ctx context.Context, repoID int64, since *time.Time) error {
+	return r.do(ctx, OperationWrite, "DeleteBackfillCursor", func() error { return r.DB.DeleteBackfillCursor(ctx, repoID, since) })
+}
+
+func (r *RetryDB) GetCommitsInSyncRuns(ctx context.Context, repoID, afterRun, throughRun int64) ([]*models.Commit, error) {
+	return retryValue(ctx, r, OperationRead, "GetCommitsInSyncRuns", func() ([]*models.Commit, error) {
+		return r.DB.GetCommitsInSyncRuns(ctx, repoID, afterRun, throughRun)
+	})
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantRead  bool
+		wantWrite bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, wantRead: true, wantWrite: true},
+		{name: "deadlock", err: fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"}), wantRead: true, wantWrite: true},
+		{name: "bad connection", err: driver.ErrBadConn, wantRead: true, wantWrite: true},
+		{name: "connection failure", err: &pq.Error{Code: "08006"}, wantRead: true, wantWrite: false},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), wantRead: true, wantWrite: false},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, wantRead: false, wantWrite: false},
+		{name: "context canceled", err: context.Canceled, wantRead: false, wantWrite: false},
+		{name: "other", err: errors.New("boom"), wantRead: false, wantWrite: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(OperationRead, tt.err); got != tt.wantRead {
+				t.Errorf("retryable(read) = %v, want %v", got, tt.wantRead)
+			}
+			if got := retryable(OperationWrite, tt.err); got != tt.wantWrite {
+				t.Errorf("retryable(write) = %v, want %v", got, tt.wantWrite)
+			}
+		})
+	}
+}
+
+func TestRetryDBDo(t *testing.T) {
+	r := NewRetryDB(nil, map[OperationClass]RetryPolicy{
+		OperationRead: {MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}, zerolog.Nop())
+
+	t.Run("retries transient errors until success", func(t *testing.T) {
+		calls := 0
+		err := r.do(context.Background(), OperationRead, "test", func() error {
+			calls++
+			if calls < 3 {
+				return &pq.Error{Code: "40001"}
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Errorf("got err=%v after %d calls, want success after 3", err, calls)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := r.do(context.Background(), OperationRead, "test", func() error {
+			calls++
+			return driver.ErrBadConn
+		})
+		if !errors.Is(err, driver.ErrBadConn) || calls != 3 {
+			t.Errorf("got err=%v after %d calls, want ErrBadConn after 3", err, calls)
+		}
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		_ = r.do(context.Background(), OperationRead, "test", func() error {
+			calls++
+			return &pq.Error{Code: "23505"}
+		})
+		if calls != 1 {
+			t.Errorf("got %d calls, want 1", calls)
+		}
+	})
+}
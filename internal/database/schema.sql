@@ -3,7 +3,8 @@
 -- Repositories table to store repository metadata
 CREATE TABLE IF NOT EXISTS repositories (
     id SERIAL PRIMARY KEY,
-    github_id BIGINT UNIQUE NOT NULL,
+    github_id BIGINT NOT NULL, -- ID at the provider, unique per provider
+    provider TEXT NOT NULL DEFAULT 'github',
     name TEXT NOT NULL,
     full_name TEXT NOT NULL UNIQUE,
     description TEXT,
@@ -17,13 +18,15 @@ CREATE TABLE IF NOT EXISTS repositories (
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
     last_commit_check TIMESTAMP WITH TIME ZONE,
     commits_since TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE, -- Set while a removed repository's data is retained
     created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
--- Commits table to store commit information
+-- Commits table to store commit information, partitioned by repository so the
+-- queries of one repository only touch its partition
 CREATE TABLE IF NOT EXISTS commits (
-    id SERIAL PRIMARY KEY,
+    id SERIAL,
     repository_id INTEGER NOT NULL,
     sha TEXT NOT NULL,
     message TEXT NOT NULL,
@@ -34,12 +37,396 @@ CREATE TABLE IF NOT EXISTS commits (
     committer_email TEXT NOT NULL,
     commit_date TIMESTAMP WITH TIME ZONE NOT NULL,
     url TEXT NOT NULL,
+    sync_run_id INTEGER,
+    additions INTEGER,
+    deletions INTEGER,
+    files_changed INTEGER,
+    commit_type TEXT, -- Conventional Commits type of the message, 'other' when it has none
     created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (repository_id, id),
     FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE,
     UNIQUE(repository_id, sha)
+) PARTITION BY HASH (repository_id);
+
+CREATE TABLE IF NOT EXISTS commits_p0 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 0);
+CREATE TABLE IF NOT EXISTS commits_p1 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 1);
+CREATE TABLE IF NOT EXISTS commits_p2 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 2);
+CREATE TABLE IF NOT EXISTS commits_p3 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 3);
+CREATE TABLE IF NOT EXISTS commits_p4 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 4);
+CREATE TABLE IF NOT EXISTS commits_p5 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 5);
+CREATE TABLE IF NOT EXISTS commits_p6 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 6);
+CREATE TABLE IF NOT EXISTS commits_p7 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 7);
+CREATE TABLE IF NOT EXISTS commits_p8 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 8);
+CREATE TABLE IF NOT EXISTS commits_p9 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 9);
+CREATE TABLE IF NOT EXISTS commits_p10 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 10);
+CREATE TABLE IF NOT EXISTS commits_p11 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 11);
+CREATE TABLE IF NOT EXISTS commits_p12 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 12);
+CREATE TABLE IF NOT EXISTS commits_p13 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 13);
+CREATE TABLE IF NOT EXISTS commits_p14 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 14);
+CREATE TABLE IF NOT EXISTS commits_p15 PARTITION OF commits FOR VALUES WITH (MODULUS 16, REMAINDER 15);
+
+-- Commit files table to store the files changed by each commit
+CREATE TABLE IF NOT EXISTS commit_files (
+    id SERIAL PRIMARY KEY,
+    commit_id INTEGER NOT NULL,
+    filename TEXT NOT NULL,
+    extension TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    additions INTEGER NOT NULL DEFAULT 0,
+    deletions INTEGER NOT NULL DEFAULT 0,
+    changes INTEGER NOT NULL DEFAULT 0,
+    repository_id INTEGER NOT NULL,
+    UNIQUE(commit_id, filename),
+    FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE
+);
+
+-- Issues table to store GitHub issues (pull requests excluded)
+CREATE TABLE IF NOT EXISTS issues (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    github_id BIGINT NOT NULL,
+    number INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    state TEXT NOT NULL,
+    author_login TEXT NOT NULL DEFAULT '',
+    comments INTEGER NOT NULL DEFAULT 0,
+    url TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, number)
+);
+
+-- Repository stats history table with one snapshot per repository and day
+CREATE TABLE IF NOT EXISTS repository_stats_history (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    snapshot_date DATE NOT NULL,
+    stars_count INTEGER NOT NULL DEFAULT 0,
+    forks_count INTEGER NOT NULL DEFAULT 0,
+    watchers_count INTEGER NOT NULL DEFAULT 0,
+    open_issues_count INTEGER NOT NULL DEFAULT 0,
+    recorded_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, snapshot_date)
+);
+
+-- Threshold rules table with the last evaluated state of each rule
+CREATE TABLE IF NOT EXISTS threshold_rules (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold BIGINT NOT NULL,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    window_days INTEGER NOT NULL DEFAULT 0,
+    triggered BOOLEAN NOT NULL DEFAULT false,
+    last_value BIGINT,
+    last_triggered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Threshold alerts table recording when rules started or stopped holding
+CREATE TABLE IF NOT EXISTS threshold_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    rule_id INTEGER REFERENCES threshold_rules(id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold BIGINT NOT NULL,
+    window_days INTEGER NOT NULL DEFAULT 0,
+    value BIGINT NOT NULL,
+    previous_value BIGINT NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Repository dependencies table holding the packages declared in manifests
+CREATE TABLE IF NOT EXISTS repository_dependencies (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    manifest TEXT NOT NULL,
+    ecosystem TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL DEFAULT '',
+    scope TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (repository_id, manifest, name)
+);
+
+-- Maintenance runs table to record index maintenance history
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+    id SERIAL PRIMARY KEY,
+    task TEXT NOT NULL,
+    target TEXT NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+-- Author identities table mapping commit author emails to the person they belong to
+CREATE TABLE IF NOT EXISTS author_identities (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    canonical_name TEXT NOT NULL,
+    canonical_email TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- API usage table aggregating the service's own API requests per hour, endpoint and API key
+CREATE TABLE IF NOT EXISTS api_usage (
+    id SERIAL PRIMARY KEY,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    api_key_id BIGINT NOT NULL DEFAULT 0,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    error_count BIGINT NOT NULL DEFAULT 0,
+    total_duration_us BIGINT NOT NULL DEFAULT 0,
+    max_duration_us BIGINT NOT NULL DEFAULT 0,
+    UNIQUE (bucket, method, route, api_key_id)
+);
+
+-- Sync runs table recording each sync of a repository; commits reference the run that ingested them
+CREATE TABLE IF NOT EXISTS sync_runs (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL,
+    incremental BOOLEAN NOT NULL DEFAULT false,
+    since TIMESTAMP WITH TIME ZONE,
+    status TEXT NOT NULL,
+    new_commits INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    FOREIGN KEY (repository_id) REFERENCES repositories(id) ON DELETE CASCADE
+);
+
+-- Tags table to store the tags of a repository and the commits they point at
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    commit_sha TEXT NOT NULL,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, name)
+);
+
+-- Releases table to store GitHub releases
+CREATE TABLE IF NOT EXISTS releases (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    github_id BIGINT NOT NULL,
+    tag_name TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    draft BOOLEAN NOT NULL DEFAULT false,
+    prerelease BOOLEAN NOT NULL DEFAULT false,
+    author_login TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at_local TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repository_id, github_id)
+);
+
+-- Commit hooks table to deliver newly ingested commits to webhooks
+CREATE TABLE IF NOT EXISTS commit_hooks (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    webhook_url TEXT NOT NULL,
+    authors TEXT[] NOT NULL DEFAULT '{}',
+    paths TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Monthly reports table to store the generated activity report of each repository and month
+CREATE TABLE IF NOT EXISTS monthly_reports (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    month TEXT NOT NULL,
+    report JSONB NOT NULL,
+    markdown TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(repository_id, month)
+);
+
+-- Digests table to store the weekly and monthly digests of repositories
+CREATE TABLE IF NOT EXISTS digests (
+    id SERIAL PRIMARY KEY,
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    period TEXT NOT NULL,
+    period_start DATE NOT NULL,
+    report JSONB NOT NULL,
+    markdown TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE(repository_id, period, period_start)
+);
+
+-- Repository groups table to store named groups of monitored repositories
+CREATE TABLE IF NOT EXISTS repository_groups (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Repository group members table to store the monitored repositories of each group
+CREATE TABLE IF NOT EXISTS repository_group_members (
+    group_id INTEGER NOT NULL REFERENCES repository_groups(id) ON DELETE CASCADE,
+    repository TEXT NOT NULL,
+    PRIMARY KEY (group_id, repository)
+);
+
+-- Tickets table to cache the status of tickets referenced by commit messages
+CREATE TABLE IF NOT EXISTS tickets (
+    key TEXT PRIMARY KEY,
+    summary TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT '',
+    status_category TEXT NOT NULL DEFAULT '',
+    found BOOLEAN NOT NULL DEFAULT FALSE,
+    refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Commit tickets table to store the tickets each commit message references
+CREATE TABLE IF NOT EXISTS commit_tickets (
+    commit_id INTEGER NOT NULL,
+    ticket_key TEXT NOT NULL,
+    repository_id INTEGER NOT NULL,
+    PRIMARY KEY (commit_id, ticket_key),
+    FOREIGN KEY (repository_id, commit_id) REFERENCES commits(repository_id, id) ON DELETE CASCADE
+);
+
+-- Weekly activity of each contributor as computed by GitHub's stats API; weeks
+-- without any activity are not stored
+CREATE TABLE IF NOT EXISTS contributor_weeks (
+    repository_id INTEGER NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    login TEXT NOT NULL,
+    week DATE NOT NULL,
+    commits INTEGER NOT NULL DEFAULT 0,
+    additions INTEGER NOT NULL DEFAULT 0,
+    deletions INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (repository_id, login, week)
+);
+
+-- GitHub users whose public repositories matching the filters are added to
+-- monitoring, and rediscovered periodically
+CREATE TABLE IF NOT EXISTS watched_users (
+    username TEXT PRIMARY KEY,
+    min_stars INTEGER NOT NULL DEFAULT 0,
+    language TEXT NOT NULL DEFAULT '',
+    updated_within_seconds BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    refreshed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Repositories discovery added to monitoring for a watched user; they are not
+-- added again after being removed
+CREATE TABLE IF NOT EXISTS discovered_repositories (
+    username TEXT NOT NULL REFERENCES watched_users(username) ON DELETE CASCADE,
+    full_name TEXT NOT NULL,
+    discovered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (username, full_name)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    repositories TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    repository TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Accounts table to store the people who logged in with GitHub
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    github_id BIGINT NOT NULL UNIQUE,
+    login TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'reader',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP WITH TIME ZONE
+);
+
+-- API keys table to store hashed API keys and their roles
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    key_prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    role TEXT NOT NULL DEFAULT 'reader',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Account repositories table to store the repositories each account added
+CREATE TABLE IF NOT EXISTS account_repositories (
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    repository TEXT NOT NULL,
+    added_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, repository)
+);
+
+-- Repository backfills table to store how far each commit history backfill got
+CREATE TABLE IF NOT EXISTS repository_backfills (
+    repository_id INTEGER PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+    since TIMESTAMP WITH TIME ZONE, -- Lower bound of the backfill; NULL for the full history
+    page INTEGER NOT NULL,
+    oldest_sha TEXT NOT NULL,
+    oldest_date TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Create indexes for better query performance
 CREATE INDEX IF NOT EXISTS idx_commits_repo_date ON commits(repository_id, commit_date DESC);
 CREATE INDEX IF NOT EXISTS idx_commits_author ON commits(author_name, author_email);
-CREATE INDEX IF NOT EXISTS idx_repositories_name ON repositories(name, full_name); 
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_commits_message_search ON commits USING GIN (to_tsvector('english', message));
+CREATE INDEX IF NOT EXISTS idx_commits_repository_sha ON commits(repository_id, sha text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_commit_files_extension ON commit_files(extension);
+CREATE INDEX IF NOT EXISTS idx_commit_files_repository ON commit_files(repository_id, commit_id);
+CREATE INDEX IF NOT EXISTS idx_issues_repository_state ON issues(repository_id, state, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_threshold_rules_repository ON threshold_rules(repository_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_runs_started ON maintenance_runs(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_author_identities_canonical ON author_identities(canonical_email);
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_lower ON commits(LOWER(author_email));
+CREATE INDEX IF NOT EXISTS idx_api_usage_bucket ON api_usage(bucket);
+CREATE INDEX IF NOT EXISTS idx_sync_runs_repository ON sync_runs(repository_id, id);
+CREATE INDEX IF NOT EXISTS idx_commits_sync_run ON commits(repository_id, sync_run_id);
+CREATE INDEX IF NOT EXISTS idx_commits_missing_stats ON commits(repository_id, commit_date DESC) WHERE additions IS NULL;
+CREATE INDEX IF NOT EXISTS idx_releases_repository_published ON releases(repository_id, published_at DESC);
+CREATE INDEX IF NOT EXISTS idx_repositories_deleted ON repositories(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_commit_hooks_repository ON commit_hooks(repository_id);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_latest ON commits(repository_id, commit_date DESC, id DESC) INCLUDE (sha, additions, deletions, files_changed);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repositories_provider_id ON repositories(provider, github_id);
+CREATE INDEX IF NOT EXISTS idx_commit_tickets_key ON commit_tickets(ticket_key);
+CREATE INDEX IF NOT EXISTS idx_tickets_refreshed ON tickets(refreshed_at NULLS FIRST);
+CREATE INDEX IF NOT EXISTS idx_repositories_name ON repositories(name, full_name); 
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_name ON commits(repository_id, LOWER(author_name), commit_date DESC);
+CREATE INDEX IF NOT EXISTS idx_commits_repository_author_email ON commits(repository_id, LOWER(author_email), commit_date DESC);
+CREATE INDEX IF NOT EXISTS idx_webhooks_repositories ON webhooks USING GIN (repositories);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_commits_author_email_date ON commits(LOWER(author_email), commit_date DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_group_members_repository ON repository_group_members(repository);
+CREATE INDEX IF NOT EXISTS idx_threshold_alerts_repository ON threshold_alerts(repository_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_dependencies_package ON repository_dependencies(ecosystem, name);
+CREATE INDEX IF NOT EXISTS idx_account_repositories_repository ON account_repositories(repository);
\ No newline at end of file
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// CreateSyncRun records the start of a sync of a repository
+func (d *DB) CreateSyncRun(ctx context.Context, run *models.SyncRun) error {
+	run.Status = models.SyncRunRunning
+	query := `
+		INSERT INTO sync_runs (repository_id, incremental, since, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, started_at`
+	return d.db.QueryRowContext(ctx, query, run.RepositoryID, run.Incremental, run.Since, run.Status).
+		Scan(&run.ID, &run.StartedAt)
+}
+
+// FinishSyncRun records the outcome of a sync run; a non-empty syncErr marks it failed
+func (d *DB) FinishSyncRun(ctx context.Context, id int64, newCommits int, syncErr string) error {
+	status := models.SyncRunSucceeded
+	if syncErr != "" {
+		status = models.SyncRunFailed
+	}
+	query := `
+		UPDATE sync_runs
+		SET status = $2, new_commits = $3, error = NULLIF($4, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+	result, err := d.db.ExecContext(ctx, query, id, status, newCommits, syncErr)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("sync run", id)
+	}
+	return nil
+}
+
+// GetFinishedSyncRuns returns up to limit finished sync runs of a repository
+// after the given run, oldest first
+func (d *DB) GetFinishedSyncRuns(ctx context.Context, repoID, afterRun int64, limit int) ([]*models.SyncRun, error) {
+	query := `
+		SELECT id, repository_id, incremental, since, status, new_commits, error, started_at, finished_at
+		FROM sync_runs
+		WHERE repository_id = $1 AND id > $2 AND status <> $3
+		ORDER BY id
+		LIMIT $4`
+	rows, err := d.db.QueryContext(ctx, query, repoID, afterRun, models.SyncRunRunning, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*models.SyncRun{}
+	for rows.Next() {
+		run := &models.SyncRun{}
+		var since, finishedAt sql.NullTime
+		var syncErr sql.NullString
+		if err := rows.Scan(&run.ID, &run.RepositoryID, &run.Incremental, &since, &run.Status,
+			&run.NewCommits, &syncErr, &run.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if since.Valid {
+			run.Since = &since.Time
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		run.Error = syncErr.String
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetCommitsInSyncRuns returns the commits of a repository ingested by the sync
+// runs after afterRun up to and including throughRun, in ingestion order
+func (d *DB) GetCommitsInSyncRuns(ctx context.Context, repoID, afterRun, throughRun int64) ([]*models.Commit, error) {
+	query := `
+		SELECT ` + commitColumns + `, sync_run_id
+		FROM commits
+		WHERE repository_id = $1 AND sync_run_id > $2 AND sync_run_id <= $3
+		ORDER BY sync_run_id, id`
+	rows, err := d.db.QueryContext(ctx, query, repoID, afterRun, throughRun)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commits := []*models.Commit{}
+	for rows.Next() {
+		commit := &models.Commit{}
+		var runID int64
+		if err := rows.Scan(
+			&commit.ID, &commit.RepositoryID, &commit.SHA, &commit.Message,
+			&commit.AuthorName, &commit.AuthorEmail, &commit.AuthorDate,
+			&commit.CommitterName, &commit.CommitterEmail, &commit.CommitDate,
+			&commit.URL, &commit.Additions, &commit.Deletions, &commit.FilesChanged,
+			&commit.Type, &commit.CreatedAtLocal, &runID,
+		); err != nil {
+			return nil, err
+		}
+		commit.SyncRunID = &runID
+		commits = append(commits, commit)
+	}
+	return commits, rows.Err()
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// thresholdRuleColumns lists the threshold rule columns in the order expected by scanThresholdRule
+const thresholdRuleColumns = `id, repository_id, metric, operator, threshold, window_days, webhook_url,
+	triggered, last_value, last_triggered_at, created_at, updated_at`
+
+// scanThresholdRule scans a row selected with thresholdRuleColumns into a threshold rule
+func scanThresholdRule(row rowScanner) (*models.ThresholdRule, error) {
+	rule := &models.ThresholdRule{}
+	var lastValue sql.NullInt64
+	var lastTriggeredAt sql.NullTime
+	err := row.Scan(
+		&rule.ID, &rule.RepositoryID, &rule.Metric, &rule.Operator, &rule.Threshold, &rule.WindowDays, &rule.WebhookURL,
+		&rule.Triggered, &lastValue, &lastTriggeredAt, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastValue.Valid {
+		rule.LastValue = &lastValue.Int64
+	}
+	if lastTriggeredAt.Valid {
+		rule.LastTriggeredAt = &lastTriggeredAt.Time
+	}
+	return rule, nil
+}
+
+// CreateThresholdRule stores a new threshold rule
+func (d *DB) CreateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error {
+	query := `
+		INSERT INTO threshold_rules (repository_id, metric, operator, threshold, window_days, webhook_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	return d.db.QueryRowContext(ctx, query,
+		rule.RepositoryID, rule.Metric, rule.Operator, rule.Threshold, rule.WindowDays, rule.WebhookURL,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+// GetThresholdRule retrieves a rule of a repository, or nil if it doesn't exist
+func (d *DB) GetThresholdRule(ctx context.Context, repoID, id int64) (*models.ThresholdRule, error) {
+	query := `SELECT ` + thresholdRuleColumns + ` FROM threshold_rules WHERE repository_id = $1 AND id = $2`
+
+	rule, err := scanThresholdRule(d.db.QueryRowContext(ctx, query, repoID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// ListThresholdRules returns the rules of a repository in creation order
+func (d *DB) ListThresholdRules(ctx context.Context, repoID int64) ([]*models.ThresholdRule, error) {
+	query := `SELECT ` + thresholdRuleColumns + ` FROM threshold_rules WHERE repository_id = $1 ORDER BY id`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.ThresholdRule
+	for rows.Next() {
+		rule, err := scanThresholdRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// UpdateThresholdRule changes the definition of a rule. Its evaluated state is
+// reset, so the next evaluation establishes it again without firing.
+func (d *DB) UpdateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error {
+	query := `
+		UPDATE threshold_rules
+		SET metric = $1, operator = $2, threshold = $3, window_days = $4, webhook_url = $5,
+			triggered = false, last_value = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE repository_id = $6 AND id = $7
+		RETURNING ` + thresholdRuleColumns
+
+	updated, err := scanThresholdRule(d.db.QueryRowContext(ctx, query,
+		rule.Metric, rule.Operator, rule.Threshold, rule.WindowDays, rule.WebhookURL, rule.RepositoryID, rule.ID,
+	))
+	if err == sql.ErrNoRows {
+		return errors.NewNotFoundError("threshold rule", rule.ID)
+	}
+	if err != nil {
+		return err
+	}
+	*rule = *updated
+	return nil
+}
+
+// UpdateThresholdRuleState records the outcome of evaluating a rule
+func (d *DB) UpdateThresholdRuleState(ctx context.Context, id int64, triggered bool, value int64, triggeredAt *time.Time) error {
+	query := `
+		UPDATE threshold_rules
+		SET triggered = $1, last_value = $2, last_triggered_at = COALESCE($3, last_triggered_at)
+		WHERE id = $4`
+
+	_, err := d.db.ExecContext(ctx, query, triggered, value, triggeredAt, id)
+	return err
+}
+
+// DeleteThresholdRule removes a rule of a repository
+func (d *DB) DeleteThresholdRule(ctx context.Context, repoID, id int64) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM threshold_rules WHERE repository_id = $1 AND id = $2`, repoID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("threshold rule", id)
+	}
+	return nil
+}
+
+// CreateThresholdAlert records a threshold rule transition
+func (d *DB) CreateThresholdAlert(ctx context.Context, alert *models.ThresholdAlert) error {
+	query := `
+		INSERT INTO threshold_alerts (
+			repository_id, rule_id, event, metric, operator, threshold, window_days, value, previous_value, occurred_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	return d.db.QueryRowContext(ctx, query,
+		alert.RepositoryID, alert.RuleID, alert.Event, alert.Metric, alert.Operator, alert.Threshold,
+		alert.WindowDays, alert.Value, alert.PreviousValue, alert.OccurredAt,
+	).Scan(&alert.ID)
+}
+
+// ListThresholdAlerts returns a page of a repository's alerts, newest first,
+// optionally only those of one rule, along with their total number
+func (d *DB) ListThresholdAlerts(ctx context.Context, repoID int64, ruleID *int64, page, perPage int) ([]*models.ThresholdAlert, int, error) {
+	var total int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM threshold_alerts WHERE repository_id = $1 AND ($2::integer IS NULL OR rule_id = $2)`,
+		repoID, ruleID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, repository_id, rule_id, event, metric, operator, threshold, window_days, value, previous_value, occurred_at
+		FROM threshold_alerts
+		WHERE repository_id = $1 AND ($2::integer IS NULL OR rule_id = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, ruleID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	alerts := []*models.ThresholdAlert{}
+	for rows.Next() {
+		alert := &models.ThresholdAlert{}
+		var ruleID sql.NullInt64
+		if err := rows.Scan(
+			&alert.ID, &alert.RepositoryID, &ruleID, &alert.Event, &alert.Metric, &alert.Operator,
+			&alert.Threshold, &alert.WindowDays, &alert.Value, &alert.PreviousValue, &alert.OccurredAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		if ruleID.Valid {
+			alert.RuleID = &ruleID.Int64
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, total, rows.Err()
+}
+
+// CountCommitsSince counts a repository's commits made on or after since
+func (d *DB) CountCommitsSince(ctx context.Context, repoID int64, since time.Time) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM commits WHERE repository_id = $1 AND commit_date >= $2`,
+		repoID, since,
+	).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// AddCommitTickets records the tickets a repository's commit references and
+// returns those that were not referenced by any commit before, so they can be
+// looked up
+func (d *DB) AddCommitTickets(ctx context.Context, repoID, commitID int64, keys []string) ([]string, error) {
+	query := `
+		WITH referenced AS (
+			SELECT DISTINCT UNNEST($3::text[]) AS key
+		), added AS (
+			INSERT INTO tickets (key)
+			SELECT key FROM referenced
+			ON CONFLICT (key) DO NOTHING
+			RETURNING key
+		), linked AS (
+			INSERT INTO commit_tickets (repository_id, commit_id, ticket_key)
+			SELECT $1, $2, key FROM referenced
+			ON CONFLICT DO NOTHING
+		)
+		SELECT key FROM added ORDER BY key`
+
+	rows, err := d.db.QueryContext(ctx, query, repoID, commitID, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var added []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		added = append(added, key)
+	}
+	return added, rows.Err()
+}
+
+// GetTicketsToRefresh returns the keys of tickets never looked up or last
+// refreshed before a time, least recently refreshed first
+func (d *DB) GetTicketsToRefresh(ctx context.Context, refreshedBefore time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT key FROM tickets
+		WHERE refreshed_at IS NULL OR refreshed_at < $1
+		ORDER BY refreshed_at NULLS FIRST, key
+		LIMIT $2`
+
+	rows, err := d.db.QueryContext(ctx, query, refreshedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// SaveTicket stores the looked up summary and status of a ticket and marks it refreshed
+func (d *DB) SaveTicket(ctx context.Context, ticket *models.Ticket) error {
+	query := `
+		INSERT INTO tickets (key, summary, status, status_category, found, refreshed_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET summary = EXCLUDED.summary, status = EXCLUDED.status, status_category = EXCLUDED.status_category,
+			found = EXCLUDED.found, refreshed_at = EXCLUDED.refreshed_at
+		RETURNING refreshed_at`
+
+	return d.db.QueryRowContext(ctx, query,
+		ticket.Key, ticket.Summary, ticket.Status, ticket.StatusCategory, ticket.Found,
+	).Scan(&ticket.RefreshedAt)
+}
+
+// CountCommitsByTicketStatus counts a repository's commits made in [start, end)
+// that reference tickets, by the status of those tickets
+func (d *DB) CountCommitsByTicketStatus(ctx context.Context, repoID int64, start, end time.Time) (*models.TicketCommitCounts, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE open),
+			COUNT(*) FILTER (WHERE closed AND NOT open),
+			COUNT(*) FILTER (WHERE NOT open AND NOT closed)
+		FROM (
+			SELECT BOOL_OR(t.found AND t.status_category <> 'done') AS open,
+				BOOL_OR(t.found AND t.status_category = 'done') AS closed
+			FROM commits c
+			JOIN commit_tickets ct ON ct.repository_id = c.repository_id AND ct.commit_id = c.id
+			JOIN tickets t ON t.key = ct.ticket_key
+			WHERE c.repository_id = $1 AND c.commit_date >= $2 AND c.commit_date < $3
+			GROUP BY c.id
+		) referencing`
+
+	counts := &models.TicketCommitCounts{}
+	err := d.db.QueryRowContext(ctx, query, repoID, start, end).Scan(&counts.Open, &counts.Closed, &counts.Unresolved)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// SaveWatchedUser starts watching a user or replaces the filters of a watched
+// user, setting its creation and refresh times
+func (d *DB) SaveWatchedUser(ctx context.Context, user *models.WatchedUser) error {
+	query := `
+		INSERT INTO watched_users (username, min_stars, language, updated_within_seconds)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (username)
+		DO UPDATE SET min_stars = $2, language = $3, updated_within_seconds = $4
+		RETURNING created_at, refreshed_at`
+
+	var refreshedAt sql.NullTime
+	err := d.db.QueryRowContext(ctx, query, user.Username, user.MinStars, user.Language, int64(user.UpdatedWithin.Seconds())).
+		Scan(&user.CreatedAt, &refreshedAt)
+	if err != nil {
+		return err
+	}
+	user.RefreshedAt = nil
+	if refreshedAt.Valid {
+		user.RefreshedAt = &refreshedAt.Time
+	}
+	return nil
+}
+
+// GetWatchedUsers returns the watched users ordered by username
+func (d *DB) GetWatchedUsers(ctx context.Context) ([]*models.WatchedUser, error) {
+	query := `
+		SELECT username, min_stars, language, updated_within_seconds, created_at, refreshed_at
+		FROM watched_users
+		ORDER BY username`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.WatchedUser
+	for rows.Next() {
+		user := &models.WatchedUser{}
+		var updatedWithin int64
+		var refreshedAt sql.NullTime
+		if err := rows.Scan(&user.Username, &user.MinStars, &user.Language, &updatedWithin, &user.CreatedAt, &refreshedAt); err != nil {
+			return nil, err
+		}
+		user.UpdatedWithin = time.Duration(updatedWithin) * time.Second
+		if refreshedAt.Valid {
+			user.RefreshedAt = &refreshedAt.Time
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// DeleteWatchedUser stops watching a user and forgets the repositories
+// discovered for it. It reports whether the user was watched.
+func (d *DB) DeleteWatchedUser(ctx context.Context, username string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM watched_users WHERE username = $1`, username)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// GetUndiscoveredRepositories returns the names, in order, that were neither
+// discovered for the user before nor are monitored, whether active or paused
+func (d *DB) GetUndiscoveredRepositories(ctx context.Context, username string, names []string) ([]string, error) {
+	query := `
+		SELECT name FROM UNNEST($2::text[]) AS name
+		WHERE NOT EXISTS (SELECT 1 FROM monitored_repositories m WHERE m.full_name = name)
+			AND NOT EXISTS (SELECT 1 FROM discovered_repositories r WHERE r.username = $1 AND r.full_name = name)
+		ORDER BY name`
+
+	rows, err := d.db.QueryContext(ctx, query, username, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var undiscovered []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		undiscovered = append(undiscovered, name)
+	}
+	return undiscovered, rows.Err()
+}
+
+// AddDiscoveredRepositories records repositories added to monitoring for a
+// user, so they are not added again once removed, and the time of the discovery
+func (d *DB) AddDiscoveredRepositories(ctx context.Context, username string, names []string, refreshedAt time.Time) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO discovered_repositories (username, full_name)
+		SELECT $1, UNNEST($2::text[])
+		ON CONFLICT DO NOTHING`, username, pq.Array(names)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE watched_users SET refreshed_at = $2 WHERE username = $1`, username, refreshedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// webhookDeliveryColumns lists the delivery columns in the order expected by scanWebhookDelivery
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event, d.repository, d.status, d.attempts,
+	d.response_status, d.error, d.next_attempt_at, d.created_at, d.delivered_at`
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns, followed
+// by any extra destinations, into a delivery
+func scanWebhookDelivery(row rowScanner, extra ...interface{}) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var responseStatus sql.NullInt64
+	dest := []interface{}{
+		&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Repository, &delivery.Status, &delivery.Attempts,
+		&responseStatus, &delivery.Error, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.DeliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+	return delivery, nil
+}
+
+// scanWebhook scans the id, url, repositories and created_at columns into a webhook
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	hook := &models.Webhook{}
+	if err := row.Scan(&hook.ID, &hook.URL, pq.Array(&hook.Repositories), &hook.CreatedAt); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// CreateWebhook stores a new webhook with the secret its deliveries are signed with
+func (d *DB) CreateWebhook(ctx context.Context, hook *models.Webhook, secret string) error {
+	query := `
+		INSERT INTO webhooks (url, secret, repositories)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	return d.db.QueryRowContext(ctx, query, hook.URL, secret, pq.Array(hook.Repositories)).
+		Scan(&hook.ID, &hook.CreatedAt)
+}
+
+// GetWebhook retrieves a webhook, or nil if it doesn't exist
+func (d *DB) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	hook, err := scanWebhook(d.db.QueryRowContext(ctx,
+		`SELECT id, url, repositories, created_at FROM webhooks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return hook, err
+}
+
+// ListWebhooks returns every webhook in creation order
+func (d *DB) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return d.queryWebhooks(ctx, `SELECT id, url, repositories, created_at FROM webhooks ORDER BY id`)
+}
+
+// GetRepositoryWebhooks returns the webhooks subscribed to a repository
+func (d *DB) GetRepositoryWebhooks(ctx context.Context, fullName string) ([]*models.Webhook, error) {
+	return d.queryWebhooks(ctx, `
+		SELECT id, url, repositories, created_at FROM webhooks
+		WHERE repositories @> ARRAY[LOWER($1)]
+		ORDER BY id`, fullName)
+}
+
+// queryWebhooks runs a query selecting the columns scanWebhook expects
+func (d *DB) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]*models.Webhook, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []*models.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// DeleteWebhook removes a webhook along with its deliveries
+func (d *DB) DeleteWebhook(ctx context.Context, id int64) error {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.NewNotFoundError("webhook", id)
+	}
+	return nil
+}
+
+// CreateWebhookDeliveries stores pending deliveries, due immediately
+func (d *DB) CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event, repository, payload, next_attempt_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		RETURNING id, status, next_attempt_at, created_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, delivery := range deliveries {
+		err := stmt.QueryRowContext(ctx, delivery.WebhookID, delivery.Event, delivery.Repository, delivery.Payload).
+			Scan(&delivery.ID, &delivery.Status, &delivery.NextAttemptAt, &delivery.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries due by now,
+// oldest first, with their payload and destination. Their next attempt is
+// pushed back by lease so that other instances skip them while they're sent.
+func (d *DB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns + `, d.payload, w.url, w.secret`
+
+	rows, err := d.db.QueryContext(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var payload []byte
+		var url, secret string
+		delivery, err := scanWebhookDelivery(rows, &payload, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload, delivery.URL, delivery.Secret = payload, url, secret
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// FinishWebhookDeliveryAttempt records the outcome of an attempt: the
+// delivery's status, attempts, response and next attempt
+func (d *DB) FinishWebhookDeliveryAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	var responseStatus sql.NullInt64
+	if delivery.ResponseStatus != nil {
+		responseStatus = sql.NullInt64{Int64: int64(*delivery.ResponseStatus), Valid: true}
+	}
+
+	_, err := d.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, error = $4, next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7`,
+		delivery.Status, delivery.Attempts, responseStatus, delivery.Error, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.ID,
+	)
+	return err
+}
+
+// ListWebhookDeliveries returns a page of a webhook's deliveries, newest first,
+// and how many it has in total
+func (d *DB) ListWebhookDeliveries(ctx context.Context, webhookID int64, page, perPage int) ([]*models.WebhookDelivery, int, error) {
+	var total int
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := d.db.QueryContext(ctx, query, webhookID, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, rows.Err()
+}
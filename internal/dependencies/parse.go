@@ -0,0 +1,211 @@
+// Package dependencies parses the dependency manifests of Go, npm and Python
+// projects.
+package dependencies
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github-service/internal/models"
+)
+
+// Manifests are the files parsed, at the root of a repository
+var Manifests = []string{"go.mod", "package.json", "requirements.txt"}
+
+// Parse returns the dependencies declared in a manifest, sorted by name.
+// manifest names the file and picks the parser.
+func Parse(manifest string, content []byte) ([]models.Dependency, error) {
+	var (
+		deps []models.Dependency
+		err  error
+	)
+	switch manifest {
+	case "go.mod":
+		deps, err = parseGoMod(content)
+	case "package.json":
+		deps, err = parsePackageJSON(content)
+	case "requirements.txt":
+		deps = parseRequirements(content)
+	default:
+		return nil, fmt.Errorf("unsupported manifest %q", manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", manifest, err)
+	}
+	for i := range deps {
+		deps[i].Manifest = manifest
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps, nil
+}
+
+// parseGoMod reads the require directives of a go.mod file, both single line
+// and in blocks
+func parseGoMod(content []byte) ([]models.Dependency, error) {
+	var deps []models.Dependency
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		code, comment, _ := strings.Cut(text, "//")
+		fields := strings.Fields(code)
+
+		if inBlock {
+			if len(fields) == 1 && fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+		} else {
+			if len(fields) == 0 || fields[0] != "require" {
+				continue
+			}
+			if len(fields) == 2 && fields[1] == "(" {
+				inBlock = true
+				continue
+			}
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: malformed requirement %q", line, text)
+		}
+
+		scope := models.ScopeRuntime
+		if strings.TrimSpace(comment) == "indirect" {
+			scope = models.ScopeIndirect
+		}
+		deps = append(deps, models.Dependency{
+			Ecosystem: models.EcosystemGo,
+			Name:      strings.Trim(fields[0], `"`),
+			Version:   fields[1],
+			Scope:     scope,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inBlock {
+		return nil, fmt.Errorf("unterminated require block")
+	}
+	return deps, nil
+}
+
+// parsePackageJSON reads the dependencies, optionalDependencies and
+// devDependencies of a package.json file. A package listed in several keeps
+// its runtime entry.
+func parsePackageJSON(content []byte) ([]models.Dependency, error) {
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var deps []models.Dependency
+	add := func(packages map[string]string, scope string) {
+		for name, version := range packages {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			deps = append(deps, models.Dependency{
+				Ecosystem: models.EcosystemNPM,
+				Name:      name,
+				Version:   version,
+				Scope:     scope,
+			})
+		}
+	}
+	add(pkg.Dependencies, models.ScopeRuntime)
+	add(pkg.OptionalDependencies, models.ScopeRuntime)
+	add(pkg.DevDependencies, models.ScopeDevelopment)
+	return deps, nil
+}
+
+// requirementName matches the project name at the start of a requirement
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// parseRequirements reads the requirements of a pip requirements file.
+// Options such as -r and -e, URLs and local paths are skipped; they don't name
+// a package on PyPI.
+func parseRequirements(content []byte) []models.Dependency {
+	var deps []models.Dependency
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "-") || strings.Contains(text, "://") {
+			continue
+		}
+
+		name := requirementName.FindString(text)
+		if name == "" {
+			continue
+		}
+		spec := text[len(name):]
+		if strings.HasPrefix(strings.TrimSpace(spec), "@") {
+			continue // name @ url
+		}
+		// Drop the extras and the environment marker
+		if strings.HasPrefix(spec, "[") {
+			if end := strings.Index(spec, "]"); end >= 0 {
+				spec = spec[end+1:]
+			}
+		}
+		spec, _, _ = strings.Cut(spec, ";")
+		spec = strings.ReplaceAll(spec, " ", "")
+		// An exact pin is stored as the bare version
+		if version, ok := strings.CutPrefix(spec, "=="); ok && !strings.ContainsAny(version, ",*") {
+			spec = version
+		}
+
+		name = NormalizeName(models.EcosystemPyPI, name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		deps = append(deps, models.Dependency{
+			Ecosystem: models.EcosystemPyPI,
+			Name:      name,
+			Version:   spec,
+			Scope:     models.ScopeRuntime,
+		})
+	}
+	return deps
+}
+
+// pypiSeparators matches the runs of characters PyPI treats as equivalent
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName returns the canonical form of a package name, so a package
+// matches however a manifest spells it. PyPI names are case insensitive and
+// treat -, _ and . alike; Go and npm names are kept as they are.
+func NormalizeName(ecosystem, name string) string {
+	if ecosystem == models.EcosystemPyPI {
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	}
+	return name
+}
+
+// ValidEcosystem reports whether dependencies of an ecosystem are tracked
+func ValidEcosystem(ecosystem string) bool {
+	switch ecosystem {
+	case models.EcosystemGo, models.EcosystemNPM, models.EcosystemPyPI:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package dependencies
+
+import (
+	"reflect"
+	"testing"
+
+	"github-service/internal/models"
+)
+
+func TestParseGoMod(t *testing.T) {
+	content := []byte(`module example.com/service
+
+go 1.22
+
+require github.com/rs/zerolog v1.31.0
+
+require (
+	// Routing
+	github.com/gorilla/mux v1.8.1
+	golang.org/x/sys v0.15.0 // indirect
+)
+
+replace github.com/gorilla/mux => ../mux
+`)
+	deps, err := Parse("go.mod", content)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []models.Dependency{
+		{Manifest: "go.mod", Ecosystem: models.EcosystemGo, Name: "github.com/gorilla/mux", Version: "v1.8.1", Scope: models.ScopeRuntime},
+		{Manifest: "go.mod", Ecosystem: models.EcosystemGo, Name: "github.com/rs/zerolog", Version: "v1.31.0", Scope: models.ScopeRuntime},
+		{Manifest: "go.mod", Ecosystem: models.EcosystemGo, Name: "golang.org/x/sys", Version: "v0.15.0", Scope: models.ScopeIndirect},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("Expected %+v, got %+v", want, deps)
+	}
+
+	if _, err := Parse("go.mod", []byte("require (\n\tgithub.com/a/b v1.0.0\n")); err == nil {
+		t.Error("Expected an error for an unterminated require block")
+	}
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	content := []byte(`{
+		"name": "web",
+		"dependencies": {"react": "^18.2.0", "@types/node": "20.10.0"},
+		"devDependencies": {"jest": "~29.7.0", "react": "^18.2.0"}
+	}`)
+	deps, err := Parse("package.json", content)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []models.Dependency{
+		{Manifest: "package.json", Ecosystem: models.EcosystemNPM, Name: "@types/node", Version: "20.10.0", Scope: models.ScopeRuntime},
+		{Manifest: "package.json", Ecosystem: models.EcosystemNPM, Name: "jest", Version: "~29.7.0", Scope: models.ScopeDevelopment},
+		{Manifest: "package.json", Ecosystem: models.EcosystemNPM, Name: "react", Version: "^18.2.0", Scope: models.ScopeRuntime},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("Expected %+v, got %+v", want, deps)
+	}
+
+	if _, err := Parse("package.json", []byte("{")); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	content := []byte(`# Web
+-r base.txt
+--index-url https://example.com/simple
+Django==4.2.7
+requests[socks] >= 2.31, < 3  # HTTP
+typing_extensions; python_version < "3.11"
+-e git+https://github.com/org/lib.git#egg=lib
+mylib @ https://example.com/mylib.tar.gz
+Flask.Login==0.6.*
+`)
+	deps, err := Parse("requirements.txt", content)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []models.Dependency{
+		{Manifest: "requirements.txt", Ecosystem: models.EcosystemPyPI, Name: "django", Version: "4.2.7", Scope: models.ScopeRuntime},
+		{Manifest: "requirements.txt", Ecosystem: models.EcosystemPyPI, Name: "flask-login", Version: "==0.6.*", Scope: models.ScopeRuntime},
+		{Manifest: "requirements.txt", Ecosystem: models.EcosystemPyPI, Name: "requests", Version: ">=2.31,<3", Scope: models.ScopeRuntime},
+		{Manifest: "requirements.txt", Ecosystem: models.EcosystemPyPI, Name: "typing-extensions", Version: "", Scope: models.ScopeRuntime},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("Expected %+v, got %+v", want, deps)
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		ecosystem, name, want string
+	}{
+		{models.EcosystemPyPI, "Typing_Extensions", "typing-extensions"},
+		{models.EcosystemPyPI, "zope..interface", "zope-interface"},
+		{models.EcosystemNPM, "@Scope/Pkg", "@Scope/Pkg"},
+		{models.EcosystemGo, "github.com/BurntSushi/toml", "github.com/BurntSushi/toml"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.ecosystem, tt.name); got != tt.want {
+			t.Errorf("NormalizeName(%q, %q) = %q, want %q", tt.ecosystem, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+//go:build e2e
+
+// Package e2e exercises the whole service against a Postgres container and a
+// fake GitHub API. Run it with: go test -tags e2e ./internal/e2e/...
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github-service/internal/app"
+	"github-service/internal/config"
+	"github-service/internal/database"
+	"github-service/internal/github"
+	"github-service/internal/models"
+	"github-service/internal/queue"
+	"github-service/internal/service"
+	"github-service/internal/testutil"
+	"github-service/internal/worker"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCommit is a commit served by the fake GitHub API
+type fakeCommit struct {
+	SHA    string
+	Author string
+	Email  string
+	Date   time.Time
+}
+
+// fakeGitHub serves the GitHub API endpoints used by a sync from in-memory repositories
+type fakeGitHub struct {
+	mu      sync.Mutex
+	commits map[string][]fakeCommit // By full name, newest first
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "repos" {
+		http.NotFound(w, r)
+		return
+	}
+	fullName := parts[1] + "/" + parts[2]
+	commits, ok := f.commits[fullName]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-RateLimit-Limit", "5000")
+	w.Header().Set("X-RateLimit-Remaining", "4999")
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+
+	switch {
+	case len(parts) == 3:
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":               int64(len(fullName)) * 1000,
+			"name":             parts[2],
+			"full_name":        fullName,
+			"html_url":         "https://github.com/" + fullName,
+			"language":         "Go",
+			"stargazers_count": 42,
+			"created_at":       "2020-01-01T00:00:00Z",
+			"updated_at":       time.Now().UTC().Format(time.RFC3339),
+		})
+	case len(parts) == 4 && parts[3] == "commits":
+		// Everything fits on the first page
+		page := []map[string]interface{}{}
+		if r.URL.Query().Get("page") == "1" {
+			for _, c := range commits {
+				author := map[string]interface{}{"name": c.Author, "email": c.Email, "date": c.Date.Format(time.RFC3339)}
+				page = append(page, map[string]interface{}{
+					"sha":      c.SHA,
+					"html_url": "https://github.com/" + fullName + "/commit/" + c.SHA,
+					"commit":   map[string]interface{}{"author": author, "committer": author, "message": "Commit " + c.SHA[:7]},
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// harness runs the full application: router, job worker, sync worker and queue
+type harness struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+func newHarness(t *testing.T, gh *fakeGitHub) *harness {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	pg, err := testutil.NewTestPostgres(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { pg.Close(context.Background()) })
+
+	db, err := database.New(pg.DSN)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	githubServer := httptest.NewServer(gh)
+	t.Cleanup(githubServer.Close)
+	github.SetBaseURL(githubServer.URL)
+
+	logger := zerolog.New(zerolog.NewTestWriter(t)).Level(zerolog.WarnLevel)
+	svc := service.New(github.NewClient("e2e-token"), db, &logger,
+		service.WithDefaultHistory(30*24*time.Hour),
+	)
+
+	jobQueue, err := queue.NewPostgresQueue(db.DB())
+	require.NoError(t, err)
+
+	jobWorker := worker.NewJobWorker(jobQueue, svc, logger)
+	go jobWorker.Start(ctx)
+	t.Cleanup(func() {
+		drainCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		jobWorker.Shutdown(drainCtx)
+	})
+
+	syncWorker := worker.NewSyncWorker(svc, time.Hour)
+	cfg := &config.Config{Server: config.ServerConfig{DefaultPerPage: 10, MaxPerPage: 100}}
+	application, err := app.New(cfg, logger, svc, jobQueue, syncWorker)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(application.Handler())
+	t.Cleanup(server.Close)
+	return &harness{t: t, server: server}
+}
+
+// do sends a request to the service, checks its status and decodes the data of
+// its JSON response into data unless data is nil
+func (h *harness) do(method, path string, wantStatus int, data interface{}) {
+	h.t.Helper()
+	req, err := http.NewRequest(method, h.server.URL+path, nil)
+	require.NoError(h.t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(h.t, err)
+	defer resp.Body.Close()
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(h.t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(h.t, wantStatus, resp.StatusCode, "%s %s", method, path)
+	if data != nil {
+		require.NoError(h.t, json.Unmarshal(body.Data, data))
+	}
+}
+
+// waitForJob polls a job until it completes
+func (h *harness) waitForJob(jobID string) {
+	h.t.Helper()
+	require.Eventually(h.t, func() bool {
+		var job struct {
+			Status queue.JobStatus `json:"status"`
+		}
+		h.do(http.MethodGet, "/api/v1/jobs/"+jobID, http.StatusOK, &job)
+		require.NotEqual(h.t, queue.JobStatusStopped, job.Status)
+		return job.Status == queue.JobStatusComplete
+	}, 30*time.Second, 200*time.Millisecond)
+}
+
+func TestRepositoryLifecycle(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	gh := &fakeGitHub{commits: map[string][]fakeCommit{
+		"octo/hello": {
+			{SHA: strings.Repeat("c", 40), Author: "Ada", Email: "ada@example.com", Date: now.Add(-1 * time.Hour)},
+			{SHA: strings.Repeat("b", 40), Author: "Ada", Email: "ada@example.com", Date: now.Add(-2 * time.Hour)},
+			{SHA: strings.Repeat("a", 40), Author: "Linus", Email: "linus@example.com", Date: now.Add(-3 * time.Hour)},
+		},
+	}}
+	h := newHarness(t, gh)
+
+	// Add: the repository is synced and its history job queued
+	var added struct {
+		JobID string `json:"job_id"`
+	}
+	h.do(http.MethodPut, "/api/v1/repositories/octo/hello", http.StatusAccepted, &added)
+	require.NotEmpty(t, added.JobID)
+	h.waitForJob(added.JobID)
+
+	// Sync: the commits are stored
+	var commits []models.Commit
+	h.do(http.MethodGet, "/api/v1/repositories/octo/hello/commits", http.StatusOK, &commits)
+	assert.Len(t, commits, 3)
+
+	var listing struct {
+		Repositories []models.RepositoryListing `json:"repositories"`
+	}
+	h.do(http.MethodGet, "/api/v1/repositories", http.StatusOK, &listing)
+	require.Len(t, listing.Repositories, 1)
+	assert.Equal(t, models.RepositoryStatusSynced, listing.Repositories[0].Status)
+
+	// Stats: authors are ranked by commit count
+	var stats struct {
+		Authors []models.CommitStats `json:"authors"`
+	}
+	h.do(http.MethodGet, "/api/v1/stats/top-authors?repository=octo/hello", http.StatusOK, &stats)
+	require.Len(t, stats.Authors, 2)
+	assert.Equal(t, "ada@example.com", stats.Authors[0].AuthorEmail)
+	assert.Equal(t, 2, stats.Authors[0].Count)
+
+	// Remove: the repository is no longer monitored and its stats are gone
+	h.do(http.MethodDelete, "/api/v1/repositories/octo/hello", http.StatusOK, nil)
+	h.do(http.MethodGet, "/api/v1/repositories", http.StatusOK, &listing)
+	assert.Empty(t, listing.Repositories)
+	h.do(http.MethodGet, "/api/v1/stats/top-authors?repository=octo/hello", http.StatusNotFound, nil)
+}
+
+func TestAddUnknownRepository(t *testing.T) {
+	h := newHarness(t, &fakeGitHub{commits: map[string][]fakeCommit{}})
+	h.do(http.MethodPut, "/api/v1/repositories/octo/missing", http.StatusNotFound, nil)
+}
+
+func TestHeadAndOptions(t *testing.T) {
+	h := newHarness(t, &fakeGitHub{commits: map[string][]fakeCommit{}})
+
+	// HEAD answers a GET route with its headers and no body
+	resp, err := http.Head(h.server.URL + "/api/v1/repositories")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Empty(t, body)
+
+	// OPTIONS lists the methods of every route at the path
+	req, err := http.NewRequest(http.MethodOptions, h.server.URL+"/api/v1/repositories/octo/hello/commits-since", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD, PUT, DELETE, OPTIONS", resp.Header.Get("Allow"))
+
+	// Other methods are still rejected, now with an Allow header
+	req, err = http.NewRequest(http.MethodPatch, h.server.URL+"/api/v1/repositories/octo/hello/commits-since", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	assert.Equal(t, "GET, HEAD, PUT, DELETE, OPTIONS", resp.Header.Get("Allow"))
+}
+
+func TestEnqueueJob(t *testing.T) {
+	h := newHarness(t, &fakeGitHub{commits: map[string][]fakeCommit{}})
+
+	post := func(body string, wantStatus int) map[string]interface{} {
+		t.Helper()
+		resp, err := http.Post(h.server.URL+"/api/v1/jobs", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var decoded struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+		require.Equal(t, wantStatus, resp.StatusCode, body)
+		return decoded.Data
+	}
+
+	// Unknown types, invalid payloads and unmonitored repositories are rejected
+	post(`{"type": "export"}`, http.StatusBadRequest)
+	post(`{"type": "maintenance", "payload": {"tasks": ["vacuum"]}}`, http.StatusBadRequest)
+	post(`{"type": "resync", "payload": {"owner": "octo", "repo": "hello", "extra": 1}}`, http.StatusBadRequest)
+	post(`{"type": "resync", "payload": {"owner": "octo", "repo": "hello"}}`, http.StatusNotFound)
+
+	// A cleanup job is run by the workers
+	data := post(`{"type": "cleanup"}`, http.StatusAccepted)
+	assert.Equal(t, "scheduled", data["status"])
+	h.waitForJob(data["job_id"].(string))
+}
@@ -3,20 +3,36 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
 	// ErrNotFound is returned when a requested resource is not found
 	ErrNotFound = errors.New("resource not found")
 
+	// ErrRepositoryNotFound is matched by the NotFoundErrors of repositories
+	ErrRepositoryNotFound = errors.New("repository not found")
+
 	// ErrDuplicate is returned when attempting to create a duplicate resource
 	ErrDuplicate = errors.New("resource already exists")
 
+	// ErrConflict is returned when a resource is not in a state that allows the operation
+	ErrConflict = errors.New("conflicting resource state")
+
+	// ErrAmbiguous is returned when a lookup matches more than one resource
+	ErrAmbiguous = errors.New("ambiguous reference")
+
+	// ErrSyncInProgress is returned when another sync of the same repository is running
+	ErrSyncInProgress = errors.New("sync already in progress")
+
 	// ErrInvalidInput is returned when the input parameters are invalid
 	ErrInvalidInput = errors.New("invalid input parameters")
 
-	// ErrRateLimit is returned when GitHub API rate limit is exceeded
-	ErrRateLimit = errors.New("github api rate limit exceeded")
+	// ErrRateLimit is returned when the API rate limit of GitHub or another provider is exceeded
+	ErrRateLimit = errors.New("rate limit exceeded")
 
 	// ErrGitHubAPI is returned when GitHub API returns an error
 	ErrGitHubAPI = errors.New("github api error")
@@ -26,8 +42,119 @@ var (
 
 	// ErrUnauthorized is returned when authentication fails
 	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrUpstreamNotFound is matched by APIErrors of resources GitHub or another provider doesn't have
+	ErrUpstreamNotFound = errors.New("not found upstream")
+
+	// ErrUpstreamUnauthorized is matched by APIErrors of credentials GitHub or another provider rejected
+	ErrUpstreamUnauthorized = errors.New("unauthorized upstream")
 )
 
+// maxAPIErrorBody is how much of a response body an APIError keeps
+const maxAPIErrorBody = 1024
+
+// APIError is a response with an unexpected status from the API of GitHub or
+// another provider. Match it with ErrUpstreamNotFound, ErrUpstreamUnauthorized
+// or ErrRateLimit rather than the status code.
+type APIError struct {
+	Provider   string // e.g. "github"
+	StatusCode int
+	Body       string // The start of the response body
+
+	// RateLimited is set for responses rejecting the request for exceeding a
+	// rate limit with a status other than 429, along with how long the
+	// provider asked to wait
+	RateLimited bool
+	RetryAfter  time.Duration
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	if e.rateLimited() {
+		msg = fmt.Sprintf("%s %v (status %d)", e.Provider, ErrRateLimit, e.StatusCode)
+		if e.RetryAfter > 0 {
+			msg += fmt.Sprintf(", retry after %v", e.RetryAfter.Round(time.Second))
+		}
+	} else {
+		msg = fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is makes errors.Is match the sentinel errors of the status
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUpstreamNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUpstreamUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimit:
+		return e.rateLimited()
+	}
+	return false
+}
+
+// rateLimited reports whether the response rejected the request for exceeding a rate limit
+func (e *APIError) rateLimited() bool {
+	return e.RateLimited || e.StatusCode == http.StatusTooManyRequests
+}
+
+// NewAPIError creates an APIError for a response of provider with the given
+// status, keeping the start of its body on a single line
+func NewAPIError(provider string, status int, body []byte) *APIError {
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if len(text) > maxAPIErrorBody {
+		// Cut before the character straddling the limit, not through it
+		cut := maxAPIErrorBody
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+	return &APIError{Provider: provider, StatusCode: status, Body: text}
+}
+
+// NotFoundError reports that a resource looked up by a key doesn't exist. It
+// matches ErrNotFound, and ErrRepositoryNotFound when the resource is a
+// repository.
+type NotFoundError struct {
+	Resource string // e.g. "repository", "monitored repository" or "job"
+	Key      string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key == "" {
+		return e.Resource + " not found"
+	}
+	return fmt.Sprintf("%s not found: %s", e.Resource, e.Key)
+}
+
+// Is makes errors.Is match the sentinel errors of missing resources
+func (e *NotFoundError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return true
+	case ErrRepositoryNotFound:
+		return repositoryResources[e.Resource]
+	}
+	return false
+}
+
+// repositoryResources are the resources whose NotFoundErrors match ErrRepositoryNotFound
+var repositoryResources = map[string]bool{
+	"repository":           true,
+	"monitored repository": true,
+	"deleted repository":   true,
+}
+
+// NewNotFoundError creates a NotFoundError for the resource with the given key
+func NewNotFoundError(resource string, key interface{}) error {
+	return &NotFoundError{Resource: resource, Key: fmt.Sprint(key)}
+}
+
 // RepositoryError represents an error related to repository operations
 type RepositoryError struct {
 	Owner string
@@ -137,6 +264,11 @@ func Is(err, target error) bool {
 	return errors.Is(err, target)
 }
 
+// Join wraps the non-nil errors into one, or returns nil when there are none
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
+
 // As finds the first error in err's chain that matches target
 func As(err error, target interface{}) bool {
 	return errors.As(err, target)
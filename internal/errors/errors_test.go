@@ -0,0 +1,81 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestNotFoundError(t *testing.T) {
+	err := fmt.Errorf("error fetching commits: %w", NewNotFoundError("repository", "octo/hello"))
+	if err.Error() != "error fetching commits: repository not found: octo/hello" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if !Is(err, ErrNotFound) || !Is(err, ErrRepositoryNotFound) {
+		t.Errorf("Expected a missing repository to match ErrNotFound and ErrRepositoryNotFound")
+	}
+	if !Is(NewNotFoundError("monitored repository", "octo/hello"), ErrRepositoryNotFound) {
+		t.Errorf("Expected a missing monitored repository to match ErrRepositoryNotFound")
+	}
+
+	job := NewNotFoundError("job", 42)
+	if !Is(job, ErrNotFound) || Is(job, ErrRepositoryNotFound) {
+		t.Errorf("Expected a missing job to match ErrNotFound only")
+	}
+	if job.Error() != "job not found: 42" {
+		t.Errorf("Unexpected message %q", job.Error())
+	}
+
+	var notFound *NotFoundError
+	if !As(job, &notFound) || notFound.Resource != "job" {
+		t.Errorf("Expected As to find the NotFoundError")
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	notFound := fmt.Errorf("executing request: %w", NewAPIError("github", 404, []byte("{\n  \"message\": \"Not Found\"\n}")))
+	if notFound.Error() != `executing request: unexpected status code: 404: { "message": "Not Found" }` {
+		t.Errorf("Unexpected message %q", notFound.Error())
+	}
+	if !Is(notFound, ErrUpstreamNotFound) || Is(notFound, ErrUpstreamUnauthorized) || Is(notFound, ErrRateLimit) {
+		t.Errorf("Expected a 404 to match ErrUpstreamNotFound only")
+	}
+	if Is(notFound, ErrNotFound) {
+		t.Errorf("Expected a 404 from a provider not to match ErrNotFound")
+	}
+
+	if !Is(NewAPIError("github", 401, nil), ErrUpstreamUnauthorized) {
+		t.Errorf("Expected a 401 to match ErrUpstreamUnauthorized")
+	}
+
+	limited := NewAPIError("github", 403, nil)
+	if Is(limited, ErrRateLimit) {
+		t.Errorf("Expected a plain 403 not to match ErrRateLimit")
+	}
+	limited.RateLimited, limited.RetryAfter = true, 90*time.Second
+	if !Is(limited, ErrRateLimit) || limited.Error() != "github rate limit exceeded (status 403), retry after 1m30s" {
+		t.Errorf("Expected a rate limited 403, got %q", limited.Error())
+	}
+	if !Is(NewAPIError("gitlab", 429, nil), ErrRateLimit) {
+		t.Errorf("Expected a 429 to match ErrRateLimit")
+	}
+
+	var apiErr *APIError
+	if !As(notFound, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("Expected As to find the APIError")
+	}
+
+	// Long bodies are truncated between characters, wherever the limit falls
+	for offset := 0; offset < 4; offset++ {
+		body := strings.Repeat("a", offset) + strings.Repeat("€", maxAPIErrorBody)
+		truncated := NewAPIError("github", 500, []byte(body)).Body
+		if !utf8.ValidString(truncated) || !strings.HasSuffix(truncated, "...") || len(truncated) > maxAPIErrorBody+len("...") {
+			t.Errorf("Expected a valid body of at most %d bytes and an ellipsis at offset %d, got %d bytes ending %q", maxAPIErrorBody, offset, len(truncated), truncated[len(truncated)-8:])
+		}
+		if kept := strings.TrimSuffix(truncated, "..."); !strings.HasPrefix(body, kept) || len(kept) < maxAPIErrorBody-utf8.UTFMax {
+			t.Errorf("Expected the start of the body up to the limit at offset %d, got %d bytes", offset, len(kept))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package events fans job and sync progress out to in-process subscribers so
+// clients can follow it live instead of polling.
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	JobEnqueued      = "job.enqueued"
+	JobStarted       = "job.started"
+	JobCompleted     = "job.completed"
+	JobFailed        = "job.failed"
+	JobQuarantined   = "job.quarantined"
+	RepositorySynced = "repository.synced"
+	CommitsIngested  = "commits.ingested"
+	TokenExpiring    = "github.token_expiring"
+)
+
+// subscriberBuffer is the number of events queued for a subscriber before
+// further events are dropped for it
+const subscriberBuffer = 256
+
+// Event is a single job or sync event
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Matches reports whether the event is one of types. A type without a dot,
+// such as "job", matches every event of that category. An empty list matches
+// every event.
+func (e Event) Matches(types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if e.Type == t || (!strings.Contains(t, ".") && strings.HasPrefix(e.Type, t+".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus delivers published events to all current subscribers
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus creates a Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish sends an event to every subscriber without blocking. Subscribers that
+// fall behind miss events rather than slow down the publisher.
+func (b *Bus) Publish(eventType string, data map[string]interface{}) {
+	event := Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber; drop the event
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events published from now on, and a
+// function that cancels the subscription
+func (b *Bus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, ch)
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import "testing"
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+
+	// Events published without subscribers are discarded
+	bus.Publish(JobEnqueued, nil)
+
+	events, unsubscribe := bus.Subscribe()
+	bus.Publish(JobStarted, map[string]interface{}{"job_id": "1"})
+	unsubscribe()
+	bus.Publish(JobCompleted, map[string]interface{}{"job_id": "1"})
+
+	select {
+	case event := <-events:
+		if event.Type != JobStarted {
+			t.Errorf("got event %q, want %q", event.Type, JobStarted)
+		}
+		if event.Data["job_id"] != "1" {
+			t.Errorf("got job_id %v, want 1", event.Data["job_id"])
+		}
+	default:
+		t.Fatal("expected subscriber to receive the published event")
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("got event %q after unsubscribing", event.Type)
+	default:
+	}
+}
+
+func TestEventMatches(t *testing.T) {
+	event := Event{Type: JobFailed}
+
+	tests := []struct {
+		types []string
+		want  bool
+	}{
+		{nil, true},
+		{[]string{JobFailed}, true},
+		{[]string{"job"}, true},
+		{[]string{RepositorySynced, "job"}, true},
+		{[]string{JobStarted}, false},
+		{[]string{"repository"}, false},
+		{[]string{"jo"}, false},
+	}
+	for _, tt := range tests {
+		if got := event.Matches(tt.types); got != tt.want {
+			t.Errorf("Matches(%v) = %v, want %v", tt.types, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Installation tokens are valid for an hour; they are refreshed once less than
+// tokenRefreshMargin of that remains so requests never race the expiry
+const (
+	tokenRefreshMargin = 5 * time.Minute
+	appJWTLifetime     = 9 * time.Minute // GitHub accepts at most 10 minutes
+	appJWTClockSkew    = time.Minute
+)
+
+// TokenSource supplies the token used to authenticate API requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// AppTokenSource authenticates as a GitHub App installation. It signs a JWT with
+// the app's private key and exchanges it for an installation token, which is
+// cached and refreshed shortly before it expires.
+type AppTokenSource struct {
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+	httpClient     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewAppTokenSource creates a token source for a GitHub App installation from
+// the app's PEM encoded private key
+func NewAppTokenSource(appID, installationID int64, privateKeyPEM []byte) (*AppTokenSource, error) {
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &AppTokenSource{
+		appID:          appID,
+		installationID: installationID,
+		key:            key,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// SetHTTPClient replaces the client used to request installation tokens
+func (s *AppTokenSource) SetHTTPClient(httpClient *http.Client) {
+	s.httpClient = httpClient
+}
+
+// parsePrivateKey parses a PKCS#1 or PKCS#8 RSA private key. GitHub issues PKCS#1 keys.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("github app private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing github app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("github app private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token returns a valid installation token, requesting a new one when the
+// cached token is missing or about to expire
+func (s *AppTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expiresAt) > tokenRefreshMargin {
+		return s.token, nil
+	}
+
+	token, expiresAt, err := s.createInstallationToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// createInstallationToken exchanges an app JWT for a new installation token
+func (s *AppTokenSource) createInstallationToken(ctx context.Context) (string, time.Time, error) {
+	jwt, err := s.signJWT(time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", baseURL, s.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("creating installation token request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("requesting installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", time.Time{}, fmt.Errorf("requesting installation token: %w", responseError(resp))
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", time.Time{}, fmt.Errorf("decoding installation token: %w", err)
+	}
+	return body.Token, body.ExpiresAt, nil
+}
+
+// signJWT creates the RS256 signed JWT identifying the app. It is backdated
+// slightly to tolerate clock drift between this host and GitHub.
+func (s *AppTokenSource) signJWT(now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": fmt.Sprintf("%d", s.appID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing github app jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
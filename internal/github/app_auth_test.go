@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		// The JWT must be signed with the app's key
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			t.Fatalf("expected a JWT, got %q", jwt)
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_installation%d", "expires_at": %q}`,
+			requests, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	tokens, err := NewAppTokenSource(7, 42, keyPEM)
+	if err != nil {
+		t.Fatalf("NewAppTokenSource: %v", err)
+	}
+
+	first, err := tokens.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	second, err := tokens.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if first != "ghs_installation1" || second != first || requests != 1 {
+		t.Errorf("expected the token to be cached, got %q then %q after %d requests", first, second, requests)
+	}
+
+	// A token close to expiry is refreshed
+	tokens.expiresAt = time.Now().Add(time.Minute)
+	refreshed, err := tokens.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if refreshed != "ghs_installation2" {
+		t.Errorf("expected a refreshed token, got %q", refreshed)
+	}
+}
@@ -2,19 +2,34 @@ package github
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	apperrors "github-service/internal/errors"
 	"github-service/internal/models"
+	"io"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/singleflight"
 )
 
 var baseURL = "https://api.github.com"
 
+// SetBaseURL points all clients at another GitHub-compatible API, such as a
+// fake server in end-to-end tests. It must be called before any request is made.
+func SetBaseURL(url string) {
+	baseURL = strings.TrimSuffix(url, "/")
+}
+
 // RateLimitInfo stores GitHub API rate limit information
 type RateLimitInfo struct {
 	Remaining int
@@ -26,6 +41,8 @@ type RateLimitInfo struct {
 type GitHubClient interface {
 	GetRepository(ctx context.Context, owner, repo string) (*Repository, error)
 	GetCommits(ctx context.Context, owner, repo string, since time.Time) ([]CommitResponse, error)
+	GetCommit(ctx context.Context, owner, repo, sha string) (*models.CommitDetail, error)
+	GetIssues(ctx context.Context, owner, repo string, since time.Time) ([]models.Issue, error)
 	GetRateLimitInfo() RateLimitInfo
 }
 
@@ -33,18 +50,31 @@ type GitHubClient interface {
 type Client struct {
 	httpClient *http.Client
 	token      string
+	tokens     TokenSource // When set, supplies the token instead of token
 	logger     zerolog.Logger
 
 	// Rate limiting
 	rateLimitMu sync.RWMutex
 	rateLimit   RateLimitInfo
+
+	// Expiry of the token as reported by GitHub; zero when it doesn't expire
+	tokenExpiry time.Time
+
+	// When set, requests rotate between these tokens instead of using token
+	pool []*pooledToken
+
+	// Concurrent requests for the same repository share one GitHub call
+	repoFlight   singleflight.Group
+	repoRequests atomic.Int64
+	repoDeduped  atomic.Int64
 }
 
 // NewClient creates a new GitHub API client
 func NewClient(token string) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Timeout: time.Second * 30,
+			Timeout:   time.Second * 30,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
 		token: token,
 		logger: zerolog.New(zerolog.NewConsoleWriter()).With().
@@ -59,6 +89,38 @@ func NewClient(token string) *Client {
 	}
 }
 
+// SetHTTPClient replaces the client used for GitHub requests, e.g. to send them
+// through a proxy or trust a GitHub Enterprise certificate authority
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// pooledToken is one of several tokens a client rotates between, with the rate
+// limit GitHub last reported for it
+type pooledToken struct {
+	token     string
+	rateLimit RateLimitInfo
+	known     bool // Whether GitHub has reported the token's rate limit yet
+	expiry    time.Time
+}
+
+// NewMultiTokenClient creates a GitHub API client that sends each request with
+// whichever of the tokens has the most rate limit remaining
+func NewMultiTokenClient(tokens []string) *Client {
+	c := NewClient("")
+	for _, token := range tokens {
+		c.pool = append(c.pool, &pooledToken{token: token})
+	}
+	return c
+}
+
+// NewAppClient creates a GitHub API client that authenticates as a GitHub App installation
+func NewAppClient(tokens TokenSource) *Client {
+	c := NewClient("")
+	c.tokens = tokens
+	return c
+}
+
 // Repository represents the GitHub repository response
 type Repository struct {
 	ID              int64     `json:"id"`
@@ -94,39 +156,143 @@ type CommitResponse struct {
 	HTMLURL string `json:"html_url"`
 }
 
-// GetRateLimitInfo returns the current rate limit information
+// GetRateLimitInfo returns the current rate limit information. With several
+// tokens, it is that of the token the next request will use.
 func (c *Client) GetRateLimitInfo() models.RateLimitInfo {
 	c.rateLimitMu.RLock()
 	defer c.rateLimitMu.RUnlock()
+
+	info := c.rateLimit
+	if len(c.pool) > 0 {
+		info = c.bestToken().rateLimit
+	}
 	return models.RateLimitInfo{
-		Remaining: c.rateLimit.Remaining,
-		Reset:     c.rateLimit.Reset,
-		Limit:     c.rateLimit.Limit,
+		Remaining: info.Remaining,
+		Reset:     info.Reset,
+		Limit:     info.Limit,
 	}
 }
 
-// updateRateLimit updates rate limit information from response headers
-func (c *Client) updateRateLimit(resp *http.Response) {
+// TokenRateLimits returns the rate limit of each token the client rotates
+// between, or nil when it uses a single token
+func (c *Client) TokenRateLimits() []models.TokenRateLimit {
+	c.rateLimitMu.RLock()
+	defer c.rateLimitMu.RUnlock()
+
+	if len(c.pool) == 0 {
+		return nil
+	}
+	limits := make([]models.TokenRateLimit, len(c.pool))
+	for i, t := range c.pool {
+		limits[i] = models.TokenRateLimit{
+			Token:     maskToken(t.token),
+			Remaining: t.rateLimit.Remaining,
+			Limit:     t.rateLimit.Limit,
+			Reset:     t.rateLimit.Reset,
+			Known:     t.known,
+		}
+	}
+	return limits
+}
+
+// maskToken keeps only the last four characters of a token, enough to tell
+// configured tokens apart
+func maskToken(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "****" + token[len(token)-4:]
+}
+
+// bestToken returns the pooled token with the most requests available, counting
+// tokens whose limit is unknown or has reset as having their full quota. The
+// caller must hold rateLimitMu.
+func (c *Client) bestToken() *pooledToken {
+	now := time.Now()
+	available := func(t *pooledToken) int {
+		if !t.known {
+			return int(^uint(0) >> 1)
+		}
+		if !t.rateLimit.Reset.After(now) {
+			return t.rateLimit.Limit
+		}
+		return t.rateLimit.Remaining
+	}
+
+	best := c.pool[0]
+	for _, t := range c.pool[1:] {
+		if available(t) > available(best) {
+			best = t
+		}
+	}
+	return best
+}
+
+// updateRateLimit updates rate limit information from response headers, for
+// the pooled token the request was sent with when there is one
+func (c *Client) updateRateLimit(resp *http.Response, pooled *pooledToken) {
 	c.rateLimitMu.Lock()
 	defer c.rateLimitMu.Unlock()
 
+	rateLimit, tokenExpiry := &c.rateLimit, &c.tokenExpiry
+	if pooled != nil {
+		rateLimit, tokenExpiry = &pooled.rateLimit, &pooled.expiry
+		pooled.known = pooled.known || resp.Header.Get("X-RateLimit-Remaining") != ""
+	}
+
 	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
 		if val, err := strconv.Atoi(remaining); err == nil {
-			c.rateLimit.Remaining = val
+			rateLimit.Remaining = val
 		}
 	}
 
 	if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
 		if val, err := strconv.ParseInt(reset, 10, 64); err == nil {
-			c.rateLimit.Reset = time.Unix(val, 0)
+			rateLimit.Reset = time.Unix(val, 0)
 		}
 	}
 
 	if limit := resp.Header.Get("X-RateLimit-Limit"); limit != "" {
 		if val, err := strconv.Atoi(limit); err == nil {
-			c.rateLimit.Limit = val
+			rateLimit.Limit = val
+		}
+	}
+
+	if expiry := resp.Header.Get(tokenExpirationHeader); expiry != "" {
+		if val, err := parseTokenExpiration(expiry); err == nil {
+			*tokenExpiry = val
+		}
+	}
+}
+
+// tokenExpirationHeader is sent by GitHub on responses to requests authenticated
+// with a token that expires, such as a fine-grained personal access token
+const tokenExpirationHeader = "GitHub-Authentication-Token-Expiration"
+
+// parseTokenExpiration parses the token expiration header, e.g. "2024-06-30 12:00:00 UTC"
+func parseTokenExpiration(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized token expiration %q", value)
+}
+
+// TokenExpiration returns when the client's token expires, or the zero time when
+// it doesn't expire or no response has reported it yet. With several tokens, it
+// is the earliest expiry among them.
+func (c *Client) TokenExpiration() time.Time {
+	c.rateLimitMu.RLock()
+	defer c.rateLimitMu.RUnlock()
+
+	earliest := c.tokenExpiry
+	for _, t := range c.pool {
+		if !t.expiry.IsZero() && (earliest.IsZero() || t.expiry.Before(earliest)) {
+			earliest = t.expiry
 		}
 	}
+	return earliest
 }
 
 // checkRateLimit checks if we should wait due to rate limiting
@@ -134,8 +300,13 @@ func (c *Client) checkRateLimit(ctx context.Context) error {
 	c.rateLimitMu.RLock()
 	defer c.rateLimitMu.RUnlock()
 
-	if c.rateLimit.Remaining == 0 {
-		waitTime := time.Until(c.rateLimit.Reset)
+	rateLimit := c.rateLimit
+	if len(c.pool) > 0 {
+		// Only wait when every token is exhausted
+		rateLimit = c.bestToken().rateLimit
+	}
+	if rateLimit.Remaining == 0 {
+		waitTime := time.Until(rateLimit.Reset)
 		if waitTime > 0 {
 			select {
 			case <-ctx.Done():
@@ -148,10 +319,70 @@ func (c *Client) checkRateLimit(ctx context.Context) error {
 	return nil
 }
 
-// doRequest performs an HTTP request with rate limit handling
+// doRequest performs an HTTP request with rate limit handling. Requests rejected
+// by a primary or secondary rate limit are retried after the wait GitHub asks
+// for, up to maxRateLimitRetries times and maxRateLimitWait each, so long syncs
+// ride out the limit.
 func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
-	if err := c.checkRateLimit(req.Context()); err != nil {
-		return nil, fmt.Errorf("rate limit check: %w", err)
+	for attempt := 0; ; attempt++ {
+		if err := c.checkRateLimit(req.Context()); err != nil {
+			return nil, fmt.Errorf("rate limit check: %w", err)
+		}
+
+		resp, err := c.sendRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		wait, limited := rateLimitWait(resp)
+		if !limited {
+			return resp, nil
+		}
+
+		// A request with a body can't be sent again once it has been read
+		if attempt >= maxRateLimitRetries || wait > maxRateLimitWait || req.Body != nil {
+			apiErr := responseError(resp)
+			resp.Body.Close()
+			apiErr.RateLimited, apiErr.RetryAfter = true, wait
+			return nil, apiErr
+		}
+		resp.Body.Close()
+
+		wait = withJitter(wait)
+		c.logger.Warn().
+			Str("url", req.URL.String()).
+			Int("status", resp.StatusCode).
+			Dur("wait", wait).
+			Msg("Rate limited by GitHub, waiting before retrying")
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// responseError reads the error a response with an unexpected status reports.
+// The response body is left for the caller to close.
+func responseError(resp *http.Response) *apperrors.APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	return apperrors.NewAPIError(models.ProviderGitHub, resp.StatusCode, body)
+}
+
+// sendRequest sends a request once, authenticated with the client's token source
+// or token pool when it has one
+func (c *Client) sendRequest(req *http.Request) (*http.Response, error) {
+	if c.tokens != nil {
+		token, err := c.tokens.Token(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("github app authentication: %w", err)
+		}
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	if len(c.pool) > 0 {
+		return c.doPooledRequest(req)
 	}
 
 	resp, err := c.httpClient.Do(req)
@@ -159,17 +390,78 @@ func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
-	c.updateRateLimit(resp)
+	c.updateRateLimit(resp, nil)
+	return resp, nil
+}
 
-	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" {
-		return nil, fmt.Errorf("rate limit exceeded, resets at %v", c.rateLimit.Reset)
-	}
+// doPooledRequest sends a request with the pooled token that has the most rate
+// limit remaining. A request rejected because its token ran out is retried with
+// the next best token, once per token; when all of them are out the rejection
+// is returned for doRequest to wait on.
+func (c *Client) doPooledRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		c.rateLimitMu.RLock()
+		pooled := c.bestToken()
+		c.rateLimitMu.RUnlock()
+		req.Header.Set("Authorization", "token "+pooled.token)
 
-	return resp, nil
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		c.updateRateLimit(resp, pooled)
+
+		if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0" &&
+			attempt < len(c.pool) && req.Body == nil {
+			resp.Body.Close()
+			continue
+		}
+
+		return resp, nil
+	}
 }
 
 // GetRepository fetches repository information from GitHub
 func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*models.Repository, error) {
+	c.repoRequests.Add(1)
+	key := strings.ToLower(owner + "/" + repo)
+
+	// The shared call outlives callers that give up, so it isn't cancelled for
+	// the others; the HTTP client timeout still bounds it
+	led := false
+	ch := c.repoFlight.DoChan(key, func() (interface{}, error) {
+		led = true
+		return c.fetchRepository(context.WithoutCancel(ctx), owner, repo)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if !led {
+			c.repoDeduped.Add(1)
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// Callers modify the repository they get, so each gets its own copy
+		repository := *res.Val.(*models.Repository)
+		return &repository, nil
+	}
+}
+
+// RepositoryDedupStats reports how many repository requests were made and how
+// many of them shared another request's GitHub call
+func (c *Client) RepositoryDedupStats() models.DedupStats {
+	return models.DedupStats{
+		Requests:     c.repoRequests.Load(),
+		Deduplicated: c.repoDeduped.Load(),
+	}
+}
+
+// fetchRepository requests a repository from GitHub
+func (c *Client) fetchRepository(ctx context.Context, owner, repo string) (*models.Repository, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s", baseURL, owner, repo)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -184,7 +476,7 @@ func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*models
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, responseError(resp)
 	}
 
 	var repository Repository
@@ -196,6 +488,7 @@ func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*models
 	now := time.Now()
 	return &models.Repository{
 		GitHubID:        repository.ID,
+		Provider:        models.ProviderGitHub,
 		Name:            repository.Name,
 		FullName:        repository.FullName,
 		Description:     repository.Description,
@@ -214,36 +507,88 @@ func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*models
 	}, nil
 }
 
-// GetCommits fetches commits from GitHub since a specific time
+// GetCommits fetches the most recent page of commits made since a specific time
 func (c *Client) GetCommits(ctx context.Context, owner, repo string, since time.Time) ([]models.CommitResponse, error) {
-	var allCommits []models.CommitResponse
+	var commits []models.CommitResponse
+	err := c.ForEachCommitPage(ctx, owner, repo, since, 1, 1, func(page []models.CommitResponse) (bool, error) {
+		commits = append(commits, page...)
+		return true, nil
+	})
+	return commits, err
+}
+
+// ForEachCommitPage fetches the commits made since a specific time page by page,
+// newest first, and passes each page to fn. Paging begins at startPage, 1 for the
+// newest commits, and stops when fn returns false, at the end of the history, or
+// after page maxPages.
+func (c *Client) ForEachCommitPage(ctx context.Context, owner, repo string, since time.Time, startPage, maxPages int, fn func(page []models.CommitResponse) (bool, error)) error {
 	perPage := 100 // GitHub's maximum per page
-	maxRetries := 3
-	baseDelay := time.Second
 	totalCommits := 0
 
 	c.logger.Info().
 		Str("owner", owner).
 		Str("repo", repo).
 		Time("since", since).
+		Int("start_page", startPage).
+		Int("max_pages", maxPages).
 		Msg("Starting commit fetch")
 
-	// Create URL for first page, sorting by most recent first
-	url := fmt.Sprintf("%s/repos/%s/%s/commits?since=%s&per_page=%d&sort=desc&order=date",
-		baseURL, owner, repo, since.Format(time.RFC3339), perPage)
+	page := max(startPage, 1)
+	for ; page <= maxPages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/commits?since=%s&per_page=%d&page=%d",
+			baseURL, owner, repo, since.Format(time.RFC3339), perPage, page)
 
-	var pageCommits []CommitResponse
-	var resp *http.Response
-	var err error
+		pageCommits, err := c.fetchCommitPage(ctx, url)
+		if err != nil {
+			c.logger.Error().
+				Str("owner", owner).
+				Str("repo", repo).
+				Int("page", page).
+				Err(err).
+				Msg("Failed to fetch commits after all retries")
+			return err
+		}
+		totalCommits += len(pageCommits)
 
-	// Retry loop with exponential backoff
+		more, err := fn(toModelCommits(pageCommits))
+		if err != nil {
+			return err
+		}
+		if !more || len(pageCommits) < perPage {
+			break
+		}
+	}
+
+	c.logger.Info().
+		Str("owner", owner).
+		Str("repo", repo).
+		Int("pages_fetched", min(page, maxPages)).
+		Int("commits_fetched", totalCommits).
+		Msg("Completed commit fetch")
+
+	return nil
+}
+
+// fetchCommitPage fetches a single page of the commit list, retrying failed
+// requests with exponential backoff
+func (c *Client) fetchCommitPage(ctx context.Context, url string) ([]CommitResponse, error) {
+	maxRetries := 3
+	baseDelay := time.Second
+
+	var lastErr error
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		if attempt > 0 {
 			c.logger.Warn().
-				Str("owner", owner).
-				Str("repo", repo).
+				Str("url", url).
 				Int("attempt", attempt+1).
+				Err(lastErr).
 				Msg("Retrying commit fetch")
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(baseDelay * time.Duration(1<<(attempt-1))): // Exponential backoff
+			}
 		}
 
 		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -252,43 +597,35 @@ func (c *Client) GetCommits(ctx context.Context, owner, repo string, since time.
 		}
 
 		c.setHeaders(req)
-		resp, err = c.doRequest(req)
-
-		if err == nil && resp.StatusCode == http.StatusOK {
-			defer resp.Body.Close()
-			if err := json.NewDecoder(resp.Body).Decode(&pageCommits); err == nil {
-				break // Success, exit retry loop
-			}
+		resp, err := c.doRequest(req)
+		if err != nil {
+			lastErr = fmt.Errorf("executing request: %w", err)
+			continue
 		}
 
-		// If we get here, either the request failed or JSON decoding failed
-		if resp != nil {
+		if resp.StatusCode != http.StatusOK {
+			lastErr = responseError(resp)
 			resp.Body.Close()
+			continue
 		}
 
-		// Check if we should retry
-		if attempt < maxRetries-1 {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(baseDelay * time.Duration(1<<attempt)): // Exponential backoff
-				continue
-			}
+		var pageCommits []CommitResponse
+		err = json.NewDecoder(resp.Body).Decode(&pageCommits)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("decoding response: %w", err)
+			continue
 		}
+		return pageCommits, nil
 	}
 
-	// If all retries failed
-	if err != nil {
-		c.logger.Error().
-			Str("owner", owner).
-			Str("repo", repo).
-			Err(err).
-			Msg("Failed to fetch commits after all retries")
-		return nil, fmt.Errorf("executing request: %w", err)
-	}
+	return nil, lastErr
+}
 
-	// Convert to models.CommitResponse and append
-	for _, commit := range pageCommits {
+// toModelCommits converts GitHub commit responses to their model representation
+func toModelCommits(commits []CommitResponse) []models.CommitResponse {
+	converted := make([]models.CommitResponse, 0, len(commits))
+	for _, commit := range commits {
 		modelCommit := models.CommitResponse{
 			SHA:     commit.SHA,
 			HTMLURL: commit.HTMLURL,
@@ -304,17 +641,541 @@ func (c *Client) GetCommits(ctx context.Context, owner, repo string, since time.
 			Email: commit.Commit.Committer.Email,
 			Date:  commit.Commit.Committer.Date,
 		}
-		allCommits = append(allCommits, modelCommit)
+		converted = append(converted, modelCommit)
+	}
+	return converted
+}
+
+// commitDetailResponse represents the GitHub single commit response
+type commitDetailResponse struct {
+	SHA   string `json:"sha"`
+	Stats struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+		Total     int `json:"total"`
+	} `json:"stats"`
+	Files []struct {
+		Filename  string `json:"filename"`
+		Status    string `json:"status"`
+		Additions int    `json:"additions"`
+		Deletions int    `json:"deletions"`
+		Changes   int    `json:"changes"`
+	} `json:"files"`
+}
+
+// GetCommit fetches a single commit including the files it changed
+func (c *Client) GetCommit(ctx context.Context, owner, repo, sha string) (*models.CommitDetail, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", baseURL, owner, repo, sha)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.setHeaders(req)
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var commit commitDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&commit); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	detail := &models.CommitDetail{
+		SHA:       commit.SHA,
+		Additions: commit.Stats.Additions,
+		Deletions: commit.Stats.Deletions,
+		Files:     make([]models.CommitFile, 0, len(commit.Files)),
+	}
+	for _, f := range commit.Files {
+		detail.Files = append(detail.Files, models.CommitFile{
+			Filename:  f.Filename,
+			Extension: FileExtension(f.Filename),
+			Status:    f.Status,
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+			Changes:   f.Changes,
+		})
+	}
+
+	return detail, nil
+}
+
+// issueResponse represents the GitHub issue response
+type issueResponse struct {
+	ID      int64  `json:"id"`
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Comments    int        `json:"comments"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	PullRequest *struct{}  `json:"pull_request"`
+}
+
+// maxIssuePages bounds the number of pages fetched by a single GetIssues call
+const maxIssuePages = 10
+
+// GetIssues fetches issues in any state updated since a specific time. Pull
+// requests, which GitHub also returns from the issues endpoint, are skipped.
+func (c *Client) GetIssues(ctx context.Context, owner, repo string, since time.Time) ([]models.Issue, error) {
+	var issues []models.Issue
+	perPage := 100 // GitHub's maximum per page
+
+	for page := 1; page <= maxIssuePages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/issues?state=all&sort=updated&direction=asc&per_page=%d&page=%d",
+			baseURL, owner, repo, perPage, page)
+		if !since.IsZero() {
+			url += "&since=" + since.UTC().Format(time.RFC3339)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+
+		c.setHeaders(req)
+		resp, err := c.doRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			apiErr := responseError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+		}
+
+		var pageIssues []issueResponse
+		err = json.NewDecoder(resp.Body).Decode(&pageIssues)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+
+		for _, i := range pageIssues {
+			if i.PullRequest != nil {
+				continue
+			}
+			issues = append(issues, models.Issue{
+				GitHubID:    i.ID,
+				Number:      i.Number,
+				Title:       i.Title,
+				Body:        i.Body,
+				State:       i.State,
+				AuthorLogin: i.User.Login,
+				Comments:    i.Comments,
+				URL:         i.HTMLURL,
+				CreatedAt:   i.CreatedAt,
+				UpdatedAt:   i.UpdatedAt,
+				ClosedAt:    i.ClosedAt,
+			})
+		}
+
+		if len(pageIssues) < perPage {
+			break
+		}
 	}
 
-	totalCommits = len(pageCommits)
 	c.logger.Info().
 		Str("owner", owner).
 		Str("repo", repo).
-		Int("commits_fetched", totalCommits).
-		Msg("Completed commit fetch")
+		Int("issues_fetched", len(issues)).
+		Msg("Completed issue fetch")
+
+	return issues, nil
+}
+
+// maxOrgRepositoryPages bounds the number of pages fetched by a single
+// ListOrganizationRepositories call
+const maxOrgRepositoryPages = 10
+
+// ListOrganizationRepositories returns the full names of an organization's
+// repositories that are not archived
+func (c *Client) ListOrganizationRepositories(ctx context.Context, org string) ([]string, error) {
+	var names []string
+	perPage := 100 // GitHub's maximum per page
+
+	for page := 1; page <= maxOrgRepositoryPages; page++ {
+		url := fmt.Sprintf("%s/orgs/%s/repos?type=all&sort=full_name&per_page=%d&page=%d", baseURL, org, perPage, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+
+		c.setHeaders(req)
+		resp, err := c.doRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, apperrors.NewNotFoundError("organization", org)
+		}
+		if resp.StatusCode != http.StatusOK {
+			apiErr := responseError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+		}
+
+		var repos []struct {
+			FullName string `json:"full_name"`
+			Archived bool   `json:"archived"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&repos)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+
+		for _, repo := range repos {
+			if !repo.Archived {
+				names = append(names, repo.FullName)
+			}
+		}
+
+		if len(repos) < perPage {
+			break
+		}
+	}
+
+	return names, nil
+}
+
+// maxUserRepositoryPages bounds the number of pages fetched by a single
+// ListUserRepositories call
+const maxUserRepositoryPages = 10
+
+// ListUserRepositories returns the public repositories a user owns, with the
+// details discovery filters them by
+func (c *Client) ListUserRepositories(ctx context.Context, username string) ([]models.UserRepository, error) {
+	var repositories []models.UserRepository
+	perPage := 100 // GitHub's maximum per page
+
+	for page := 1; page <= maxUserRepositoryPages; page++ {
+		url := fmt.Sprintf("%s/users/%s/repos?type=owner&sort=full_name&per_page=%d&page=%d", baseURL, username, perPage, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+
+		c.setHeaders(req)
+		resp, err := c.doRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, apperrors.NewNotFoundError("user", username)
+		}
+		if resp.StatusCode != http.StatusOK {
+			apiErr := responseError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+		}
+
+		var pageRepositories []models.UserRepository
+		err = json.NewDecoder(resp.Body).Decode(&pageRepositories)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+		repositories = append(repositories, pageRepositories...)
+
+		if len(pageRepositories) < perPage {
+			break
+		}
+	}
+
+	return repositories, nil
+}
+
+// maxReleasePages bounds the number of pages fetched by a single ListTags or
+// ListReleases call
+const maxReleasePages = 10
+
+// ListTags returns a repository's tags with the commits they point at
+func (c *Client) ListTags(ctx context.Context, owner, repo string) ([]models.Tag, error) {
+	var tags []models.Tag
+	perPage := 100 // GitHub's maximum per page
+
+	for page := 1; page <= maxReleasePages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/tags?per_page=%d&page=%d", baseURL, owner, repo, perPage, page)
+		var pageTags []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				SHA string `json:"sha"`
+			} `json:"commit"`
+		}
+		if err := c.getJSON(ctx, url, &pageTags); err != nil {
+			return nil, err
+		}
+
+		for _, t := range pageTags {
+			tags = append(tags, models.Tag{Name: t.Name, CommitSHA: t.Commit.SHA})
+		}
+
+		if len(pageTags) < perPage {
+			break
+		}
+	}
+
+	return tags, nil
+}
+
+// releaseResponse represents the GitHub release response
+type releaseResponse struct {
+	ID          int64      `json:"id"`
+	TagName     string     `json:"tag_name"`
+	Name        string     `json:"name"`
+	Draft       bool       `json:"draft"`
+	Prerelease  bool       `json:"prerelease"`
+	HTMLURL     string     `json:"html_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	Author      struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// ListReleases returns a repository's releases, newest first. Drafts are only
+// included when the token can push to the repository.
+func (c *Client) ListReleases(ctx context.Context, owner, repo string) ([]models.Release, error) {
+	var releases []models.Release
+	perPage := 100 // GitHub's maximum per page
+
+	for page := 1; page <= maxReleasePages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d&page=%d", baseURL, owner, repo, perPage, page)
+		var pageReleases []releaseResponse
+		if err := c.getJSON(ctx, url, &pageReleases); err != nil {
+			return nil, err
+		}
+
+		for _, r := range pageReleases {
+			releases = append(releases, models.Release{
+				GitHubID:    r.ID,
+				TagName:     r.TagName,
+				Name:        r.Name,
+				Draft:       r.Draft,
+				Prerelease:  r.Prerelease,
+				AuthorLogin: r.Author.Login,
+				URL:         r.HTMLURL,
+				CreatedAt:   r.CreatedAt,
+				PublishedAt: r.PublishedAt,
+			})
+		}
+
+		if len(pageReleases) < perPage {
+			break
+		}
+	}
 
-	return allCommits, nil
+	return releases, nil
+}
+
+// ErrStatsPending is returned when GitHub is still computing the statistics of
+// a repository after all retries; they are usually ready on the next request
+var ErrStatsPending = errors.New("GitHub is still computing the repository statistics")
+
+// contributorStatsAttempts bounds the requests made while GitHub answers with
+// 202 Accepted, waiting contributorStatsDelay before the first retry and twice
+// as long before each further one
+var (
+	contributorStatsAttempts = 5
+	contributorStatsDelay    = 2 * time.Second
+)
+
+// contributorStatsResponse is a contributor of GitHub's contributor stats
+type contributorStatsResponse struct {
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Weeks []struct {
+		Week      int64 `json:"w"`
+		Additions int   `json:"a"`
+		Deletions int   `json:"d"`
+		Commits   int   `json:"c"`
+	} `json:"weeks"`
+}
+
+// GetContributorStats returns the weekly additions, deletions and commits of the
+// top 100 contributors of a repository, leaving out weeks without activity.
+// GitHub computes these statistics in the background and answers 202 Accepted
+// until they are ready, so the request is retried with backoff, returning
+// ErrStatsPending if they are still not ready. Contributors whose account was
+// deleted are skipped.
+func (c *Client) GetContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/stats/contributors", baseURL, owner, repo)
+	delay := contributorStatsDelay
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		c.setHeaders(req)
+		resp, err := c.doRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			var contributors []contributorStatsResponse
+			err := json.NewDecoder(resp.Body).Decode(&contributors)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("decoding response: %w", err)
+			}
+			return toContributorStats(contributors), nil
+		case http.StatusNoContent:
+			// An empty repository has no contributors
+			resp.Body.Close()
+			return nil, nil
+		case http.StatusAccepted:
+			resp.Body.Close()
+		default:
+			apiErr := responseError(resp)
+			resp.Body.Close()
+			return nil, apiErr
+		}
+
+		if attempt == contributorStatsAttempts {
+			return nil, ErrStatsPending
+		}
+		c.logger.Debug().Str("owner", owner).Str("repo", repo).Dur("retry_in", delay).Msg("Waiting for GitHub to compute contributor stats")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// toContributorStats converts GitHub's contributor stats, keeping the weeks with activity
+func toContributorStats(contributors []contributorStatsResponse) []models.ContributorStats {
+	var stats []models.ContributorStats
+	for _, c := range contributors {
+		if c.Author == nil {
+			continue
+		}
+		contributor := models.ContributorStats{Login: c.Author.Login}
+		for _, w := range c.Weeks {
+			if w.Commits == 0 && w.Additions == 0 && w.Deletions == 0 {
+				continue
+			}
+			contributor.Weeks = append(contributor.Weeks, models.ContributorWeek{
+				Week:      time.Unix(w.Week, 0).UTC(),
+				Commits:   w.Commits,
+				Additions: w.Additions,
+				Deletions: w.Deletions,
+			})
+			contributor.Commits += w.Commits
+			contributor.Additions += w.Additions
+			contributor.Deletions += w.Deletions
+		}
+		stats = append(stats, contributor)
+	}
+	return stats
+}
+
+// fileContentResponse is a file returned by GitHub's contents API
+type fileContentResponse struct {
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// GetFileContent returns the content of a file on the default branch of a
+// repository, or nil when the file doesn't exist. Files over 1 MB, which the
+// contents API doesn't inline, are reported as an error.
+func (c *Client) GetFileContent(ctx context.Context, owner, repo, filePath string) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", baseURL, owner, repo, filePath)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	c.setHeaders(req)
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, responseError(resp)
+	}
+
+	var file fileContentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if file.Type != "file" {
+		return nil, fmt.Errorf("%s is a %s, not a file", filePath, file.Type)
+	}
+	if file.Encoding != "base64" {
+		return nil, fmt.Errorf("%s has unsupported encoding %q", filePath, file.Encoding)
+	}
+	// GitHub wraps the encoded content in lines of 60 characters
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("decoding content: %w", err)
+	}
+	return content, nil
+}
+
+// getJSON requests a GitHub API URL and decodes its JSON response into v
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	c.setHeaders(req)
+	resp, err := c.doRequest(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// FileExtension returns the lower-cased extension of a file name without the
+// leading dot, or an empty string when the file has none
+func FileExtension(filename string) string {
+	ext := path.Ext(path.Base(filename))
+	if ext == "" || ext == path.Base(filename) {
+		return "" // e.g. Makefile or .gitignore
+	}
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
 }
 
 // setHeaders sets the required headers for GitHub API requests
@@ -2,11 +2,19 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	apperrors "github-service/internal/errors"
+	"github-service/internal/models"
 )
 
 func TestGetRepository(t *testing.T) {
@@ -146,6 +154,102 @@ func TestGetRepository(t *testing.T) {
 	})
 }
 
+func TestGetRepositoryErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		case "/repos/owner/private":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Bad credentials"}`))
+		default:
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	_, err := client.GetRepository(ctx, "owner", "missing")
+	var apiErr *apperrors.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Body != `{"message": "Not Found"}` {
+		t.Fatalf("Expected a 404 APIError with the body, got %v", err)
+	}
+	if !errors.Is(err, apperrors.ErrUpstreamNotFound) || errors.Is(err, apperrors.ErrUpstreamUnauthorized) {
+		t.Errorf("Expected the 404 to match ErrUpstreamNotFound only")
+	}
+
+	if _, err := client.GetRepository(ctx, "owner", "private"); !errors.Is(err, apperrors.ErrUpstreamUnauthorized) {
+		t.Errorf("Expected the 401 to match ErrUpstreamUnauthorized, got %v", err)
+	}
+
+	// The wait is beyond maxRateLimitWait, so the request isn't retried
+	_, err = client.GetRepository(ctx, "owner", "limited")
+	if !errors.Is(err, apperrors.ErrRateLimit) || !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Errorf("Expected a rate limit error asking to wait an hour, got %v", err)
+	}
+}
+
+func TestGetRepositoryDeduplication(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1, "name": "repo", "full_name": "owner/repo"}`))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+
+	const callers = 5
+	repos := make([]*models.Repository, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			repo, err := client.GetRepository(context.Background(), "owner", "repo")
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+			repos[i] = repo
+		}(i)
+	}
+
+	// Hold the GitHub call until every caller has joined it
+	for client.RepositoryDedupStats().Requests < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if hits.Load() != 1 {
+		t.Errorf("Expected 1 GitHub request, got %d", hits.Load())
+	}
+	stats := client.RepositoryDedupStats()
+	if stats.Requests != callers || stats.Deduplicated != callers-1 {
+		t.Errorf("Expected %d requests sharing one call, got %+v", callers, stats)
+	}
+	if repos[0] == repos[1] {
+		t.Error("Expected each caller to get its own copy of the repository")
+	}
+}
+
 func TestGetCommits(t *testing.T) {
 	t.Run("successful request", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -240,6 +344,374 @@ func TestGetCommits(t *testing.T) {
 	})
 }
 
+func TestForEachCommitPage(t *testing.T) {
+	// Two full pages followed by a short last page
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		count := 100
+		if page == 3 {
+			count = 5
+		}
+
+		commits := make([]string, count)
+		for i := range commits {
+			commits[i] = fmt.Sprintf(`{"sha": "p%dc%d"}`, page, i)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("[" + strings.Join(commits, ",") + "]"))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		startPage    int
+		maxPages     int
+		stopAfter    int // Pages after which fn stops paging, 0 to never stop
+		wantRequests int
+		wantCommits  int
+	}{
+		{name: "until short page", maxPages: 10, wantRequests: 3, wantCommits: 205},
+		{name: "from start page", startPage: 2, maxPages: 10, wantRequests: 2, wantCommits: 105},
+		{name: "start page counts toward budget", startPage: 2, maxPages: 2, wantRequests: 1, wantCommits: 100},
+		{name: "page budget", maxPages: 2, wantRequests: 2, wantCommits: 200},
+		{name: "early exit", maxPages: 10, stopAfter: 1, wantRequests: 1, wantCommits: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			pages, commits := 0, 0
+			err := client.ForEachCommitPage(ctx, "owner", "repo", time.Time{}, tt.startPage, tt.maxPages, func(page []models.CommitResponse) (bool, error) {
+				pages++
+				commits += len(page)
+				return tt.stopAfter == 0 || pages < tt.stopAfter, nil
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, requests)
+			}
+			if commits != tt.wantCommits {
+				t.Errorf("Expected %d commits, got %d", tt.wantCommits, commits)
+			}
+		})
+	}
+}
+
+func TestListOrganizationRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orgs/missing/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{"full_name": "org/a"}, {"full_name": "org/old", "archived": true}, {"full_name": "org/b"}]`))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	names, err := client.ListOrganizationRepositories(ctx, "org")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(names, ",") != "org/a,org/b" {
+		t.Errorf("Expected unarchived repositories org/a and org/b, got %v", names)
+	}
+
+	if _, err := client.ListOrganizationRepositories(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "organization not found") {
+		t.Errorf("Expected organization not found error, got %v", err)
+	}
+}
+
+func TestListUserRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/missing/repos" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("type") != "owner" {
+			t.Errorf("Expected repositories the user owns, got type %q", r.URL.Query().Get("type"))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{"full_name": "octocat/tool", "language": "Go", "stargazers_count": 12, "pushed_at": "2024-05-01T00:00:00Z"},
+			{"full_name": "octocat/fork", "fork": true, "archived": true}
+		]`))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	repos, err := client.ListUserRepositories(ctx, "octocat")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("Expected 2 repositories, got %d", len(repos))
+	}
+	if repos[0].StarsCount != 12 || repos[0].Language != "Go" || !repos[0].PushedAt.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected repository details %+v", repos[0])
+	}
+	if !repos[1].Fork || !repos[1].Archived {
+		t.Errorf("Expected a fork that is archived, got %+v", repos[1])
+	}
+
+	if _, err := client.ListUserRepositories(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "user not found") {
+		t.Errorf("Expected user not found error, got %v", err)
+	}
+}
+
+func TestListTagsAndReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		switch r.URL.Path {
+		case "/repos/owner/repo/tags":
+			w.Write([]byte(`[{"name": "v1.1.0", "commit": {"sha": "bbb"}}, {"name": "v1.0.0", "commit": {"sha": "aaa"}}]`))
+		case "/repos/owner/repo/releases":
+			w.Write([]byte(`[
+				{"id": 2, "tag_name": "v1.1.0", "name": "Next", "draft": true, "created_at": "2024-02-01T00:00:00Z", "published_at": null},
+				{"id": 1, "tag_name": "v1.0.0", "name": "First", "prerelease": true, "html_url": "https://github.com/owner/repo/releases/v1.0.0",
+				 "created_at": "2024-01-01T00:00:00Z", "published_at": "2024-01-02T00:00:00Z", "author": {"login": "octocat"}}
+			]`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	tags, err := client.ListTags(ctx, "owner", "repo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tags) != 2 || tags[1] != (models.Tag{Name: "v1.0.0", CommitSHA: "aaa"}) {
+		t.Errorf("Unexpected tags: %+v", tags)
+	}
+
+	releases, err := client.ListReleases(ctx, "owner", "repo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("Expected 2 releases, got %d", len(releases))
+	}
+	if !releases[0].Draft || releases[0].PublishedAt != nil {
+		t.Errorf("Expected an unpublished draft, got %+v", releases[0])
+	}
+	first := releases[1]
+	if first.GitHubID != 1 || first.TagName != "v1.0.0" || !first.Prerelease || first.AuthorLogin != "octocat" ||
+		first.PublishedAt == nil || !first.PublishedAt.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected release: %+v", first)
+	}
+}
+
+func TestGetContributorStats(t *testing.T) {
+	defer func(delay time.Duration) { contributorStatsDelay = delay }(contributorStatsDelay)
+	contributorStatsDelay = time.Millisecond
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/repos/owner/computing/stats/contributors":
+			// GitHub accepts the request until the stats are computed
+			if requests < 3 {
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[
+				{"author": {"login": "ann"}, "total": 3, "weeks": [
+					{"w": 1704585600, "a": 0, "d": 0, "c": 0},
+					{"w": 1705190400, "a": 10, "d": 2, "c": 3}
+				]},
+				{"author": null, "total": 1, "weeks": [{"w": 1704585600, "a": 1, "d": 1, "c": 1}]}
+			]`))
+		case "/repos/owner/pending/stats/contributors":
+			w.WriteHeader(http.StatusAccepted)
+		case "/repos/owner/empty/stats/contributors":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	stats, err := client.GetContributorStats(ctx, "owner", "computing")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests)
+	}
+	if len(stats) != 1 || stats[0].Login != "ann" {
+		t.Fatalf("Expected only the contributor with an account, got %+v", stats)
+	}
+	if len(stats[0].Weeks) != 1 || stats[0].Commits != 3 || stats[0].Additions != 10 || stats[0].Deletions != 2 {
+		t.Errorf("Expected the single active week to be kept and totalled, got %+v", stats[0])
+	}
+	if week := stats[0].Weeks[0].Week; !week.Equal(time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the week of 2024-01-14, got %v", week)
+	}
+
+	requests = 0
+	if _, err := client.GetContributorStats(ctx, "owner", "pending"); !errors.Is(err, ErrStatsPending) {
+		t.Errorf("Expected ErrStatsPending, got %v", err)
+	}
+	if requests != contributorStatsAttempts {
+		t.Errorf("Expected %d requests, got %d", contributorStatsAttempts, requests)
+	}
+
+	if stats, err := client.GetContributorStats(ctx, "owner", "empty"); err != nil || stats != nil {
+		t.Errorf("Expected no stats for an empty repository, got %v, %v", stats, err)
+	}
+}
+
+func TestGetFileContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/contents/go.mod":
+			w.WriteHeader(http.StatusOK)
+			// "module example.com/m\n" wrapped like GitHub wraps long content
+			w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "bW9kdWxlIGV4YW1w\nbGUuY29tL20K\n"}`))
+		case "/repos/owner/repo/contents/docs":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	ctx := context.Background()
+
+	content, err := client.GetFileContent(ctx, "owner", "repo", "go.mod")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(content) != "module example.com/m\n" {
+		t.Errorf("Expected the decoded file, got %q", content)
+	}
+
+	if content, err := client.GetFileContent(ctx, "owner", "repo", "package.json"); err != nil || content != nil {
+		t.Errorf("Expected no content for a missing file, got %q, %v", content, err)
+	}
+
+	if _, err := client.GetFileContent(ctx, "owner", "repo", "docs"); err == nil {
+		t.Error("Expected an error for a directory")
+	}
+}
+
+func TestTokenExpiration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("GitHub-Authentication-Token-Expiration", "2030-06-30 12:00:00 UTC")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := &Client{
+		httpClient: server.Client(),
+		token:      "test-token",
+	}
+	if !client.TokenExpiration().IsZero() {
+		t.Fatalf("Expected no expiration before the first response, got %v", client.TokenExpiration())
+	}
+
+	if _, err := client.GetRepository(context.Background(), "owner", "repo"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := time.Date(2030, 6, 30, 12, 0, 0, 0, time.UTC)
+	if !client.TokenExpiration().Equal(expected) {
+		t.Errorf("Expected expiration %v, got %v", expected, client.TokenExpiration())
+	}
+}
+
+func TestMultiTokenRotation(t *testing.T) {
+	remaining := map[string]int{"ghp_one_1111": 10, "ghp_two_2222": 500, "ghp_three_3333": 0}
+	var mu sync.Mutex
+	var used []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		mu.Lock()
+		used = append(used, token)
+		mu.Unlock()
+
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[token]))
+		if remaining[token] == 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": 1, "full_name": "owner/repo"}`))
+	}))
+	defer server.Close()
+	baseURL = server.URL
+
+	client := NewMultiTokenClient([]string{"ghp_one_1111", "ghp_two_2222", "ghp_three_3333"})
+	client.httpClient = server.Client()
+	ctx := context.Background()
+
+	// Tokens are tried until GitHub has reported the limits of all of them;
+	// the exhausted one is retried with another token
+	for i := 0; i < 4; i++ {
+		if _, err := client.GetRepository(ctx, "owner", fmt.Sprintf("repo%d", i)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if got := used[len(used)-1]; got != "ghp_two_2222" {
+		t.Errorf("Expected the token with the most remaining requests to be used, got %q (all: %v)", got, used)
+	}
+
+	info := client.GetRateLimitInfo()
+	if info.Remaining != 500 {
+		t.Errorf("Expected the rate limit of the best token, got %+v", info)
+	}
+	limits := client.TokenRateLimits()
+	if len(limits) != 3 || limits[1].Token != "****2222" || limits[1].Remaining != 500 || !limits[1].Known {
+		t.Errorf("Unexpected token rate limits: %+v", limits)
+	}
+}
+
 func TestRateLimitHandling(t *testing.T) {
 	t.Run("rate limit info update", func(t *testing.T) {
 		resetTime := time.Now().Add(time.Hour)
@@ -315,3 +787,104 @@ func TestRateLimitHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestSecondaryRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		reject func(w http.ResponseWriter)
+	}{
+		{"429 with Retry-After", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}},
+		{"403 with Retry-After", func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= 2 {
+					tt.reject(w)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"id": 1}`))
+			}))
+			defer server.Close()
+
+			client := &Client{httpClient: server.Client(), token: "test-token"}
+			baseURL = server.URL
+
+			if _, err := client.GetRepository(context.Background(), "owner", "repo"); err != nil {
+				t.Fatalf("Expected the request to be retried, got %v", err)
+			}
+			if got := requests.Load(); got != 3 {
+				t.Errorf("Expected 3 requests, got %d", got)
+			}
+		})
+	}
+
+	t.Run("gives up after retries", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client := &Client{httpClient: server.Client(), token: "test-token"}
+		baseURL = server.URL
+
+		if _, err := client.GetRepository(context.Background(), "owner", "repo"); err == nil {
+			t.Fatal("Expected a rate limit error, got nil")
+		}
+		if got := requests.Load(); got != maxRateLimitRetries+1 {
+			t.Errorf("Expected %d requests, got %d", maxRateLimitRetries+1, got)
+		}
+	})
+
+	t.Run("permission denied is not retried", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		}))
+		defer server.Close()
+
+		client := &Client{httpClient: server.Client(), token: "test-token"}
+		baseURL = server.URL
+
+		client.GetRepository(context.Background(), "owner", "repo")
+		if got := requests.Load(); got != 1 {
+			t.Errorf("Expected 1 request, got %d", got)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"30", 30 * time.Second, true},
+		{"Sat, 01 Jun 2024 12:01:00 GMT", time.Minute, true},
+		{"Sat, 01 Jun 2024 11:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// OAuthClient logs people in with their GitHub account through the web flow of
+// a GitHub OAuth app
+type OAuthClient struct {
+	webURL       string
+	apiURL       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	httpClient   *http.Client
+}
+
+// NewOAuthClient creates a client for the OAuth app with the given credentials.
+// webURL is the GitHub web root, e.g. https://github.com, and redirectURL the
+// callback registered with the app.
+func NewOAuthClient(webURL, clientID, clientSecret, redirectURL string) *OAuthClient {
+	webURL = strings.TrimSuffix(webURL, "/")
+	apiURL := baseURL
+	if webURL != "https://github.com" {
+		apiURL = webURL + "/api/v3" // GitHub Enterprise Server
+	}
+	return &OAuthClient{
+		webURL:       webURL,
+		apiURL:       apiURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetHTTPClient replaces the client used for OAuth requests
+func (o *OAuthClient) SetHTTPClient(httpClient *http.Client) {
+	o.httpClient = httpClient
+}
+
+// AuthorizeURL returns the GitHub page asking the person to authorize the app.
+// GitHub redirects back with state, which must match to prevent CSRF.
+func (o *OAuthClient) AuthorizeURL(state string) string {
+	params := url.Values{
+		"client_id":    {o.clientID},
+		"redirect_uri": {o.redirectURL},
+		"state":        {state},
+		"allow_signup": {"false"},
+	}
+	return o.webURL + "/login/oauth/authorize?" + params.Encode()
+}
+
+// Exchange trades the code GitHub redirected back with for an access token
+func (o *OAuthClient) Exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"client_id":     {o.clientID},
+		"client_secret": {o.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {o.redirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", o.webURL+"/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", responseError(resp)
+	}
+
+	// GitHub reports a bad code with 200 and an error
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("no access token in response")
+	}
+	return token.AccessToken, nil
+}
+
+// GetUser returns the GitHub user an access token belongs to
+func (o *OAuthClient) GetUser(ctx context.Context, token string) (*models.GitHubUser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.apiURL+"/user", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var user models.GitHubUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if user.ID == 0 || user.Login == "" {
+		return nil, fmt.Errorf("incomplete user in response")
+	}
+	return &user, nil
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOAuthClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			if err := r.ParseForm(); err != nil {
+				t.Fatalf("Failed to parse form: %v", err)
+			}
+			if r.Form.Get("client_secret") != "secret" {
+				t.Errorf("Expected the client secret, got %q", r.Form.Get("client_secret"))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if r.Form.Get("code") != "good" {
+				w.Write([]byte(`{"error": "bad_verification_code", "error_description": "The code passed is incorrect or expired."}`))
+				return
+			}
+			w.Write([]byte(`{"access_token": "gho_token", "token_type": "bearer"}`))
+		case "/api/v3/user": // The API of a GitHub Enterprise Server at the web root
+			if r.Header.Get("Authorization") != "Bearer gho_token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id": 42, "login": "octocat"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOAuthClient(server.URL+"/", "client", "secret", "https://service.example.com/auth/github/callback")
+	client.SetHTTPClient(server.Client())
+	ctx := context.Background()
+
+	authorize, err := url.Parse(client.AuthorizeURL("xyz"))
+	if err != nil {
+		t.Fatalf("Expected a valid URL, got %v", err)
+	}
+	if authorize.Path != "/login/oauth/authorize" || authorize.Query().Get("state") != "xyz" || authorize.Query().Get("client_id") != "client" {
+		t.Errorf("Unexpected authorize URL %s", authorize)
+	}
+
+	token, err := client.Exchange(ctx, "good")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token != "gho_token" {
+		t.Errorf("Expected gho_token, got %q", token)
+	}
+	if _, err := client.Exchange(ctx, "stale"); err == nil || !strings.Contains(err.Error(), "bad_verification_code") {
+		t.Errorf("Expected the OAuth error, got %v", err)
+	}
+
+	user, err := client.GetUser(ctx, token)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.ID != 42 || user.Login != "octocat" {
+		t.Errorf("Expected octocat, got %+v", user)
+	}
+	if _, err := client.GetUser(ctx, "revoked"); err == nil {
+		t.Error("Expected an error for a rejected token")
+	}
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRateLimitRetries is how many times a rate limited request is sent again
+const maxRateLimitRetries = 3
+
+// maxRateLimitWait is the longest a request waits out a rate limit; beyond
+// that it fails, e.g. when the primary limit resets in an hour
+const maxRateLimitWait = 5 * time.Minute
+
+// secondaryRateLimitWait is how long to back off from a secondary rate limit
+// that doesn't say when to retry, as GitHub recommends
+const secondaryRateLimitWait = time.Minute
+
+// rateLimitWait reports whether a response was rejected by a rate limit and how
+// long to wait before retrying. GitHub signals its primary limit with a 403 or
+// 429 and no remaining requests, and its secondary (abuse) limits with a 403 or
+// 429 that usually carries a Retry-After header.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		return wait, true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0)), 0), true
+		}
+		return secondaryRateLimitWait, true
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp) {
+		return secondaryRateLimitWait, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header, either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isSecondaryRateLimit reports whether a 403 without rate limit headers is a
+// secondary rate limit, which GitHub only states in the message. The body is
+// put back so a 403 for another reason can still be read by the caller.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+// withJitter adds up to a tenth of wait plus a quarter second, so clients
+// limited together don't all retry at the same moment
+func withJitter(wait time.Duration) time.Duration {
+	return wait + time.Duration(rand.Int63n(int64(wait/10+250*time.Millisecond)))
+}
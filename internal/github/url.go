@@ -0,0 +1,58 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// namePattern matches valid GitHub owner and repository names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// scpLikePattern matches scp-style SSH clone URLs such as git@github.com:owner/repo.git
+var scpLikePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+@([A-Za-z0-9_.-]+):(.+)$`)
+
+// ParseRepositoryURL extracts the owner and repository name from a GitHub
+// repository reference. It accepts web and clone URLs, e.g.
+// https://github.com/owner/repo, git@github.com:owner/repo.git and
+// ssh://git@github.com/owner/repo.git, as well as a plain owner/repo.
+func ParseRepositoryURL(raw string) (owner, repo string, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", "", fmt.Errorf("repository URL is empty")
+	}
+
+	var host, path string
+	switch {
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid repository URL %q: %w", raw, err)
+		}
+		host, path = u.Hostname(), u.Path
+	case scpLikePattern.MatchString(raw):
+		matches := scpLikePattern.FindStringSubmatch(raw)
+		host, path = matches[1], matches[2]
+	case strings.HasPrefix(raw, "github.com/") || strings.HasPrefix(raw, "www.github.com/"):
+		host, path, _ = strings.Cut(raw, "/")
+	default:
+		path = raw
+	}
+
+	if host != "" && !strings.EqualFold(strings.TrimPrefix(host, "www."), "github.com") {
+		return "", "", fmt.Errorf("unsupported repository host %q", host)
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || (host == "" && len(parts) != 2) {
+		return "", "", fmt.Errorf("repository URL %q does not contain owner/repo", raw)
+	}
+
+	owner, repo = parts[0], strings.TrimSuffix(parts[1], ".git")
+	if !namePattern.MatchString(owner) || !namePattern.MatchString(repo) {
+		return "", "", fmt.Errorf("invalid repository name in %q", raw)
+	}
+
+	return owner, repo, nil
+}
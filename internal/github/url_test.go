@@ -0,0 +1,41 @@
+package github
+
+import "testing"
+
+func TestParseRepositoryURL(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{input: "https://github.com/owner/repo", wantOwner: "owner", wantRepo: "repo"},
+		{input: "https://github.com/owner/repo.git", wantOwner: "owner", wantRepo: "repo"},
+		{input: "https://www.github.com/owner/repo/tree/main/docs", wantOwner: "owner", wantRepo: "repo"},
+		{input: "http://github.com/owner/repo/", wantOwner: "owner", wantRepo: "repo"},
+		{input: "git@github.com:owner/repo.git", wantOwner: "owner", wantRepo: "repo"},
+		{input: "ssh://git@github.com/owner/repo.git", wantOwner: "owner", wantRepo: "repo"},
+		{input: "github.com/owner/repo", wantOwner: "owner", wantRepo: "repo"},
+		{input: "owner/repo", wantOwner: "owner", wantRepo: "repo"},
+		{input: "  owner/my.repo-name  ", wantOwner: "owner", wantRepo: "my.repo-name"},
+		{input: "", wantErr: true},
+		{input: "owner", wantErr: true},
+		{input: "https://github.com/owner", wantErr: true},
+		{input: "https://gitlab.com/owner/repo", wantErr: true},
+		{input: "git@bitbucket.org:owner/repo.git", wantErr: true},
+		{input: "owner/repo/extra", wantErr: true},
+		{input: "https://github.com/own er/repo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			owner, repo, err := ParseRepositoryURL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRepositoryURL(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo {
+				t.Errorf("ParseRepositoryURL(%q) = %s/%s, want %s/%s", tt.input, owner, repo, tt.wantOwner, tt.wantRepo)
+			}
+		})
+	}
+}
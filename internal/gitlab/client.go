@@ -0,0 +1,263 @@
+// Package gitlab fetches projects and commits from the GitLab REST API, so
+// repositories hosted on GitLab can be monitored like those on GitHub
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github-service/internal/errors"
+	"github-service/internal/github"
+	"github-service/internal/models"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// DefaultBaseURL is the API of gitlab.com
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// perPage is the page size of list requests, GitLab's maximum
+const perPage = 100
+
+// Client handles interactions with the GitLab API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a GitLab API client for the API at baseURL, e.g. DefaultBaseURL
+// or https://gitlab.example.com/api/v4 for a self-managed instance. The token may
+// be empty for public projects.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+	}
+}
+
+// SetHTTPClient replaces the client used for GitLab requests, e.g. to send them
+// through a proxy or trust the instance's certificate authority
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// project represents the GitLab project response
+type project struct {
+	ID                int64     `json:"id"`
+	Path              string    `json:"path"`
+	PathWithNamespace string    `json:"path_with_namespace"`
+	Description       string    `json:"description"`
+	WebURL            string    `json:"web_url"`
+	ForksCount        int       `json:"forks_count"`
+	StarCount         int       `json:"star_count"`
+	OpenIssuesCount   int       `json:"open_issues_count"`
+	CreatedAt         time.Time `json:"created_at"`
+	LastActivityAt    time.Time `json:"last_activity_at"`
+}
+
+// commit represents the GitLab commit response
+type commit struct {
+	ID             string    `json:"id"`
+	Message        string    `json:"message"`
+	AuthorName     string    `json:"author_name"`
+	AuthorEmail    string    `json:"author_email"`
+	AuthoredDate   time.Time `json:"authored_date"`
+	CommitterName  string    `json:"committer_name"`
+	CommitterEmail string    `json:"committer_email"`
+	CommittedDate  time.Time `json:"committed_date"`
+	WebURL         string    `json:"web_url"`
+	Stats          *struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+}
+
+// diff represents one file of the GitLab commit diff response
+type diff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+// projectPath returns the URL-encoded project path GitLab accepts in place of its ID
+func projectPath(owner, repo string) string {
+	return url.PathEscape(owner + "/" + repo)
+}
+
+// GetRepository fetches a project's information. Stars are reported as
+// stargazers and GitLab has no watchers or primary language, so those stay empty.
+func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*models.Repository, error) {
+	var p project
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/projects/%s", c.baseURL, projectPath(owner, repo)), &p); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &models.Repository{
+		GitHubID:        p.ID,
+		Provider:        models.ProviderGitLab,
+		Name:            p.Path,
+		FullName:        p.PathWithNamespace,
+		Description:     p.Description,
+		URL:             p.WebURL,
+		ForksCount:      p.ForksCount,
+		StarsCount:      p.StarCount,
+		OpenIssuesCount: p.OpenIssuesCount,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.LastActivityAt,
+		LastCommitCheck: &now,
+		CreatedAtLocal:  now,
+		UpdatedAtLocal:  now,
+	}, nil
+}
+
+// ForEachCommitPage fetches the commits of a project's default branch made since
+// a time, newest first, and calls fn with each page from startPage on until fn
+// returns false, the last page is reached or page maxPages has been fetched
+func (c *Client) ForEachCommitPage(ctx context.Context, owner, repo string, since time.Time, startPage, maxPages int, fn func(page []models.CommitResponse) (bool, error)) error {
+	for page := max(startPage, 1); page <= maxPages; page++ {
+		query := url.Values{}
+		if !since.IsZero() {
+			query.Set("since", since.Format(time.RFC3339))
+		}
+		query.Set("per_page", fmt.Sprint(perPage))
+		query.Set("page", fmt.Sprint(page))
+
+		var commits []commit
+		endpoint := fmt.Sprintf("%s/projects/%s/repository/commits?%s", c.baseURL, projectPath(owner, repo), query.Encode())
+		if err := c.getJSON(ctx, endpoint, &commits); err != nil {
+			return err
+		}
+
+		more, err := fn(toModelCommits(commits))
+		if err != nil {
+			return err
+		}
+		if !more || len(commits) < perPage {
+			return nil
+		}
+	}
+	return nil
+}
+
+// toModelCommits converts GitLab commits to the shape of GitHub's commit list
+func toModelCommits(commits []commit) []models.CommitResponse {
+	result := make([]models.CommitResponse, len(commits))
+	for i, c := range commits {
+		result[i].SHA = c.ID
+		result[i].Commit.Message = c.Message
+		result[i].Commit.Author = models.CommitAuthor{Name: c.AuthorName, Email: c.AuthorEmail, Date: c.AuthoredDate}
+		result[i].Commit.Committer = models.CommitAuthor{Name: c.CommitterName, Email: c.CommitterEmail, Date: c.CommittedDate}
+		result[i].HTMLURL = c.WebURL
+	}
+	return result
+}
+
+// GetCommit fetches a single commit with its diff stats and changed files. GitLab
+// only reports totals, so the lines added and removed per file are counted from the diff.
+func (c *Client) GetCommit(ctx context.Context, owner, repo, sha string) (*models.CommitDetail, error) {
+	base := fmt.Sprintf("%s/projects/%s/repository/commits/%s", c.baseURL, projectPath(owner, repo), url.PathEscape(sha))
+
+	var cm commit
+	if err := c.getJSON(ctx, base, &cm); err != nil {
+		return nil, err
+	}
+
+	detail := &models.CommitDetail{SHA: cm.ID}
+	if cm.Stats != nil {
+		detail.Additions = cm.Stats.Additions
+		detail.Deletions = cm.Stats.Deletions
+	}
+
+	var diffs []diff
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/diff?per_page=%d", base, perPage), &diffs); err != nil {
+		return nil, err
+	}
+	detail.Files = make([]models.CommitFile, 0, len(diffs))
+	for _, d := range diffs {
+		additions, deletions := countDiffLines(d.Diff)
+		detail.Files = append(detail.Files, models.CommitFile{
+			Filename:  d.NewPath,
+			Extension: github.FileExtension(d.NewPath),
+			Status:    diffStatus(d),
+			Additions: additions,
+			Deletions: deletions,
+			Changes:   additions + deletions,
+		})
+	}
+	return detail, nil
+}
+
+// diffStatus maps a GitLab diff to the file statuses GitHub reports
+func diffStatus(d diff) string {
+	switch {
+	case d.NewFile:
+		return "added"
+	case d.DeletedFile:
+		return "removed"
+	case d.RenamedFile:
+		return "renamed"
+	default:
+		return "modified"
+	}
+}
+
+// countDiffLines counts the added and removed lines of a unified diff without file headers
+func countDiffLines(d string) (additions, deletions int) {
+	for _, line := range strings.Split(d, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			additions++
+		case strings.HasPrefix(line, "-"):
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+// getJSON performs a GET request and decodes its JSON response into v
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		apiErr := apperrors.NewAPIError(models.ProviderGitLab, resp.StatusCode, body)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
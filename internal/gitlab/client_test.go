@@ -0,0 +1,146 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+func TestGetRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fproject" {
+			t.Errorf("Expected path '/projects/group%%2Fproject', got '%s'", r.URL.EscapedPath())
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			t.Errorf("Expected PRIVATE-TOKEN header 'token', got '%s'", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		w.Write([]byte(`{
+			"id": 42,
+			"path": "project",
+			"path_with_namespace": "group/project",
+			"web_url": "https://gitlab.com/group/project",
+			"star_count": 7,
+			"forks_count": 2,
+			"created_at": "2024-01-01T00:00:00Z",
+			"last_activity_at": "2024-02-01T00:00:00Z"
+		}`))
+	}))
+	defer server.Close()
+
+	repo, err := NewClient(server.URL, "token").GetRepository(context.Background(), "group", "project")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.GitHubID != 42 || repo.FullName != "group/project" || repo.Name != "project" {
+		t.Errorf("Unexpected repository %+v", repo)
+	}
+	if repo.Provider != models.ProviderGitLab {
+		t.Errorf("Expected provider %q, got %q", models.ProviderGitLab, repo.Provider)
+	}
+	if repo.StarsCount != 7 || repo.ForksCount != 2 {
+		t.Errorf("Expected 7 stars and 2 forks, got %d and %d", repo.StarsCount, repo.ForksCount)
+	}
+}
+
+func TestGetRepositoryNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "").GetRepository(context.Background(), "group", "missing")
+	if err == nil || err.Error() != "unexpected status code: 404" {
+		t.Errorf("Expected 'unexpected status code: 404', got %v", err)
+	}
+	if !apperrors.Is(err, apperrors.ErrUpstreamNotFound) {
+		t.Errorf("Expected the error to match ErrUpstreamNotFound, got %v", err)
+	}
+}
+
+func TestForEachCommitPage(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("since"); got != "2024-01-01T00:00:00Z" {
+			t.Errorf("Expected since '2024-01-01T00:00:00Z', got '%s'", got)
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+
+		// A full first page and a short second one
+		count := perPage
+		if page == "2" {
+			count = 1
+		}
+		w.Write([]byte("["))
+		for i := 0; i < count; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"id": "sha-%s-%d", "message": "msg", "author_name": "Ann", "authored_date": "2024-01-02T00:00:00Z"}`, page, i)
+		}
+		w.Write([]byte("]"))
+	}))
+	defer server.Close()
+
+	var commits []models.CommitResponse
+	err := NewClient(server.URL, "").ForEachCommitPage(context.Background(), "group", "project", since, 1, 5, func(page []models.CommitResponse) (bool, error) {
+		commits = append(commits, page...)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("Expected 2 pages to be fetched, got %v", pages)
+	}
+	if len(commits) != perPage+1 {
+		t.Fatalf("Expected %d commits, got %d", perPage+1, len(commits))
+	}
+	if commits[0].SHA != "sha-1-0" || commits[0].Commit.Author.Name != "Ann" {
+		t.Errorf("Unexpected first commit %+v", commits[0])
+	}
+}
+
+func TestGetCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/group/project/repository/commits/abc":
+			w.Write([]byte(`{"id": "abc", "stats": {"additions": 3, "deletions": 1}}`))
+		case "/projects/group/project/repository/commits/abc/diff":
+			w.Write([]byte(`[
+				{"old_path": "main.go", "new_path": "main.go", "diff": "@@ -1,2 +1,3 @@\n-old\n+new\n+added\n context\n"},
+				{"old_path": "README.md", "new_path": "README.md", "new_file": true, "diff": "@@ -0,0 +1 @@\n+# Title\n"}
+			]`))
+		default:
+			t.Errorf("Unexpected path '%s'", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	detail, err := NewClient(server.URL, "").GetCommit(context.Background(), "group", "project", "abc")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if detail.Additions != 3 || detail.Deletions != 1 {
+		t.Errorf("Expected 3 additions and 1 deletion, got %d and %d", detail.Additions, detail.Deletions)
+	}
+	if len(detail.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(detail.Files))
+	}
+
+	file := detail.Files[0]
+	if file.Filename != "main.go" || file.Status != "modified" || file.Additions != 2 || file.Deletions != 1 || file.Changes != 3 {
+		t.Errorf("Unexpected file %+v", file)
+	}
+	if detail.Files[1].Status != "added" || detail.Files[1].Additions != 1 {
+		t.Errorf("Unexpected file %+v", detail.Files[1])
+	}
+}
@@ -0,0 +1,50 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// namePattern matches valid GitLab group and project paths
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// scpLikePattern matches scp-style SSH clone URLs such as git@gitlab.com:group/project.git
+var scpLikePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+@([A-Za-z0-9_.-]+):(.+)$`)
+
+// ParseProjectURL extracts the group and project name from a GitLab project
+// reference on any host, e.g. https://gitlab.com/group/project,
+// https://gitlab.com/group/project/-/tree/main, git@gitlab.com:group/project.git
+// or a plain group/project. Projects in subgroups are not supported.
+func ParseProjectURL(raw string) (owner, repo string, err error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", "", fmt.Errorf("project URL is empty")
+	}
+
+	path := raw
+	switch {
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid project URL %q: %w", raw, err)
+		}
+		path = u.Path
+	case scpLikePattern.MatchString(raw):
+		path = scpLikePattern.FindStringSubmatch(raw)[2]
+	}
+
+	// Pages of a project, such as its tree or commits, follow a /-/ separator
+	path, _, _ = strings.Cut(strings.Trim(path, "/"), "/-/")
+	parts := strings.Split(strings.TrimSuffix(path, ".git"), "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("project URL %q does not contain group/project", raw)
+	}
+
+	owner, repo = parts[0], parts[1]
+	if !namePattern.MatchString(owner) || !namePattern.MatchString(repo) {
+		return "", "", fmt.Errorf("invalid project name in %q", raw)
+	}
+	return owner, repo, nil
+}
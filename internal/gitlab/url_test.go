@@ -0,0 +1,35 @@
+package gitlab
+
+import "testing"
+
+func TestParseProjectURL(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantOwner string
+		wantRepo  string
+		wantErr   bool
+	}{
+		{input: "https://gitlab.com/group/project", wantOwner: "group", wantRepo: "project"},
+		{input: "https://gitlab.com/group/project.git", wantOwner: "group", wantRepo: "project"},
+		{input: "https://gitlab.example.com/group/project/-/tree/main", wantOwner: "group", wantRepo: "project"},
+		{input: "git@gitlab.com:group/project.git", wantOwner: "group", wantRepo: "project"},
+		{input: "ssh://git@gitlab.com/group/project.git", wantOwner: "group", wantRepo: "project"},
+		{input: "  group/my.project-name  ", wantOwner: "group", wantRepo: "my.project-name"},
+		{input: "", wantErr: true},
+		{input: "group", wantErr: true},
+		{input: "https://gitlab.com/group/subgroup/project", wantErr: true},
+		{input: "https://gitlab.com/gro up/project", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			owner, repo, err := ParseProjectURL(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProjectURL(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo {
+				t.Errorf("ParseProjectURL(%q) = %s/%s, want %s/%s", tt.input, owner, repo, tt.wantOwner, tt.wantRepo)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package httpclient builds the transport of the clients calling GitHub, GitLab
+// and Jira, so an outbound proxy and private certificate authorities are
+// configured once for all of them
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Options configures the outbound transport
+type Options struct {
+	ProxyURL              string        // Proxy for all requests; empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	CAFile                string        // PEM bundle of certificate authorities trusted next to the system ones
+	InsecureSkipVerify    bool          // Skip verifying server certificates
+	DialTimeout           time.Duration // 0 uses the default of 30s
+	TLSHandshakeTimeout   time.Duration // 0 uses the default of 10s
+	ResponseHeaderTimeout time.Duration // 0 waits as long as the client timeout allows
+}
+
+// NewTransport returns a traced transport honouring the options
+func NewTransport(opts Options) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if opts.CAFile != "" || opts.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: opts.InsecureSkipVerify,
+		}
+		if opts.CAFile != "" {
+			pool, err := certPool(opts.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if opts.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+
+	return otelhttp.NewTransport(transport), nil
+}
+
+// certPool returns the system certificate authorities with the ones of the
+// bundle added
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func get(t *testing.T, opts Options, url string) (*http.Response, error) {
+	t.Helper()
+	transport, err := NewTransport(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return (&http.Client{Transport: transport}).Get(url)
+}
+
+func TestNewTransportTrustsCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := get(t, Options{}, server.URL); err == nil {
+		t.Error("expected the self-signed certificate to be rejected without a CA file")
+	}
+
+	resp, err := get(t, Options{CAFile: caFile}, server.URL)
+	if err != nil {
+		t.Fatalf("with CA file: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = get(t, Options{InsecureSkipVerify: true}, server.URL)
+	if err != nil {
+		t.Fatalf("skipping verification: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransportUsesProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	resp, err := get(t, Options{ProxyURL: proxy.URL}, "http://api.example.com/repos/golang/go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if proxied != "http://api.example.com/repos/golang/go" {
+		t.Errorf("proxy received %q, want the absolute request URL", proxied)
+	}
+}
+
+func TestNewTransportRejectsInvalidOptions(t *testing.T) {
+	if _, err := NewTransport(Options{ProxyURL: "proxy:3128"}); err == nil {
+		t.Error("expected error for a proxy URL without scheme")
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("no certificates here"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTransport(Options{CAFile: empty}); err == nil {
+		t.Error("expected error for a CA bundle without certificates")
+	}
+	if _, err := NewTransport(Options{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected error for a missing CA bundle")
+	}
+}
@@ -0,0 +1,103 @@
+// Package jira looks up issues in the Jira REST API, so commits referencing
+// tickets can be reported against the tickets' status
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Client handles interactions with the Jira API
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	email      string
+	token      string
+}
+
+// NewClient creates a Jira API client for the instance at baseURL, e.g.
+// https://example.atlassian.net. With an email the token is sent as a Jira
+// Cloud API token, without one as a Data Center personal access token.
+func NewClient(baseURL, email, token string) *Client {
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		email:   email,
+		token:   token,
+	}
+}
+
+// SetHTTPClient replaces the client used for Jira requests, e.g. to send them
+// through a proxy or trust the instance's certificate authority
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// issue represents the Jira issue response, limited to the requested fields
+type issue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+// GetTicket fetches the summary and status of an issue. It returns nil when the
+// issue doesn't exist or isn't visible to the configured user.
+func (c *Client) GetTicket(ctx context.Context, key string) (*models.Ticket, error) {
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,status", c.baseURL, url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.email != "":
+		req.SetBasicAuth(c.email, c.token)
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("jira rate limit exceeded, retry after %s seconds", resp.Header.Get("Retry-After"))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var i issue
+	if err := json.NewDecoder(resp.Body).Decode(&i); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &models.Ticket{
+		Key:            i.Key,
+		Summary:        i.Fields.Summary,
+		Status:         i.Fields.Status.Name,
+		StatusCategory: i.Fields.Status.StatusCategory.Key,
+	}, nil
+}
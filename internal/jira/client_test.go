@@ -0,0 +1,79 @@
+package jira
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetTicket(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue/PROJ-12" {
+			t.Errorf("Expected path '/rest/api/2/issue/PROJ-12', got '%s'", r.URL.Path)
+		}
+		if r.URL.Query().Get("fields") != "summary,status" {
+			t.Errorf("Expected fields 'summary,status', got '%s'", r.URL.Query().Get("fields"))
+		}
+		if user, token, ok := r.BasicAuth(); !ok || user != "dev@example.com" || token != "token" {
+			t.Errorf("Expected basic auth dev@example.com:token, got %q:%q", user, token)
+		}
+		w.Write([]byte(`{
+			"key": "PROJ-12",
+			"fields": {
+				"summary": "Login fails",
+				"status": {"name": "In Review", "statusCategory": {"key": "indeterminate"}}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	ticket, err := NewClient(server.URL+"/", "dev@example.com", "token").GetTicket(context.Background(), "PROJ-12")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ticket.Key != "PROJ-12" || ticket.Summary != "Login fails" {
+		t.Errorf("Unexpected ticket %+v", ticket)
+	}
+	if ticket.Status != "In Review" || ticket.StatusCategory != "indeterminate" {
+		t.Errorf("Expected status In Review (indeterminate), got %s (%s)", ticket.Status, ticket.StatusCategory)
+	}
+}
+
+func TestGetTicketPersonalAccessToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			t.Errorf("Expected Authorization 'Bearer pat', got '%s'", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"key": "OPS-1", "fields": {"summary": "", "status": {"name": "Done", "statusCategory": {"key": "done"}}}}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "", "pat").GetTicket(context.Background(), "OPS-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestGetTicketNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	ticket, err := NewClient(server.URL, "", "").GetTicket(context.Background(), "PROJ-404")
+	if err != nil || ticket != nil {
+		t.Errorf("Expected no ticket and no error, got %+v, %v", ticket, err)
+	}
+}
+
+func TestGetTicketError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, "", "bad").GetTicket(context.Background(), "PROJ-1")
+	if err == nil || err.Error() != "unexpected status code: 401" {
+		t.Errorf("Expected 'unexpected status code: 401', got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package logbuffer keeps the most recent structured log entries in memory so
+// they can be inspected and tailed through the API.
+package logbuffer
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// subscriberBuffer is the number of entries queued for a subscriber before
+// further entries are dropped for it
+const subscriberBuffer = 256
+
+// Entry is a single structured log entry
+type Entry struct {
+	Level     zerolog.Level
+	Component string
+	Raw       json.RawMessage
+}
+
+// Buffer is an io.Writer for zerolog that retains the last entries in a ring
+// and fans new entries out to subscribers
+type Buffer struct {
+	mu          sync.Mutex
+	entries     []Entry
+	next        int
+	full        bool
+	subscribers map[chan Entry]struct{}
+}
+
+// New creates a Buffer retaining up to size entries
+func New(size int) *Buffer {
+	if size <= 0 {
+		size = 1000
+	}
+	return &Buffer{
+		entries:     make([]Entry, size),
+		subscribers: make(map[chan Entry]struct{}),
+	}
+}
+
+// Write stores a single JSON log entry. zerolog writes each entry in one call.
+func (b *Buffer) Write(p []byte) (int, error) {
+	var fields struct {
+		Level     string `json:"level"`
+		Component string `json:"component"`
+	}
+	if err := json.Unmarshal(p, &fields); err != nil {
+		// Not a structured entry; nothing to index it by
+		return len(p), nil
+	}
+
+	level, err := zerolog.ParseLevel(fields.Level)
+	if err != nil {
+		level = zerolog.NoLevel
+	}
+	raw := make(json.RawMessage, len(p))
+	copy(raw, p)
+	entry := Entry{Level: level, Component: fields.Component, Raw: raw}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- entry:
+		default:
+			// Slow subscriber; drop the entry rather than block logging
+		}
+	}
+	return len(p), nil
+}
+
+// Recent returns the retained entries, oldest first
+func (b *Buffer) Recent() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return append([]Entry(nil), b.entries[:b.next]...)
+	}
+	recent := make([]Entry, 0, len(b.entries))
+	recent = append(recent, b.entries[b.next:]...)
+	return append(recent, b.entries[:b.next]...)
+}
+
+// Subscribe returns a channel receiving entries written from now on, and a
+// function that cancels the subscription
+func (b *Buffer) Subscribe() (<-chan Entry, func()) {
+	ch := make(chan Entry, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, ch)
+	}
+}
+
+// Matches reports whether the entry is at least minLevel and, when component
+// is not empty, was logged by that component
+func (e Entry) Matches(minLevel zerolog.Level, component string) bool {
+	if e.Level < minLevel {
+		return false
+	}
+	return component == "" || e.Component == component
+}
@@ -0,0 +1,47 @@
+package logbuffer
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestBuffer(t *testing.T) {
+	buf := New(2)
+	logger := zerolog.New(buf)
+
+	logger.Info().Str("component", "worker").Msg("first")
+	logger.Warn().Str("component", "database").Msg("second")
+	logger.Error().Str("component", "worker").Msg("third")
+
+	recent := buf.Recent()
+	if len(recent) != 2 {
+		t.Fatalf("got %d entries, want 2", len(recent))
+	}
+	if recent[0].Level != zerolog.WarnLevel || recent[1].Level != zerolog.ErrorLevel {
+		t.Errorf("got levels %v, %v; want oldest first after wrap-around", recent[0].Level, recent[1].Level)
+	}
+
+	if !recent[1].Matches(zerolog.WarnLevel, "worker") {
+		t.Error("expected error entry from worker to match warn/worker")
+	}
+	if recent[0].Matches(zerolog.WarnLevel, "worker") {
+		t.Error("expected database entry not to match component worker")
+	}
+	if recent[0].Matches(zerolog.ErrorLevel, "") {
+		t.Error("expected warn entry not to match minimum level error")
+	}
+
+	entries, unsubscribe := buf.Subscribe()
+	logger.Info().Msg("live")
+	unsubscribe()
+
+	select {
+	case entry := <-entries:
+		if entry.Level != zerolog.InfoLevel {
+			t.Errorf("got level %v, want info", entry.Level)
+		}
+	default:
+		t.Error("expected subscriber to receive the new entry")
+	}
+}
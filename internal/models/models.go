@@ -5,7 +5,8 @@ import "time"
 // Repository represents a GitHub repository
 type Repository struct {
 	ID              int64      `json:"id"`
-	GitHubID        int64      `json:"github_id"`
+	GitHubID        int64      `json:"github_id"` // ID of the repository at its provider
+	Provider        string     `json:"provider"`
 	Name            string     `json:"name"`
 	FullName        string     `json:"full_name"`
 	Description     string     `json:"description"`
@@ -36,14 +37,76 @@ type Commit struct {
 	CommitterEmail string    `json:"committer_email" db:"committer_email"`
 	CommitDate     time.Time `json:"commit_date" db:"commit_date"`
 	URL            string    `json:"url" db:"url"`
+	SyncRunID      *int64    `json:"sync_run_id,omitempty" db:"sync_run_id"` // Run that ingested the commit; only set where requested
+	Additions      *int      `json:"additions,omitempty" db:"additions"`     // Diff stats are only set once the commit has been enriched
+	Deletions      *int      `json:"deletions,omitempty" db:"deletions"`
+	FilesChanged   *int      `json:"files_changed,omitempty" db:"files_changed"`
+	Type           string    `json:"type,omitempty" db:"commit_type"` // Conventional Commits type of the message, e.g. feat or fix; "other" when it has none
 	CreatedAtLocal time.Time `json:"created_at_local" db:"created_at_local"`
 }
 
+// AuthorCommit is a commit listed across repositories along with the name of
+// its repository
+type AuthorCommit struct {
+	Commit
+	Repository string `json:"repository" example:"golang/go"`
+}
+
 // CommitStats represents statistics about commits
 type CommitStats struct {
 	AuthorName  string `json:"author_name" db:"author_name"`
 	AuthorEmail string `json:"author_email" db:"author_email"`
 	Count       int    `json:"commit_count" db:"commit_count"`
+
+	// Lines changed by the author's commits that have been enriched with diff stats
+	Additions       int `json:"additions" db:"additions"`
+	Deletions       int `json:"deletions" db:"deletions"`
+	FilesChanged    int `json:"files_changed" db:"files_changed"`
+	EnrichedCommits int `json:"enriched_commits" db:"enriched_commits"`
+}
+
+// AuthorIdentity maps a commit author email to the canonical identity it was merged into
+type AuthorIdentity struct {
+	ID             int64     `json:"id"`
+	Email          string    `json:"email"`
+	CanonicalName  string    `json:"canonical_name"`
+	CanonicalEmail string    `json:"canonical_email"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CommitSearchOptions holds the filters for searching commits within a repository
+type CommitSearchOptions struct {
+	Query     string     // Full-text query matched against commit messages
+	Author    string     // Author name or email
+	Since     *time.Time // Only commits on or after this time
+	Until     *time.Time // Only commits on or before this time
+	SHAPrefix string     // Abbreviated commit SHA
+}
+
+// CommitFilter narrows the commits listed for a repository. Unlike a search,
+// the author must match a name or email exactly, ignoring case, so every
+// filter is answered from an index.
+type CommitFilter struct {
+	Author    string     // Author name or email
+	Since     *time.Time // Only commits on or after this time
+	Until     *time.Time // Only commits on or before this time
+	SHAPrefix string     // Abbreviated commit SHA
+}
+
+// CommitCursor is the position after a commit in a listing ordered newest
+// first, where commits with the same date are ordered by descending ID
+type CommitCursor struct {
+	CommitDate time.Time
+	ID         int64
+}
+
+// CommitLookup is a single commit with its changed files and the commits
+// made directly before and after it
+type CommitLookup struct {
+	Commit   *Commit      `json:"commit"`
+	Files    []CommitFile `json:"files"`
+	Previous *Commit      `json:"previous"`
+	Next     *Commit      `json:"next"`
 }
 
 // CommitAuthor represents a commit author or committer
@@ -66,16 +129,744 @@ type CommitResponse struct {
 
 // RateLimitInfo stores GitHub API rate limit information
 type RateLimitInfo struct {
-	Remaining int
-	Reset     time.Time
-	Limit     int
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Limit     int       `json:"limit"`
+}
+
+// TokenRateLimit is the rate limit of one of several GitHub tokens used in rotation
+type TokenRateLimit struct {
+	Token     string    `json:"token"` // Masked to its last four characters
+	Remaining int       `json:"remaining"`
+	Limit     int       `json:"limit"`
+	Reset     time.Time `json:"reset"`
+	Known     bool      `json:"known"` // False until GitHub has reported the token's limit
+}
+
+// DedupStats counts requests and how many of them were served by sharing a
+// concurrent identical request
+type DedupStats struct {
+	Requests     int64 `json:"requests"`
+	Deduplicated int64 `json:"deduplicated"`
+}
+
+// TokenStatus describes the expiry of the GitHub token used by the service
+type TokenStatus struct {
+	ExpiresAt    *time.Time `json:"expires_at"` // Null when the token doesn't expire or no response has reported it yet
+	ExpiresIn    string     `json:"expires_in,omitempty"`
+	Expired      bool       `json:"expired"`
+	ExpiringSoon bool       `json:"expiring_soon"` // Within the warning window
+	WarnBefore   string     `json:"warn_before"`
+}
+
+// Throttled reports whether requests are currently blocked until the limit resets
+func (r RateLimitInfo) Throttled(now time.Time) bool {
+	return r.Remaining <= 0 && r.Reset.After(now)
 }
 
 // MonitoredRepository represents a repository being monitored
 type MonitoredRepository struct {
 	ID           int64
 	FullName     string
+	Provider     string
 	LastSyncTime time.Time
 	SyncInterval time.Duration
 	IsActive     bool
 }
+
+// Code hosting providers a repository can be monitored on
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// ValidProvider reports whether provider is a known code hosting provider
+func ValidProvider(provider string) bool {
+	return provider == ProviderGitHub || provider == ProviderGitLab
+}
+
+// Statuses of a monitored repository in listings
+const (
+	RepositoryStatusPending = "pending" // Initial sync has not completed yet
+	RepositoryStatusSynced  = "synced"
+)
+
+// RepositoryListing is a monitored repository merged with its synced details.
+// The details are absent while the repository is pending.
+type RepositoryListing struct {
+	*Repository
+	FullName       string     `json:"full_name"`
+	Provider       string     `json:"provider"`
+	Status         string     `json:"status"`
+	SyncInterval   string     `json:"sync_interval"`
+	LastSyncTime   *time.Time `json:"last_sync_time"`
+	MonitoredSince time.Time  `json:"monitored_since"`
+}
+
+// UserRepository is a repository owned by a GitHub user, as listed when
+// discovering the repositories of watched users
+type UserRepository struct {
+	FullName   string    `json:"full_name"`
+	Language   string    `json:"language"`
+	StarsCount int       `json:"stargazers_count"`
+	PushedAt   time.Time `json:"pushed_at"`
+	Archived   bool      `json:"archived"`
+	Fork       bool      `json:"fork"`
+}
+
+// WatchedUser is a GitHub user whose repositories matching the filters are
+// added to monitoring, now and as they appear
+type WatchedUser struct {
+	Username      string        // Lowercase GitHub login
+	MinStars      int           // Repositories with fewer stars are skipped
+	Language      string        // Primary language repositories must have; any when empty
+	UpdatedWithin time.Duration // Repositories last pushed to longer ago are skipped; 0 keeps all
+	CreatedAt     time.Time
+	RefreshedAt   *time.Time // Last discovery of the user's repositories
+}
+
+// Role represents the permission level of an API key
+type Role string
+
+const (
+	RoleReader Role = "reader" // Read-only access
+	RoleWriter Role = "writer" // Can add, remove and resync repositories
+	RoleAdmin  Role = "admin"  // Full access including admin endpoints
+)
+
+var roleRanks = map[Role]int{
+	RoleReader: 1,
+	RoleWriter: 2,
+	RoleAdmin:  3,
+}
+
+// Valid reports whether the role is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether the role grants at least the permissions of required
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// APIKey represents an API key used to authenticate against the service
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       Role       `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	AccountID  *int64     `json:"account_id,omitempty"` // Account whose login issued the key
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Scoped reports whether requests authenticated by the key only see the
+// repositories of its account. Keys of admin accounts see everything.
+func (k *APIKey) Scoped() bool {
+	return k.AccountID != nil && k.Role != RoleAdmin
+}
+
+// Account is a person who logged in with their GitHub account
+type Account struct {
+	ID          int64      `json:"id"`
+	GitHubID    int64      `json:"github_id"`
+	Login       string     `json:"login"`
+	Role        Role       `json:"role"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// GitHubUser is the GitHub user an OAuth access token belongs to
+type GitHubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// CommitFile represents a file changed by a commit
+type CommitFile struct {
+	CommitID  int64  `json:"commit_id"`
+	Filename  string `json:"filename"`
+	Extension string `json:"extension"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+}
+
+// CommitDetail represents a single commit fetched with its changed files
+type CommitDetail struct {
+	SHA       string       `json:"sha"`
+	Additions int          `json:"additions"`
+	Deletions int          `json:"deletions"`
+	Files     []CommitFile `json:"files"`
+}
+
+// FileExtensionStats represents aggregated changes for one file extension
+type FileExtensionStats struct {
+	Extension    string `json:"extension"`
+	CommitCount  int    `json:"commit_count"`
+	FilesChanged int    `json:"files_changed"`
+	Additions    int    `json:"additions"`
+	Deletions    int    `json:"deletions"`
+	Changes      int    `json:"changes"`
+}
+
+// CommitTypeStats represents the commits of one Conventional Commits type
+type CommitTypeStats struct {
+	Type        string  `json:"type"`
+	CommitCount int     `json:"commit_count"`
+	Percentage  float64 `json:"percentage"` // Share of the repository's commits in the range
+}
+
+// ContributionDistribution describes how concentrated a repository's commits
+// are among its authors
+type ContributionDistribution struct {
+	Authors int           `json:"authors"`
+	Commits int           `json:"commits"`
+	Gini    float64       `json:"gini"`   // 0 when every author made as many commits, approaching 1 when one author made them all
+	Lorenz  []LorenzPoint `json:"lorenz"` // Lorenz curve at every tenth of the authors
+}
+
+// LorenzPoint is a point of a Lorenz curve: the share of the commits made by
+// the given share of the authors with the fewest commits
+type LorenzPoint struct {
+	Authors float64 `json:"authors"`
+	Commits float64 `json:"commits"`
+}
+
+// Issue states accepted when filtering issues
+const (
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+	IssueStateAll    = "all"
+)
+
+// Issue represents a GitHub issue in our database
+type Issue struct {
+	ID             int64      `json:"id"`
+	RepositoryID   int64      `json:"repository_id"`
+	GitHubID       int64      `json:"github_id"`
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	State          string     `json:"state"`
+	AuthorLogin    string     `json:"author_login"`
+	Comments       int        `json:"comments"`
+	URL            string     `json:"url"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty"`
+	CreatedAtLocal time.Time  `json:"created_at_local"`
+}
+
+// Tag represents a git tag of a repository
+type Tag struct {
+	Name      string `json:"name"`
+	CommitSHA string `json:"commit_sha"`
+}
+
+// Release represents a GitHub release of a repository
+type Release struct {
+	ID             int64      `json:"id"`
+	RepositoryID   int64      `json:"repository_id"`
+	GitHubID       int64      `json:"github_id"`
+	TagName        string     `json:"tag_name"`
+	Name           string     `json:"name"`
+	Draft          bool       `json:"draft"`
+	Prerelease     bool       `json:"prerelease"`
+	AuthorLogin    string     `json:"author_login"`
+	URL            string     `json:"url"`
+	CreatedAt      time.Time  `json:"created_at"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"` // Unset for drafts
+	CommitSHA      string     `json:"commit_sha,omitempty"`   // Commit the tag points at, once the tag is synced
+	CreatedAtLocal time.Time  `json:"created_at_local"`
+}
+
+// ReleaseInterval describes a published release and the commits it shipped: those
+// committed after the commit tagged by the previous release, up to its own
+type ReleaseInterval struct {
+	TagName             string     `json:"tag_name"`
+	PublishedAt         time.Time  `json:"published_at"`
+	PreviousPublishedAt *time.Time `json:"previous_published_at,omitempty"` // Unset for the first release
+	DaysSincePrevious   *float64   `json:"days_since_previous,omitempty"`
+	Commits             int        `json:"commits"`
+	AvgDaysToRelease    *float64   `json:"avg_days_to_release,omitempty"` // Unset when the release shipped no synced commits
+}
+
+// ReleaseCadence summarizes how often a repository publishes releases
+type ReleaseCadence struct {
+	Releases                  int                `json:"releases"`
+	AvgDaysBetweenReleases    *float64           `json:"avg_days_between_releases"`
+	MedianDaysBetweenReleases *float64           `json:"median_days_between_releases"`
+	AvgCommitsPerRelease      float64            `json:"avg_commits_per_release"`
+	AvgDaysCommitToRelease    *float64           `json:"avg_days_commit_to_release"` // Weighted by the commits of each release
+	Intervals                 []*ReleaseInterval `json:"intervals"`
+}
+
+// Metrics repositories can be ranked by in the top repositories
+const (
+	ActivityMetricCommits = "commits"
+	ActivityMetricAuthors = "authors"
+)
+
+// RepositoryActivity is a monitored repository's commit activity over a window
+type RepositoryActivity struct {
+	FullName    string `json:"full_name"`
+	Language    string `json:"language"`
+	CommitCount int    `json:"commit_count"`
+	AuthorCount int    `json:"author_count"` // Distinct authors after merging identities
+}
+
+// RepositoryStatsSnapshot holds a repository's popularity counters as of a day
+type RepositoryStatsSnapshot struct {
+	Date            time.Time `json:"date"`
+	StarsCount      int       `json:"stargazers_count"`
+	ForksCount      int       `json:"forks_count"`
+	WatchersCount   int       `json:"watchers_count"`
+	OpenIssuesCount int       `json:"open_issues_count"`
+	RecordedAt      time.Time `json:"recorded_at"`
+}
+
+// ContributorWeek is a contributor's activity in a repository during one week
+type ContributorWeek struct {
+	Week      time.Time `json:"week"` // Start of the week, Sunday 00:00 UTC
+	Commits   int       `json:"commits"`
+	Additions int       `json:"additions"`
+	Deletions int       `json:"deletions"`
+}
+
+// ContributorStats is a contributor's activity in a repository as computed by
+// GitHub, with the totals of the listed weeks
+type ContributorStats struct {
+	Login     string            `json:"login"`
+	Commits   int               `json:"commits"`
+	Additions int               `json:"additions"`
+	Deletions int               `json:"deletions"`
+	Weeks     []ContributorWeek `json:"weeks"`
+}
+
+// Ecosystems of dependencies
+const (
+	EcosystemGo   = "go"
+	EcosystemNPM  = "npm"
+	EcosystemPyPI = "pypi"
+)
+
+// Scopes of dependencies
+const (
+	ScopeRuntime     = "runtime"
+	ScopeDevelopment = "development" // npm devDependencies
+	ScopeIndirect    = "indirect"    // Go requirements marked // indirect
+)
+
+// Dependency is a package a repository declares in one of its manifests
+type Dependency struct {
+	Manifest  string `json:"manifest"` // Path of the manifest, e.g. go.mod
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	Version   string `json:"version"` // Version or constraint as declared; empty when unconstrained
+	Scope     string `json:"scope"`
+}
+
+// Dependent is a monitored repository depending on a package
+type Dependent struct {
+	Repository string    `json:"repository"`
+	Manifest   string    `json:"manifest"`
+	Version    string    `json:"version"`
+	Scope      string    `json:"scope"`
+	UpdatedAt  time.Time `json:"updated_at"` // When the manifest was last synced
+}
+
+// PackageDependents lists the monitored repositories depending on a package,
+// with the number of repositories per declared version
+type PackageDependents struct {
+	Ecosystem  string         `json:"ecosystem"`
+	Name       string         `json:"name"`
+	Versions   map[string]int `json:"versions"`
+	Dependents []*Dependent   `json:"dependents"`
+}
+
+// Metrics that threshold rules can watch
+const (
+	MetricStars         = "stars"
+	MetricForks         = "forks"
+	MetricWatchers      = "watchers"
+	MetricOpenIssues    = "open_issues"
+	MetricWeeklyCommits = "weekly_commits" // Commits in the last 7 days
+
+	// Changes over the rule's window, from the daily stats snapshots
+	MetricStarsDelta      = "stars_delta"
+	MetricForksDelta      = "forks_delta"
+	MetricWatchersDelta   = "watchers_delta"
+	MetricOpenIssuesDelta = "open_issues_delta"
+)
+
+// IsDeltaMetric reports whether a threshold rule metric is the change of a
+// counter over the rule's window
+func IsDeltaMetric(metric string) bool {
+	switch metric {
+	case MetricStarsDelta, MetricForksDelta, MetricWatchersDelta, MetricOpenIssuesDelta:
+		return true
+	}
+	return false
+}
+
+// Comparison operators of threshold rules
+const (
+	OperatorGT  = "gt"
+	OperatorGTE = "gte"
+	OperatorLT  = "lt"
+	OperatorLTE = "lte"
+)
+
+// ThresholdRule alerts when a repository metric starts or stops meeting a threshold
+type ThresholdRule struct {
+	ID              int64      `json:"id"`
+	RepositoryID    int64      `json:"repository_id"`
+	Metric          string     `json:"metric"`
+	Operator        string     `json:"operator"`
+	Threshold       int64      `json:"threshold"`
+	WindowDays      int        `json:"window_days,omitempty"` // Days a delta metric is measured over
+	WebhookURL      string     `json:"webhook_url,omitempty"` // Optional: also sent each alert
+	Triggered       bool       `json:"triggered"`
+	LastValue       *int64     `json:"last_value,omitempty"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Holds reports whether value meets the rule's threshold
+func (r *ThresholdRule) Holds(value int64) bool {
+	switch r.Operator {
+	case OperatorGT:
+		return value > r.Threshold
+	case OperatorGTE:
+		return value >= r.Threshold
+	case OperatorLT:
+		return value < r.Threshold
+	case OperatorLTE:
+		return value <= r.Threshold
+	}
+	return false
+}
+
+// ThresholdAlert records a threshold rule starting or stopping to hold, with
+// the rule's definition at the time
+type ThresholdAlert struct {
+	ID            int64     `json:"id"`
+	RepositoryID  int64     `json:"repository_id"`
+	RuleID        *int64    `json:"rule_id"` // Nil once the rule is deleted
+	Event         string    `json:"event"`   // triggered or resolved
+	Metric        string    `json:"metric"`
+	Operator      string    `json:"operator"`
+	Threshold     int64     `json:"threshold"`
+	WindowDays    int       `json:"window_days,omitempty"`
+	Value         int64     `json:"value"`
+	PreviousValue int64     `json:"previous_value"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// Events of threshold alerts
+const (
+	AlertTriggered = "triggered"
+	AlertResolved  = "resolved"
+)
+
+// CommitHook delivers the commits newly ingested for a repository to a webhook,
+// optionally only those by some authors or touching some paths
+type CommitHook struct {
+	ID           int64     `json:"id"`
+	RepositoryID int64     `json:"repository_id"`
+	WebhookURL   string    `json:"webhook_url"`
+	Authors      []string  `json:"authors"` // Author names or emails; empty matches every author
+	Paths        []string  `json:"paths"`   // Glob patterns or directories of changed files; empty matches every commit
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Webhook receives the commits ingested for the repositories it subscribes to,
+// signed with its secret
+type Webhook struct {
+	ID           int64     `json:"id"`
+	URL          string    `json:"url"`
+	Repositories []string  `json:"repositories"` // Full names, lowercase
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Statuses of a webhook delivery
+const (
+	DeliveryPending   = "pending"   // Waiting for its first or next attempt
+	DeliveryDelivered = "delivered" // Acknowledged with a 2xx response
+	DeliveryFailed    = "failed"    // Gave up after the last attempt
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Repository     string     `json:"repository"`
+	Payload        []byte     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"response_status"` // Of the last attempt; nil without a response
+	Error          string     `json:"error,omitempty"` // Of the last failed attempt
+	NextAttemptAt  *time.Time `json:"next_attempt_at"` // Set while pending
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+
+	// Destination, only loaded for the deliveries being sent
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// MonthlyReport summarizes a repository's activity during one calendar month (UTC)
+type MonthlyReport struct {
+	Repository           string              `json:"repository"`
+	Month                string              `json:"month"` // YYYY-MM
+	Commits              int                 `json:"commits"`
+	PreviousMonthCommits int                 `json:"previous_month_commits"`
+	CommitChangePercent  *float64            `json:"commit_change_percent"` // Unset when the previous month had no commits
+	Contributors         int                 `json:"contributors"`
+	NewContributors      []*CommitStats      `json:"new_contributors"` // Authors whose first commit to the repository was made this month
+	TopAuthors           []*CommitStats      `json:"top_authors"`
+	BusiestDays          []*DailyCommitCount `json:"busiest_days"`
+	Tickets              *TicketCommitCounts `json:"tickets,omitempty"` // Set when a ticket tracker is configured
+	GeneratedAt          time.Time           `json:"generated_at"`
+	Markdown             string              `json:"-"` // The report rendered for humans
+}
+
+// Periods that digests cover
+const (
+	DigestWeekly  = "weekly"  // Monday to Sunday (UTC)
+	DigestMonthly = "monthly" // A calendar month (UTC)
+)
+
+// ValidDigestPeriod reports whether period is a known digest period
+func ValidDigestPeriod(period string) bool {
+	return period == DigestWeekly || period == DigestMonthly
+}
+
+// Digest summarizes a repository's activity during one week or month
+type Digest struct {
+	Repository   string              `json:"repository"`
+	Period       string              `json:"period"` // weekly or monthly
+	Start        string              `json:"start"`  // First day of the period (YYYY-MM-DD)
+	End          string              `json:"end"`    // Last day of the period (YYYY-MM-DD)
+	Commits      int                 `json:"commits"`
+	Contributors int                 `json:"contributors"`
+	TopAuthors   []*CommitStats      `json:"top_authors"`
+	StarsStart   *int                `json:"stars_start"` // Unset without a stats snapshot from before the period
+	StarsEnd     *int                `json:"stars_end"`   // Unset without a stats snapshot from within or before the period
+	StarDelta    *int                `json:"star_delta"`  // Unset unless both star counts are known
+	BusiestDays  []*DailyCommitCount `json:"busiest_days"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	Markdown     string              `json:"-"` // The digest rendered for humans
+}
+
+// DailyCommitCount is the number of commits made on a day (UTC)
+type DailyCommitCount struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Commits int    `json:"commits"`
+}
+
+// RepositoryGroup is a named set of monitored repositories, e.g. those of a team
+type RepositoryGroup struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Repositories []string  `json:"repositories"` // Full names of the members, sorted
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GroupSummary aggregates the activity of a repository group's members since a time
+type GroupSummary struct {
+	Group              string                `json:"group"`
+	Since              time.Time             `json:"since"`
+	Until              time.Time             `json:"until"`
+	Repositories       int                   `json:"repositories"`        // Members
+	ActiveRepositories int                   `json:"active_repositories"` // Members with commits since Since
+	Commits            int                   `json:"commits"`
+	Authors            int                   `json:"authors"` // Distinct authors after merging identities
+	TopAuthors         []*CommitStats        `json:"top_authors"`
+	Stars              int                   `json:"stars"`      // Current stars of the synced members
+	StarDelta          int                   `json:"star_delta"` // Stars gained since Since by the members with a stats snapshot from before it
+	Activity           []*RepositoryActivity `json:"activity"`   // Active members, most commits first
+}
+
+// TicketStatusDone is the status category of resolved Jira tickets; the others
+// are "new" and "indeterminate" (in progress)
+const TicketStatusDone = "done"
+
+// Ticket is an issue tracker ticket referenced by commit messages
+type Ticket struct {
+	Key            string     `json:"key"`
+	Summary        string     `json:"summary"`
+	Status         string     `json:"status"`          // Status name, as shown in the tracker
+	StatusCategory string     `json:"status_category"` // new, indeterminate or done
+	Found          bool       `json:"found"`           // Whether the tracker knows the ticket
+	RefreshedAt    *time.Time `json:"refreshed_at,omitempty"`
+}
+
+// TicketCommitCounts counts commits by the status of the tickets they reference.
+// Commits referencing no ticket are not counted.
+type TicketCommitCounts struct {
+	Open       int `json:"open"`       // Referencing at least one ticket that isn't done
+	Closed     int `json:"closed"`     // Referencing only tickets that are done
+	Unresolved int `json:"unresolved"` // Referencing only tickets not found or not looked up yet
+}
+
+// Maintenance tasks run against the database
+const (
+	MaintenanceAnalyze = "analyze"
+	MaintenanceReindex = "reindex"
+)
+
+// MaintenanceRun records the outcome of one maintenance task on one table or index
+type MaintenanceRun struct {
+	ID         int64     `json:"id"`
+	Task       string    `json:"task"`
+	Target     string    `json:"target"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// Statuses of a sync run
+const (
+	SyncRunRunning   = "running"
+	SyncRunSucceeded = "succeeded"
+	SyncRunFailed    = "failed"
+)
+
+// SyncRun records one sync of a repository and how many commits it ingested
+type SyncRun struct {
+	ID           int64      `json:"id"`
+	RepositoryID int64      `json:"repository_id"`
+	Incremental  bool       `json:"incremental"`
+	Since        *time.Time `json:"since,omitempty"`
+	Status       string     `json:"status"`
+	NewCommits   int        `json:"new_commits"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
+
+// SyncResult summarizes what a sync fetched and stored. It is saved on the job
+// that ran the sync, so users can check what the job actually did.
+type SyncResult struct {
+	SyncRunID          int64 `json:"sync_run_id,omitempty"`
+	CommitsFetched     int   `json:"commits_fetched"`
+	NewCommits         int   `json:"new_commits"`
+	ExistingCommits    int   `json:"existing_commits"` // Fetched commits that an earlier sync stored
+	Pages              int   `json:"pages"`
+	DurationMS         int64 `json:"duration_ms"`
+	RateLimitRemaining *int  `json:"rate_limit_remaining,omitempty"` // GitHub requests left once the sync ended
+	ResumedFromPage    int   `json:"resumed_from_page,omitempty"`    // Commit page an interrupted backfill resumed at
+}
+
+// BackfillCursor is how far the commit history backfill of a repository got.
+// It outlives the job that made it, so a backfill interrupted for good is
+// resumed by the next sync job of the repository with the same since instead
+// of starting over.
+type BackfillCursor struct {
+	RepositoryID int64      `json:"repository_id"`
+	Since        *time.Time `json:"since,omitempty"` // Lower bound of the backfill; nil for the full history
+	Page         int        `json:"page"`            // Last commit page stored
+	OldestSHA    string     `json:"oldest_sha"`      // Oldest commit of that page
+	OldestDate   time.Time  `json:"oldest_date"`     // Commit date of OldestSHA
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// CommitIncrement holds the commits ingested by the sync runs after a given run.
+// Cursor is the last run included and is passed as the next since_run.
+type CommitIncrement struct {
+	SinceRun int64      `json:"since_run"`
+	Cursor   int64      `json:"cursor"`
+	HasMore  bool       `json:"has_more"`
+	Runs     []*SyncRun `json:"runs"`
+	Commits  []*Commit  `json:"commits"`
+}
+
+// APIUsage aggregates the requests of one API key to one endpoint within an hour
+type APIUsage struct {
+	Bucket        time.Time // Start of the hour
+	Method        string
+	Route         string // Route template, e.g. /api/v1/repositories/{owner}/{repo}
+	APIKeyID      int64  // 0 for unauthenticated requests and the bootstrap admin key
+	Requests      int64
+	Errors        int64 // Responses with a 5xx status
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// EndpointUsage summarizes the requests to one endpoint
+type EndpointUsage struct {
+	Method       string  `json:"method"`
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	MaxLatencyMs float64 `json:"max_latency_ms"`
+}
+
+// APIKeyUsage summarizes the requests made with one API key
+type APIKeyUsage struct {
+	APIKeyID     int64   `json:"api_key_id"`
+	Name         string  `json:"name,omitempty"`
+	Prefix       string  `json:"prefix,omitempty"`
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// CommitImportResult summarizes an import of commits from a dump
+type CommitImportResult struct {
+	Repository string              `json:"repository"`
+	Imported   int                 `json:"imported"`
+	Duplicates int                 `json:"duplicates"` // Already stored, or repeated within the dump
+	Rejected   int                 `json:"rejected"`
+	Errors     []CommitImportError `json:"errors"` // Only the first rejected records are listed
+}
+
+// CommitImportError describes a record of a commit dump that failed validation
+type CommitImportError struct {
+	Line  int    `json:"line"`
+	SHA   string `json:"sha,omitempty"`
+	Error string `json:"error"`
+}
+
+// Events that notifications are sent for
+const (
+	NotificationJobFailed     = "job_failed"     // A job failed its last retry
+	NotificationSyncFailing   = "sync_failing"   // A repository failed to sync too many times in a row
+	NotificationSyncRecovered = "sync_recovered" // A failing repository synced again
+	NotificationDigest        = "digest"         // A scheduled digest of a repository was generated
+	NotificationRuleTriggered = "rule_triggered" // A threshold rule started holding
+	NotificationRuleResolved  = "rule_resolved"  // A threshold rule stopped holding
+)
+
+// ValidNotificationEvent reports whether event is a known notification event
+func ValidNotificationEvent(event string) bool {
+	switch event {
+	case NotificationJobFailed, NotificationSyncFailing, NotificationSyncRecovered, NotificationDigest,
+		NotificationRuleTriggered, NotificationRuleResolved:
+		return true
+	}
+	return false
+}
+
+// Notification is a message about a failure or a digest sent to the configured channels
+type Notification struct {
+	Event      string
+	Subject    string
+	Message    string
+	Repository string // Empty when not about a repository
+	Time       time.Time
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// Email sends notifications by SMTP
+type Email struct {
+	host string
+	addr string
+	auth smtp.Auth // nil without credentials
+	from string
+	to   []string
+}
+
+// NewEmail creates an email channel sending through the SMTP server at host
+// and port, authenticating with PLAIN auth when username is set
+func NewEmail(host string, port int, username, password, from string, to []string) *Email {
+	e := &Email{
+		host: host,
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		to:   to,
+	}
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e
+}
+
+// Send mails the notification to every recipient, upgrading the connection
+// with STARTTLS when the server offers it
+func (e *Email) Send(ctx context.Context, n *models.Notification) error {
+	if err := e.send(ctx, e.message(n)); err != nil {
+		return fmt.Errorf("email delivery failed: %w", err)
+	}
+	return nil
+}
+
+// send delivers msg like smtp.SendMail, within the deadline of ctx
+func (e *Email) send(ctx context.Context, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.host}); err != nil {
+			return err
+		}
+	}
+	if e.auth != nil {
+		if err := client.Auth(e.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.from); err != nil {
+		return err
+	}
+	for _, to := range e.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message formats the notification as a plain text email
+func (e *Email) message(n *models.Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: [github-service] %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(n.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
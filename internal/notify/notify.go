@@ -0,0 +1,77 @@
+// Package notify sends notifications about failing syncs and jobs to Slack
+// and email.
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+// sendTimeout bounds the delivery of a notification to one channel
+const sendTimeout = 15 * time.Second
+
+// Channel delivers notifications to one destination
+type Channel interface {
+	Send(ctx context.Context, n *models.Notification) error
+}
+
+// route is a channel and the events sent to it
+type route struct {
+	name    string
+	channel Channel
+	events  map[string]bool // All events when empty
+}
+
+// Notifier sends each notification to the channels subscribed to its event
+type Notifier struct {
+	routes []route
+	log    zerolog.Logger
+}
+
+// New creates a notifier without channels
+func New(log zerolog.Logger) *Notifier {
+	return &Notifier{log: log}
+}
+
+// Add subscribes a channel to the given events, or to every event when none
+// are given. It must be called before the notifier is used.
+func (n *Notifier) Add(name string, channel Channel, events []string) {
+	r := route{name: name, channel: channel, events: make(map[string]bool, len(events))}
+	for _, event := range events {
+		r.events[event] = true
+	}
+	n.routes = append(n.routes, r)
+}
+
+// Enabled reports whether any channel was added
+func (n *Notifier) Enabled() bool {
+	return len(n.routes) > 0
+}
+
+// Notify sends a notification to every channel subscribed to its event. A
+// channel that fails to deliver it is logged and doesn't stop the others.
+func (n *Notifier) Notify(ctx context.Context, notification *models.Notification) {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+	for _, r := range n.routes {
+		if len(r.events) > 0 && !r.events[notification.Event] {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := r.channel.Send(sendCtx, notification)
+		cancel()
+		if err != nil {
+			n.log.Warn().
+				Err(err).
+				Str("channel", r.name).
+				Str("event", notification.Event).
+				Str("repository", notification.Repository).
+				Msg("Failed to send notification")
+		}
+	}
+}
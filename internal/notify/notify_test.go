@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+// recordingChannel records the events it was sent
+type recordingChannel struct {
+	events []string
+	err    error
+}
+
+func (c *recordingChannel) Send(ctx context.Context, n *models.Notification) error {
+	c.events = append(c.events, n.Event)
+	return c.err
+}
+
+func TestNotifierRoutesEvents(t *testing.T) {
+	all := &recordingChannel{}
+	jobs := &recordingChannel{}
+	failing := &recordingChannel{err: errors.New("unreachable")}
+
+	n := New(zerolog.Nop())
+	n.Add("failing", failing, nil)
+	n.Add("all", all, nil)
+	n.Add("jobs", jobs, []string{models.NotificationJobFailed})
+
+	n.Notify(context.Background(), &models.Notification{Event: models.NotificationSyncFailing})
+	n.Notify(context.Background(), &models.Notification{Event: models.NotificationJobFailed})
+
+	// A failing channel doesn't keep the others from being notified
+	if strings.Join(all.events, ",") != "sync_failing,job_failed" {
+		t.Errorf("channel without events got %v, want every event", all.events)
+	}
+	if strings.Join(jobs.events, ",") != "job_failed" {
+		t.Errorf("job channel got %v, want only job_failed", jobs.events)
+	}
+}
+
+func TestSlackSend(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	notification := &models.Notification{Subject: "golang/go failed to sync 3 times in a row", Message: "Last error: timeout"}
+	if err := NewSlack(server.URL, server.Client()).Send(context.Background(), notification); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if want := "*golang/go failed to sync 3 times in a row*\nLast error: timeout"; got["text"] != want {
+		t.Errorf("text = %q, want %q", got["text"], want)
+	}
+
+	if err := NewSlack(server.URL+"/fail", server.Client()).Send(context.Background(), notification); err == nil {
+		t.Error("expected an error for a non-2xx response")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	e := NewEmail("smtp.example.com", 587, "", "", "alerts@example.com", []string{"ops@example.com", "dev@example.com"})
+	msg := string(e.message(&models.Notification{
+		Subject: "sync job failed\r\nBcc: someone@example.com",
+		Message: "line one\nline two",
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}))
+
+	for _, want := range []string{
+		"From: alerts@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [github-service] sync job failed  Bcc: someone@example.com\r\n",
+		"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
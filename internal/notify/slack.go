@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github-service/internal/models"
+)
+
+// Slack posts notifications to a Slack incoming webhook
+type Slack struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlack creates a Slack channel posting to webhookURL with client
+func NewSlack(webhookURL string, client *http.Client) *Slack {
+	return &Slack{webhookURL: webhookURL, client: client}
+}
+
+// Send posts the notification as a message with its subject in bold
+func (s *Slack) Send(ctx context.Context, n *models.Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", n.Subject, n.Message),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Slack delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Slack delivery failed: webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
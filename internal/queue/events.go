@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/events"
+)
+
+// EventQueue is a Queue that publishes job lifecycle events as jobs are
+// enqueued, picked up, completed, failed and quarantined
+type EventQueue struct {
+	Queue
+	bus *events.Bus
+}
+
+// NewEventQueue wraps q so its job transitions are published on bus
+func NewEventQueue(q Queue, bus *events.Bus) *EventQueue {
+	return &EventQueue{Queue: q, bus: bus}
+}
+
+func (q *EventQueue) Enqueue(ctx context.Context, job *Job) error {
+	if err := q.Queue.Enqueue(ctx, job); err != nil {
+		return err
+	}
+	switch {
+	case job.Existing:
+	case job.Status == JobStatusQuarantined:
+		q.bus.Publish(events.JobQuarantined, jobEventData(job))
+	default:
+		q.bus.Publish(events.JobEnqueued, jobEventData(job))
+	}
+	return nil
+}
+
+func (q *EventQueue) Dequeue(ctx context.Context) (*Job, error) {
+	job, err := q.Queue.Dequeue(ctx)
+	if err == nil && job != nil {
+		q.bus.Publish(events.JobStarted, jobEventData(job))
+	}
+	return job, err
+}
+
+func (q *EventQueue) Complete(ctx context.Context, jobID string) error {
+	if err := q.Queue.Complete(ctx, jobID); err != nil {
+		return err
+	}
+	q.bus.Publish(events.JobCompleted, map[string]interface{}{"job_id": jobID})
+	return nil
+}
+
+func (q *EventQueue) Fail(ctx context.Context, jobID string, jobErr error, retryAt time.Time) error {
+	if err := q.Queue.Fail(ctx, jobID, jobErr, retryAt); err != nil {
+		return err
+	}
+	data := map[string]interface{}{"job_id": jobID, "error": jobErr.Error()}
+	if !retryAt.IsZero() {
+		data["next_retry_at"] = retryAt.UTC().Format(time.RFC3339)
+	}
+	q.bus.Publish(events.JobFailed, data)
+	return nil
+}
+
+func (q *EventQueue) RecordPanic(ctx context.Context, jobID string, jobErr error) (bool, error) {
+	quarantined, err := q.Queue.RecordPanic(ctx, jobID, jobErr)
+	if err != nil {
+		return false, err
+	}
+	eventType := events.JobFailed
+	if quarantined {
+		eventType = events.JobQuarantined
+	}
+	q.bus.Publish(eventType, map[string]interface{}{"job_id": jobID, "error": jobErr.Error()})
+	return quarantined, nil
+}
+
+func (q *EventQueue) Release(ctx context.Context, jobID string) (*Job, error) {
+	job, err := q.Queue.Release(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	q.bus.Publish(events.JobEnqueued, jobEventData(job))
+	return job, nil
+}
+
+// jobEventData describes a job in an event
+func jobEventData(job *Job) map[string]interface{} {
+	return map[string]interface{}{
+		"job_id":  job.ID,
+		"type":    job.Type,
+		"payload": job.Payload,
+	}
+}
@@ -1,7 +1,9 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,29 @@ const (
 	JobTypeSync    JobType = "sync"
 	JobTypeResync  JobType = "resync"
 	JobTypeCleanup JobType = "cleanup"
+	JobTypeIssues  JobType = "sync_issues"
+	JobTypeReport  JobType = "report"
+	JobTypeDigest  JobType = "digest"
+
+	JobTypeMaintenance JobType = "maintenance"
+	JobTypeTickets     JobType = "refresh_tickets"
+	JobTypeDiscover    JobType = "discover_repositories"
+)
+
+// Valid reports whether t is a job type the workers process
+func (t JobType) Valid() bool {
+	switch t {
+	case JobTypeSync, JobTypeResync, JobTypeCleanup, JobTypeIssues, JobTypeReport, JobTypeDigest, JobTypeMaintenance, JobTypeTickets, JobTypeDiscover:
+		return true
+	}
+	return false
+}
+
+// Priorities of jobs
+const (
+	PriorityLow    = -10 // Background work nobody waits on, e.g. the initial syncs of an import
+	PriorityNormal = 0
+	PriorityHigh   = 10 // Work a client asked for and is waiting on, e.g. a manual resync
 )
 
 // JobStatus represents the status of a job
@@ -23,6 +48,10 @@ const (
 	JobStatusComplete JobStatus = "complete"
 	JobStatusFailed   JobStatus = "failed"
 	JobStatusStopped  JobStatus = "stopped" // New status for jobs that hit max retries
+
+	// JobStatusQuarantined is the status of jobs whose payload kept crashing
+	// their handler; they are not run until released
+	JobStatusQuarantined JobStatus = "quarantined"
 )
 
 // Default retry configuration
@@ -32,6 +61,7 @@ const (
 	DefaultMaxBackoff     = 1 * time.Hour
 	DefaultBackoffFactor  = 2.0
 	DefaultJitterFactor   = 0.1
+	DefaultMaxPanics      = 3 // Panics of jobs with the same payload before it is quarantined
 )
 
 // Job represents a background job
@@ -44,6 +74,33 @@ type Job struct {
 	UpdatedAt time.Time       `json:"updated_at"`
 	Error     string          `json:"error,omitempty"`
 	Schedule  string          `json:"schedule,omitempty"` // Cron expression for scheduled jobs
+	NextRunAt time.Time       `json:"next_run_at,omitempty"`
+	DedupeKey string          `json:"dedupe_key,omitempty"` // At most one pending or running job per key
+
+	// Priority orders pending jobs: higher ones are dequeued first, those of
+	// equal priority oldest first
+	Priority int `json:"priority"`
+
+	// TraceContext carries the trace of the request that enqueued the job, so
+	// processing it continues that trace
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+
+	// Checkpoint is the progress saved by a running job, e.g. the commit page a
+	// sync reached. It is kept when the job is requeued or retried, so the next
+	// run resumes there
+	Checkpoint json.RawMessage `json:"checkpoint,omitempty"`
+
+	// Result is what the job's last run did, e.g. the commits a sync stored
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// StartedAt is when a worker first picked the job up and FinishedAt when it
+	// last completed or failed
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	// Existing is set by Enqueue when an active job with the same DedupeKey was
+	// found; the job then describes that job instead of a new one
+	Existing bool `json:"-"`
 
 	// Retry configuration
 	RetryCount     int           `json:"retry_count"`
@@ -53,18 +110,133 @@ type Job struct {
 	InitialBackoff time.Duration `json:"initial_backoff"`
 }
 
+// MarshalJSON encodes the job with its scheduling times as RFC3339 strings, omitting
+// unset ones. next_retry_at and retry_in, the time left until then, are only
+// set while a retry is scheduled.
+func (j Job) MarshalJSON() ([]byte, error) {
+	type jobAlias Job
+	return json.Marshal(struct {
+		jobAlias
+		NextRunAt   string `json:"next_run_at,omitempty"`
+		LastRetryAt string `json:"last_retry_at,omitempty"`
+		NextRetryAt string `json:"next_retry_at,omitempty"`
+		StartedAt   string `json:"started_at,omitempty"`
+		FinishedAt  string `json:"finished_at,omitempty"`
+		RetryIn     string `json:"retry_in,omitempty"`
+	}{
+		jobAlias:    jobAlias(j),
+		NextRunAt:   formatTime(j.NextRunAt),
+		LastRetryAt: formatTime(j.LastRetryAt),
+		NextRetryAt: formatTime(j.retryAt()),
+		StartedAt:   formatTime(j.StartedAt),
+		FinishedAt:  formatTime(j.FinishedAt),
+		RetryIn:     j.retryIn(time.Now()),
+	})
+}
+
+// retryAt returns when a job waiting to be retried after a failure runs again,
+// or the zero time when it isn't waiting for a retry. NextRetryAt stays set
+// while the retry runs, so only pending jobs count.
+func (j Job) retryAt() time.Time {
+	if j.Status != JobStatusPending || j.RetryCount == 0 {
+		return time.Time{}
+	}
+	return j.NextRetryAt
+}
+
+// retryIn returns the human-readable time left until a failed job is retried,
+// or an empty string when no retry is scheduled
+func (j Job) retryIn(now time.Time) string {
+	next := j.retryAt()
+	if next.IsZero() {
+		return ""
+	}
+	if !next.After(now) {
+		return "0s"
+	}
+	return next.Sub(now).Round(time.Second).String()
+}
+
+// formatTime formats t as RFC3339, or returns an empty string for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SyncDedupeKey returns the dedupe key that allows one queued sync of a repository at a time
+func SyncDedupeKey(owner, repo string) string {
+	return "sync:" + strings.ToLower(owner+"/"+repo)
+}
+
 // SyncPayload represents the payload for sync jobs
 type SyncPayload struct {
+	Owner string     `json:"owner"`
+	Repo  string     `json:"repo"`
+	Since *time.Time `json:"since,omitempty"` // Full history when zero; when unset, sync jobs fetch the full history and resync jobs the default window
+}
+
+// SyncCheckpoint is the checkpoint of sync and resync jobs
+type SyncCheckpoint struct {
+	Page int `json:"page"` // Last commit page whose commits are stored
+}
+
+// ReportDedupeKey returns the dedupe key that allows one queued report of a repository and month at a time
+func ReportDedupeKey(owner, repo, month string) string {
+	return "report:" + strings.ToLower(owner+"/"+repo) + ":" + month
+}
+
+// ReportPayload represents the payload for monthly report jobs
+type ReportPayload struct {
 	Owner string `json:"owner"`
 	Repo  string `json:"repo"`
+	Month string `json:"month"` // YYYY-MM
+}
+
+// DigestDedupeKey returns the dedupe key that allows one queued digest of a
+// repository and period at a time. Without a repository it is the key of the
+// scheduled digests of every monitored repository.
+func DigestDedupeKey(owner, repo, period, start string) string {
+	if owner == "" {
+		return "digest:" + period
+	}
+	return "digest:" + strings.ToLower(owner+"/"+repo) + ":" + period + ":" + start
+}
+
+// DigestPayload represents the payload for digest jobs. Without a repository
+// the job digests the last complete period of every monitored repository that
+// hasn't been digested yet.
+type DigestPayload struct {
+	Owner  string `json:"owner,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	Period string `json:"period"`          // weekly or monthly
+	Start  string `json:"start,omitempty"` // First day of the period (YYYY-MM-DD); the last complete period when unset
+}
+
+// MaintenancePayload represents the payload for maintenance jobs
+type MaintenancePayload struct {
+	Tasks []string `json:"tasks"`
 }
 
 // Queue interface defines the methods for job queue operations
 type Queue interface {
-	Enqueue(job *Job) error
-	Dequeue() (*Job, error)
-	Complete(jobID string) error
-	Fail(jobID string, err error) error
-	GetStatus(jobID string) (JobStatus, error)
-	GetJobs() ([]*Job, error)
+	Enqueue(ctx context.Context, job *Job) error
+	Dequeue(ctx context.Context) (*Job, error)
+	Complete(ctx context.Context, jobID string) error
+	Fail(ctx context.Context, jobID string, err error, retryAt time.Time) error
+	Requeue(ctx context.Context, jobID string) error
+	SaveCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error
+	SaveResult(ctx context.Context, jobID string, result json.RawMessage) error
+	Heartbeat(ctx context.Context, jobID string) error
+	ReapStuckJobs(ctx context.Context, timeout time.Duration) (int64, error)
+	RecordPanic(ctx context.Context, jobID string, err error) (quarantined bool, recordErr error)
+	Release(ctx context.Context, jobID string) (*Job, error)
+	GetStatus(ctx context.Context, jobID string) (JobStatus, error)
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+	GetActiveJob(ctx context.Context, dedupeKey string) (*Job, error)
+	GetLatencyStats(ctx context.Context, since, until time.Time, startSLO time.Duration) ([]*JobLatency, error)
+	GetQueueDepth(ctx context.Context) (*QueueDepth, error)
+	GetJobs(ctx context.Context) ([]*Job, error)
+	StreamJobs(ctx context.Context, fn func(*Job) error) error
 }
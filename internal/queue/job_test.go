@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJobMarshalJSON(t *testing.T) {
+	t.Run("job waiting for a retry", func(t *testing.T) {
+		next := time.Now().Add(90 * time.Second)
+		job := Job{ID: "1", Status: JobStatusPending, RetryCount: 1, NextRetryAt: next}
+
+		data, err := json.Marshal(job)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if got["next_retry_at"] != next.UTC().Format(time.RFC3339) {
+			t.Errorf("next_retry_at = %v, want %s", got["next_retry_at"], next.UTC().Format(time.RFC3339))
+		}
+		if got["retry_in"] == nil || got["retry_in"] == "" {
+			t.Errorf("expected retry_in to be set, got %v", got["retry_in"])
+		}
+	})
+
+	t.Run("retry of a job that failed for good is omitted", func(t *testing.T) {
+		data, err := json.Marshal(&Job{ID: "3", Status: JobStatusFailed, RetryCount: 4, NextRetryAt: time.Now().Add(time.Minute)})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		for _, field := range []string{"next_retry_at", "retry_in"} {
+			if _, ok := got[field]; ok {
+				t.Errorf("expected %s to be omitted, got %v", field, got[field])
+			}
+		}
+	})
+
+	t.Run("unset times are omitted", func(t *testing.T) {
+		data, err := json.Marshal(&Job{ID: "2", Status: JobStatusPending})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		for _, field := range []string{"next_run_at", "last_retry_at", "next_retry_at", "retry_in"} {
+			if _, ok := got[field]; ok {
+				t.Errorf("expected %s to be omitted, got %v", field, got[field])
+			}
+		}
+	})
+}
+
+func TestJobRetryIn(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		job  Job
+		want string
+	}{
+		{name: "pending", job: Job{Status: JobStatusPending}, want: ""},
+		{name: "failed for good", job: Job{Status: JobStatusFailed, RetryCount: 4, NextRetryAt: now.Add(time.Minute)}, want: ""},
+		{name: "retry running", job: Job{Status: JobStatusRunning, RetryCount: 1, NextRetryAt: now.Add(-time.Minute)}, want: ""},
+		{name: "retry due", job: Job{Status: JobStatusPending, RetryCount: 1, NextRetryAt: now.Add(-time.Minute)}, want: "0s"},
+		{name: "retry pending", job: Job{Status: JobStatusPending, RetryCount: 2, NextRetryAt: now.Add(2*time.Minute + 400*time.Millisecond)}, want: "2m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.job.retryIn(now); got != tt.want {
+				t.Errorf("retryIn() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the job latency histogram buckets
+var LatencyBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	15 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+}
+
+// LatencyHistogram summarizes durations in seconds
+type LatencyHistogram struct {
+	Count   int             `json:"count"`
+	P50     float64         `json:"p50_seconds"`
+	P95     float64         `json:"p95_seconds"`
+	P99     float64         `json:"p99_seconds"`
+	Max     float64         `json:"max_seconds"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+// LatencyBucket counts the durations up to LE, including those of the
+// smaller buckets as Prometheus histograms do
+type LatencyBucket struct {
+	LE    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+// JobLatency describes how long jobs of a type waited in the queue and ran
+type JobLatency struct {
+	Type JobType          `json:"type"`
+	Wait LatencyHistogram `json:"wait"` // From enqueueing, or the scheduled run time, to the first start
+	Run  LatencyHistogram `json:"run"`  // From the first start to completion or failure, of finished jobs
+
+	// StartedWithinSLO is the percentage of the jobs that started within the
+	// start SLO of being due
+	StartedWithinSLO float64 `json:"started_within_slo"`
+}
+
+// latencySample is the timing of one started job
+type latencySample struct {
+	jobType JobType
+	wait    float64  // Seconds
+	run     *float64 // Seconds; nil while running
+}
+
+// GetLatencyStats reports the queue latencies of the jobs first started within
+// since and until, per job type
+func (q *PostgresQueue) GetLatencyStats(ctx context.Context, since, until time.Time, startSLO time.Duration) ([]*JobLatency, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT type,
+			GREATEST(EXTRACT(EPOCH FROM started_at - GREATEST(created_at, COALESCE(next_run_at, created_at))), 0),
+			EXTRACT(EPOCH FROM finished_at - started_at)
+		FROM jobs
+		WHERE started_at >= $1 AND started_at <= $2
+	`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []latencySample
+	for rows.Next() {
+		var sample latencySample
+		if err := rows.Scan(&sample.jobType, &sample.wait, &sample.run); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return latencyReport(samples, startSLO), nil
+}
+
+// latencyReport aggregates the samples by job type, in type order
+func latencyReport(samples []latencySample, startSLO time.Duration) []*JobLatency {
+	waits := make(map[JobType][]float64)
+	runs := make(map[JobType][]float64)
+	for _, sample := range samples {
+		waits[sample.jobType] = append(waits[sample.jobType], sample.wait)
+		if sample.run != nil {
+			runs[sample.jobType] = append(runs[sample.jobType], *sample.run)
+		}
+	}
+
+	report := make([]*JobLatency, 0, len(waits))
+	for jobType, wait := range waits {
+		withinSLO := 0
+		for _, seconds := range wait {
+			if seconds <= startSLO.Seconds() {
+				withinSLO++
+			}
+		}
+		report = append(report, &JobLatency{
+			Type:             jobType,
+			Wait:             latencyHistogram(wait),
+			Run:              latencyHistogram(runs[jobType]),
+			StartedWithinSLO: roundLatency(100 * float64(withinSLO) / float64(len(wait))),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Type < report[j].Type })
+	return report
+}
+
+// latencyHistogram summarizes durations in seconds
+func latencyHistogram(seconds []float64) LatencyHistogram {
+	sorted := append([]float64(nil), seconds...)
+	sort.Float64s(sorted)
+
+	histogram := LatencyHistogram{
+		Count:   len(sorted),
+		P50:     percentile(sorted, 0.50),
+		P95:     percentile(sorted, 0.95),
+		P99:     percentile(sorted, 0.99),
+		Buckets: make([]LatencyBucket, 0, len(LatencyBuckets)+1),
+	}
+	if len(sorted) > 0 {
+		histogram.Max = roundLatency(sorted[len(sorted)-1])
+	}
+	for _, bound := range LatencyBuckets {
+		count := sort.Search(len(sorted), func(i int) bool { return sorted[i] > bound.Seconds() })
+		histogram.Buckets = append(histogram.Buckets, LatencyBucket{LE: bound.String(), Count: count})
+	}
+	histogram.Buckets = append(histogram.Buckets, LatencyBucket{LE: "+Inf", Count: len(sorted)})
+	return histogram
+}
+
+// percentile returns the nearest-rank percentile of sorted durations, or 0
+// without any
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return roundLatency(sorted[rank])
+}
+
+// roundLatency rounds to milliseconds, or hundredths of a percent
+func roundLatency(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
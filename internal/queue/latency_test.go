@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyReport(t *testing.T) {
+	seconds := func(s float64) *float64 { return &s }
+	samples := []latencySample{
+		{jobType: JobTypeSync, wait: 2, run: seconds(30)},
+		{jobType: JobTypeSync, wait: 45, run: seconds(90)},
+		{jobType: JobTypeSync, wait: 70, run: nil}, // Still running
+		{jobType: JobTypeSync, wait: 0.5, run: seconds(10)},
+		{jobType: JobTypeCleanup, wait: 3, run: seconds(1)},
+	}
+
+	report := latencyReport(samples, time.Minute)
+	if len(report) != 2 || report[0].Type != JobTypeCleanup || report[1].Type != JobTypeSync {
+		t.Fatalf("report types = %v, want cleanup then sync", report)
+	}
+
+	sync := report[1]
+	if sync.StartedWithinSLO != 75 {
+		t.Errorf("started within SLO = %v, want 75", sync.StartedWithinSLO)
+	}
+	if sync.Wait.Count != 4 || sync.Run.Count != 3 {
+		t.Errorf("counts = %d waits, %d runs, want 4 and 3", sync.Wait.Count, sync.Run.Count)
+	}
+	if sync.Wait.P50 != 2 || sync.Wait.P99 != 70 || sync.Wait.Max != 70 {
+		t.Errorf("wait percentiles = p50 %v, p99 %v, max %v, want 2, 70, 70", sync.Wait.P50, sync.Wait.P99, sync.Wait.Max)
+	}
+
+	// Buckets are cumulative and end with +Inf holding every sample
+	want := map[string]int{"1s": 1, "5s": 2, "1m0s": 3, "5m0s": 4, "+Inf": 4}
+	for _, bucket := range sync.Wait.Buckets {
+		if count, ok := want[bucket.LE]; ok && bucket.Count != count {
+			t.Errorf("wait bucket %s = %d, want %d", bucket.LE, bucket.Count, count)
+		}
+	}
+	if last := sync.Wait.Buckets[len(sync.Wait.Buckets)-1]; last.LE != "+Inf" {
+		t.Errorf("last bucket = %s, want +Inf", last.LE)
+	}
+}
+
+func TestLatencyHistogramEmpty(t *testing.T) {
+	histogram := latencyHistogram(nil)
+	if histogram.Count != 0 || histogram.P95 != 0 || histogram.Max != 0 {
+		t.Errorf("empty histogram = %+v", histogram)
+	}
+	if len(histogram.Buckets) != len(LatencyBuckets)+1 {
+		t.Errorf("buckets = %d, want %d", len(histogram.Buckets), len(LatencyBuckets)+1)
+	}
+}
@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the Postgres channel on which job availability is announced
+const notifyChannel = "jobs_available"
+
+// listenerPingInterval is how often an idle listener checks its connection
+const listenerPingInterval = 90 * time.Second
+
+// notifyJobsAvailable tells listening workers that a job of jobType can be dequeued.
+// Workers also poll, so a failed notification only delays the job.
+func notifyJobsAvailable(ctx context.Context, db *sql.DB, jobType JobType) {
+	db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, string(jobType))
+}
+
+// Notifier wakes workers when jobs become available, using Postgres LISTEN/NOTIFY
+type Notifier struct {
+	listener *pq.Listener
+
+	mu   sync.Mutex
+	wake chan struct{}
+}
+
+// NewNotifier listens for job notifications on a dedicated connection to the
+// database at dsn. Run must be called for notifications to be delivered.
+func NewNotifier(dsn string) (*Notifier, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, nil)
+	if err := listener.Listen(notifyChannel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen for job notifications: %w", err)
+	}
+	return &Notifier{listener: listener, wake: make(chan struct{})}, nil
+}
+
+// Wait returns a channel that is closed by the next notification
+func (n *Notifier) Wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.wake
+}
+
+// broadcast wakes everyone waiting for a notification
+func (n *Notifier) broadcast() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.wake)
+	n.wake = make(chan struct{})
+}
+
+// Run delivers notifications until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.listener.Notify:
+			// A nil notification follows a reconnect, after which notifications
+			// sent while disconnected are lost; waking up covers them too
+			n.broadcast()
+		case <-time.After(listenerPingInterval):
+			go n.listener.Ping()
+		}
+	}
+}
+
+// Close stops listening and closes the connection
+func (n *Notifier) Close() error {
+	return n.listener.Close()
+}
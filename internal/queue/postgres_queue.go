@@ -1,17 +1,28 @@
 package queue
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
+	"github-service/internal/errors"
+
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // PostgresQueue implements Queue interface using PostgreSQL
 type PostgresQueue struct {
 	db *sql.DB
+
+	mu     sync.RWMutex
+	limits map[JobType]int // Most jobs of a type running at once across all workers
+	lease  time.Duration   // How long a running job may go without an update before another worker takes it over
+
+	maxPanics int // Panics of jobs with the same payload before it is quarantined; 0 never quarantines
 }
 
 // NewPostgresQueue creates a new PostgreSQL-based queue
@@ -19,18 +30,79 @@ func NewPostgresQueue(db *sql.DB) (*PostgresQueue, error) {
 	if err := initializeQueueSchema(db); err != nil {
 		return nil, fmt.Errorf("failed to initialize queue schema: %w", err)
 	}
-	return &PostgresQueue{db: db}, nil
+	return &PostgresQueue{db: db, limits: make(map[JobType]int), maxPanics: DefaultMaxPanics}, nil
 }
 
-func initializeQueueSchema(db *sql.DB) error {
-	// First drop the existing table to recreate with the correct schema
-	dropSchema := `DROP TABLE IF EXISTS jobs;`
-	if _, err := db.Exec(dropSchema); err != nil {
-		return err
+// SetConcurrencyLimit bounds how many jobs of a type run at once across all
+// workers sharing the queue. Jobs over the limit stay pending. Zero removes the limit.
+func (q *PostgresQueue) SetConcurrencyLimit(jobType JobType, limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if limit <= 0 {
+		delete(q.limits, jobType)
+		return
+	}
+	q.limits[jobType] = limit
+}
+
+// SetLeaseDuration lets Dequeue take over running jobs that have not been updated
+// for d, e.g. because their worker died. It must exceed the heartbeat interval
+// of the workers, or the longest job run without heartbeats. Zero leaves running
+// jobs to RequeueRunningJobs and ReapStuckJobs.
+func (q *PostgresQueue) SetLeaseDuration(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lease = d
+}
+
+// SetMaxPanics sets how many times jobs with the same payload may crash their
+// handler before further jobs with it are quarantined. Zero never quarantines.
+func (q *PostgresQueue) SetMaxPanics(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.maxPanics = n
+}
+
+// saturatedTypes returns the job types that have reached their concurrency limit,
+// as a non-nil slice so it can be passed to ANY
+func (q *PostgresQueue) saturatedTypes(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	q.mu.RLock()
+	limits := make(map[JobType]int, len(q.limits))
+	for jobType, limit := range q.limits {
+		limits[jobType] = limit
+	}
+	q.mu.RUnlock()
+
+	saturated := []string{}
+	if len(limits) == 0 {
+		return saturated, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT type, COUNT(*) FROM jobs WHERE status = $1 GROUP BY type`, JobStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jobType JobType
+		var running int
+		if err := rows.Scan(&jobType, &running); err != nil {
+			return nil, err
+		}
+		if limit, ok := limits[jobType]; ok && running >= limit {
+			saturated = append(saturated, string(jobType))
+		}
 	}
+	return saturated, rows.Err()
+}
 
-	schema := `
-		CREATE TABLE jobs (
+// queueMigrations holds the versioned schema changes for the queue tables.
+// Each entry is applied once, in order; released entries must never be edited.
+var queueMigrations = []string{
+	// 1: initial jobs table
+	`
+		CREATE TABLE IF NOT EXISTS jobs (
 			id TEXT PRIMARY KEY,
 			type TEXT NOT NULL,
 			status TEXT NOT NULL,
@@ -51,12 +123,172 @@ func initializeQueueSchema(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_jobs_type ON jobs(type);
 		CREATE INDEX IF NOT EXISTS idx_jobs_next_run ON jobs(next_run_at) WHERE status = 'pending';
 		CREATE INDEX IF NOT EXISTS idx_jobs_next_retry ON jobs(next_retry_at) WHERE status = 'failed';
+	`,
+	// 2: dedupe keys of active jobs
+	`
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS dedupe_key TEXT;
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_dedupe_active ON jobs(dedupe_key) WHERE status IN ('pending', 'running');
+	`,
+	// 3: trace context of the request that enqueued a job
+	`
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS trace_context JSONB;
+	`,
+	// 4: progress checkpoints of resumable jobs
 	`
-	_, err := db.Exec(schema)
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS checkpoint JSONB;
+	`,
+	// 5: panics counted per job payload
+	`
+		CREATE TABLE IF NOT EXISTS job_poison (
+			key TEXT PRIMARY KEY,
+			panics INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`,
+	// 6: start and finish times for queue latency metrics
+	`
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP WITH TIME ZONE;
+
+		CREATE INDEX IF NOT EXISTS idx_jobs_started_at ON jobs(started_at);
+	`,
+	// 7: job priorities
+	`
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+		CREATE INDEX IF NOT EXISTS idx_jobs_pending_priority ON jobs(priority DESC, (COALESCE(next_run_at, created_at))) WHERE status = 'pending';
+	`,
+	// 8: results of finished jobs
+	`
+		ALTER TABLE jobs ADD COLUMN IF NOT EXISTS result JSONB;
+	`,
+}
+
+// poisonKey identifies the jobs of a row sharing its payload in job_poison: by
+// their dedupe key when they have one, otherwise by their type and payload
+const poisonKey = `COALESCE(dedupe_key, type || ':' || md5(COALESCE(payload::text, '')))`
+
+// initializeQueueSchema applies any queue migrations that have not been applied yet.
+// Existing jobs are preserved across restarts. Replicas starting together serialize
+// on an advisory lock, so each migration is applied by exactly one of them.
+func initializeQueueSchema(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Transaction level, so the lock is released on commit or rollback
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('queue_schema'))`); err != nil {
+		return fmt.Errorf("acquiring schema lock: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS queue_schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("creating migrations table: %w", err)
+	}
+
+	var current int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM queue_schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for i := current; i < len(queueMigrations); i++ {
+		version := i + 1
+		if err := applyQueueMigration(tx, version, queueMigrations[i]); err != nil {
+			return fmt.Errorf("applying queue migration %d: %w", version, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// applyQueueMigration runs a single migration and records its version
+func applyQueueMigration(tx *sql.Tx, version int, migration string) error {
+	if _, err := tx.Exec(migration); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO queue_schema_migrations (version) VALUES ($1)`, version)
 	return err
 }
 
-func (q *PostgresQueue) Enqueue(job *Job) error {
+// RequeueRunningJobs returns jobs left in the running state without a heartbeat
+// for timeout, e.g. by a crash, to pending. Jobs with a recent heartbeat are
+// left alone, as other instances sharing the queue may be running them. Zero,
+// when workers send no heartbeats, requeues every running job, as nothing else
+// would ever return them to the queue. Their checkpoints are kept, so the
+// resumable ones continue where they stopped; resumed counts those that saved
+// one. It should be called on startup before workers start.
+func (q *PostgresQueue) RequeueRunningJobs(ctx context.Context, timeout time.Duration) (requeued, resumed int64, err error) {
+	now := time.Now()
+	query := `
+		WITH requeued AS (
+			UPDATE jobs
+			SET status = $1, updated_at = $2, error = $3
+			WHERE status = $4 AND updated_at <= $5
+			RETURNING checkpoint
+		)
+		SELECT COUNT(*), COUNT(checkpoint) FROM requeued
+	`
+	err = q.db.QueryRowContext(ctx, query, JobStatusPending, now, "requeued after interrupted run", JobStatusRunning, now.Add(-max(timeout, 0))).Scan(&requeued, &resumed)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to requeue running jobs: %w", err)
+	}
+	if requeued > 0 {
+		notifyJobsAvailable(ctx, q.db, "")
+	}
+	return requeued, resumed, nil
+}
+
+// SaveCheckpoint records the progress of a running job
+func (q *PostgresQueue) SaveCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET checkpoint = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`, []byte(checkpoint), time.Now(), jobID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to save job checkpoint: %w", err)
+	}
+	return nil
+}
+
+// SaveResult records what a running job did, e.g. the commits a sync stored.
+// Results of failed runs are kept until the next run saves its own.
+func (q *PostgresQueue) SaveResult(ctx context.Context, jobID string, result json.RawMessage) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET result = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`, []byte(result), time.Now(), jobID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to save job result: %w", err)
+	}
+	return nil
+}
+
+// Requeue returns a running job to pending without counting a retry, e.g. when a
+// worker shuts down before the job finishes
+func (q *PostgresQueue) Requeue(ctx context.Context, jobID string) error {
+	query := `
+		UPDATE jobs
+		SET status = $1, updated_at = $2, error = $3
+		WHERE id = $4 AND status = $5
+	`
+	_, err := q.db.ExecContext(ctx, query, JobStatusPending, time.Now(), "requeued after shutdown", jobID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	notifyJobsAvailable(ctx, q.db, "")
+	return nil
+}
+
+func (q *PostgresQueue) Enqueue(ctx context.Context, job *Job) error {
 	if job.ID == "" {
 		job.ID = uuid.New().String()
 	}
@@ -75,71 +307,144 @@ func (q *PostgresQueue) Enqueue(job *Job) error {
 		job.InitialBackoff = DefaultInitialBackoff
 	}
 
+	var nextRunAt sql.NullTime
+	if !job.NextRunAt.IsZero() {
+		nextRunAt = sql.NullTime{Time: job.NextRunAt, Valid: true}
+	}
+	dedupeKey := sql.NullString{String: job.DedupeKey, Valid: job.DedupeKey != ""}
+	var traceContext []byte
+	if len(job.TraceContext) > 0 {
+		encoded, err := json.Marshal(job.TraceContext)
+		if err != nil {
+			return fmt.Errorf("failed to marshal trace context: %w", err)
+		}
+		traceContext = encoded
+	}
+
+	// Payloads that kept crashing their handler are stored but not run
+	poisoned, err := q.poisoned(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to check job quarantine: %w", err)
+	}
+	if poisoned {
+		job.Status = JobStatusQuarantined
+	}
+
 	query := `
 		INSERT INTO jobs (
 			id, type, status, payload, created_at, updated_at, error,
-			retry_count, max_retries, initial_backoff
+			retry_count, max_retries, initial_backoff, next_run_at, dedupe_key, trace_context, priority
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (dedupe_key) WHERE status IN ('pending', 'running') DO NOTHING
 	`
-	_, err := q.db.Exec(
-		query,
-		job.ID, job.Type, job.Status, job.Payload, job.CreatedAt, job.UpdatedAt, job.Error,
-		job.RetryCount, job.MaxRetries, int64(job.InitialBackoff),
-	)
-	return err
+
+	// The active job holding the key may finish between the insert and the
+	// lookup, in which case the insert is tried again
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := q.db.ExecContext(ctx,
+			query,
+			job.ID, job.Type, job.Status, job.Payload, job.CreatedAt, job.UpdatedAt, job.Error,
+			job.RetryCount, job.MaxRetries, int64(job.InitialBackoff), nextRunAt, dedupeKey, traceContext, job.Priority,
+		)
+		if err != nil {
+			return err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if inserted > 0 {
+			if !poisoned {
+				notifyJobsAvailable(ctx, q.db, job.Type)
+			}
+			return nil
+		}
+
+		existing, err := q.GetActiveJob(ctx, job.DedupeKey)
+		if err != nil {
+			return fmt.Errorf("failed to look up duplicate job: %w", err)
+		}
+		if existing == nil {
+			continue
+		}
+		// A duplicate asked for more urgently raises the pending job's priority
+		if existing.Status == JobStatusPending && job.Priority > existing.Priority {
+			if _, err := q.db.ExecContext(ctx, `
+				UPDATE jobs SET priority = $2, updated_at = $3
+				WHERE id = $1 AND status = 'pending' AND priority < $2
+			`, existing.ID, job.Priority, time.Now()); err != nil {
+				return fmt.Errorf("failed to raise job priority: %w", err)
+			}
+			existing.Priority = job.Priority
+		}
+		*job = *existing
+		job.Existing = true
+		return nil
+	}
+	return fmt.Errorf("failed to enqueue job with dedupe key %s", job.DedupeKey)
 }
 
-func (q *PostgresQueue) Dequeue() (*Job, error) {
-	tx, err := q.db.Begin()
+// poisoned reports whether jobs with the payload of job are quarantined
+func (q *PostgresQueue) poisoned(ctx context.Context, job *Job) (bool, error) {
+	q.mu.RLock()
+	maxPanics := q.maxPanics
+	q.mu.RUnlock()
+	if maxPanics <= 0 {
+		return false, nil
+	}
+
+	var poisoned bool
+	err := q.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM job_poison
+			WHERE key = COALESCE($1, $2::text || ':' || md5(COALESCE($3::jsonb::text, '')))
+				AND panics >= $4
+		)
+	`, sql.NullString{String: job.DedupeKey, Valid: job.DedupeKey != ""}, job.Type, []byte(job.Payload), maxPanics).Scan(&poisoned)
+	return poisoned, err
+}
+
+func (q *PostgresQueue) Dequeue(ctx context.Context) (*Job, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	saturated, err := q.saturatedTypes(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count running jobs: %w", err)
+	}
+
+	q.mu.RLock()
+	lease := q.lease
+	q.mu.RUnlock()
+
+	// Jobs scheduled for later, e.g. staggered imports, wait until their run time.
+	// Running jobs whose lease expired are taken over as if they were pending.
+	now := time.Now()
+	var leaseExpiry sql.NullTime
+	if lease > 0 {
+		leaseExpiry = sql.NullTime{Time: now.Add(-lease), Valid: true}
+	}
 	query := `
 		UPDATE jobs
-		SET status = $1, updated_at = $2
+		SET status = $1, updated_at = $2, started_at = COALESCE(started_at, $2)
 		WHERE id = (
 			SELECT id
 			FROM jobs
-			WHERE status = $3
-			ORDER BY created_at ASC
+			WHERE ((status = $3
+					AND (next_run_at IS NULL OR next_run_at <= $2)
+					AND NOT (type = ANY($4)))
+				OR (status = $1 AND updated_at < $5))
+			ORDER BY priority DESC, COALESCE(next_run_at, created_at) ASC
 			FOR UPDATE SKIP LOCKED
 			LIMIT 1
 		)
-		RETURNING id, type, status, payload, created_at, updated_at, error, schedule,
-			retry_count, max_retries, last_retry_at, next_retry_at, initial_backoff
-	`
-
-	job := &Job{
-		MaxRetries:     DefaultMaxRetries,
-		InitialBackoff: DefaultInitialBackoff,
-	}
-
-	var errMsg sql.NullString
-	var schedule sql.NullString
-	var payload []byte
-	var lastRetryAt, nextRetryAt sql.NullTime
-	var initialBackoff sql.NullInt64
-
-	row := tx.QueryRow(query, JobStatusRunning, time.Now(), JobStatusPending)
-	err = row.Scan(
-		&job.ID,
-		&job.Type,
-		&job.Status,
-		&payload,
-		&job.CreatedAt,
-		&job.UpdatedAt,
-		&errMsg,
-		&schedule,
-		&job.RetryCount,
-		&job.MaxRetries,
-		&lastRetryAt,
-		&nextRetryAt,
-		&initialBackoff,
-	)
+		RETURNING ` + jobColumns
 
+	job, err := scanJob(tx.QueryRowContext(ctx, query, JobStatusRunning, now, JobStatusPending, pq.Array(saturated), leaseExpiry))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -147,26 +452,6 @@ func (q *PostgresQueue) Dequeue() (*Job, error) {
 		return nil, err
 	}
 
-	// Handle nullable fields
-	if len(payload) > 0 {
-		job.Payload = json.RawMessage(payload)
-	}
-	if errMsg.Valid {
-		job.Error = errMsg.String
-	}
-	if schedule.Valid {
-		job.Schedule = schedule.String
-	}
-	if lastRetryAt.Valid {
-		job.LastRetryAt = lastRetryAt.Time
-	}
-	if nextRetryAt.Valid {
-		job.NextRetryAt = nextRetryAt.Time
-	}
-	if initialBackoff.Valid {
-		job.InitialBackoff = time.Duration(initialBackoff.Int64)
-	}
-
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -174,19 +459,24 @@ func (q *PostgresQueue) Dequeue() (*Job, error) {
 	return job, nil
 }
 
-func (q *PostgresQueue) Complete(jobID string) error {
+func (q *PostgresQueue) Complete(ctx context.Context, jobID string) error {
 	query := `
 		UPDATE jobs
 		SET 
 			status = $1,
-			updated_at = $2
+			updated_at = $2,
+			finished_at = $2,
+			next_retry_at = NULL
 		WHERE id = $3
 	`
-	_, err := q.db.Exec(query, JobStatusComplete, time.Now(), jobID)
+	_, err := q.db.ExecContext(ctx, query, JobStatusComplete, time.Now(), jobID)
 	return err
 }
 
-func (q *PostgresQueue) Fail(jobID string, err error) error {
+// Fail records a failed run of a job. With a retryAt the job is returned to
+// pending and dequeued again from then on, keeping its dedupe key and
+// checkpoint; with the zero time it fails for good.
+func (q *PostgresQueue) Fail(ctx context.Context, jobID string, err error, retryAt time.Time) error {
 	query := `
 		UPDATE jobs
 		SET 
@@ -194,34 +484,141 @@ func (q *PostgresQueue) Fail(jobID string, err error) error {
 			updated_at = $2,
 			error = $3,
 			retry_count = COALESCE(retry_count, 0) + 1,
-			last_retry_at = $4,
-			next_retry_at = $5
-		WHERE id = $6
-		RETURNING retry_count
+			last_retry_at = $2,
+			next_retry_at = $4,
+			next_run_at = COALESCE($4, next_run_at),
+			finished_at = $2
+		WHERE id = $5
 	`
+	status := JobStatusFailed
+	var nextRetryAt sql.NullTime
+	if !retryAt.IsZero() {
+		status = JobStatusPending
+		nextRetryAt = sql.NullTime{Time: retryAt, Valid: true}
+	}
+	if _, execErr := q.db.ExecContext(ctx, query, status, time.Now(), err.Error(), nextRetryAt, jobID); execErr != nil {
+		return fmt.Errorf("failed to update job status: %w", execErr)
+	}
+	return nil
+}
+
+// RecordPanic fails a job whose handler panicked and counts the panic against
+// its payload. Once jobs with the payload have panicked maxPanics times, the job
+// and the pending jobs with the same payload are quarantined instead.
+func (q *PostgresQueue) RecordPanic(ctx context.Context, jobID string, jobErr error) (bool, error) {
+	q.mu.RLock()
+	maxPanics := q.maxPanics
+	q.mu.RUnlock()
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	var retryCount int
-	row := q.db.QueryRow(query, JobStatusFailed, now, err.Error(), now, now.Add(DefaultInitialBackoff), jobID)
-	if scanErr := row.Scan(&retryCount); scanErr != nil {
-		return fmt.Errorf("failed to update job status: %w", scanErr)
-	}
-
-	// If this was the first retry, update the initial backoff
-	if retryCount == 1 {
-		_, updateErr := q.db.Exec(`
-			UPDATE jobs 
-			SET initial_backoff = $1 
-			WHERE id = $2 AND retry_count = 1
-		`, int64(DefaultInitialBackoff), jobID)
-		if updateErr != nil {
-			return fmt.Errorf("failed to update initial backoff: %w", updateErr)
+	var key string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE jobs
+		SET status = $1, updated_at = $2, error = $3, retry_count = COALESCE(retry_count, 0) + 1, last_retry_at = $2, finished_at = $2
+		WHERE id = $4
+		RETURNING `+poisonKey,
+		JobStatusFailed, now, jobErr.Error(), jobID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return false, errors.NewNotFoundError("job", jobID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update job status: %w", err)
+	}
+
+	var panics int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO job_poison (key, panics, last_error, updated_at)
+		VALUES ($1, 1, $2, $3)
+		ON CONFLICT (key) DO UPDATE
+		SET panics = job_poison.panics + 1, last_error = EXCLUDED.last_error, updated_at = EXCLUDED.updated_at
+		RETURNING panics
+	`, key, jobErr.Error(), now).Scan(&panics)
+	if err != nil {
+		return false, fmt.Errorf("failed to count job panic: %w", err)
+	}
+
+	quarantined := maxPanics > 0 && panics >= maxPanics
+	if quarantined {
+		// Pending jobs with the same payload would crash as well
+		_, err = tx.ExecContext(ctx, `
+			UPDATE jobs
+			SET status = $1, updated_at = $2
+			WHERE (id = $3 OR status = $4) AND `+poisonKey+` = $5
+		`, JobStatusQuarantined, now, jobID, JobStatusPending, key)
+		if err != nil {
+			return false, fmt.Errorf("failed to quarantine jobs: %w", err)
 		}
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return quarantined, nil
 }
 
-func (q *PostgresQueue) GetStatus(jobID string) (JobStatus, error) {
+// Release returns a quarantined job to pending and forgets the panics counted
+// against its payload, e.g. once the handler is fixed
+func (q *PostgresQueue) Release(ctx context.Context, jobID string) (*Job, error) {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status JobStatus
+	var key string
+	err = tx.QueryRowContext(ctx, `SELECT status, `+poisonKey+` FROM jobs WHERE id = $1 FOR UPDATE`, jobID).Scan(&status, &key)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("job", jobID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != JobStatusQuarantined {
+		return nil, fmt.Errorf("%w: job %s is not quarantined but %s", errors.ErrConflict, jobID, status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM job_poison WHERE key = $1`, key); err != nil {
+		return nil, fmt.Errorf("failed to reset job panics: %w", err)
+	}
+	job, err := scanJob(tx.QueryRowContext(ctx, `
+		UPDATE jobs
+		SET status = $1, updated_at = $2, next_run_at = NULL, finished_at = NULL
+		WHERE id = $3
+		RETURNING `+jobColumns,
+		JobStatusPending, time.Now(), jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to release job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	notifyJobsAvailable(ctx, q.db, job.Type)
+	return job, nil
+}
+
+// GetActiveJob returns the pending or running job holding a dedupe key, or nil
+// when there is none
+func (q *PostgresQueue) GetActiveJob(ctx context.Context, dedupeKey string) (*Job, error) {
+	job, err := scanJob(q.db.QueryRowContext(ctx, `
+		SELECT `+jobColumns+`
+		FROM jobs
+		WHERE dedupe_key = $1 AND status IN ('pending', 'running')
+	`, dedupeKey))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (q *PostgresQueue) GetStatus(ctx context.Context, jobID string) (JobStatus, error) {
 	query := `
 		SELECT status, error 
 		FROM jobs 
@@ -231,9 +628,9 @@ func (q *PostgresQueue) GetStatus(jobID string) (JobStatus, error) {
 	var status JobStatus
 	var errMsg sql.NullString
 
-	err := q.db.QueryRow(query, jobID).Scan(&status, &errMsg)
+	err := q.db.QueryRowContext(ctx, query, jobID).Scan(&status, &errMsg)
 	if err == sql.ErrNoRows {
-		return "", fmt.Errorf("job not found")
+		return "", errors.NewNotFoundError("job", jobID)
 	}
 	if err != nil {
 		return "", err
@@ -242,79 +639,145 @@ func (q *PostgresQueue) GetStatus(jobID string) (JobStatus, error) {
 	return status, nil
 }
 
+// GetJob returns a job by its ID
+func (q *PostgresQueue) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	job, err := scanJob(q.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, jobID))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFoundError("job", jobID)
+	}
+	return job, err
+}
+
+// jobColumns lists the job columns in the order expected by scanJob
+const jobColumns = `id, type, status, payload, created_at, updated_at, error, schedule,
+	next_run_at, retry_count, max_retries, last_retry_at, next_retry_at, initial_backoff, dedupe_key, trace_context, checkpoint, started_at, finished_at, priority, result`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob scans a row selected with jobColumns into a job
+func scanJob(row rowScanner) (*Job, error) {
+	job := &Job{
+		MaxRetries:     DefaultMaxRetries,
+		InitialBackoff: DefaultInitialBackoff,
+	}
+
+	var errMsg sql.NullString
+	var schedule, dedupeKey sql.NullString
+	var payload, traceContext, checkpoint, result []byte
+	var nextRunAt, lastRetryAt, nextRetryAt, startedAt, finishedAt sql.NullTime
+	var initialBackoff sql.NullInt64
+
+	if err := row.Scan(
+		&job.ID,
+		&job.Type,
+		&job.Status,
+		&payload,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&errMsg,
+		&schedule,
+		&nextRunAt,
+		&job.RetryCount,
+		&job.MaxRetries,
+		&lastRetryAt,
+		&nextRetryAt,
+		&initialBackoff,
+		&dedupeKey,
+		&traceContext,
+		&checkpoint,
+		&startedAt,
+		&finishedAt,
+		&job.Priority,
+		&result,
+	); err != nil {
+		return nil, err
+	}
+
+	// Handle nullable fields
+	if len(payload) > 0 {
+		job.Payload = json.RawMessage(payload)
+	}
+	if errMsg.Valid {
+		job.Error = errMsg.String
+	}
+	if schedule.Valid {
+		job.Schedule = schedule.String
+	}
+	if dedupeKey.Valid {
+		job.DedupeKey = dedupeKey.String
+	}
+	if nextRunAt.Valid {
+		job.NextRunAt = nextRunAt.Time
+	}
+	if lastRetryAt.Valid {
+		job.LastRetryAt = lastRetryAt.Time
+	}
+	if nextRetryAt.Valid {
+		job.NextRetryAt = nextRetryAt.Time
+	}
+	if startedAt.Valid {
+		job.StartedAt = startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = finishedAt.Time
+	}
+	if initialBackoff.Valid {
+		job.InitialBackoff = time.Duration(initialBackoff.Int64)
+	}
+	if len(checkpoint) > 0 {
+		job.Checkpoint = json.RawMessage(checkpoint)
+	}
+	if len(result) > 0 {
+		job.Result = json.RawMessage(result)
+	}
+	if len(traceContext) > 0 {
+		if err := json.Unmarshal(traceContext, &job.TraceContext); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal trace context: %w", err)
+		}
+	}
+
+	return job, nil
+}
+
 // GetJobs retrieves all jobs from the queue
-func (q *PostgresQueue) GetJobs() ([]*Job, error) {
-	query := `
-		SELECT 
-			id, type, status, payload, created_at, updated_at, error, schedule,
-			retry_count, max_retries, last_retry_at, next_retry_at, initial_backoff
-		FROM jobs
-		ORDER BY created_at DESC
-	`
+func (q *PostgresQueue) GetJobs(ctx context.Context) ([]*Job, error) {
+	var jobs []*Job
+	err := q.StreamJobs(ctx, func(job *Job) error {
+		jobs = append(jobs, job)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// StreamJobs calls fn for each job, newest first, as rows are scanned
+func (q *PostgresQueue) StreamJobs(ctx context.Context, fn func(*Job) error) error {
+	query := `SELECT ` + jobColumns + ` FROM jobs ORDER BY created_at DESC`
 
-	rows, err := q.db.Query(query)
+	rows, err := q.db.QueryContext(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("error querying jobs: %w", err)
+		return fmt.Errorf("error querying jobs: %w", err)
 	}
 	defer rows.Close()
 
-	var jobs []*Job
 	for rows.Next() {
-		job := &Job{
-			MaxRetries:     DefaultMaxRetries,
-			InitialBackoff: DefaultInitialBackoff,
-		}
-
-		var errMsg sql.NullString
-		var schedule sql.NullString
-		var payload []byte
-		var lastRetryAt, nextRetryAt sql.NullTime
-		var initialBackoff sql.NullInt64
-
-		if err := rows.Scan(
-			&job.ID,
-			&job.Type,
-			&job.Status,
-			&payload,
-			&job.CreatedAt,
-			&job.UpdatedAt,
-			&errMsg,
-			&schedule,
-			&job.RetryCount,
-			&job.MaxRetries,
-			&lastRetryAt,
-			&nextRetryAt,
-			&initialBackoff,
-		); err != nil {
-			return nil, fmt.Errorf("error scanning job: %w", err)
-		}
-
-		// Handle nullable fields
-		if len(payload) > 0 {
-			job.Payload = json.RawMessage(payload)
-		}
-		if errMsg.Valid {
-			job.Error = errMsg.String
-		}
-		if schedule.Valid {
-			job.Schedule = schedule.String
-		}
-		if lastRetryAt.Valid {
-			job.LastRetryAt = lastRetryAt.Time
-		}
-		if nextRetryAt.Valid {
-			job.NextRetryAt = nextRetryAt.Time
+		job, err := scanJob(rows)
+		if err != nil {
+			return fmt.Errorf("error scanning job: %w", err)
 		}
-		if initialBackoff.Valid {
-			job.InitialBackoff = time.Duration(initialBackoff.Int64)
+		if err := fn(job); err != nil {
+			return err
 		}
-
-		jobs = append(jobs, job)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating jobs: %w", err)
+		return fmt.Errorf("error iterating jobs: %w", err)
 	}
 
-	return jobs, nil
+	return nil
 }
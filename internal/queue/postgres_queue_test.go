@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github-service/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestQueue starts a Postgres container with the queue schema
+func setupTestQueue(t *testing.T) *PostgresQueue {
+	ctx := context.Background()
+	pg, err := testutil.NewTestPostgres(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, pg.Close(ctx))
+	})
+	q, err := NewPostgresQueue(pg.DB)
+	require.NoError(t, err)
+	return q
+}
+
+func TestRequeueRunningJobs(t *testing.T) {
+	ctx := context.Background()
+
+	// runJobs enqueues and dequeues jobs, the first of which stopped sending
+	// heartbeats an hour ago
+	runJobs := func(t *testing.T, q *PostgresQueue) {
+		for _, id := range []string{"stale", "live"} {
+			require.NoError(t, q.Enqueue(ctx, &Job{ID: id, Type: JobTypeCleanup}))
+			job, err := q.Dequeue(ctx)
+			require.NoError(t, err)
+			require.Equal(t, id, job.ID)
+		}
+		require.NoError(t, q.SaveCheckpoint(ctx, "stale", []byte(`{"page":3}`)))
+		_, err := q.db.Exec(`UPDATE jobs SET updated_at = $1 WHERE id = 'stale'`, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+	}
+	status := func(t *testing.T, q *PostgresQueue, id string) JobStatus {
+		status, err := q.GetStatus(ctx, id)
+		require.NoError(t, err)
+		return status
+	}
+
+	t.Run("with heartbeats", func(t *testing.T) {
+		q := setupTestQueue(t)
+		runJobs(t, q)
+
+		requeued, resumed, err := q.RequeueRunningJobs(ctx, 15*time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), requeued)
+		assert.Equal(t, int64(1), resumed)
+		assert.Equal(t, JobStatusPending, status(t, q, "stale"))
+		assert.Equal(t, JobStatusRunning, status(t, q, "live"))
+	})
+
+	t.Run("without heartbeats", func(t *testing.T) {
+		q := setupTestQueue(t)
+		runJobs(t, q)
+
+		requeued, resumed, err := q.RequeueRunningJobs(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), requeued)
+		assert.Equal(t, int64(1), resumed)
+		assert.Equal(t, JobStatusPending, status(t, q, "stale"))
+		assert.Equal(t, JobStatusPending, status(t, q, "live"))
+	})
+}
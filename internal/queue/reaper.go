@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueueDepth describes the jobs waiting in and running from the queue
+type QueueDepth struct {
+	Pending       int             `json:"pending"` // Due and scheduled for later
+	Running       int             `json:"running"`
+	PendingByType map[JobType]int `json:"pending_by_type"`
+
+	// OldestPendingSeconds is how long the pending job that has been due the
+	// longest has waited, or 0 without any due jobs
+	OldestPendingSeconds float64 `json:"oldest_pending_seconds"`
+}
+
+// Heartbeat records that a running job is still being worked on, so it isn't
+// taken for stuck
+func (q *PostgresQueue) Heartbeat(ctx context.Context, jobID string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET updated_at = $1
+		WHERE id = $2 AND status = $3
+	`, time.Now(), jobID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to record job heartbeat: %w", err)
+	}
+	return nil
+}
+
+// ReapStuckJobs returns running jobs that have not been updated for timeout,
+// e.g. because their worker crashed, to pending without counting a retry.
+// Their checkpoints are kept, so resumable jobs continue where they stopped.
+func (q *PostgresQueue) ReapStuckJobs(ctx context.Context, timeout time.Duration) (int64, error) {
+	now := time.Now()
+	result, err := q.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $1, updated_at = $2, error = $3
+		WHERE status = $4 AND updated_at < $5
+	`, JobStatusPending, now, fmt.Sprintf("requeued after no progress for %s", timeout), JobStatusRunning, now.Add(-timeout))
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap stuck jobs: %w", err)
+	}
+	reaped, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if reaped > 0 {
+		notifyJobsAvailable(ctx, q.db, "")
+	}
+	return reaped, nil
+}
+
+// GetQueueDepth counts the pending and running jobs and how long the oldest
+// due job has waited
+func (q *PostgresQueue) GetQueueDepth(ctx context.Context) (*QueueDepth, error) {
+	now := time.Now()
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT type, status, COUNT(*),
+			COALESCE(EXTRACT(EPOCH FROM $1 - MIN(COALESCE(next_run_at, created_at))
+				FILTER (WHERE status = $2 AND (next_run_at IS NULL OR next_run_at <= $1))), 0)
+		FROM jobs
+		WHERE status IN ($2, $3)
+		GROUP BY type, status
+	`, now, JobStatusPending, JobStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queued jobs: %w", err)
+	}
+	defer rows.Close()
+
+	depth := &QueueDepth{PendingByType: make(map[JobType]int)}
+	for rows.Next() {
+		var (
+			jobType JobType
+			status  JobStatus
+			count   int
+			waited  float64
+		)
+		if err := rows.Scan(&jobType, &status, &count, &waited); err != nil {
+			return nil, err
+		}
+		if status == JobStatusRunning {
+			depth.Running += count
+			continue
+		}
+		depth.Pending += count
+		depth.PendingByType[jobType] = count
+		if waited > depth.OldestPendingSeconds {
+			depth.OldestPendingSeconds = roundLatency(waited)
+		}
+	}
+	return depth, rows.Err()
+}
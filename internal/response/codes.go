@@ -0,0 +1,54 @@
+package response
+
+import "net/http"
+
+// Error codes identify what went wrong in error responses, so clients can
+// branch on them instead of on messages
+const (
+	CodeInvalidInput      = "INVALID_INPUT"
+	CodeInvalidBody       = "INVALID_BODY"       // The body is not the JSON the endpoint expects
+	CodeInvalidRepository = "INVALID_REPOSITORY" // An owner or repository name that can't exist
+	CodeInvalidPagination = "INVALID_PAGINATION"
+	CodeUnauthorized      = "UNAUTHORIZED"
+	CodeForbidden         = "FORBIDDEN"
+	CodeNotFound          = "NOT_FOUND"
+	CodeRepoNotFound      = "REPO_NOT_FOUND"
+	CodeMethodNotAllowed  = "METHOD_NOT_ALLOWED"
+	CodeConflict          = "CONFLICT"
+	CodePayloadTooLarge   = "PAYLOAD_TOO_LARGE"
+	CodeRateLimited       = "RATE_LIMITED"
+	CodeInternal          = "INTERNAL_ERROR"
+	CodeUpstream          = "UPSTREAM_ERROR" // GitHub or another provider failed
+	CodeUnavailable       = "SERVICE_UNAVAILABLE"
+)
+
+// StatusCode returns the error code of responses with the HTTP status that
+// don't set a more specific one
+func StatusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidInput
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidInput
+}
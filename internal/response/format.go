@@ -0,0 +1,155 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Timestamp formats clients can choose with the timestamps query parameter
+const (
+	// TimestampsRFC3339 renders timestamps as UTC RFC 3339 strings, each with a
+	// <field>_unix sibling holding the epoch seconds, and durations with a
+	// <field>_seconds sibling
+	TimestampsRFC3339 = "rfc3339"
+	// TimestampsUnix renders timestamps as epoch seconds and durations as seconds
+	TimestampsUnix = "unix"
+)
+
+// durationSuffixes identifies the fields holding durations such as "42m10s"
+var durationSuffixes = []string{"_in", "_interval", "_before", "_duration"}
+
+// formatWriter carries the timestamp format requested by a client to JSON and Stream
+type formatWriter struct {
+	http.ResponseWriter
+	format string
+}
+
+// WithTimestampFormat returns a writer whose JSON responses render timestamps
+// and durations in the given format
+func WithTimestampFormat(w http.ResponseWriter, format string) http.ResponseWriter {
+	return &formatWriter{ResponseWriter: w, format: format}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *formatWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush sends buffered data to the client when the underlying writer supports it
+func (w *formatWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// ValidTimestampFormat reports whether format is a supported timestamp format
+func ValidTimestampFormat(format string) bool {
+	return format == TimestampsRFC3339 || format == TimestampsUnix
+}
+
+// timestampFormat returns the timestamp format requested for a response, or an
+// empty string when responses are written as encoded
+func timestampFormat(w http.ResponseWriter) string {
+	if fw, ok := w.(*formatWriter); ok {
+		return fw.format
+	}
+	return ""
+}
+
+// encode marshals v and renders its timestamps and durations in the format
+// requested for the response
+func encode(w http.ResponseWriter, v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	format := timestampFormat(w)
+	if format == "" {
+		return data, nil
+	}
+	return FormatTimestamps(data, format)
+}
+
+// FormatTimestamps rewrites the timestamps and durations in a JSON document in
+// the given format. Timestamps are recognized by value and durations by field name.
+func FormatTimestamps(data []byte, format string) ([]byte, error) {
+	if !ValidTimestampFormat(format) {
+		return nil, fmt.Errorf("unsupported timestamp format %q", format)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(formatValue(doc, format))
+}
+
+// formatValue rewrites the timestamps and durations held by a decoded JSON value
+func formatValue(v interface{}, format string) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = formatValue(v[i], format)
+		}
+		return v
+	case map[string]interface{}:
+		formatted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			formatted[key] = formatValue(value, format)
+		}
+		for key, value := range v {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if t, ok := parseTimestamp(s); ok {
+				if format == TimestampsUnix {
+					formatted[key] = t.Unix()
+				} else {
+					formatted[key] = t.UTC().Format(time.RFC3339Nano)
+					addSibling(formatted, key+"_unix", t.Unix())
+				}
+			} else if d, ok := parseDuration(key, s); ok {
+				if format == TimestampsUnix {
+					formatted[key] = d.Seconds()
+				} else {
+					addSibling(formatted, key+"_seconds", d.Seconds())
+				}
+			}
+		}
+		return formatted
+	default:
+		return v
+	}
+}
+
+// addSibling sets a derived field unless the document already has one by that name
+func addSibling(m map[string]interface{}, key string, value interface{}) {
+	if _, exists := m[key]; !exists {
+		m[key] = value
+	}
+}
+
+// parseTimestamp parses an RFC 3339 timestamp such as those encoded for time.Time
+func parseTimestamp(s string) (time.Time, bool) {
+	if len(s) < len("2006-01-02T15:04:05Z") || s[4] != '-' || s[10] != 'T' {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// parseDuration parses the value of a duration field
+func parseDuration(key, s string) (time.Duration, bool) {
+	for _, suffix := range durationSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			d, err := time.ParseDuration(s)
+			return d, err == nil
+		}
+	}
+	return 0, false
+}
@@ -2,14 +2,23 @@ package response
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 )
 
+// RequestIDHeader carries the ID that identifies a request in logs and error responses
+const RequestIDHeader = "X-Request-ID"
+
 // Response represents a standard API response
 type Response struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Status    string      `json:"status"`
+	Code      string      `json:"code,omitempty"` // Machine-readable error code, set on error responses
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // Set on error responses so users can reference them
 }
 
 // PaginatedResponse represents a paginated API response
@@ -22,10 +31,17 @@ type PaginatedResponse struct {
 
 // Pagination contains pagination metadata
 type Pagination struct {
-	Page       int `json:"page"`
-	PerPage    int `json:"per_page"`
-	TotalItems int `json:"total_items"`
-	TotalPages int `json:"total_pages"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the following items, where cursor pagination is supported
+}
+
+// CursorPagination contains the metadata of a page fetched by cursor
+type CursorPagination struct {
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor"` // Empty after the last page
 }
 
 // Success creates a successful response
@@ -37,19 +53,24 @@ func Success(message string, data interface{}) Response {
 	}
 }
 
+// NewPagination creates pagination metadata for the given page
+func NewPagination(page, perPage, totalItems int) Pagination {
+	totalPages := (totalItems + perPage - 1) / perPage // Ceiling division
+	return Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+	}
+}
+
 // SuccessPaginated creates a successful paginated response
 func SuccessPaginated(message string, data interface{}, page, perPage, totalItems int) PaginatedResponse {
-	totalPages := (totalItems + perPage - 1) / perPage // Ceiling division
 	return PaginatedResponse{
 		Status:  "success",
 		Message: message,
 		Data:    data,
-		Meta: Pagination{
-			Page:       page,
-			PerPage:    perPage,
-			TotalItems: totalItems,
-			TotalPages: totalPages,
-		},
+		Meta:    NewPagination(page, perPage, totalItems),
 	}
 }
 
@@ -61,11 +82,268 @@ func Error(message string) Response {
 	}
 }
 
-// JSON writes a JSON response with the given status code
+// ErrorCode creates an error response with a more specific code than the one
+// its HTTP status implies
+func ErrorCode(code, message string) Response {
+	return Response{
+		Status:  "error",
+		Code:    code,
+		Message: message,
+	}
+}
+
+// ErrorWithData creates an error response carrying data, e.g. the existing
+// resource of a conflict
+func ErrorWithData(message string, data interface{}) Response {
+	return Response{
+		Status:  "error",
+		Message: message,
+		Data:    data,
+	}
+}
+
+// JSON writes a JSON response with the given status code. Error responses
+// include the request ID set on the response headers and, unless they set
+// one, the error code of the status. Timestamps are rendered in the format
+// requested for the response.
 func JSON(w http.ResponseWriter, code int, payload interface{}) {
+	if resp, ok := payload.(Response); ok && resp.Status == "error" {
+		if resp.RequestID == "" {
+			resp.RequestID = w.Header().Get(RequestIDHeader)
+		}
+		if resp.Code == "" {
+			resp.Code = StatusCode(code)
+		}
+		payload = resp
+	}
+
+	data, err := encode(w, payload)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	w.Write(append(data, '\n'))
+}
+
+// ETagMatches reports whether an If-None-Match header matches etag. The
+// comparison is weak, as RFC 9110 prescribes for If-None-Match.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// streamFlushInterval is the number of items written between flushes of a Stream
+const streamFlushInterval = 100
+
+// Stream writes a successful response whose list of items is encoded one at a
+// time as it is produced, so large listings never have to be held in memory.
+// The envelope is only written once the first item arrives or the stream is
+// closed, so callers can still send a regular error response before that.
+type Stream struct {
+	w          http.ResponseWriter
+	code       int
+	message    string
+	arrayField string
+	started    bool
+	count      int
+}
+
+// NewStream creates a Stream. When arrayField is empty the items form the
+// data array itself, otherwise data is an object holding the items under arrayField.
+func NewStream(w http.ResponseWriter, code int, message, arrayField string) *Stream {
+	return &Stream{
+		w:          w,
+		code:       code,
+		message:    message,
+		arrayField: arrayField,
+	}
+}
+
+// Started reports whether any part of the response has been written
+func (s *Stream) Started() bool {
+	return s.started
+}
+
+// Count returns the number of items written so far
+func (s *Stream) Count() int {
+	return s.count
+}
+
+func (s *Stream) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	message, err := json.Marshal(s.message)
+	if err != nil {
+		return err
+	}
+
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(s.code)
+
+	prefix := `{"status":"success","message":` + string(message) + `,"data":`
+	if s.arrayField != "" {
+		field, _ := json.Marshal(s.arrayField)
+		prefix += "{" + string(field) + ":"
+	}
+	_, err = s.w.Write([]byte(prefix + "["))
+	return err
+}
+
+// Write encodes a single item, flushing the response periodically
+func (s *Stream) Write(item interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	data, err := encode(s.w, item)
+	if err != nil {
+		return err
+	}
+	if s.count > 0 {
+		data = append([]byte(","), data...)
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	s.count++
+	if s.count%streamFlushInterval == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+// Close terminates the item list and appends the given fields, which are
+// placed inside data when an array field is used and at the top level otherwise
+func (s *Stream) Close(fields map[string]interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	encoded, err := encodeFields(s.w, fields)
+	if err != nil {
+		return err
+	}
+	if s.arrayField != "" {
+		return s.finish("]" + encoded + "}")
+	}
+	return s.finish("]" + encoded)
+}
+
+// Abort terminates a stream that failed part way through. The status code has
+// already been sent, so the failure is reported in a top-level error field.
+func (s *Stream) Abort(cause error) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	encoded, err := encodeFields(s.w, map[string]interface{}{"error": cause.Error()})
+	if err != nil {
+		return err
+	}
+	if s.arrayField != "" {
+		return s.finish("]}" + encoded)
+	}
+	return s.finish("]" + encoded)
+}
+
+// finish writes the closing part of the envelope and flushes the response
+func (s *Stream) finish(closing string) error {
+	if _, err := s.w.Write([]byte(closing + "}\n")); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// flush sends buffered data to the client when the writer supports it
+func (s *Stream) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// encodeFields encodes fields as a sequence of ,"key":value pairs in key order
+func encodeFields(w http.ResponseWriter, fields map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var encoded string
+	for _, key := range keys {
+		name, err := json.Marshal(key)
+		if err != nil {
+			return "", err
+		}
+		value, err := encode(w, fields[key])
+		if err != nil {
+			return "", err
+		}
+		encoded += "," + string(name) + ":" + string(value)
+	}
+	return encoded, nil
+}
+
+// EventStream writes server-sent events. It lifts the server's write timeout so
+// the stream can stay open for as long as the client is connected.
+type EventStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// NewEventStream starts a server-sent event response
+func NewEventStream(w http.ResponseWriter) (*EventStream, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		return nil, fmt.Errorf("streaming not supported: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &EventStream{w: w, rc: rc}
+	return s, s.rc.Flush()
+}
+
+// Send writes an event whose data is the JSON encoding of data. An empty event
+// name sends an unnamed event, which clients receive as "message".
+func (s *EventStream) Send(event string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var msg string
+	if event != "" {
+		msg = "event: " + event + "\n"
+	}
+	msg += "data: " + string(encoded) + "\n\n"
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// KeepAlive writes a comment line so idle connections are not closed by proxies
+func (s *EventStream) KeepAlive() error {
+	if _, err := s.w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
 	}
+	return s.rc.Flush()
 }
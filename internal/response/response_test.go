@@ -0,0 +1,161 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONErrorRequestID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-123")
+	JSON(rec, http.StatusNotFound, Error("Route not found"))
+
+	expected := `{"status":"error","code":"NOT_FOUND","message":"Route not found","request_id":"req-123"}` + "\n"
+	if rec.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-123")
+	JSON(rec, http.StatusOK, Success("OK", nil))
+	if expected := `{"status":"success","message":"OK"}` + "\n"; rec.Body.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, rec.Body.String())
+	}
+}
+
+func TestJSONErrorCode(t *testing.T) {
+	tests := []struct {
+		status int
+		resp   Response
+		code   string
+	}{
+		{http.StatusBadRequest, Error("Bad page"), CodeInvalidInput},
+		{http.StatusTooManyRequests, Error("Slow down"), CodeRateLimited},
+		{http.StatusInternalServerError, Error("Boom"), CodeInternal},
+		{http.StatusNotFound, ErrorCode(CodeRepoNotFound, "Repository octo/hello not found"), CodeRepoNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		JSON(rec, tt.status, tt.resp)
+
+		var body Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Expected JSON, got %s", rec.Body.String())
+		}
+		if body.Code != tt.code {
+			t.Errorf("Expected code %s for status %d, got %s", tt.code, tt.status, body.Code)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Run("data array with meta", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := NewStream(rec, http.StatusOK, "Items retrieved", "")
+		for i := 0; i < 3; i++ {
+			if err := stream.Write(map[string]int{"n": i}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+		if err := stream.Close(map[string]interface{}{"meta": NewPagination(1, 3, 7)}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var got PaginatedResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Expected valid JSON, got %v: %s", err, rec.Body.String())
+		}
+		if got.Status != "success" || got.Message != "Items retrieved" {
+			t.Errorf("Unexpected envelope: %+v", got)
+		}
+		if items, ok := got.Data.([]interface{}); !ok || len(items) != 3 {
+			t.Errorf("Expected 3 items, got %v", got.Data)
+		}
+		if got.Meta.TotalPages != 3 {
+			t.Errorf("Expected 3 total pages, got %d", got.Meta.TotalPages)
+		}
+	})
+
+	t.Run("data object with array field", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := NewStream(rec, http.StatusOK, "Jobs retrieved", "jobs")
+		if err := stream.Close(map[string]interface{}{"count": stream.Count()}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := `{"status":"success","message":"Jobs retrieved","data":{"jobs":[],"count":0}}` + "\n"
+		if rec.Body.String() != expected {
+			t.Errorf("Expected %s, got %s", expected, rec.Body.String())
+		}
+	})
+
+	t.Run("abort after start", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		stream := NewStream(rec, http.StatusOK, "Jobs retrieved", "jobs")
+		if stream.Started() {
+			t.Fatal("Expected stream not to be started before the first write")
+		}
+		stream.Write("a")
+		stream.Abort(fmt.Errorf("boom"))
+
+		var got map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Expected valid JSON, got %v: %s", err, rec.Body.String())
+		}
+		if got["error"] != "boom" {
+			t.Errorf("Expected error field 'boom', got %v", got["error"])
+		}
+	})
+}
+
+func TestJSONTimestampFormats(t *testing.T) {
+	payload := Success("ok", map[string]interface{}{
+		"created_at": "2024-03-01T12:00:00+02:00",
+		"next_in":    "1m30s",
+		"name":       "octocat",
+	})
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"", `{"status":"success","message":"ok","data":{"created_at":"2024-03-01T12:00:00+02:00","name":"octocat","next_in":"1m30s"}}`},
+		{TimestampsRFC3339, `{"data":{"created_at":"2024-03-01T10:00:00Z","created_at_unix":1709287200,"name":"octocat","next_in":"1m30s","next_in_seconds":90},"message":"ok","status":"success"}`},
+		{TimestampsUnix, `{"data":{"created_at":1709287200,"name":"octocat","next_in":90},"message":"ok","status":"success"}`},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		var w http.ResponseWriter = rec
+		if tt.format != "" {
+			w = WithTimestampFormat(rec, tt.format)
+		}
+		JSON(w, http.StatusOK, payload)
+
+		if rec.Body.String() != tt.expected+"\n" {
+			t.Errorf("format %q: expected %s, got %s", tt.format, tt.expected, rec.Body.String())
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+	}
+	for _, tt := range tests {
+		if got := ETagMatches(tt.ifNoneMatch, `"abc"`); got != tt.want {
+			t.Errorf("ETagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
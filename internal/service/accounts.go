@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/cache"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// WithOAuth enables logging in with a GitHub account. Accounts get role on
+// their first login, and each login issues an API key valid for sessionTTL.
+func WithOAuth(provider OAuthProvider, role models.Role, sessionTTL time.Duration) Option {
+	return func(s *Service) {
+		s.oauth = provider
+		s.accountRole = role
+		s.accountTTL = sessionTTL
+	}
+}
+
+// LoginWithGitHub completes a login through GitHub's OAuth web flow with the
+// code GitHub redirected back with. It records the account and issues an API
+// key for the session, whose plaintext is only returned here.
+func (s *Service) LoginWithGitHub(ctx context.Context, code string) (*models.Account, *models.APIKey, string, error) {
+	if s.oauth == nil {
+		return nil, nil, "", fmt.Errorf("%w: GitHub login is not configured", errors.ErrInvalidInput)
+	}
+	if code == "" {
+		return nil, nil, "", fmt.Errorf("%w: code is required", errors.ErrInvalidInput)
+	}
+
+	token, err := s.oauth.Exchange(ctx, code)
+	if err != nil {
+		// Mostly codes that expired or were used already
+		return nil, nil, "", fmt.Errorf("%w: exchanging code: %v", errors.ErrUnauthorized, err)
+	}
+	user, err := s.oauth.GetUser(ctx, token)
+	if err != nil {
+		return nil, nil, "", errors.NewGitHubError("GetUser", "", err)
+	}
+
+	account, err := s.db.UpsertAccount(ctx, user, s.accountRole)
+	if err != nil {
+		return nil, nil, "", errors.NewDatabaseError("UpsertAccount", err)
+	}
+
+	expiresAt := time.Now().Add(s.accountTTL)
+	key := &models.APIKey{
+		Name:      "github:" + account.Login,
+		Role:      account.Role,
+		AccountID: &account.ID,
+		ExpiresAt: &expiresAt,
+	}
+	plaintext, err := s.issueAPIKey(ctx, key)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return account, key, plaintext, nil
+}
+
+// GetAccount returns an account by ID
+func (s *Service) GetAccount(ctx context.Context, id int64) (*models.Account, error) {
+	account, err := s.db.GetAccount(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetAccount", err)
+	}
+	if account == nil {
+		return nil, errors.NewNotFoundError("account", id)
+	}
+	return account, nil
+}
+
+// ListAccounts returns every account ordered by login
+func (s *Service) ListAccounts(ctx context.Context) ([]*models.Account, error) {
+	return s.db.ListAccounts(ctx)
+}
+
+// UpdateAccountRole changes the role of an account and its sessions
+func (s *Service) UpdateAccountRole(ctx context.Context, id int64, role models.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: unknown role %q", errors.ErrInvalidInput, role)
+	}
+	return s.db.UpdateAccountRole(ctx, id, role)
+}
+
+// DeleteAccount removes an account, ending its sessions. The repositories it
+// added stay monitored.
+func (s *Service) DeleteAccount(ctx context.Context, id int64) error {
+	return s.db.DeleteAccount(ctx, id)
+}
+
+// AddAccountRepository associates a monitored repository with an account, so
+// its sessions see the repository
+func (s *Service) AddAccountRepository(ctx context.Context, accountID int64, fullName string) error {
+	if err := s.db.AddAccountRepository(ctx, accountID, fullName); err != nil {
+		return errors.NewDatabaseError("AddAccountRepository", err)
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// RemoveAccountRepository dissociates a repository from an account, returning
+// how many accounts it remains associated with
+func (s *Service) RemoveAccountRepository(ctx context.Context, accountID int64, fullName string) (int, error) {
+	remaining, err := s.db.RemoveAccountRepository(ctx, accountID, fullName)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return remaining, nil
+}
+
+// HasAccountRepository reports whether an account added a repository
+func (s *Service) HasAccountRepository(ctx context.Context, accountID int64, fullName string) (bool, error) {
+	has, err := s.db.HasAccountRepository(ctx, accountID, fullName)
+	if err != nil {
+		return false, errors.NewDatabaseError("HasAccountRepository", err)
+	}
+	return has, nil
+}
+
+// GetAccountRepositoryListings returns a page of the monitored repositories an
+// account added, along with their total number
+func (s *Service) GetAccountRepositoryListings(ctx context.Context, accountID int64, page, perPage int) ([]*models.RepositoryListing, int, error) {
+	total, err := s.db.CountAccountRepositories(ctx, accountID)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("CountAccountRepositories", err)
+	}
+	listings, err := s.db.GetAccountRepositoryListings(ctx, accountID, page, perPage)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("GetAccountRepositoryListings", err)
+	}
+	return listings, total, nil
+}
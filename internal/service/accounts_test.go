@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingOAuth rejects every code, like GitHub does for expired ones
+type failingOAuth struct{}
+
+func (failingOAuth) Exchange(ctx context.Context, code string) (string, error) {
+	return "", fmt.Errorf("bad_verification_code: The code passed is incorrect or expired.")
+}
+
+func (failingOAuth) GetUser(ctx context.Context, token string) (*models.GitHubUser, error) {
+	return nil, fmt.Errorf("unexpected call")
+}
+
+func TestLoginWithGitHubRejectsCodes(t *testing.T) {
+	s := &Service{}
+	_, _, _, err := s.LoginWithGitHub(context.Background(), "code")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), "login without OAuth configured: %v", err)
+
+	WithOAuth(failingOAuth{}, models.RoleWriter, time.Hour)(s)
+	_, _, _, err = s.LoginWithGitHub(context.Background(), "")
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput), "login without code: %v", err)
+
+	_, _, _, err = s.LoginWithGitHub(context.Background(), "expired")
+	assert.True(t, errors.Is(err, errors.ErrUnauthorized), "login with rejected code: %v", err)
+}
+
+func TestAPIKeyScoped(t *testing.T) {
+	account := int64(1)
+	assert.False(t, (&models.APIKey{Role: models.RoleWriter}).Scoped())
+	assert.True(t, (&models.APIKey{Role: models.RoleWriter, AccountID: &account}).Scoped())
+	assert.False(t, (&models.APIKey{Role: models.RoleAdmin, AccountID: &account}).Scoped())
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// apiKeyPrefix marks keys issued by this service so they are easy to recognise in logs and secret scanners
+const apiKeyPrefix = "ghs_"
+
+// HashAPIKey returns the hash under which an API key is stored
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a new API key with the given role. The plaintext key is
+// only returned here and cannot be recovered later.
+func (s *Service) CreateAPIKey(ctx context.Context, name string, role models.Role) (*models.APIKey, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: api key name is required", errors.ErrInvalidInput)
+	}
+	if !role.Valid() {
+		return nil, "", fmt.Errorf("%w: unknown role %q", errors.ErrInvalidInput, role)
+	}
+
+	key := &models.APIKey{Name: name, Role: role}
+	plaintext, err := s.issueAPIKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, plaintext, nil
+}
+
+// issueAPIKey generates the secret of a new API key and stores the key,
+// returning the plaintext key
+func (s *Service) issueAPIKey(ctx context.Context, key *models.APIKey) (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating api key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key.Prefix = plaintext[:len(apiKeyPrefix)+8]
+	if err := s.db.CreateAPIKey(ctx, key, HashAPIKey(plaintext)); err != nil {
+		return "", errors.NewDatabaseError("CreateAPIKey", err)
+	}
+	return plaintext, nil
+}
+
+// AuthenticateAPIKey resolves a plaintext API key to its stored record.
+// It returns ErrUnauthorized when the key is unknown, revoked or expired.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, plaintext string) (*models.APIKey, error) {
+	key, err := s.db.GetAPIKeyByHash(ctx, HashAPIKey(plaintext))
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetAPIKeyByHash", err)
+	}
+	if key == nil {
+		return nil, errors.ErrUnauthorized
+	}
+
+	if err := s.db.TouchAPIKey(ctx, key.ID); err != nil && s.logger != nil {
+		s.logger.Warn().Err(err).Int64("api_key_id", key.ID).Msg("Failed to record api key usage")
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys returns all issued API keys without their secrets
+func (s *Service) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return s.db.ListAPIKeys(ctx)
+}
+
+// UpdateAPIKeyRole changes the role of an API key
+func (s *Service) UpdateAPIKeyRole(ctx context.Context, id int64, role models.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: unknown role %q", errors.ErrInvalidInput, role)
+	}
+	return s.db.UpdateAPIKeyRole(ctx, id, role)
+}
+
+// RevokeAPIKey revokes an API key so it can no longer be used
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) error {
+	return s.db.RevokeAPIKey(ctx, id)
+}
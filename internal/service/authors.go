@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github-service/internal/cache"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// normalizeAuthorEmail lower-cases an author email for identity matching
+func normalizeAuthorEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || !strings.Contains(email, "@") {
+		return "", fmt.Errorf("%w: invalid email %q", errors.ErrInvalidInput, email)
+	}
+	return email, nil
+}
+
+// MergeAuthorIdentities merges the aliases into the identity of email so top-author
+// statistics count their commits as one author. The name is optional and defaults
+// to the name the email most often commits under.
+func (s *Service) MergeAuthorIdentities(ctx context.Context, name, email string, aliases []string) ([]*models.AuthorIdentity, error) {
+	canonical, err := normalizeAuthorEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return nil, fmt.Errorf("%w: at least one alias is required", errors.ErrInvalidInput)
+	}
+
+	emails := []string{canonical}
+	seen := map[string]bool{canonical: true}
+	for _, alias := range aliases {
+		normalized, err := normalizeAuthorEmail(alias)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			emails = append(emails, normalized)
+		}
+	}
+	if len(emails) == 1 {
+		return nil, fmt.Errorf("%w: aliases must differ from email", errors.ErrInvalidInput)
+	}
+
+	identities, err := s.db.MergeAuthorIdentities(ctx, strings.TrimSpace(name), canonical, emails)
+	if err != nil {
+		return nil, fmt.Errorf("error merging author identities: %w", err)
+	}
+	// The rankings of single repositories catch up once their values expire
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return identities, nil
+}
+
+// GetAuthorCommits returns a page of the commits of the author with email,
+// including those under the emails merged into the same identity, newest
+// first, along with their total. A non-empty repository limits them to the
+// commits to that repository.
+func (s *Service) GetAuthorCommits(ctx context.Context, email, repository string, page, perPage int) ([]*models.AuthorCommit, int, error) {
+	normalized, err := normalizeAuthorEmail(email)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var repoID int64
+	if repository != "" {
+		repo, err := s.db.GetRepositoryByName(ctx, repository)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error fetching repository: %w", err)
+		}
+		if repo == nil {
+			return nil, 0, errors.NewNotFoundError("repository", repository)
+		}
+		repoID = repo.ID
+	}
+
+	totalCount, err := s.db.CountAuthorCommits(ctx, normalized, repoID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting author commits: %w", err)
+	}
+	commits, err := s.db.GetAuthorCommits(ctx, normalized, repoID, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching author commits: %w", err)
+	}
+	return commits, totalCount, nil
+}
+
+// ListAuthorIdentities returns all merged author emails
+func (s *Service) ListAuthorIdentities(ctx context.Context) ([]*models.AuthorIdentity, error) {
+	return s.db.ListAuthorIdentities(ctx)
+}
+
+// UnmergeAuthorIdentity detaches an email from the identity it was merged into
+func (s *Service) UnmergeAuthorIdentity(ctx context.Context, email string) error {
+	normalized, err := normalizeAuthorEmail(email)
+	if err != nil {
+		return err
+	}
+	if err := s.db.DeleteAuthorIdentity(ctx, normalized); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-service/internal/cache"
+	"github-service/internal/models"
+)
+
+// WithCache caches the aggregate stats reads, such as the top authors and
+// commit counts, in c. Syncs and imports that store commits invalidate the
+// values of their repository and those computed over every repository.
+func WithCache(c *cache.Cache) Option {
+	return func(s *Service) {
+		s.cache = c
+	}
+}
+
+// CacheStats returns the lookups counted by the cache, or nil without one
+func (s *Service) CacheStats() *cache.Stats {
+	if s.cache == nil {
+		return nil
+	}
+	stats := s.cache.Stats()
+	return &stats
+}
+
+// authorsPage is a cached page of commit authors with the total number of authors
+type authorsPage struct {
+	Authors []*models.CommitStats `json:"authors"`
+	Total   int                   `json:"total"`
+}
+
+// invalidateRepository drops the cached stats of a repository along with those
+// computed over every repository
+func (s *Service) invalidateRepository(ctx context.Context, fullName string) {
+	s.cache.Invalidate(ctx, cache.RepositoryGroup(fullName), cache.GroupAll)
+}
+
+// cacheKey joins the arguments of a cached read into a key. Times are
+// formatted to the second and nil times as "-".
+func cacheKey(name string, args ...interface{}) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, name)
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case *time.Time:
+			if v == nil {
+				parts = append(parts, "-")
+			} else {
+				parts = append(parts, v.UTC().Format(time.RFC3339))
+			}
+		case time.Time:
+			parts = append(parts, v.UTC().Format(time.RFC3339))
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// ticketKeyPattern matches Jira style ticket keys such as PROJ-123
+var ticketKeyPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+)-[1-9][0-9]*\b`)
+
+// ticketLookupBatch is the most tickets looked up at once, by a sync for the
+// tickets it found or by a refresh; the rest wait for the next refresh
+const ticketLookupBatch = 500
+
+// defaultTicketRefreshAge is how old a ticket's status gets before it is looked
+// up again unless configured
+const defaultTicketRefreshAge = 6 * time.Hour
+
+// WithTicketTracker enables recording the tickets referenced by commit messages
+// and looking up their status in tracker. Only keys of the given projects are
+// recorded, or all keys without projects, which may mistake e.g. UTF-8 for a
+// ticket. Tickets are looked up when first referenced and refreshed once their
+// status is older than refreshAge.
+func WithTicketTracker(tracker TicketTracker, projects []string, refreshAge time.Duration) Option {
+	return func(s *Service) {
+		s.tickets = tracker
+		s.ticketProjects = make(map[string]bool, len(projects))
+		for _, project := range projects {
+			s.ticketProjects[strings.ToUpper(project)] = true
+		}
+		s.ticketRefreshAge = defaultTicketRefreshAge
+		if refreshAge > 0 {
+			s.ticketRefreshAge = refreshAge
+		}
+	}
+}
+
+// ticketKeys returns the distinct ticket keys referenced by a commit message in
+// order of appearance, limited to the given projects unless there are none
+func ticketKeys(message string, projects map[string]bool) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, m := range ticketKeyPattern.FindAllStringSubmatch(message, -1) {
+		key, project := m[0], m[1]
+		if seen[key] || (len(projects) > 0 && !projects[project]) {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// recordCommitTickets stores the tickets a commit references and returns those
+// no earlier commit referenced. Failures are logged rather than returned so a
+// reference never fails the ingest.
+func (s *Service) recordCommitTickets(ctx context.Context, commit *models.Commit) []string {
+	keys := ticketKeys(commit.Message, s.ticketProjects)
+	if len(keys) == 0 {
+		return nil
+	}
+	added, err := s.db.AddCommitTickets(ctx, commit.RepositoryID, commit.ID, keys)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to record commit tickets")
+		return nil
+	}
+	return added
+}
+
+// lookupTickets fetches the status of tickets from the tracker and stores it,
+// returning how many lookups failed. Tickets the tracker doesn't know are
+// stored as not found, so they are counted as unresolved.
+func (s *Service) lookupTickets(ctx context.Context, keys []string) int {
+	failed := 0
+	for _, key := range keys {
+		ticket, err := s.tickets.GetTicket(ctx, key)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("ticket", key).Msg("Failed to look up ticket")
+			failed++
+			continue
+		}
+		if ticket == nil {
+			ticket = &models.Ticket{}
+		} else {
+			ticket.Found = true
+		}
+		// Moved tickets answer with their new key; keep the referenced one
+		ticket.Key = key
+
+		if err := s.db.SaveTicket(ctx, ticket); err != nil {
+			s.logger.Warn().Err(err).Str("ticket", key).Msg("Failed to store ticket")
+			failed++
+		}
+	}
+	return failed
+}
+
+// RefreshTickets looks up the tickets not looked up yet or whose status is older
+// than the refresh age, least recently refreshed first, and returns how many
+// were refreshed
+func (s *Service) RefreshTickets(ctx context.Context) (int, error) {
+	if s.tickets == nil {
+		return 0, fmt.Errorf("no ticket tracker is configured")
+	}
+
+	keys, err := s.db.GetTicketsToRefresh(ctx, time.Now().Add(-s.ticketRefreshAge), ticketLookupBatch)
+	if err != nil {
+		return 0, errors.NewDatabaseError("GetTicketsToRefresh", err)
+	}
+
+	failed := s.lookupTickets(ctx, keys)
+	if failed > 0 {
+		return len(keys) - failed, fmt.Errorf("%d of %d ticket lookup(s) failed", failed, len(keys))
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketKeys(t *testing.T) {
+	message := "PROJ-12: fix login\n\nAlso touches OPS-7 and PROJ-12 again, see UTF-8 and ABC-0"
+	assert.Equal(t, []string{"PROJ-12", "OPS-7", "UTF-8"}, ticketKeys(message, nil))
+	assert.Equal(t, []string{"PROJ-12", "OPS-7"}, ticketKeys(message, map[string]bool{"PROJ": true, "OPS": true}))
+	assert.Equal(t, []string{"OPS-7"}, ticketKeys(message, map[string]bool{"OPS": true}))
+
+	assert.Empty(t, ticketKeys("proj-12 lower case, X-1 single letter, PROJ-12abc", nil))
+}
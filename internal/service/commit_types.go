@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github-service/internal/cache"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// commitTypeOther is the type of commits whose message has no Conventional Commits type
+const commitTypeOther = "other"
+
+// commitTypes are the Conventional Commits types recognized in commit messages
+var commitTypes = map[string]bool{
+	"build": true, "chore": true, "ci": true, "docs": true, "feat": true, "fix": true,
+	"perf": true, "refactor": true, "revert": true, "style": true, "test": true,
+}
+
+// commitTypePattern matches the "type(scope)!: " prefix of a Conventional Commits message
+var commitTypePattern = regexp.MustCompile(`^([A-Za-z]+)(\([^()]*\))?!?: `)
+
+// commitType classifies a commit message by its Conventional Commits type, e.g.
+// "feat(api): add endpoint" is a feat. Types are matched case-insensitively and
+// commits created by git revert count as reverts; anything else is "other".
+// The database backfill of older commits applies the same rules.
+func commitType(message string) string {
+	if m := commitTypePattern.FindStringSubmatch(message); m != nil {
+		if t := strings.ToLower(m[1]); commitTypes[t] {
+			return t
+		}
+	}
+	if strings.HasPrefix(message, `Revert "`) {
+		return "revert"
+	}
+	return commitTypeOther
+}
+
+// GetCommitTypeStats returns a repository's commits counted by Conventional Commits type
+func (s *Service) GetCommitTypeStats(ctx context.Context, fullName string, since, until *time.Time) ([]*models.CommitTypeStats, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	return cache.Get(ctx, s.cache, cache.RepositoryGroup(repo.FullName), cacheKey("commit-types", since, until), func() ([]*models.CommitTypeStats, error) {
+		return s.db.GetCommitTypeStats(ctx, repo.ID, since, until)
+	})
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitType(t *testing.T) {
+	tests := map[string]string{
+		"feat: add latest commits endpoint":         "feat",
+		"fix(api): reject empty names":              "fix",
+		"refactor!: drop the v0 routes":             "refactor",
+		"Docs(readme): describe polling":            "docs",
+		"chore(deps): bump lib/pq\n\nBody: details": "chore",
+		`Revert "feat: add cache"`:                  "revert",
+		"wip: half done":                            "other",
+		"feat add endpoint":                         "other",
+		"feat:no space":                             "other",
+		"Merge pull request #12 from owner/branch":  "other",
+		"": "other",
+	}
+
+	for message, want := range tests {
+		assert.Equal(t, want, commitType(message), message)
+	}
+}
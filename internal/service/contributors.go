@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/github"
+	"github-service/internal/models"
+)
+
+// WithContributorStats enables syncing the weekly contributor stats GitHub
+// computes for repositories along with their commits. This costs one extra
+// GitHub API request per sync, more while GitHub is still computing them.
+func WithContributorStats(enabled bool) Option {
+	return func(s *Service) {
+		s.syncContributors = enabled
+	}
+}
+
+// syncContributorStats fetches and stores the weekly activity of a repository's
+// contributors. Failures are logged rather than returned so they never fail the
+// commit sync.
+func (s *Service) syncContributorStats(ctx context.Context, owner, name string, repoID int64) {
+	fullName := fmt.Sprintf("%s/%s", owner, name)
+
+	contributors, err := s.github.GetContributorStats(ctx, owner, name)
+	if errors.Is(err, github.ErrStatsPending) {
+		s.logger.Info().Str("repository", fullName).Msg("Contributor stats are not computed yet; the next sync fetches them")
+		return
+	}
+	if err != nil {
+		s.logger.Warn().Err(errors.NewGitHubError("GetContributorStats", fullName, err)).Msg("Failed to fetch contributor stats")
+		return
+	}
+	if err := s.db.UpsertContributorWeeks(ctx, repoID, contributors); err != nil {
+		s.logger.Warn().Err(err).Str("repository", fullName).Msg("Failed to store contributor stats")
+	}
+}
+
+// GetContributorStats returns the contributors of a repository with their weekly
+// activity between since and until, most active first
+func (s *Service) GetContributorStats(ctx context.Context, fullName string, since, until *time.Time) ([]*models.ContributorStats, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	contributors, err := s.db.GetContributorStats(ctx, repo.ID, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching contributor stats: %w", err)
+	}
+	return contributors, nil
+}
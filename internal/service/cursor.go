@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// EncodeCommitCursor returns the opaque cursor of the position after a commit
+func EncodeCommitCursor(commit *models.Commit) string {
+	raw := commit.CommitDate.UTC().Format(time.RFC3339Nano) + "," + strconv.FormatInt(commit.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCommitCursor decodes a cursor returned by EncodeCommitCursor
+func ParseCommitCursor(cursor string) (*models.CommitCursor, error) {
+	invalid := fmt.Errorf("%w: invalid cursor %q", errors.ErrInvalidInput, cursor)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	date, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, invalid
+	}
+	commitDate, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return nil, invalid
+	}
+	commitID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || commitID <= 0 {
+		return nil, invalid
+	}
+	return &models.CommitCursor{CommitDate: commitDate, ID: commitID}, nil
+}
+
+// StreamCommitsAfter calls fn for each of up to limit commits matching the
+// filter that follow the cursor. Unlike StreamCommitsByRepository, it doesn't
+// count the matching commits.
+func (s *Service) StreamCommitsAfter(ctx context.Context, fullName string, filter models.CommitFilter, cursor *models.CommitCursor, limit int, fn func(*models.Commit) error) error {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return errors.NewNotFoundError("repository", fullName)
+	}
+
+	if err := s.db.StreamCommitsAfter(ctx, repo.ID, filter, cursor, limit, fn); err != nil {
+		return fmt.Errorf("error fetching commits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitCursor(t *testing.T) {
+	commit := &models.Commit{ID: 42, CommitDate: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.FixedZone("CET", 3600))}
+
+	cursor, err := ParseCommitCursor(EncodeCommitCursor(commit))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), cursor.ID)
+	assert.True(t, cursor.CommitDate.Equal(commit.CommitDate))
+
+	for _, invalid := range []string{"", "not base64!", "bm8gY29tbWE", "MjAyNC0wMy0wMVQxMjozMDowMFosMA"} {
+		_, err := ParseCommitCursor(invalid)
+		assert.ErrorIs(t, err, errors.ErrInvalidInput, invalid)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github-service/internal/dependencies"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// WithDependencies enables syncing the dependencies declared in the go.mod,
+// package.json and requirements.txt at the root of repositories along with
+// their commits. This costs one extra GitHub API request per manifest and sync.
+func WithDependencies(enabled bool) Option {
+	return func(s *Service) {
+		s.syncDeps = enabled
+	}
+}
+
+// syncDependencies fetches, parses and stores the manifests of a repository,
+// clearing the dependencies of manifests that no longer exist. Failures are
+// logged rather than returned so they never fail the commit sync, and leave
+// the manifest's stored dependencies as they were.
+func (s *Service) syncDependencies(ctx context.Context, owner, name string, repoID int64) {
+	fullName := fmt.Sprintf("%s/%s", owner, name)
+
+	for _, manifest := range dependencies.Manifests {
+		content, err := s.github.GetFileContent(ctx, owner, name, manifest)
+		if err != nil {
+			s.logger.Warn().Err(errors.NewGitHubError("GetFileContent", fullName, err)).Str("manifest", manifest).Msg("Failed to fetch manifest")
+			continue
+		}
+
+		var deps []models.Dependency
+		if content != nil {
+			if deps, err = dependencies.Parse(manifest, content); err != nil {
+				s.logger.Warn().Err(err).Str("repository", fullName).Msg("Failed to parse manifest")
+				continue
+			}
+		}
+		if err := s.db.ReplaceRepositoryDependencies(ctx, repoID, manifest, deps); err != nil {
+			s.logger.Warn().Err(err).Str("repository", fullName).Str("manifest", manifest).Msg("Failed to store dependencies")
+		}
+	}
+}
+
+// checkEcosystem fails with an invalid input error unless the ecosystem's
+// dependencies are tracked
+func checkEcosystem(ecosystem string) error {
+	if !dependencies.ValidEcosystem(ecosystem) {
+		return fmt.Errorf("%w: ecosystem must be one of %s, %s or %s", errors.ErrInvalidInput,
+			models.EcosystemGo, models.EcosystemNPM, models.EcosystemPyPI)
+	}
+	return nil
+}
+
+// GetRepositoryDependencies returns the dependencies a repository declares,
+// optionally only those of one ecosystem
+func (s *Service) GetRepositoryDependencies(ctx context.Context, fullName, ecosystem string) ([]*models.Dependency, error) {
+	if ecosystem != "" {
+		if err := checkEcosystem(ecosystem); err != nil {
+			return nil, err
+		}
+	}
+
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	deps, err := s.db.GetRepositoryDependencies(ctx, repo.ID, ecosystem)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching dependencies: %w", err)
+	}
+	return deps, nil
+}
+
+// GetPackageDependents returns the monitored repositories depending on a
+// package, optionally only those declaring the given version and those account
+// added, with the number of repositories per declared version
+func (s *Service) GetPackageDependents(ctx context.Context, ecosystem, name, version string, account int64) (*models.PackageDependents, error) {
+	if err := checkEcosystem(ecosystem); err != nil {
+		return nil, err
+	}
+	name = dependencies.NormalizeName(ecosystem, strings.TrimSpace(name))
+	if name == "" {
+		return nil, fmt.Errorf("%w: package name is required", errors.ErrInvalidInput)
+	}
+
+	dependents, err := s.db.GetPackageDependents(ctx, ecosystem, name, version, account)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching dependents: %w", err)
+	}
+
+	result := &models.PackageDependents{
+		Ecosystem:  ecosystem,
+		Name:       name,
+		Versions:   make(map[string]int),
+		Dependents: dependents,
+	}
+	for _, dep := range dependents {
+		result.Versions[dep.Version]++
+	}
+	return result, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// Sizes of the lists in a digest
+const (
+	digestTopAuthors  = 5
+	digestBusiestDays = 3
+)
+
+// digestDayLayout is the format of the days bounding a digest
+const digestDayLayout = "2006-01-02"
+
+// digestPeriodStart returns the first instant (UTC) of the period containing t.
+// Weeks start on Monday.
+func digestPeriodStart(period string, t time.Time) time.Time {
+	t = t.UTC()
+	if period == models.DigestMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// digestPeriodEnd returns the first instant after the period starting at start
+func digestPeriodEnd(period string, start time.Time) time.Time {
+	if period == models.DigestMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// lastDigestPeriod returns the start of the last period that ended by now
+func lastDigestPeriod(period string, now time.Time) time.Time {
+	current := digestPeriodStart(period, now)
+	if period == models.DigestMonthly {
+		return current.AddDate(0, -1, 0)
+	}
+	return current.AddDate(0, 0, -7)
+}
+
+// ParseDigestPeriod validates a digest period and the first day (YYYY-MM-DD) of
+// one of its periods, a Monday or the first of a month. Without a day the last
+// complete period is returned. Periods that haven't ended yet are rejected.
+func ParseDigestPeriod(period, start string) (time.Time, error) {
+	if !models.ValidDigestPeriod(period) {
+		return time.Time{}, fmt.Errorf("%w: period must be %s or %s", errors.ErrInvalidInput, models.DigestWeekly, models.DigestMonthly)
+	}
+	if start == "" {
+		return lastDigestPeriod(period, time.Now()), nil
+	}
+
+	day, err := time.Parse(digestDayLayout, start)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: start must be formatted as YYYY-MM-DD", errors.ErrInvalidInput)
+	}
+	if !digestPeriodStart(period, day).Equal(day) {
+		if period == models.DigestMonthly {
+			return time.Time{}, fmt.Errorf("%w: monthly digests start on the first of a month", errors.ErrInvalidInput)
+		}
+		return time.Time{}, fmt.Errorf("%w: weekly digests start on a Monday", errors.ErrInvalidInput)
+	}
+	if digestPeriodEnd(period, day).After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: the %s period starting %s has not ended yet", errors.ErrInvalidInput, period, start)
+	}
+	return day, nil
+}
+
+// GenerateDigest computes a repository's digest of the period starting at start
+// from its synced commits and stats snapshots and stores it, replacing an
+// earlier digest of the period
+func (s *Service) GenerateDigest(ctx context.Context, fullName, period string, start time.Time) (*models.Digest, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+	return s.generateDigest(ctx, repo, period, start)
+}
+
+func (s *Service) generateDigest(ctx context.Context, repo *models.Repository, period string, start time.Time) (*models.Digest, error) {
+	end := digestPeriodEnd(period, start)
+	digest := &models.Digest{
+		Repository:  repo.FullName,
+		Period:      period,
+		Start:       start.Format(digestDayLayout),
+		End:         end.AddDate(0, 0, -1).Format(digestDayLayout),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	var err error
+	if digest.Commits, digest.Contributors, err = s.db.CountCommitsAndAuthors(ctx, repo.ID, start, end); err != nil {
+		return nil, errors.NewDatabaseError("CountCommitsAndAuthors", err)
+	}
+	// The top authors query treats until as inclusive
+	until := end.Add(-time.Microsecond)
+	if digest.TopAuthors, err = s.db.GetTopCommitAuthorsByRepository(ctx, repo.ID, &start, &until, digestTopAuthors, 0); err != nil {
+		return nil, errors.NewDatabaseError("GetTopCommitAuthorsByRepository", err)
+	}
+	if digest.BusiestDays, err = s.db.GetBusiestDays(ctx, repo.ID, start, end, digestBusiestDays); err != nil {
+		return nil, errors.NewDatabaseError("GetBusiestDays", err)
+	}
+
+	// Stars are counted by the daily snapshots: the last one taken before the
+	// period and the last one taken by its end
+	if digest.StarsStart, err = s.db.GetStarsCountAsOf(ctx, repo.ID, start.AddDate(0, 0, -1)); err != nil {
+		return nil, errors.NewDatabaseError("GetStarsCountAsOf", err)
+	}
+	if digest.StarsEnd, err = s.db.GetStarsCountAsOf(ctx, repo.ID, end.AddDate(0, 0, -1)); err != nil {
+		return nil, errors.NewDatabaseError("GetStarsCountAsOf", err)
+	}
+	if digest.StarsStart != nil && digest.StarsEnd != nil {
+		delta := *digest.StarsEnd - *digest.StarsStart
+		digest.StarDelta = &delta
+	}
+
+	if digest.TopAuthors == nil {
+		digest.TopAuthors = []*models.CommitStats{}
+	}
+	if digest.BusiestDays == nil {
+		digest.BusiestDays = []*models.DailyCommitCount{}
+	}
+	digest.Markdown = renderDigestMarkdown(digest)
+
+	if err := s.db.SaveDigest(ctx, repo.ID, digest); err != nil {
+		return nil, errors.NewDatabaseError("SaveDigest", err)
+	}
+	return digest, nil
+}
+
+// GetDigests returns a page of a repository's stored digests of a period,
+// latest first
+func (s *Service) GetDigests(ctx context.Context, fullName, period string, page, perPage int) ([]*models.Digest, int, error) {
+	if !models.ValidDigestPeriod(period) {
+		return nil, 0, fmt.Errorf("%w: period must be %s or %s", errors.ErrInvalidInput, models.DigestWeekly, models.DigestMonthly)
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	digests, total, err := s.db.GetDigests(ctx, repoID, period, page, perPage)
+	if err != nil {
+		return nil, 0, errors.NewDatabaseError("GetDigests", err)
+	}
+	return digests, total, nil
+}
+
+// GenerateDueDigests generates the digest of the last complete period of every
+// monitored repository that doesn't have one yet, sending each through the
+// digest notifier. It returns how many digests were generated. A repository
+// that fails doesn't stop the others; the error reports how many failed.
+func (s *Service) GenerateDueDigests(ctx context.Context, period string) (int, error) {
+	if !models.ValidDigestPeriod(period) {
+		return 0, fmt.Errorf("%w: period must be %s or %s", errors.ErrInvalidInput, models.DigestWeekly, models.DigestMonthly)
+	}
+	start := lastDigestPeriod(period, time.Now())
+
+	monitored, err := s.db.GetMonitoredRepositories(ctx)
+	if err != nil {
+		return 0, errors.NewDatabaseError("GetMonitoredRepositories", err)
+	}
+
+	generated, failed := 0, 0
+	var lastErr error
+	for _, m := range monitored {
+		if err := ctx.Err(); err != nil {
+			return generated, err
+		}
+
+		digest, err := s.generateDueDigest(ctx, m.FullName, period, start)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("repository", m.FullName).Str("period", period).Msg("Failed to generate digest")
+			failed++
+			lastErr = err
+			continue
+		}
+		if digest == nil {
+			continue
+		}
+		generated++
+
+		if s.digestNotifier != nil {
+			s.digestNotifier.Notify(ctx, &models.Notification{
+				Event:      models.NotificationDigest,
+				Subject:    fmt.Sprintf("%s %s digest: %s to %s", digest.Repository, digest.Period, digest.Start, digest.End),
+				Message:    digest.Markdown,
+				Repository: digest.Repository,
+			})
+		}
+	}
+
+	if failed > 0 {
+		return generated, fmt.Errorf("failed to generate %d of %d digests: %w", failed, len(monitored), lastErr)
+	}
+	return generated, nil
+}
+
+// generateDueDigest generates a repository's digest of the period starting at
+// start unless it exists, or the repository has never been synced
+func (s *Service) generateDueDigest(ctx context.Context, fullName, period string, start time.Time) (*models.Digest, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, nil
+	}
+
+	exists, err := s.db.DigestExists(ctx, repo.ID, period, start)
+	if err != nil {
+		return nil, errors.NewDatabaseError("DigestExists", err)
+	}
+	if exists {
+		return nil, nil
+	}
+	return s.generateDigest(ctx, repo, period, start)
+}
+
+// renderDigestMarkdown renders a digest as a Markdown document
+func renderDigestMarkdown(digest *models.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s digest, %s to %s\n\n", digest.Repository, digest.Period, digest.Start, digest.End)
+
+	fmt.Fprintf(&b, "- Commits: %d\n- Contributors: %d\n", digest.Commits, digest.Contributors)
+	switch {
+	case digest.StarDelta != nil:
+		fmt.Fprintf(&b, "- Stars: %d (%+d)\n", *digest.StarsEnd, *digest.StarDelta)
+	case digest.StarsEnd != nil:
+		fmt.Fprintf(&b, "- Stars: %d (no snapshot from before the period)\n", *digest.StarsEnd)
+	default:
+		b.WriteString("- Stars: unknown (no snapshots)\n")
+	}
+
+	writeAuthorsMarkdown(&b, "Top authors", digest.TopAuthors)
+	writeBusiestDaysMarkdown(&b, digest.BusiestDays)
+
+	fmt.Fprintf(&b, "\n_Generated %s_\n", digest.GeneratedAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestPeriods(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, 3, 13, 15, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), digestPeriodStart(models.DigestWeekly, now))
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), lastDigestPeriod(models.DigestWeekly, now))
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), digestPeriodStart(models.DigestMonthly, now))
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), lastDigestPeriod(models.DigestMonthly, now))
+
+	// Sundays belong to the week started the Monday before
+	sunday := time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), digestPeriodStart(models.DigestWeekly, sunday))
+
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), digestPeriodEnd(models.DigestMonthly, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC), digestPeriodEnd(models.DigestWeekly, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestParseDigestPeriod(t *testing.T) {
+	start, err := ParseDigestPeriod(models.DigestWeekly, "2024-03-04")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), start)
+
+	start, err = ParseDigestPeriod(models.DigestMonthly, "")
+	require.NoError(t, err)
+	assert.Equal(t, lastDigestPeriod(models.DigestMonthly, time.Now()), start)
+
+	invalid := []struct{ period, start string }{
+		{"daily", ""},
+		{models.DigestWeekly, "2024-03-05"}, // A Tuesday
+		{models.DigestMonthly, "2024-03-04"},
+		{models.DigestWeekly, "March 4"},
+		{models.DigestWeekly, digestPeriodStart(models.DigestWeekly, time.Now()).Format(digestDayLayout)}, // Not over yet
+	}
+	for _, tc := range invalid {
+		_, err := ParseDigestPeriod(tc.period, tc.start)
+		assert.True(t, errors.Is(err, errors.ErrInvalidInput), "%s %s", tc.period, tc.start)
+	}
+}
+
+func TestRenderDigestMarkdown(t *testing.T) {
+	starsStart, starsEnd, delta := 120, 134, 14
+	digest := &models.Digest{
+		Repository:   "octo/hello",
+		Period:       models.DigestWeekly,
+		Start:        "2024-03-04",
+		End:          "2024-03-10",
+		Commits:      7,
+		Contributors: 2,
+		TopAuthors:   []*models.CommitStats{{AuthorName: "Ada", AuthorEmail: "ada@example.com", Count: 5}},
+		StarsStart:   &starsStart,
+		StarsEnd:     &starsEnd,
+		StarDelta:    &delta,
+		BusiestDays:  []*models.DailyCommitCount{{Date: "2024-03-05", Commits: 4}},
+		GeneratedAt:  time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+	}
+
+	markdown := renderDigestMarkdown(digest)
+	assert.Contains(t, markdown, "# octo/hello: weekly digest, 2024-03-04 to 2024-03-10\n")
+	assert.Contains(t, markdown, "- Commits: 7\n- Contributors: 2\n- Stars: 134 (+14)\n")
+	assert.Contains(t, markdown, "| Ada | ada@example.com | 5 |\n")
+	assert.Contains(t, markdown, "| 2024-03-05 | 4 |\n")
+
+	digest.StarsStart, digest.StarDelta = nil, nil
+	assert.Contains(t, renderDigestMarkdown(digest), "- Stars: 134 (no snapshot from before the period)\n")
+	digest.StarsEnd = nil
+	assert.Contains(t, renderDigestMarkdown(digest), "- Stars: unknown (no snapshots)\n")
+}
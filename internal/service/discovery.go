@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// DiscoverUserRepositories lists the public repositories of a watched user
+// that match its filters. It returns all of them and those that were neither
+// discovered for the user before nor are monitored already.
+func (s *Service) DiscoverUserRepositories(ctx context.Context, user *models.WatchedUser) (matching, undiscovered []string, err error) {
+	repositories, err := s.github.ListUserRepositories(ctx, user.Username)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing user repositories: %w", err)
+	}
+
+	now := time.Now()
+	matching = []string{}
+	for _, repo := range repositories {
+		if matchesDiscovery(user, repo, now) {
+			matching = append(matching, repo.FullName)
+		}
+	}
+	if len(matching) == 0 {
+		return matching, nil, nil
+	}
+
+	undiscovered, err = s.db.GetUndiscoveredRepositories(ctx, user.Username, matching)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking discovered repositories: %w", err)
+	}
+	return matching, undiscovered, nil
+}
+
+// RecordDiscoveredRepositories remembers the repositories added to monitoring
+// for a watched user, so removing one of them later sticks
+func (s *Service) RecordDiscoveredRepositories(ctx context.Context, username string, names []string) error {
+	if err := s.db.AddDiscoveredRepositories(ctx, username, names, time.Now().UTC()); err != nil {
+		return fmt.Errorf("error recording discovered repositories: %w", err)
+	}
+	return nil
+}
+
+// matchesDiscovery reports whether a user's repository passes the user's
+// filters. Archived repositories and forks are never discovered.
+func matchesDiscovery(user *models.WatchedUser, repo models.UserRepository, now time.Time) bool {
+	if repo.Archived || repo.Fork {
+		return false
+	}
+	if repo.StarsCount < user.MinStars {
+		return false
+	}
+	if user.Language != "" && !strings.EqualFold(repo.Language, user.Language) {
+		return false
+	}
+	if user.UpdatedWithin > 0 && repo.PushedAt.Before(now.Add(-user.UpdatedWithin)) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesDiscovery(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	user := &models.WatchedUser{
+		Username:      "octocat",
+		MinStars:      10,
+		Language:      "go",
+		UpdatedWithin: 30 * 24 * time.Hour,
+	}
+	repo := models.UserRepository{
+		FullName:   "octocat/tool",
+		Language:   "Go",
+		StarsCount: 12,
+		PushedAt:   now.Add(-24 * time.Hour),
+	}
+
+	assert.True(t, matchesDiscovery(user, repo, now), "language is matched ignoring case")
+
+	few := repo
+	few.StarsCount = 9
+	assert.False(t, matchesDiscovery(user, few, now), "too few stars")
+
+	other := repo
+	other.Language = "Rust"
+	assert.False(t, matchesDiscovery(user, other, now), "other language")
+
+	stale := repo
+	stale.PushedAt = now.Add(-31 * 24 * time.Hour)
+	assert.False(t, matchesDiscovery(user, stale, now), "not updated within the window")
+
+	fork := repo
+	fork.Fork = true
+	assert.False(t, matchesDiscovery(user, fork, now), "forks are skipped")
+
+	archived := repo
+	archived.Archived = true
+	assert.False(t, matchesDiscovery(user, archived, now), "archived repositories are skipped")
+
+	assert.True(t, matchesDiscovery(&models.WatchedUser{Username: "octocat"}, stale, now), "no filters")
+}
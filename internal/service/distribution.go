@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// lorenzIntervals is the number of equal shares of the authors the Lorenz
+// curve is reported at
+const lorenzIntervals = 10
+
+// GetContributionDistribution returns how concentrated a repository's commits
+// within since and until are among its authors
+func (s *Service) GetContributionDistribution(ctx context.Context, fullName string, since, until *time.Time) (*models.ContributionDistribution, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	counts, err := s.db.GetAuthorCommitCounts(ctx, repo.ID, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("error counting author commits: %w", err)
+	}
+	return contributionDistribution(counts), nil
+}
+
+// contributionDistribution computes the Gini coefficient and Lorenz curve of
+// the commit counts of each author, sorted fewest first
+func contributionDistribution(counts []int) *models.ContributionDistribution {
+	n := len(counts)
+	cumulative := make([]int, n+1)
+	weighted := 0
+	for i, count := range counts {
+		cumulative[i+1] = cumulative[i] + count
+		weighted += (i + 1) * count
+	}
+	total := cumulative[n]
+
+	dist := &models.ContributionDistribution{
+		Authors: n,
+		Commits: total,
+		Lorenz:  make([]models.LorenzPoint, 0, lorenzIntervals+1),
+	}
+	if total > 0 {
+		dist.Gini = roundShare(2*float64(weighted)/(float64(n)*float64(total)) - float64(n+1)/float64(n))
+	}
+
+	for i := 0; i <= lorenzIntervals; i++ {
+		share := float64(i) / lorenzIntervals
+		point := models.LorenzPoint{Authors: share}
+		if total > 0 {
+			// Interpolate linearly within the author the share ends in
+			position := share * float64(n)
+			whole := int(position)
+			commits := float64(cumulative[whole])
+			if whole < n {
+				commits += (position - float64(whole)) * float64(counts[whole])
+			}
+			point.Commits = roundShare(commits / float64(total))
+		}
+		dist.Lorenz = append(dist.Lorenz, point)
+	}
+	return dist
+}
+
+// roundShare rounds a share to four decimals
+func roundShare(share float64) float64 {
+	return math.Round(share*10000) / 10000
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContributionDistribution(t *testing.T) {
+	t.Run("no commits", func(t *testing.T) {
+		dist := contributionDistribution(nil)
+		assert.Equal(t, 0, dist.Authors)
+		assert.Equal(t, 0.0, dist.Gini)
+		assert.Len(t, dist.Lorenz, lorenzIntervals+1)
+		assert.Equal(t, 0.0, dist.Lorenz[lorenzIntervals].Commits)
+	})
+
+	t.Run("equal authors", func(t *testing.T) {
+		dist := contributionDistribution([]int{5, 5, 5, 5})
+		assert.Equal(t, 4, dist.Authors)
+		assert.Equal(t, 20, dist.Commits)
+		assert.Equal(t, 0.0, dist.Gini)
+		for _, point := range dist.Lorenz {
+			assert.InDelta(t, point.Authors, point.Commits, 0.0001)
+		}
+	})
+
+	t.Run("single dominant author", func(t *testing.T) {
+		dist := contributionDistribution([]int{0, 0, 0, 10})
+		assert.Equal(t, 0.75, dist.Gini)
+		assert.Equal(t, 0.0, dist.Lorenz[7].Commits)
+		assert.Equal(t, 0.6, dist.Lorenz[9].Commits)
+		assert.Equal(t, 1.0, dist.Lorenz[lorenzIntervals].Commits)
+	})
+
+	t.Run("skewed authors", func(t *testing.T) {
+		dist := contributionDistribution([]int{1, 2, 3, 4})
+		assert.Equal(t, 0.25, dist.Gini)
+		assert.Equal(t, 0.3, dist.Lorenz[5].Commits)
+	})
+}
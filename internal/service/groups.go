@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github-service/internal/cache"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// groupSummaryAuthors is the number of top authors in a group summary
+const groupSummaryAuthors = 10
+
+// groupNamePattern matches the names groups can have, which appear in paths
+var groupNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// normalizeRepositoryGroup cleans up and checks a group definition supplied by
+// a user. Names are lower case; repositories are deduplicated and sorted.
+func normalizeRepositoryGroup(group *models.RepositoryGroup) error {
+	group.Name = strings.ToLower(strings.TrimSpace(group.Name))
+	if !groupNamePattern.MatchString(group.Name) {
+		return fmt.Errorf("%w: group name %q must be 1 to 63 lowercase letters, digits, - or _, starting with a letter or digit", errors.ErrInvalidInput, group.Name)
+	}
+	group.Description = strings.TrimSpace(group.Description)
+
+	seen := make(map[string]bool, len(group.Repositories))
+	repositories := make([]string, 0, len(group.Repositories))
+	for _, name := range group.Repositories {
+		name = strings.TrimSpace(name)
+		if owner, repo, ok := strings.Cut(name, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return fmt.Errorf("%w: repository %q must be in the form owner/repo", errors.ErrInvalidInput, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			repositories = append(repositories, name)
+		}
+	}
+	sort.Strings(repositories)
+	group.Repositories = repositories
+	return nil
+}
+
+// CreateRepositoryGroup creates a group of monitored repositories
+func (s *Service) CreateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	if err := normalizeRepositoryGroup(group); err != nil {
+		return err
+	}
+	if err := s.db.CreateRepositoryGroup(ctx, group); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// ListRepositoryGroups returns every group ordered by name
+func (s *Service) ListRepositoryGroups(ctx context.Context) ([]*models.RepositoryGroup, error) {
+	return s.db.ListRepositoryGroups(ctx)
+}
+
+// GetRepositoryGroup returns a group with its members
+func (s *Service) GetRepositoryGroup(ctx context.Context, name string) (*models.RepositoryGroup, error) {
+	group, err := s.db.GetRepositoryGroup(ctx, name)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetRepositoryGroup", err)
+	}
+	if group == nil {
+		return nil, errors.NewNotFoundError("repository group", name)
+	}
+	return group, nil
+}
+
+// UpdateRepositoryGroup replaces the description and members of a group
+func (s *Service) UpdateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error {
+	if err := normalizeRepositoryGroup(group); err != nil {
+		return err
+	}
+	if err := s.db.UpdateRepositoryGroup(ctx, group); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// DeleteRepositoryGroup removes a group, leaving its repositories monitored
+func (s *Service) DeleteRepositoryGroup(ctx context.Context, name string) error {
+	if err := s.db.DeleteRepositoryGroup(ctx, name); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// AddRepositoryGroupMember adds a monitored repository to a group
+func (s *Service) AddRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	if err := s.db.AddRepositoryGroupMember(ctx, name, fullName); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// RemoveRepositoryGroupMember removes a repository from a group
+func (s *Service) RemoveRepositoryGroupMember(ctx context.Context, name, fullName string) error {
+	if err := s.db.RemoveRepositoryGroupMember(ctx, name, fullName); err != nil {
+		return err
+	}
+	s.cache.Invalidate(ctx, cache.GroupAll)
+	return nil
+}
+
+// checkRepositoryGroup fails with a NotFoundError unless the group exists or
+// name is empty, so stats filtered by a missing group aren't reported as empty
+func (s *Service) checkRepositoryGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := s.GetRepositoryGroup(ctx, name)
+	return err
+}
+
+// GetGroupSummary aggregates the commits, authors and stars of a group's
+// members since the given time
+func (s *Service) GetGroupSummary(ctx context.Context, name string, since time.Time) (*models.GroupSummary, error) {
+	group, err := s.GetRepositoryGroup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.GroupSummary{
+		Group:        group.Name,
+		Since:        since,
+		Until:        time.Now(),
+		Repositories: len(group.Repositories),
+	}
+
+	// Every active member is ranked, so the ranking doubles as the totals
+	activity, err := s.db.GetTopRepositories(ctx, since, models.ActivityMetricCommits, group.Name, 0, len(group.Repositories)+1)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetTopRepositories", err)
+	}
+	summary.Activity = activity
+	if summary.Activity == nil {
+		summary.Activity = []*models.RepositoryActivity{}
+	}
+	summary.ActiveRepositories = len(activity)
+	for _, repo := range activity {
+		summary.Commits += repo.CommitCount
+	}
+
+	if summary.Authors, err = s.db.CountCommitAuthors(ctx, &since, nil, "", group.Name, 0); err != nil {
+		return nil, errors.NewDatabaseError("CountCommitAuthors", err)
+	}
+	if summary.TopAuthors, err = s.db.GetTopCommitAuthors(ctx, &since, nil, "", group.Name, 0, groupSummaryAuthors, 0); err != nil {
+		return nil, errors.NewDatabaseError("GetTopCommitAuthors", err)
+	}
+	if summary.TopAuthors == nil {
+		summary.TopAuthors = []*models.CommitStats{}
+	}
+
+	for _, fullName := range group.Repositories {
+		repo, err := s.db.GetRepositoryByName(ctx, fullName)
+		if err != nil {
+			return nil, errors.NewDatabaseError("GetRepositoryByName", err)
+		}
+		if repo == nil {
+			continue // Not synced yet
+		}
+		summary.Stars += repo.StarsCount
+
+		before, err := s.db.GetStarsCountAsOf(ctx, repo.ID, since.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, errors.NewDatabaseError("GetStarsCountAsOf", err)
+		}
+		if before != nil {
+			summary.StarDelta += repo.StarsCount - *before
+		}
+	}
+	return summary, nil
+}
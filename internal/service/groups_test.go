@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRepositoryGroup(t *testing.T) {
+	group := &models.RepositoryGroup{
+		Name:         " Platform ",
+		Description:  " Platform team ",
+		Repositories: []string{"octo/web", " octo/api", "octo/web"},
+	}
+	require.NoError(t, normalizeRepositoryGroup(group))
+	assert.Equal(t, "platform", group.Name)
+	assert.Equal(t, "Platform team", group.Description)
+	assert.Equal(t, []string{"octo/api", "octo/web"}, group.Repositories)
+
+	for _, invalid := range []models.RepositoryGroup{
+		{Name: ""},
+		{Name: "-platform"},
+		{Name: "platform/web"},
+		{Name: "platform", Repositories: []string{"octo"}},
+		{Name: "platform", Repositories: []string{"octo/api/extra"}},
+		{Name: "platform", Repositories: []string{"/api"}},
+	} {
+		err := normalizeRepositoryGroup(&invalid)
+		assert.True(t, errors.Is(err, errors.ErrInvalidInput), "group %+v", invalid)
+	}
+}
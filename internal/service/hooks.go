@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/events"
+	"github-service/internal/models"
+)
+
+// commitHookBatchSize is the most commits delivered to a commit hook in one request
+const commitHookBatchSize = 100
+
+// CommitHookEvent is the webhook payload carrying a batch of newly ingested commits
+type CommitHookEvent struct {
+	Event      string           `json:"event"`
+	Repository string           `json:"repository"`
+	HookID     int64            `json:"hook_id"`
+	SyncRunID  int64            `json:"sync_run_id"`
+	Batch      int              `json:"batch"`   // 1-based position of this batch among the sync's deliveries
+	Batches    int              `json:"batches"` // Deliveries made for the sync
+	Commits    []*models.Commit `json:"commits"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// normalizeCommitHook cleans up and checks a hook definition supplied by a user
+func normalizeCommitHook(hook *models.CommitHook) error {
+	if err := validateWebhookURL(hook.WebhookURL); err != nil {
+		return err
+	}
+
+	authors := make([]string, 0, len(hook.Authors))
+	for _, author := range hook.Authors {
+		if author = strings.ToLower(strings.TrimSpace(author)); author != "" {
+			authors = append(authors, author)
+		}
+	}
+	hook.Authors = authors
+
+	paths := make([]string, 0, len(hook.Paths))
+	for _, pattern := range hook.Paths {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: invalid path pattern %q", errors.ErrInvalidInput, pattern)
+		}
+		paths = append(paths, pattern)
+	}
+	hook.Paths = paths
+	return nil
+}
+
+// commitHookMatches reports whether a commit that changed files passes a hook's
+// filters. A commit whose files are unknown never matches a hook with paths.
+func commitHookMatches(hook *models.CommitHook, commit *models.Commit, files []string) bool {
+	if len(hook.Authors) > 0 {
+		name, email := strings.ToLower(commit.AuthorName), strings.ToLower(commit.AuthorEmail)
+		matched := false
+		for _, author := range hook.Authors {
+			if author == name || author == email {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(hook.Paths) == 0 {
+		return true
+	}
+	for _, file := range files {
+		for _, pattern := range hook.Paths {
+			if pathMatches(pattern, file) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathMatches reports whether file matches a glob pattern or lies in the
+// directory it names
+func pathMatches(pattern, file string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(file, pattern)
+	}
+	if ok, _ := path.Match(pattern, file); ok {
+		return true
+	}
+	return strings.HasPrefix(file, pattern+"/")
+}
+
+// CreateCommitHook adds a commit hook to a repository. It receives the commits
+// ingested by later syncs.
+func (s *Service) CreateCommitHook(ctx context.Context, fullName string, hook *models.CommitHook) error {
+	if err := normalizeCommitHook(hook); err != nil {
+		return err
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+
+	hook.RepositoryID = repoID
+	if err := s.db.CreateCommitHook(ctx, hook); err != nil {
+		return errors.NewDatabaseError("CreateCommitHook", err)
+	}
+	return nil
+}
+
+// ListCommitHooks returns the commit hooks of a repository
+func (s *Service) ListCommitHooks(ctx context.Context, fullName string) ([]*models.CommitHook, error) {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.ListCommitHooks(ctx, repoID)
+}
+
+// GetCommitHook returns a commit hook of a repository
+func (s *Service) GetCommitHook(ctx context.Context, fullName string, id int64) (*models.CommitHook, error) {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := s.db.GetCommitHook(ctx, repoID, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetCommitHook", err)
+	}
+	if hook == nil {
+		return nil, errors.NewNotFoundError("commit hook", id)
+	}
+	return hook, nil
+}
+
+// UpdateCommitHook replaces the definition of a commit hook
+func (s *Service) UpdateCommitHook(ctx context.Context, fullName string, hook *models.CommitHook) error {
+	if err := normalizeCommitHook(hook); err != nil {
+		return err
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+
+	hook.RepositoryID = repoID
+	return s.db.UpdateCommitHook(ctx, hook)
+}
+
+// DeleteCommitHook removes a commit hook from a repository
+func (s *Service) DeleteCommitHook(ctx context.Context, fullName string, id int64) error {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteCommitHook(ctx, repoID, id)
+}
+
+// deliverCommitHooks sends the commits ingested by a sync to each of the
+// repository's hooks they match, in batches. files holds the changed files by
+// commit SHA. Failures are logged rather than returned so hooks never fail a sync.
+func (s *Service) deliverCommitHooks(ctx context.Context, repo *models.Repository, runID int64, commits []*models.Commit, files map[string][]string) {
+	hooks, err := s.db.ListCommitHooks(ctx, repo.ID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("repository", repo.FullName).Msg("Failed to load commit hooks")
+		return
+	}
+
+	for _, hook := range hooks {
+		var matched []*models.Commit
+		for _, commit := range commits {
+			if commitHookMatches(hook, commit, files[commit.SHA]) {
+				matched = append(matched, commit)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		batches := (len(matched) + commitHookBatchSize - 1) / commitHookBatchSize
+		for i := 0; i < batches; i++ {
+			end := min((i+1)*commitHookBatchSize, len(matched))
+			s.sendCommitHookEvent(ctx, hook, CommitHookEvent{
+				Event:      events.CommitsIngested,
+				Repository: repo.FullName,
+				HookID:     hook.ID,
+				SyncRunID:  runID,
+				Batch:      i + 1,
+				Batches:    batches,
+				Commits:    matched[i*commitHookBatchSize : end],
+				OccurredAt: time.Now().UTC(),
+			})
+		}
+	}
+}
+
+// sendCommitHookEvent delivers a batch of commits to a hook's webhook
+func (s *Service) sendCommitHookEvent(ctx context.Context, hook *models.CommitHook, event CommitHookEvent) {
+	log := s.logger.With().
+		Int64("hook_id", hook.ID).
+		Str("repository", event.Repository).
+		Int("batch", event.Batch).
+		Int("commits", len(event.Commits)).
+		Logger()
+
+	if s.webhooks == nil {
+		log.Info().Msg("Commits matched a commit hook; no webhook sender configured")
+		return
+	}
+	if err := s.webhooks.Send(ctx, hook.WebhookURL, event); err != nil {
+		log.Warn().Err(err).Msg("Failed to deliver commit hook webhook")
+		return
+	}
+	log.Info().Msg("Delivered commit hook webhook")
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCommitHook(t *testing.T) {
+	hook := &models.CommitHook{
+		WebhookURL: "https://example.com/hook",
+		Authors:    []string{" Octocat@GitHub.com ", ""},
+		Paths:      []string{"/deploy/", " *.tf", ""},
+	}
+	require.NoError(t, normalizeCommitHook(hook))
+	assert.Equal(t, []string{"octocat@github.com"}, hook.Authors)
+	assert.Equal(t, []string{"deploy/", "*.tf"}, hook.Paths)
+
+	err := normalizeCommitHook(&models.CommitHook{WebhookURL: "https://example.com/hook", Paths: []string{"[a-"}})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+
+	err = normalizeCommitHook(&models.CommitHook{WebhookURL: "ftp://example.com/hook"})
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestCommitHookMatches(t *testing.T) {
+	commit := &models.Commit{AuthorName: "The Octocat", AuthorEmail: "Octocat@GitHub.com"}
+
+	tests := []struct {
+		name  string
+		hook  models.CommitHook
+		files []string
+		want  bool
+	}{
+		{"no filters", models.CommitHook{}, nil, true},
+		{"author email", models.CommitHook{Authors: []string{"octocat@github.com"}}, nil, true},
+		{"author name", models.CommitHook{Authors: []string{"the octocat"}}, nil, true},
+		{"other author", models.CommitHook{Authors: []string{"hubot"}}, nil, false},
+		{"directory", models.CommitHook{Paths: []string{"deploy/"}}, []string{"README.md", "deploy/app.yaml"}, true},
+		{"directory without slash", models.CommitHook{Paths: []string{"deploy"}}, []string{"deploy/app.yaml"}, true},
+		{"glob", models.CommitHook{Paths: []string{"*.tf"}}, []string{"main.tf"}, true},
+		{"glob does not cross directories", models.CommitHook{Paths: []string{"*.tf"}}, []string{"infra/main.tf"}, false},
+		{"unknown files", models.CommitHook{Paths: []string{"deploy/"}}, nil, false},
+		{"author and path", models.CommitHook{Authors: []string{"hubot"}, Paths: []string{"deploy/"}}, []string{"deploy/app.yaml"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, commitHookMatches(&tt.hook, commit, tt.files))
+		})
+	}
+}
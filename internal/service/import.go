@@ -0,0 +1,284 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// Commit dump formats accepted by ImportCommits
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+const (
+	// importBatchSize is the number of records checked for duplicates at once
+	importBatchSize = 500
+	// maxImportErrors bounds the rejected records listed in an import result
+	maxImportErrors = 100
+)
+
+// shaPattern matches a full hexadecimal commit SHA
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// importDateLayouts are the date formats accepted in commit dumps: strict ISO 8601
+// as produced by git log --format=%aI, and git's default ISO format (%ai)
+var importDateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05 -0700"}
+
+// importRecord is a commit of a dump, keyed by the CSV header or JSON field names
+type importRecord struct {
+	SHA            string `json:"sha"`
+	Message        string `json:"message"`
+	AuthorName     string `json:"author_name"`
+	AuthorEmail    string `json:"author_email"`
+	AuthorDate     string `json:"author_date"`
+	CommitterName  string `json:"committer_name"`
+	CommitterEmail string `json:"committer_email"`
+	CommitDate     string `json:"commit_date"`
+	URL            string `json:"url"`
+
+	line int
+}
+
+// ImportCommits stores the commits of a CSV or NDJSON dump in a repository that
+// is already stored, e.g. one the token can no longer access. Records are
+// validated individually and those that fail are reported rather than failing
+// the import. Commits are deduplicated by SHA, so a dump can be imported again.
+func (s *Service) ImportCommits(ctx context.Context, fullName, format string, r io.Reader) (*models.CommitImportResult, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	result := &models.CommitImportResult{Repository: fullName, Errors: []models.CommitImportError{}}
+	reject := func(rec importRecord, err error) {
+		result.Rejected++
+		if len(result.Errors) < maxImportErrors {
+			result.Errors = append(result.Errors, models.CommitImportError{Line: rec.line, SHA: rec.SHA, Error: err.Error()})
+		}
+	}
+
+	seen := make(map[string]bool)
+	batch := make([]*models.Commit, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		// Commits already stored are skipped by the insert
+		created, existing, err := s.db.CreateCommits(ctx, batch)
+		if err != nil {
+			return errors.NewCommitError(repo.ID, "", "CreateCommits", err)
+		}
+		result.Imported += created
+		result.Duplicates += existing
+
+		// Imported tickets are looked up by the next refresh
+		if s.tickets != nil {
+			for _, commit := range batch {
+				if commit.ID != 0 {
+					s.recordCommitTickets(ctx, commit)
+				}
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err = readImportRecords(format, r, func(rec importRecord) error {
+		commit, err := rec.commit(repo)
+		if err != nil {
+			reject(rec, err)
+			return nil
+		}
+		if seen[commit.SHA] {
+			result.Duplicates++
+			return nil
+		}
+		seen[commit.SHA] = true
+
+		batch = append(batch, commit)
+		if len(batch) == importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	// Cached stats don't count the imported commits yet
+	if result.Imported > 0 {
+		s.invalidateRepository(ctx, repo.FullName)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	s.logger.Info().
+		Str("repository", fullName).
+		Int("imported", result.Imported).
+		Int("duplicates", result.Duplicates).
+		Int("rejected", result.Rejected).
+		Msg("Imported commits from dump")
+
+	return result, nil
+}
+
+// commit validates the record and converts it to a commit of the repository.
+// Committer fields default to the author's.
+func (rec importRecord) commit(repo *models.Repository) (*models.Commit, error) {
+	sha := strings.ToLower(strings.TrimSpace(rec.SHA))
+	if !shaPattern.MatchString(sha) {
+		return nil, fmt.Errorf("invalid sha %q", rec.SHA)
+	}
+	if rec.AuthorName == "" && rec.AuthorEmail == "" {
+		return nil, fmt.Errorf("missing author")
+	}
+	authorDate, err := parseImportDate(rec.AuthorDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid author_date: %w", err)
+	}
+
+	commit := &models.Commit{
+		RepositoryID:   repo.ID,
+		SHA:            sha,
+		Message:        rec.Message,
+		AuthorName:     rec.AuthorName,
+		AuthorEmail:    rec.AuthorEmail,
+		AuthorDate:     authorDate,
+		CommitterName:  rec.CommitterName,
+		CommitterEmail: rec.CommitterEmail,
+		CommitDate:     authorDate,
+		URL:            rec.URL,
+		Type:           commitType(rec.Message),
+	}
+	if commit.CommitterName == "" && commit.CommitterEmail == "" {
+		commit.CommitterName, commit.CommitterEmail = rec.AuthorName, rec.AuthorEmail
+	}
+	if rec.CommitDate != "" {
+		if commit.CommitDate, err = parseImportDate(rec.CommitDate); err != nil {
+			return nil, fmt.Errorf("invalid commit_date: %w", err)
+		}
+	}
+	if commit.URL == "" && repo.URL != "" {
+		commit.URL = fmt.Sprintf("%s/commit/%s", strings.TrimSuffix(repo.URL, "/"), sha)
+	}
+	return commit, nil
+}
+
+// parseImportDate parses a commit date in one of the accepted layouts
+func parseImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing date")
+	}
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// readImportRecords calls fn with each record of a commit dump. A malformed
+// dump fails with an error wrapping ErrInvalidInput.
+func readImportRecords(format string, r io.Reader, fn func(importRecord) error) error {
+	switch format {
+	case ImportFormatCSV:
+		return readCSVRecords(r, fn)
+	case ImportFormatNDJSON:
+		return readNDJSONRecords(r, fn)
+	default:
+		return fmt.Errorf("%w: unsupported import format %q, expected csv or ndjson", errors.ErrInvalidInput, format)
+	}
+}
+
+// readCSVRecords reads a CSV dump whose header row names the columns, in any
+// order. Unknown columns are ignored.
+func readCSVRecords(r io.Reader, fn func(importRecord) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%w: reading CSV header: %v", errors.ErrInvalidInput, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"sha", "author_date"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("%w: CSV header lacks the %s column", errors.ErrInvalidInput, required)
+		}
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", errors.ErrInvalidInput, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		line, _ := reader.FieldPos(0)
+		rec := importRecord{
+			SHA:            field("sha"),
+			Message:        field("message"),
+			AuthorName:     field("author_name"),
+			AuthorEmail:    field("author_email"),
+			AuthorDate:     field("author_date"),
+			CommitterName:  field("committer_name"),
+			CommitterEmail: field("committer_email"),
+			CommitDate:     field("commit_date"),
+			URL:            field("url"),
+			line:           line,
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// readNDJSONRecords reads a dump with one JSON object per line. Blank lines are skipped.
+func readNDJSONRecords(r io.Reader, fn func(importRecord) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec importRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return fmt.Errorf("%w: line %d: %v", errors.ErrInvalidInput, line, err)
+		}
+		rec.line = line
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading dump: %w", err)
+	}
+	return nil
+}
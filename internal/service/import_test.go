@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github-service/internal/cache"
+	"github-service/internal/models"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadImportRecords(t *testing.T) {
+	repo := &models.Repository{ID: 1, URL: "https://github.com/octo/air-gapped"}
+	sha := strings.Repeat("a", 40)
+
+	tests := []struct {
+		name     string
+		format   string
+		dump     string
+		valid    int
+		rejected []int // Lines of the rejected records
+	}{
+		{
+			name:   "csv",
+			format: ImportFormatCSV,
+			dump: "sha,author_name,author_email,author_date,message\n" +
+				sha + ",Octo Cat,octo@example.com,2024-03-01T12:00:00Z,\"Initial commit\n\nWith body\"\n" +
+				"not-a-sha,Octo Cat,octo@example.com,2024-03-01T12:00:00Z,Broken\n" +
+				strings.Repeat("b", 40) + ",Octo Cat,,2024-03-02 09:30:00 +0100,Second\n",
+			valid:    2,
+			rejected: []int{5},
+		},
+		{
+			name:   "ndjson",
+			format: ImportFormatNDJSON,
+			dump: `{"sha":"` + strings.ToUpper(sha) + `","author_name":"Octo Cat","author_date":"2024-03-01T12:00:00Z"}` + "\n\n" +
+				`{"sha":"` + sha + `","author_date":"2024-03-01T12:00:00Z"}` + "\n" +
+				`{"sha":"` + sha + `","author_name":"Octo Cat","author_date":"yesterday"}` + "\n",
+			valid:    1,
+			rejected: []int{3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var valid int
+			var rejected []int
+			err := readImportRecords(tt.format, strings.NewReader(tt.dump), func(rec importRecord) error {
+				commit, err := rec.commit(repo)
+				if err != nil {
+					rejected = append(rejected, rec.line)
+					return nil
+				}
+				valid++
+				assert.Equal(t, commit.AuthorName, commit.CommitterName)
+				assert.True(t, commit.CommitDate.Equal(commit.AuthorDate))
+				assert.Equal(t, repo.URL+"/commit/"+commit.SHA, commit.URL)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.valid, valid)
+			assert.Equal(t, tt.rejected, rejected)
+		})
+	}
+
+	err := readImportRecords(ImportFormatCSV, strings.NewReader("author_name\nOcto\n"), func(importRecord) error { return nil })
+	assert.Error(t, err, "a CSV dump needs a sha column")
+}
+
+// importStore is a database holding one repository whose commits are only
+// counted, each SHA being new once
+type importStore struct {
+	Database
+	repo   *models.Repository
+	stored map[string]bool
+}
+
+func (d *importStore) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
+	if fullName != d.repo.FullName {
+		return nil, nil
+	}
+	return d.repo, nil
+}
+
+func (d *importStore) CreateCommits(ctx context.Context, commits []*models.Commit) (created, existing int, err error) {
+	for _, commit := range commits {
+		if d.stored[commit.SHA] {
+			existing++
+			continue
+		}
+		d.stored[commit.SHA] = true
+		commit.ID = int64(len(d.stored))
+		created++
+	}
+	return created, existing, nil
+}
+
+func TestImportCommitsInvalidatesCache(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	c := cache.New(cache.NewMemory(100), time.Hour, logger)
+	repo := &models.Repository{ID: 1, FullName: "octo/air-gapped", URL: "https://github.com/octo/air-gapped"}
+	s := New(nil, &importStore{repo: repo, stored: map[string]bool{}}, &logger, WithCache(c))
+
+	// loads counts the reads of a cached value that missed the cache
+	loads := map[string]int{}
+	read := func(group string) {
+		_, err := cache.Get(ctx, c, group, "top-authors", func() (int, error) {
+			loads[group]++
+			return loads[group], nil
+		})
+		require.NoError(t, err)
+	}
+	repoGroup := cache.RepositoryGroup(repo.FullName)
+	otherGroup := cache.RepositoryGroup("octo/other")
+	dump := "sha,author_name,author_date\n" + strings.Repeat("a", 40) + ",Octo Cat,2024-03-01T12:00:00Z\n"
+
+	for _, group := range []string{repoGroup, cache.GroupAll, otherGroup} {
+		read(group)
+	}
+	result, err := s.ImportCommits(ctx, repo.FullName, ImportFormatCSV, strings.NewReader(dump))
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Imported)
+	for _, group := range []string{repoGroup, cache.GroupAll, otherGroup} {
+		read(group)
+	}
+	assert.Equal(t, 2, loads[repoGroup], "stats of the repository are read again")
+	assert.Equal(t, 2, loads[cache.GroupAll], "stats over every repository are read again")
+	assert.Equal(t, 1, loads[otherGroup], "stats of other repositories stay cached")
+
+	// Importing the dump again stores nothing, so the cache is kept
+	result, err = s.ImportCommits(ctx, repo.FullName, ImportFormatCSV, strings.NewReader(dump))
+	require.NoError(t, err)
+	require.Equal(t, 0, result.Imported)
+	read(repoGroup)
+	assert.Equal(t, 2, loads[repoGroup])
+}
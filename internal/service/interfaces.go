@@ -7,33 +7,306 @@ import (
 	"github-service/internal/models"
 )
 
-// GitHubClient defines the interface for GitHub operations
-type GitHubClient interface {
+// Provider fetches repositories and their commits from a code hosting service
+type Provider interface {
 	GetRepository(ctx context.Context, owner, repo string) (*models.Repository, error)
-	GetCommits(ctx context.Context, owner, repo string, since time.Time) ([]models.CommitResponse, error)
+	ForEachCommitPage(ctx context.Context, owner, repo string, since time.Time, startPage, maxPages int, fn func(page []models.CommitResponse) (bool, error)) error
+	GetCommit(ctx context.Context, owner, repo, sha string) (*models.CommitDetail, error)
+}
+
+// GitHubClient defines the interface for GitHub operations. Besides what every
+// provider offers, GitHub repositories also have their issues, tags, releases
+// and contributor stats synced.
+type GitHubClient interface {
+	Provider
+	GetIssues(ctx context.Context, owner, repo string, since time.Time) ([]models.Issue, error)
+	ListOrganizationRepositories(ctx context.Context, org string) ([]string, error)
+	ListUserRepositories(ctx context.Context, username string) ([]models.UserRepository, error)
+	ListTags(ctx context.Context, owner, repo string) ([]models.Tag, error)
+	ListReleases(ctx context.Context, owner, repo string) ([]models.Release, error)
+	GetContributorStats(ctx context.Context, owner, repo string) ([]models.ContributorStats, error)
+	GetFileContent(ctx context.Context, owner, repo, path string) ([]byte, error)
 	GetRateLimitInfo() models.RateLimitInfo
+	TokenRateLimits() []models.TokenRateLimit
+	TokenExpiration() time.Time
+	RepositoryDedupStats() models.DedupStats
 }
 
-// Database defines the interface for database operations
-type Database interface {
+// OAuthProvider identifies the people logging in through GitHub's OAuth web flow
+type OAuthProvider interface {
+	Exchange(ctx context.Context, code string) (string, error)
+	GetUser(ctx context.Context, token string) (*models.GitHubUser, error)
+}
+
+// WebhookSender delivers JSON payloads to webhook URLs
+type WebhookSender interface {
+	Send(ctx context.Context, url string, payload interface{}) error
+	// SendSigned posts an encoded body signed with secret, returning the
+	// response status or 0 without a response
+	SendSigned(ctx context.Context, url, secret, event string, deliveryID int64, body []byte) (int, error)
+}
+
+// Notifier sends notifications about failures to the configured channels
+type Notifier interface {
+	Notify(ctx context.Context, n *models.Notification)
+}
+
+// EventPublisher broadcasts sync progress to live subscribers
+type EventPublisher interface {
+	Publish(eventType string, data map[string]interface{})
+}
+
+// TicketTracker looks up the tickets referenced by commit messages
+type TicketTracker interface {
+	// GetTicket returns nil when the ticket doesn't exist
+	GetTicket(ctx context.Context, key string) (*models.Ticket, error)
+}
+
+// RepositoryStore persists repositories and their removal and restoration
+type RepositoryStore interface {
 	CreateRepository(ctx context.Context, repo *models.Repository) error
 	UpdateRepository(ctx context.Context, repo *models.Repository) error
 	GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error)
 	UpdateLastCommitCheck(ctx context.Context, repoID int64, lastCheck time.Time) error
-	SetCommitsSince(ctx context.Context, repoID int64, since time.Time) error
-	CreateCommit(ctx context.Context, commit *models.Commit) error
+	SetCommitsSince(ctx context.Context, repoID int64, since *time.Time) error
+	DeleteRepository(ctx context.Context, repoID int64) error
+	SoftDeleteRepository(ctx context.Context, repoID int64) error
+	RestoreRepository(ctx context.Context, fullName string) (*models.Repository, error)
+	PurgeDeletedRepositories(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// CommitStore persists commits with their changed files and diff stats
+type CommitStore interface {
+	CreateCommits(ctx context.Context, commits []*models.Commit) (created, existing int, err error)
 	GetCommitsBySHA(ctx context.Context, repoID int64, sha string) (*models.Commit, error)
+	FindCommitsBySHAPrefix(ctx context.Context, repoID int64, prefix string, limit int) ([]*models.Commit, error)
+	GetNeighborCommits(ctx context.Context, commit *models.Commit) (previous, next *models.Commit, err error)
 	GetCommitsByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Commit, error)
+	StreamCommitsByRepository(ctx context.Context, repoID int64, filter models.CommitFilter, page, perPage int, fn func(*models.Commit) error) error
+	StreamCommitsAfter(ctx context.Context, repoID int64, filter models.CommitFilter, cursor *models.CommitCursor, limit int, fn func(*models.Commit) error) error
+	GetLatestCommits(ctx context.Context, repoID int64, count int) ([]*models.Commit, error)
+	GetLatestCommitKeys(ctx context.Context, repoID int64, count int) ([]*models.Commit, error)
 	GetCommitCountByRepository(ctx context.Context, repoID int64) (int, error)
-	GetTopCommitAuthors(ctx context.Context, limit int) ([]*models.CommitStats, error)
-	GetTopCommitAuthorsByRepository(ctx context.Context, repoID int64, limit int) ([]*models.CommitStats, error)
-	DeleteRepository(ctx context.Context, repoID int64) error
+	CountCommits(ctx context.Context, repoID int64, filter models.CommitFilter) (int, error)
+	SearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions, page, perPage int) ([]*models.Commit, error)
+	CountSearchCommits(ctx context.Context, repoID int64, opts models.CommitSearchOptions) (int, error)
 
-	// Monitored repositories
-	AddMonitoredRepository(ctx context.Context, fullName string, syncInterval time.Duration) error
+	// Commit files and diff stats
+	CreateCommitFiles(ctx context.Context, repoID, commitID int64, files []models.CommitFile) error
+	GetCommitFiles(ctx context.Context, commitID int64) ([]models.CommitFile, error)
+	UpdateCommitStats(ctx context.Context, repoID, commitID int64, additions, deletions, filesChanged int) error
+	GetCommitsMissingStats(ctx context.Context, repoID int64, limit int) ([]*models.Commit, error)
+}
+
+// MonitorStore tracks the monitored repositories and their syncs
+type MonitorStore interface {
+	AddMonitoredRepository(ctx context.Context, fullName, provider string, syncInterval time.Duration) error
+	GetRepositoryProvider(ctx context.Context, fullName string) (string, error)
 	GetMonitoredRepositories(ctx context.Context) ([]models.MonitoredRepository, error)
+	GetRepositoryListings(ctx context.Context, page, perPage int) ([]*models.RepositoryListing, error)
+	GetRepositoryListing(ctx context.Context, fullName string) (*models.RepositoryListing, error)
+	CountMonitoredRepositories(ctx context.Context) (int, error)
 	UpdateMonitoredRepositorySync(ctx context.Context, fullName string, lastSyncTime time.Time) error
+	RecordSyncOutcome(ctx context.Context, fullName string, failed bool) (previous int, monitored bool, err error)
 	RemoveMonitoredRepository(ctx context.Context, fullName string) error
+	SetMonitoredRepositoryActive(ctx context.Context, fullName string, active bool) error
+	ClaimResync(ctx context.Context, fullName string, minInterval time.Duration) (time.Duration, error)
+	TryLockRepositorySync(ctx context.Context, fullName string) (func(), bool, error)
+
+	// Watched users whose repositories are discovered
+	SaveWatchedUser(ctx context.Context, user *models.WatchedUser) error
+	GetWatchedUsers(ctx context.Context) ([]*models.WatchedUser, error)
+	DeleteWatchedUser(ctx context.Context, username string) (bool, error)
+	GetUndiscoveredRepositories(ctx context.Context, username string, names []string) ([]string, error)
+	AddDiscoveredRepositories(ctx context.Context, username string, names []string, refreshedAt time.Time) error
+
+	// Sync runs
+	CreateSyncRun(ctx context.Context, run *models.SyncRun) error
+	FinishSyncRun(ctx context.Context, id int64, newCommits int, syncErr string) error
+	GetFinishedSyncRuns(ctx context.Context, repoID, afterRun int64, limit int) ([]*models.SyncRun, error)
+	GetCommitsInSyncRuns(ctx context.Context, repoID, afterRun, throughRun int64) ([]*models.Commit, error)
+
+	// Cursors of unfinished commit history backfills
+	GetBackfillCursor(ctx context.Context, repoID int64) (*models.BackfillCursor, error)
+	SaveBackfillCursor(ctx context.Context, cursor *models.BackfillCursor) error
+	DeleteBackfillCursor(ctx context.Context, repoID int64, since *time.Time) error
+}
+
+// StatsStore aggregates commits, releases and repository snapshots
+type StatsStore interface {
+	GetTopCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64, limit, offset int) ([]*models.CommitStats, error)
+	GetTopCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time, limit, offset int) ([]*models.CommitStats, error)
+	CountCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64) (int, error)
+	CountCommitAuthorsByRepository(ctx context.Context, repoID int64, since, until *time.Time) (int, error)
+	GetAuthorCommitCounts(ctx context.Context, repoID int64, since, until *time.Time) ([]int, error)
+	GetTopRepositories(ctx context.Context, since time.Time, metric, group string, account int64, limit int) ([]*models.RepositoryActivity, error)
+	CountCommitsSince(ctx context.Context, repoID int64, since time.Time) (int, error)
+	GetFileExtensionStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.FileExtensionStats, error)
+	GetCommitTypeStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.CommitTypeStats, error)
+	GetReleaseIntervals(ctx context.Context, repoID int64, since, until *time.Time, includePrereleases bool) ([]*models.ReleaseInterval, error)
+
+	// Repository stats history
+	RecordRepositoryStats(ctx context.Context, repo *models.Repository, day time.Time) error
+	GetRepositoryStatsHistory(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.RepositoryStatsSnapshot, error)
+	GetStarsCountAsOf(ctx context.Context, repoID int64, day time.Time) (*int, error)
+	GetRepositoryStatsAsOf(ctx context.Context, repoID int64, day time.Time) (*models.RepositoryStatsSnapshot, error)
+
+	// Contributor stats computed by GitHub
+	UpsertContributorWeeks(ctx context.Context, repoID int64, contributors []models.ContributorStats) error
+	GetContributorStats(ctx context.Context, repoID int64, since, until *time.Time) ([]*models.ContributorStats, error)
+
+	// Dependencies declared in repository manifests
+	ReplaceRepositoryDependencies(ctx context.Context, repoID int64, manifest string, deps []models.Dependency) error
+	GetRepositoryDependencies(ctx context.Context, repoID int64, ecosystem string) ([]*models.Dependency, error)
+	GetPackageDependents(ctx context.Context, ecosystem, name, version string, account int64) ([]*models.Dependent, error)
+
+	// Monthly report figures
+	CountCommitsAndAuthors(ctx context.Context, repoID int64, start, end time.Time) (commits, authors int, err error)
+	GetNewCommitAuthors(ctx context.Context, repoID int64, start, end time.Time) ([]*models.CommitStats, error)
+	GetBusiestDays(ctx context.Context, repoID int64, start, end time.Time, limit int) ([]*models.DailyCommitCount, error)
+}
+
+// AuthorIdentityStore persists the merged email aliases of commit authors
+type AuthorIdentityStore interface {
+	MergeAuthorIdentities(ctx context.Context, name, canonicalEmail string, emails []string) ([]*models.AuthorIdentity, error)
+	ListAuthorIdentities(ctx context.Context) ([]*models.AuthorIdentity, error)
+	DeleteAuthorIdentity(ctx context.Context, email string) error
+	GetAuthorCommits(ctx context.Context, email string, repoID int64, page, perPage int) ([]*models.AuthorCommit, error)
+	CountAuthorCommits(ctx context.Context, email string, repoID int64) (int, error)
+}
+
+// IssueStore persists the issues of GitHub repositories
+type IssueStore interface {
+	UpsertIssue(ctx context.Context, issue *models.Issue) error
+	GetIssuesByRepository(ctx context.Context, repoID int64, state string, page, perPage int) ([]*models.Issue, error)
+	GetIssueCountByRepository(ctx context.Context, repoID int64, state string) (int, error)
+	GetLatestIssueUpdate(ctx context.Context, repoID int64) (*time.Time, error)
+}
+
+// ReleaseStore persists the tags and releases of GitHub repositories
+type ReleaseStore interface {
+	UpsertTags(ctx context.Context, repoID int64, tags []models.Tag) error
+	UpsertReleases(ctx context.Context, repoID int64, releases []models.Release) error
+	GetReleasesByRepository(ctx context.Context, repoID int64, page, perPage int) ([]*models.Release, error)
+	GetReleaseCountByRepository(ctx context.Context, repoID int64) (int, error)
+}
+
+// AlertStore persists threshold rules and commit hooks, which notify on syncs
+type AlertStore interface {
+	// Threshold rules
+	CreateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error
+	GetThresholdRule(ctx context.Context, repoID, id int64) (*models.ThresholdRule, error)
+	ListThresholdRules(ctx context.Context, repoID int64) ([]*models.ThresholdRule, error)
+	UpdateThresholdRule(ctx context.Context, rule *models.ThresholdRule) error
+	UpdateThresholdRuleState(ctx context.Context, id int64, triggered bool, value int64, triggeredAt *time.Time) error
+	DeleteThresholdRule(ctx context.Context, repoID, id int64) error
+	CreateThresholdAlert(ctx context.Context, alert *models.ThresholdAlert) error
+	ListThresholdAlerts(ctx context.Context, repoID int64, ruleID *int64, page, perPage int) ([]*models.ThresholdAlert, int, error)
+
+	// Commit hooks
+	CreateCommitHook(ctx context.Context, hook *models.CommitHook) error
+	GetCommitHook(ctx context.Context, repoID, id int64) (*models.CommitHook, error)
+	ListCommitHooks(ctx context.Context, repoID int64) ([]*models.CommitHook, error)
+	UpdateCommitHook(ctx context.Context, hook *models.CommitHook) error
+	DeleteCommitHook(ctx context.Context, repoID, id int64) error
+}
+
+// WebhookStore persists webhooks and the log of their deliveries, which doubles
+// as the queue of deliveries still to be attempted
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, hook *models.Webhook, secret string) error
+	GetWebhook(ctx context.Context, id int64) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetRepositoryWebhooks(ctx context.Context, fullName string) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+
+	CreateWebhookDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	FinishWebhookDeliveryAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, page, perPage int) ([]*models.WebhookDelivery, int, error)
+}
+
+// ReportStore persists generated monthly reports and digests
+type ReportStore interface {
+	SaveMonthlyReport(ctx context.Context, repoID int64, report *models.MonthlyReport) error
+	GetMonthlyReport(ctx context.Context, repoID int64, month string) (*models.MonthlyReport, error)
+	SaveDigest(ctx context.Context, repoID int64, digest *models.Digest) error
+	DigestExists(ctx context.Context, repoID int64, period string, start time.Time) (bool, error)
+	GetDigests(ctx context.Context, repoID int64, period string, page, perPage int) ([]*models.Digest, int, error)
+}
+
+// GroupStore persists named groups of monitored repositories
+type GroupStore interface {
+	CreateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error
+	GetRepositoryGroup(ctx context.Context, name string) (*models.RepositoryGroup, error)
+	ListRepositoryGroups(ctx context.Context) ([]*models.RepositoryGroup, error)
+	UpdateRepositoryGroup(ctx context.Context, group *models.RepositoryGroup) error
+	DeleteRepositoryGroup(ctx context.Context, name string) error
+	AddRepositoryGroupMember(ctx context.Context, name, fullName string) error
+	RemoveRepositoryGroupMember(ctx context.Context, name, fullName string) error
+}
+
+// TicketStore persists the tickets referenced by commits and their status
+type TicketStore interface {
+	AddCommitTickets(ctx context.Context, repoID, commitID int64, keys []string) ([]string, error)
+	GetTicketsToRefresh(ctx context.Context, refreshedBefore time.Time, limit int) ([]string, error)
+	SaveTicket(ctx context.Context, ticket *models.Ticket) error
+	CountCommitsByTicketStatus(ctx context.Context, repoID int64, start, end time.Time) (*models.TicketCommitCounts, error)
+}
+
+// AccessStore persists API keys and their usage
+type AccessStore interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	UpdateAPIKeyRole(ctx context.Context, id int64, role models.Role) error
+	RevokeAPIKey(ctx context.Context, id int64) error
+	TouchAPIKey(ctx context.Context, id int64) error
+
+	// Accounts of people logged in with GitHub and the repositories they added
+	UpsertAccount(ctx context.Context, user *models.GitHubUser, role models.Role) (*models.Account, error)
+	GetAccount(ctx context.Context, id int64) (*models.Account, error)
+	ListAccounts(ctx context.Context) ([]*models.Account, error)
+	UpdateAccountRole(ctx context.Context, id int64, role models.Role) error
+	DeleteAccount(ctx context.Context, id int64) error
+	AddAccountRepository(ctx context.Context, accountID int64, fullName string) error
+	RemoveAccountRepository(ctx context.Context, accountID int64, fullName string) (int, error)
+	HasAccountRepository(ctx context.Context, accountID int64, fullName string) (bool, error)
+	GetAccountRepositoryListings(ctx context.Context, accountID int64, page, perPage int) ([]*models.RepositoryListing, error)
+	CountAccountRepositories(ctx context.Context, accountID int64) (int, error)
+
+	// API usage
+	AddAPIUsage(ctx context.Context, usage []*models.APIUsage) error
+	GetEndpointUsage(ctx context.Context, since, until time.Time) ([]*models.EndpointUsage, error)
+	GetAPIKeyUsage(ctx context.Context, since, until time.Time) ([]*models.APIKeyUsage, error)
+}
+
+// MaintenanceStore runs and records database maintenance
+type MaintenanceStore interface {
+	ReindexConcurrently(ctx context.Context, index string) error
+	Analyze(ctx context.Context, table string) error
+	CreateMaintenanceRun(ctx context.Context, run *models.MaintenanceRun) error
+	ListMaintenanceRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error)
+}
+
+// Database is the complete storage backend of the service. Code that needs only
+// part of it should depend on the focused store instead, so tests can mock just
+// that and alternate backends can be added one store at a time.
+type Database interface {
+	RepositoryStore
+	CommitStore
+	MonitorStore
+	StatsStore
+	AuthorIdentityStore
+	IssueStore
+	ReleaseStore
+	AlertStore
+	WebhookStore
+	ReportStore
+	GroupStore
+	TicketStore
+	AccessStore
+	MaintenanceStore
 
 	// Migration
 	MigrateDB(migrationsPath string) error
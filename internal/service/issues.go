@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// SyncIssues synchronizes the issues of a repository that has already been synced.
+// Only issues updated since the most recently stored update are fetched.
+// Repositories on other providers have no issues synced.
+func (s *Service) SyncIssues(ctx context.Context, owner, name string) (int, error) {
+	fullName := fmt.Sprintf("%s/%s", owner, name)
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return 0, errors.NewDatabaseError("GetRepositoryByName", err)
+	}
+	if repo == nil {
+		return 0, errors.NewNotFoundError("repository", fullName)
+	}
+	// Issues are only synced from GitHub
+	if repo.Provider != models.ProviderGitHub {
+		return 0, nil
+	}
+
+	var since time.Time
+	latest, err := s.db.GetLatestIssueUpdate(ctx, repo.ID)
+	if err != nil {
+		return 0, errors.NewRepositoryError(owner, name, "GetLatestIssueUpdate", err)
+	}
+	if latest != nil {
+		since = *latest
+	}
+
+	issues, err := s.github.GetIssues(ctx, owner, name, since)
+	if err != nil {
+		return 0, errors.NewGitHubError("GetIssues", fullName, err)
+	}
+
+	for i := range issues {
+		issue := &issues[i]
+		issue.RepositoryID = repo.ID
+		if err := s.db.UpsertIssue(ctx, issue); err != nil {
+			return 0, errors.NewRepositoryError(owner, name, "UpsertIssue", err)
+		}
+	}
+
+	return len(issues), nil
+}
+
+// GetIssuesByRepository returns a page of a repository's issues filtered by state,
+// along with the total number of matching issues
+func (s *Service) GetIssuesByRepository(ctx context.Context, fullName, state string, page, perPage int) ([]*models.Issue, int, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, 0, errors.NewNotFoundError("repository", fullName)
+	}
+
+	totalCount, err := s.db.GetIssueCountByRepository(ctx, repo.ID, state)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting issue count: %w", err)
+	}
+
+	issues, err := s.db.GetIssuesByRepository(ctx, repo.ID, state, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching issues: %w", err)
+	}
+
+	return issues, totalCount, nil
+}
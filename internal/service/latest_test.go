@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitsVersion(t *testing.T) {
+	n := func(v int) *int { return &v }
+	commits := []*models.Commit{
+		{SHA: "bbb", Message: "second"},
+		{SHA: "aaa", Message: "first", Additions: n(3), Deletions: n(1), FilesChanged: n(2)},
+	}
+	version := commitsVersion(commits)
+
+	// Only the SHAs and diff stats are versioned, so partial rows match full ones
+	keys := []*models.Commit{
+		{SHA: "bbb"},
+		{SHA: "aaa", Additions: n(3), Deletions: n(1), FilesChanged: n(2)},
+	}
+	assert.Equal(t, version, commitsVersion(keys))
+
+	// A new commit, a different order or an enriched commit changes the version
+	assert.NotEqual(t, version, commitsVersion(append([]*models.Commit{{SHA: "ccc"}}, commits...)))
+	assert.NotEqual(t, version, commitsVersion([]*models.Commit{commits[1], commits[0]}))
+	enriched := []*models.Commit{
+		{SHA: "bbb", Additions: n(0), Deletions: n(0), FilesChanged: n(1)},
+		commits[1],
+	}
+	assert.NotEqual(t, version, commitsVersion(enriched))
+}
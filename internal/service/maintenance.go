@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/database"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+// Maintenance run statuses
+const (
+	maintenanceStatusSuccess = "success"
+	maintenanceStatusFailed  = "failed"
+)
+
+// RunMaintenance runs the given maintenance tasks against the hot commit tables
+// and indexes, recording the outcome of each step in the maintenance history.
+// Every step is attempted; an error is returned if any of them failed.
+func (s *Service) RunMaintenance(ctx context.Context, tasks []string) error {
+	return runMaintenance(ctx, s.db, s.logger, tasks)
+}
+
+// runMaintenance runs the maintenance tasks of RunMaintenance. It needs only the
+// maintenance store, so it is tested without the rest of the database.
+func runMaintenance(ctx context.Context, store MaintenanceStore, logger *zerolog.Logger, tasks []string) error {
+	var failed int
+	for _, task := range tasks {
+		var targets []string
+		var run func(context.Context, string) error
+		switch task {
+		case models.MaintenanceAnalyze:
+			targets, run = database.MaintainedTables, store.Analyze
+		case models.MaintenanceReindex:
+			targets, run = database.HotCommitIndexes, store.ReindexConcurrently
+		default:
+			return fmt.Errorf("%w: unknown maintenance task: %s", errors.ErrInvalidInput, task)
+		}
+
+		for _, target := range targets {
+			if err := runMaintenanceStep(ctx, store, logger, task, target, run); err != nil {
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d maintenance step(s) failed", failed)
+	}
+	return nil
+}
+
+// runMaintenanceStep runs a single maintenance step and records its outcome
+func runMaintenanceStep(ctx context.Context, store MaintenanceStore, logger *zerolog.Logger, task, target string, run func(context.Context, string) error) error {
+	record := &models.MaintenanceRun{
+		Task:      task,
+		Target:    target,
+		Status:    maintenanceStatusSuccess,
+		StartedAt: time.Now(),
+	}
+
+	err := run(ctx, target)
+	record.FinishedAt = time.Now()
+	record.DurationMs = record.FinishedAt.Sub(record.StartedAt).Milliseconds()
+	if err != nil {
+		record.Status = maintenanceStatusFailed
+		record.Error = err.Error()
+		logger.Error().Err(err).Str("task", task).Str("target", target).Msg("Maintenance step failed")
+	} else {
+		logger.Info().
+			Str("task", task).
+			Str("target", target).
+			Int64("duration_ms", record.DurationMs).
+			Msg("Maintenance step completed")
+	}
+
+	if recErr := store.CreateMaintenanceRun(ctx, record); recErr != nil {
+		logger.Warn().Err(recErr).Str("task", task).Str("target", target).Msg("Failed to record maintenance run")
+	}
+	return err
+}
+
+// GetMaintenanceHistory returns the most recent maintenance runs
+func (s *Service) GetMaintenanceHistory(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	return s.db.ListMaintenanceRuns(ctx, limit)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github-service/internal/database"
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// maintenanceStore records maintenance runs, failing the steps on one target
+type maintenanceStore struct {
+	failing string
+	runs    []*models.MaintenanceRun
+}
+
+func (s *maintenanceStore) ReindexConcurrently(ctx context.Context, index string) error {
+	if index == s.failing {
+		return fmt.Errorf("reindex %s: lock timeout", index)
+	}
+	return nil
+}
+
+func (s *maintenanceStore) Analyze(ctx context.Context, table string) error {
+	if table == s.failing {
+		return fmt.Errorf("analyze %s: lock timeout", table)
+	}
+	return nil
+}
+
+func (s *maintenanceStore) CreateMaintenanceRun(ctx context.Context, run *models.MaintenanceRun) error {
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *maintenanceStore) ListMaintenanceRuns(ctx context.Context, limit int) ([]*models.MaintenanceRun, error) {
+	return s.runs, nil
+}
+
+func TestRunMaintenance(t *testing.T) {
+	logger := zerolog.Nop()
+	failing := database.HotCommitIndexes[0]
+	store := &maintenanceStore{failing: failing}
+
+	// A failed step is recorded and the remaining ones still run
+	err := runMaintenance(context.Background(), store, &logger, []string{models.MaintenanceAnalyze, models.MaintenanceReindex})
+	require.Error(t, err)
+	require.Len(t, store.runs, len(database.MaintainedTables)+len(database.HotCommitIndexes))
+	for _, run := range store.runs {
+		want := maintenanceStatusSuccess
+		if run.Target == failing {
+			want = maintenanceStatusFailed
+			assert.Contains(t, run.Error, "lock timeout")
+		}
+		assert.Equal(t, want, run.Status, "%s of %s", run.Task, run.Target)
+		assert.False(t, run.FinishedAt.Before(run.StartedAt))
+	}
+
+	err = runMaintenance(context.Background(), &maintenanceStore{}, &logger, []string{"vacuum"})
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+}
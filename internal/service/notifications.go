@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github-service/internal/models"
+)
+
+// recordSyncOutcome counts the consecutive failed syncs of a monitored
+// repository, notifying when the count reaches the threshold and when a
+// repository that reached it syncs again. Syncs cut short by cancellation,
+// e.g. on shutdown, are not counted.
+func (s *Service) recordSyncOutcome(ctx context.Context, fullName string, syncErr error) {
+	if s.notifier == nil || (syncErr != nil && ctx.Err() != nil) {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	previous, monitored, err := s.db.RecordSyncOutcome(ctx, fullName, syncErr != nil)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("repository", fullName).Msg("Failed to record sync outcome")
+		return
+	}
+	if !monitored {
+		return
+	}
+
+	switch {
+	case syncErr != nil && previous+1 == s.syncFailureThreshold:
+		s.notifier.Notify(ctx, &models.Notification{
+			Event:      models.NotificationSyncFailing,
+			Subject:    fmt.Sprintf("%s failed to sync %d times in a row", fullName, s.syncFailureThreshold),
+			Message:    fmt.Sprintf("The last %d syncs of %s failed. Last error: %v", s.syncFailureThreshold, fullName, syncErr),
+			Repository: fullName,
+		})
+	case syncErr == nil && previous >= s.syncFailureThreshold:
+		s.notifier.Notify(ctx, &models.Notification{
+			Event:      models.NotificationSyncRecovered,
+			Subject:    fmt.Sprintf("%s synced again", fullName),
+			Message:    fmt.Sprintf("%s synced successfully after %d failed syncs.", fullName, previous),
+			Repository: fullName,
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// WithReleases enables syncing the tags and releases of repositories along
+// with their commits. This costs at least two extra GitHub API requests per sync.
+func WithReleases(enabled bool) Option {
+	return func(s *Service) {
+		s.syncReleases = enabled
+	}
+}
+
+// syncTagsAndReleases fetches and stores the tags and releases of a repository.
+// Failures are logged rather than returned so they never fail the commit sync.
+func (s *Service) syncTagsAndReleases(ctx context.Context, owner, name string, repoID int64) {
+	fullName := fmt.Sprintf("%s/%s", owner, name)
+
+	tags, err := s.github.ListTags(ctx, owner, name)
+	if err != nil {
+		s.logger.Warn().Err(errors.NewGitHubError("ListTags", fullName, err)).Msg("Failed to fetch tags")
+	} else if err := s.db.UpsertTags(ctx, repoID, tags); err != nil {
+		s.logger.Warn().Err(err).Str("repository", fullName).Msg("Failed to store tags")
+	}
+
+	releases, err := s.github.ListReleases(ctx, owner, name)
+	if err != nil {
+		s.logger.Warn().Err(errors.NewGitHubError("ListReleases", fullName, err)).Msg("Failed to fetch releases")
+	} else if err := s.db.UpsertReleases(ctx, repoID, releases); err != nil {
+		s.logger.Warn().Err(err).Str("repository", fullName).Msg("Failed to store releases")
+	}
+}
+
+// GetReleasesByRepository returns a page of a repository's releases along with
+// the total number of its releases
+func (s *Service) GetReleasesByRepository(ctx context.Context, fullName string, page, perPage int) ([]*models.Release, int, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, 0, errors.NewNotFoundError("repository", fullName)
+	}
+
+	totalCount, err := s.db.GetReleaseCountByRepository(ctx, repo.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting release count: %w", err)
+	}
+
+	releases, err := s.db.GetReleasesByRepository(ctx, repo.ID, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching releases: %w", err)
+	}
+
+	return releases, totalCount, nil
+}
+
+// GetReleaseCadence summarizes the releases a repository published within the
+// time window: the days between them, the commits each shipped and how long those
+// commits waited to be released. Prereleases are left out unless requested.
+func (s *Service) GetReleaseCadence(ctx context.Context, fullName string, since, until *time.Time, includePrereleases bool) (*models.ReleaseCadence, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	intervals, err := s.db.GetReleaseIntervals(ctx, repo.ID, since, until, includePrereleases)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching release intervals: %w", err)
+	}
+	return summarizeReleaseCadence(intervals), nil
+}
+
+// summarizeReleaseCadence aggregates the intervals of consecutive releases
+func summarizeReleaseCadence(intervals []*models.ReleaseInterval) *models.ReleaseCadence {
+	cadence := &models.ReleaseCadence{
+		Releases:  len(intervals),
+		Intervals: intervals,
+	}
+	if cadence.Intervals == nil {
+		cadence.Intervals = []*models.ReleaseInterval{}
+	}
+	if len(intervals) == 0 {
+		return cadence
+	}
+
+	var gaps []float64
+	var commits int
+	var commitDays float64
+	for _, interval := range intervals {
+		if interval.DaysSincePrevious != nil {
+			gaps = append(gaps, *interval.DaysSincePrevious)
+		}
+		commits += interval.Commits
+		if interval.AvgDaysToRelease != nil {
+			commitDays += *interval.AvgDaysToRelease * float64(interval.Commits)
+		}
+	}
+
+	cadence.AvgCommitsPerRelease = float64(commits) / float64(len(intervals))
+	if commits > 0 {
+		avg := commitDays / float64(commits)
+		cadence.AvgDaysCommitToRelease = &avg
+	}
+	if len(gaps) > 0 {
+		var total float64
+		for _, gap := range gaps {
+			total += gap
+		}
+		avg := total / float64(len(gaps))
+		cadence.AvgDaysBetweenReleases = &avg
+
+		sort.Float64s(gaps)
+		median := gaps[len(gaps)/2]
+		if len(gaps)%2 == 0 {
+			median = (gaps[len(gaps)/2-1] + gaps[len(gaps)/2]) / 2
+		}
+		cadence.MedianDaysBetweenReleases = &median
+	}
+	return cadence
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeReleaseCadence(t *testing.T) {
+	days := func(d float64) *float64 { return &d }
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cadence := summarizeReleaseCadence([]*models.ReleaseInterval{
+		{TagName: "v1.0.0", PublishedAt: start, Commits: 10, AvgDaysToRelease: days(20)},
+		{TagName: "v1.1.0", PublishedAt: start.AddDate(0, 0, 10), DaysSincePrevious: days(10), Commits: 0},
+		{TagName: "v1.2.0", PublishedAt: start.AddDate(0, 0, 40), DaysSincePrevious: days(30), Commits: 5, AvgDaysToRelease: days(5)},
+		{TagName: "v1.3.0", PublishedAt: start.AddDate(0, 0, 60), DaysSincePrevious: days(20), Commits: 5, AvgDaysToRelease: days(2)},
+	})
+
+	assert.Equal(t, 4, cadence.Releases)
+	assert.Equal(t, 5.0, cadence.AvgCommitsPerRelease)
+	require.NotNil(t, cadence.AvgDaysBetweenReleases)
+	assert.Equal(t, 20.0, *cadence.AvgDaysBetweenReleases)
+	require.NotNil(t, cadence.MedianDaysBetweenReleases)
+	assert.Equal(t, 20.0, *cadence.MedianDaysBetweenReleases)
+	require.NotNil(t, cadence.AvgDaysCommitToRelease)
+	assert.InDelta(t, 11.75, *cadence.AvgDaysCommitToRelease, 1e-9)
+
+	empty := summarizeReleaseCadence(nil)
+	assert.Equal(t, 0, empty.Releases)
+	assert.Nil(t, empty.AvgDaysBetweenReleases)
+	assert.NotNil(t, empty.Intervals)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// Sizes of the lists in a monthly report
+const (
+	reportTopAuthors  = 10
+	reportBusiestDays = 5
+)
+
+// reportMonthLayout is the format of report months
+const reportMonthLayout = "2006-01"
+
+// ParseReportMonth parses a report month (YYYY-MM) into its first instant in UTC.
+// Months that haven't started yet are rejected.
+func ParseReportMonth(month string) (time.Time, error) {
+	start, err := time.Parse(reportMonthLayout, month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: month must be formatted as YYYY-MM", errors.ErrInvalidInput)
+	}
+	if start.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: month %s has not started yet", errors.ErrInvalidInput, month)
+	}
+	return start, nil
+}
+
+// GenerateMonthlyReport computes a repository's report for a month (YYYY-MM) from
+// its synced commits and stores it, replacing an earlier report of the month
+func (s *Service) GenerateMonthlyReport(ctx context.Context, fullName, month string) (*models.MonthlyReport, error) {
+	start, err := ParseReportMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	end := start.AddDate(0, 1, 0)
+
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	report := &models.MonthlyReport{
+		Repository:  repo.FullName,
+		Month:       month,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	if report.Commits, report.Contributors, err = s.db.CountCommitsAndAuthors(ctx, repo.ID, start, end); err != nil {
+		return nil, errors.NewDatabaseError("CountCommitsAndAuthors", err)
+	}
+	if report.PreviousMonthCommits, _, err = s.db.CountCommitsAndAuthors(ctx, repo.ID, start.AddDate(0, -1, 0), start); err != nil {
+		return nil, errors.NewDatabaseError("CountCommitsAndAuthors", err)
+	}
+	report.CommitChangePercent = percentChange(report.PreviousMonthCommits, report.Commits)
+
+	if report.NewContributors, err = s.db.GetNewCommitAuthors(ctx, repo.ID, start, end); err != nil {
+		return nil, errors.NewDatabaseError("GetNewCommitAuthors", err)
+	}
+	// The top authors query treats until as inclusive
+	until := end.Add(-time.Microsecond)
+	if report.TopAuthors, err = s.db.GetTopCommitAuthorsByRepository(ctx, repo.ID, &start, &until, reportTopAuthors, 0); err != nil {
+		return nil, errors.NewDatabaseError("GetTopCommitAuthorsByRepository", err)
+	}
+	if report.BusiestDays, err = s.db.GetBusiestDays(ctx, repo.ID, start, end, reportBusiestDays); err != nil {
+		return nil, errors.NewDatabaseError("GetBusiestDays", err)
+	}
+
+	if s.tickets != nil {
+		if report.Tickets, err = s.db.CountCommitsByTicketStatus(ctx, repo.ID, start, end); err != nil {
+			return nil, errors.NewDatabaseError("CountCommitsByTicketStatus", err)
+		}
+	}
+
+	if report.NewContributors == nil {
+		report.NewContributors = []*models.CommitStats{}
+	}
+	if report.TopAuthors == nil {
+		report.TopAuthors = []*models.CommitStats{}
+	}
+	if report.BusiestDays == nil {
+		report.BusiestDays = []*models.DailyCommitCount{}
+	}
+	report.Markdown = renderReportMarkdown(report)
+
+	if err := s.db.SaveMonthlyReport(ctx, repo.ID, report); err != nil {
+		return nil, errors.NewDatabaseError("SaveMonthlyReport", err)
+	}
+	return report, nil
+}
+
+// GetMonthlyReport returns a repository's stored report for a month (YYYY-MM)
+func (s *Service) GetMonthlyReport(ctx context.Context, fullName, month string) (*models.MonthlyReport, error) {
+	if _, err := ParseReportMonth(month); err != nil {
+		return nil, err
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.db.GetMonthlyReport(ctx, repoID, month)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetMonthlyReport", err)
+	}
+	if report == nil {
+		return nil, errors.NewNotFoundError("monthly report", fullName+" "+month)
+	}
+	return report, nil
+}
+
+// percentChange returns the change from previous to current in percent, or nil
+// when previous is zero
+func percentChange(previous, current int) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := float64(current-previous) / float64(previous) * 100
+	return &change
+}
+
+// renderReportMarkdown renders a monthly report as a Markdown document
+func renderReportMarkdown(report *models.MonthlyReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: %s\n\n", report.Repository, report.Month)
+
+	fmt.Fprintf(&b, "- Commits: %d", report.Commits)
+	if report.CommitChangePercent != nil {
+		fmt.Fprintf(&b, " (%+.1f%% vs %d the previous month)", *report.CommitChangePercent, report.PreviousMonthCommits)
+	} else {
+		b.WriteString(" (no commits the previous month)")
+	}
+	fmt.Fprintf(&b, "\n- Contributors: %d\n- New contributors: %d\n", report.Contributors, len(report.NewContributors))
+	if report.Tickets != nil {
+		fmt.Fprintf(&b, "- Commits against open tickets: %d, closed tickets: %d, unresolved tickets: %d\n",
+			report.Tickets.Open, report.Tickets.Closed, report.Tickets.Unresolved)
+	}
+
+	writeAuthorsMarkdown(&b, "Top authors", report.TopAuthors)
+	writeAuthorsMarkdown(&b, "New contributors", report.NewContributors)
+	writeBusiestDaysMarkdown(&b, report.BusiestDays)
+
+	fmt.Fprintf(&b, "\n_Generated %s_\n", report.GeneratedAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
+
+// writeAuthorsMarkdown writes a section listing authors and their commits
+func writeAuthorsMarkdown(b *strings.Builder, title string, authors []*models.CommitStats) {
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	if len(authors) == 0 {
+		b.WriteString("None.\n")
+		return
+	}
+	b.WriteString("| Author | Email | Commits |\n| --- | --- | ---: |\n")
+	for _, author := range authors {
+		fmt.Fprintf(b, "| %s | %s | %d |\n", markdownCell(author.AuthorName), markdownCell(author.AuthorEmail), author.Count)
+	}
+}
+
+// writeBusiestDaysMarkdown writes a section listing the busiest days
+func writeBusiestDaysMarkdown(b *strings.Builder, days []*models.DailyCommitCount) {
+	b.WriteString("\n## Busiest days\n\n")
+	if len(days) == 0 {
+		b.WriteString("None.\n")
+		return
+	}
+	b.WriteString("| Date | Commits |\n| --- | ---: |\n")
+	for _, day := range days {
+		fmt.Fprintf(b, "| %s | %d |\n", day.Date, day.Commits)
+	}
+}
+
+// markdownCell escapes a value for use in a Markdown table cell
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReportMonth(t *testing.T) {
+	start, err := ParseReportMonth("2024-02")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), start)
+
+	for _, month := range []string{"2024-2", "2024-13", "February 2024", ""} {
+		_, err := ParseReportMonth(month)
+		assert.True(t, errors.Is(err, errors.ErrInvalidInput), month)
+	}
+
+	_, err = ParseReportMonth(time.Now().AddDate(0, 2, 0).Format("2006-01"))
+	assert.True(t, errors.Is(err, errors.ErrInvalidInput))
+}
+
+func TestPercentChange(t *testing.T) {
+	assert.Nil(t, percentChange(0, 10))
+	assert.InDelta(t, 50.0, *percentChange(10, 15), 1e-9)
+	assert.InDelta(t, -100.0, *percentChange(4, 0), 1e-9)
+}
+
+func TestRenderReportMarkdown(t *testing.T) {
+	change := 25.0
+	report := &models.MonthlyReport{
+		Repository:           "octo/hello",
+		Month:                "2024-02",
+		Commits:              5,
+		PreviousMonthCommits: 4,
+		CommitChangePercent:  &change,
+		Contributors:         2,
+		NewContributors:      []*models.CommitStats{{AuthorName: "Linus", AuthorEmail: "linus@example.com", Count: 1}},
+		TopAuthors: []*models.CommitStats{
+			{AuthorName: "Ada | Lovelace", AuthorEmail: "ada@example.com", Count: 4},
+			{AuthorName: "Linus", AuthorEmail: "linus@example.com", Count: 1},
+		},
+		BusiestDays: []*models.DailyCommitCount{},
+		GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	markdown := renderReportMarkdown(report)
+	assert.Contains(t, markdown, "# octo/hello: 2024-02\n")
+	assert.Contains(t, markdown, "- Commits: 5 (+25.0% vs 4 the previous month)\n")
+	assert.Contains(t, markdown, "- New contributors: 1\n")
+	assert.Contains(t, markdown, "| Ada \\| Lovelace | ada@example.com | 4 |\n")
+	assert.Contains(t, markdown, "## Busiest days\n\nNone.\n")
+	assert.Contains(t, markdown, "_Generated 2024-03-01T00:00:00Z_")
+	assert.NotContains(t, markdown, "tickets")
+
+	report.Tickets = &models.TicketCommitCounts{Open: 3, Closed: 1, Unresolved: 1}
+	markdown = renderReportMarkdown(report)
+	assert.Contains(t, markdown, "- Commits against open tickets: 3, closed tickets: 1, unresolved tickets: 1\n")
+}
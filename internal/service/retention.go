@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// WithDeletedRetention keeps the data of removed repositories for the given
+// period, during which they can be restored. Zero deletes it immediately.
+func WithDeletedRetention(retention time.Duration) Option {
+	return func(s *Service) {
+		s.deletedRetention = retention
+	}
+}
+
+// DeletedRetention returns how long the data of removed repositories is retained
+func (s *Service) DeletedRetention() time.Duration {
+	return s.deletedRetention
+}
+
+// DeleteRepository deletes a repository and its associated commits from the
+// database, or marks it as removed when its data is retained
+func (s *Service) DeleteRepository(ctx context.Context, fullName string) error {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return fmt.Errorf("error finding repository: %w", err)
+	}
+	if repo == nil {
+		return errors.NewNotFoundError("repository", fullName)
+	}
+
+	if s.deletedRetention > 0 {
+		err = s.db.SoftDeleteRepository(ctx, repo.ID)
+	} else {
+		err = s.db.DeleteRepository(ctx, repo.ID)
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidateRepository(ctx, repo.FullName)
+	return nil
+}
+
+// RestoreRepository restores a removed repository whose data is still retained
+func (s *Service) RestoreRepository(ctx context.Context, fullName string) (*models.Repository, error) {
+	repo, err := s.db.RestoreRepository(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("deleted repository", fullName)
+	}
+	s.invalidateRepository(ctx, repo.FullName)
+	return repo, nil
+}
+
+// PurgeDeletedRepositories deletes the data of repositories removed longer ago
+// than the retention period and returns the names of the purged repositories
+func (s *Service) PurgeDeletedRepositories(ctx context.Context) ([]string, error) {
+	purged, err := s.db.PurgeDeletedRepositories(ctx, time.Now().Add(-s.deletedRetention))
+	if err != nil {
+		return nil, fmt.Errorf("error purging deleted repositories: %w", err)
+	}
+	if len(purged) > 0 {
+		s.logger.Info().Strs("repositories", purged).Msg("Purged data of removed repositories")
+	}
+	return purged, nil
+}
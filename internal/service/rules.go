@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+)
+
+// Threshold rule webhook events
+const (
+	EventRuleTriggered = "threshold_rule.triggered"
+	EventRuleResolved  = "threshold_rule.resolved"
+)
+
+// RuleEvent is the webhook payload sent when a threshold rule transitions
+type RuleEvent struct {
+	Event         string                `json:"event"`
+	Repository    string                `json:"repository"`
+	Rule          *models.ThresholdRule `json:"rule"`
+	Value         int64                 `json:"value"`
+	PreviousValue int64                 `json:"previous_value"`
+	OccurredAt    time.Time             `json:"occurred_at"`
+}
+
+// maxRuleWindowDays bounds the window delta metrics are measured over
+const maxRuleWindowDays = 90
+
+// errNoBaseline is returned for a delta metric without a stats snapshot from
+// the start of its window, e.g. for a repository synced for the first time
+var errNoBaseline = fmt.Errorf("no stats snapshot from the start of the window")
+
+// validateThresholdRule checks a rule definition supplied by a user, defaulting
+// the window of delta metrics to one day
+func validateThresholdRule(rule *models.ThresholdRule) error {
+	switch rule.Metric {
+	case models.MetricStars, models.MetricForks, models.MetricWatchers, models.MetricOpenIssues, models.MetricWeeklyCommits:
+		if rule.WindowDays != 0 {
+			return fmt.Errorf("%w: window_days only applies to delta metrics", errors.ErrInvalidInput)
+		}
+		// Counters can't go below zero, unlike their changes
+		if rule.Threshold < 0 {
+			return fmt.Errorf("%w: threshold must not be negative", errors.ErrInvalidInput)
+		}
+	case models.MetricStarsDelta, models.MetricForksDelta, models.MetricWatchersDelta, models.MetricOpenIssuesDelta:
+		if rule.WindowDays == 0 {
+			rule.WindowDays = 1
+		}
+		if rule.WindowDays < 1 || rule.WindowDays > maxRuleWindowDays {
+			return fmt.Errorf("%w: window_days must be between 1 and %d", errors.ErrInvalidInput, maxRuleWindowDays)
+		}
+	default:
+		return fmt.Errorf("%w: unknown metric %q", errors.ErrInvalidInput, rule.Metric)
+	}
+
+	switch rule.Operator {
+	case models.OperatorGT, models.OperatorGTE, models.OperatorLT, models.OperatorLTE:
+	default:
+		return fmt.Errorf("%w: unknown operator %q", errors.ErrInvalidInput, rule.Operator)
+	}
+
+	if rule.WebhookURL == "" {
+		return nil // Only the notification channels are alerted
+	}
+	return validateWebhookURL(rule.WebhookURL)
+}
+
+// validateWebhookURL checks that a user-supplied webhook URL is an absolute http(s) URL
+func validateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: webhook_url must be an absolute http(s) URL", errors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// repositoryID resolves the ID of a stored repository
+func (s *Service) repositoryID(ctx context.Context, fullName string) (int64, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return 0, errors.NewNotFoundError("repository", fullName)
+	}
+	return repo.ID, nil
+}
+
+// CreateThresholdRule adds a threshold rule to a repository. Its state is
+// established by the next sync, which never fires the rule's webhook.
+func (s *Service) CreateThresholdRule(ctx context.Context, fullName string, rule *models.ThresholdRule) error {
+	if err := validateThresholdRule(rule); err != nil {
+		return err
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+
+	rule.RepositoryID = repoID
+	if err := s.db.CreateThresholdRule(ctx, rule); err != nil {
+		return errors.NewDatabaseError("CreateThresholdRule", err)
+	}
+	return nil
+}
+
+// ListThresholdRules returns the threshold rules of a repository
+func (s *Service) ListThresholdRules(ctx context.Context, fullName string) ([]*models.ThresholdRule, error) {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.ListThresholdRules(ctx, repoID)
+}
+
+// GetThresholdRule returns a threshold rule of a repository
+func (s *Service) GetThresholdRule(ctx context.Context, fullName string, id int64) (*models.ThresholdRule, error) {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := s.db.GetThresholdRule(ctx, repoID, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetThresholdRule", err)
+	}
+	if rule == nil {
+		return nil, errors.NewNotFoundError("threshold rule", id)
+	}
+	return rule, nil
+}
+
+// UpdateThresholdRule replaces the definition of a threshold rule
+func (s *Service) UpdateThresholdRule(ctx context.Context, fullName string, rule *models.ThresholdRule) error {
+	if err := validateThresholdRule(rule); err != nil {
+		return err
+	}
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+
+	rule.RepositoryID = repoID
+	return s.db.UpdateThresholdRule(ctx, rule)
+}
+
+// DeleteThresholdRule removes a threshold rule from a repository
+func (s *Service) DeleteThresholdRule(ctx context.Context, fullName string, id int64) error {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteThresholdRule(ctx, repoID, id)
+}
+
+// ListThresholdAlerts returns a page of a repository's alerts, newest first,
+// optionally only those of one rule, along with their total number
+func (s *Service) ListThresholdAlerts(ctx context.Context, fullName string, ruleID *int64, page, perPage int) ([]*models.ThresholdAlert, int, error) {
+	repoID, err := s.repositoryID(ctx, fullName)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.db.ListThresholdAlerts(ctx, repoID, ruleID, page, perPage)
+}
+
+// evaluateThresholdRules evaluates a repository's rules against its freshly synced
+// metrics and alerts for each rule that started or stopped holding. Failures
+// are logged rather than returned so rules never fail a sync.
+func (s *Service) evaluateThresholdRules(ctx context.Context, repo *models.Repository) {
+	rules, err := s.db.ListThresholdRules(ctx, repo.ID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("repository", repo.FullName).Msg("Failed to load threshold rules")
+		return
+	}
+
+	for _, rule := range rules {
+		value, err := s.metricValue(ctx, repo, rule)
+		if err == errNoBaseline {
+			s.logger.Debug().Int64("rule_id", rule.ID).Msg("Skipped threshold rule without a stats baseline")
+			continue
+		}
+		if err != nil {
+			s.logger.Warn().Err(err).Int64("rule_id", rule.ID).Msg("Failed to compute threshold rule metric")
+			continue
+		}
+
+		holds := rule.Holds(value)
+		var triggeredAt *time.Time
+		// A rule without a previous value is being evaluated for the first time,
+		// which establishes its state rather than being a transition
+		if rule.LastValue != nil && holds != rule.Triggered {
+			event := RuleEvent{
+				Event:         EventRuleResolved,
+				Repository:    repo.FullName,
+				Rule:          rule,
+				Value:         value,
+				PreviousValue: *rule.LastValue,
+				OccurredAt:    time.Now().UTC(),
+			}
+			if holds {
+				event.Event = EventRuleTriggered
+				triggeredAt = &event.OccurredAt
+			}
+			s.recordAlert(ctx, rule, event)
+			s.sendRuleEvent(ctx, rule, event)
+		}
+
+		if err := s.db.UpdateThresholdRuleState(ctx, rule.ID, holds, value, triggeredAt); err != nil {
+			s.logger.Warn().Err(err).Int64("rule_id", rule.ID).Msg("Failed to record threshold rule state")
+		}
+	}
+}
+
+// metricValue returns the current value of a threshold rule's metric for a repository
+func (s *Service) metricValue(ctx context.Context, repo *models.Repository, rule *models.ThresholdRule) (int64, error) {
+	if models.IsDeltaMetric(rule.Metric) {
+		return s.metricDelta(ctx, repo, rule)
+	}
+
+	metric := rule.Metric
+	switch metric {
+	case models.MetricStars:
+		return int64(repo.StarsCount), nil
+	case models.MetricForks:
+		return int64(repo.ForksCount), nil
+	case models.MetricWatchers:
+		return int64(repo.WatchersCount), nil
+	case models.MetricOpenIssues:
+		return int64(repo.OpenIssuesCount), nil
+	case models.MetricWeeklyCommits:
+		count, err := s.db.CountCommitsSince(ctx, repo.ID, time.Now().AddDate(0, 0, -7))
+		return int64(count), err
+	}
+	return 0, fmt.Errorf("%w: unknown metric %q", errors.ErrInvalidInput, metric)
+}
+
+// metricDelta returns how much a counter changed over a rule's window, from the
+// latest stats snapshot taken on or before the day the window starts
+func (s *Service) metricDelta(ctx context.Context, repo *models.Repository, rule *models.ThresholdRule) (int64, error) {
+	baseline, err := s.db.GetRepositoryStatsAsOf(ctx, repo.ID, time.Now().AddDate(0, 0, -rule.WindowDays))
+	if err != nil {
+		return 0, err
+	}
+	if baseline == nil {
+		return 0, errNoBaseline
+	}
+
+	switch rule.Metric {
+	case models.MetricStarsDelta:
+		return int64(repo.StarsCount - baseline.StarsCount), nil
+	case models.MetricForksDelta:
+		return int64(repo.ForksCount - baseline.ForksCount), nil
+	case models.MetricWatchersDelta:
+		return int64(repo.WatchersCount - baseline.WatchersCount), nil
+	case models.MetricOpenIssuesDelta:
+		return int64(repo.OpenIssuesCount - baseline.OpenIssuesCount), nil
+	}
+	return 0, fmt.Errorf("%w: unknown metric %q", errors.ErrInvalidInput, rule.Metric)
+}
+
+// recordAlert adds a rule transition to the alert history and notifies the
+// configured channels of it
+func (s *Service) recordAlert(ctx context.Context, rule *models.ThresholdRule, event RuleEvent) {
+	alert := &models.ThresholdAlert{
+		RepositoryID:  rule.RepositoryID,
+		RuleID:        &rule.ID,
+		Event:         models.AlertResolved,
+		Metric:        rule.Metric,
+		Operator:      rule.Operator,
+		Threshold:     rule.Threshold,
+		WindowDays:    rule.WindowDays,
+		Value:         event.Value,
+		PreviousValue: event.PreviousValue,
+		OccurredAt:    event.OccurredAt,
+	}
+	notification := models.NotificationRuleResolved
+	if event.Event == EventRuleTriggered {
+		alert.Event = models.AlertTriggered
+		notification = models.NotificationRuleTriggered
+	}
+	if err := s.db.CreateThresholdAlert(ctx, alert); err != nil {
+		s.logger.Warn().Err(err).Int64("rule_id", rule.ID).Msg("Failed to record threshold alert")
+	}
+
+	if s.alertNotifier == nil {
+		return
+	}
+	s.alertNotifier.Notify(ctx, &models.Notification{
+		Event:      notification,
+		Subject:    fmt.Sprintf("%s: %s %s", event.Repository, describeRule(rule), alert.Event),
+		Message:    fmt.Sprintf("%s of %s is now %d (was %d), so the rule %s is %s.", metricName(rule), event.Repository, event.Value, event.PreviousValue, describeRule(rule), alert.Event),
+		Repository: event.Repository,
+		Time:       event.OccurredAt,
+	})
+}
+
+// operatorSymbols are how rule operators read in notifications
+var operatorSymbols = map[string]string{
+	models.OperatorGT:  ">",
+	models.OperatorGTE: ">=",
+	models.OperatorLT:  "<",
+	models.OperatorLTE: "<=",
+}
+
+// describeRule summarizes a rule for people, e.g. "stars_delta over 1d > 100"
+func describeRule(rule *models.ThresholdRule) string {
+	return fmt.Sprintf("%s %s %d", metricName(rule), operatorSymbols[rule.Operator], rule.Threshold)
+}
+
+// metricName names a rule's metric along with its window, if any
+func metricName(rule *models.ThresholdRule) string {
+	if rule.WindowDays > 0 {
+		return fmt.Sprintf("%s over %dd", rule.Metric, rule.WindowDays)
+	}
+	return rule.Metric
+}
+
+// sendRuleEvent delivers a rule transition to the rule's webhook, if it has one
+func (s *Service) sendRuleEvent(ctx context.Context, rule *models.ThresholdRule, event RuleEvent) {
+	if rule.WebhookURL == "" {
+		return
+	}
+
+	log := s.logger.With().
+		Int64("rule_id", rule.ID).
+		Str("repository", event.Repository).
+		Str("event", event.Event).
+		Int64("value", event.Value).
+		Logger()
+
+	if s.webhooks == nil {
+		log.Info().Msg("Threshold rule transitioned; no webhook sender configured")
+		return
+	}
+	if err := s.webhooks.Send(ctx, rule.WebhookURL, event); err != nil {
+		log.Warn().Err(err).Msg("Failed to deliver threshold rule webhook")
+		return
+	}
+	log.Info().Msg("Delivered threshold rule webhook")
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github-service/internal/errors"
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateThresholdRule(t *testing.T) {
+	rule := &models.ThresholdRule{Metric: models.MetricStarsDelta, Operator: models.OperatorLT, Threshold: -10}
+	require.NoError(t, validateThresholdRule(rule))
+	assert.Equal(t, 1, rule.WindowDays, "delta window defaults to a day")
+
+	tests := []struct {
+		name string
+		rule models.ThresholdRule
+	}{
+		{"unknown metric", models.ThresholdRule{Metric: "likes", Operator: models.OperatorGT}},
+		{"unknown operator", models.ThresholdRule{Metric: models.MetricStars, Operator: "eq"}},
+		{"negative counter threshold", models.ThresholdRule{Metric: models.MetricStars, Operator: models.OperatorLT, Threshold: -1}},
+		{"window on a counter", models.ThresholdRule{Metric: models.MetricStars, Operator: models.OperatorGT, WindowDays: 7}},
+		{"window too long", models.ThresholdRule{Metric: models.MetricForksDelta, Operator: models.OperatorGT, WindowDays: 91}},
+		{"invalid webhook", models.ThresholdRule{Metric: models.MetricStars, Operator: models.OperatorGT, WebhookURL: "example.com/hook"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateThresholdRule(&tt.rule)
+			assert.True(t, errors.Is(err, errors.ErrInvalidInput), "error = %v", err)
+		})
+	}
+}
+
+func TestDescribeRule(t *testing.T) {
+	rule := &models.ThresholdRule{Metric: models.MetricStarsDelta, Operator: models.OperatorGT, Threshold: 100, WindowDays: 1}
+	assert.Equal(t, "stars_delta over 1d > 100", describeRule(rule))
+
+	rule = &models.ThresholdRule{Metric: models.MetricOpenIssues, Operator: models.OperatorGTE, Threshold: 50}
+	assert.Equal(t, "open_issues >= 50", describeRule(rule))
+}
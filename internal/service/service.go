@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github-service/internal/cache"
 	"github-service/internal/errors"
+	"github-service/internal/events"
 	"github-service/internal/models"
 
 	"github.com/rs/zerolog"
@@ -16,9 +22,65 @@ import (
 
 // Service handles the core business logic
 type Service struct {
-	github GitHubClient
-	db     Database
-	logger *zerolog.Logger
+	github    GitHubClient
+	providers map[string]Provider // Clients by provider name, GitHub's among them
+	db        Database
+	logger    *zerolog.Logger
+
+	webhooks        WebhookSender
+	webhookAttempts int // Attempts made at a webhook delivery before it fails
+	events          EventPublisher
+	cache           *cache.Cache // Optional: caches aggregate stats reads
+
+	notifier             Notifier // Optional: told about repositories that keep failing to sync
+	syncFailureThreshold int
+	digestNotifier       Notifier // Optional: sent the digests generated on schedule
+	alertNotifier        Notifier // Optional: told about threshold rules starting or stopping to hold
+
+	tickets          TicketTracker   // Optional: looks up the tickets commits reference
+	ticketProjects   map[string]bool // Projects whose ticket keys are recorded; all when empty
+	ticketRefreshAge time.Duration
+
+	oauth       OAuthProvider // Optional: identifies people logging in with GitHub
+	accountRole models.Role   // Role of accounts on their first login
+	accountTTL  time.Duration // Lifetime of the API keys issued on login
+
+	fetchCommitFiles bool
+	commitStatsBatch int
+	syncIssues       bool
+	syncReleases     bool
+	syncContributors bool
+	syncDeps         bool
+	maxCommitPages   int
+	defaultHistory   time.Duration
+	minResync        time.Duration
+	tokenWarning     time.Duration
+	deletedRetention time.Duration
+	syncSlots        chan struct{} // Holds a token per running sync when concurrent syncs are limited
+
+	tokenWarnMu   sync.Mutex
+	tokenWarnedAt time.Time
+}
+
+// Option configures optional Service behaviour
+type Option func(*Service)
+
+// WithCommitFiles enables fetching and storing the files changed by each new commit.
+// This costs one extra GitHub API request per commit.
+func WithCommitFiles(enabled bool) Option {
+	return func(s *Service) {
+		s.fetchCommitFiles = enabled
+	}
+}
+
+// WithCommitStats enables enriching up to batch commits per sync with their
+// additions, deletions and number of files changed, newest first. Each costs one
+// extra GitHub API request, so older history is enriched over several syncs.
+// Zero disables the enrichment.
+func WithCommitStats(batch int) Option {
+	return func(s *Service) {
+		s.commitStatsBatch = batch
+	}
 }
 
 // Config holds the service configuration
@@ -27,125 +89,735 @@ type Config struct {
 	DB          Database
 }
 
+// WithIssues enables syncing the issues of monitored repositories
+func WithIssues(enabled bool) Option {
+	return func(s *Service) {
+		s.syncIssues = enabled
+	}
+}
+
+// WithWebhookSender sets how threshold rule webhooks are delivered. Without one,
+// rules are still evaluated but their webhooks are not sent.
+func WithWebhookSender(sender WebhookSender) Option {
+	return func(s *Service) {
+		s.webhooks = sender
+	}
+}
+
+// WithWebhookAttempts sets how many times a webhook delivery is attempted
+// before it is marked failed
+func WithWebhookAttempts(attempts int) Option {
+	return func(s *Service) {
+		if attempts > 0 {
+			s.webhookAttempts = attempts
+		}
+	}
+}
+
+// WithSyncFailureNotifier sends a notification through notifier once a
+// monitored repository failed to sync threshold times in a row, and another
+// when it syncs again. A threshold of zero or less disables the notifications.
+func WithSyncFailureNotifier(notifier Notifier, threshold int) Option {
+	return func(s *Service) {
+		if threshold > 0 {
+			s.notifier = notifier
+			s.syncFailureThreshold = threshold
+		}
+	}
+}
+
+// WithDigestNotifier sends each digest generated on schedule through notifier
+// as a digest notification
+func WithDigestNotifier(notifier Notifier) Option {
+	return func(s *Service) {
+		s.digestNotifier = notifier
+	}
+}
+
+// WithAlertNotifier sends a notification through notifier whenever a threshold
+// rule starts or stops holding
+func WithAlertNotifier(notifier Notifier) Option {
+	return func(s *Service) {
+		s.alertNotifier = notifier
+	}
+}
+
+// defaultHistoryDepth is how far back commits are synced unless configured
+const defaultHistoryDepth = 7 * 24 * time.Hour
+
+// WithDefaultHistory sets how far back commits are synced when no other window
+// is requested. Zero syncs the full history.
+func WithDefaultHistory(depth time.Duration) Option {
+	return func(s *Service) {
+		s.defaultHistory = depth
+	}
+}
+
+// WithMinResyncInterval sets the shortest time between manual resyncs of a
+// repository. Zero allows unlimited resyncs.
+func WithMinResyncInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.minResync = interval
+	}
+}
+
+// WithMaxConcurrentSyncs limits how many repository syncs run at once, whether
+// queued, scheduled or manual; further syncs wait for one to finish. Zero or less
+// is unlimited.
+func WithMaxConcurrentSyncs(limit int) Option {
+	return func(s *Service) {
+		if limit > 0 {
+			s.syncSlots = make(chan struct{}, limit)
+		}
+	}
+}
+
+// defaultMaxCommitPages is the number of commit pages fetched per sync unless configured
+const defaultMaxCommitPages = 10
+
+// WithMaxCommitPages bounds how many pages of commits a sync fetches from GitHub
+func WithMaxCommitPages(pages int) Option {
+	return func(s *Service) {
+		if pages > 0 {
+			s.maxCommitPages = pages
+		}
+	}
+}
+
+// WithProvider adds the client of another code hosting provider, such as GitLab,
+// that repositories can be monitored on
+func WithProvider(name string, provider Provider) Option {
+	return func(s *Service) {
+		s.providers[name] = provider
+	}
+}
+
+// WithEventPublisher sets where repository sync events are published
+func WithEventPublisher(publisher EventPublisher) Option {
+	return func(s *Service) {
+		s.events = publisher
+	}
+}
+
 // New creates a new service instance
-func New(githubClient GitHubClient, db Database, logger *zerolog.Logger) *Service {
-	return &Service{
-		github: githubClient,
-		db:     db,
-		logger: logger,
+func New(githubClient GitHubClient, db Database, logger *zerolog.Logger, opts ...Option) *Service {
+	s := &Service{
+		github:         githubClient,
+		providers:      map[string]Provider{models.ProviderGitHub: githubClient},
+		db:             db,
+		logger:         logger,
+		maxCommitPages: defaultMaxCommitPages,
+		defaultHistory: defaultHistoryDepth,
+		tokenWarning:   defaultTokenExpiryWarning,
+
+		webhookAttempts: defaultWebhookAttempts,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// ProviderEnabled reports whether repositories can be monitored on a provider
+func (s *Service) ProviderEnabled(name string) bool {
+	_, ok := s.providers[name]
+	return ok
+}
+
+// provider returns the client of a provider, GitHub's when name is empty
+func (s *Service) provider(name string) (Provider, error) {
+	if name == "" {
+		name = models.ProviderGitHub
+	}
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: provider %s is not configured", errors.ErrInvalidInput, name)
+	}
+	return provider, nil
+}
+
+// IssuesEnabled reports whether issues are synced for monitored repositories
+func (s *Service) IssuesEnabled() bool {
+	return s.syncIssues
 }
 
-// DB returns the database instance
-func (s *Service) DB() Database {
+// DefaultSince returns the start of the default commit history window, or the
+// zero time when the full history is synced by default
+func (s *Service) DefaultSince() time.Time {
+	if s.defaultHistory <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-s.defaultHistory)
+}
+
+// ClaimResync reserves a manual resync of a monitored repository. When the
+// previous one was too recent, the time left until the next is allowed is returned.
+func (s *Service) ClaimResync(ctx context.Context, fullName string) (time.Duration, error) {
+	if s.minResync <= 0 {
+		return 0, nil
+	}
+	wait, err := s.db.ClaimResync(ctx, fullName, s.minResync)
+	if err != nil {
+		return 0, fmt.Errorf("error claiming resync: %w", err)
+	}
+	return wait, nil
+}
+
+// Monitor returns the store of monitored repositories
+func (s *Service) Monitor() MonitorStore {
 	return s.db
 }
 
+// GetRateLimitInfo returns the GitHub client's current rate limit information
+func (s *Service) GetRateLimitInfo() models.RateLimitInfo {
+	return s.github.GetRateLimitInfo()
+}
+
+// GetTokenRateLimits returns the rate limit of each GitHub token the client
+// rotates between, or nil when a single token is configured
+func (s *Service) GetTokenRateLimits() []models.TokenRateLimit {
+	return s.github.TokenRateLimits()
+}
+
+// GetRepositoryDedupStats reports how many repository metadata requests shared
+// a concurrent GitHub call instead of making their own
+func (s *Service) GetRepositoryDedupStats() models.DedupStats {
+	return s.github.RepositoryDedupStats()
+}
+
 // Close closes the service and its resources
 func (s *Service) Close() error {
 	return s.db.Close()
 }
 
-// SyncRepository synchronizes a repository's information and commits
+// SyncRepository synchronizes a repository's information and every commit made
+// since the given time, up to the commit page budget
 func (s *Service) SyncRepository(ctx context.Context, owner, name string, since time.Time) error {
-	// Get repository information from GitHub
-	repo, err := s.github.GetRepository(ctx, owner, name)
+	_, err := s.syncRepository(ctx, "", owner, name, since, false, nil)
+	return err
+}
+
+// SyncCheckpoint lets a sync continue a history backfill where an interrupted
+// run stopped instead of fetching every page again
+type SyncCheckpoint struct {
+	Page   int            // Commit page to start at; the pages before it were stored by the earlier run
+	Record func(page int) // Called once the commits of a page are stored
+}
+
+// SyncRepositoryFromCheckpoint synchronizes a repository like SyncRepository,
+// starting at the checkpoint's commit page and recording the pages it stores.
+// Resuming refetches the recorded page, so commits pushed since only shift the
+// history by less than a page without leaving a gap. The result summarizes
+// the commits the sync fetched and stored, also when it failed part way.
+func (s *Service) SyncRepositoryFromCheckpoint(ctx context.Context, owner, name string, since time.Time, checkpoint *SyncCheckpoint) (*models.SyncResult, error) {
+	return s.syncRepository(ctx, "", owner, name, since, false, checkpoint)
+}
+
+// SyncRepositoryFrom synchronizes a repository like SyncRepository from the given
+// provider, for repositories that are not monitored yet
+func (s *Service) SyncRepositoryFrom(ctx context.Context, provider, owner, name string, since time.Time) error {
+	_, err := s.syncRepository(ctx, provider, owner, name, since, false, nil)
+	return err
+}
+
+// SyncRepositoryIncremental synchronizes a repository like SyncRepository but stops
+// paging through commits at the first page whose commits are all stored already.
+// It suits scheduled syncs, where everything older than that page was fetched before.
+func (s *Service) SyncRepositoryIncremental(ctx context.Context, owner, name string, since time.Time) error {
+	_, err := s.syncRepository(ctx, "", owner, name, since, true, nil)
+	return err
+}
+
+// syncRepository synchronizes a repository's information and commits from
+// providerName, or from the provider it is monitored on when that is empty
+func (s *Service) syncRepository(ctx context.Context, providerName, owner, name string, since time.Time, incremental bool, checkpoint *SyncCheckpoint) (result *models.SyncResult, err error) {
+	if s.syncSlots != nil {
+		select {
+		case s.syncSlots <- struct{}{}:
+			defer func() { <-s.syncSlots }()
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+
+	start := time.Now()
+	result = &models.SyncResult{}
+	defer func() {
+		result.DurationMS = time.Since(start).Milliseconds()
+		if providerName == models.ProviderGitHub {
+			remaining := s.GetRateLimitInfo().Remaining
+			result.RateLimitRemaining = &remaining
+		}
+	}()
+
+	// Only one sync of a repository runs at a time, whether scheduled, queued or manual
+	fullName := fmt.Sprintf("%s/%s", owner, name)
+	unlock, locked, err := s.db.TryLockRepositorySync(ctx, fullName)
+	if err != nil {
+		return result, errors.NewDatabaseError("TryLockRepositorySync", err)
+	}
+	if !locked {
+		return result, fmt.Errorf("%w: %s", errors.ErrSyncInProgress, fullName)
+	}
+	defer unlock()
+	defer func() { s.recordSyncOutcome(ctx, fullName, err) }()
+
+	if providerName == "" {
+		providerName, err = s.db.GetRepositoryProvider(ctx, fullName)
+		if err != nil {
+			return result, errors.NewDatabaseError("GetRepositoryProvider", err)
+		}
+		if providerName == "" {
+			providerName = models.ProviderGitHub
+		}
+	}
+	provider, err := s.provider(providerName)
 	if err != nil {
-		return errors.NewGitHubError("GetRepository", fmt.Sprintf("%s/%s", owner, name), err)
+		return result, err
+	}
+
+	// Get repository information from the provider
+	repo, err := provider.GetRepository(ctx, owner, name)
+	if err != nil {
+		return result, errors.NewGitHubError("GetRepository", fmt.Sprintf("%s/%s", owner, name), err)
+	}
+	repo.Provider = providerName
+	isGitHub := providerName == models.ProviderGitHub
+	if isGitHub {
+		s.checkTokenExpiry()
 	}
 
 	// Check if repository exists in database
 	existingRepo, err := s.db.GetRepositoryByName(ctx, repo.FullName)
 	if err != nil {
-		return errors.NewDatabaseError("GetRepositoryByName", err)
+		return result, errors.NewDatabaseError("GetRepositoryByName", err)
+	}
+	if existingRepo == nil {
+		// Adding a removed repository again restores its retained data
+		existingRepo, err = s.db.RestoreRepository(ctx, repo.FullName)
+		if err != nil {
+			return result, errors.NewDatabaseError("RestoreRepository", err)
+		}
+	}
+
+	// A commits_since override is the lower bound for every sync of the repository
+	if existingRepo != nil && existingRepo.CommitsSince != nil && since.Before(*existingRepo.CommitsSince) {
+		since = *existingRepo.CommitsSince
 	}
 
 	if existingRepo == nil {
 		// Create new repository
 		if err := s.db.CreateRepository(ctx, repo); err != nil {
-			return errors.NewRepositoryError(owner, name, "CreateRepository", err)
+			return result, errors.NewRepositoryError(owner, name, "CreateRepository", err)
 		}
 	} else {
 		// Update existing repository
 		repo.ID = existingRepo.ID
 		if err := s.db.UpdateRepository(ctx, repo); err != nil {
-			return errors.NewRepositoryError(owner, name, "UpdateRepository", err)
+			return result, errors.NewRepositoryError(owner, name, "UpdateRepository", err)
 		}
 	}
 
-	// Get commits since the specified time
-	commits, err := s.github.GetCommits(ctx, owner, name, since)
-	if err != nil {
-		return errors.NewGitHubError("GetCommits", fmt.Sprintf("%s/%s", owner, name), err)
+	// Snapshot the popularity counters so their growth can be charted; a failure
+	// only costs a data point, so it doesn't fail the sync
+	if err := s.db.RecordRepositoryStats(ctx, repo, time.Now()); err != nil {
+		s.logger.Warn().Err(err).Str("repository", repo.FullName).Msg("Failed to record repository stats")
 	}
 
-	// Process each commit
-	for _, c := range commits {
-		commit := &models.Commit{
-			RepositoryID:   repo.ID,
-			SHA:            c.SHA,
-			Message:        c.Commit.Message,
-			AuthorName:     c.Commit.Author.Name,
-			AuthorEmail:    c.Commit.Author.Email,
-			AuthorDate:     c.Commit.Author.Date,
-			CommitterName:  c.Commit.Committer.Name,
-			CommitterEmail: c.Commit.Committer.Email,
-			CommitDate:     c.Commit.Committer.Date,
-			URL:            c.HTMLURL,
-		}
-
-		// Check if commit exists
-		existingCommit, err := s.db.GetCommitsBySHA(ctx, repo.ID, commit.SHA)
+	// Record the run so consumers can fetch the commits it ingested
+	run := &models.SyncRun{RepositoryID: repo.ID, Incremental: incremental}
+	if !since.IsZero() {
+		run.Since = &since
+	}
+	if err := s.db.CreateSyncRun(ctx, run); err != nil {
+		return result, errors.NewDatabaseError("CreateSyncRun", err)
+	}
+	result.SyncRunID = run.ID
+
+	// Fetch commits since the specified time page by page, newest first
+	var newCommits []string
+	var newTickets []string
+	var ingested []*models.Commit
+	changedFiles := make(map[string][]string)
+	defer func() {
+		var syncErr string
 		if err != nil {
-			return errors.NewCommitError(repo.ID, commit.SHA, "GetCommitsBySHA", err)
+			syncErr = err.Error()
 		}
+		// Record the outcome even when the sync was cancelled
+		if finishErr := s.db.FinishSyncRun(context.WithoutCancel(ctx), run.ID, len(newCommits), syncErr); finishErr != nil {
+			s.logger.Warn().Err(finishErr).Int64("sync_run_id", run.ID).Msg("Failed to record sync run outcome")
+		}
+	}()
 
-		if existingCommit == nil {
-			if err := s.db.CreateCommit(ctx, commit); err != nil {
-				return errors.NewCommitError(repo.ID, commit.SHA, "CreateCommit", err)
+	pageNumber := 1
+	if checkpoint != nil && checkpoint.Page > 1 {
+		pageNumber = checkpoint.Page
+		s.logger.Info().Str("repository", repo.FullName).Int("page", pageNumber).Msg("Resuming sync from checkpoint")
+	}
+	// Jobs also resume the backfill of an earlier job with the same since that
+	// was interrupted for good, from the repository's cursor. A cursor saved by
+	// a backfill with another since is left for that backfill.
+	var backfill bool
+	cursorSince := backfillSince(since)
+	if checkpoint != nil && !incremental {
+		var page int
+		page, backfill = s.resumeBackfill(ctx, repo.ID, since)
+		if page > pageNumber {
+			pageNumber = page
+			s.logger.Info().Str("repository", repo.FullName).Int("page", pageNumber).Msg("Resuming backfill from cursor")
+		}
+	}
+	if pageNumber > 1 {
+		result.ResumedFromPage = pageNumber
+	}
+	err = provider.ForEachCommitPage(ctx, owner, name, since, pageNumber, s.maxCommitPages, func(page []models.CommitResponse) (bool, error) {
+		commits := make([]*models.Commit, len(page))
+		for i, c := range page {
+			commits[i] = &models.Commit{
+				RepositoryID:   repo.ID,
+				SHA:            c.SHA,
+				Message:        c.Commit.Message,
+				AuthorName:     c.Commit.Author.Name,
+				AuthorEmail:    c.Commit.Author.Email,
+				AuthorDate:     c.Commit.Author.Date,
+				CommitterName:  c.Commit.Committer.Name,
+				CommitterEmail: c.Commit.Committer.Email,
+				CommitDate:     c.Commit.Committer.Date,
+				URL:            c.HTMLURL,
+				SyncRunID:      &run.ID,
+				Type:           commitType(c.Commit.Message),
 			}
 		}
+
+		// Commits stored by an earlier sync are skipped by the insert
+		created, existing, err := s.db.CreateCommits(ctx, commits)
+		if err != nil {
+			return false, errors.NewCommitError(repo.ID, "", "CreateCommits", err)
+		}
+		result.CommitsFetched += len(page)
+		result.NewCommits += created
+		result.ExistingCommits += existing
+		result.Pages++
+
+		for _, commit := range commits {
+			if commit.ID == 0 {
+				continue
+			}
+			newCommits = append(newCommits, commit.SHA)
+			ingested = append(ingested, commit)
+			if s.tickets != nil {
+				newTickets = append(newTickets, s.recordCommitTickets(ctx, commit)...)
+			}
+			if s.fetchCommitFiles {
+				changedFiles[commit.SHA] = s.syncCommitFiles(ctx, provider, owner, name, commit)
+			}
+		}
+		if checkpoint != nil && checkpoint.Record != nil {
+			checkpoint.Record(pageNumber)
+		}
+		if backfill && len(commits) > 0 {
+			s.saveBackfillCursor(ctx, commits[len(commits)-1], cursorSince, pageNumber)
+		}
+		pageNumber++
+
+		// Older pages of an incremental sync were stored by earlier syncs
+		if incremental && created == 0 {
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		var commitErr *errors.CommitError
+		var dbErr *errors.DatabaseError
+		if errors.As(err, &commitErr) || errors.As(err, &dbErr) {
+			return result, err
+		}
+		return result, errors.NewGitHubError("GetCommits", fmt.Sprintf("%s/%s", owner, name), err)
+	}
+	if backfill {
+		if err := s.db.DeleteBackfillCursor(ctx, repo.ID, cursorSince); err != nil {
+			s.logger.Warn().Err(err).Str("repository", repo.FullName).Msg("Failed to clear backfill cursor")
+		}
 	}
 
 	// Update last commit check time
 	if err := s.db.UpdateLastCommitCheck(ctx, repo.ID, time.Now()); err != nil {
-		return errors.NewRepositoryError(owner, name, "UpdateLastCommitCheck", err)
+		return result, errors.NewRepositoryError(owner, name, "UpdateLastCommitCheck", err)
+	}
+
+	if s.commitStatsBatch > 0 {
+		s.enrichCommitStats(ctx, provider, owner, name, repo.ID)
+	}
+	// Look up the tickets first referenced now; the refresh job covers any beyond the batch
+	if len(newTickets) > 0 {
+		s.lookupTickets(ctx, newTickets[:min(len(newTickets), ticketLookupBatch)])
+	}
+	// Only GitHub repositories have their tags and releases synced
+	if s.syncReleases && isGitHub {
+		s.syncTagsAndReleases(ctx, owner, name, repo.ID)
+	}
+	if s.syncContributors && isGitHub {
+		s.syncContributorStats(ctx, owner, name, repo.ID)
+	}
+	if s.syncDeps && isGitHub {
+		s.syncDependencies(ctx, owner, name, repo.ID)
+	}
+
+	s.evaluateThresholdRules(ctx, repo)
+
+	if len(ingested) > 0 {
+		s.invalidateRepository(ctx, repo.FullName)
+		s.deliverCommitHooks(ctx, repo, run.ID, ingested, changedFiles)
+		s.queueWebhookDeliveries(ctx, repo, run.ID, ingested)
+	}
+
+	if len(newCommits) > 0 {
+		s.publish(events.CommitsIngested, map[string]interface{}{
+			"repository":  repo.FullName,
+			"sync_run_id": run.ID,
+			"count":       len(newCommits),
+			"shas":        newCommits,
+		})
 	}
+	s.publish(events.RepositorySynced, map[string]interface{}{
+		"repository":       repo.FullName,
+		"sync_run_id":      run.ID,
+		"new_commits":      len(newCommits),
+		"existing_commits": result.ExistingCommits,
+	})
 
-	// Update commits since time
-	if err := s.db.SetCommitsSince(ctx, repo.ID, since); err != nil {
-		return errors.NewRepositoryError(owner, name, "SetCommitsSince", err)
+	return result, nil
+}
+
+// abandonedBackfillAge is how long the cursor of a backfill with another since
+// is kept before a new backfill replaces it
+const abandonedBackfillAge = 7 * 24 * time.Hour
+
+// resumeBackfill returns the commit page to resume a backfill of a repository
+// since the given time at, or 0 to start at the newest commits, and whether
+// the backfill owns the repository's cursor, i.e. may save and clear it.
+// Resuming refetches the last page stored; commits pushed since only move the
+// history to later pages, so no commit is skipped.
+func (s *Service) resumeBackfill(ctx context.Context, repoID int64, since time.Time) (page int, owned bool) {
+	cursor, err := s.db.GetBackfillCursor(ctx, repoID)
+	if err != nil {
+		// Without the cursor the backfill starts over, which only costs requests
+		s.logger.Warn().Err(err).Int64("repository_id", repoID).Msg("Failed to read backfill cursor")
+		return 0, false
 	}
+	return backfillCursorPage(cursor, since, time.Now())
+}
 
-	return nil
+// backfillCursorPage decides what a backfill since the given time does with a
+// repository's cursor: without one it starts a cursor of its own, and with one
+// it resumes at its page only when the cursor was saved by a backfill with the
+// same since. Other backfills don't page through the same commits, so their
+// cursor is neither used nor replaced, unless it hasn't moved for
+// abandonedBackfillAge and the backfill that saved it was given up.
+func backfillCursorPage(cursor *models.BackfillCursor, since, now time.Time) (page int, owned bool) {
+	if cursor == nil {
+		return 0, true
+	}
+	cursorSince, backfill := cursor.Since, backfillSince(since)
+	if (cursorSince == nil) != (backfill == nil) || (backfill != nil && !cursorSince.Equal(*backfill)) {
+		return 0, now.Sub(cursor.UpdatedAt) > abandonedBackfillAge
+	}
+	return cursor.Page, true
 }
 
-// GetTopCommitAuthors returns the top N commit authors
-func (s *Service) GetTopCommitAuthors(ctx context.Context, limit int) ([]*models.CommitStats, error) {
-	return s.db.GetTopCommitAuthors(ctx, limit)
+// backfillSince is the since a backfill's cursor records: nil for the full
+// history, otherwise the time at the microsecond precision Postgres stores
+func backfillSince(since time.Time) *time.Time {
+	if since.IsZero() {
+		return nil
+	}
+	since = since.Truncate(time.Microsecond)
+	return &since
 }
 
-// GetTopCommitAuthorsByRepository returns the top N commit authors for a specific repository
-func (s *Service) GetTopCommitAuthorsByRepository(ctx context.Context, fullName string, limit int) ([]*models.CommitStats, error) {
-	// First check if the repository exists in the database
+// saveBackfillCursor records that a backfill since the given time stored the
+// commit page ending with oldest
+func (s *Service) saveBackfillCursor(ctx context.Context, oldest *models.Commit, since *time.Time, page int) {
+	cursor := &models.BackfillCursor{
+		RepositoryID: oldest.RepositoryID,
+		Since:        since,
+		Page:         page,
+		OldestSHA:    oldest.SHA,
+		OldestDate:   oldest.CommitDate,
+	}
+	if err := s.db.SaveBackfillCursor(ctx, cursor); err != nil {
+		s.logger.Warn().Err(err).Int64("repository_id", oldest.RepositoryID).Int("page", page).Msg("Failed to save backfill cursor")
+	}
+}
+
+// publish sends a sync event when an event publisher is configured
+func (s *Service) publish(eventType string, data map[string]interface{}) {
+	if s.events != nil {
+		s.events.Publish(eventType, data)
+	}
+}
+
+// syncCommitFiles fetches and stores the files changed by a commit and returns
+// their names. Failures are logged rather than returned so a missing file list
+// never fails the sync.
+func (s *Service) syncCommitFiles(ctx context.Context, provider Provider, owner, name string, commit *models.Commit) []string {
+	detail, err := provider.GetCommit(ctx, owner, name, commit.SHA)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to fetch commit files")
+		return nil
+	}
+	if err := s.db.CreateCommitFiles(ctx, commit.RepositoryID, commit.ID, detail.Files); err != nil {
+		s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to store commit files")
+	}
+	// The stats come with the files, sparing the enrichment pass a request
+	if err := s.db.UpdateCommitStats(ctx, commit.RepositoryID, commit.ID, detail.Additions, detail.Deletions, len(detail.Files)); err != nil {
+		s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to store commit stats")
+	}
+
+	files := make([]string, len(detail.Files))
+	for i, file := range detail.Files {
+		files[i] = file.Filename
+	}
+	return files
+}
+
+// enrichCommitStats fetches the diff stats of a batch of the repository's commits
+// that don't have them yet. Failures are logged rather than returned; the
+// commits are retried by the next sync.
+func (s *Service) enrichCommitStats(ctx context.Context, provider Provider, owner, name string, repoID int64) {
+	commits, err := s.db.GetCommitsMissingStats(ctx, repoID, s.commitStatsBatch)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("repository", fmt.Sprintf("%s/%s", owner, name)).Msg("Failed to list commits missing stats")
+		return
+	}
+
+	for _, commit := range commits {
+		detail, err := provider.GetCommit(ctx, owner, name, commit.SHA)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to fetch commit stats")
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if err := s.db.UpdateCommitStats(ctx, commit.RepositoryID, commit.ID, detail.Additions, detail.Deletions, len(detail.Files)); err != nil {
+			s.logger.Warn().Err(err).Str("sha", commit.SHA).Msg("Failed to store commit stats")
+		}
+	}
+}
+
+// GetFileExtensionStats returns changes aggregated by file extension for a repository
+func (s *Service) GetFileExtensionStats(ctx context.Context, fullName string, since, until *time.Time) ([]*models.FileExtensionStats, error) {
 	repo, err := s.db.GetRepositoryByName(ctx, fullName)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching repository: %w", err)
 	}
 	if repo == nil {
-		return nil, fmt.Errorf("repository not found: %s", fullName)
+		return nil, errors.NewNotFoundError("repository", fullName)
 	}
 
-	// Get the commits for this repository
-	commits, err := s.db.GetCommitsByRepository(ctx, repo.ID, 1, 0)
+	return cache.Get(ctx, s.cache, cache.RepositoryGroup(repo.FullName), cacheKey("extensions", since, until), func() ([]*models.FileExtensionStats, error) {
+		return s.db.GetFileExtensionStats(ctx, repo.ID, since, until)
+	})
+}
+
+// GetRepositoryStatsHistory returns a repository's daily stats snapshots, oldest first
+func (s *Service) GetRepositoryStatsHistory(ctx context.Context, fullName string, since, until *time.Time) ([]*models.RepositoryStatsSnapshot, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
 	if err != nil {
-		return nil, fmt.Errorf("error checking repository commits: %w", err)
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	return s.db.GetRepositoryStatsHistory(ctx, repo.ID, since, until)
+}
+
+// GetTopCommitAuthors returns a page of commit authors ordered by commit count,
+// along with the total number of authors. Only commits made within since and
+// until, when given, are counted. A non-empty language limits the ranking to
+// repositories with that primary language, a non-empty group to the
+// repositories in that group and a non-zero account to the repositories that
+// account added.
+func (s *Service) GetTopCommitAuthors(ctx context.Context, since, until *time.Time, language, group string, account int64, page, perPage int) ([]*models.CommitStats, int, error) {
+	if err := s.checkRepositoryGroup(ctx, group); err != nil {
+		return nil, 0, err
+	}
+
+	key := cacheKey("authors", since, until, strings.ToLower(language), group, account, page, perPage)
+	result, err := cache.Get(ctx, s.cache, cache.GroupAll, key, func() (authorsPage, error) {
+		totalCount, err := s.db.CountCommitAuthors(ctx, since, until, language, group, account)
+		if err != nil {
+			return authorsPage{}, fmt.Errorf("error counting commit authors: %w", err)
+		}
+
+		authors, err := s.db.GetTopCommitAuthors(ctx, since, until, language, group, account, perPage, (page-1)*perPage)
+		if err != nil {
+			return authorsPage{}, err
+		}
+		return authorsPage{Authors: authors, Total: totalCount}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.Authors, result.Total, nil
+}
+
+// GetTopRepositories returns up to limit monitored repositories ranked by the
+// given metric over the commits made since the given time, only counting the
+// repositories in group when it is non-empty and those account added when it
+// is non-zero. When cached, the requests for the same minute share a ranking.
+func (s *Service) GetTopRepositories(ctx context.Context, metric, group string, account int64, since time.Time, limit int) ([]*models.RepositoryActivity, error) {
+	if err := s.checkRepositoryGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	key := cacheKey("top-repositories", metric, group, account, since.Truncate(time.Minute), limit)
+	return cache.Get(ctx, s.cache, cache.GroupAll, key, func() ([]*models.RepositoryActivity, error) {
+		return s.db.GetTopRepositories(ctx, since, metric, group, account, limit)
+	})
+}
+
+// GetTopCommitAuthorsByRepository returns a page of commit authors for a specific repository
+// ordered by commit count, along with the total number of authors
+func (s *Service) GetTopCommitAuthorsByRepository(ctx context.Context, fullName string, since, until *time.Time, page, perPage int) ([]*models.CommitStats, int, error) {
+	// First check if the repository exists in the database
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, 0, errors.NewNotFoundError("repository", fullName)
 	}
-	if len(commits) == 0 {
-		return nil, fmt.Errorf("no commits found for repository: %s", fullName)
+
+	key := cacheKey("authors", since, until, page, perPage)
+	result, err := cache.Get(ctx, s.cache, cache.RepositoryGroup(repo.FullName), key, func() (authorsPage, error) {
+		totalCount, err := s.db.CountCommitAuthorsByRepository(ctx, repo.ID, since, until)
+		if err != nil {
+			return authorsPage{}, fmt.Errorf("error counting commit authors: %w", err)
+		}
+		if totalCount == 0 {
+			return authorsPage{}, nil
+		}
+
+		authors, err := s.db.GetTopCommitAuthorsByRepository(ctx, repo.ID, since, until, perPage, (page-1)*perPage)
+		if err != nil {
+			return authorsPage{}, err
+		}
+		return authorsPage{Authors: authors, Total: totalCount}, nil
+	})
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return s.db.GetTopCommitAuthorsByRepository(ctx, repo.ID, limit)
+	// Without a time window, no authors means the repository has no commits.
+	// An empty window simply has no authors.
+	if result.Total == 0 && since == nil && until == nil {
+		return nil, 0, errors.NewNotFoundError("commits", fullName)
+	}
+	return result.Authors, result.Total, nil
 }
 
 // GetCommitsByRepository returns commits for a repository with pagination
@@ -155,11 +827,12 @@ func (s *Service) GetCommitsByRepository(ctx context.Context, fullName string, p
 		return nil, 0, fmt.Errorf("error fetching repository: %w", err)
 	}
 	if repo == nil {
-		return nil, 0, fmt.Errorf("repository not found: %s", fullName)
+		return nil, 0, errors.NewNotFoundError("repository", fullName)
 	}
 
-	// Get total count
-	totalCount, err := s.db.GetCommitCountByRepository(ctx, repo.ID)
+	totalCount, err := cache.Get(ctx, s.cache, cache.RepositoryGroup(repo.FullName), "commit-count", func() (int, error) {
+		return s.db.GetCommitCountByRepository(ctx, repo.ID)
+	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("error getting commit count: %w", err)
 	}
@@ -172,32 +845,253 @@ func (s *Service) GetCommitsByRepository(ctx context.Context, fullName string, p
 	return commits, totalCount, nil
 }
 
-// GetRepositoryByName retrieves a repository by its full name (owner/repo)
-func (s *Service) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
-	return s.db.GetRepositoryByName(ctx, fullName)
+// StreamCommitsByRepository calls fn for each commit of the requested page
+// matching the filter and returns the total number of matching commits
+func (s *Service) StreamCommitsByRepository(ctx context.Context, fullName string, filter models.CommitFilter, page, perPage int, fn func(*models.Commit) error) (int, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return 0, errors.NewNotFoundError("repository", fullName)
+	}
+
+	totalCount, err := s.db.CountCommits(ctx, repo.ID, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error getting commit count: %w", err)
+	}
+
+	if err := s.db.StreamCommitsByRepository(ctx, repo.ID, filter, page, perPage, fn); err != nil {
+		return 0, fmt.Errorf("error fetching commits: %w", err)
+	}
+
+	return totalCount, nil
 }
 
-// DeleteRepository deletes a repository and its associated commits from the database
-func (s *Service) DeleteRepository(ctx context.Context, fullName string) error {
+// GetLatestCommitsVersion returns the version of the newest count commits of a
+// repository, as GetLatestCommits would return it, without loading the commits
+func (s *Service) GetLatestCommitsVersion(ctx context.Context, fullName string, count int) (string, error) {
 	repo, err := s.db.GetRepositoryByName(ctx, fullName)
 	if err != nil {
-		return fmt.Errorf("error finding repository: %w", err)
+		return "", fmt.Errorf("error fetching repository: %w", err)
 	}
 	if repo == nil {
-		return fmt.Errorf("repository not found: %s", fullName)
+		return "", errors.NewNotFoundError("repository", fullName)
 	}
 
-	return s.db.DeleteRepository(ctx, repo.ID)
+	keys, err := s.db.GetLatestCommitKeys(ctx, repo.ID, count)
+	if err != nil {
+		return "", fmt.Errorf("error fetching latest commits: %w", err)
+	}
+	return commitsVersion(keys), nil
 }
 
-// RepositoryExists checks if a repository exists in GitHub without syncing it
-func (s *Service) RepositoryExists(ctx context.Context, owner, name string) (bool, error) {
-	_, err := s.github.GetRepository(ctx, owner, name)
+// GetLatestCommits returns the newest count commits of a repository and their version
+func (s *Service) GetLatestCommits(ctx context.Context, fullName string, count int) ([]*models.Commit, string, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
 	if err != nil {
-		if strings.Contains(err.Error(), "404") {
+		return nil, "", fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, "", errors.NewNotFoundError("repository", fullName)
+	}
+
+	commits, err := s.db.GetLatestCommits(ctx, repo.ID, count)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching latest commits: %w", err)
+	}
+	return commits, commitsVersion(commits), nil
+}
+
+// commitsVersion hashes the SHAs and diff stats of commits in order. Stored commits
+// only change when they are enriched with diff stats, so the version changes
+// exactly when a new commit arrives, one is removed or one is enriched.
+func commitsVersion(commits []*models.Commit) string {
+	h := sha256.New()
+	for _, c := range commits {
+		fmt.Fprintf(h, "%s:%s:%s:%s\n", c.SHA, intOrEmpty(c.Additions), intOrEmpty(c.Deletions), intOrEmpty(c.FilesChanged))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+func intOrEmpty(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// SearchCommits searches the commits of a repository with pagination
+func (s *Service) SearchCommits(ctx context.Context, fullName string, opts models.CommitSearchOptions, page, perPage int) ([]*models.Commit, int, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, 0, errors.NewNotFoundError("repository", fullName)
+	}
+
+	totalCount, err := s.db.CountSearchCommits(ctx, repo.ID, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting matching commits: %w", err)
+	}
+
+	commits, err := s.db.SearchCommits(ctx, repo.ID, opts, page, perPage)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching commits: %w", err)
+	}
+
+	return commits, totalCount, nil
+}
+
+// Bounds on abbreviated commit SHAs accepted by LookupCommit
+const (
+	minSHAPrefixLength = 4
+	maxSHALength       = 40
+)
+
+// maxAmbiguousCandidates caps the candidate SHAs reported for an ambiguous prefix
+const maxAmbiguousCandidates = 10
+
+// GetCommitIncrement returns the commits of a repository ingested by the finished
+// sync runs after sinceRun. Whole runs are returned, as many as fit within limit
+// commits but at least one, so the cursor never splits a run.
+func (s *Service) GetCommitIncrement(ctx context.Context, fullName string, sinceRun int64, limit int) (*models.CommitIncrement, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	// Runs without new commits count towards the run budget only
+	runs, err := s.db.GetFinishedSyncRuns(ctx, repo.ID, sinceRun, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching sync runs: %w", err)
+	}
+
+	increment := &models.CommitIncrement{
+		SinceRun: sinceRun,
+		Cursor:   sinceRun,
+		Runs:     []*models.SyncRun{},
+		Commits:  []*models.Commit{},
+	}
+	total := 0
+	for i, run := range runs {
+		if i == limit || (i > 0 && total+run.NewCommits > limit) {
+			increment.HasMore = true
+			break
+		}
+		total += run.NewCommits
+		increment.Runs = append(increment.Runs, run)
+		increment.Cursor = run.ID
+	}
+
+	if increment.Cursor > sinceRun {
+		increment.Commits, err = s.db.GetCommitsInSyncRuns(ctx, repo.ID, sinceRun, increment.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching commits: %w", err)
+		}
+	}
+	return increment, nil
+}
+
+// LookupCommit finds a stored commit of a repository by its full or abbreviated SHA
+// and returns it with its changed files and neighboring commits
+func (s *Service) LookupCommit(ctx context.Context, fullName, shaPrefix string) (*models.CommitLookup, error) {
+	if len(shaPrefix) < minSHAPrefixLength || len(shaPrefix) > maxSHALength {
+		return nil, fmt.Errorf("%w: SHA must be between %d and %d characters", errors.ErrInvalidInput, minSHAPrefixLength, maxSHALength)
+	}
+
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+
+	matches, err := s.db.FindCommitsBySHAPrefix(ctx, repo.ID, shaPrefix, maxAmbiguousCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("error finding commit: %w", err)
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, errors.NewNotFoundError("commit", shaPrefix)
+	case len(matches) > 1:
+		candidates := make([]string, len(matches))
+		for i, c := range matches {
+			candidates[i] = c.SHA
+		}
+		return nil, fmt.Errorf("%w: SHA prefix %s matches multiple commits: %s",
+			errors.ErrAmbiguous, shaPrefix, strings.Join(candidates, ", "))
+	}
+
+	lookup := &models.CommitLookup{Commit: matches[0]}
+
+	if lookup.Files, err = s.db.GetCommitFiles(ctx, lookup.Commit.ID); err != nil {
+		return nil, fmt.Errorf("error fetching commit files: %w", err)
+	}
+	if lookup.Previous, lookup.Next, err = s.db.GetNeighborCommits(ctx, lookup.Commit); err != nil {
+		return nil, fmt.Errorf("error fetching neighboring commits: %w", err)
+	}
+
+	return lookup, nil
+}
+
+// GetCommitsSince returns the commits_since override of a repository, or nil when none is set
+func (s *Service) GetCommitsSince(ctx context.Context, fullName string) (*time.Time, error) {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return nil, errors.NewNotFoundError("repository", fullName)
+	}
+	return repo.CommitsSince, nil
+}
+
+// SetCommitsSince sets the commits_since override of a repository. Syncs never fetch
+// commits older than the override; a nil since clears it so syncs fall back to the
+// default history window.
+func (s *Service) SetCommitsSince(ctx context.Context, fullName string, since *time.Time) error {
+	repo, err := s.db.GetRepositoryByName(ctx, fullName)
+	if err != nil {
+		return fmt.Errorf("error fetching repository: %w", err)
+	}
+	if repo == nil {
+		return errors.NewNotFoundError("repository", fullName)
+	}
+	return s.db.SetCommitsSince(ctx, repo.ID, since)
+}
+
+// GetRepositoryByName retrieves a repository by its full name (owner/repo)
+func (s *Service) GetRepositoryByName(ctx context.Context, fullName string) (*models.Repository, error) {
+	return s.db.GetRepositoryByName(ctx, fullName)
+}
+
+// RepositoryExists checks if a repository exists on a provider without syncing it
+func (s *Service) RepositoryExists(ctx context.Context, providerName, owner, name string) (bool, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return false, err
+	}
+	_, err = provider.GetRepository(ctx, owner, name)
+	if err != nil {
+		if errors.Is(err, errors.ErrUpstreamNotFound) {
 			return false, nil
 		}
 		return false, err
 	}
 	return true, nil
 }
+
+// ListOrganizationRepositories returns the full names of an organization's active repositories
+func (s *Service) ListOrganizationRepositories(ctx context.Context, org string) ([]string, error) {
+	names, err := s.github.ListOrganizationRepositories(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("error listing organization repositories: %w", err)
+	}
+	return names, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github-service/internal/models"
 	"github-service/internal/testutil"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,6 +75,64 @@ func (m *MockGitHubClient) GetCommits(ctx context.Context, owner, name string, s
 	return []models.CommitResponse{commit}, nil
 }
 
+func (m *MockGitHubClient) ForEachCommitPage(ctx context.Context, owner, name string, since time.Time, startPage, maxPages int, fn func([]models.CommitResponse) (bool, error)) error {
+	commits, err := m.GetCommits(ctx, owner, name, since)
+	if err != nil {
+		return err
+	}
+	_, err = fn(commits)
+	return err
+}
+
+func (m *MockGitHubClient) GetCommit(ctx context.Context, owner, name, sha string) (*models.CommitDetail, error) {
+	return &models.CommitDetail{
+		SHA: sha,
+		Files: []models.CommitFile{
+			{Filename: "main.go", Extension: "go", Status: "modified", Additions: 1, Changes: 1},
+		},
+	}, nil
+}
+
+func (m *MockGitHubClient) GetIssues(ctx context.Context, owner, name string, since time.Time) ([]models.Issue, error) {
+	return nil, nil
+}
+
+func (m *MockGitHubClient) ListOrganizationRepositories(ctx context.Context, org string) ([]string, error) {
+	return []string{org + "/test"}, nil
+}
+
+func (m *MockGitHubClient) ListUserRepositories(ctx context.Context, username string) ([]models.UserRepository, error) {
+	return nil, nil
+}
+
+func (m *MockGitHubClient) ListTags(ctx context.Context, owner, name string) ([]models.Tag, error) {
+	return []models.Tag{{Name: "v1.0.0", CommitSHA: "abc123"}}, nil
+}
+
+func (m *MockGitHubClient) ListReleases(ctx context.Context, owner, name string) ([]models.Release, error) {
+	return nil, nil
+}
+
+func (m *MockGitHubClient) GetContributorStats(ctx context.Context, owner, name string) ([]models.ContributorStats, error) {
+	return nil, nil
+}
+
+func (m *MockGitHubClient) GetFileContent(ctx context.Context, owner, name, path string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *MockGitHubClient) TokenRateLimits() []models.TokenRateLimit {
+	return nil
+}
+
+func (m *MockGitHubClient) TokenExpiration() time.Time {
+	return time.Time{}
+}
+
+func (m *MockGitHubClient) RepositoryDedupStats() models.DedupStats {
+	return models.DedupStats{}
+}
+
 func (m *MockGitHubClient) GetRateLimitInfo() models.RateLimitInfo {
 	return models.RateLimitInfo{
 		Remaining: 1000,
@@ -133,9 +192,11 @@ func TestSyncRepository(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db, mockClient := tt.setup(t)
+			logger := zerolog.Nop()
 			svc := &Service{
 				db:     db,
 				github: mockClient,
+				logger: &logger,
 			}
 
 			err := svc.SyncRepository(context.Background(), tt.owner, tt.repo, tt.since)
@@ -173,7 +234,7 @@ func TestGetTopCommitAuthors(t *testing.T) {
 				db: database.NewFromDB(pg.DB),
 			}
 
-			got, err := svc.GetTopCommitAuthors(context.Background(), tt.limit)
+			got, _, err := svc.GetTopCommitAuthors(context.Background(), nil, nil, "", "", 0, 1, tt.limit)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetTopCommitAuthors() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -189,3 +250,34 @@ func TestGetTopCommitAuthors(t *testing.T) {
 		})
 	}
 }
+
+func TestBackfillCursorPage(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	stored := since.Truncate(time.Microsecond) // Postgres keeps microseconds
+	other := since.AddDate(0, -1, 0)
+
+	tests := []struct {
+		name      string
+		cursor    *models.BackfillCursor
+		since     time.Time
+		wantPage  int
+		wantOwned bool
+	}{
+		{"no cursor", nil, since, 0, true},
+		{"full history resumes full history", &models.BackfillCursor{Page: 12, UpdatedAt: now}, time.Time{}, 12, true},
+		{"same since resumes", &models.BackfillCursor{Since: &stored, Page: 7, UpdatedAt: now}, since, 7, true},
+		{"windowed sync leaves full history cursor", &models.BackfillCursor{Page: 12, UpdatedAt: now}, since, 0, false},
+		{"full history leaves windowed cursor", &models.BackfillCursor{Since: &stored, Page: 7, UpdatedAt: now}, time.Time{}, 0, false},
+		{"other since leaves cursor", &models.BackfillCursor{Since: &other, Page: 7, UpdatedAt: now.Add(-time.Hour)}, since, 0, false},
+		{"other since replaces abandoned cursor", &models.BackfillCursor{Since: &other, Page: 7, UpdatedAt: now.Add(-abandonedBackfillAge - time.Hour)}, since, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, owned := backfillCursorPage(tt.cursor, tt.since, now)
+			assert.Equal(t, tt.wantPage, page)
+			assert.Equal(t, tt.wantOwned, owned)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"time"
+
+	"github-service/internal/events"
+	"github-service/internal/models"
+)
+
+// defaultTokenExpiryWarning is how long before the GitHub token expires that
+// warnings start unless configured
+const defaultTokenExpiryWarning = 7 * 24 * time.Hour
+
+// tokenWarningInterval is the shortest time between two expiry warnings
+const tokenWarningInterval = 24 * time.Hour
+
+// WithTokenExpiryWarning sets how long before the GitHub token expires that
+// warnings are logged and published. Zero disables the warnings.
+func WithTokenExpiryWarning(window time.Duration) Option {
+	return func(s *Service) {
+		s.tokenWarning = window
+	}
+}
+
+// GetTokenStatus returns the expiry of the GitHub token as last reported by GitHub
+func (s *Service) GetTokenStatus() models.TokenStatus {
+	status := models.TokenStatus{WarnBefore: s.tokenWarning.String()}
+	expiry := s.github.TokenExpiration()
+	if expiry.IsZero() {
+		return status
+	}
+
+	left := time.Until(expiry)
+	status.ExpiresAt = &expiry
+	status.Expired = left <= 0
+	if !status.Expired {
+		status.ExpiresIn = left.Truncate(time.Second).String()
+	}
+	status.ExpiringSoon = s.tokenWarning > 0 && left < s.tokenWarning
+	return status
+}
+
+// checkTokenExpiry warns, at most once per tokenWarningInterval, when the GitHub
+// token expires within the warning window, so syncs don't start failing unnoticed
+func (s *Service) checkTokenExpiry() {
+	status := s.GetTokenStatus()
+	if !status.ExpiringSoon {
+		return
+	}
+
+	s.tokenWarnMu.Lock()
+	if time.Since(s.tokenWarnedAt) < tokenWarningInterval {
+		s.tokenWarnMu.Unlock()
+		return
+	}
+	s.tokenWarnedAt = time.Now()
+	s.tokenWarnMu.Unlock()
+
+	s.logger.Warn().
+		Time("expires_at", *status.ExpiresAt).
+		Bool("expired", status.Expired).
+		Msg("GitHub token is about to expire")
+	s.publish(events.TokenExpiring, map[string]interface{}{
+		"expires_at": status.ExpiresAt.UTC().Format(time.RFC3339),
+		"expires_in": status.ExpiresIn,
+		"expired":    status.Expired,
+	})
+}
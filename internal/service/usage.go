@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// RecordAPIUsage stores aggregated API requests
+func (s *Service) RecordAPIUsage(ctx context.Context, usage []*models.APIUsage) error {
+	if err := s.db.AddAPIUsage(ctx, usage); err != nil {
+		return fmt.Errorf("error storing api usage: %w", err)
+	}
+	return nil
+}
+
+// GetAPIUsage summarizes API usage per endpoint and per API key for the hours
+// starting within [since, until]
+func (s *Service) GetAPIUsage(ctx context.Context, since, until time.Time) ([]*models.EndpointUsage, []*models.APIKeyUsage, error) {
+	endpoints, err := s.db.GetEndpointUsage(ctx, since, until)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching endpoint usage: %w", err)
+	}
+
+	keys, err := s.db.GetAPIKeyUsage(ctx, since, until)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching api key usage: %w", err)
+	}
+
+	return endpoints, keys, nil
+}
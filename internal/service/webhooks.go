@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github-service/internal/errors"
+	"github-service/internal/events"
+	"github-service/internal/models"
+)
+
+const (
+	// defaultWebhookAttempts is how many times a webhook delivery is attempted
+	// unless configured
+	defaultWebhookAttempts = 6
+
+	// webhookRetryDelay is the wait before the first retry of a delivery. It
+	// doubles with every further attempt, up to webhookMaxRetryDelay.
+	webhookRetryDelay    = time.Minute
+	webhookMaxRetryDelay = time.Hour
+
+	// webhookDeliveryBatch is the most deliveries sent by one DeliverWebhooks call
+	webhookDeliveryBatch = 50
+
+	// webhookDeliveryLease is how long a claimed delivery is skipped by other
+	// instances while it is sent
+	webhookDeliveryLease = 5 * time.Minute
+)
+
+// WebhookEvent is the signed payload carrying a batch of newly ingested commits
+// to a webhook
+type WebhookEvent struct {
+	Event      string           `json:"event"`
+	Repository string           `json:"repository"`
+	WebhookID  int64            `json:"webhook_id"`
+	SyncRunID  int64            `json:"sync_run_id"`
+	Batch      int              `json:"batch"`   // 1-based position of this batch among the sync's deliveries
+	Batches    int              `json:"batches"` // Deliveries made for the sync
+	Commits    []*models.Commit `json:"commits"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
+
+// CreateWebhook registers a webhook for the commits ingested for its
+// repositories, which need not be monitored yet. It returns the secret its
+// deliveries are signed with, which is not shown again.
+func (s *Service) CreateWebhook(ctx context.Context, hook *models.Webhook) (string, error) {
+	if err := validateWebhookURL(hook.URL); err != nil {
+		return "", err
+	}
+
+	seen := make(map[string]bool, len(hook.Repositories))
+	repositories := make([]string, 0, len(hook.Repositories))
+	for _, name := range hook.Repositories {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if owner, repo, ok := strings.Cut(name, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return "", fmt.Errorf("%w: repository %q must be in the form owner/repo", errors.ErrInvalidInput, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			repositories = append(repositories, name)
+		}
+	}
+	if len(repositories) == 0 {
+		return "", fmt.Errorf("%w: at least one repository is required", errors.ErrInvalidInput)
+	}
+	hook.Repositories = repositories
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	plaintext := hex.EncodeToString(secret)
+
+	if err := s.db.CreateWebhook(ctx, hook, plaintext); err != nil {
+		return "", errors.NewDatabaseError("CreateWebhook", err)
+	}
+	return plaintext, nil
+}
+
+// ListWebhooks returns every webhook
+func (s *Service) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	return s.db.ListWebhooks(ctx)
+}
+
+// GetWebhook returns a webhook
+func (s *Service) GetWebhook(ctx context.Context, id int64) (*models.Webhook, error) {
+	hook, err := s.db.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, errors.NewDatabaseError("GetWebhook", err)
+	}
+	if hook == nil {
+		return nil, errors.NewNotFoundError("webhook", id)
+	}
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook, dropping its pending deliveries
+func (s *Service) DeleteWebhook(ctx context.Context, id int64) error {
+	return s.db.DeleteWebhook(ctx, id)
+}
+
+// ListWebhookDeliveries returns a page of a webhook's deliveries, newest first,
+// and how many it has in total
+func (s *Service) ListWebhookDeliveries(ctx context.Context, id int64, page, perPage int) ([]*models.WebhookDelivery, int, error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.db.ListWebhookDeliveries(ctx, id, page, perPage)
+}
+
+// queueWebhookDeliveries records a delivery of the commits ingested by a sync,
+// in batches, for each webhook subscribed to the repository. DeliverWebhooks
+// sends them. Failures are logged rather than returned so webhooks never fail a sync.
+func (s *Service) queueWebhookDeliveries(ctx context.Context, repo *models.Repository, runID int64, commits []*models.Commit) {
+	log := s.logger.With().Str("repository", repo.FullName).Logger()
+
+	hooks, err := s.db.GetRepositoryWebhooks(ctx, repo.FullName)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load webhooks")
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	batches := (len(commits) + commitHookBatchSize - 1) / commitHookBatchSize
+	var deliveries []*models.WebhookDelivery
+	for _, hook := range hooks {
+		for i := 0; i < batches; i++ {
+			end := min((i+1)*commitHookBatchSize, len(commits))
+			payload, err := json.Marshal(WebhookEvent{
+				Event:      events.CommitsIngested,
+				Repository: repo.FullName,
+				WebhookID:  hook.ID,
+				SyncRunID:  runID,
+				Batch:      i + 1,
+				Batches:    batches,
+				Commits:    commits[i*commitHookBatchSize : end],
+				OccurredAt: time.Now().UTC(),
+			})
+			if err != nil {
+				log.Warn().Err(err).Int64("webhook_id", hook.ID).Msg("Failed to encode webhook payload")
+				return
+			}
+			deliveries = append(deliveries, &models.WebhookDelivery{
+				WebhookID:  hook.ID,
+				Event:      events.CommitsIngested,
+				Repository: repo.FullName,
+				Payload:    payload,
+			})
+		}
+	}
+
+	if err := s.db.CreateWebhookDeliveries(ctx, deliveries); err != nil {
+		log.Warn().Err(err).Msg("Failed to queue webhook deliveries")
+		return
+	}
+	log.Info().Int("webhooks", len(hooks)).Int("deliveries", len(deliveries)).Msg("Queued webhook deliveries")
+}
+
+// DeliverWebhooks sends the webhook deliveries that are due, returning how many
+// were attempted. A failed delivery is retried with exponential backoff until
+// it has been attempted the configured number of times.
+func (s *Service) DeliverWebhooks(ctx context.Context) (int, error) {
+	if s.webhooks == nil {
+		return 0, nil
+	}
+
+	deliveries, err := s.db.ClaimWebhookDeliveries(ctx, time.Now(), webhookDeliveryLease, webhookDeliveryBatch)
+	if err != nil {
+		return 0, errors.NewDatabaseError("ClaimWebhookDeliveries", err)
+	}
+
+	for _, delivery := range deliveries {
+		status, sendErr := s.webhooks.SendSigned(ctx, delivery.URL, delivery.Secret, delivery.Event, delivery.ID, delivery.Payload)
+		finishWebhookAttempt(delivery, status, sendErr, s.webhookAttempts, time.Now())
+
+		log := s.logger.With().
+			Int64("delivery_id", delivery.ID).
+			Int64("webhook_id", delivery.WebhookID).
+			Str("repository", delivery.Repository).
+			Int("attempts", delivery.Attempts).
+			Logger()
+		switch delivery.Status {
+		case models.DeliveryDelivered:
+			log.Info().Msg("Delivered webhook")
+		case models.DeliveryFailed:
+			log.Error().Err(sendErr).Msg("Webhook delivery failed for the last time")
+		default:
+			log.Warn().Err(sendErr).Time("next_attempt_at", *delivery.NextAttemptAt).Msg("Webhook delivery failed; will retry")
+		}
+
+		if err := s.db.FinishWebhookDeliveryAttempt(ctx, delivery); err != nil {
+			return len(deliveries), errors.NewDatabaseError("FinishWebhookDeliveryAttempt", err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// finishWebhookAttempt records the outcome of an attempt at a delivery made
+// at now: delivered, scheduled for a retry, or failed after maxAttempts
+func finishWebhookAttempt(delivery *models.WebhookDelivery, status int, sendErr error, maxAttempts int, now time.Time) {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+
+	if sendErr == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.Error = ""
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.Error = sendErr.Error()
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delay := webhookRetryDelay
+	for i := 1; i < delivery.Attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	next := now.Add(min(delay, webhookMaxRetryDelay))
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = &next
+}
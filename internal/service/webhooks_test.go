@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishWebhookAttempt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("delivered", func(t *testing.T) {
+		delivery := &models.WebhookDelivery{Attempts: 1, Error: "timeout"}
+		finishWebhookAttempt(delivery, 200, nil, 3, now)
+		assert.Equal(t, models.DeliveryDelivered, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Empty(t, delivery.Error)
+		assert.Nil(t, delivery.NextAttemptAt)
+		require.NotNil(t, delivery.DeliveredAt)
+		assert.Equal(t, 200, *delivery.ResponseStatus)
+	})
+
+	t.Run("retried with backoff", func(t *testing.T) {
+		delivery := &models.WebhookDelivery{}
+		finishWebhookAttempt(delivery, 0, fmt.Errorf("connection refused"), 3, now)
+		assert.Equal(t, models.DeliveryPending, delivery.Status)
+		assert.Nil(t, delivery.ResponseStatus)
+		assert.Equal(t, now.Add(webhookRetryDelay), *delivery.NextAttemptAt)
+
+		finishWebhookAttempt(delivery, 503, fmt.Errorf("status 503"), 3, now)
+		assert.Equal(t, now.Add(2*webhookRetryDelay), *delivery.NextAttemptAt)
+		assert.Equal(t, 503, *delivery.ResponseStatus)
+	})
+
+	t.Run("backoff is capped", func(t *testing.T) {
+		delivery := &models.WebhookDelivery{Attempts: 40}
+		finishWebhookAttempt(delivery, 500, fmt.Errorf("status 500"), 100, now)
+		assert.Equal(t, now.Add(webhookMaxRetryDelay), *delivery.NextAttemptAt)
+	})
+
+	t.Run("failed after the last attempt", func(t *testing.T) {
+		delivery := &models.WebhookDelivery{Attempts: 2}
+		finishWebhookAttempt(delivery, 500, fmt.Errorf("status 500"), 3, now)
+		assert.Equal(t, models.DeliveryFailed, delivery.Status)
+		assert.Equal(t, "status 500", delivery.Error)
+		assert.Nil(t, delivery.NextAttemptAt)
+	})
+}
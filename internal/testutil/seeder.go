@@ -66,7 +66,7 @@ func SeedChromiumData(ctx context.Context, db *database.DB, githubToken string)
 			URL:            commit.HTMLURL,
 		}
 
-		if err := db.CreateCommit(ctx, dbCommit); err != nil {
+		if _, err := db.CreateCommit(ctx, dbCommit); err != nil {
 			return fmt.Errorf("failed to create commit record: %w", err)
 		}
 	}
@@ -83,7 +83,7 @@ func GetChromiumStats(ctx context.Context, db *database.DB) (*models.Repository,
 	}
 
 	// Get top commit authors
-	authors, err := db.GetTopCommitAuthors(ctx, 10)
+	authors, err := db.GetTopCommitAuthors(ctx, nil, nil, "", "", 0, 10, 0)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get top authors: %w", err)
 	}
@@ -0,0 +1,93 @@
+// Package tracing sets up OpenTelemetry tracing, exported over OTLP, and
+// carries trace context through the job queue so work started by an API call
+// can be followed into the job that performs it
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the service's own spans
+const instrumentationName = "github-service"
+
+// Config configures where and how many traces are exported
+type Config struct {
+	Endpoint    string  // OTLP/HTTP endpoint URL; empty uses the OTEL_EXPORTER_OTLP_* environment variables
+	Insecure    bool    // Send over plain HTTP
+	SampleRatio float64 // Share of new traces recorded; traces started by callers follow their sampling decision
+	ServiceName string
+}
+
+// Setup installs a tracer provider exporting spans to the OTLP endpoint and the
+// W3C trace context propagator. The returned function flushes pending spans and
+// must be called on shutdown. Without Setup all spans are no-ops.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for the service's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Inject returns the trace context of ctx as a map to be stored with a job, or
+// nil when there is nothing to propagate
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx carrying the trace context stored by Inject, so spans
+// started from it continue the trace
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// RecordError marks span as failed with err, if any
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
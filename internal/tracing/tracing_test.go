@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Errorf("Expected no trace context without a span, got %v", carrier)
+	}
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "enqueue")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	if carrier["traceparent"] == "" {
+		t.Fatalf("Expected a traceparent, got %v", carrier)
+	}
+
+	restored := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	if restored.TraceID() != span.SpanContext().TraceID() || restored.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected span context %v, got %v", span.SpanContext(), restored)
+	}
+	if !restored.IsRemote() {
+		t.Error("Expected the extracted span context to be remote")
+	}
+}
@@ -0,0 +1,90 @@
+// Package usage aggregates the service's own API requests in memory until they
+// are flushed to the database.
+package usage
+
+import (
+	"sync"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// bucketSize is the period requests are aggregated over
+const bucketSize = time.Hour
+
+// key identifies one aggregate
+type key struct {
+	bucket   time.Time
+	method   string
+	route    string
+	apiKeyID int64
+}
+
+// Recorder aggregates requests per hour, endpoint and API key
+type Recorder struct {
+	mu      sync.Mutex
+	entries map[key]*models.APIUsage
+}
+
+// NewRecorder creates an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{entries: make(map[key]*models.APIUsage)}
+}
+
+// Record adds a request that finished at the given time
+func (r *Recorder) Record(at time.Time, method, route string, apiKeyID int64, status int, duration time.Duration) {
+	k := key{bucket: at.UTC().Truncate(bucketSize), method: method, route: route, apiKeyID: apiKeyID}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[k]
+	if !ok {
+		entry = &models.APIUsage{Bucket: k.bucket, Method: method, Route: route, APIKeyID: apiKeyID}
+		r.entries[k] = entry
+	}
+	entry.Requests++
+	if status >= 500 {
+		entry.Errors++
+	}
+	entry.TotalDuration += duration
+	if duration > entry.MaxDuration {
+		entry.MaxDuration = duration
+	}
+}
+
+// Drain returns the aggregates recorded since the last drain and resets the recorder
+func (r *Recorder) Drain() []*models.APIUsage {
+	r.mu.Lock()
+	entries := r.entries
+	r.entries = make(map[key]*models.APIUsage)
+	r.mu.Unlock()
+
+	drained := make([]*models.APIUsage, 0, len(entries))
+	for _, entry := range entries {
+		drained = append(drained, entry)
+	}
+	return drained
+}
+
+// Restore puts aggregates back, e.g. after they failed to be flushed, merging
+// them with anything recorded in the meantime
+func (r *Recorder) Restore(entries []*models.APIUsage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range entries {
+		k := key{bucket: e.Bucket, method: e.Method, route: e.Route, apiKeyID: e.APIKeyID}
+		existing, ok := r.entries[k]
+		if !ok {
+			r.entries[k] = e
+			continue
+		}
+		existing.Requests += e.Requests
+		existing.Errors += e.Errors
+		existing.TotalDuration += e.TotalDuration
+		if e.MaxDuration > existing.MaxDuration {
+			existing.MaxDuration = e.MaxDuration
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	at := time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)
+
+	r.Record(at, "GET", "/api/v1/repositories", 1, 200, 10*time.Millisecond)
+	r.Record(at.Add(30*time.Minute), "GET", "/api/v1/repositories", 1, 500, 30*time.Millisecond)
+	r.Record(at, "GET", "/api/v1/repositories", 2, 200, 5*time.Millisecond)
+	r.Record(at.Add(time.Hour), "GET", "/api/v1/repositories", 1, 200, 5*time.Millisecond)
+
+	entries := r.Drain()
+	if len(entries) != 3 {
+		t.Fatalf("got %d aggregates, want 3", len(entries))
+	}
+
+	var found bool
+	for _, e := range entries {
+		if e.APIKeyID != 1 || !e.Bucket.Equal(at.Truncate(time.Hour)) {
+			continue
+		}
+		found = true
+		if e.Requests != 2 || e.Errors != 1 {
+			t.Errorf("got %d requests and %d errors, want 2 and 1", e.Requests, e.Errors)
+		}
+		if e.TotalDuration != 40*time.Millisecond || e.MaxDuration != 30*time.Millisecond {
+			t.Errorf("got total %v and max %v, want 40ms and 30ms", e.TotalDuration, e.MaxDuration)
+		}
+	}
+	if !found {
+		t.Fatal("expected an aggregate for key 1 in the first hour")
+	}
+
+	if left := r.Drain(); len(left) != 0 {
+		t.Errorf("got %d aggregates after draining, want 0", len(left))
+	}
+
+	// Restored aggregates merge with new ones
+	r.Record(at, "GET", "/api/v1/repositories", 2, 200, 5*time.Millisecond)
+	r.Restore(entries)
+	for _, e := range r.Drain() {
+		if e.APIKeyID == 2 && e.Requests != 2 {
+			t.Errorf("got %d requests for key 2 after restore, want 2", e.Requests)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Package webhook delivers JSON event payloads to user-configured URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// userAgent identifies deliveries made by this service
+const userAgent = "github-service-webhook"
+
+// Headers set on signed deliveries
+const (
+	SignatureHeader = "X-Webhook-Signature-256" // sha256= followed by the hex HMAC-SHA256 of the body
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sender posts JSON payloads to webhook URLs
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a Sender whose deliveries time out after timeout
+func NewSender(timeout time.Duration) *Sender {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &Sender{client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts payload as JSON to url. Any non-2xx response is an error.
+func (s *Sender) Send(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	_, err = s.post(ctx, url, body, nil)
+	return err
+}
+
+// SendSigned posts an encoded JSON body to url, signed with secret and
+// identified by the event and delivery ID headers. It returns the response
+// status, or 0 when no response was received; any non-2xx response is an error.
+func (s *Sender) SendSigned(ctx context.Context, url, secret, event string, deliveryID int64, body []byte) (int, error) {
+	return s.post(ctx, url, body, map[string]string{
+		SignatureHeader: Sign(secret, body),
+		EventHeader:     event,
+		DeliveryHeader:  fmt.Sprint(deliveryID),
+	})
+}
+
+// Sign returns the signature header value of a body: sha256= followed by the
+// hex HMAC-SHA256 of the body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends body to url with the extra headers
+func (s *Sender) post(ctx context.Context, url string, body []byte, headers map[string]string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook delivery failed: %s responded with status %d", url, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
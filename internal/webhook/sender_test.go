@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSend(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second)
+
+	if err := sender.Send(context.Background(), server.URL+"/ok", map[string]string{"event": "test"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got["event"] != "test" {
+		t.Errorf("payload event = %v, want test", got["event"])
+	}
+
+	if err := sender.Send(context.Background(), server.URL+"/fail", map[string]string{"event": "test"}); err == nil {
+		t.Error("expected an error for a non-2xx response")
+	}
+}
+
+func TestSendSigned(t *testing.T) {
+	body := []byte(`{"event":"commits.ingested"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get(SignatureHeader), Sign("secret", body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get(EventHeader) != "commits.ingested" || r.Header.Get(DeliveryHeader) != "42" {
+			t.Errorf("event, delivery = %q, %q", r.Header.Get(EventHeader), r.Header.Get(DeliveryHeader))
+		}
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer server.Close()
+
+	sender := NewSender(time.Second)
+
+	status, err := sender.SendSigned(context.Background(), server.URL+"/ok", "secret", "commits.ingested", 42, body)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("SendSigned = %d, %v, want 200", status, err)
+	}
+	status, err = sender.SendSigned(context.Background(), server.URL+"/gone", "secret", "commits.ingested", 42, body)
+	if err == nil || status != http.StatusGone {
+		t.Errorf("SendSigned = %d, %v, want 410 and an error", status, err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Known HMAC-SHA256 test vector (RFC 4231 test case 2)
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got := Sign("Jefe", []byte("what do ya want for nothing?")); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
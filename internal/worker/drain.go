@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github-service/internal/queue"
+)
+
+// drainer tracks the jobs a worker is running so they can be finished or requeued on shutdown.
+// Jobs run with the drainer's context rather than the worker's, so a shutdown
+// signal stops dequeuing without interrupting them.
+type drainer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+func newDrainer() *drainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &drainer{
+		ctx:      ctx,
+		cancel:   cancel,
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// begin marks a job as in flight
+func (d *drainer) begin(jobID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight[jobID] = struct{}{}
+}
+
+// done marks an in-flight job as finished
+func (d *drainer) done(jobID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inFlight, jobID)
+}
+
+// aborted reports whether draining timed out and in-flight jobs were cancelled.
+// Their outcome is then left to drain, which requeues them.
+func (d *drainer) aborted() bool {
+	return d.ctx.Err() != nil
+}
+
+// drain waits for the worker loops tracked by loops to return after finishing their
+// current job. If ctx expires first, the jobs still in flight are cancelled and
+// returned to the queue, and their number is returned.
+func (d *drainer) drain(ctx context.Context, q queue.Queue, loops *sync.WaitGroup) (int, error) {
+	finished := make(chan struct{})
+	go func() {
+		loops.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return 0, nil
+	case <-ctx.Done():
+	}
+
+	d.cancel()
+
+	// ctx has expired, but the jobs must still be handed back
+	requeueCtx := context.WithoutCancel(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var requeued int
+	for jobID := range d.inFlight {
+		if err := q.Requeue(requeueCtx, jobID); err != nil {
+			return requeued, fmt.Errorf("failed to requeue job %s: %w", jobID, err)
+		}
+		requeued++
+	}
+	return requeued, nil
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github-service/internal/queue"
+)
+
+// requeueRecorder is a queue that only records requeued jobs
+type requeueRecorder struct {
+	queue.Queue
+	mu       sync.Mutex
+	requeued []string
+}
+
+func (q *requeueRecorder) Requeue(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.requeued = append(q.requeued, jobID)
+	return nil
+}
+
+func TestDrain(t *testing.T) {
+	t.Run("waits for running job", func(t *testing.T) {
+		d := newDrainer()
+		q := &requeueRecorder{}
+		var loops sync.WaitGroup
+
+		loops.Add(1)
+		d.begin("job-1")
+		go func() {
+			defer loops.Done()
+			time.Sleep(20 * time.Millisecond)
+			d.done("job-1")
+		}()
+
+		requeued, err := d.drain(context.Background(), q, &loops)
+		if err != nil {
+			t.Fatalf("drain: %v", err)
+		}
+		if requeued != 0 || len(q.requeued) != 0 {
+			t.Errorf("requeued %d jobs, want 0", requeued)
+		}
+		if d.aborted() {
+			t.Error("expected running jobs not to be cancelled")
+		}
+	})
+
+	t.Run("requeues job that outlives timeout", func(t *testing.T) {
+		d := newDrainer()
+		q := &requeueRecorder{}
+		var loops sync.WaitGroup
+
+		loops.Add(1)
+		d.begin("job-1")
+		go func() {
+			defer loops.Done()
+			<-d.ctx.Done()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		requeued, err := d.drain(ctx, q, &loops)
+		if err != nil {
+			t.Fatalf("drain: %v", err)
+		}
+		if requeued != 1 || len(q.requeued) != 1 || q.requeued[0] != "job-1" {
+			t.Errorf("requeued %v, want [job-1]", q.requeued)
+		}
+		if !d.aborted() {
+			t.Error("expected running jobs to be cancelled")
+		}
+	})
+}
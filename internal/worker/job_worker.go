@@ -3,35 +3,95 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
+	"github-service/internal/models"
 	"github-service/internal/queue"
 	"github-service/internal/service"
+	"github-service/internal/tracing"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // JobWorker processes jobs from the queue
 type JobWorker struct {
-	queue   queue.Queue
-	service *service.Service
-	log     zerolog.Logger
-	stop    chan struct{}
+	queue    queue.Queue
+	service  *service.Service
+	log      zerolog.Logger
+	stop     chan struct{}
+	stopOnce sync.Once
+	running  sync.WaitGroup
+	drain    *drainer
+
+	pollInterval      time.Duration    // Wait between dequeues while the queue is empty
+	syncInterval      time.Duration    // Sync interval of the repositories discovery adds to monitoring
+	heartbeatInterval time.Duration    // Between heartbeats of a running job; 0 sends none
+	notifier          Notifier         // Optional: wakes the worker when jobs are enqueued
+	failures          service.Notifier // Optional: told about jobs that failed their last retry
+}
+
+// Notifier signals that jobs may have been added to the queue
+type Notifier interface {
+	// Wait returns a channel that is closed by the next signal
+	Wait() <-chan struct{}
 }
 
 // NewJobWorker creates a new job worker
 func NewJobWorker(queue queue.Queue, service *service.Service, log zerolog.Logger) *JobWorker {
 	return &JobWorker{
-		queue:   queue,
-		service: service,
-		log:     log,
-		stop:    make(chan struct{}),
+		queue:        queue,
+		service:      service,
+		log:          log,
+		stop:         make(chan struct{}),
+		drain:        newDrainer(),
+		pollInterval: time.Second,
+		syncInterval: time.Hour,
+	}
+}
+
+// SetPollInterval sets how long the worker waits between dequeues while the
+// queue is empty. With a notifier it is only a fallback. It must be called before Start.
+func (w *JobWorker) SetPollInterval(d time.Duration) {
+	if d > 0 {
+		w.pollInterval = d
 	}
 }
 
+// SetSyncInterval sets the sync interval of the repositories that discovery
+// jobs add to monitoring. It must be called before Start.
+func (w *JobWorker) SetSyncInterval(d time.Duration) {
+	if d > 0 {
+		w.syncInterval = d
+	}
+}
+
+// SetHeartbeatInterval makes the worker record every d that the job it runs is
+// still alive, so the job isn't reaped or taken over by another worker. It
+// must be called before Start.
+func (w *JobWorker) SetHeartbeatInterval(d time.Duration) {
+	w.heartbeatInterval = d
+}
+
+// SetFailureNotifier sends a notification through n when a job fails its last
+// retry. It must be called before Start.
+func (w *JobWorker) SetFailureNotifier(n service.Notifier) {
+	w.failures = n
+}
+
+// SetNotifier makes the worker dequeue as soon as n signals a new job instead
+// of waiting for the next poll. It must be called before Start.
+func (w *JobWorker) SetNotifier(n Notifier) {
+	w.notifier = n
+}
+
 // calculateBackoff calculates the next retry backoff duration with jitter
 func (w *JobWorker) calculateBackoff(job *queue.Job) time.Duration {
 	if job.InitialBackoff == 0 {
@@ -52,8 +112,12 @@ func (w *JobWorker) calculateBackoff(job *queue.Job) time.Duration {
 	return time.Duration(backoff)
 }
 
-// Start starts the job worker
+// Start starts the job worker. Cancelling ctx stops dequeuing; a job already
+// running is left to finish, see Shutdown. Start may be called from several
+// goroutines to process jobs concurrently, as Pool does.
 func (w *JobWorker) Start(ctx context.Context) error {
+	w.running.Add(1)
+	defer w.running.Done()
 	w.log.Info().Msg("Starting job worker")
 
 	for {
@@ -65,29 +129,67 @@ func (w *JobWorker) Start(ctx context.Context) error {
 			w.log.Info().Msg("Job worker stopped")
 			return nil
 		default:
-			if err := w.processNextJob(ctx); err != nil {
+		}
+
+		// Subscribe before dequeuing so a job enqueued in between isn't missed
+		var notified <-chan struct{}
+		if w.notifier != nil {
+			notified = w.notifier.Wait()
+		}
+
+		job, err := w.queue.Dequeue(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			w.log.Error().Err(err).Msg("Failed to dequeue job")
+		} else if job != nil {
+			if err := w.processJob(w.drain.ctx, job); err != nil {
 				w.log.Error().Err(err).Msg("Failed to process job")
 			}
-			// Small delay to prevent tight loop
-			time.Sleep(time.Second)
+			// More jobs may be waiting
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-w.stop:
+		case <-notified:
+		case <-time.After(w.pollInterval):
 		}
 	}
 }
 
-// Stop stops the job worker
+// Stop stops the job worker from dequeuing further jobs
 func (w *JobWorker) Stop() {
-	close(w.stop)
+	w.stopOnce.Do(func() { close(w.stop) })
 }
 
-// processNextJob processes the next job in the queue
-func (w *JobWorker) processNextJob(ctx context.Context) error {
-	job, err := w.queue.Dequeue()
-	if err != nil {
-		return fmt.Errorf("failed to dequeue job: %w", err)
-	}
-	if job == nil {
-		return nil // No jobs available
+// Shutdown stops dequeuing and waits for the running jobs to finish. If ctx
+// expires first, the jobs are cancelled and returned to the queue.
+func (w *JobWorker) Shutdown(ctx context.Context) error {
+	w.Stop()
+	requeued, err := w.drain.drain(ctx, w.queue, &w.running)
+	if requeued > 0 {
+		w.log.Warn().Int("count", requeued).Msg("Requeued jobs that did not finish before shutdown")
 	}
+	return err
+}
+
+// processJob processes a dequeued job and records its outcome in the queue
+func (w *JobWorker) processJob(ctx context.Context, job *queue.Job) error {
+	w.drain.begin(job.ID)
+	defer w.drain.done(job.ID)
+
+	// Continue the trace of the request that enqueued the job
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, job.TraceContext), "job "+string(job.Type),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", string(job.Type)),
+			attribute.Int("job.retry_count", job.RetryCount),
+		))
+	defer span.End()
 
 	w.log.Info().
 		Str("job_id", job.ID).
@@ -95,16 +197,25 @@ func (w *JobWorker) processNextJob(ctx context.Context) error {
 		Int("retry_count", job.RetryCount).
 		Msg("Processing job")
 
-	var processErr error
-	switch job.Type {
-	case queue.JobTypeSync:
-		processErr = w.handleSyncJob(ctx, job)
-	case queue.JobTypeResync:
-		processErr = w.handleResyncJob(ctx, job)
-	default:
-		processErr = fmt.Errorf("unknown job type: %s", job.Type)
+	stopHeartbeat := w.startHeartbeat(ctx, job.ID)
+	processErr := w.runHandler(ctx, job)
+	stopHeartbeat()
+	tracing.RecordError(span, processErr)
+
+	var panicErr *PanicError
+	if errors.As(processErr, &panicErr) {
+		return w.recordPanic(context.WithoutCancel(ctx), job, panicErr)
 	}
 
+	if processErr != nil && w.drain.aborted() {
+		// Interrupted by shutdown; the job is requeued without counting a retry
+		return nil
+	}
+
+	// Record the outcome even if draining times out meanwhile, so a finished job
+	// is not requeued and run again
+	ctx = context.WithoutCancel(ctx)
+
 	if processErr != nil {
 		w.log.Error().
 			Err(processErr).
@@ -120,9 +231,9 @@ func (w *JobWorker) processNextJob(ctx context.Context) error {
 				Int("max_retries", job.MaxRetries).
 				Msg("Job reached maximum retries, marking as stopped")
 
-			// Update job status to stopped
-			job.Status = queue.JobStatusStopped
-			return w.queue.Fail(job.ID, fmt.Errorf("max retries reached: %w", processErr))
+			failErr := w.queue.Fail(ctx, job.ID, fmt.Errorf("max retries reached: %w", processErr), time.Time{})
+			w.notifyFailed(ctx, job, processErr)
+			return failErr
 		}
 
 		// Calculate next retry time with exponential backoff
@@ -138,14 +249,98 @@ func (w *JobWorker) processNextJob(ctx context.Context) error {
 			Time("next_retry", job.NextRetryAt).
 			Msg("Scheduling job retry")
 
-		return w.queue.Fail(job.ID, processErr)
+		return w.queue.Fail(ctx, job.ID, processErr, job.NextRetryAt)
 	}
 
 	w.log.Info().
 		Str("job_id", job.ID).
 		Str("type", string(job.Type)).
 		Msg("Job completed")
-	return w.queue.Complete(job.ID)
+	return w.queue.Complete(ctx, job.ID)
+}
+
+// startHeartbeat sends heartbeats of the job until the returned function is
+// called, which waits for the last one to finish
+func (w *JobWorker) startHeartbeat(ctx context.Context, jobID string) func() {
+	if w.heartbeatInterval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.queue.Heartbeat(ctx, jobID); err != nil && ctx.Err() == nil {
+					w.log.Warn().Err(err).Str("job_id", jobID).Msg("Failed to record job heartbeat")
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// notifyFailed sends a notification about a job that failed its last retry
+func (w *JobWorker) notifyFailed(ctx context.Context, job *queue.Job, err error) {
+	if w.failures == nil {
+		return
+	}
+
+	// Name the repository of repository jobs
+	var payload struct {
+		Owner string `json:"owner"`
+		Repo  string `json:"repo"`
+	}
+	var repository string
+	if json.Unmarshal(job.Payload, &payload) == nil && payload.Owner != "" && payload.Repo != "" {
+		repository = payload.Owner + "/" + payload.Repo
+	}
+
+	subject := fmt.Sprintf("%s job %s failed", job.Type, job.ID)
+	if repository != "" {
+		subject = fmt.Sprintf("%s job of %s failed", job.Type, repository)
+	}
+	w.failures.Notify(ctx, &models.Notification{
+		Event:      models.NotificationJobFailed,
+		Subject:    subject,
+		Message:    fmt.Sprintf("Job %s of type %s failed after %d retries and was stopped: %v", job.ID, job.Type, job.RetryCount, err),
+		Repository: repository,
+	})
+}
+
+// handle runs the handler of the job's type
+func (w *JobWorker) handle(ctx context.Context, job *queue.Job) error {
+	switch job.Type {
+	case queue.JobTypeSync:
+		return w.handleSyncJob(ctx, job)
+	case queue.JobTypeResync:
+		return w.handleResyncJob(ctx, job)
+	case queue.JobTypeIssues:
+		return w.handleIssuesJob(ctx, job)
+	case queue.JobTypeReport:
+		return w.handleReportJob(ctx, job)
+	case queue.JobTypeDigest:
+		return w.handleDigestJob(ctx, job)
+	case queue.JobTypeMaintenance:
+		return w.handleMaintenanceJob(ctx, job)
+	case queue.JobTypeCleanup:
+		return w.handleCleanupJob(ctx, job)
+	case queue.JobTypeTickets:
+		return w.handleTicketsJob(ctx, job)
+	case queue.JobTypeDiscover:
+		return w.handleDiscoverJob(ctx, job)
+	default:
+		return fmt.Errorf("unknown job type: %s", job.Type)
+	}
 }
 
 func (w *JobWorker) handleSyncJob(ctx context.Context, job *queue.Job) error {
@@ -154,7 +349,13 @@ func (w *JobWorker) handleSyncJob(ctx context.Context, job *queue.Job) error {
 		return fmt.Errorf("failed to unmarshal sync payload: %w", err)
 	}
 
-	return w.service.SyncRepository(ctx, payload.Owner, payload.Repo, time.Time{})
+	var since time.Time
+	if payload.Since != nil {
+		since = *payload.Since
+	}
+	result, err := w.service.SyncRepositoryFromCheckpoint(ctx, payload.Owner, payload.Repo, since, w.syncCheckpoint(ctx, job))
+	w.saveSyncResult(ctx, job, result)
+	return err
 }
 
 func (w *JobWorker) handleResyncJob(ctx context.Context, job *queue.Job) error {
@@ -163,6 +364,228 @@ func (w *JobWorker) handleResyncJob(ctx context.Context, job *queue.Job) error {
 		return fmt.Errorf("failed to unmarshal resync payload: %w", err)
 	}
 
-	since := time.Now().AddDate(0, 0, -7) // Last 7 days
-	return w.service.SyncRepository(ctx, payload.Owner, payload.Repo, since)
+	since := w.service.DefaultSince()
+	if payload.Since != nil {
+		since = *payload.Since
+	}
+	result, err := w.service.SyncRepositoryFromCheckpoint(ctx, payload.Owner, payload.Repo, since, w.syncCheckpoint(ctx, job))
+	w.saveSyncResult(ctx, job, result)
+	return err
+}
+
+// syncCheckpoint resumes a sync job from the checkpoint of a run that was
+// interrupted, taken over after its lease expired or failed and is now being
+// retried, and saves the commit pages it stores as the job's checkpoint
+func (w *JobWorker) syncCheckpoint(ctx context.Context, job *queue.Job) *service.SyncCheckpoint {
+	var saved queue.SyncCheckpoint
+	if len(job.Checkpoint) > 0 {
+		if err := json.Unmarshal(job.Checkpoint, &saved); err != nil {
+			w.log.Warn().Err(err).Str("job_id", job.ID).Msg("Ignoring unreadable job checkpoint")
+			saved = queue.SyncCheckpoint{}
+		}
+	}
+
+	return &service.SyncCheckpoint{
+		Page: saved.Page,
+		Record: func(page int) {
+			data, err := json.Marshal(queue.SyncCheckpoint{Page: page})
+			if err == nil {
+				err = w.queue.SaveCheckpoint(ctx, job.ID, data)
+			}
+			if err != nil {
+				// Losing a checkpoint only costs refetching pages on a resume
+				w.log.Warn().Err(err).Str("job_id", job.ID).Int("page", page).Msg("Failed to save sync checkpoint")
+			}
+		},
+	}
+}
+
+// saveSyncResult records the result of a sync on its job, so it is returned
+// with the job's status. Syncs that never started have no result to save.
+func (w *JobWorker) saveSyncResult(ctx context.Context, job *queue.Job, result *models.SyncResult) {
+	if result == nil {
+		return
+	}
+	data, err := json.Marshal(result)
+	if err == nil {
+		// Saved even when the job was cancelled, so an interrupted run reports its progress
+		err = w.queue.SaveResult(context.WithoutCancel(ctx), job.ID, data)
+	}
+	if err != nil {
+		w.log.Warn().Err(err).Str("job_id", job.ID).Msg("Failed to save job result")
+	}
+}
+
+func (w *JobWorker) handleIssuesJob(ctx context.Context, job *queue.Job) error {
+	var payload queue.SyncPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal issues payload: %w", err)
+	}
+
+	count, err := w.service.SyncIssues(ctx, payload.Owner, payload.Repo)
+	if err != nil {
+		return err
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Str("owner", payload.Owner).
+		Str("repo", payload.Repo).
+		Int("issues_synced", count).
+		Msg("Synced repository issues")
+	return nil
+}
+
+func (w *JobWorker) handleReportJob(ctx context.Context, job *queue.Job) error {
+	var payload queue.ReportPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal report payload: %w", err)
+	}
+
+	report, err := w.service.GenerateMonthlyReport(ctx, payload.Owner+"/"+payload.Repo, payload.Month)
+	if err != nil {
+		return err
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Str("repository", report.Repository).
+		Str("month", report.Month).
+		Int("commits", report.Commits).
+		Msg("Generated monthly report")
+	return nil
+}
+
+func (w *JobWorker) handleDigestJob(ctx context.Context, job *queue.Job) error {
+	var payload queue.DigestPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal digest payload: %w", err)
+	}
+
+	if payload.Owner == "" {
+		generated, err := w.service.GenerateDueDigests(ctx, payload.Period)
+		w.log.Info().
+			Str("job_id", job.ID).
+			Str("period", payload.Period).
+			Int("digests_generated", generated).
+			Msg("Generated scheduled digests")
+		return err
+	}
+
+	start, err := service.ParseDigestPeriod(payload.Period, payload.Start)
+	if err != nil {
+		return err
+	}
+	digest, err := w.service.GenerateDigest(ctx, payload.Owner+"/"+payload.Repo, payload.Period, start)
+	if err != nil {
+		return err
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Str("repository", digest.Repository).
+		Str("period", digest.Period).
+		Str("start", digest.Start).
+		Int("commits", digest.Commits).
+		Msg("Generated digest")
+	return nil
+}
+
+func (w *JobWorker) handleMaintenanceJob(ctx context.Context, job *queue.Job) error {
+	var payload queue.MaintenancePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal maintenance payload: %w", err)
+	}
+
+	return w.service.RunMaintenance(ctx, payload.Tasks)
+}
+
+func (w *JobWorker) handleCleanupJob(ctx context.Context, job *queue.Job) error {
+	purged, err := w.service.PurgeDeletedRepositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Int("repositories_purged", len(purged)).
+		Msg("Purged removed repositories past retention")
+	return nil
+}
+
+func (w *JobWorker) handleTicketsJob(ctx context.Context, job *queue.Job) error {
+	refreshed, err := w.service.RefreshTickets(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Int("tickets_refreshed", refreshed).
+		Msg("Refreshed ticket statuses")
+	return nil
+}
+
+// handleDiscoverJob adds the new repositories of watched users that match
+// their filters to monitoring and queues their initial syncs over the default
+// history window. A user that cannot be listed doesn't hold up the others.
+func (w *JobWorker) handleDiscoverJob(ctx context.Context, job *queue.Job) error {
+	users, err := w.service.Monitor().GetWatchedUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get watched users: %w", err)
+	}
+
+	var errs []error
+	added := 0
+	for _, user := range users {
+		_, names, err := w.service.DiscoverUserRepositories(ctx, user)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", user.Username, err))
+			continue
+		}
+
+		var enrolled []string
+		for _, name := range names {
+			if err := w.enrollDiscovered(ctx, name); err != nil {
+				errs = append(errs, fmt.Errorf("repository %s: %w", name, err))
+				continue
+			}
+			enrolled = append(enrolled, name)
+		}
+		if err := w.service.RecordDiscoveredRepositories(ctx, user.Username, enrolled); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", user.Username, err))
+		}
+		added += len(enrolled)
+	}
+
+	w.log.Info().
+		Str("job_id", job.ID).
+		Int("users", len(users)).
+		Int("repositories_added", added).
+		Msg("Discovered repositories of watched users")
+	return errors.Join(errs...)
+}
+
+// enrollDiscovered adds a discovered repository to monitoring and queues its
+// initial sync
+func (w *JobWorker) enrollDiscovered(ctx context.Context, fullName string) error {
+	if err := w.service.Monitor().AddMonitoredRepository(ctx, fullName, models.ProviderGitHub, w.syncInterval); err != nil {
+		return fmt.Errorf("failed to add repository to monitoring: %w", err)
+	}
+
+	owner, repo, _ := strings.Cut(fullName, "/")
+	payload := queue.SyncPayload{Owner: owner, Repo: repo}
+	if since := w.service.DefaultSince(); !since.IsZero() {
+		payload.Since = &since
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync payload: %w", err)
+	}
+	return w.queue.Enqueue(ctx, &queue.Job{
+		Type:      queue.JobTypeSync,
+		Payload:   encoded,
+		DedupeKey: queue.SyncDedupeKey(owner, repo),
+		Priority:  queue.PriorityLow,
+	})
 }
@@ -0,0 +1,313 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/queue"
+
+	"github.com/rs/zerolog"
+)
+
+// memoryQueue is a queue holding jobs in memory that records failed jobs
+type memoryQueue struct {
+	queue.Queue
+	mu       sync.Mutex
+	pending  []*queue.Job
+	failed   chan string
+	panicked chan error
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, job *queue.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, job)
+	return nil
+}
+
+func (q *memoryQueue) Dequeue(ctx context.Context) (*queue.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil, nil
+	}
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+	return job, nil
+}
+
+func (q *memoryQueue) Fail(ctx context.Context, jobID string, err error, retryAt time.Time) error {
+	q.failed <- jobID
+	return nil
+}
+
+func (q *memoryQueue) RecordPanic(ctx context.Context, jobID string, err error) (bool, error) {
+	q.panicked <- err
+	return false, nil
+}
+
+// manualNotifier signals when told to
+type manualNotifier struct {
+	mu   sync.Mutex
+	wake chan struct{}
+}
+
+func (n *manualNotifier) Wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.wake
+}
+
+func (n *manualNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	close(n.wake)
+	n.wake = make(chan struct{})
+}
+
+func TestJobWorkerWakesOnNotification(t *testing.T) {
+	q := &memoryQueue{failed: make(chan string, 2)}
+	notifier := &manualNotifier{wake: make(chan struct{})}
+
+	w := NewJobWorker(q, nil, zerolog.Nop())
+	w.SetPollInterval(time.Hour)
+	w.SetNotifier(notifier)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Start(ctx)
+
+	// Let the worker find the queue empty and go idle
+	time.Sleep(20 * time.Millisecond)
+
+	// Jobs of an unknown type fail at once, which shows they were dequeued
+	q.Enqueue(ctx, &queue.Job{ID: "job-1", Type: "unknown", MaxRetries: 1})
+	q.Enqueue(ctx, &queue.Job{ID: "job-2", Type: "unknown", MaxRetries: 1})
+	notifier.notify()
+
+	for _, want := range []string{"job-1", "job-2"} {
+		select {
+		case got := <-q.failed:
+			if got != want {
+				t.Errorf("processed %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not processed after the notification", want)
+		}
+	}
+
+	w.Shutdown(context.Background())
+}
+
+func TestJobWorkerSurvivesPanickingHandler(t *testing.T) {
+	q := &memoryQueue{failed: make(chan string, 1), panicked: make(chan error, 1)}
+
+	// Without a service, the resync handler panics on a nil pointer
+	w := NewJobWorker(q, nil, zerolog.Nop())
+	w.SetPollInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Enqueue(ctx, &queue.Job{ID: "job-1", Type: queue.JobTypeResync, Payload: json.RawMessage(`{}`)})
+	q.Enqueue(ctx, &queue.Job{ID: "job-2", Type: "unknown"})
+	go w.Start(ctx)
+
+	select {
+	case err := <-q.panicked:
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) || len(panicErr.Stack) == 0 {
+			t.Errorf("recorded %v, want a panic with its stack", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic was not recorded")
+	}
+
+	// The worker goes on with the next job
+	select {
+	case got := <-q.failed:
+		if got != "job-2" {
+			t.Errorf("processed %s, want job-2", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("worker stopped processing jobs after the panic")
+	}
+
+	w.Shutdown(context.Background())
+}
+
+// checkpointQueue records saved checkpoints
+type checkpointQueue struct {
+	queue.Queue
+	saved map[string]string
+}
+
+func (q *checkpointQueue) SaveCheckpoint(ctx context.Context, jobID string, checkpoint json.RawMessage) error {
+	q.saved[jobID] = string(checkpoint)
+	return nil
+}
+
+func TestSyncCheckpoint(t *testing.T) {
+	q := &checkpointQueue{saved: make(map[string]string)}
+	w := NewJobWorker(q, nil, zerolog.Nop())
+
+	checkpoint := w.syncCheckpoint(context.Background(), &queue.Job{ID: "job-1", Checkpoint: json.RawMessage(`{"page": 3}`)})
+	if checkpoint.Page != 3 {
+		t.Errorf("resume page = %d, want 3", checkpoint.Page)
+	}
+	checkpoint.Record(4)
+	if got := q.saved["job-1"]; got != `{"page":4}` {
+		t.Errorf("saved checkpoint = %s, want {\"page\":4}", got)
+	}
+
+	// A job without a usable checkpoint starts at the newest commits
+	for _, saved := range []string{"", "not json"} {
+		if checkpoint := w.syncCheckpoint(context.Background(), &queue.Job{ID: "job-2", Checkpoint: json.RawMessage(saved)}); checkpoint.Page != 0 {
+			t.Errorf("checkpoint %q: resume page = %d, want 0", saved, checkpoint.Page)
+		}
+	}
+}
+
+// resultQueue records saved job results
+type resultQueue struct {
+	queue.Queue
+	saved map[string]string
+}
+
+func (q *resultQueue) SaveResult(ctx context.Context, jobID string, result json.RawMessage) error {
+	q.saved[jobID] = string(result)
+	return nil
+}
+
+func TestSaveSyncResult(t *testing.T) {
+	q := &resultQueue{saved: make(map[string]string)}
+	w := NewJobWorker(q, nil, zerolog.Nop())
+
+	remaining := 4200
+	w.saveSyncResult(context.Background(), &queue.Job{ID: "job-1"}, &models.SyncResult{
+		SyncRunID:          7,
+		CommitsFetched:     150,
+		NewCommits:         120,
+		ExistingCommits:    30,
+		Pages:              2,
+		DurationMS:         1500,
+		RateLimitRemaining: &remaining,
+	})
+	want := `{"sync_run_id":7,"commits_fetched":150,"new_commits":120,"existing_commits":30,"pages":2,"duration_ms":1500,"rate_limit_remaining":4200}`
+	if got := q.saved["job-1"]; got != want {
+		t.Errorf("saved result = %s, want %s", got, want)
+	}
+
+	// A sync that never started has nothing to report
+	w.saveSyncResult(context.Background(), &queue.Job{ID: "job-2"}, nil)
+	if _, ok := q.saved["job-2"]; ok {
+		t.Error("saved a result for a sync that never started")
+	}
+}
+
+// heartbeatQueue counts heartbeats per job
+type heartbeatQueue struct {
+	queue.Queue
+	mu    sync.Mutex
+	beats map[string]int
+}
+
+func (q *heartbeatQueue) Heartbeat(ctx context.Context, jobID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.beats[jobID]++
+	return nil
+}
+
+func (q *heartbeatQueue) count(jobID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.beats[jobID]
+}
+
+func TestHeartbeat(t *testing.T) {
+	q := &heartbeatQueue{beats: make(map[string]int)}
+	w := NewJobWorker(q, nil, zerolog.Nop())
+	w.SetHeartbeatInterval(5 * time.Millisecond)
+
+	stop := w.startHeartbeat(context.Background(), "job-1")
+	time.Sleep(30 * time.Millisecond)
+	stop()
+	beats := q.count("job-1")
+	if beats == 0 {
+		t.Fatal("no heartbeats sent while the job ran")
+	}
+
+	// No heartbeats follow once the job finished
+	time.Sleep(20 * time.Millisecond)
+	if got := q.count("job-1"); got != beats {
+		t.Errorf("heartbeats after stop = %d, want %d", got, beats)
+	}
+
+	w.SetHeartbeatInterval(0)
+	w.startHeartbeat(context.Background(), "job-2")()
+	if got := q.count("job-2"); got != 0 {
+		t.Errorf("heartbeats without an interval = %d, want 0", got)
+	}
+}
+
+// retryQueue records the retry time of each failed run
+type retryQueue struct {
+	queue.Queue
+	retries []time.Time
+}
+
+func (q *retryQueue) Fail(ctx context.Context, jobID string, err error, retryAt time.Time) error {
+	q.retries = append(q.retries, retryAt)
+	return nil
+}
+
+// failureRecorder records the notifications it is sent
+type failureRecorder struct {
+	notifications []*models.Notification
+}
+
+func (n *failureRecorder) Notify(ctx context.Context, notification *models.Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestJobWorkerRetriesUntilExhausted(t *testing.T) {
+	q := &retryQueue{}
+	failures := &failureRecorder{}
+	w := NewJobWorker(q, nil, zerolog.Nop())
+	w.SetFailureNotifier(failures)
+
+	// Each run is the job as the queue hands it out again, with the failed
+	// runs so far counted
+	const maxRetries = 2
+	for run := 0; run <= maxRetries; run++ {
+		start := time.Now()
+		job := &queue.Job{ID: "job-1", Type: "unknown", RetryCount: run, MaxRetries: maxRetries}
+		if err := w.processJob(context.Background(), job); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+
+		retryAt := q.retries[run]
+		if run < maxRetries {
+			if !retryAt.After(start) {
+				t.Errorf("run %d: retry at %v, want a retry after a backoff", run, retryAt)
+			}
+			if len(failures.notifications) != 0 {
+				t.Errorf("run %d: notified before the last retry", run)
+			}
+		} else if !retryAt.IsZero() {
+			t.Errorf("last run: retry at %v, want no retry", retryAt)
+		}
+	}
+
+	if len(failures.notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(failures.notifications))
+	}
+	if n := failures.notifications[0]; n.Event != models.NotificationJobFailed {
+		t.Errorf("notified %s, want %s", n.Event, models.NotificationJobFailed)
+	}
+}
@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github-service/internal/models"
+	"github-service/internal/queue"
+
+	"github.com/rs/zerolog"
+)
+
+// MaintenanceScheduler periodically enqueues database maintenance jobs so that
+// planner statistics and the hot commit indexes stay healthy as tables grow,
+// cleanup jobs that purge removed repositories past their retention, jobs
+// refreshing the status of referenced tickets, jobs discovering the new
+// repositories of watched users, and jobs digesting the periods that ended
+type MaintenanceScheduler struct {
+	queue             queue.Queue
+	analyzeInterval   time.Duration
+	reindexInterval   time.Duration
+	cleanupInterval   time.Duration
+	ticketInterval    time.Duration
+	discoveryInterval time.Duration
+	digestPeriods     []string
+	log               zerolog.Logger
+}
+
+// digestCheckInterval is how often the scheduler enqueues digest jobs. Each
+// job only digests the repositories whose last complete period hasn't been
+// digested, so most runs generate nothing.
+const digestCheckInterval = time.Hour
+
+// NewMaintenanceScheduler creates a maintenance scheduler. A zero interval
+// disables the corresponding task.
+func NewMaintenanceScheduler(q queue.Queue, analyzeInterval, reindexInterval, cleanupInterval time.Duration, log zerolog.Logger) *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		queue:           q,
+		analyzeInterval: analyzeInterval,
+		reindexInterval: reindexInterval,
+		cleanupInterval: cleanupInterval,
+		log:             log,
+	}
+}
+
+// SetTicketRefreshInterval enables enqueuing a job refreshing ticket statuses
+// at the given interval. It must be called before Start.
+func (s *MaintenanceScheduler) SetTicketRefreshInterval(interval time.Duration) {
+	s.ticketInterval = interval
+}
+
+// SetDiscoveryInterval enables enqueuing a job discovering the repositories of
+// watched users at the given interval. It must be called before Start.
+func (s *MaintenanceScheduler) SetDiscoveryInterval(interval time.Duration) {
+	s.discoveryInterval = interval
+}
+
+// SetDigestPeriods enables enqueuing jobs digesting the last complete weekly or
+// monthly period of every monitored repository. It must be called before Start.
+func (s *MaintenanceScheduler) SetDigestPeriods(periods []string) {
+	s.digestPeriods = periods
+}
+
+// Start enqueues maintenance jobs on their intervals until ctx is cancelled
+func (s *MaintenanceScheduler) Start(ctx context.Context) {
+	analyze := newOptionalTicker(s.analyzeInterval)
+	defer analyze.stop()
+	reindex := newOptionalTicker(s.reindexInterval)
+	defer reindex.stop()
+	cleanup := newOptionalTicker(s.cleanupInterval)
+	defer cleanup.stop()
+	tickets := newOptionalTicker(s.ticketInterval)
+	defer tickets.stop()
+	discovery := newOptionalTicker(s.discoveryInterval)
+	defer discovery.stop()
+	var digestInterval time.Duration
+	if len(s.digestPeriods) > 0 {
+		digestInterval = digestCheckInterval
+	}
+	digests := newOptionalTicker(digestInterval)
+	defer digests.stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-analyze.c:
+			s.enqueue(ctx, models.MaintenanceAnalyze)
+		case <-reindex.c:
+			// Reindexing leaves stale statistics behind, so analyze afterwards
+			s.enqueue(ctx, models.MaintenanceReindex, models.MaintenanceAnalyze)
+		case <-cleanup.c:
+			s.enqueueCleanup(ctx)
+		case <-tickets.c:
+			s.enqueueTicketRefresh(ctx)
+		case <-discovery.c:
+			s.enqueueDiscovery(ctx)
+		case <-digests.c:
+			for _, period := range s.digestPeriods {
+				s.enqueueDigests(ctx, period)
+			}
+		}
+	}
+}
+
+// enqueueCleanup schedules a job purging removed repositories past their retention
+func (s *MaintenanceScheduler) enqueueCleanup(ctx context.Context) {
+	job := &queue.Job{
+		Type:    queue.JobTypeCleanup,
+		Payload: json.RawMessage(`{}`),
+		// A pending cleanup covers everything a second one would purge
+		DedupeKey:  string(queue.JobTypeCleanup),
+		MaxRetries: 1,
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error().Err(err).Msg("Failed to enqueue cleanup job")
+		return
+	}
+	if !job.Existing {
+		s.log.Info().Str("job_id", job.ID).Msg("Scheduled cleanup job")
+	}
+}
+
+// enqueueTicketRefresh schedules a job refreshing the status of referenced tickets
+func (s *MaintenanceScheduler) enqueueTicketRefresh(ctx context.Context) {
+	job := &queue.Job{
+		Type:    queue.JobTypeTickets,
+		Payload: json.RawMessage(`{}`),
+		// A pending refresh covers the tickets a second one would look up
+		DedupeKey:  string(queue.JobTypeTickets),
+		MaxRetries: 1,
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error().Err(err).Msg("Failed to enqueue ticket refresh job")
+		return
+	}
+	if !job.Existing {
+		s.log.Info().Str("job_id", job.ID).Msg("Scheduled ticket refresh job")
+	}
+}
+
+// enqueueDiscovery schedules a job discovering the repositories of watched users
+func (s *MaintenanceScheduler) enqueueDiscovery(ctx context.Context) {
+	job := &queue.Job{
+		Type:    queue.JobTypeDiscover,
+		Payload: json.RawMessage(`{}`),
+		// A pending discovery covers the users a second one would list
+		DedupeKey:  string(queue.JobTypeDiscover),
+		MaxRetries: 1,
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error().Err(err).Msg("Failed to enqueue repository discovery job")
+		return
+	}
+	if !job.Existing {
+		s.log.Info().Str("job_id", job.ID).Msg("Scheduled repository discovery job")
+	}
+}
+
+// enqueueDigests schedules a job digesting the last complete period of the
+// monitored repositories
+func (s *MaintenanceScheduler) enqueueDigests(ctx context.Context, period string) {
+	payload, err := json.Marshal(queue.DigestPayload{Period: period})
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to marshal digest payload")
+		return
+	}
+
+	job := &queue.Job{
+		Type:    queue.JobTypeDigest,
+		Payload: payload,
+		// A pending run covers the repositories a second one would digest
+		DedupeKey:  queue.DigestDedupeKey("", "", period, ""),
+		MaxRetries: 1,
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error().Err(err).Str("period", period).Msg("Failed to enqueue digest job")
+		return
+	}
+	if !job.Existing {
+		s.log.Debug().Str("job_id", job.ID).Str("period", period).Msg("Scheduled digest job")
+	}
+}
+
+// enqueue schedules a maintenance job running the given tasks
+func (s *MaintenanceScheduler) enqueue(ctx context.Context, tasks ...string) {
+	payload, err := json.Marshal(queue.MaintenancePayload{Tasks: tasks})
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to marshal maintenance payload")
+		return
+	}
+
+	job := &queue.Job{
+		Type:    queue.JobTypeMaintenance,
+		Payload: payload,
+		// Failed steps are recorded in the history; the next interval tries again
+		MaxRetries: 1,
+	}
+	if err := s.queue.Enqueue(ctx, job); err != nil {
+		s.log.Error().Err(err).Strs("tasks", tasks).Msg("Failed to enqueue maintenance job")
+		return
+	}
+	s.log.Info().Str("job_id", job.ID).Strs("tasks", tasks).Msg("Scheduled maintenance job")
+}
+
+// optionalTicker is a ticker whose channel never fires when its interval is zero
+type optionalTicker struct {
+	c      <-chan time.Time
+	ticker *time.Ticker
+}
+
+func newOptionalTicker(interval time.Duration) optionalTicker {
+	if interval <= 0 {
+		return optionalTicker{}
+	}
+	t := time.NewTicker(interval)
+	return optionalTicker{c: t.C, ticker: t}
+}
+
+func (t optionalTicker) stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github-service/internal/queue"
+)
+
+// PanicError is the error of a job whose handler panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// runHandler runs the job's handler, turning a panic into a PanicError so it
+// fails the job instead of killing the worker
+func (w *JobWorker) runHandler(ctx context.Context, job *queue.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return w.handle(ctx, job)
+}
+
+// recordPanic fails a job whose handler panicked. Jobs with a payload that
+// keeps crashing are quarantined by the queue, so they can't take down the
+// workers one after the other
+func (w *JobWorker) recordPanic(ctx context.Context, job *queue.Job, panicErr *PanicError) error {
+	w.log.Error().
+		Str("job_id", job.ID).
+		Str("type", string(job.Type)).
+		Interface("panic", panicErr.Value).
+		Str("stack", string(panicErr.Stack)).
+		Msg("Job handler panicked")
+
+	quarantined, err := w.queue.RecordPanic(ctx, job.ID, panicErr)
+	if err != nil {
+		return fmt.Errorf("failed to record job panic: %w", err)
+	}
+	if quarantined {
+		w.log.Warn().
+			Str("job_id", job.ID).
+			Str("type", string(job.Type)).
+			Msg("Job payload keeps panicking, quarantined its jobs")
+	}
+	return nil
+}
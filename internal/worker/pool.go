@@ -2,160 +2,87 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"github-service/internal/queue"
 	"github-service/internal/service"
+
+	"github.com/rs/zerolog"
 )
 
-// Pool represents a worker pool for processing jobs
+// defaultPoolWorkers is the number of workers of a pool unless configured
+const defaultPoolWorkers = 5
+
+// Pool processes jobs from the queue with several workers at once
 type Pool struct {
-	queue    queue.Queue
-	service  *service.Service
-	workers  int
-	stopChan chan struct{}
-	wg       sync.WaitGroup
+	worker  *JobWorker
+	workers int
+	started sync.Once
 }
 
-// NewPool creates a new worker pool
-func NewPool(queue queue.Queue, service *service.Service, workers int) *Pool {
+// NewPool creates a pool of workers processing jobs from queue
+func NewPool(queue queue.Queue, service *service.Service, log zerolog.Logger, workers int) *Pool {
 	if workers <= 0 {
-		workers = 5 // default number of workers
+		workers = defaultPoolWorkers
 	}
 	return &Pool{
-		queue:    queue,
-		service:  service,
-		workers:  workers,
-		stopChan: make(chan struct{}),
+		worker:  NewJobWorker(queue, service, log),
+		workers: workers,
 	}
 }
 
-// Start starts the worker pool
-func (p *Pool) Start(ctx context.Context) {
-	for i := 0; i < p.workers; i++ {
-		p.wg.Add(1)
-		go p.worker(ctx, i)
-	}
+// SetPollInterval sets how long each worker waits between dequeues. It must be
+// called before Start.
+func (p *Pool) SetPollInterval(d time.Duration) {
+	p.worker.SetPollInterval(d)
 }
 
-// Stop stops the worker pool
-func (p *Pool) Stop() {
-	close(p.stopChan)
-	p.wg.Wait()
+// SetSyncInterval sets the sync interval of the repositories that discovery
+// jobs add to monitoring. It must be called before Start.
+func (p *Pool) SetSyncInterval(d time.Duration) {
+	p.worker.SetSyncInterval(d)
 }
 
-func (p *Pool) worker(ctx context.Context, id int) {
-	defer p.wg.Done()
-	log.Printf("Worker %d started", id)
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("Worker %d stopping due to context cancellation", id)
-			return
-		case <-p.stopChan:
-			log.Printf("Worker %d stopping due to pool shutdown", id)
-			return
-		default:
-			if err := p.processNextJob(ctx); err != nil {
-				log.Printf("Worker %d error processing job: %v", id, err)
-				// Add a small delay before trying again
-				time.Sleep(time.Second)
-			}
-		}
-	}
+// SetHeartbeatInterval makes the workers record every d that their jobs are
+// still alive. It must be called before Start.
+func (p *Pool) SetHeartbeatInterval(d time.Duration) {
+	p.worker.SetHeartbeatInterval(d)
 }
 
-func (p *Pool) processNextJob(ctx context.Context) error {
-	// Get next job from queue
-	job, err := p.queue.Dequeue()
-	if err != nil {
-		return fmt.Errorf("error dequeuing job: %w", err)
-	}
-	if job == nil {
-		// No jobs available, wait a bit
-		time.Sleep(time.Second)
-		return nil
-	}
-
-	log.Printf("Processing job %s of type %s", job.ID, job.Type)
-
-	// Process the job based on its type
-	var processErr error
-	switch job.Type {
-	case queue.JobTypeSync:
-		processErr = p.processSyncJob(ctx, job)
-	case queue.JobTypeResync:
-		processErr = p.processResyncJob(ctx, job)
-	case queue.JobTypeCleanup:
-		processErr = p.processCleanupJob(ctx, job)
-	default:
-		processErr = fmt.Errorf("unknown job type: %s", job.Type)
-	}
-
-	if processErr != nil {
-		if err := p.queue.Fail(job.ID, processErr); err != nil {
-			log.Printf("Error marking job %s as failed: %v", job.ID, err)
-		}
-		return processErr
-	}
-
-	if err := p.queue.Complete(job.ID); err != nil {
-		return fmt.Errorf("error marking job as complete: %w", err)
-	}
-
-	return nil
+// SetFailureNotifier sends a notification through n when a job fails its last
+// retry. It must be called before Start.
+func (p *Pool) SetFailureNotifier(n service.Notifier) {
+	p.worker.SetFailureNotifier(n)
 }
 
-func (p *Pool) processSyncJob(ctx context.Context, job *queue.Job) error {
-	var payload queue.SyncPayload
-	if err := json.Unmarshal(job.Payload, &payload); err != nil {
-		return fmt.Errorf("error unmarshaling sync job payload: %w", err)
-	}
-
-	// Process repository sync with retries
-	maxRetries := 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err := p.service.SyncRepository(ctx, payload.Owner, payload.Repo, time.Time{})
-		if err == nil {
-			return nil
-		}
-
-		if attempt == maxRetries {
-			return fmt.Errorf("failed to sync repository after %d attempts: %w", maxRetries, err)
-		}
-
-		// Exponential backoff
-		backoffDuration := time.Duration(attempt*attempt) * time.Second
-		log.Printf("Retry attempt %d for repository %s/%s after %v: %v",
-			attempt, payload.Owner, payload.Repo, backoffDuration, err)
+// SetNotifier makes the workers dequeue as soon as n signals a new job. It must
+// be called before Start.
+func (p *Pool) SetNotifier(n Notifier) {
+	p.worker.SetNotifier(n)
+}
 
-		select {
-		case <-time.After(backoffDuration):
-			continue
-		case <-ctx.Done():
-			return ctx.Err()
+// Start starts the workers of the pool. Cancelling ctx stops dequeuing; jobs
+// already running are left to finish, see Shutdown.
+func (p *Pool) Start(ctx context.Context) {
+	p.started.Do(func() {
+		for i := 0; i < p.workers; i++ {
+			go func() {
+				if err := p.worker.Start(ctx); err != nil {
+					p.worker.log.Error().Err(err).Msg("Job worker error")
+				}
+			}()
 		}
-	}
-
-	return nil
+	})
 }
 
-func (p *Pool) processResyncJob(ctx context.Context, job *queue.Job) error {
-	var payload queue.SyncPayload
-	if err := json.Unmarshal(job.Payload, &payload); err != nil {
-		return fmt.Errorf("error unmarshaling resync job payload: %w", err)
-	}
-
-	since := time.Now().AddDate(0, 0, -7) // Last 7 days
-	return p.service.SyncRepository(ctx, payload.Owner, payload.Repo, since)
+// Stop stops the pool and waits for running jobs to finish
+func (p *Pool) Stop() {
+	p.Shutdown(context.Background())
 }
 
-func (p *Pool) processCleanupJob(ctx context.Context, job *queue.Job) error {
-	// TODO: Implement cleanup logic
-	return nil
+// Shutdown stops the workers from dequeuing and waits for running jobs to
+// finish. If ctx expires first, those jobs are cancelled and returned to the queue.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	return p.worker.Shutdown(ctx)
 }
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/queue"
+
+	"github.com/rs/zerolog"
+)
+
+// Reaper periodically returns jobs stuck in the running state, e.g. because
+// their worker crashed mid-sync, to the queue. Workers send heartbeats while a
+// job runs, so only jobs without a live worker are reaped.
+type Reaper struct {
+	queue    queue.Queue
+	timeout  time.Duration
+	interval time.Duration
+	log      zerolog.Logger
+}
+
+// NewReaper creates a reaper requeuing running jobs that have not been updated
+// for timeout, checking every interval
+func NewReaper(q queue.Queue, timeout, interval time.Duration, log zerolog.Logger) *Reaper {
+	return &Reaper{queue: q, timeout: timeout, interval: interval, log: log}
+}
+
+// Start reaps stuck jobs on the interval until ctx is cancelled
+func (r *Reaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+// reap requeues the jobs that are stuck now
+func (r *Reaper) reap(ctx context.Context) {
+	reaped, err := r.queue.ReapStuckJobs(ctx, r.timeout)
+	if err != nil {
+		r.log.Error().Err(err).Msg("Failed to reap stuck jobs")
+		return
+	}
+	if reaped > 0 {
+		r.log.Warn().Int64("count", reaped).Dur("timeout", r.timeout).Msg("Requeued stuck jobs")
+	}
+}
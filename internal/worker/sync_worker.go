@@ -5,34 +5,71 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
+	"github-service/internal/errors"
+	"github-service/internal/models"
 	"github-service/internal/service"
 )
 
+// defaultSyncConcurrency is how many repositories a round of scheduled syncs
+// syncs at once unless set otherwise
+const defaultSyncConcurrency = 4
+
+// SyncWorkerState is the lifecycle state of the background scheduler of a SyncWorker
+type SyncWorkerState string
+
+const (
+	SyncWorkerStopped SyncWorkerState = "stopped"
+	SyncWorkerRunning SyncWorkerState = "running"
+	SyncWorkerPaused  SyncWorkerState = "paused"
+)
+
 // SyncWorker handles background synchronization of repositories
 type SyncWorker struct {
 	service      *service.Service
 	syncInterval time.Duration
-	defaultAge   time.Duration
-	stop         chan struct{}
+
+	// sync runs one round of scheduled syncs; syncAll unless replaced in tests
+	sync func(ctx context.Context)
+	// syncRepo syncs one repository of a round; syncRepository unless replaced in tests
+	syncRepo    func(ctx context.Context, repo models.MonitoredRepository) error
+	concurrency int
+
+	mu     sync.Mutex
+	state  SyncWorkerState
+	parent context.Context // Context passed to Start, which Resume restarts the loop under
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // NewSyncWorker creates a new sync worker
-func NewSyncWorker(service *service.Service, syncInterval, defaultAge time.Duration) *SyncWorker {
+func NewSyncWorker(service *service.Service, syncInterval time.Duration) *SyncWorker {
 	if syncInterval <= 0 {
 		syncInterval = time.Hour // default to 1 hour if not set or invalid
 	}
-	return &SyncWorker{
+	w := &SyncWorker{
 		service:      service,
 		syncInterval: syncInterval,
-		defaultAge:   defaultAge,
-		stop:         make(chan struct{}),
+		state:        SyncWorkerStopped,
+		concurrency:  defaultSyncConcurrency,
 	}
+	w.sync = w.syncAll
+	w.syncRepo = w.syncRepository
+	return w
 }
 
-// AddRepository adds a repository to be monitored
-func (w *SyncWorker) AddRepository(ctx context.Context, owner, name string) error {
+// SetConcurrency sets how many repositories a round of scheduled syncs syncs at
+// once, so a slow repository doesn't hold up the others. Values below 1 sync
+// one at a time.
+func (w *SyncWorker) SetConcurrency(n int) {
+	w.concurrency = max(n, 1)
+}
+
+// AddRepository adds a repository on a provider to be monitored, syncing its
+// commits made since the given time. The zero time syncs its full history.
+func (w *SyncWorker) AddRepository(ctx context.Context, provider, owner, name string, since time.Time) error {
 	fullName := owner + "/" + name
 
 	// Check if repository is already being monitored
@@ -41,22 +78,21 @@ func (w *SyncWorker) AddRepository(ctx context.Context, owner, name string) erro
 	}
 
 	// Add to database first
-	if err := w.service.DB().AddMonitoredRepository(ctx, fullName, w.syncInterval); err != nil {
+	if err := w.service.Monitor().AddMonitoredRepository(ctx, fullName, provider, w.syncInterval); err != nil {
 		return fmt.Errorf("failed to add repository to monitoring: %w", err)
 	}
 
 	// Perform initial sync with rate limit awareness
-	since := time.Now().Add(-w.defaultAge)
 	err := w.service.SyncRepository(ctx, owner, name, since)
 	if err != nil {
 		// If sync fails, remove from monitoring
-		if removeErr := w.service.DB().RemoveMonitoredRepository(ctx, fullName); removeErr != nil {
+		if removeErr := w.service.Monitor().RemoveMonitoredRepository(ctx, fullName); removeErr != nil {
 			log.Printf("Failed to remove repository after sync failure: %v", removeErr)
 		}
 
 		// Check if it's a rate limit error
-		if strings.Contains(strings.ToLower(err.Error()), "rate limit") {
-			return fmt.Errorf("github rate limit exceeded, please try again later: %w", err)
+		if errors.Is(err, errors.ErrRateLimit) {
+			return fmt.Errorf("rate limit exceeded, please try again later: %w", err)
 		}
 
 		// Return detailed error
@@ -64,78 +100,231 @@ func (w *SyncWorker) AddRepository(ctx context.Context, owner, name string) erro
 	}
 
 	// Update last sync time
-	if err := w.service.DB().UpdateMonitoredRepositorySync(ctx, fullName, time.Now().UTC()); err != nil {
+	if err := w.service.Monitor().UpdateMonitoredRepositorySync(ctx, fullName, time.Now().UTC()); err != nil {
 		log.Printf("Failed to update last sync time: %v", err)
 	}
 
 	return nil
 }
 
-// Start begins the background sync process
-func (w *SyncWorker) Start(ctx context.Context) {
+// EnrollRepository adds a repository on a provider to be monitored without
+// syncing it, for callers that schedule its initial sync as a job
+func (w *SyncWorker) EnrollRepository(ctx context.Context, provider, owner, name string) error {
+	fullName := owner + "/" + name
+	if err := w.service.Monitor().AddMonitoredRepository(ctx, fullName, provider, w.syncInterval); err != nil {
+		return fmt.Errorf("failed to add repository to monitoring: %w", err)
+	}
+	return nil
+}
+
+// Start begins the background sync process, which runs until ctx is cancelled
+// or Stop is called. A stopped worker can be started again.
+func (w *SyncWorker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.currentState() != SyncWorkerStopped {
+		return fmt.Errorf("sync worker is already %s", w.state)
+	}
+	// Reap a loop that exited because its previous Start context was cancelled
+	w.halt()
+	w.parent = ctx
+	w.launch()
+	return nil
+}
+
+// Pause stops scheduling syncs until Resume is called, cancelling the syncs in
+// progress. It returns once the scheduler has stopped.
+func (w *SyncWorker) Pause() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state := w.currentState(); state != SyncWorkerRunning {
+		return fmt.Errorf("sync worker is %s", state)
+	}
+	w.halt()
+	w.state = SyncWorkerPaused
+	return nil
+}
+
+// Resume restarts a paused scheduler, beginning with a round of syncs
+func (w *SyncWorker) Resume() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if state := w.currentState(); state != SyncWorkerPaused {
+		return fmt.Errorf("sync worker is %s", state)
+	}
+	w.launch()
+	return nil
+}
+
+// Stop stops the background sync process and waits for it to exit. Stopping a
+// worker that isn't running is a no-op.
+func (w *SyncWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.halt()
+	w.state = SyncWorkerStopped
+}
+
+// State returns the lifecycle state of the background scheduler
+func (w *SyncWorker) State() SyncWorkerState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.currentState()
+}
+
+// currentState returns the state, treating a scheduler whose Start context has
+// been cancelled as stopped. The caller must hold w.mu.
+func (w *SyncWorker) currentState() SyncWorkerState {
+	if w.state != SyncWorkerStopped && w.parent.Err() != nil {
+		return SyncWorkerStopped
+	}
+	return w.state
+}
+
+// launch starts the scheduling loop under the Start context. The caller must hold w.mu.
+func (w *SyncWorker) launch() {
+	ctx, cancel := context.WithCancel(w.parent)
+	done := make(chan struct{})
+	w.cancel, w.done = cancel, done
+	w.state = SyncWorkerRunning
+
+	go func() {
+		defer close(done)
+		w.run(ctx)
+	}()
+}
+
+// halt cancels the scheduling loop and waits for it to exit. The caller must hold w.mu.
+func (w *SyncWorker) halt() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	w.cancel, w.done = nil, nil
+}
+
+// run syncs all monitored repositories on every interval until ctx is cancelled
+func (w *SyncWorker) run(ctx context.Context) {
 	ticker := time.NewTicker(w.syncInterval)
 	defer ticker.Stop()
 
 	// Initial sync
-	w.syncAll(ctx)
+	w.sync(ctx)
 
 	for {
 		select {
 		case <-ticker.C:
-			w.syncAll(ctx)
+			w.sync(ctx)
 		case <-ctx.Done():
 			return
-		case <-w.stop:
-			return
 		}
 	}
 }
 
-// Stop stops the background sync process
-func (w *SyncWorker) Stop() {
-	close(w.stop)
-}
-
 // syncAll synchronizes all monitored repositories
 func (w *SyncWorker) syncAll(ctx context.Context) {
-	repos, err := w.service.DB().GetMonitoredRepositories(ctx)
+	repos, err := w.service.Monitor().GetMonitoredRepositories(ctx)
 	if err != nil {
 		log.Printf("Error fetching monitored repositories: %v", err)
 		return
 	}
 
+	if err := w.syncRound(ctx, repos); err != nil {
+		log.Printf("Scheduled sync finished with errors: %v", err)
+	}
+}
+
+// syncRound syncs the given repositories, up to w.concurrency at once. Each
+// sync gets its own context, bounded by the sync interval so a stuck
+// repository can't hold up the next round. The errors of the repositories
+// that failed are joined into the returned error.
+func (w *SyncWorker) syncRound(ctx context.Context, repos []models.MonitoredRepository) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed []error
+	)
+	slots := make(chan struct{}, w.concurrency)
+
 	for _, repo := range repos {
-		owner, name := splitRepoName(repo.FullName)
-		if owner == "" || name == "" {
-			log.Printf("Invalid repository name format: %s", repo.FullName)
-			continue
+		if ctx.Err() == nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			wg.Wait()
+			return errors.Join(append(failed, ctx.Err())...)
 		}
 
-		// Implement retry logic with exponential backoff
-		maxRetries := 3
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			err := w.service.SyncRepository(ctx, owner, name, repo.LastSyncTime)
-			if err == nil {
-				if updateErr := w.service.DB().UpdateMonitoredRepositorySync(ctx, repo.FullName, time.Now().UTC()); updateErr != nil {
-					log.Printf("Failed to update last sync time for %s: %v", repo.FullName, updateErr)
-				}
-				break
-			}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
 
-			if attempt == maxRetries {
-				log.Printf("Error syncing repository %s after %d attempts: %v", repo.FullName, maxRetries, err)
-				continue
+			repoCtx, cancel := context.WithTimeout(ctx, w.syncInterval)
+			defer cancel()
+			if err := w.syncRepo(repoCtx, repo); err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Errorf("%s: %w", repo.FullName, err))
+				mu.Unlock()
 			}
+		}()
+	}
 
-			// Exponential backoff
-			backoffDuration := time.Duration(attempt*attempt) * time.Second
-			log.Printf("Retry attempt %d for repository %s after %v: %v", attempt, repo.FullName, backoffDuration, err)
-			select {
-			case <-time.After(backoffDuration):
-				continue
-			case <-ctx.Done():
-				return
+	wg.Wait()
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d repositories failed to sync: %w", len(failed), len(repos), errors.Join(failed...))
+	}
+	return nil
+}
+
+// syncRepository syncs the commits of a monitored repository made since its
+// last sync, retrying with exponential backoff
+func (w *SyncWorker) syncRepository(ctx context.Context, repo models.MonitoredRepository) error {
+	owner, name := splitRepoName(repo.FullName)
+	if owner == "" || name == "" {
+		return fmt.Errorf("invalid repository name format")
+	}
+
+	maxRetries := 3
+	for attempt := 1; ; attempt++ {
+		err := w.service.SyncRepositoryIncremental(ctx, owner, name, repo.LastSyncTime)
+		if err == nil {
+			if updateErr := w.service.Monitor().UpdateMonitoredRepositorySync(ctx, repo.FullName, time.Now().UTC()); updateErr != nil {
+				log.Printf("Failed to update last sync time for %s: %v", repo.FullName, updateErr)
 			}
+			if w.service.IssuesEnabled() {
+				if _, issuesErr := w.service.SyncIssues(ctx, owner, name); issuesErr != nil {
+					log.Printf("Error syncing issues for %s: %v", repo.FullName, issuesErr)
+				}
+			}
+			return nil
+		}
+
+		// Another sync of the repository is already fetching its commits
+		if errors.Is(err, errors.ErrSyncInProgress) {
+			log.Printf("Skipping repository %s: %v", repo.FullName, err)
+			return nil
+		}
+
+		if attempt == maxRetries {
+			return fmt.Errorf("after %d attempts: %w", maxRetries, err)
+		}
+
+		// Exponential backoff
+		backoffDuration := time.Duration(attempt*attempt) * time.Second
+		log.Printf("Retry attempt %d for repository %s after %v: %v", attempt, repo.FullName, backoffDuration, err)
+		select {
+		case <-time.After(backoffDuration):
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
@@ -151,7 +340,7 @@ func splitRepoName(fullName string) (owner, name string) {
 
 // IsRepositoryMonitored checks if a repository is being monitored
 func (w *SyncWorker) IsRepositoryMonitored(ctx context.Context, fullName string) bool {
-	repos, err := w.service.DB().GetMonitoredRepositories(ctx)
+	repos, err := w.service.Monitor().GetMonitoredRepositories(ctx)
 	if err != nil {
 		log.Printf("Error checking monitored status: %v", err)
 		return false
@@ -167,18 +356,30 @@ func (w *SyncWorker) IsRepositoryMonitored(ctx context.Context, fullName string)
 // ResetRepository resets the sync time for a repository
 func (w *SyncWorker) ResetRepository(ctx context.Context, owner, name string, since time.Time) error {
 	fullName := owner + "/" + name
-	return w.service.DB().UpdateMonitoredRepositorySync(ctx, fullName, since)
+	return w.service.Monitor().UpdateMonitoredRepositorySync(ctx, fullName, since)
 }
 
 // RemoveRepository removes a repository from monitoring
 func (w *SyncWorker) RemoveRepository(ctx context.Context, owner, name string) error {
 	fullName := owner + "/" + name
-	return w.service.DB().RemoveMonitoredRepository(ctx, fullName)
+	return w.service.Monitor().RemoveMonitoredRepository(ctx, fullName)
+}
+
+// PauseRepository stops syncing a repository without deleting its stored data
+func (w *SyncWorker) PauseRepository(ctx context.Context, owner, name string) error {
+	fullName := owner + "/" + name
+	return w.service.Monitor().SetMonitoredRepositoryActive(ctx, fullName, false)
+}
+
+// ResumeRepository resumes syncing a paused repository from its last sync
+func (w *SyncWorker) ResumeRepository(ctx context.Context, owner, name string) error {
+	fullName := owner + "/" + name
+	return w.service.Monitor().SetMonitoredRepositoryActive(ctx, fullName, true)
 }
 
 // ListRepositories returns all monitored repositories
 func (w *SyncWorker) ListRepositories(ctx context.Context) ([]string, error) {
-	repos, err := w.service.DB().GetMonitoredRepositories(ctx)
+	repos, err := w.service.Monitor().GetMonitoredRepositories(ctx)
 	if err != nil {
 		return nil, err
 	}
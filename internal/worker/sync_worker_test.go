@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github-service/internal/models"
+)
+
+// newTestSyncWorker returns a sync worker that counts its rounds of syncs
+func newTestSyncWorker(rounds *atomic.Int32) *SyncWorker {
+	w := NewSyncWorker(nil, time.Hour)
+	w.sync = func(ctx context.Context) {
+		rounds.Add(1)
+	}
+	return w
+}
+
+func waitForRounds(t *testing.T, rounds *atomic.Int32, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for rounds.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d rounds of syncs, got %d", want, rounds.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSyncWorkerLifecycle(t *testing.T) {
+	t.Run("stop is idempotent and the worker restarts", func(t *testing.T) {
+		var rounds atomic.Int32
+		w := newTestSyncWorker(&rounds)
+
+		w.Stop()
+		if err := w.Start(context.Background()); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		if err := w.Start(context.Background()); err == nil {
+			t.Error("expected starting a running worker to fail")
+		}
+		waitForRounds(t, &rounds, 1)
+
+		w.Stop()
+		w.Stop()
+		if state := w.State(); state != SyncWorkerStopped {
+			t.Errorf("expected state %q, got %q", SyncWorkerStopped, state)
+		}
+
+		if err := w.Start(context.Background()); err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+		waitForRounds(t, &rounds, 2)
+		w.Stop()
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		var rounds atomic.Int32
+		w := newTestSyncWorker(&rounds)
+
+		if err := w.Pause(); err == nil {
+			t.Error("expected pausing a stopped worker to fail")
+		}
+		if err := w.Start(context.Background()); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		waitForRounds(t, &rounds, 1)
+
+		if err := w.Pause(); err != nil {
+			t.Fatalf("Pause: %v", err)
+		}
+		if state := w.State(); state != SyncWorkerPaused {
+			t.Errorf("expected state %q, got %q", SyncWorkerPaused, state)
+		}
+		if err := w.Start(context.Background()); err == nil {
+			t.Error("expected starting a paused worker to fail")
+		}
+
+		if err := w.Resume(); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+		waitForRounds(t, &rounds, 2)
+		if err := w.Resume(); err == nil {
+			t.Error("expected resuming a running worker to fail")
+		}
+		w.Stop()
+	})
+
+	t.Run("cancelled context stops the worker", func(t *testing.T) {
+		var rounds atomic.Int32
+		w := newTestSyncWorker(&rounds)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := w.Start(ctx); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		cancel()
+		if state := w.State(); state != SyncWorkerStopped {
+			t.Errorf("expected state %q, got %q", SyncWorkerStopped, state)
+		}
+		if err := w.Start(context.Background()); err != nil {
+			t.Fatalf("restart: %v", err)
+		}
+		w.Stop()
+	})
+}
+
+func TestSyncRound(t *testing.T) {
+	repos := make([]models.MonitoredRepository, 10)
+	for i := range repos {
+		repos[i].FullName = fmt.Sprintf("octo/repo-%d", i)
+	}
+
+	w := NewSyncWorker(nil, time.Hour)
+	w.SetConcurrency(3)
+
+	var running, peak atomic.Int32
+	w.syncRepo = func(ctx context.Context, repo models.MonitoredRepository) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("expected the sync of %s to have a deadline", repo.FullName)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if strings.HasSuffix(repo.FullName, "-3") || strings.HasSuffix(repo.FullName, "-7") {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	err := w.syncRound(context.Background(), repos)
+	if err == nil {
+		t.Fatal("expected the failed repositories to be reported")
+	}
+	for _, want := range []string{"2 of 10 repositories failed", "octo/repo-3: boom", "octo/repo-7: boom"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("expected up to 3 concurrent syncs, got %d", p)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.SetConcurrency(1)
+	if err := w.syncRound(ctx, repos); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled round to report the cancellation, got %v", err)
+	}
+}
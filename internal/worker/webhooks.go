@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github-service/internal/service"
+
+	"github.com/rs/zerolog"
+)
+
+// WebhookDispatcher periodically sends the webhook deliveries that are due,
+// including the retries of earlier failed attempts
+type WebhookDispatcher struct {
+	service  *service.Service
+	interval time.Duration
+	log      zerolog.Logger
+}
+
+// NewWebhookDispatcher creates a dispatcher looking for due deliveries every interval
+func NewWebhookDispatcher(svc *service.Service, interval time.Duration, log zerolog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{service: svc, interval: interval, log: log}
+}
+
+// Start sends due deliveries on the interval until ctx is cancelled
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.dispatch(ctx)
+		}
+	}
+}
+
+// dispatch sends deliveries until none are due. Each is either delivered or
+// rescheduled for later, so this ends once the backlog is drained.
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := d.service.DeliverWebhooks(ctx)
+		if err != nil {
+			d.log.Error().Err(err).Msg("Failed to deliver webhooks")
+			return
+		}
+		if sent == 0 {
+			return
+		}
+	}
+}